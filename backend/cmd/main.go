@@ -55,7 +55,7 @@ func main() {
 			"Access-Control-Allow-Origin",
 			"Access-Control-Allow-Credentials",
 		},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Authorization", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
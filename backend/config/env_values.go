@@ -23,13 +23,51 @@ type Environment struct {
 	ExampleDatabaseUsername string
 	ExampleDatabasePassword string
 	// Auth configs
-	SchemaEncryptionKey              string
-	JWTSecret                        string
-	JWTExpirationMilliseconds        int
-	JWTRefreshExpirationMilliseconds int
-	AdminUser                        string
-	AdminPassword                    string
-	DefaultLLMClient                 string
+	SchemaEncryptionKey                 string
+	JWTSecret                           string
+	JWTExpirationMilliseconds           int
+	JWTRefreshExpirationMilliseconds    int
+	AdminUser                           string
+	APIKeyRateLimitPerMinute            int // Requests each API key can make per minute
+	AdminPassword                       string
+	DefaultLLMClient                    string
+	NudgeOnEmptyQueries                 bool   // Re-prompt the LLM once when a data request produced no queries
+	AutoRefreshStaleSchema              bool   // Refresh the schema & generate again once when the LLM asks for a schema refresh
+	MaxConcurrentLLMCalls               int    // LLM calls running at the same time across users, the others are queued, 0 doesn't limit them
+	LLMRateLimitPerMinute               int    // LLM generations each user can start per minute, 0 doesn't limit them, see models.User.LLMRateLimitPerMinute
+	LLMRateLimitBurst                   int    // LLM generations a user can start in a row before being limited to LLMRateLimitPerMinute
+	SharedResultMaxRows                 int    // Rows of a query result shared with the LLM when ShareDataWithAI is on, 0 shares every row
+	SharedResultColumnSummaries         bool   // Add a summary of each column to shared results whose rows were cut
+	ExportSignedURLExpiryMinutes        int    // Validity of the signed URLs returned by cloud exports
+	EmptyResultDiagnostics              string // "off", "relaxed" (count with each filter removed) or "llm" (relaxed + LLM suggestion)
+	EmptyResultDiagnosticsMaxProbes     int    // Max number of relaxed count queries run for an empty result
+	CriticalQueryConfirmationTTLMinutes int    // Critical queries older than this must be regenerated before execution, 0 disables the check
+	StaleSchemaRetryMinutes             int    // A query failing on a missing table or column refreshes a schema older than this & runs again, 0 disables the retry
+	MaxQueryResultRows                  int    // Rows of a query result read from the database, the rest is never loaded in memory
+	MaxQueryResultBytes                 int    // Size of a query result read from the database, larger results fail with RESULT_TOO_LARGE
+	MaxResultCellBytes                  int    // Size of a value of a stored query result, larger values are cut
+	PaginationOrderByPrimaryKey         bool   // Order paginated SELECTs without an ORDER BY on the primary key so that pages are stable
+	MaxListPageSize                     int    // Largest page of chats or messages returned by the list endpoints
+	LLMContextMaxMessages               int    // Latest messages of a chat sent to the LLM with its system messages, 0 sends the whole chat
+	FullTableReadRowThreshold           int    // Rows above which a SELECT reading a whole table is limited unless confirmed, 0 disables the check
+	AffectedRowsConfirmationThreshold   int    // Rows above which an UPDATE or DELETE is only executed once confirmed, 0 disables the confirmation
+	MaxGeneratedQueryLength             int    // Characters above which a query generated by the LLM is dropped, 0 disables the check
+	SchemaFullDetailTables              int    // Most queried tables keeping their example records in larger schemas, 0 keeps them for every table
+	AutoExecuteConnectionAffinity       bool   // Auto executed queries of a response share a connection checked once, instead of each checking it
+	LLMResponseCacheTTLMinutes          int    // Validity of the LLM responses cached for an identical history, schema & database type, 0 disables the cache
+	QueryResultCacheTTLSeconds          int    // Validity of the read query results cached for an identical query of the chat, 0 disables the cache
+	AuditLogBufferSize                  int    // Audit log entries waiting to be written before new entries wait in the background
+	SchemaChunking                      bool   // Split schemas too large for a prompt over LLM calls scoped to a part of the tables, then generate from the needed ones
+	SchemaChunkMaxChars                 int    // Size of the schema above which it is split & of each part
+	SchemaChunkMaxCalls                 int    // Parts a schema can be split into, a larger schema is sent whole
+	PIIMaskExampleRecords               bool   // Mask the personal data of the example records shared with the LLM (emails, phone numbers, PII columns)
+	PIIColumnPatterns                   string // Comma separated regular expressions of the column names whose example values are always masked
+	SQLiteDataDir                       string // Directory of the SQLite database files chats can open (local development), empty disables SQLite
+	TenantsFile                         string // JSON file of the tenants sharing the deployment with their LLM API keys & quotas, empty runs a single tenant
+	ScheduledQueriesEnabled             bool   // Run the scheduled queries of the chats, keep it on a single instance of a deployment
+
+	// Tenant configs, read from TenantsFile & keyed by tenant ID
+	Tenants map[string]Tenant
 
 	// Database configs
 	MongoURI          string
@@ -52,6 +90,12 @@ type Environment struct {
 	GeminiModel               string
 	GeminiMaxCompletionTokens int
 	GeminiTemperature         float64
+
+	// Anthropic configs
+	AnthropicAPIKey              string
+	AnthropicModel               string
+	AnthropicMaxCompletionTokens int
+	AnthropicTemperature         float64
 }
 
 var Env Environment
@@ -79,6 +123,7 @@ func LoadEnv() error {
 	Env.JWTSecret = getRequiredEnv("JWT_SECRET", "databot_jwt_secret")
 	Env.JWTExpirationMilliseconds = getIntEnvWithDefault("JWT_EXPIRATION_MILLISECONDS", 1000*60*60*24*10)                 // 10 days default
 	Env.JWTRefreshExpirationMilliseconds = getIntEnvWithDefault("_JWT_REFRESH_EXPIRATION_MILLISECONDS", 1000*60*60*24*30) // 30 days default
+	Env.APIKeyRateLimitPerMinute = getIntEnvWithDefault("API_KEY_RATE_LIMIT_PER_MINUTE", constants.DefaultAPIKeyRateLimitPerMinute)
 	Env.AdminUser = getEnvWithDefault("DATABOT_ADMIN_USERNAME", "bhaskar")
 	Env.AdminPassword = getEnvWithDefault("DATABOT_ADMIN_PASSWORD", "bhaskar")
 
@@ -99,7 +144,47 @@ func LoadEnv() error {
 	Env.ExampleDatabasePassword = getRequiredEnv("EXAMPLE_DB_PASSWORD", "")
 
 	// LLM configs
-	Env.DefaultLLMClient = getEnvWithDefault("DEFAULT_LLM_CLIENT", constants.OpenAI)
+	// LLM_PROVIDER takes precedence over DEFAULT_LLM_CLIENT, OpenAI is used when neither is set
+	Env.DefaultLLMClient = getEnvWithDefault("LLM_PROVIDER", getEnvWithDefault("DEFAULT_LLM_CLIENT", constants.OpenAI))
+	Env.NudgeOnEmptyQueries = getBoolEnvWithDefault("LLM_NUDGE_ON_EMPTY_QUERIES", false)
+	Env.AutoRefreshStaleSchema = getBoolEnvWithDefault("LLM_AUTO_REFRESH_STALE_SCHEMA", false)
+	Env.MaxConcurrentLLMCalls = getIntEnvWithDefault("MAX_CONCURRENT_LLM_CALLS", constants.DefaultMaxConcurrentLLMCalls)
+	Env.LLMRateLimitPerMinute = getIntEnvWithDefault("LLM_RATE_LIMIT_PER_MINUTE", constants.DefaultLLMRateLimitPerMinute)
+	Env.LLMRateLimitBurst = getIntEnvWithDefault("LLM_RATE_LIMIT_BURST", constants.DefaultLLMRateLimitBurst)
+	Env.SharedResultMaxRows = getIntEnvWithDefault("SHARED_RESULT_MAX_ROWS", constants.DefaultSharedResultMaxRows)
+	Env.SharedResultColumnSummaries = getBoolEnvWithDefault("SHARED_RESULT_COLUMN_SUMMARIES", true)
+	Env.ExportSignedURLExpiryMinutes = getIntEnvWithDefault("EXPORT_SIGNED_URL_EXPIRY_MINUTES", 60)
+	Env.EmptyResultDiagnostics = getEnvWithDefault("EMPTY_RESULT_DIAGNOSTICS", "off") // Off by default, probes add load on the database
+	Env.EmptyResultDiagnosticsMaxProbes = getIntEnvWithDefault("EMPTY_RESULT_DIAGNOSTICS_MAX_PROBES", 3)
+	Env.CriticalQueryConfirmationTTLMinutes = getIntEnvWithDefault("CRITICAL_QUERY_CONFIRMATION_TTL_MINUTES", 30)
+	Env.StaleSchemaRetryMinutes = getIntEnvWithDefault("STALE_SCHEMA_RETRY_MINUTES", 10)
+	Env.MaxQueryResultRows = getIntEnvWithDefault("MAX_QUERY_RESULT_ROWS", constants.DefaultMaxQueryResultRows)
+	Env.MaxQueryResultBytes = getIntEnvWithDefault("MAX_QUERY_RESULT_BYTES", constants.DefaultMaxQueryResultBytes)
+	Env.MaxResultCellBytes = getIntEnvWithDefault("MAX_RESULT_CELL_BYTES", constants.DefaultMaxResultCellBytes)
+	Env.PaginationOrderByPrimaryKey = getBoolEnvWithDefault("PAGINATION_ORDER_BY_PRIMARY_KEY", true)
+	Env.MaxListPageSize = getIntEnvWithDefault("MAX_LIST_PAGE_SIZE", constants.DefaultMaxListPageSize)
+	Env.LLMContextMaxMessages = getIntEnvWithDefault("LLM_CONTEXT_MAX_MESSAGES", 0)
+	Env.FullTableReadRowThreshold = getIntEnvWithDefault("FULL_TABLE_READ_ROW_THRESHOLD", constants.DefaultFullTableReadRowThreshold)
+	Env.AffectedRowsConfirmationThreshold = getIntEnvWithDefault("AFFECTED_ROWS_CONFIRMATION_THRESHOLD", constants.DefaultAffectedRowsConfirmationThreshold)
+	Env.MaxGeneratedQueryLength = getIntEnvWithDefault("MAX_GENERATED_QUERY_LENGTH", constants.DefaultMaxGeneratedQueryLength)
+	Env.SchemaFullDetailTables = getIntEnvWithDefault("SCHEMA_FULL_DETAIL_TABLES", constants.DefaultSchemaFullDetailTables)
+	Env.AutoExecuteConnectionAffinity = getBoolEnvWithDefault("AUTO_EXECUTE_CONNECTION_AFFINITY", true)
+	Env.LLMResponseCacheTTLMinutes = getIntEnvWithDefault("LLM_RESPONSE_CACHE_TTL_MINUTES", 60)
+	Env.QueryResultCacheTTLSeconds = getIntEnvWithDefault("QUERY_RESULT_CACHE_TTL_SECONDS", constants.DefaultQueryResultCacheTTLSeconds)
+	Env.AuditLogBufferSize = getIntEnvWithDefault("AUDIT_LOG_BUFFER_SIZE", constants.DefaultAuditLogBufferSize)
+	Env.SchemaChunking = getBoolEnvWithDefault("SCHEMA_CHUNKING", false)
+	Env.SchemaChunkMaxChars = getIntEnvWithDefault("SCHEMA_CHUNK_MAX_CHARS", constants.DefaultSchemaChunkMaxChars)
+	Env.SchemaChunkMaxCalls = getIntEnvWithDefault("SCHEMA_CHUNK_MAX_CALLS", constants.DefaultSchemaChunkMaxCalls)
+	Env.PIIMaskExampleRecords = getBoolEnvWithDefault("PII_MASK_EXAMPLE_RECORDS", true)
+	Env.PIIColumnPatterns = getEnvWithDefault("PII_COLUMN_PATTERNS", constants.DefaultPIIColumnPatterns)
+	Env.SQLiteDataDir = getEnvWithDefault("SQLITE_DATA_DIR", "")
+	Env.TenantsFile = getEnvWithDefault("TENANTS_FILE", "")
+	Env.ScheduledQueriesEnabled = getBoolEnvWithDefault("SCHEDULED_QUERIES_ENABLED", true)
+	tenants, err := loadTenants(Env.TenantsFile)
+	if err != nil {
+		return err
+	}
+	Env.Tenants = tenants
 
 	// OpenAI configs
 	Env.OpenAIAPIKey = getRequiredEnv("OPENAI_API_KEY", "")
@@ -113,6 +198,12 @@ func LoadEnv() error {
 	Env.GeminiMaxCompletionTokens = getIntEnvWithDefault("GEMINI_MAX_COMPLETION_TOKENS", constants.GeminiMaxCompletionTokens)
 	Env.GeminiTemperature = getFloatEnvWithDefault("GEMINI_TEMPERATURE", constants.GeminiTemperature)
 
+	// Anthropic configs
+	Env.AnthropicAPIKey = getRequiredEnv("ANTHROPIC_API_KEY", "")
+	Env.AnthropicModel = getEnvWithDefault("ANTHROPIC_MODEL", constants.AnthropicModel)
+	Env.AnthropicMaxCompletionTokens = getIntEnvWithDefault("ANTHROPIC_MAX_COMPLETION_TOKENS", constants.AnthropicMaxCompletionTokens)
+	Env.AnthropicTemperature = getFloatEnvWithDefault("ANTHROPIC_TEMPERATURE", constants.AnthropicTemperature)
+
 	return validateConfig()
 }
 
@@ -145,6 +236,20 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 	return value
 }
 
+func getBoolEnvWithDefault(key string, defaultValue bool) bool {
+	strValue := os.Getenv(key)
+	if strValue == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(strValue)
+	if err != nil {
+		fmt.Printf("Warning: Invalid value for %s, using default: %t\n", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 func getFloatEnvWithDefault(key string, defaultValue float64) float64 {
 	strValue := os.Getenv(key)
 	if strValue == "" {
@@ -164,6 +269,98 @@ func validateConfig() error {
 		return fmt.Errorf("JWT_EXPIRATION_MILLISECONDS must be positive, got: %d", Env.JWTExpirationMilliseconds)
 	}
 
+	// Signed URLs of cloud exports can't outlive 7 days
+	if Env.ExportSignedURLExpiryMinutes < 1 || Env.ExportSignedURLExpiryMinutes > 7*24*60 {
+		return fmt.Errorf("EXPORT_SIGNED_URL_EXPIRY_MINUTES must be between 1 and %d, got: %d", 7*24*60, Env.ExportSignedURLExpiryMinutes)
+	}
+
+	// Results are capped at 50 records after execution, reading less would hide rows of the capped result
+	if Env.MaxQueryResultRows < 50 {
+		return fmt.Errorf("MAX_QUERY_RESULT_ROWS must be at least 50, got: %d", Env.MaxQueryResultRows)
+	}
+
+	// 0 disables the limits
+	if Env.MaxQueryResultBytes < 0 {
+		return fmt.Errorf("MAX_QUERY_RESULT_BYTES can't be negative, got: %d", Env.MaxQueryResultBytes)
+	}
+	if Env.MaxResultCellBytes < 0 {
+		return fmt.Errorf("MAX_RESULT_CELL_BYTES can't be negative, got: %d", Env.MaxResultCellBytes)
+	}
+
+	if Env.MaxListPageSize < 1 {
+		return fmt.Errorf("MAX_LIST_PAGE_SIZE must be positive, got: %d", Env.MaxListPageSize)
+	}
+
+	if Env.LLMContextMaxMessages < 0 {
+		return fmt.Errorf("LLM_CONTEXT_MAX_MESSAGES must not be negative, got: %d", Env.LLMContextMaxMessages)
+	}
+
+	if Env.LLMResponseCacheTTLMinutes < 0 {
+		return fmt.Errorf("LLM_RESPONSE_CACHE_TTL_MINUTES must not be negative, got: %d", Env.LLMResponseCacheTTLMinutes)
+	}
+
+	if Env.QueryResultCacheTTLSeconds < 0 {
+		return fmt.Errorf("QUERY_RESULT_CACHE_TTL_SECONDS must not be negative, got: %d", Env.QueryResultCacheTTLSeconds)
+	}
+
+	if Env.AuditLogBufferSize < 1 {
+		return fmt.Errorf("AUDIT_LOG_BUFFER_SIZE must be positive, got: %d", Env.AuditLogBufferSize)
+	}
+
+	if Env.SchemaChunking && Env.SchemaChunkMaxChars < 1 {
+		return fmt.Errorf("SCHEMA_CHUNK_MAX_CHARS must be positive, got: %d", Env.SchemaChunkMaxChars)
+	}
+
+	if Env.SchemaChunking && Env.SchemaChunkMaxCalls < 2 {
+		return fmt.Errorf("SCHEMA_CHUNK_MAX_CALLS must be at least 2, got: %d", Env.SchemaChunkMaxCalls)
+	}
+
+	if Env.FullTableReadRowThreshold < 0 {
+		return fmt.Errorf("FULL_TABLE_READ_ROW_THRESHOLD must not be negative, got: %d", Env.FullTableReadRowThreshold)
+	}
+
+	if Env.AffectedRowsConfirmationThreshold < 0 {
+		return fmt.Errorf("AFFECTED_ROWS_CONFIRMATION_THRESHOLD must not be negative, got: %d", Env.AffectedRowsConfirmationThreshold)
+	}
+
+	if Env.MaxGeneratedQueryLength < 0 {
+		return fmt.Errorf("MAX_GENERATED_QUERY_LENGTH must not be negative, got: %d", Env.MaxGeneratedQueryLength)
+	}
+
+	switch Env.DefaultLLMClient {
+	case constants.OpenAI, constants.Gemini, constants.Anthropic:
+	default:
+		return fmt.Errorf("LLM_PROVIDER must be one of %s, %s or %s, got: %s", constants.OpenAI, constants.Gemini, constants.Anthropic, Env.DefaultLLMClient)
+	}
+
+	if Env.AnthropicTemperature < 0 || Env.AnthropicTemperature > 1 {
+		return fmt.Errorf("ANTHROPIC_TEMPERATURE must be between 0 & 1, got: %v", Env.AnthropicTemperature)
+	}
+
+	if Env.SchemaFullDetailTables < 0 {
+		return fmt.Errorf("SCHEMA_FULL_DETAIL_TABLES must not be negative, got: %d", Env.SchemaFullDetailTables)
+	}
+
+	if Env.SharedResultMaxRows < 0 {
+		return fmt.Errorf("SHARED_RESULT_MAX_ROWS must not be negative, got: %d", Env.SharedResultMaxRows)
+	}
+
+	if Env.MaxConcurrentLLMCalls < 0 {
+		return fmt.Errorf("MAX_CONCURRENT_LLM_CALLS must not be negative, got: %d", Env.MaxConcurrentLLMCalls)
+	}
+
+	if Env.LLMRateLimitPerMinute < 0 {
+		return fmt.Errorf("LLM_RATE_LIMIT_PER_MINUTE must not be negative, got: %d", Env.LLMRateLimitPerMinute)
+	}
+
+	if Env.LLMRateLimitBurst < 1 {
+		return fmt.Errorf("LLM_RATE_LIMIT_BURST must be positive, got: %d", Env.LLMRateLimitBurst)
+	}
+
+	if Env.APIKeyRateLimitPerMinute < 1 {
+		return fmt.Errorf("API_KEY_RATE_LIMIT_PER_MINUTE must be positive, got: %d", Env.APIKeyRateLimitPerMinute)
+	}
+
 	if Env.AdminUser == "databot-admin" || Env.AdminPassword == "databot-password" {
 		return fmt.Errorf("default credentials: databot-admin and databot-password should not be used")
 	}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"databot-ai/internal/constants"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Tenant is an organization sharing the deployment, its users call the LLM with its own API keys & within its quotas
+type Tenant struct {
+	LLMAPIKeys            map[string]string `json:"llm_api_keys"`             // API key of each LLM provider (ex: "openai"), the others use the key of the deployment
+	MaxConcurrentLLMCalls int               `json:"max_concurrent_llm_calls"` // LLM calls of the tenant running at the same time, 0 only applies MAX_CONCURRENT_LLM_CALLS
+	DailyLLMCalls         int               `json:"daily_llm_calls"`          // 0 doesn't limit them
+	DailyQueryExecutions  int               `json:"daily_query_executions"`   // 0 doesn't limit them
+	MaxConnections        int               `json:"max_connections"`          // Chats of the tenant connected to their database at the same time, 0 doesn't limit them
+}
+
+// loadTenants reads the tenants of a JSON file keyed by tenant ID, ex: {"acme": {"llm_api_keys": {"openai": "sk-..."},
+// "daily_llm_calls": 1000}}. No file runs a single tenant deployment.
+func loadTenants(path string) (map[string]Tenant, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TENANTS_FILE: %v", err)
+	}
+	var tenants map[string]Tenant
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return nil, fmt.Errorf("invalid TENANTS_FILE: %v", err)
+	}
+
+	for id, tenant := range tenants {
+		if id == "" {
+			return nil, fmt.Errorf("invalid TENANTS_FILE: a tenant has an empty ID")
+		}
+		for provider := range tenant.LLMAPIKeys {
+			switch provider {
+			case constants.OpenAI, constants.Gemini, constants.Anthropic:
+			default:
+				return nil, fmt.Errorf("invalid TENANTS_FILE: tenant %s has an API key for the unknown LLM provider %s", id, provider)
+			}
+		}
+		if tenant.MaxConcurrentLLMCalls < 0 || tenant.DailyLLMCalls < 0 || tenant.DailyQueryExecutions < 0 || tenant.MaxConnections < 0 {
+			return nil, fmt.Errorf("invalid TENANTS_FILE: the limits of tenant %s must not be negative", id)
+		}
+	}
+	return tenants, nil
+}
@@ -0,0 +1,34 @@
+package dtos
+
+import "time"
+
+// AuditLogRequest filters the audit log, the zero values don't filter
+type AuditLogRequest struct {
+	UserID string     `form:"user_id"`
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int        `form:"limit"`
+	Offset int        `form:"offset"`
+}
+
+// AuditLogItem is a schema refresh, LLM generation, query execution or rollback of a chat
+type AuditLogItem struct {
+	ID        string                 `json:"id"`
+	UserID    string                 `json:"user_id"`
+	ChatID    string                 `json:"chat_id"`
+	Action    string                 `json:"action"`
+	SourceIP  string                 `json:"source_ip"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Timestamp string                 `json:"timestamp"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash"`
+	IsIntact  bool                   `json:"is_intact"` // The hash of the entry matches its content
+}
+
+type AuditLogResponse struct {
+	Entries []AuditLogItem `json:"entries"`
+	Total   int64          `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	HasMore bool           `json:"has_more"` // Older entries are left for the next pages
+}
@@ -1,21 +1,49 @@
 package dtos
 
 type CreateChatSettings struct {
-	AutoExecuteQuery *bool `json:"auto_execute_query"`
-	ShareDataWithAI  *bool `json:"share_data_with_ai"`
+	AutoExecuteQuery *bool             `json:"auto_execute_query"`
+	ShareDataWithAI  *bool             `json:"share_data_with_ai"`
+	ColumnMasks      map[string]string `json:"column_masks"` // nil keeps the current masks, empty map clears them
+	SessionMode      *bool             `json:"session_mode"`
+	AnonymizeSchema  *bool             `json:"anonymize_schema"`
+	GenerateOnly     *bool             `json:"generate_only"`
+
+	DisableExampleRecords *bool   `json:"disable_example_records"`
+	TextCollation         *string `json:"text_collation"` // Empty string clears it
+	ReadOnly              *bool   `json:"read_only"`
+	DisableResponseCache  *bool   `json:"disable_response_cache"`
+	DefaultPageSize       *int    `json:"default_page_size"`     // 0 resets it to the default page size
+	QueryTimeoutSeconds   *int    `json:"query_timeout_seconds"` // 0 sizes the timeout on the response time estimated by the LLM
+
+	SchemaRefreshWebhookURL *string `json:"schema_refresh_webhook_url"` // Empty string clears it
 }
 
 type ChatSettingsResponse struct {
-	AutoExecuteQuery bool `json:"auto_execute_query"`
-	ShareDataWithAI  bool `json:"share_data_with_ai"`
+	AutoExecuteQuery bool              `json:"auto_execute_query"`
+	ShareDataWithAI  bool              `json:"share_data_with_ai"`
+	ColumnMasks      map[string]string `json:"column_masks,omitempty"`
+	SessionMode      bool              `json:"session_mode"`
+	AnonymizeSchema  bool              `json:"anonymize_schema"`
+	GenerateOnly     bool              `json:"generate_only"`
+
+	DisableExampleRecords bool   `json:"disable_example_records"`
+	TextCollation         string `json:"text_collation,omitempty"`
+	ReadOnly              bool   `json:"read_only"`
+	DisableResponseCache  bool   `json:"disable_response_cache"`
+	DefaultPageSize       int    `json:"default_page_size"`
+	QueryTimeoutSeconds   int    `json:"query_timeout_seconds,omitempty"`
+
+	SchemaRefreshWebhookURL string `json:"schema_refresh_webhook_url,omitempty"`
 }
 type CreateConnectionRequest struct {
-	Type     string  `json:"type" binding:"required,oneof=postgresql yugabytedb mysql clickhouse mongodb redis neo4j cassandra"`
-	Host     string  `json:"host" binding:"required"`
-	Port     *string `json:"port"`
-	Username string  `json:"username" binding:"required"`
-	Password *string `json:"password"`
-	Database string  `json:"database" binding:"required"`
+	Type     string   `json:"type" binding:"required,oneof=postgresql yugabytedb mysql mariadb clickhouse mongodb redis neo4j cassandra sqlite bigquery elasticsearch"`
+	Host     string   `json:"host"`   // Host, username & database are required unless the chat is generate only
+	Hosts    []string `json:"hosts"`  // Failover hosts of a cluster, tried in order after Host, ex: "db-2" or "db-2:5433"
+	Shards   []string `json:"shards"` // Other shards holding the same tables, queried with the same credentials, ex: "shard-2" or "shard-2:5433/orders_2"
+	Port     *string  `json:"port"`
+	Username string   `json:"username"`
+	Password *string  `json:"password"`
+	Database string   `json:"database"`
 
 	// SSL/TLS Configuration
 	UseSSL         bool    `json:"use_ssl"`
@@ -23,16 +51,40 @@ type CreateConnectionRequest struct {
 	SSLCertURL     *string `json:"ssl_cert_url,omitempty"`
 	SSLKeyURL      *string `json:"ssl_key_url,omitempty"`
 	SSLRootCertURL *string `json:"ssl_root_cert_url,omitempty"`
+
+	MaxResultRows int `json:"max_result_rows" binding:"min=0"` // Rows the database returns for a read query at most, 0 is unlimited
+
+	Role string `json:"role,omitempty"` // PostgreSQL/YugabyteDB role the queries run as, the user must be a member of it
+
+	FilePath string `json:"file_path,omitempty"` // SQLite database file relative to SQLITE_DATA_DIR, replaces host & port
+
+	// BigQuery project & service account key (JSON), replace host, username & password, the database is the dataset
+	ProjectID       string `json:"project_id,omitempty"`
+	CredentialsJSON string `json:"credentials_json,omitempty"`
+
+	// Connection pool, 0 uses the default (10 open, 5 idle, 30 minutes lifetime)
+	MaxOpenConns    int `json:"max_open_conns" binding:"min=0"`
+	MaxIdleConns    int `json:"max_idle_conns" binding:"min=0"`
+	ConnMaxLifetime int `json:"conn_max_lifetime" binding:"min=0"` // in seconds
+
+	// SSH bastion the database is reached through, the host & port of the database are resolved by the bastion
+	SSHHost       string `json:"ssh_host,omitempty"`
+	SSHPort       string `json:"ssh_port,omitempty"` // 22 if empty
+	SSHUser       string `json:"ssh_user,omitempty"`
+	SSHPrivateKey string `json:"ssh_private_key,omitempty"` // PEM private key, an update without one keeps the current key
+	SSHHostKey    string `json:"ssh_host_key,omitempty"`    // Public key of the bastion (authorized_keys format), required with an SSH host
 }
 
 type ConnectionResponse struct {
-	ID          string  `json:"id" binding:"required"`
-	Type        string  `json:"type" binding:"required"`
-	Host        string  `json:"host" binding:"required"`
-	Port        *string `json:"port"`
-	Username    string  `json:"username" binding:"required"`
-	Database    string  `json:"database" binding:"required"`
-	IsExampleDB bool    `json:"is_example_db"`
+	ID          string   `json:"id" binding:"required"`
+	Type        string   `json:"type" binding:"required"`
+	Host        string   `json:"host" binding:"required"`
+	Hosts       []string `json:"hosts,omitempty"`
+	Shards      []string `json:"shards,omitempty"`
+	Port        *string  `json:"port"`
+	Username    string   `json:"username" binding:"required"`
+	Database    string   `json:"database" binding:"required"`
+	IsExampleDB bool     `json:"is_example_db"`
 	// Password not exposed in response
 
 	// SSL/TLS Configuration
@@ -41,6 +93,30 @@ type ConnectionResponse struct {
 	SSLCertURL     *string `json:"ssl_cert_url,omitempty"`
 	SSLKeyURL      *string `json:"ssl_key_url,omitempty"`
 	SSLRootCertURL *string `json:"ssl_root_cert_url,omitempty"`
+
+	MaxResultRows   int `json:"max_result_rows,omitempty"`
+	MaxOpenConns    int `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime int `json:"conn_max_lifetime,omitempty"` // in seconds
+
+	Role      string `json:"role,omitempty"`
+	FilePath  string `json:"file_path,omitempty"`
+	ProjectID string `json:"project_id,omitempty"` // The BigQuery credentials are not exposed in response
+
+	SSHHost    string `json:"ssh_host,omitempty"`
+	SSHPort    string `json:"ssh_port,omitempty"`
+	SSHUser    string `json:"ssh_user,omitempty"`
+	SSHHostKey string `json:"ssh_host_key,omitempty"` // The SSH private key is not exposed in response
+
+	// TLS negotiated by the connection test of a create or update, not set otherwise
+	TLS *TLSInfo `json:"tls,omitempty"`
+}
+
+// NamedConnectionResponse is a named connection of a chat, queried next to its main connection
+type NamedConnectionResponse struct {
+	Name        string             `json:"name"`
+	Connection  ConnectionResponse `json:"connection"`
+	IsConnected bool               `json:"is_connected"`
 }
 
 type CreateChatRequest struct {
@@ -55,18 +131,31 @@ type UpdateChatRequest struct {
 }
 
 type ChatResponse struct {
-	ID                  string               `json:"id"`
-	UserID              string               `json:"user_id"`
-	Connection          ConnectionResponse   `json:"connection"`
-	SelectedCollections string               `json:"selected_collections"`
-	CreatedAt           string               `json:"created_at"`
-	UpdatedAt           string               `json:"updated_at"`
-	Settings            ChatSettingsResponse `json:"settings"`
+	ID                  string                     `json:"id"`
+	UserID              string                     `json:"user_id"`
+	Connection          ConnectionResponse         `json:"connection"`
+	Connections         []NamedConnectionResponse  `json:"connections,omitempty"`
+	SelectedCollections string                     `json:"selected_collections"`
+	CreatedAt           string                     `json:"created_at"`
+	UpdatedAt           string                     `json:"updated_at"`
+	Settings            ChatSettingsResponse       `json:"settings"`
+	ExportDestination   *ExportDestinationResponse `json:"export_destination,omitempty"`
+	QueryTemplates      []QueryTemplate            `json:"query_templates,omitempty"`
+	SchemaDescriptions  []SchemaDescription        `json:"schema_descriptions,omitempty"`
+	HasImportedSchema   bool                       `json:"has_imported_schema"`
+}
+
+// ImportSchemaRequest holds the schema a generate only chat generates queries from, ex: CREATE TABLE statements
+type ImportSchemaRequest struct {
+	Schema string `json:"schema" binding:"required"`
 }
 
 type ChatListResponse struct {
-	Chats []ChatResponse `json:"chats"`
-	Total int64          `json:"total"`
+	Chats    []ChatResponse `json:"chats"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	HasMore  bool           `json:"has_more"` // Older chats are left for the next pages
 }
 
 // TableInfo represents a table with its columns
@@ -87,3 +176,66 @@ type ColumnInfo struct {
 type TablesResponse struct {
 	Tables []TableInfo `json:"tables"`
 }
+
+// SchemaGraphNode represents a table in the schema graph
+type SchemaGraphNode struct {
+	ID         string             `json:"id"` // Table name
+	Columns    []SchemaGraphField `json:"columns"`
+	PrimaryKey []string           `json:"primary_key,omitempty"`
+	RowCount   int64              `json:"row_count"`
+	IsSelected bool               `json:"is_selected"`
+}
+
+// SchemaGraphField represents a column of a table node in the schema graph
+type SchemaGraphField struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	IsNullable   bool   `json:"is_nullable"`
+	IsPrimaryKey bool   `json:"is_primary_key"`
+	IsForeignKey bool   `json:"is_foreign_key"`
+}
+
+// SchemaGraphEdge represents a foreign key relationship between two tables
+type SchemaGraphEdge struct {
+	ID           string `json:"id"` // Foreign key name
+	Source       string `json:"source"`
+	SourceColumn string `json:"source_column"`
+	Target       string `json:"target"`
+	TargetColumn string `json:"target_column"`
+	Cardinality  string `json:"cardinality"` // one_to_one, one_to_many
+}
+
+// ColumnValuesResponse lists the distinct values of a column, to build filters
+type ColumnValuesResponse struct {
+	Table  string        `json:"table"`
+	Column string        `json:"column"`
+	Values []interface{} `json:"values"` // Sorted, null included
+}
+
+// SchemaDiagramResponse is the ER diagram of the stored schema, rendered by the frontend
+type SchemaDiagramResponse struct {
+	Format  string `json:"format"`  // "mermaid" or "dot"
+	Diagram string `json:"diagram"` // Mermaid erDiagram or Graphviz DOT text
+}
+
+// SchemaStatsResponse are the aggregates of the stored schema of a chat, for the dashboards
+type SchemaStatsResponse struct {
+	HasSchema         bool            `json:"has_schema"` // False until the schema is fetched, the aggregates are then zero
+	TableCount        int             `json:"table_count"`
+	TotalRowCount     int64           `json:"total_row_count"` // Estimated
+	RelationshipCount int             `json:"relationship_count"`
+	LastRefreshedAt   string          `json:"last_refreshed_at,omitempty"`
+	Tables            []TableRowCount `json:"tables"` // By row count, the largest first
+}
+
+// TableRowCount is the estimated row count of a table
+type TableRowCount struct {
+	Name     string `json:"name"`
+	RowCount int64  `json:"row_count"`
+}
+
+// SchemaGraphResponse represents the response for the schema graph API, used to render an ER diagram
+type SchemaGraphResponse struct {
+	Nodes []SchemaGraphNode `json:"nodes"`
+	Edges []SchemaGraphEdge `json:"edges"`
+}
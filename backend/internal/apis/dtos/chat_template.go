@@ -0,0 +1,51 @@
+package dtos
+
+// ChatTemplateConnection is the connection of a chat template, credentials are never part of a template
+type ChatTemplateConnection struct {
+	Type           string   `json:"type" binding:"required,oneof=postgresql yugabytedb mysql mariadb clickhouse mongodb redis neo4j cassandra sqlite bigquery elasticsearch"`
+	Host           string   `json:"host"`
+	Hosts          []string `json:"hosts,omitempty"`
+	Shards         []string `json:"shards,omitempty"`
+	Port           *string  `json:"port"`
+	Database       string   `json:"database"`
+	UseSSL         bool     `json:"use_ssl"`
+	SSLMode        *string  `json:"ssl_mode,omitempty"` // type: disable, require, verify-ca, verify-full
+	SSLRootCertURL *string  `json:"ssl_root_cert_url,omitempty"`
+	MaxResultRows  int      `json:"max_result_rows,omitempty" binding:"min=0"`
+	Role           string   `json:"role,omitempty"`
+	FilePath       string   `json:"file_path,omitempty"`
+	ProjectID      string   `json:"project_id,omitempty"`
+
+	MaxOpenConns    int `json:"max_open_conns,omitempty" binding:"min=0"`
+	MaxIdleConns    int `json:"max_idle_conns,omitempty" binding:"min=0"`
+	ConnMaxLifetime int `json:"conn_max_lifetime,omitempty" binding:"min=0"` // in seconds
+
+	SSHHost    string `json:"ssh_host,omitempty"`
+	SSHPort    string `json:"ssh_port,omitempty"`
+	SSHUser    string `json:"ssh_user,omitempty"`
+	SSHHostKey string `json:"ssh_host_key,omitempty"`
+}
+
+// ChatTemplate is the portable configuration of a chat, shared with a team to set up the same chat on each account
+type ChatTemplate struct {
+	Version             int                    `json:"version" binding:"required"` // See constants.ChatTemplateVersion
+	Connection          ChatTemplateConnection `json:"connection" binding:"required"`
+	SelectedCollections string                 `json:"selected_collections"` // "ALL" or comma-separated table names
+	Settings            ChatSettingsResponse   `json:"settings"`
+	QueryTemplates      []QueryTemplate        `json:"query_templates,omitempty" binding:"dive"`
+	SchemaDescriptions  []SchemaDescription    `json:"schema_descriptions,omitempty" binding:"dive"`
+	ImportedSchema      string                 `json:"imported_schema,omitempty"` // Generate only chats only
+}
+
+// ImportChatTemplateRequest creates a chat from a template with the credentials of the importing user
+type ImportChatTemplateRequest struct {
+	Template ChatTemplate `json:"template" binding:"required"`
+
+	// Credentials, required unless the template is generate only
+	Username   string  `json:"username"`
+	Password   *string `json:"password"`
+	SSLCertURL *string `json:"ssl_cert_url,omitempty"`
+	SSLKeyURL  *string `json:"ssl_key_url,omitempty"`
+
+	SSHPrivateKey string `json:"ssh_private_key,omitempty"` // Required when the template connects through an SSH bastion
+}
@@ -8,6 +8,42 @@ type ConnectionStatusResponse struct {
 	Database    string `json:"database"`
 	Username    string `json:"username"`
 	IsExampleDB bool   `json:"is_example_db"`
+
+	// Session mode, a dedicated connection is held while a session is active
+	SessionActive    bool    `json:"session_active"`
+	SessionStartedAt *string `json:"session_started_at,omitempty"`
+
+	// Version & capabilities of the server captured at connect time, nil if it couldn't be determined
+	Server *ServerInfo `json:"server,omitempty"`
+	// TLS negotiated with the server, nil if it couldn't be determined
+	TLS *TLSInfo `json:"tls,omitempty"`
+	// host:port the connection is connected to, one of the failover hosts when the first host is down or a replica
+	ConnectedHost string `json:"connected_host,omitempty"`
+}
+
+type ServerInfo struct {
+	Version      string          `json:"version"`
+	MajorVersion int             `json:"major_version"`
+	MinorVersion int             `json:"minor_version"`
+	PatchVersion int             `json:"patch_version"`
+	Flavor       string          `json:"flavor,omitempty"`
+	Capabilities []string        `json:"capabilities"`
+	Clusters     []ServerCluster `json:"clusters,omitempty"` // ClickHouse clusters the server is a member of
+}
+
+// TLSInfo is the TLS actually negotiated with the database, Used is nil if the driver doesn't tell
+type TLSInfo struct {
+	Requested bool   `json:"requested"`
+	SSLMode   string `json:"ssl_mode,omitempty"`
+	Used      *bool  `json:"used"`
+	Version   string `json:"version,omitempty"`
+	Cipher    string `json:"cipher,omitempty"`
+}
+
+type ServerCluster struct {
+	Name     string `json:"name"`
+	Shards   int    `json:"shards"`
+	Replicas int    `json:"replicas"`
 }
 
 type ConnectDBRequest struct {
@@ -17,3 +53,18 @@ type ConnectDBRequest struct {
 type DisconnectDBRequest struct {
 	StreamID string `json:"stream_id" binding:"required"`
 }
+
+type ServerActivity struct {
+	ID            string `json:"id"` // pid, thread ID, query ID or operation ID depending on the database type
+	User          string `json:"user"`
+	Database      string `json:"database"`
+	State         string `json:"state"`
+	Query         string `json:"query"`
+	DurationMs    int64  `json:"duration_ms"` // Since the start of the transaction if one is open, of the query otherwise
+	InTransaction bool   `json:"in_transaction"`
+}
+
+type ServerActivityResponse struct {
+	ChatID     string           `json:"chat_id"`
+	Activities []ServerActivity `json:"activities"`
+}
@@ -0,0 +1,47 @@
+package dtos
+
+type ExportDestinationRequest struct {
+	Provider        string `json:"provider" binding:"required,oneof=s3 gcs"`
+	Bucket          string `json:"bucket" binding:"required"`
+	Region          string `json:"region"`
+	Endpoint        string `json:"endpoint"` // Optional, for S3 compatible storages such as MinIO
+	Prefix          string `json:"prefix"`
+	AccessKeyID     string `json:"access_key_id" binding:"required"`
+	SecretAccessKey string `json:"secret_access_key" binding:"required"`
+}
+
+type ExportDestinationResponse struct {
+	Provider string `json:"provider"`
+	Bucket   string `json:"bucket"`
+	Region   string `json:"region,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	// Credentials not exposed in response
+}
+
+type CloudExportRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+	StreamID  string `json:"stream_id" binding:"required"`
+	Format    string `json:"format" binding:"omitempty,oneof=csv parquet"` // default is csv
+}
+
+// StreamExportRequest streams the results of a read query as newline delimited JSON, see ChatHandler.StreamQueryResults
+type StreamExportRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+	StreamID  string `json:"stream_id" binding:"required"`
+}
+
+type CloudExportResponse struct {
+	ChatID       string `json:"chat_id"`
+	MessageID    string `json:"message_id"`
+	QueryID      string `json:"query_id"`
+	Format       string `json:"format"`
+	Bucket       string `json:"bucket"`
+	ObjectKey    string `json:"object_key"`
+	SignedURL    string `json:"signed_url"`
+	ExpiresAt    string `json:"expires_at"`
+	RowsExported int    `json:"rows_exported"`
+	SizeBytes    int64  `json:"size_bytes"`
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type CreateMessageRequest struct {
-	StreamID string `json:"stream_id" binding:"required"`
-	Content  string `json:"content" binding:"required"`
+	StreamID  string `json:"stream_id" binding:"required"`
+	Content   string `json:"content" binding:"required"`
+	Migration bool   `json:"migration"` // Content describes the desired state of the schema, the AI responds with the migration reaching it
 }
 
 type MessageResponse struct {
@@ -19,39 +20,89 @@ type MessageResponse struct {
 	Content       string          `json:"content"`
 	Queries       *[]Query        `json:"queries,omitempty"`
 	ActionButtons *[]ActionButton `json:"action_buttons,omitempty"` // UI action buttons suggested by the LLM
-	IsEdited      bool            `json:"is_edited"`
-	CreatedAt     string          `json:"created_at"`
-	UpdatedAt     string          `json:"updated_at"`
+	// Values the user has to supply before the queries can run
+	ParameterRequests *[]ParameterRequest `json:"parameter_requests,omitempty"`
+	IsEdited          bool                `json:"is_edited"`
+	IsMigration       bool                `json:"is_migration,omitempty"`
+	CreatedAt         string              `json:"created_at"`
+	UpdatedAt         string              `json:"updated_at"`
+	// Set when the content is a clarification question, the client asks the user for more input instead of showing an answer
+	NeedsClarification bool   `json:"needs_clarification,omitempty"`
+	Confidence         string `json:"confidence,omitempty"` // high, medium or low
 }
 
 // ActionButton represents a UI action button that can be suggested by the LLM
 type ActionButton struct {
-	ID        string `json:"id"`
-	Label     string `json:"label"`     // Display text for the button
-	Action    string `json:"action"`    // Action identifier (e.g., "refresh_schema", "show_tables")
-	IsPrimary bool   `json:"isPrimary"` // Whether this is a primary (highlighted) action
+	ID        string                 `json:"id"`
+	Label     string                 `json:"label"`             // Display text for the button
+	Action    string                 `json:"action"`            // Action identifier (e.g., "refresh_schema", "show_tables")
+	IsPrimary bool                   `json:"isPrimary"`         // Whether this is a primary (highlighted) action
+	Payload   map[string]interface{} `json:"payload,omitempty"` // Context the action needs, sent back when the button is clicked
+}
+
+// ParameterRequest is a value asked to the user, submitted with SubmitQueryParametersRequest
+type ParameterRequest struct {
+	Name        string  `json:"name"`
+	Label       string  `json:"label"`
+	Type        string  `json:"type"` // string, number, boolean or date
+	Description string  `json:"description,omitempty"`
+	Value       *string `json:"value,omitempty"` // Set once supplied
+}
+
+// SubmitQueryParametersRequest holds the values of the parameters requested in an AI message, by parameter name
+type SubmitQueryParametersRequest struct {
+	StreamID string            `json:"stream_id" binding:"required"`
+	Values   map[string]string `json:"values" binding:"required"`
+}
+
+// RegenerateQueryRequest asks for another approach to the request answered by an AI message, the response is streamed
+type RegenerateQueryRequest struct {
+	StreamID string `json:"stream_id" binding:"required"`
 }
 
 type Query struct {
-	ID                     string                 `json:"id"`
-	Query                  string                 `json:"query"`
-	Description            string                 `json:"description"`
-	ExecutionTime          *int                   `json:"execution_time,omitempty"`
-	ExampleExecutionTime   int                    `json:"example_execution_time"`
-	CanRollback            bool                   `json:"can_rollback"`
-	IsCritical             bool                   `json:"is_critical"`
-	IsExecuted             bool                   `json:"is_executed"`
-	IsRolledBack           bool                   `json:"is_rolled_back"`
-	Error                  *QueryError            `json:"error,omitempty"`
-	ExampleResult          []interface{}          `json:"example_result,omitempty"`
-	ExecutionResult        map[string]interface{} `json:"execution_result,omitempty"`
-	QueryType              *string                `json:"query_type,omitempty"`
-	Tables                 *string                `json:"tables,omitempty"`
-	RollbackQuery          *string                `json:"rollback_query,omitempty"`
-	RollbackDependentQuery *string                `json:"rollback_dependent_query,omitempty"`
-	Pagination             *Pagination            `json:"pagination,omitempty"`
-	IsEdited               bool                   `json:"is_edited"`
-	ActionAt               *string                `json:"action_at,omitempty"` // The timestamp when the action was taken
+	ID                     string                  `json:"id"`
+	Query                  string                  `json:"query"`
+	Description            string                  `json:"description"`
+	ExecutionTime          *int                    `json:"execution_time,omitempty"`
+	ExampleExecutionTime   int                     `json:"example_execution_time"`
+	CanRollback            bool                    `json:"can_rollback"`
+	IsCritical             bool                    `json:"is_critical"`
+	IsExecuted             bool                    `json:"is_executed"`
+	IsRolledBack           bool                    `json:"is_rolled_back"`
+	Error                  *QueryError             `json:"error,omitempty"`
+	ExampleResult          []interface{}           `json:"example_result,omitempty"`
+	ExecutionResult        map[string]interface{}  `json:"execution_result,omitempty"`
+	QueryType              *string                 `json:"query_type,omitempty"`
+	Tables                 *string                 `json:"tables,omitempty"`
+	RollbackQuery          *string                 `json:"rollback_query,omitempty"`
+	RollbackDependentQuery *string                 `json:"rollback_dependent_query,omitempty"`
+	Pagination             *Pagination             `json:"pagination,omitempty"`
+	IsEdited               bool                    `json:"is_edited"`
+	ActionAt               *string                 `json:"action_at,omitempty"`                // The timestamp when the action was taken
+	Warnings               []string                `json:"warnings,omitempty"`                 // Warnings raised by the database during the last execution
+	EmptyResultDiagnostics *EmptyResultDiagnostics `json:"empty_result_diagnostics,omitempty"` // Only for SELECTs executed automatically returning no rows
+	GeneratedAt            *string                 `json:"generated_at,omitempty"`
+	NearDuplicateOf        *string                 `json:"near_duplicate_of,omitempty"` // ID of an earlier query of the message only differing by its quoting or LIMIT
+	Note                   *string                 `json:"note,omitempty"`
+	NotedAt                *string                 `json:"noted_at,omitempty"`
+	ComplexityScore        *string                 `json:"complexity_score,omitempty"`   // low, medium or high, from the JOINs, subqueries & unindexed scans of large tables
+	ComplexityReasons      []string                `json:"complexity_reasons,omitempty"` // Why the score isn't low
+	IsFavorite             bool                    `json:"is_favorite"`
+	FavoritedAt            *string                 `json:"favorited_at,omitempty"`
+	ConnectionName         string                  `json:"connection_name,omitempty"` // Named connection the query runs on, empty for the main connection
+	SuggestedIndexes       []SuggestedIndex        `json:"suggested_indexes,omitempty"`
+}
+
+// SuggestedIndex is an index proposed for a read query scanning a large table, created with the create_suggested_index
+// action
+type SuggestedIndex struct {
+	ID             string   `json:"id"`
+	Table          string   `json:"table"`
+	Columns        []string `json:"columns"`
+	Query          string   `json:"query"`
+	Reason         string   `json:"reason,omitempty"`
+	CreatedQueryID *string  `json:"created_query_id,omitempty"` // Query of the message creating the index
 }
 
 type Pagination struct {
@@ -68,6 +119,9 @@ type QueryError struct {
 type MessageListResponse struct {
 	Messages []MessageResponse `json:"messages"`
 	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	HasMore  bool              `json:"has_more"` // Older messages are left for the next pages
 }
 
 type MessageListRequest struct {
@@ -145,11 +199,59 @@ func ToQueryDto(queries *[]models.Query) *[]Query {
 			Pagination:             pagination,
 			IsEdited:               query.IsEdited,
 			ActionAt:               query.ActionAt,
+			GeneratedAt:            query.GeneratedAt,
+			Warnings:               query.Warnings,
+			NearDuplicateOf:        query.NearDuplicateOf,
+			Note:                   query.Note,
+			NotedAt:                query.NotedAt,
+			ComplexityScore:        query.ComplexityScore,
+			ComplexityReasons:      query.ComplexityReasons,
+			IsFavorite:             query.IsFavorite,
+			FavoritedAt:            query.FavoritedAt,
+			ConnectionName:         query.ConnectionName,
+			SuggestedIndexes:       toSuggestedIndexDtos(query.SuggestedIndexes),
 		}
 	}
 	return &queriesDto
 }
 
+func toSuggestedIndexDtos(suggestedIndexes []models.SuggestedIndex) []SuggestedIndex {
+	if len(suggestedIndexes) == 0 {
+		return nil
+	}
+	suggestedIndexDtos := make([]SuggestedIndex, len(suggestedIndexes))
+	for i, index := range suggestedIndexes {
+		suggestedIndexDtos[i] = SuggestedIndex{
+			ID:             index.ID.Hex(),
+			Table:          index.Table,
+			Columns:        index.Columns,
+			Query:          index.Query,
+			Reason:         index.Reason,
+			CreatedQueryID: index.CreatedQueryID,
+		}
+	}
+	return suggestedIndexDtos
+}
+
+// ToParameterRequestDto converts model parameter requests to DTO parameter requests
+func ToParameterRequestDto(parameterRequests *[]models.ParameterRequest) *[]ParameterRequest {
+	if parameterRequests == nil || len(*parameterRequests) == 0 {
+		return nil
+	}
+
+	parameterRequestsDto := make([]ParameterRequest, len(*parameterRequests))
+	for i, parameter := range *parameterRequests {
+		parameterRequestsDto[i] = ParameterRequest{
+			Name:        parameter.Name,
+			Label:       parameter.Label,
+			Type:        parameter.Type,
+			Description: parameter.Description,
+			Value:       parameter.Value,
+		}
+	}
+	return &parameterRequestsDto
+}
+
 // ToActionButtonDto converts model action buttons to DTO action buttons
 func ToActionButtonDto(actionButtons *[]models.ActionButton) *[]ActionButton {
 	log.Printf("ToActionButtonDto -> input actionButtons: %+v", actionButtons)
@@ -164,6 +266,7 @@ func ToActionButtonDto(actionButtons *[]models.ActionButton) *[]ActionButton {
 			Label:     button.Label,
 			Action:    button.Action,
 			IsPrimary: button.IsPrimary,
+			Payload:   button.Payload,
 		}
 	}
 	log.Printf("ToActionButtonDto -> returning actionButtonsDto: %+v", actionButtonsDto)
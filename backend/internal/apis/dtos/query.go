@@ -1,9 +1,21 @@
 package dtos
 
+import "time"
+
 type ExecuteQueryRequest struct {
-	MessageID string `json:"message_id" binding:"required"`
-	QueryID   string `json:"query_id" binding:"required"`
-	StreamID  string `json:"stream_id" binding:"required"`
+	MessageID            string     `json:"message_id" binding:"required"`
+	QueryID              string     `json:"query_id" binding:"required"`
+	StreamID             string     `json:"stream_id" binding:"required"`
+	Preview              bool       `json:"preview"`                 // Only fetch a few sample rows of a read query, the query isn't marked as executed
+	AsOf                 *time.Time `json:"as_of,omitempty"`         // Read the data as it was at this time (RFC 3339), for databases keeping the history of rows
+	AllShards            bool       `json:"all_shards"`              // Run the read query on every shard of the connection & merge their rows
+	ConfirmFullTableRead bool       `json:"confirm_full_table_read"` // Read every row of a large table, such reads are limited otherwise
+	ConfirmAffectedRows  bool       `json:"confirm_affected_rows"`   // Execute an UPDATE or DELETE changing more rows than the confirmation threshold
+	TimeoutSeconds       int        `json:"timeout_seconds"`         // Retry a query that timed out with a longer timeout, overrides the one of the chat
+	BypassCache          bool       `json:"bypass_cache"`            // Execute a read query again instead of serving its cached result
+	ConnectionName       string     `json:"connection_name"`         // Named connection of the chat to execute the query on, replaces the one the query was generated for
+	ReadOnly             bool       `json:"-"`                       // Set for requests of read only API keys, only read queries can be executed
+	ConnectionHeld       bool       `json:"-"`                       // Set when the caller holds the connection (see dbmanager.Manager.HoldConnection), it isn't checked again
 }
 
 type RollbackQueryRequest struct {
@@ -30,13 +42,41 @@ type QueryExecutionResponse struct {
 	TotalRecordsCount *int            `json:"total_records_count"`
 	ActionButtons     *[]ActionButton `json:"action_buttons,omitempty"`
 	ActionAt          *string         `json:"action_at,omitempty"`
+	Warnings          []string        `json:"warnings,omitempty"` // Warnings raised by the database, ex: data truncation, deprecated syntax
+	IsPreview         bool            `json:"is_preview,omitempty"`
+	Columns           []ResultColumn  `json:"columns,omitempty"` // Columns of the result with their types, in the order of the query when the database reports it
+	Cached            bool            `json:"cached,omitempty"`  // The result was served from the cache, see bypass_cache
+
+	EmptyResultDiagnostics *EmptyResultDiagnostics `json:"empty_result_diagnostics,omitempty"` // Only for SELECTs returning no rows
+	AffectedRowsEstimate   *int                    `json:"affected_rows_estimate,omitempty"`   // Rows an UPDATE or DELETE was counted to change before its execution
+}
+
+// ResultColumn describes a column of a query result
+type ResultColumn struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`                    // integer, number, boolean, text, datetime, date, time, json, binary, objectid or unknown
+	DatabaseType string `json:"database_type,omitempty"` // Type named by the database, ex: TIMESTAMPTZ, empty when inferred from the values
+}
+
+// EmptyResultDiagnostics explains why a query returned no rows, by re-counting the rows with each filter removed
+type EmptyResultDiagnostics struct {
+	Message        string                `json:"message"`
+	RelaxedFilters []RelaxedFilterResult `json:"relaxed_filters,omitempty"`
+	Suggestion     *string               `json:"suggestion,omitempty"` // LLM suggestion, only when diagnostics are set to "llm"
+}
+
+type RelaxedFilterResult struct {
+	Filter   string `json:"filter"`    // The removed condition
+	RowCount *int   `json:"row_count"` // Rows matching without the condition, nil if the probe failed
 }
 
 type QueryResultsRequest struct {
-	MessageID string `json:"message_id" binding:"required"`
-	QueryID   string `json:"query_id" binding:"required"`
-	StreamID  string `json:"stream_id" binding:"required"`
-	Offset    int    `json:"offset" binding:"required"`
+	MessageID   string `json:"message_id" binding:"required"`
+	QueryID     string `json:"query_id" binding:"required"`
+	StreamID    string `json:"stream_id" binding:"required"`
+	Offset      int    `json:"offset" binding:"required"`
+	AllShards   bool   `json:"all_shards"`   // Fetch the page from every shard of the connection, as the query was executed
+	BypassCache bool   `json:"bypass_cache"` // Fetch the page again instead of serving its cached result
 }
 
 type QueryResultsResponse struct {
@@ -48,6 +88,7 @@ type QueryResultsResponse struct {
 	TotalRecordsCount *int            `json:"total_records_count"`
 	ActionButtons     *[]ActionButton `json:"action_buttons,omitempty"`
 	ActionAt          *string         `json:"action_at,omitempty"`
+	Cached            bool            `json:"cached,omitempty"` // The page was served from the cache, see bypass_cache
 }
 
 type EditQueryRequest struct {
@@ -63,3 +104,151 @@ type EditQueryResponse struct {
 	Query     string `json:"query"`
 	IsEdited  bool   `json:"is_edited"`
 }
+
+// UpdateQueryNoteRequest attaches a note to an executed query, an empty note removes it
+type UpdateQueryNoteRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+	Note      string `json:"note"`
+}
+
+type QueryNoteResponse struct {
+	ChatID    string  `json:"chat_id"`
+	MessageID string  `json:"message_id"`
+	QueryID   string  `json:"query_id"`
+	Note      *string `json:"note"`
+	NotedAt   *string `json:"noted_at"`
+}
+
+type QueryFavoriteResponse struct {
+	ChatID      string  `json:"chat_id"`
+	MessageID   string  `json:"message_id"`
+	QueryID     string  `json:"query_id"`
+	IsFavorite  bool    `json:"is_favorite"`
+	FavoritedAt *string `json:"favorited_at"`
+}
+
+// FavoriteQueryResponse is a query pinned by the user, with the chat & message it belongs to
+type FavoriteQueryResponse struct {
+	ChatID         string `json:"chat_id"`
+	ConnectionType string `json:"connection_type"`
+	Database       string `json:"database"`
+	MessageID      string `json:"message_id"`
+	Query          Query  `json:"query"`
+}
+
+type CompareQueryResultsRequest struct {
+	MessageID    string `json:"message_id" binding:"required"`
+	QueryID      string `json:"query_id" binding:"required"`
+	StreamID     string `json:"stream_id" binding:"required"`
+	TargetChatID string `json:"target_chat_id" binding:"required"` // Chat whose connection the query is also executed on
+	KeyColumn    string `json:"key_column" binding:"required"`     // Column identifying a row in both results
+}
+
+// QueryComparisonResponse is the diff of the results of a query executed on the connections of two chats, rows are
+// matched on the key column
+type QueryComparisonResponse struct {
+	ChatID            string          `json:"chat_id"`
+	TargetChatID      string          `json:"target_chat_id"`
+	MessageID         string          `json:"message_id"`
+	QueryID           string          `json:"query_id"`
+	KeyColumn         string          `json:"key_column"`
+	SourceRowCount    int             `json:"source_row_count"`
+	TargetRowCount    int             `json:"target_row_count"`
+	MatchingRowCount  int             `json:"matching_row_count"`
+	OnlyInSourceCount int             `json:"only_in_source_count"`
+	OnlyInTargetCount int             `json:"only_in_target_count"`
+	ChangedRowCount   int             `json:"changed_row_count"`
+	OnlyInSource      []interface{}   `json:"only_in_source"` // Rows whose key is missing from the target result
+	OnlyInTarget      []interface{}   `json:"only_in_target"` // Rows whose key is missing from the source result
+	ChangedRows       []RowDifference `json:"changed_rows"`
+	IsTruncated       bool            `json:"is_truncated"`       // The lists of differences were capped, the counts are complete
+	Warnings          []string        `json:"warnings,omitempty"` // Ex: truncated results, duplicate or missing keys
+}
+
+// RowDifference lists the columns whose values differ between the rows of a key
+type RowDifference struct {
+	Key     interface{}                 `json:"key"`
+	Columns map[string]ColumnDifference `json:"columns"`
+}
+
+type ColumnDifference struct {
+	Source interface{} `json:"source"`
+	Target interface{} `json:"target"`
+}
+
+// QueryExecutionHistoryItem is an execution or a rollback of a query of the chat
+type QueryExecutionHistoryItem struct {
+	ID            string      `json:"id"`
+	MessageID     string      `json:"message_id"`
+	QueryID       string      `json:"query_id"`
+	Query         string      `json:"query"` // The rollback query for a rollback
+	QueryType     *string     `json:"query_type"`
+	IsRollback    bool        `json:"is_rollback"`
+	IsPreview     bool        `json:"is_preview"`
+	ExecutionTime *int        `json:"execution_time"` // in milliseconds
+	RowCount      *int        `json:"row_count"`      // Rows returned or affected, null if unknown
+	Error         *QueryError `json:"error,omitempty"`
+	CreatedAt     string      `json:"created_at"`
+}
+
+type ExecutionHistoryResponse struct {
+	Executions []QueryExecutionHistoryItem `json:"executions"`
+	Total      int64                       `json:"total"`
+	Limit      int                         `json:"limit"`
+	Offset     int                         `json:"offset"`
+	HasMore    bool                        `json:"has_more"` // Older executions are left for the next pages
+}
+
+// ExecuteQueriesInTransactionRequest executes queries of a message atomically, in the order of QueryIDs
+type ExecuteQueriesInTransactionRequest struct {
+	MessageID string   `json:"message_id" binding:"required"`
+	QueryIDs  []string `json:"query_ids" binding:"required,min=1"`
+	StreamID  string   `json:"stream_id" binding:"required"`
+	ReadOnly  bool     `json:"-"` // Set for requests of read only API keys, only read queries can be executed
+}
+
+type TransactionExecutionResponse struct {
+	ChatID    string                   `json:"chat_id"`
+	MessageID string                   `json:"message_id"`
+	Committed bool                     `json:"committed"`
+	Results   []QueryExecutionResponse `json:"results"` // One per query executed, up to the one that failed
+	Error     *QueryError              `json:"error,omitempty"`
+}
+
+// ExplainQueryRequest fetches the execution plan of a read query of a message, the query itself isn't executed unless
+// Analyze is set (EXPLAIN ANALYZE), its rows are never returned
+type ExplainQueryRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+	StreamID  string `json:"stream_id"`
+	Analyze   bool   `json:"analyze"`
+}
+
+// CreateSuggestedIndexRequest creates an index the LLM suggested for a read query of a message, see
+// models.SuggestedIndex
+type CreateSuggestedIndexRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+	IndexID   string `json:"index_id" binding:"required"`
+	StreamID  string `json:"stream_id" binding:"required"`
+	ReadOnly  bool   `json:"-"` // Set for requests of read only API keys, they can't create indexes
+}
+
+// ExplainResultRequest asks for a plain English explanation of the result of an executed query
+type ExplainResultRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	QueryID   string `json:"query_id" binding:"required"`
+}
+
+type QueryPlanResponse struct {
+	ChatID        string      `json:"chat_id"`
+	MessageID     string      `json:"message_id"`
+	QueryID       string      `json:"query_id"`
+	Statement     string      `json:"statement"` // Statement run to fetch the plan, ex: EXPLAIN (FORMAT JSON) SELECT ...
+	Format        string      `json:"format"`    // json or text, a text plan is a list of lines
+	Plan          interface{} `json:"plan"`
+	Analyzed      bool        `json:"analyzed"`
+	ExecutionTime int         `json:"execution_time"`
+	Warnings      []string    `json:"warnings,omitempty"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+type QueryTemplate struct {
+	Name        string `json:"name" binding:"required"`
+	Query       string `json:"query" binding:"required"` // The SELECT defining the CTE
+	Description string `json:"description"`
+	AutoPrepend bool   `json:"auto_prepend"` // Add the definition to generated queries referencing the template by name
+}
+
+type UpdateQueryTemplatesRequest struct {
+	Templates []QueryTemplate `json:"templates" binding:"dive"` // Replaces all the templates of the chat, empty to remove them
+}
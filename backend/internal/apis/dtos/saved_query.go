@@ -0,0 +1,74 @@
+package dtos
+
+import (
+	"databot-ai/internal/models"
+	"time"
+)
+
+// SavedQueryParameter is a {{name}} placeholder of a saved query, its value is supplied on each execution
+type SavedQueryParameter struct {
+	Name        string `json:"name" binding:"required"`
+	Label       string `json:"label"`                                                    // Display text of the form field, the name if empty
+	Type        string `json:"type" binding:"required,oneof=string number boolean date"` // Values are validated against the type
+	Description string `json:"description,omitempty"`
+}
+
+type CreateSavedQueryRequest struct {
+	Name           string                `json:"name" binding:"required"`
+	Description    string                `json:"description"`
+	Query          string                `json:"query" binding:"required"`
+	PaginatedQuery *string               `json:"paginated_query,omitempty"` // The query with an offset_size placeholder for the OFFSET, ex: OFFSET offset_size LIMIT 50
+	CountQuery     *string               `json:"count_query,omitempty"`     // Counts the rows of the query, for the pagination
+	Parameters     []SavedQueryParameter `json:"parameters" binding:"dive"`
+	ConnectionType *string               `json:"connection_type,omitempty" binding:"omitempty,oneof=postgresql yugabytedb mysql mariadb clickhouse mongodb redis neo4j cassandra sqlite bigquery elasticsearch"` // Only chats of this type can run the query, any chat if empty
+}
+
+type SavedQueryResponse struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Query          string                `json:"query"`
+	PaginatedQuery *string               `json:"paginated_query,omitempty"`
+	CountQuery     *string               `json:"count_query,omitempty"`
+	Parameters     []SavedQueryParameter `json:"parameters"`
+	ConnectionType *string               `json:"connection_type,omitempty"`
+	CreatedAt      string                `json:"created_at"`
+}
+
+// ExecuteSavedQueryRequest holds the values of the parameters of a saved query, by parameter name
+type ExecuteSavedQueryRequest struct {
+	StreamID string            `json:"stream_id" binding:"required"`
+	Values   map[string]string `json:"values"`
+}
+
+// ExecuteSavedQueryResponse is the message the saved query was added to in the chat & the result of its execution
+type ExecuteSavedQueryResponse struct {
+	Message   *MessageResponse        `json:"message"`
+	Execution *QueryExecutionResponse `json:"execution,omitempty"` // Unset for a critical query, it is executed once confirmed in the chat
+}
+
+func ToSavedQueryDto(savedQuery *models.SavedQuery) SavedQueryResponse {
+	parameters := make([]SavedQueryParameter, len(savedQuery.Parameters))
+	for i, parameter := range savedQuery.Parameters {
+		parameters[i] = SavedQueryParameter{
+			Name:        parameter.Name,
+			Label:       parameter.Label,
+			Type:        parameter.Type,
+			Description: parameter.Description,
+		}
+	}
+	response := SavedQueryResponse{
+		ID:             savedQuery.ID.Hex(),
+		Name:           savedQuery.Name,
+		Description:    savedQuery.Description,
+		Query:          savedQuery.Query,
+		Parameters:     parameters,
+		ConnectionType: savedQuery.ConnectionType,
+		CreatedAt:      savedQuery.CreatedAt.Format(time.RFC3339),
+	}
+	if savedQuery.Pagination != nil {
+		response.PaginatedQuery = savedQuery.Pagination.PaginatedQuery
+		response.CountQuery = savedQuery.Pagination.CountQuery
+	}
+	return response
+}
@@ -0,0 +1,87 @@
+package dtos
+
+import (
+	"databot-ai/internal/models"
+	"time"
+)
+
+// CreateScheduledQueryRequest schedules a read query of the chat, the query is copied so that editing or deleting its
+// message doesn't change the schedule
+type CreateScheduledQueryRequest struct {
+	Name           string `json:"name" binding:"required"`
+	MessageID      string `json:"message_id" binding:"required"`
+	QueryID        string `json:"query_id" binding:"required"`
+	CronExpression string `json:"cron_expression" binding:"required"` // ex: "0 8 * * 1-5" for 8:00 on weekdays
+	Timezone       string `json:"timezone"`                           // IANA name, ex: Europe/Paris, UTC if empty
+	Enabled        *bool  `json:"enabled"`                            // Enabled if not given
+	WebhookURL     string `json:"webhook_url"`                        // Notified with the result of each run
+}
+
+// UpdateScheduledQueryRequest changes the fields given, an empty webhook_url removes the webhook
+type UpdateScheduledQueryRequest struct {
+	Name           *string `json:"name"`
+	CronExpression *string `json:"cron_expression"`
+	Timezone       *string `json:"timezone"`
+	Enabled        *bool   `json:"enabled"`
+	WebhookURL     *string `json:"webhook_url"`
+}
+
+type ScheduledQueryResponse struct {
+	ID             string  `json:"id"`
+	ChatID         string  `json:"chat_id"`
+	Name           string  `json:"name"`
+	Query          string  `json:"query"`
+	QueryType      *string `json:"query_type,omitempty"`
+	CronExpression string  `json:"cron_expression"`
+	Timezone       string  `json:"timezone"`
+	Enabled        bool    `json:"enabled"`
+	WebhookURL     string  `json:"webhook_url,omitempty"`
+	IsRunning      bool    `json:"is_running"`
+	NextRunAt      *string `json:"next_run_at,omitempty"`
+	LastRunAt      *string `json:"last_run_at,omitempty"`
+	LastRunError   *string `json:"last_run_error,omitempty"`
+	LastMessageID  *string `json:"last_message_id,omitempty"` // Message holding the result of the last run
+	CreatedAt      string  `json:"created_at"`
+}
+
+// ScheduledQueryWebhookPayload is posted to the webhook of a scheduled query after each run
+type ScheduledQueryWebhookPayload struct {
+	ScheduledQueryID string                  `json:"scheduled_query_id"`
+	ChatID           string                  `json:"chat_id"`
+	Name             string                  `json:"name"`
+	Status           string                  `json:"status"` // "completed" or "failed"
+	RanAt            time.Time               `json:"ran_at"`
+	MessageID        string                  `json:"message_id,omitempty"`
+	Execution        *QueryExecutionResponse `json:"execution,omitempty"`
+	Error            string                  `json:"error,omitempty"`
+}
+
+func ToScheduledQueryDto(scheduledQuery *models.ScheduledQuery, isRunning bool) ScheduledQueryResponse {
+	response := ScheduledQueryResponse{
+		ID:             scheduledQuery.ID.Hex(),
+		ChatID:         scheduledQuery.ChatID.Hex(),
+		Name:           scheduledQuery.Name,
+		Query:          scheduledQuery.Query,
+		QueryType:      scheduledQuery.QueryType,
+		CronExpression: scheduledQuery.CronExpression,
+		Timezone:       scheduledQuery.Timezone,
+		Enabled:        scheduledQuery.Enabled,
+		WebhookURL:     scheduledQuery.WebhookURL,
+		IsRunning:      isRunning,
+		LastRunError:   scheduledQuery.LastRunError,
+		CreatedAt:      scheduledQuery.CreatedAt.Format(time.RFC3339),
+	}
+	if scheduledQuery.NextRunAt != nil {
+		nextRunAt := scheduledQuery.NextRunAt.Format(time.RFC3339)
+		response.NextRunAt = &nextRunAt
+	}
+	if scheduledQuery.LastRunAt != nil {
+		lastRunAt := scheduledQuery.LastRunAt.Format(time.RFC3339)
+		response.LastRunAt = &lastRunAt
+	}
+	if scheduledQuery.LastMessageID != nil {
+		lastMessageID := scheduledQuery.LastMessageID.Hex()
+		response.LastMessageID = &lastMessageID
+	}
+	return response
+}
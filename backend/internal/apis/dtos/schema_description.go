@@ -0,0 +1,11 @@
+package dtos
+
+type SchemaDescription struct {
+	Table       string `json:"table" binding:"required"`
+	Column      string `json:"column"` // Empty to describe the table
+	Description string `json:"description" binding:"required"`
+}
+
+type UpdateSchemaDescriptionsRequest struct {
+	Descriptions []SchemaDescription `json:"descriptions" binding:"dive"` // Replaces all the descriptions of the chat, empty to remove them
+}
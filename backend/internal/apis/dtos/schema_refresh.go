@@ -0,0 +1,18 @@
+package dtos
+
+import "time"
+
+// RefreshSchemaRequest is the optional body of the refresh schema API, without it the refresh is synchronous
+type RefreshSchemaRequest struct {
+	Async      bool   `json:"async"`       // Return once the refresh is started, WebhookURL is notified when it completes
+	WebhookURL string `json:"webhook_url"` // Overrides the webhook of the chat settings for this refresh
+}
+
+// SchemaRefreshWebhookPayload is posted to the webhook of a chat once a schema refresh completes
+type SchemaRefreshWebhookPayload struct {
+	ChatID      string    `json:"chat_id"`
+	Status      string    `json:"status"` // "completed" or "failed"
+	TableCount  int       `json:"table_count"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
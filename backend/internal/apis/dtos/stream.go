@@ -1,6 +1,13 @@
 package dtos
 
 type StreamResponse struct {
-	Event string      `json:"event"` // ai-response, ai-response-step, ai-response-error, db-connected, db-disconnected, sse-connected, response-cancelled, query-results, rollback-executed, rollback-query-failed
+	Event string      `json:"event"` // ai-response, ai-response-chunk, ai-response-step, ai-response-error, db-connected, db-disconnected, sse-connected, response-cancelled, query-results, rollback-executed, rollback-query-failed
 	Data  interface{} `json:"data,omitempty"`
 }
+
+// AIResponseChunk is a piece of the message of an AI response sent as it is generated, the ai-response event that
+// follows holds the complete message & replaces the pieces
+type AIResponseChunk struct {
+	UserMessageID string `json:"user_message_id"` // Message the response answers
+	Chunk         string `json:"chunk"`
+}
@@ -232,3 +232,91 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 		Data:    user,
 	})
 }
+
+// @Summary Create API key
+// @Description Create an API key to call the API from scripts, the key is only returned once
+// @Accept json
+// @Produce json
+// @Param createAPIKeyRequest body dtos.CreateAPIKeyRequest true "Create API key request"
+// @Success 201 {object} dtos.Response
+
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dtos.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorMsg := err.Error()
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	response, statusCode, err := h.authService.CreateAPIKey(userID, &req)
+	if err != nil {
+		errorMsg := err.Error()
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List API keys
+// @Description List the API keys of the user that aren't revoked
+// @Accept json
+// @Produce json
+// @Success 200 {object} dtos.Response
+
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.authService.ListAPIKeys(userID)
+	if err != nil {
+		errorMsg := err.Error()
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key, requests made with it are rejected from then on
+// @Accept json
+// @Produce json
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} dtos.Response
+
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	userID := c.GetString("userID")
+	keyID := c.Param("keyId")
+
+	statusCode, err := h.authService.RevokeAPIKey(userID, keyID)
+	if err != nil {
+		errorMsg := err.Error()
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "API key revoked",
+	})
+}
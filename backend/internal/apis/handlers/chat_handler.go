@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
 	"databot-ai/internal/services"
 	"databot-ai/internal/utils"
 	"encoding/json"
@@ -9,10 +10,12 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type ChatHandler struct {
@@ -69,7 +72,7 @@ func (h *ChatHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(10)
+// @Param page_size query int false "Page size, at most MAX_LIST_PAGE_SIZE" default(10)
 
 func (h *ChatHandler) List(c *gin.Context) {
 	userID := c.GetString("userID")
@@ -208,11 +211,80 @@ func (h *ChatHandler) Duplicate(c *gin.Context) {
 	})
 }
 
+// @Summary Export a chat template
+// @Description Export the configuration of a chat (connection without credentials, selected collections, settings, query templates & schema descriptions) as a portable template
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ExportChatTemplate(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.ExportChatTemplate(userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Import a chat template
+// @Description Create a chat from a template exported by ExportChatTemplate & the credentials of the user, fields unknown to this version of the template are rejected
+// @Accept json
+// @Produce json
+// @Param request body dtos.ImportChatTemplateRequest true "Template & credentials"
+
+func (h *ChatHandler) ImportChatTemplate(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dtos.ImportChatTemplateRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(fmt.Sprintf("invalid template: %v", err)),
+		})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(fmt.Sprintf("invalid template: %v", err)),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.ImportChatTemplate(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
 // @Summary List messages
-// @Description List all messages for a chat
+// @Description List the messages of a chat from the latest, one page at a time
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size, at most MAX_LIST_PAGE_SIZE" default(50)
 
 func (h *ChatHandler) ListMessages(c *gin.Context) {
 	userID := c.GetString("userID")
@@ -256,7 +328,7 @@ func (h *ChatHandler) CreateMessage(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
 
-	response, statusCode, err := h.chatService.CreateMessage(c.Request.Context(), userID, chatID, req.StreamID, req.Content)
+	response, statusCode, err := h.chatService.CreateMessage(c.Request.Context(), userID, chatID, req.StreamID, req.Content, req.Migration)
 	if err != nil {
 		errorMsg := err.Error()
 		c.JSON(int(statusCode), dtos.Response{
@@ -564,18 +636,18 @@ func (h *ChatHandler) DisconnectDB(c *gin.Context) {
 	})
 }
 
-// @Summary Get DB Connection Status
-// @Description Get the current connection status of a database
+// @Summary Close DB Session
+// @Description Close the session of a chat in session mode, temp tables & session variables are dropped
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
 
-// GetDBConnectionStatus checks the current connection status
-func (h *ChatHandler) GetDBConnectionStatus(c *gin.Context) {
+// CloseDBSession releases the dedicated connection held in session mode
+func (h *ChatHandler) CloseDBSession(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
 
-	status, statusCode, err := h.chatService.GetDBConnectionStatus(c.Request.Context(), userID, chatID)
+	statusCode, err := h.chatService.CloseDBSession(userID, chatID)
 	if err != nil {
 		c.JSON(int(statusCode), dtos.Response{
 			Success: false,
@@ -586,57 +658,58 @@ func (h *ChatHandler) GetDBConnectionStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dtos.Response{
 		Success: true,
-		Data:    status,
+		Data:    "Session closed successfully",
 	})
 }
 
-// @Summary Refresh Schema
-// @Description Refresh the schema of a database
+// @Summary Update named connection
+// @Description Add a database connection to the chat under a name or replace the one with this name, queries can run on it next to the main connection
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
+// @Param name path string true "Connection name"
 
-func (h *ChatHandler) RefreshSchema(c *gin.Context) {
+func (h *ChatHandler) UpdateNamedConnection(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
+	name := c.Param("name")
+
+	var req dtos.CreateConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
 
-	statusCode, err := h.chatService.RefreshSchema(c.Request.Context(), userID, chatID, true)
+	response, status, err := h.chatService.UpdateNamedConnection(userID, chatID, name, &req)
 	if err != nil {
-		c.JSON(int(statusCode), dtos.Response{
+		c.JSON(int(status), dtos.Response{
 			Success: false,
 			Error:   utils.ToStringPtr(err.Error()),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, dtos.Response{
+	c.JSON(int(status), dtos.Response{
 		Success: true,
-		Data:    "Schema refreshed successfully",
+		Data:    response,
 	})
 }
 
-// @Summary Execute query
-// @Description Execute a query
+// @Summary Delete named connection
+// @Description Disconnect & remove a named connection of the chat
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
+// @Param name path string true "Connection name"
 
-// Add query execution methods
-func (h *ChatHandler) ExecuteQuery(c *gin.Context) {
+func (h *ChatHandler) DeleteNamedConnection(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
 
-	var req dtos.ExecuteQueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   utils.ToStringPtr(err.Error()),
-		})
-		return
-	}
-
-	// Execute query
-	response, status, err := h.chatService.ExecuteQuery(c.Request.Context(), userID, chatID, &req)
+	status, err := h.chatService.DeleteNamedConnection(userID, chatID, c.Param("name"))
 	if err != nil {
 		c.JSON(int(status), dtos.Response{
 			Success: false,
@@ -647,169 +720,149 @@ func (h *ChatHandler) ExecuteQuery(c *gin.Context) {
 
 	c.JSON(int(status), dtos.Response{
 		Success: true,
-		Data:    response,
+		Data:    "Connection removed successfully",
 	})
 }
 
-// @Summary Rollback query
-// @Description Rollback a query
+// @Summary List server activity
+// @Description List the queries & open transactions of the connection's database user, longest running first
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
+// @Param min_duration_ms query int false "Only list activity running for at least this long"
 
-func (h *ChatHandler) RollbackQuery(c *gin.Context) {
+func (h *ChatHandler) ListServerActivity(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
+	minDurationMs, _ := strconv.ParseInt(c.DefaultQuery("min_duration_ms", "0"), 10, 64)
 
-	var req dtos.RollbackQueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dtos.Response{
-			Success: false,
-			Error:   utils.ToStringPtr(err.Error()),
-		})
-		return
-	}
-
-	// Execute rollback
-	response, status, err := h.chatService.RollbackQuery(c.Request.Context(), userID, chatID, &req)
+	response, statusCode, err := h.chatService.ListServerActivity(c.Request.Context(), userID, chatID, minDurationMs)
 	if err != nil {
-		c.JSON(int(status), dtos.Response{
+		c.JSON(int(statusCode), dtos.Response{
 			Success: false,
 			Error:   utils.ToStringPtr(err.Error()),
 		})
 		return
 	}
 
-	c.JSON(int(status), dtos.Response{
+	c.JSON(http.StatusOK, dtos.Response{
 		Success: true,
 		Data:    response,
 	})
 }
 
-// @Summary Cancel query execution
-// @Description Cancel a query execution
+// @Summary Terminate server activity
+// @Description Cancel a query of the connection's database user, or terminate its connection to end a hanging transaction
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
+// @Param activityId path string true "Activity ID as listed by ListServerActivity"
+// @Param terminate query bool false "Terminate the connection & roll back its transaction instead of cancelling the query" default(false)
 
-func (h *ChatHandler) CancelQueryExecution(c *gin.Context) {
+func (h *ChatHandler) TerminateServerActivity(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
-	var req dtos.CancelQueryExecutionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	activityID := c.Param("activityId")
+	terminate, _ := strconv.ParseBool(c.DefaultQuery("terminate", "false"))
+
+	statusCode, err := h.chatService.TerminateServerActivity(c.Request.Context(), userID, chatID, activityID, terminate)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
 		return
 	}
 
-	// Cancel execution
-	h.chatService.CancelQueryExecution(userID, chatID, req.MessageID, req.QueryID, req.StreamID)
+	message := "Query cancelled successfully"
+	if terminate {
+		message = "Activity terminated successfully"
+	}
 	c.JSON(http.StatusOK, dtos.Response{
 		Success: true,
-		Data:    "Query execution cancelled successfully",
+		Data:    message,
 	})
 }
 
-// Update the stream handling
-func (h *ChatHandler) HandleStream(c *gin.Context) {
+// @Summary Get DB Connection Status
+// @Description Get the current connection status of a database
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+// GetDBConnectionStatus checks the current connection status
+func (h *ChatHandler) GetDBConnectionStatus(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
-	streamID := c.Query("stream_id")
 
-	if streamID == "" {
-		c.JSON(http.StatusBadRequest, dtos.Response{
+	status, statusCode, err := h.chatService.GetDBConnectionStatus(c.Request.Context(), userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
 			Success: false,
-			Error:   utils.ToStringPtr("stream_id is required"),
+			Error:   utils.ToStringPtr(err.Error()),
 		})
 		return
 	}
 
-	// Create stream key
-	streamKey := fmt.Sprintf("%s:%s:%s", userID, chatID, streamID)
-
-	// Check if stream already exists
-	h.streamMutex.Lock()
-	if existingChan, exists := h.streams[streamKey]; exists {
-		log.Printf("Stream already exists: %s, closing old stream", streamKey)
-		close(existingChan)
-		delete(h.streams, streamKey)
-	}
-
-	// Create new stream channel
-	streamChan := make(chan dtos.StreamResponse, 100)
-	h.streams[streamKey] = streamChan
-	h.streamMutex.Unlock()
-
-	log.Printf("Created new stream: %s", streamKey)
-
-	// Set headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Transfer-Encoding", "chunked")
-	c.Header("X-Accel-Buffering", "no")
-
-	// Send initial connection event
-	c.SSEvent("message", dtos.StreamResponse{
-		Event: "connected",
-		Data:  "Stream established",
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    status,
 	})
-	c.Writer.Flush()
+}
 
-	// Setup context and ticker
-	ctx := c.Request.Context()
-	heartbeatTicker := time.NewTicker(30 * time.Second)
-	defer heartbeatTicker.Stop()
+// @Summary Refresh Schema
+// @Description Refresh the schema of a database, an async refresh returns once started & notifies the webhook when it completes
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param request body dtos.RefreshSchemaRequest false "Async refresh & its webhook"
 
-	// Cleanup on exit
-	defer func() {
-		h.streamMutex.Lock()
-		if ch, exists := h.streams[streamKey]; exists {
-			log.Printf("Closing stream: %s", streamKey)
-			close(ch)
-			delete(h.streams, streamKey)
-		}
-		h.streamMutex.Unlock()
-	}()
+func (h *ChatHandler) RefreshSchema(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
 
-	// Stream handling loop
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("Context done for stream: %s", streamKey)
+	var req dtos.RefreshSchemaRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dtos.Response{
+				Success: false,
+				Error:   utils.ToStringPtr(err.Error()),
+			})
 			return
+		}
+	}
 
-		case <-heartbeatTicker.C:
-			if f, ok := c.Writer.(http.Flusher); ok {
-				c.SSEvent("message", dtos.StreamResponse{
-					Event: "heartbeat",
-					Data:  "ping",
-				})
-				f.Flush()
-			}
+	statusCode, err := h.chatService.RefreshSchema(c.Request.Context(), userID, chatID, !req.Async, req.WebhookURL)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
 
-		case msg, ok := <-streamChan:
-			if !ok {
-				log.Printf("Stream channel closed: %s", streamKey)
-				return
-			}
-			if f, ok := c.Writer.(http.Flusher); ok {
-				c.SSEvent("message", msg)
-				f.Flush()
-			}
-		}
+	message := "Schema refreshed successfully"
+	if req.Async {
+		message = "Schema refresh started"
 	}
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    message,
+	})
 }
 
-// @Summary Get query results
-// @Description Get the results of a query
+// @Summary Execute query
+// @Description Execute a query
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
 
-func (h *ChatHandler) GetQueryResults(c *gin.Context) {
+// Add query execution methods
+func (h *ChatHandler) ExecuteQuery(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
-	var req dtos.QueryResultsRequest
+
+	var req dtos.ExecuteQueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dtos.Response{
 			Success: false,
@@ -817,13 +870,20 @@ func (h *ChatHandler) GetQueryResults(c *gin.Context) {
 		})
 		return
 	}
+	req.ReadOnly = c.GetString("apiKeyScope") == constants.APIKeyScopeRead
 
-	response, status, err := h.chatService.GetQueryResults(c.Request.Context(), userID, chatID, req.MessageID, req.QueryID, req.StreamID, req.Offset)
+	// Execute query
+	response, status, err := h.chatService.ExecuteQuery(c.Request.Context(), userID, chatID, &req)
 	if err != nil {
-		c.JSON(int(status), dtos.Response{
+		// The response carries the code of the query error when there is one, ex: READ_ONLY_MODE
+		resp := dtos.Response{
 			Success: false,
 			Error:   utils.ToStringPtr(err.Error()),
-		})
+		}
+		if response != nil {
+			resp.Data = response
+		}
+		c.JSON(int(status), resp)
 		return
 	}
 
@@ -833,16 +893,17 @@ func (h *ChatHandler) GetQueryResults(c *gin.Context) {
 	})
 }
 
-// @Summary Edit query
-// @Description Edit a query
+// @Summary Execute queries in a transaction
+// @Description Execute dependent queries of a message in a single transaction, committed only if every query succeeds
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
 
-func (h *ChatHandler) EditQuery(c *gin.Context) {
+func (h *ChatHandler) ExecuteQueriesInTransaction(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
-	var req dtos.EditQueryRequest
+
+	var req dtos.ExecuteQueriesInTransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dtos.Response{
 			Success: false,
@@ -850,13 +911,19 @@ func (h *ChatHandler) EditQuery(c *gin.Context) {
 		})
 		return
 	}
+	req.ReadOnly = c.GetString("apiKeyScope") == constants.APIKeyScopeRead
 
-	response, status, err := h.chatService.EditQuery(c.Request.Context(), userID, chatID, req.MessageID, req.QueryID, req.Query)
+	response, status, err := h.chatService.ExecuteQueriesInTransaction(c.Request.Context(), userID, chatID, &req)
 	if err != nil {
-		c.JSON(int(status), dtos.Response{
+		// The response carries the code of the error when there is one, ex: TRANSACTIONS_UNSUPPORTED
+		resp := dtos.Response{
 			Success: false,
 			Error:   utils.ToStringPtr(err.Error()),
-		})
+		}
+		if response != nil {
+			resp.Data = response
+		}
+		c.JSON(int(status), resp)
 		return
 	}
 
@@ -866,17 +933,159 @@ func (h *ChatHandler) EditQuery(c *gin.Context) {
 	})
 }
 
-// @Summary Get tables
-// @Description Get all tables with their columns for a specific chat, marking which ones are selected
+// @Summary Create suggested index
+// @Description Create an index suggested for a read query, its statement is added to the message as a critical query & executed
 // @Accept json
 // @Produce json
 // @Param id path string true "Chat ID"
 
-func (h *ChatHandler) GetTables(c *gin.Context) {
+func (h *ChatHandler) CreateSuggestedIndex(c *gin.Context) {
 	userID := c.GetString("userID")
 	chatID := c.Param("id")
 
-	response, statusCode, err := h.chatService.GetAllTables(c.Request.Context(), userID, chatID)
+	var req dtos.CreateSuggestedIndexRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+	req.ReadOnly = c.GetString("apiKeyScope") == constants.APIKeyScopeRead
+
+	response, status, err := h.chatService.CreateSuggestedIndex(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Explain query
+// @Description Get the execution plan of a read query without changing data, optionally with EXPLAIN ANALYZE
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ExplainQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.ExplainQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.ExplainQuery(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Explain query result
+// @Description Add a plain English explanation of the result of an executed query to the chat, with its notable insights
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ExplainResult(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.ExplainResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.ExplainResult(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Rollback query
+// @Description Rollback a query
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) RollbackQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.RollbackQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	// Execute rollback
+	response, status, err := h.chatService.RollbackQuery(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get the execution history
+// @Description List the queries executed & rolled back in a chat, newest first
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param limit query int false "Number of executions, at most MAX_LIST_PAGE_SIZE" default(50)
+// @Param offset query int false "Number of executions skipped" default(0)
+
+func (h *ChatHandler) GetExecutionHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	response, statusCode, err := h.chatService.GetExecutionHistory(userID, chatID, limit, offset)
 	if err != nil {
 		errorMsg := err.Error()
 		c.JSON(int(statusCode), dtos.Response{
@@ -886,8 +1095,1125 @@ func (h *ChatHandler) GetTables(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dtos.Response{
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get audit log
+// @Description List the schema refreshes, LLM generations, query executions & rollbacks of all the chats, newest first. Admin only.
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Only list the entries of this user"
+// @Param from query string false "Only list the entries from this time (RFC 3339)"
+// @Param to query string false "Only list the entries until this time (RFC 3339)"
+// @Param limit query int false "Number of entries, at most MAX_LIST_PAGE_SIZE" default(50)
+// @Param offset query int false "Number of entries skipped" default(0)
+
+func (h *ChatHandler) GetAuditLog(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dtos.AuditLogRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.GetAuditLog(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
 		Success: true,
 		Data:    response,
 	})
 }
+
+// @Summary Cancel query execution
+// @Description Cancel a query execution
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) CancelQueryExecution(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	var req dtos.CancelQueryExecutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Cancel execution
+	h.chatService.CancelQueryExecution(userID, chatID, req.MessageID, req.QueryID, req.StreamID)
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    "Query execution cancelled successfully",
+	})
+}
+
+// Update the stream handling
+func (h *ChatHandler) HandleStream(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	streamID := c.Query("stream_id")
+
+	if streamID == "" {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr("stream_id is required"),
+		})
+		return
+	}
+
+	// Create stream key
+	streamKey := fmt.Sprintf("%s:%s:%s", userID, chatID, streamID)
+
+	// Check if stream already exists
+	h.streamMutex.Lock()
+	if existingChan, exists := h.streams[streamKey]; exists {
+		log.Printf("Stream already exists: %s, closing old stream", streamKey)
+		close(existingChan)
+		delete(h.streams, streamKey)
+	}
+
+	// Create new stream channel
+	streamChan := make(chan dtos.StreamResponse, 100)
+	h.streams[streamKey] = streamChan
+	h.streamMutex.Unlock()
+
+	log.Printf("Created new stream: %s", streamKey)
+
+	// Set headers
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Transfer-Encoding", "chunked")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Send initial connection event
+	c.SSEvent("message", dtos.StreamResponse{
+		Event: "connected",
+		Data:  "Stream established",
+	})
+	c.Writer.Flush()
+
+	// Setup context and ticker
+	ctx := c.Request.Context()
+	heartbeatTicker := time.NewTicker(30 * time.Second)
+	defer heartbeatTicker.Stop()
+
+	// Cleanup on exit
+	defer func() {
+		h.streamMutex.Lock()
+		if ch, exists := h.streams[streamKey]; exists {
+			log.Printf("Closing stream: %s", streamKey)
+			close(ch)
+			delete(h.streams, streamKey)
+		}
+		h.streamMutex.Unlock()
+	}()
+
+	// Stream handling loop
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Context done for stream: %s", streamKey)
+			return
+
+		case <-heartbeatTicker.C:
+			if f, ok := c.Writer.(http.Flusher); ok {
+				c.SSEvent("message", dtos.StreamResponse{
+					Event: "heartbeat",
+					Data:  "ping",
+				})
+				f.Flush()
+			}
+
+		case msg, ok := <-streamChan:
+			if !ok {
+				log.Printf("Stream channel closed: %s", streamKey)
+				return
+			}
+			if f, ok := c.Writer.(http.Flusher); ok {
+				c.SSEvent("message", msg)
+				f.Flush()
+			}
+		}
+	}
+}
+
+// @Summary Get query results
+// @Description Get the results of a query
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) GetQueryResults(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	var req dtos.QueryResultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.GetQueryResults(c.Request.Context(), userID, chatID, req.MessageID, req.QueryID, req.StreamID, req.Offset, req.AllShards, req.BypassCache)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Compare query results
+// @Description Execute a read query on the chat's connection and on the connection of another chat, and return the diff of the results keyed by a column
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) CompareQueryResults(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	var req dtos.CompareQueryResultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.CompareQueryResults(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Edit query
+// @Description Edit a query
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) EditQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	var req dtos.EditQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.EditQuery(c.Request.Context(), userID, chatID, req.MessageID, req.QueryID, req.Query)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update query note
+// @Description Attach a note to an executed query, an empty note removes it
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) UpdateQueryNote(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	var req dtos.UpdateQueryNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.UpdateQueryNote(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Toggle query favorite
+// @Description Pin a query, or unpin it if it is pinned
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param messageId path string true "Message ID"
+// @Param queryId path string true "Query ID"
+
+func (h *ChatHandler) ToggleQueryFavorite(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	messageID := c.Param("messageId")
+	queryID := c.Param("queryId")
+
+	response, status, err := h.chatService.ToggleQueryFavorite(userID, chatID, messageID, queryID)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get favorite queries
+// @Description Get the queries pinned by the user across all the chats
+// @Produce json
+
+func (h *ChatHandler) GetFavoriteQueries(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, status, err := h.chatService.GetFavoriteQueries(userID)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get tables
+// @Description Get all tables with their columns for a specific chat, marking which ones are selected
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) GetTables(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.GetAllTables(c.Request.Context(), userID, chatID)
+	if err != nil {
+		errorMsg := err.Error()
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get the values of a column
+// @Description List the distinct values of a low cardinality column of a table, to build filters
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param table path string true "Table name"
+// @Param column path string true "Column name"
+// @Param limit query int false "Values listed at most, columns with more values are refused" default(100)
+// @Param stream_id query string false "Stream notified if the database has to be connected"
+
+func (h *ChatHandler) GetColumnValues(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	response, statusCode, err := h.chatService.GetColumnValues(c.Request.Context(), userID, chatID, c.Query("stream_id"), c.Param("table"), c.Param("column"), limit)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Export schema diagram
+// @Description Render the stored schema as an ER diagram, the database doesn't need to be connected
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param format query string false "mermaid or dot" default(mermaid)
+
+func (h *ChatHandler) ExportSchemaDiagram(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.ExportSchemaDiagram(c.Request.Context(), userID, chatID, c.Query("format"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get schema stats
+// @Description Get the table count, estimated rows, relationships & last refresh of the stored schema, with the row count of each table
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) GetSchemaStats(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.GetSchemaStats(c.Request.Context(), userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get schema graph
+// @Description Get tables, columns and foreign key relationships of the connected database as a graph, used to render an ER diagram
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) GetSchemaGraph(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.GetSchemaGraph(c.Request.Context(), userID, chatID)
+	if err != nil {
+		errorMsg := err.Error()
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   &errorMsg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update export destination
+// @Description Set the S3/GCS bucket where large query results are exported, credentials are stored encrypted
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) UpdateExportDestination(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.ExportDestinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.UpdateExportDestination(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete export destination
+// @Description Remove the S3/GCS export destination of a chat
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) DeleteExportDestination(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	status, err := h.chatService.DeleteExportDestination(userID, chatID)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    "Export destination removed successfully",
+	})
+}
+
+// @Summary Update query templates
+// @Description Replace the reusable CTEs of the chat's connection, they are validated against the schema & shared with the LLM
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) UpdateQueryTemplates(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.UpdateQueryTemplatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.UpdateQueryTemplates(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update schema descriptions
+// @Description Replace the table & column descriptions written by the user, they are layered over the database comments shared with the LLM & survive schema refreshes
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) UpdateSchemaDescriptions(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.UpdateSchemaDescriptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.UpdateSchemaDescriptions(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Import a schema
+// @Description Store the schema (ex: CREATE TABLE statements) a generate only chat generates queries from, without connecting to the database
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ImportSchema(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.ImportSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.ImportSchema(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Submit query parameters
+// @Description Supply the values requested by an AI message, they are filled in its queries which are executed if the chat auto executes queries
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param messageId path string true "Message ID"
+
+func (h *ChatHandler) SubmitQueryParameters(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	messageID := c.Param("messageId")
+
+	var req dtos.SubmitQueryParametersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.SubmitQueryParameters(c.Request.Context(), userID, chatID, messageID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Regenerate query
+// @Description Ask for alternative queries to the request answered by an AI message, the message is updated in place & streamed
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param messageId path string true "Message ID"
+
+func (h *ChatHandler) RegenerateQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	messageID := c.Param("messageId")
+
+	var req dtos.RegenerateQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.RegenerateQuery(c.Request.Context(), userID, chatID, messageID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Export query results to cloud storage
+// @Description Re-execute a read query without the result cap, upload the results to the chat's export destination and return a signed URL
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ExportQueryResultsToCloud(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.CloudExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, status, err := h.chatService.ExportQueryResultsToCloud(c.Request.Context(), userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(status), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(status), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Stream query results as NDJSON
+// @Description Re-execute a read query without the result cap and stream its rows as newline delimited JSON while they are read from the database, the last line holds the metadata of the export ({"_metadata":{"total_count":N}}). Requires Accept: application/x-ndjson
+// @Accept json
+// @Produce application/x-ndjson
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) StreamQueryResults(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	if !strings.Contains(c.GetHeader("Accept"), services.ContentTypeNDJSON) {
+		c.JSON(http.StatusNotAcceptable, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr("only " + services.ContentTypeNDJSON + " is supported, set it in the Accept header"),
+		})
+		return
+	}
+
+	var req dtos.StreamExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	writer := &ndjsonResponseWriter{c: c}
+	rows, status, err := h.chatService.StreamQueryResults(c.Request.Context(), userID, chatID, &req, writer)
+	if err != nil {
+		if !writer.started {
+			c.JSON(int(status), dtos.Response{
+				Success: false,
+				Error:   utils.ToStringPtr(err.Error()),
+			})
+			return
+		}
+		// The status is already sent, the consumer finds the error in place of the metadata line
+		log.Printf("ChatHandler -> StreamQueryResults -> Stream interrupted after %d rows: %v", rows, err)
+		line, _ := json.Marshal(map[string]interface{}{"_metadata": map[string]interface{}{"total_count": rows, "error": err.Error()}})
+		c.Writer.Write(append(line, '\n'))
+	}
+}
+
+// @Summary Download a cell value
+// @Description Re-execute a read query and download the full value of one cell of its result, binary values are sent with their sniffed content type
+// @Produce octet-stream
+// @Param id path string true "Chat ID"
+// @Param messageId path string true "Message ID"
+// @Param queryId path string true "Query ID"
+// @Param row query int true "Row of the result, 0 based"
+// @Param column query string true "Column of the result"
+// @Param stream_id query string false "Stream notified if the database has to be connected"
+
+func (h *ChatHandler) DownloadCellValue(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	row, err := strconv.Atoi(c.Query("row"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr("row must be a number"),
+		})
+		return
+	}
+
+	content, statusCode, err := h.chatService.DownloadCellValue(c.Request.Context(), userID, chatID, c.Param("messageId"), c.Param("queryId"), c.Query("stream_id"), row, c.Query("column"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", content.FileName))
+	c.Data(http.StatusOK, content.ContentType, content.Data)
+}
+
+// @Summary Export query results as CSV
+// @Description Re-execute a read query without the result cap and download its rows as CSV while they are read from the database. X-Total-Count holds the row count of the query pagination when it is known.
+// @Produce text/csv
+// @Param id path string true "Chat ID"
+// @Param messageId path string true "Message ID"
+// @Param queryId path string true "Query ID"
+// @Param stream_id query string false "Stream notified if the database has to be connected"
+
+func (h *ChatHandler) ExportQueryResults(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	queryID := c.Param("queryId")
+
+	writer := &csvResponseWriter{c: c, fileName: fmt.Sprintf("query-%s.csv", queryID)}
+	rows, statusCode, err := h.chatService.ExportQueryResults(c.Request.Context(), userID, chatID, c.Param("messageId"), queryID, c.Query("stream_id"), writer)
+	if err != nil {
+		if !writer.started {
+			c.JSON(int(statusCode), dtos.Response{
+				Success: false,
+				Error:   utils.ToStringPtr(err.Error()),
+			})
+			return
+		}
+		// The status is already sent, the download ends short of the announced rows
+		log.Printf("ChatHandler -> ExportQueryResults -> Export interrupted after %d rows: %v", rows, err)
+	}
+}
+
+// csvResponseWriter sends the CSV headers with the first bytes, like ndjsonResponseWriter
+type csvResponseWriter struct {
+	c          *gin.Context
+	fileName   string
+	totalCount *int
+	started    bool
+}
+
+func (w *csvResponseWriter) SetTotalCount(count int) {
+	w.totalCount = &count
+}
+
+func (w *csvResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "text/csv; charset=utf-8")
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.fileName))
+		if w.totalCount != nil {
+			w.c.Header("X-Total-Count", strconv.Itoa(*w.totalCount))
+		}
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// ndjsonResponseWriter sends the NDJSON headers with the first line, so that errors raised before any row is read are
+// still returned as a JSON response with their status
+type ndjsonResponseWriter struct {
+	c       *gin.Context
+	started bool
+}
+
+func (w *ndjsonResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", services.ContentTypeNDJSON)
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// @Summary Save a query
+// @Description Save a query with {{name}} placeholders for the user, the values of its parameters are supplied on each execution
+// @Accept json
+// @Produce json
+// @Param request body dtos.CreateSavedQueryRequest true "Saved query"
+
+func (h *ChatHandler) CreateSavedQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dtos.CreateSavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.CreateSavedQuery(userID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List saved queries
+// @Description List the saved queries of the user by name
+// @Produce json
+// @Param connection_type query string false "Only the queries a chat of this connection type can run"
+
+func (h *ChatHandler) ListSavedQueries(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	response, statusCode, err := h.chatService.ListSavedQueries(userID, c.Query("connection_type"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a saved query
+// @Description Delete a saved query of the user, the messages it was executed in are kept
+// @Produce json
+// @Param savedQueryId path string true "Saved query ID"
+
+func (h *ChatHandler) DeleteSavedQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	statusCode, err := h.chatService.DeleteSavedQuery(userID, c.Param("savedQueryId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Saved query deleted successfully",
+	})
+}
+
+// @Summary Execute a saved query
+// @Description Fill the parameters of a saved query with the supplied values & execute it in the chat, the query is added to the chat in a message of its own
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param savedQueryId path string true "Saved query ID"
+// @Param request body dtos.ExecuteSavedQueryRequest true "Parameter values"
+
+func (h *ChatHandler) ExecuteSavedQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	savedQueryID := c.Param("savedQueryId")
+
+	var req dtos.ExecuteSavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.ExecuteSavedQuery(c.Request.Context(), userID, chatID, savedQueryID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Schedule a query
+// @Description Schedule a read query of the chat on a cron expression, each run adds the query to the chat in a message of its own holding the result
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param request body dtos.CreateScheduledQueryRequest true "Scheduled query"
+
+func (h *ChatHandler) CreateScheduledQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	var req dtos.CreateScheduledQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.CreateScheduledQuery(userID, chatID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary List scheduled queries
+// @Description List the scheduled queries of the chat by name, with the outcome of their last run
+// @Produce json
+// @Param id path string true "Chat ID"
+
+func (h *ChatHandler) ListScheduledQueries(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	response, statusCode, err := h.chatService.ListScheduledQueries(userID, chatID)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a scheduled query
+// @Description Change the name, schedule or webhook of a scheduled query, or enable or disable it
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param scheduledQueryId path string true "Scheduled query ID"
+// @Param request body dtos.UpdateScheduledQueryRequest true "Changes"
+
+func (h *ChatHandler) UpdateScheduledQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+	scheduledQueryID := c.Param("scheduledQueryId")
+
+	var req dtos.UpdateScheduledQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	response, statusCode, err := h.chatService.UpdateScheduledQuery(userID, chatID, scheduledQueryID, &req)
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a scheduled query
+// @Description Delete a scheduled query of the chat, the messages of its past runs are kept
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param scheduledQueryId path string true "Scheduled query ID"
+
+func (h *ChatHandler) DeleteScheduledQuery(c *gin.Context) {
+	userID := c.GetString("userID")
+	chatID := c.Param("id")
+
+	statusCode, err := h.chatService.DeleteScheduledQuery(userID, chatID, c.Param("scheduledQueryId"))
+	if err != nil {
+		c.JSON(int(statusCode), dtos.Response{
+			Success: false,
+			Error:   utils.ToStringPtr(err.Error()),
+		})
+		return
+	}
+
+	c.JSON(int(statusCode), dtos.Response{
+		Success: true,
+		Data:    "Scheduled query deleted successfully",
+	})
+}
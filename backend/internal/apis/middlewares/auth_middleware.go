@@ -2,8 +2,10 @@ package middlewares
 
 import (
 	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
 	"databot-ai/internal/di"
 	"databot-ai/internal/repositories"
+	"databot-ai/internal/services"
 	"databot-ai/internal/utils"
 	"log"
 	"net/http"
@@ -14,6 +16,19 @@ import (
 
 var jwtService *utils.JWTService
 var tokenRepo repositories.TokenRepository
+var authService services.AuthService
+
+// readScopeRoutes are the non GET routes read only API keys can call, they don't change the connected databases
+// (ExecuteQuery only runs read queries for these keys)
+var readScopeRoutes = map[string]bool{
+	"/api/chats/:id/connect":               true,
+	"/api/chats/:id/stream/cancel":         true,
+	"/api/chats/:id/queries/execute":       true,
+	"/api/chats/:id/queries/cancel":        true,
+	"/api/chats/:id/queries/results":       true,
+	"/api/chats/:id/queries/compare":       true,
+	"/api/chats/:id/queries/export/stream": true,
+}
 
 func AuthMiddleware() gin.HandlerFunc {
 	if jwtService == nil {
@@ -30,6 +45,13 @@ func AuthMiddleware() gin.HandlerFunc {
 			log.Fatalf("Failed to provide Token repository: %v", err)
 		}
 	}
+	if authService == nil {
+		if err := di.DiContainer.Invoke(func(service services.AuthService) {
+			authService = service
+		}); err != nil {
+			log.Fatalf("Failed to provide Auth service: %v", err)
+		}
+	}
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -56,6 +78,35 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		token := parts[1]
 
+		// API keys of scripts, the requests of a read only key are limited to readScopeRoutes & GET routes
+		if strings.HasPrefix(token, constants.APIKeyPrefix) {
+			apiKey, status, err := authService.AuthenticateAPIKey(token)
+			if err != nil {
+				errorMsg := err.Error()
+				c.JSON(int(status), dtos.Response{
+					Success: false,
+					Error:   &errorMsg,
+				})
+				c.Abort()
+				return
+			}
+			if apiKey.Scope == constants.APIKeyScopeRead && c.Request.Method != http.MethodGet && !readScopeRoutes[c.FullPath()] {
+				errorMsg := "This API key is read only"
+				c.JSON(http.StatusForbidden, dtos.Response{
+					Success: false,
+					Error:   &errorMsg,
+				})
+				c.Abort()
+				return
+			}
+
+			c.Set("userID", apiKey.UserID.Hex())
+			c.Set("apiKeyID", apiKey.ID.Hex())
+			c.Set("apiKeyScope", apiKey.Scope)
+			c.Next()
+			return
+		}
+
 		// Check if token is blacklisted
 		if tokenRepo.IsTokenBlacklisted(token) {
 			errorMsg := "Token has been revoked"
@@ -83,3 +134,20 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// SessionAuthMiddleware rejects requests authenticated with an API key, it is used after AuthMiddleware on routes
+// that only the user can call such as the management of API keys
+func SessionAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			errorMsg := "This route can't be called with an API key"
+			c.JSON(http.StatusForbidden, dtos.Response{
+				Success: false,
+				Error:   &errorMsg,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"databot-ai/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SourceIPMiddleware sets the IP address of the client on the context of the request, the services record it in the
+// audit log (see services.WithSourceIP)
+func SourceIPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(services.WithSourceIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
@@ -29,4 +29,12 @@ func SetupAuthRoutes(router *gin.Engine) {
 		protected.POST("/logout", authHandler.Logout)
 		protected.GET("/refresh-token", authHandler.RefreshToken)
 	}
+
+	apiKeys := router.Group("/api/auth/api-keys")
+	apiKeys.Use(middlewares.AuthMiddleware(), middlewares.SessionAuthMiddleware())
+	{
+		apiKeys.POST("", authHandler.CreateAPIKey)
+		apiKeys.GET("", authHandler.ListAPIKeys)
+		apiKeys.DELETE("/:keyId", authHandler.RevokeAPIKey)
+	}
 }
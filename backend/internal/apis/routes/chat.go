@@ -15,7 +15,7 @@ func SetupChatRoutes(router *gin.Engine) {
 	}
 
 	protected := router.Group("/api/chats")
-	protected.Use(middlewares.AuthMiddleware())
+	protected.Use(middlewares.AuthMiddleware(), middlewares.SourceIPMiddleware())
 	{
 		// Chat CRUD
 		protected.POST("", chatHandler.Create)
@@ -24,19 +24,40 @@ func SetupChatRoutes(router *gin.Engine) {
 		protected.PATCH("/:id", chatHandler.Update)
 		protected.DELETE("/:id", chatHandler.Delete)
 		protected.POST("/:id/duplicate", chatHandler.Duplicate) // Has query param "duplicate_messages"
+		protected.GET("/:id/template", chatHandler.ExportChatTemplate)
+		protected.POST("/import", chatHandler.ImportChatTemplate)
+
+		// Saved queries of the user, executed in a chat
+		protected.POST("/saved-queries", chatHandler.CreateSavedQuery)
+		protected.GET("/saved-queries", chatHandler.ListSavedQueries) // Has query param "connection_type"
+		protected.DELETE("/saved-queries/:savedQueryId", chatHandler.DeleteSavedQuery)
+
+		// Queries pinned by the user across the chats
+		protected.GET("/favorite-queries", chatHandler.GetFavoriteQueries)
 
 		// Messages within a chat
 		protected.GET("/:id/messages", chatHandler.ListMessages)
 		protected.POST("/:id/messages", chatHandler.CreateMessage)
 		protected.PATCH("/:id/messages/:messageId", chatHandler.UpdateMessage)
+		protected.POST("/:id/messages/:messageId/parameters", chatHandler.SubmitQueryParameters)
+		protected.POST("/:id/messages/:messageId/regenerate", chatHandler.RegenerateQuery)
 		protected.DELETE("/:id/messages", chatHandler.DeleteMessages)
 
 		// Database connection routes
 		protected.POST("/:id/connect", chatHandler.ConnectDB)
 		protected.POST("/:id/disconnect", chatHandler.DisconnectDB)
 		protected.GET("/:id/connection-status", chatHandler.GetDBConnectionStatus)
+		protected.DELETE("/:id/session", chatHandler.CloseDBSession)
+		protected.PUT("/:id/connections/:name", chatHandler.UpdateNamedConnection)
+		protected.DELETE("/:id/connections/:name", chatHandler.DeleteNamedConnection)
+		protected.GET("/:id/activity", chatHandler.ListServerActivity)
+		protected.DELETE("/:id/activity/:activityId", chatHandler.TerminateServerActivity)
 		protected.POST("/:id/refresh-schema", chatHandler.RefreshSchema)
 		protected.GET("/:id/tables", chatHandler.GetTables)
+		protected.GET("/:id/tables/:table/columns/:column/values", chatHandler.GetColumnValues) // Has query param "limit"
+		protected.GET("/:id/schema/graph", chatHandler.GetSchemaGraph)
+		protected.GET("/:id/schema/diagram", chatHandler.ExportSchemaDiagram) // Has query param "format"
+		protected.GET("/:id/schema/stats", chatHandler.GetSchemaStats)
 
 		// SSE endpoints for streaming
 		protected.GET("/:id/stream", chatHandler.StreamChat)
@@ -45,8 +66,39 @@ func SetupChatRoutes(router *gin.Engine) {
 		// Query execution routes
 		protected.POST("/:id/queries/execute", chatHandler.ExecuteQuery)
 		protected.POST("/:id/queries/rollback", chatHandler.RollbackQuery)
+		protected.POST("/:id/queries/execute-transaction", chatHandler.ExecuteQueriesInTransaction)
+		protected.POST("/:id/queries/explain", chatHandler.ExplainQuery)
+		protected.POST("/:id/queries/suggested-index", chatHandler.CreateSuggestedIndex)
+		protected.POST("/:id/queries/explain-result", chatHandler.ExplainResult)
+		protected.POST("/:id/saved-queries/:savedQueryId/execute", chatHandler.ExecuteSavedQuery)
+		protected.POST("/:id/scheduled-queries", chatHandler.CreateScheduledQuery)
+		protected.GET("/:id/scheduled-queries", chatHandler.ListScheduledQueries)
+		protected.PATCH("/:id/scheduled-queries/:scheduledQueryId", chatHandler.UpdateScheduledQuery)
+		protected.DELETE("/:id/scheduled-queries/:scheduledQueryId", chatHandler.DeleteScheduledQuery)
+		protected.GET("/:id/executions", chatHandler.GetExecutionHistory)
 		protected.POST("/:id/queries/cancel", chatHandler.CancelQueryExecution)
 		protected.POST("/:id/queries/results", chatHandler.GetQueryResults)
+		protected.POST("/:id/queries/compare", chatHandler.CompareQueryResults)
 		protected.PATCH("/:id/queries/edit", chatHandler.EditQuery)
+		protected.PUT("/:id/queries/note", chatHandler.UpdateQueryNote)
+		protected.PUT("/:id/messages/:messageId/queries/:queryId/favorite", chatHandler.ToggleQueryFavorite)
+		protected.PUT("/:id/query-templates", chatHandler.UpdateQueryTemplates)
+		protected.PUT("/:id/schema-descriptions", chatHandler.UpdateSchemaDescriptions)
+		protected.PUT("/:id/imported-schema", chatHandler.ImportSchema)
+
+		// Export routes
+		protected.PUT("/:id/export-destination", chatHandler.UpdateExportDestination)
+		protected.DELETE("/:id/export-destination", chatHandler.DeleteExportDestination)
+		protected.POST("/:id/queries/export/cloud", chatHandler.ExportQueryResultsToCloud)
+		protected.POST("/:id/queries/export/stream", chatHandler.StreamQueryResults)
+		protected.GET("/:id/messages/:messageId/queries/:queryId/cell", chatHandler.DownloadCellValue) // Has query params "row" & "column"
+		protected.GET("/:id/messages/:messageId/queries/:queryId/csv", chatHandler.ExportQueryResults)
+	}
+
+	// Admin routes, the service checks that the user is the admin user
+	admin := router.Group("/api/admin")
+	admin.Use(middlewares.AuthMiddleware(), middlewares.SessionAuthMiddleware())
+	{
+		admin.GET("/audit-log", chatHandler.GetAuditLog) // Has query params "user_id", "from", "to" (RFC 3339), "limit" & "offset"
 	}
 }
@@ -2,7 +2,9 @@ package routes
 
 import (
 	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/di"
 	"databot-ai/internal/middleware"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +22,26 @@ func SetupDefaultRoutes(router *gin.Engine) {
 		})
 	})
 
+	llmManager, err := di.GetLLMManager()
+	if err != nil {
+		log.Fatalf("Failed to get LLM manager: %v", err)
+	}
+	dbManager, err := di.GetDBManager()
+	if err != nil {
+		log.Fatalf("Failed to get DB manager: %v", err)
+	}
+
+	// Metrics route, load of the LLM calls & of the database connection pools
+	router.GET("/metrics", func(c *gin.Context) {
+		c.JSON(http.StatusOK, dtos.Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"llm_calls": llmManager.GetCallMetrics(),
+				"db_pools":  dbManager.GetPoolMetrics(),
+			},
+		})
+	})
+
 	// Setup all route groups
 	SetupAuthRoutes(router)
 	SetupChatRoutes(router)
@@ -0,0 +1,10 @@
+package constants
+
+// Anthropic shares the system prompts & JSON response schemas of OpenAI (see GetSystemPrompt & GetLLMResponseSchema),
+// the schema is given to Claude as the input schema of the tool it must call
+const (
+	AnthropicModel               = "claude-sonnet-4-20250514"
+	AnthropicTemperature         = 1
+	AnthropicMaxCompletionTokens = 16000
+	AnthropicAPIVersion          = "2023-06-01"
+)
@@ -0,0 +1,20 @@
+package constants
+
+// API keys let users call the API from scripts, a key is only returned when it is created & is stored hashed
+const (
+	APIKeyPrefix = "dbk_" // Tells API keys apart from JWTs in the Authorization header
+
+	APIKeyScopeRead = "read" // Reads of chats & results, only read queries can be executed
+	APIKeyScopeFull = "full" // Same access as the user
+
+	// DefaultAPIKeyRateLimitPerMinute is the number of requests an API key can make per minute when
+	// API_KEY_RATE_LIMIT_PER_MINUTE isn't set, each key has its own limit
+	DefaultAPIKeyRateLimitPerMinute = 60
+
+	MaxAPIKeysPerUser = 20
+)
+
+// IsValidAPIKeyScope checks if scope is one of the APIKeyScope constants
+func IsValidAPIKeyScope(scope string) bool {
+	return scope == APIKeyScopeRead || scope == APIKeyScopeFull
+}
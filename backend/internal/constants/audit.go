@@ -0,0 +1,13 @@
+package constants
+
+// Audit log actions, every schema refresh, LLM generation, query execution & rollback of a chat is recorded
+const (
+	AuditActionSchemaRefresh  = "schema_refresh"
+	AuditActionLLMGeneration  = "llm_generation"
+	AuditActionQueryExecution = "query_execution"
+	AuditActionQueryRollback  = "query_rollback"
+
+	// DefaultAuditLogBufferSize is the number of audit log entries waiting to be written when AUDIT_LOG_BUFFER_SIZE
+	// isn't set
+	DefaultAuditLogBufferSize = 1000
+)
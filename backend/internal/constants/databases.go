@@ -1,12 +1,75 @@
 package constants
 
 const (
-	DatabaseTypePostgreSQL = "postgresql"
-	DatabaseTypeYugabyteDB = "yugabytedb"
-	DatabaseTypeMySQL      = "mysql"
-	DatabaseTypeMongoDB    = "mongodb"
-	DatabaseTypeRedis      = "redis"
-	DatabaseTypeNeo4j      = "neo4j"
-	DatabaseTypeClickhouse = "clickhouse"
-	DatabaseTypeCassandra  = "cassandra"
+	DatabaseTypePostgreSQL    = "postgresql"
+	DatabaseTypeYugabyteDB    = "yugabytedb"
+	DatabaseTypeMySQL         = "mysql"
+	DatabaseTypeMariaDB       = "mariadb"
+	DatabaseTypeMongoDB       = "mongodb"
+	DatabaseTypeRedis         = "redis"
+	DatabaseTypeNeo4j         = "neo4j"
+	DatabaseTypeClickhouse    = "clickhouse"
+	DatabaseTypeCassandra     = "cassandra"
+	DatabaseTypeSQLite        = "sqlite"
+	DatabaseTypeBigQuery      = "bigquery"
+	DatabaseTypeElasticsearch = "elasticsearch"
 )
+
+// DefaultMaxQueryResultRows is the number of rows of a query result read from the database when MAX_QUERY_RESULT_ROWS
+// isn't set, results are capped at 50 records for the LLM & the UI so anything above that is only kept as margin
+const DefaultMaxQueryResultRows = 1000
+
+// DefaultMaxQueryResultBytes is the size of a query result read from the database when MAX_QUERY_RESULT_BYTES isn't
+// set, a few rows of large JSON or binary columns can weigh hundreds of MB
+const DefaultMaxQueryResultBytes = 50 * 1024 * 1024
+
+// DefaultMaxResultCellBytes is the size of a value of a stored query result when MAX_RESULT_CELL_BYTES isn't set,
+// larger values are cut, the full value stays downloadable
+const DefaultMaxResultCellBytes = 64 * 1024
+
+// DefaultFullTableReadRowThreshold is the row count above which a SELECT reading a whole table is limited unless
+// confirmed, when FULL_TABLE_READ_ROW_THRESHOLD isn't set
+const DefaultFullTableReadRowThreshold = 10_000_000
+
+// DefaultAffectedRowsConfirmationThreshold is the number of rows above which an UPDATE or DELETE waits for the user to
+// confirm it, when AFFECTED_ROWS_CONFIRMATION_THRESHOLD isn't set
+const DefaultAffectedRowsConfirmationThreshold = 1000
+
+// DefaultQueryResultCacheTTLSeconds is how long the result of a read query is reused when QUERY_RESULT_CACHE_TTL_SECONDS
+// isn't set, kept short since the data may be changed outside of the chat
+const DefaultQueryResultCacheTTLSeconds = 60
+
+// MaxImportedSchemaLength is the size in bytes of the largest schema a generate only chat can import, the whole
+// schema is sent to the LLM with every message
+const MaxImportedSchemaLength = 256 * 1024
+
+// ChatTemplateVersion is the version of the chat templates exported by this server, templates of another version are
+// rejected on import
+const ChatTemplateVersion = 1
+
+// MinDynamicQueryTimeoutSeconds is the shortest timeout of a query sized on the response time estimated by the LLM,
+// MaxQueryTimeoutSeconds is the longest timeout a chat or a retry of a query can choose
+const (
+	MinDynamicQueryTimeoutSeconds = 30
+	MaxQueryTimeoutSeconds        = 3600
+)
+
+// QueryPreviewRows is the number of sample rows returned when a query is previewed before its full execution
+const QueryPreviewRows = 5
+
+// MaxComparisonDifferences is the number of rows listed in each kind of difference of a query result comparison
+const MaxComparisonDifferences = 100
+
+// DefaultColumnValuesLimit & MaxColumnValuesLimit are the default & largest number of distinct values listed for a
+// column, columns with more values are refused
+const (
+	DefaultColumnValuesLimit = 100
+	MaxColumnValuesLimit     = 1000
+)
+
+// MaxQueryNoteLength is the number of characters of the note a user can attach to a query
+const MaxQueryNoteLength = 2000
+
+// DefaultPIIColumnPatterns are the column name patterns whose example records are masked when PII_COLUMN_PATTERNS
+// isn't set, each a case insensitive regular expression matched anywhere in the name
+const DefaultPIIColumnPatterns = "e_?mail,phone,mobile,ssn,social_security,passw,secret,token,api_?key,credit_?card,card_?number,cvv,iban,tax_?id,passport,birth"
@@ -2,16 +2,71 @@ package constants
 
 // LLMResponse represents the structured response from LLM
 type LLMResponse struct {
-	Queries          []QueryInfo    `json:"queries,omitempty"`
-	AssistantMessage string         `json:"assistantMessage"`
-	ActionButtons    []ActionButton `json:"actionButtons,omitempty"`
+	Queries           []QueryInfo        `json:"queries,omitempty"`
+	AssistantMessage  string             `json:"assistantMessage"`
+	ActionButtons     []ActionButton     `json:"actionButtons,omitempty"`
+	ParameterRequests []ParameterRequest `json:"parameterRequests,omitempty"`
+	// NeedsClarification is set when AssistantMessage asks the user a clarification question instead of answering
+	NeedsClarification bool   `json:"needsClarification,omitempty"`
+	Confidence         string `json:"confidence,omitempty"` // high, medium or low
 }
 
 // ActionButton represents a UI action button that can be suggested by the LLM
 type ActionButton struct {
-	Label     string `json:"label"`     // Display text for the button
-	Action    string `json:"action"`    // Action identifier (e.g., "refresh_schema", "show_tables")
-	IsPrimary bool   `json:"isPrimary"` // Whether this is a primary (highlighted) action
+	Label     string                 `json:"label"`             // Display text for the button
+	Action    string                 `json:"action"`            // Action identifier (e.g., "refresh_schema", "show_tables")
+	IsPrimary bool                   `json:"isPrimary"`         // Whether this is a primary (highlighted) action
+	Payload   map[string]interface{} `json:"payload,omitempty"` // Context the action needs, see the ActionPayload keys
+}
+
+// Keys of the payload of an action button, the client sends the payload back when the button is clicked
+const (
+	ActionPayloadTable    = "table"     // Table or collection the action applies to
+	ActionPayloadColumns  = "columns"   // Columns or fields the action applies to
+	ActionPayloadQueryID  = "query_id"  // Query the action applies to, ex: fix_rollback_error
+	ActionPayloadQueryIDs = "query_ids" // Queries the action applies to, ex: the failed queries of fix_error
+	ActionPayloadIndexID  = "index_id"  // Suggested index of the query the action applies to, ex: create_suggested_index
+)
+
+// ParameterRequest represents a value the LLM needs from the user, the queries reference it with a {{name}} placeholder
+type ParameterRequest struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Type        string `json:"type"` // string, number, boolean or date
+	Description string `json:"description,omitempty"`
+}
+
+// Types of the values the LLM can ask the user for in a ParameterRequest
+const (
+	ParameterTypeString  = "string"
+	ParameterTypeNumber  = "number"
+	ParameterTypeBoolean = "boolean"
+	ParameterTypeDate    = "date"
+)
+
+// IsValidParameterType checks if the type of a ParameterRequest is supported
+func IsValidParameterType(paramType string) bool {
+	switch paramType {
+	case ParameterTypeString, ParameterTypeNumber, ParameterTypeBoolean, ParameterTypeDate:
+		return true
+	}
+	return false
+}
+
+// Confidence levels of an LLM response, how sure the LLM is that it answers the request as the user meant it
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// IsValidConfidence checks if the confidence of an LLMResponse is supported
+func IsValidConfidence(confidence string) bool {
+	switch confidence {
+	case ConfidenceHigh, ConfidenceMedium, ConfidenceLow:
+		return true
+	}
+	return false
 }
 
 // QueryInfo represents a single query in the LLM response
@@ -29,6 +84,16 @@ type QueryInfo struct {
 	RollbackQuery          string                    `json:"rollbackQuery,omitempty"`
 	EstimateResponseTime   interface{}               `json:"estimateResponseTime"`
 	RollbackDependentQuery string                    `json:"rollbackDependentQuery,omitempty"`
+	ConnectionName         string                    `json:"connectionName,omitempty"` // Named connection of the chat the query runs on, empty for the main one
+	SuggestedIndexes       []SuggestedIndex          `json:"suggestedIndexes,omitempty"`
+}
+
+// SuggestedIndex is an index the LLM proposes when a SELECT filters a large table on a column without an index
+type SuggestedIndex struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Query   string   `json:"query"` // CREATE INDEX statement
+	Reason  string   `json:"reason"`
 }
 
 type Pagination struct {
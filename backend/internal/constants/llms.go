@@ -1,13 +1,152 @@
 package constants
 
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	OpenAI    = "openai"
+	Gemini    = "gemini"
+	Anthropic = "anthropic"
+)
+
+// DefaultMaxConcurrentLLMCalls is the number of LLM calls running at the same time when MAX_CONCURRENT_LLM_CALLS
+// isn't set
+const DefaultMaxConcurrentLLMCalls = 10
+
+// DefaultLLMRateLimitPerMinute & DefaultLLMRateLimitBurst are the LLM generations each user can start per minute & in
+// a burst when LLM_RATE_LIMIT_PER_MINUTE & LLM_RATE_LIMIT_BURST aren't set
+const (
+	DefaultLLMRateLimitPerMinute = 10
+	DefaultLLMRateLimitBurst     = 5
+)
+
+// DefaultSharedResultMaxRows is the number of rows of a query result shared with the LLM when SHARED_RESULT_MAX_ROWS
+// isn't set, the result is part of the context of every following message of the chat
+const DefaultSharedResultMaxRows = 10
+
+// DefaultMaxGeneratedQueryLength is the number of characters above which a generated query is dropped when
+// MAX_GENERATED_QUERY_LENGTH isn't set
+const DefaultMaxGeneratedQueryLength = 20000
+
+// DefaultSchemaFullDetailTables is the number of most queried tables keeping their example records in the schema
+// shared with the LLM when SCHEMA_FULL_DETAIL_TABLES isn't set, the other tables of larger schemas are summarized
+const DefaultSchemaFullDetailTables = 30
+
+// DefaultSchemaChunkMaxChars is the size of the parts a schema is split into when SCHEMA_CHUNKING is on &
+// SCHEMA_CHUNK_MAX_CHARS isn't set, about 50k tokens
+const DefaultSchemaChunkMaxChars = 200000
+
+// DefaultSchemaChunkMaxCalls is the number of parts a schema can be split into when SCHEMA_CHUNK_MAX_CALLS isn't
+// set, each part costs an LLM call
+const DefaultSchemaChunkMaxCalls = 5
+
+// DefaultPageSize is the number of rows of a page of query results when the chat doesn't choose its own (see
+// models.ChatSettings.DefaultPageSize), MinPageSize & MaxPageSize bound the page size a chat can choose
 const (
-	OpenAI = "openai"
-	Gemini = "gemini"
+	DefaultPageSize = 50
+	MinPageSize     = 20
+	MaxPageSize     = 500
 )
 
+// PageSizePlaceholder is replaced by the page size of the chat in the system prompts & response schemas, the LLM
+// writes the LIMIT of the paginated queries with it
+const PageSizePlaceholder = "{{page_size}}"
+
+// ResolvePageSize returns the page size of a chat, DefaultPageSize when it didn't choose one
+func ResolvePageSize(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	return min(pageSize, MaxPageSize)
+}
+
+// RenderPageSize writes the page size of a chat in a system prompt or a response schema, see PageSizePlaceholder
+func RenderPageSize(text string, pageSize int) string {
+	return strings.ReplaceAll(text, PageSizePlaceholder, strconv.Itoa(ResolvePageSize(pageSize)))
+}
+
+// EmptyQueriesNudgePrompt is sent once when the user asked for data but the LLM didn't generate any query
+const EmptyQueriesNudgePrompt = `Your previous response did not include any query, but the user's request needs data from the database.
+Respond again in the same JSON format with at least one concrete query that answers the request using the available schema.
+If it is truly impossible to answer with a query (ex: the required tables or fields do not exist), return an empty queries array and clearly explain why in assistantMessage instead of asking a generic clarifying question.`
+
+// MissingJoinsPrompt is sent once when queries of a response use tables they don't join, the placeholder lists the
+// missing joins of each query
+const MissingJoinsPrompt = `Some queries of your previous response use tables that they don't read in any FROM or JOIN clause, their results would be incomplete or fail:
+%s
+
+Respond again in the same JSON format with the queries fixed to JOIN the tables they need, following the relationships of the schema.`
+
+// TypeCoercionsPrompt is sent once when queries of a response compare columns with literals of another type, the
+// placeholder lists the comparisons of each query
+const TypeCoercionsPrompt = `Some queries of your previous response compare columns with values of another type, the database converts them implicitly which can skip indexes (full scans) or match the wrong rows:
+%s
+
+Respond again in the same JSON format with the queries fixed to compare each column with a value of its own type, following the column types of the schema.`
+
+// AlternativeQueryPrompt is sent when the user asks to regenerate the queries of a response, the previous response is
+// sent before it
+const AlternativeQueryPrompt = `The user is not satisfied with the queries of your previous response, even though they may be valid.
+Respond again in the same JSON format with alternative queries answering the same request with a different approach: for example other tables, joins, filters, grouping or aggregation. Do not repeat the queries of your previous response, and explain briefly in assistantMessage how the new approach differs.`
+
+// MigrationRequestPrompt wraps a message describing the desired state of the schema, the placeholder is the
+// description of the user
+const MigrationRequestPrompt = `Generate the schema migration reaching the following desired state, starting from the current database schema:
+%s
+
+Respond with the DDL statements of the migration (ALTER TABLE, CREATE INDEX, CREATE TABLE...), one query per statement in the order they must run. Every query must have isCritical set to true, canRollback set to true & a rollbackQuery restoring the current state of the schema (ex: ALTER TABLE orders DROP COLUMN note for ALTER TABLE orders ADD COLUMN note TEXT). Only reference the tables & columns of the current schema or the ones created by the previous queries of the migration, and explain in assistantMessage what changes & whether existing rows are affected.`
+
+// MigrationObjectsPrompt is sent once when queries of a migration reference schema objects that don't exist, the
+// placeholder lists the problems of each query
+const MigrationObjectsPrompt = `Some queries of the migration in your previous response don't match the current schema, they would fail:
+%s
+
+Respond again in the same JSON format with the migration fixed to only alter, drop or index the tables & columns that exist, and to only create the ones that don't.`
+
+// EmptyResultSuggestionPrompt asks the LLM whether the filters of a query returning no rows are too narrow,
+// the placeholders are the query & the row counts with each filter removed
+const EmptyResultSuggestionPrompt = `The following query returned no rows:
+
+%s
+
+Row counts when removing one filter at a time:
+%s
+
+Explain briefly in assistantMessage whether the filters look too narrow or the data simply doesn't exist, and suggest how to adjust the query. Respond in the same JSON format with an empty queries array.`
+
+// ResultExplanationPrompt asks the LLM to explain the result of a query in plain English, the placeholders are the
+// query, the response it was generated in & its result (only its shape when the chat doesn't share data with AI)
+const ResultExplanationPrompt = `The following query was run:
+
+%s
+
+It was generated in this response: %s
+
+Its result:
+%s
+
+Explain in assistantMessage, in plain English for a reader who doesn't know the database, what this result means, then list the notable insights it shows (totals, trends, outliers) if any. Don't generate any query. Respond in the same JSON format with an empty queries array.`
+
+// SchemaChunkScopingPrompt asks the LLM which tables of a part of a schema too large to be shared at once a request
+// needs, the placeholders are the part & the number of parts
+const SchemaChunkScopingPrompt = `The database schema is too large to be shared at once, the schema above is only part %d of %d. Don't generate any query yet.
+List in assistantMessage the names of the tables of this part needed to answer the latest request of the user, exactly as written in the schema & separated by commas, or NONE if no table of this part is needed. Respond in the same JSON format with an empty queries array.`
+
+// DataRequestPrefixes are the ways a message asking for data usually starts, used to detect missing queries. Only the
+// start of the message is checked, words such as "show" or "which" are too common elsewhere to tell a data request apart.
+var DataRequestPrefixes = []string{
+	"list ", "get ", "fetch ", "count ", "find all ", "give me ", "display ", "show me ", "show all ", "how many ",
+	"how much ", "what is the total ", "what's the total ", "top ", "select ",
+}
+
+// DataRequestPolitePrefixes are stripped from the start of a message before DataRequestPrefixes are matched
+var DataRequestPolitePrefixes = []string{"please ", "can you ", "could you ", "would you "}
+
 func GetLLMResponseSchema(provider string, dbType string) interface{} {
 	switch provider {
-	case OpenAI:
+	case OpenAI, Anthropic:
 		switch dbType {
 		case DatabaseTypePostgreSQL:
 			return OpenAIPostgresLLMResponseSchema
@@ -15,10 +154,20 @@ func GetLLMResponseSchema(provider string, dbType string) interface{} {
 			return OpenAIYugabyteDBLLMResponseSchema
 		case DatabaseTypeMySQL:
 			return OpenAIMySQLLLMResponseSchema
+		case DatabaseTypeMariaDB:
+			return OpenAIMySQLLLMResponseSchema // Same SQL queries & rollbacks, the prompt describes RETURNING & sequences
 		case DatabaseTypeClickhouse:
 			return OpenAIClickhouseLLMResponseSchema
 		case DatabaseTypeMongoDB:
 			return OpenAIMongoDBLLMResponseSchema
+		case DatabaseTypeSQLite:
+			return OpenAIMySQLLLMResponseSchema // Same SQL queries & rollbacks
+		case DatabaseTypeBigQuery:
+			return OpenAIMySQLLLMResponseSchema // Same SQL queries, the prompt keeps canRollback false
+		case DatabaseTypeElasticsearch:
+			return OpenAIMongoDBLLMResponseSchema // Same structure, the prompt describes the Query DSL & keeps canRollback false
+		case DatabaseTypeCassandra:
+			return OpenAIMySQLLLMResponseSchema // Same queries & rollbacks, the prompt describes CQL & leaves the pagination empty
 		default:
 			return OpenAIPostgresLLMResponseSchema
 		}
@@ -30,10 +179,20 @@ func GetLLMResponseSchema(provider string, dbType string) interface{} {
 			return GeminiYugabyteDBLLMResponseSchema
 		case DatabaseTypeMySQL:
 			return GeminiMySQLLLMResponseSchema
+		case DatabaseTypeMariaDB:
+			return GeminiMySQLLLMResponseSchema // Same SQL queries & rollbacks, the prompt describes RETURNING & sequences
 		case DatabaseTypeClickhouse:
 			return GeminiClickhouseLLMResponseSchema
 		case DatabaseTypeMongoDB:
 			return GeminiMongoDBLLMResponseSchema
+		case DatabaseTypeSQLite:
+			return GeminiMySQLLLMResponseSchema // Same SQL queries & rollbacks
+		case DatabaseTypeBigQuery:
+			return GeminiMySQLLLMResponseSchema // Same SQL queries, the prompt keeps canRollback false
+		case DatabaseTypeElasticsearch:
+			return GeminiMongoDBLLMResponseSchema // Same structure, the prompt describes the Query DSL & keeps canRollback false
+		case DatabaseTypeCassandra:
+			return GeminiMySQLLLMResponseSchema // Same queries & rollbacks, the prompt describes CQL & leaves the pagination empty
 		default:
 			return GeminiPostgresLLMResponseSchema
 		}
@@ -44,18 +203,28 @@ func GetLLMResponseSchema(provider string, dbType string) interface{} {
 // GetSystemPrompt returns the appropriate system prompt based on database type
 func GetSystemPrompt(provider string, dbType string) string {
 	switch provider {
-	case OpenAI:
+	case OpenAI, Anthropic:
 		switch dbType {
 		case DatabaseTypePostgreSQL:
 			return OpenAIPostgreSQLPrompt
 		case DatabaseTypeMySQL:
 			return OpenAIMySQLPrompt
+		case DatabaseTypeMariaDB:
+			return OpenAIMariaDBPrompt
 		case DatabaseTypeYugabyteDB:
 			return OpenAIYugabyteDBPrompt
 		case DatabaseTypeClickhouse:
 			return OpenAIClickhousePrompt
 		case DatabaseTypeMongoDB:
 			return OpenAIMongoDBPrompt
+		case DatabaseTypeSQLite:
+			return OpenAISQLitePrompt
+		case DatabaseTypeBigQuery:
+			return OpenAIBigQueryPrompt
+		case DatabaseTypeElasticsearch:
+			return OpenAIElasticsearchPrompt
+		case DatabaseTypeCassandra:
+			return OpenAICassandraPrompt
 		default:
 			return OpenAIPostgreSQLPrompt // Default to PostgreSQL
 		}
@@ -67,10 +236,20 @@ func GetSystemPrompt(provider string, dbType string) string {
 			return GeminiYugabyteDBPrompt
 		case DatabaseTypeMySQL:
 			return GeminiMySQLPrompt
+		case DatabaseTypeMariaDB:
+			return GeminiMariaDBPrompt
 		case DatabaseTypeClickhouse:
 			return GeminiClickhousePrompt
 		case DatabaseTypeMongoDB:
 			return GeminiMongoDBPrompt
+		case DatabaseTypeSQLite:
+			return GeminiSQLitePrompt
+		case DatabaseTypeBigQuery:
+			return GeminiBigQueryPrompt
+		case DatabaseTypeElasticsearch:
+			return GeminiElasticsearchPrompt
+		case DatabaseTypeCassandra:
+			return GeminiCassandraPrompt
 		default:
 			return GeminiPostgreSQLPrompt // Default to PostgreSQL
 		}
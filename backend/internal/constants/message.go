@@ -7,3 +7,7 @@ const (
 	MessageTypeAssistant MessageType = "assistant"
 	MessageTypeSystem    MessageType = "system"
 )
+
+// DefaultMaxListPageSize is the largest page of chats or messages returned by the list endpoints when
+// MAX_LIST_PAGE_SIZE isn't set
+const DefaultMaxListPageSize = 100
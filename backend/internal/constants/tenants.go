@@ -0,0 +1,7 @@
+package constants
+
+// Resources of a tenant whose daily use is limited by its quotas, see config.Tenant
+const (
+	TenantUsageLLMCalls        = "llm_calls"
+	TenantUsageQueryExecutions = "query_executions"
+)
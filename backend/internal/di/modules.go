@@ -12,6 +12,7 @@ import (
 	"databot-ai/pkg/mongodb"
 	"databot-ai/pkg/redis"
 	"log"
+	"strings"
 	"time"
 
 	"go.uber.org/dig"
@@ -45,9 +46,13 @@ func Initialize() {
 
 	// Initialize token repository
 	tokenRepo := repositories.NewTokenRepository(redisRepo)
+	tenantUsageRepo := repositories.NewTenantUsageRepository(redisRepo)
+	rateLimitRepo := repositories.NewRateLimitRepository(redisRepo)
 
 	chatRepo := repositories.NewChatRepository(mongodbClient)
 	llmRepo := repositories.NewLLMMessageRepository(mongodbClient)
+	executionRepo := repositories.NewQueryExecutionRepository(mongodbClient)
+	auditLogRepo := repositories.NewAuditLogRepository(mongodbClient)
 
 	// Provide all dependencies to the container
 	if err := DiContainer.Provide(func() *mongodb.MongoDBClient { return mongodbClient }); err != nil {
@@ -70,6 +75,14 @@ func Initialize() {
 		log.Fatalf("Failed to provide LLM message repository: %v", err)
 	}
 
+	if err := DiContainer.Provide(func() repositories.QueryExecutionRepository { return executionRepo }); err != nil {
+		log.Fatalf("Failed to provide query execution repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func() repositories.AuditLogRepository { return auditLogRepo }); err != nil {
+		log.Fatalf("Failed to provide audit log repository: %v", err)
+	}
+
 	// Provide DB Manager
 	if err := DiContainer.Provide(func(redisRepo redis.IRedisRepositories) (*dbmanager.Manager, error) {
 		encryptionKey := config.Env.SchemaEncryptionKey
@@ -81,8 +94,19 @@ func Initialize() {
 		manager.RegisterDriver(constants.DatabaseTypePostgreSQL, dbmanager.NewPostgresDriver())
 		manager.RegisterDriver(constants.DatabaseTypeYugabyteDB, dbmanager.NewPostgresDriver()) // Use same driver for both
 		manager.RegisterDriver(constants.DatabaseTypeMySQL, dbmanager.NewMySQLDriver())
+		manager.RegisterDriver(constants.DatabaseTypeMariaDB, dbmanager.NewMySQLDriver()) // Use same driver for both
 		manager.RegisterDriver(constants.DatabaseTypeClickhouse, dbmanager.NewClickHouseDriver())
 		manager.RegisterDriver(constants.DatabaseTypeMongoDB, dbmanager.NewMongoDBDriver())
+		manager.RegisterDriver(constants.DatabaseTypeSQLite, dbmanager.NewSQLiteDriver(config.Env.SQLiteDataDir))
+		manager.SetMaxResultRows(config.Env.MaxQueryResultRows)
+		manager.SetMaxResultBytes(int64(config.Env.MaxQueryResultBytes))
+		manager.SetPaginationOrderInjection(config.Env.PaginationOrderByPrimaryKey)
+		manager.SetFullDetailTables(config.Env.SchemaFullDetailTables)
+		manager.SetLLMResponseCacheTTL(time.Duration(config.Env.LLMResponseCacheTTLMinutes) * time.Minute)
+		manager.SetQueryResultCacheTTL(time.Duration(config.Env.QueryResultCacheTTLSeconds) * time.Second)
+		if err := manager.SetPIIMasking(config.Env.PIIMaskExampleRecords, strings.Split(config.Env.PIIColumnPatterns, ",")); err != nil {
+			log.Fatalf("Failed to provide DB manager: %v", err)
+		}
 		return manager, nil
 	}); err != nil {
 		log.Fatalf("Failed to provide DB manager: %v", err)
@@ -98,16 +122,45 @@ func Initialize() {
 		log.Fatalf("Failed to provide token repository: %v", err)
 	}
 
+	if err := DiContainer.Provide(func() repositories.TenantUsageRepository { return tenantUsageRepo }); err != nil {
+		log.Fatalf("Failed to provide tenant usage repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(db *mongodb.MongoDBClient) repositories.APIKeyRepository {
+		return repositories.NewAPIKeyRepository(db)
+	}); err != nil {
+		log.Fatalf("Failed to provide API key repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(db *mongodb.MongoDBClient) repositories.SavedQueryRepository {
+		return repositories.NewSavedQueryRepository(db)
+	}); err != nil {
+		log.Fatalf("Failed to provide saved query repository: %v", err)
+	}
+
+	if err := DiContainer.Provide(func(db *mongodb.MongoDBClient) repositories.ScheduledQueryRepository {
+		return repositories.NewScheduledQueryRepository(db)
+	}); err != nil {
+		log.Fatalf("Failed to provide scheduled query repository: %v", err)
+	}
+
 	// Provide services
-	if err := DiContainer.Provide(func(userRepo repositories.UserRepository, tokenRepo repositories.TokenRepository, jwt utils.JWTService) services.AuthService {
-		return services.NewAuthService(userRepo, jwt, tokenRepo)
+	if err := DiContainer.Provide(func(userRepo repositories.UserRepository, tokenRepo repositories.TokenRepository, apiKeyRepo repositories.APIKeyRepository, jwt utils.JWTService) services.AuthService {
+		return services.NewAuthService(userRepo, jwt, tokenRepo, apiKeyRepo)
 	}); err != nil {
 		log.Fatalf("Failed to provide auth service: %v", err)
 	}
 
 	// Add LLM Manager
-	if err := DiContainer.Provide(func() *llm.Manager {
+	if err := DiContainer.Provide(func(tenantUsageRepo repositories.TenantUsageRepository) *llm.Manager {
 		manager := llm.NewManager()
+		manager.SetMaxConcurrentCalls(config.Env.MaxConcurrentLLMCalls)
+		if len(config.Env.Tenants) > 0 {
+			manager.SetTenants(services.LLMTenants())
+			manager.SetCallQuota(func(tenantID string) error {
+				return services.CountTenantUsage(tenantUsageRepo, tenantID, constants.TenantUsageLLMCalls)
+			})
+		}
 
 		switch config.Env.DefaultLLMClient {
 		case constants.OpenAI:
@@ -134,6 +187,11 @@ func Initialize() {
 						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeMySQL),
 						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeMySQL),
 					},
+					{
+						DBType:       constants.DatabaseTypeMariaDB,
+						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeMariaDB),
+						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeMariaDB),
+					},
 					{
 						DBType:       constants.DatabaseTypeClickhouse,
 						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeClickhouse),
@@ -144,6 +202,26 @@ func Initialize() {
 						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeMongoDB),
 						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeMongoDB),
 					},
+					{
+						DBType:       constants.DatabaseTypeSQLite,
+						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeSQLite),
+						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeSQLite),
+					},
+					{
+						DBType:       constants.DatabaseTypeBigQuery,
+						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeBigQuery),
+						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeBigQuery),
+					},
+					{
+						DBType:       constants.DatabaseTypeElasticsearch,
+						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeElasticsearch),
+						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeElasticsearch),
+					},
+					{
+						DBType:       constants.DatabaseTypeCassandra,
+						Schema:       constants.GetLLMResponseSchema(constants.OpenAI, constants.DatabaseTypeCassandra),
+						SystemPrompt: constants.GetSystemPrompt(constants.OpenAI, constants.DatabaseTypeCassandra),
+					},
 				},
 			})
 			if err != nil {
@@ -173,6 +251,11 @@ func Initialize() {
 						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeMySQL),
 						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeMySQL),
 					},
+					{
+						DBType:       constants.DatabaseTypeMariaDB,
+						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeMariaDB),
+						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeMariaDB),
+					},
 					{
 						DBType:       constants.DatabaseTypeClickhouse,
 						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeClickhouse),
@@ -183,11 +266,95 @@ func Initialize() {
 						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeMongoDB),
 						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeMongoDB),
 					},
+					{
+						DBType:       constants.DatabaseTypeSQLite,
+						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeSQLite),
+						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeSQLite),
+					},
+					{
+						DBType:       constants.DatabaseTypeBigQuery,
+						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeBigQuery),
+						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeBigQuery),
+					},
+					{
+						DBType:       constants.DatabaseTypeElasticsearch,
+						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeElasticsearch),
+						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeElasticsearch),
+					},
+					{
+						DBType:       constants.DatabaseTypeCassandra,
+						Schema:       constants.GetLLMResponseSchema(constants.Gemini, constants.DatabaseTypeCassandra),
+						SystemPrompt: constants.GetSystemPrompt(constants.Gemini, constants.DatabaseTypeCassandra),
+					},
 				},
 			})
 			if err != nil {
 				log.Printf("Warning: Failed to register Gemini client: %v", err)
 			}
+		case constants.Anthropic:
+			// Register default Anthropic client, it shares the prompts & response schemas of OpenAI
+			err := manager.RegisterClient(constants.Anthropic, llm.Config{
+				Provider:            constants.Anthropic,
+				Model:               config.Env.AnthropicModel,
+				APIKey:              config.Env.AnthropicAPIKey,
+				MaxCompletionTokens: config.Env.AnthropicMaxCompletionTokens,
+				Temperature:         config.Env.AnthropicTemperature,
+				DBConfigs: []llm.LLMDBConfig{
+					{
+						DBType:       constants.DatabaseTypePostgreSQL,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypePostgreSQL),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypePostgreSQL),
+					},
+					{
+						DBType:       constants.DatabaseTypeYugabyteDB,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeYugabyteDB),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeYugabyteDB),
+					},
+					{
+						DBType:       constants.DatabaseTypeMySQL,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeMySQL),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeMySQL),
+					},
+					{
+						DBType:       constants.DatabaseTypeMariaDB,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeMariaDB),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeMariaDB),
+					},
+					{
+						DBType:       constants.DatabaseTypeClickhouse,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeClickhouse),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeClickhouse),
+					},
+					{
+						DBType:       constants.DatabaseTypeMongoDB,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeMongoDB),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeMongoDB),
+					},
+					{
+						DBType:       constants.DatabaseTypeSQLite,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeSQLite),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeSQLite),
+					},
+					{
+						DBType:       constants.DatabaseTypeBigQuery,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeBigQuery),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeBigQuery),
+					},
+					{
+						DBType:       constants.DatabaseTypeElasticsearch,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeElasticsearch),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeElasticsearch),
+					},
+					{
+						DBType:       constants.DatabaseTypeCassandra,
+						Schema:       constants.GetLLMResponseSchema(constants.Anthropic, constants.DatabaseTypeCassandra),
+						SystemPrompt: constants.GetSystemPrompt(constants.Anthropic, constants.DatabaseTypeCassandra),
+					},
+				},
+			})
+			if err != nil {
+				log.Printf("Warning: Failed to register Anthropic client: %v", err)
+			}
 		}
 		return manager
 	}); err != nil {
@@ -198,8 +365,14 @@ func Initialize() {
 	if err := DiContainer.Provide(func(
 		chatRepo repositories.ChatRepository,
 		llmRepo repositories.LLMMessageRepository,
+		executionRepo repositories.QueryExecutionRepository,
 		dbManager *dbmanager.Manager,
 		llmManager *llm.Manager,
+		userRepo repositories.UserRepository,
+		tenantUsageRepo repositories.TenantUsageRepository,
+		savedQueryRepo repositories.SavedQueryRepository,
+		scheduledQueryRepo repositories.ScheduledQueryRepository,
+		auditLogRepo repositories.AuditLogRepository,
 	) services.ChatService {
 		// Get default LLM client
 		llmClient, err := llmManager.GetClient(config.Env.DefaultLLMClient)
@@ -207,7 +380,7 @@ func Initialize() {
 			log.Printf("Warning: Failed to get default LLM client: %v", err)
 		}
 
-		chatService := services.NewChatService(chatRepo, llmRepo, dbManager, llmClient)
+		chatService := services.NewChatService(chatRepo, llmRepo, executionRepo, dbManager, llmClient, userRepo, tenantUsageRepo, rateLimitRepo, savedQueryRepo, scheduledQueryRepo, auditLogRepo)
 
 		// Set chat service as stream handler for DB manager
 		dbManager.SetStreamHandler(chatService)
@@ -241,6 +414,22 @@ func Initialize() {
 	}); err != nil {
 		log.Fatalf("Failed to provide chat handler: %v", err)
 	}
+
+	// Write the audit log in the background, the requests only queue its entries
+	if err := DiContainer.Invoke(func(chatService services.ChatService) {
+		chatService.StartAuditLogWriter()
+	}); err != nil {
+		log.Fatalf("Failed to start the audit log writer: %v", err)
+	}
+
+	// Run the scheduled queries in the background
+	if config.Env.ScheduledQueriesEnabled {
+		if err := DiContainer.Invoke(func(chatService services.ChatService) {
+			chatService.StartQueryScheduler()
+		}); err != nil {
+			log.Fatalf("Failed to start the query scheduler: %v", err)
+		}
+	}
 }
 
 // GetAuthHandler retrieves the AuthHandler from the DI container
@@ -266,3 +455,27 @@ func GetChatHandler() (*handlers.ChatHandler, error) {
 	}
 	return handler, nil
 }
+
+// GetLLMManager retrieves the LLM Manager from the DI container
+func GetLLMManager() (*llm.Manager, error) {
+	var manager *llm.Manager
+	err := DiContainer.Invoke(func(m *llm.Manager) {
+		manager = m
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// GetDBManager retrieves the DB Manager from the DI container
+func GetDBManager() (*dbmanager.Manager, error) {
+	var manager *dbmanager.Manager
+	err := DiContainer.Invoke(func(m *dbmanager.Manager) {
+		manager = m
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
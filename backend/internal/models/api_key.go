@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey authenticates the scripts of a user, only the hash of the key is stored
+type APIKey struct {
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	KeyPrefix  string             `bson:"key_prefix" json:"key_prefix"` // First characters of the key, to tell keys apart
+	KeyHash    string             `bson:"key_hash" json:"-"`            // SHA-256 of the key
+	Scope      string             `bson:"scope" json:"scope"`           // constants.APIKeyScopeRead or constants.APIKeyScopeFull
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	Base       `bson:",inline"`
+}
+
+func NewAPIKey(userID primitive.ObjectID, name, keyPrefix, keyHash, scope string) *APIKey {
+	return &APIKey{
+		UserID:    userID,
+		Name:      name,
+		KeyPrefix: keyPrefix,
+		KeyHash:   keyHash,
+		Scope:     scope,
+		Base:      NewBase(),
+	}
+}
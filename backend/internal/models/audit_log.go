@@ -0,0 +1,57 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLog records a schema refresh, LLM generation, query execution or rollback of a chat. The entries are never
+// updated or deleted, each one holds the hash of the previous entry so that a changed or removed entry breaks the chain.
+type AuditLog struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	ChatID    primitive.ObjectID     `bson:"chat_id" json:"chat_id"`
+	Action    string                 `bson:"action" json:"action"` // One of the constants.AuditAction
+	SourceIP  string                 `bson:"source_ip" json:"source_ip"`
+	Details   map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"` // IDs of the message & queries, error of the call
+	Timestamp time.Time              `bson:"timestamp" json:"timestamp"`
+	PrevHash  string                 `bson:"prev_hash" json:"prev_hash"` // Hash of the entry written before, empty for the first one
+	Hash      string                 `bson:"hash" json:"hash"`
+}
+
+func NewAuditLog(userID, chatID primitive.ObjectID, action, sourceIP string, details map[string]interface{}) *AuditLog {
+	return &AuditLog{
+		ID:       primitive.NewObjectID(),
+		UserID:   userID,
+		ChatID:   chatID,
+		Action:   action,
+		SourceIP: sourceIP,
+		Details:  details,
+		// Stored by MongoDB with a millisecond precision, the hash must be computed from the stored time
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// ComputeHash hashes the entry chained to the hash of the previous one, see AuditLog
+func (l *AuditLog) ComputeHash() string {
+	details, _ := json.Marshal(l.Details)
+	sum := sha256.New()
+	for _, field := range []string{
+		l.PrevHash,
+		l.ID.Hex(),
+		l.UserID.Hex(),
+		l.ChatID.Hex(),
+		l.Action,
+		l.SourceIP,
+		string(details),
+		l.Timestamp.UTC().Format(time.RFC3339Nano),
+	} {
+		sum.Write([]byte(field))
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -1,22 +1,60 @@
 package models
 
 import (
+	"strings"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ChatSettings struct {
 	AutoExecuteQuery bool `bson:"auto_execute_query" json:"auto_execute_query,omitempty"` // default is false, Execute query automatically when LLM response is received
 	ShareDataWithAI  bool `bson:"share_data_with_ai" json:"share_data_with_ai,omitempty"` // default is false, Don't share data with AI
+
+	// ColumnMasks maps "column" or "table.column" to a masking format (ex: "last4", "email_domain", "partial:2:2", "***-##-####")
+	// Applied to example records & results shared with AI
+	ColumnMasks map[string]string `bson:"column_masks,omitempty" json:"column_masks,omitempty"`
+
+	// SessionMode holds a dedicated connection so that temp tables & session variables persist across queries (SQL databases only)
+	SessionMode bool `bson:"session_mode" json:"session_mode,omitempty"` // default is false, Every query runs on any pooled connection
+
+	// AnonymizeSchema replaces table & column names with opaque tokens in everything sent to the LLM, see Chat.SchemaAliases
+	AnonymizeSchema bool `bson:"anonymize_schema" json:"anonymize_schema,omitempty"` // default is false, Real names are sent to the LLM
+
+	// GenerateOnly never connects to the database, queries are generated from Chat.ImportedSchema & never executed
+	GenerateOnly bool `bson:"generate_only" json:"generate_only,omitempty"` // default is false, The chat connects & executes queries
+
+	// DisableExampleRecords builds the schema without fetching example records, none are sent to the LLM
+	DisableExampleRecords bool `bson:"disable_example_records" json:"disable_example_records,omitempty"` // default is false, A few rows of every table are shared
+
+	// TextCollation is the collation the generated queries compare text with (ex: utf8mb4_0900_ai_ci, or a locale for MongoDB)
+	TextCollation string `bson:"text_collation,omitempty" json:"text_collation,omitempty"` // default is "", Columns compare with their own collation
+
+	// ReadOnly only executes queries reading data, checked on the query itself rather than on the generated isCritical flag
+	ReadOnly bool `bson:"read_only" json:"read_only,omitempty"` // default is false, Every query can be executed
+
+	// DisableResponseCache always calls the LLM, even when a response was generated for the same history & schema
+	DisableResponseCache bool `bson:"disable_response_cache" json:"disable_response_cache,omitempty"` // default is false, Identical requests reuse the cached response
+
+	// DefaultPageSize is the number of rows of a page of query results, the LIMIT of the paginated queries
+	DefaultPageSize int `bson:"default_page_size,omitempty" json:"default_page_size,omitempty"` // default is 0, constants.DefaultPageSize rows
+
+	// QueryTimeoutSeconds is the time a query can run before it fails with QUERY_TIMEOUT
+	QueryTimeoutSeconds int `bson:"query_timeout_seconds,omitempty" json:"query_timeout_seconds,omitempty"` // default is 0, 3 times the response time estimated by the LLM (at least 30s)
+
+	// SchemaRefreshWebhookURL is notified with a POST when a schema refresh of the chat completes, see notifySchemaRefreshWebhook
+	SchemaRefreshWebhookURL string `bson:"schema_refresh_webhook_url,omitempty" json:"schema_refresh_webhook_url,omitempty"` // default is "", No notification
 }
 
 type Connection struct {
-	Type        string  `bson:"type" json:"type"`
-	Host        string  `bson:"host" json:"host"`
-	Port        *string `bson:"port" json:"port"`
-	Username    *string `bson:"username" json:"username"`
-	Password    *string `bson:"password" json:"-"` // Hide in JSON
-	Database    string  `bson:"database" json:"database"`
-	IsExampleDB bool    `bson:"is_example_db" json:"is_example_db"` // default is false, if true, then the database is an example database configs setup from environment variables
+	Type        string   `bson:"type" json:"type"`
+	Host        string   `bson:"host" json:"host"`
+	Hosts       []string `bson:"hosts,omitempty" json:"hosts,omitempty"`   // Failover hosts of a cluster, tried after Host
+	Shards      []string `bson:"shards,omitempty" json:"shards,omitempty"` // Other shards holding the same tables, queried with the same credentials
+	Port        *string  `bson:"port" json:"port"`
+	Username    *string  `bson:"username" json:"username"`
+	Password    *string  `bson:"password" json:"-"` // Hide in JSON
+	Database    string   `bson:"database" json:"database"`
+	IsExampleDB bool     `bson:"is_example_db" json:"is_example_db"` // default is false, if true, then the database is an example database configs setup from environment variables
 
 	// SSL/TLS Configuration
 	UseSSL         bool    `bson:"use_ssl" json:"use_ssl"`
@@ -25,14 +63,87 @@ type Connection struct {
 	SSLKeyURL      *string `bson:"ssl_key_url,omitempty" json:"ssl_key_url,omitempty"`
 	SSLRootCertURL *string `bson:"ssl_root_cert_url,omitempty" json:"ssl_root_cert_url,omitempty"`
 
+	// MaxResultRows is the number of rows the database returns for a read query at most, the rows past it never leave
+	// the database. 0 only applies the row limit of the server (MAX_QUERY_RESULT_ROWS).
+	MaxResultRows int `bson:"max_result_rows,omitempty" json:"max_result_rows,omitempty"`
+
+	// Role is the PostgreSQL/YugabyteDB role the queries run as (SET ROLE after connecting), empty runs them as the user
+	Role string `bson:"role,omitempty" json:"role,omitempty"`
+
+	// FilePath is the SQLite database file, relative to SQLITE_DATA_DIR, SQLite connections have no host & port
+	FilePath string `bson:"file_path,omitempty" json:"file_path,omitempty"`
+
+	// ProjectID & CredentialsJSON (service account key) authenticate a BigQuery connection, its Database is the dataset
+	ProjectID       string `bson:"project_id,omitempty" json:"project_id,omitempty"`
+	CredentialsJSON string `bson:"credentials_json,omitempty" json:"-"` // Hide in JSON
+
+	// Connection pool of the chat, 0 uses the default (10 open, 5 idle, 30 minutes lifetime)
+	MaxOpenConns    int `bson:"max_open_conns,omitempty" json:"max_open_conns,omitempty"`
+	MaxIdleConns    int `bson:"max_idle_conns,omitempty" json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime int `bson:"conn_max_lifetime,omitempty" json:"conn_max_lifetime,omitempty"` // in seconds
+
+	// SSH bastion the database is reached through, the host & port of the database are resolved by the bastion
+	SSHHost       string `bson:"ssh_host,omitempty" json:"ssh_host,omitempty"`
+	SSHPort       string `bson:"ssh_port,omitempty" json:"ssh_port,omitempty"`
+	SSHUser       string `bson:"ssh_user,omitempty" json:"ssh_user,omitempty"`
+	SSHPrivateKey string `bson:"ssh_private_key,omitempty" json:"-"` // Hide in JSON
+	SSHHostKey    string `bson:"ssh_host_key,omitempty" json:"ssh_host_key,omitempty"`
+
 	Base `bson:",inline"`
 }
 
+// NamedConnection is another database of a chat next to its main connection, ex: a replica or an analytics database
+// compared with the main one. It has the type of the main connection, the queries running on it hold its name.
+type NamedConnection struct {
+	Name       string     `bson:"name" json:"name"`
+	Connection Connection `bson:"connection" json:"connection"`
+}
+
+// ExportDestination is an object storage bucket where large query results are exported, credentials are stored encrypted
+type ExportDestination struct {
+	Provider        string `bson:"provider" json:"provider"` // s3, gcs
+	Bucket          string `bson:"bucket" json:"bucket"`
+	Region          string `bson:"region,omitempty" json:"region,omitempty"`
+	Endpoint        string `bson:"endpoint,omitempty" json:"endpoint,omitempty"` // For S3 compatible storages
+	Prefix          string `bson:"prefix,omitempty" json:"prefix,omitempty"`     // Key prefix of the exported objects
+	AccessKeyID     string `bson:"access_key_id" json:"-"`
+	SecretAccessKey string `bson:"secret_access_key" json:"-"`
+}
+
+// QueryTemplate is a reusable CTE of the connection, shared with the LLM & optionally prepended to the generated queries referencing it
+type QueryTemplate struct {
+	Name        string `bson:"name" json:"name"`
+	Query       string `bson:"query" json:"query"` // The SELECT defining the CTE
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	AutoPrepend bool   `bson:"auto_prepend" json:"auto_prepend"` // Add the definition to queries referencing the template by name
+}
+
+// SchemaDescription is the business meaning of a table, or of one of its columns if Column is set, written by the user.
+// It is layered over the comments of the fetched schema so that it survives schema refreshes.
+type SchemaDescription struct {
+	Table       string `bson:"table" json:"table"`
+	Column      string `bson:"column,omitempty" json:"column,omitempty"`
+	Description string `bson:"description" json:"description"`
+}
+
+// SchemaAlias is the opaque token a schema name is replaced with before reaching the LLM, stored so that tokens stay stable
+type SchemaAlias struct {
+	Name  string `bson:"name" json:"name"`
+	Token string `bson:"token" json:"token"`
+}
+
 type Chat struct {
-	UserID              primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Connection          Connection         `bson:"connection" json:"connection"`
-	SelectedCollections string             `bson:"selected_collections" json:"selected_collections"` // "ALL" or comma-separated table names
-	Settings            ChatSettings       `bson:"settings" json:"settings"`
+	UserID              primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Connection          Connection          `bson:"connection" json:"connection"`
+	Connections         []NamedConnection   `bson:"connections,omitempty" json:"connections,omitempty"`
+	SelectedCollections string              `bson:"selected_collections" json:"selected_collections"` // "ALL" or comma-separated table names
+	Settings            ChatSettings        `bson:"settings" json:"settings"`
+	ExportDestination   *ExportDestination  `bson:"export_destination,omitempty" json:"export_destination,omitempty"`
+	QueryTemplates      []QueryTemplate     `bson:"query_templates,omitempty" json:"query_templates,omitempty"`
+	SchemaDescriptions  []SchemaDescription `bson:"schema_descriptions,omitempty" json:"schema_descriptions,omitempty"`
+	SchemaAliases       []SchemaAlias       `bson:"schema_aliases,omitempty" json:"-"`      // Tokens of the schema names when AnonymizeSchema is enabled
+	ImportedSchema      string              `bson:"imported_schema,omitempty" json:"-"`     // Schema supplied by the user (ex: DDL), shared with the LLM in GenerateOnly mode
+	TableAccessCounts   map[string]int64    `bson:"table_access_counts,omitempty" json:"-"` // Generated queries using each table, keyed by TableAccessKey
 	Base                `bson:",inline"`
 }
 
@@ -50,5 +161,40 @@ func DefaultChatSettings() ChatSettings {
 	return ChatSettings{
 		AutoExecuteQuery: true,  // default is true, Execute query automatically when LLM response is received
 		ShareDataWithAI:  false, // default is false, Don't share data with AI
+		SessionMode:      false, // default is false, Don't hold a dedicated connection across queries
+		AnonymizeSchema:  false, // default is false, Send the real schema names to the LLM
+		GenerateOnly:     false, // default is false, Connect to the database & execute queries
+
+		DisableExampleRecords: false, // default is false, Share a few example rows of every table with the LLM
+		ReadOnly:              false, // default is false, Execute queries changing data too
+		DisableResponseCache:  false, // default is false, Reuse the LLM response of an identical request
+	}
+}
+
+// tableAccessDot replaces the dots of table names (ex: schema.table) in the keys of Chat.TableAccessCounts, MongoDB
+// reads a dot in a field path as a nested field
+const tableAccessDot = "．"
+
+// TableAccessKey returns the key of a table in Chat.TableAccessCounts
+func TableAccessKey(table string) string {
+	return strings.ReplaceAll(table, ".", tableAccessDot)
+}
+
+// TableAccessCountsByTable returns the access counts of the chat keyed by the real table names
+func (c *Chat) TableAccessCountsByTable() map[string]int64 {
+	counts := make(map[string]int64, len(c.TableAccessCounts))
+	for key, count := range c.TableAccessCounts {
+		counts[strings.ReplaceAll(key, tableAccessDot, ".")] = count
+	}
+	return counts
+}
+
+// NamedConnection returns the named connection of the chat, nil if the chat has none with this name
+func (c *Chat) NamedConnection(name string) *NamedConnection {
+	for i := range c.Connections {
+		if c.Connections[i].Name == name {
+			return &c.Connections[i]
+		}
 	}
+	return nil
 }
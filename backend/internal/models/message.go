@@ -15,15 +15,33 @@ type Message struct {
 	IsEdited      bool                `bson:"is_edited" json:"is_edited"` // if the message content has been edited, only for user messages
 	Queries       *[]Query            `bson:"queries,omitempty" json:"queries,omitempty"`
 	ActionButtons *[]ActionButton     `bson:"action_buttons,omitempty" json:"action_buttons,omitempty"` // UI action buttons suggested by the LLM
-	Base          `bson:",inline"`
+	// Values the LLM needs from the user, the queries hold {{name}} placeholders until they are supplied
+	ParameterRequests *[]ParameterRequest `bson:"parameter_requests,omitempty" json:"parameter_requests,omitempty"`
+	// NeedsClarification is set on assistant messages asking the user a clarification question instead of answering
+	NeedsClarification bool `bson:"needs_clarification,omitempty" json:"needs_clarification,omitempty"`
+	// Confidence of the LLM in its response, see the constants.Confidence levels, empty when the LLM didn't send it
+	Confidence string `bson:"confidence,omitempty" json:"confidence,omitempty"`
+	// IsMigration is set on user messages describing the desired state of the schema, see constants.MigrationRequestPrompt
+	IsMigration bool `bson:"is_migration,omitempty" json:"is_migration,omitempty"`
+	Base        `bson:",inline"`
 }
 
 // ActionButton represents a UI action button that can be suggested by the LLM
 type ActionButton struct {
-	ID        primitive.ObjectID `bson:"id" json:"id"`
-	Label     string             `bson:"label" json:"label"`          // Display text for the button
-	Action    string             `bson:"action" json:"action"`        // Action identifier (e.g., "refresh_schema", "show_tables")
-	IsPrimary bool               `bson:"is_primary" json:"isPrimary"` // Whether this is a primary (highlighted) action
+	ID        primitive.ObjectID     `bson:"id" json:"id"`
+	Label     string                 `bson:"label" json:"label"`                         // Display text for the button
+	Action    string                 `bson:"action" json:"action"`                       // Action identifier (e.g., "refresh_schema", "show_tables")
+	IsPrimary bool                   `bson:"is_primary" json:"isPrimary"`                // Whether this is a primary (highlighted) action
+	Payload   map[string]interface{} `bson:"payload,omitempty" json:"payload,omitempty"` // Context the action needs, see the constants.ActionPayload keys
+}
+
+// ParameterRequest is a value the LLM asked the user for, it is filled in the {{Name}} placeholders of the queries
+type ParameterRequest struct {
+	Name        string  `bson:"name" json:"name"`
+	Label       string  `bson:"label" json:"label"`                       // Display text of the form field
+	Type        string  `bson:"type" json:"type"`                         // string, number, boolean or date
+	Description string  `bson:"description,omitempty" json:"description"` // Hint shown under the form field
+	Value       *string `bson:"value,omitempty" json:"value,omitempty"`   // Set once the user supplied it
 }
 
 type Query struct {
@@ -42,11 +60,33 @@ type Query struct {
 	IsExecuted             bool               `bson:"is_executed" json:"is_executed"`       // if the query has been executed
 	IsRolledBack           bool               `bson:"is_rolled_back" json:"is_rolled_back"` // if the query has been rolled back
 	Error                  *QueryError        `bson:"error,omitempty" json:"error,omitempty"`
-	ExampleResult          *string            `bson:"example_result,omitempty" json:"example_result,omitempty"`     // JSON string
-	ExecutionResult        *string            `bson:"execution_result,omitempty" json:"execution_result,omitempty"` // JSON string
-	IsEdited               bool               `bson:"is_edited" json:"is_edited"`                                   // if the query has been edited
-	Metadata               *string            `bson:"metadata,omitempty" json:"metadata,omitempty"`                 // JSON string for database-specific metadata (e.g., ClickHouse engine type)
-	ActionAt               *string            `bson:"action_at,omitempty" json:"action_at,omitempty"`               // The timestamp when the action was taken
+	ExampleResult          *string            `bson:"example_result,omitempty" json:"example_result,omitempty"`       // JSON string
+	ExecutionResult        *string            `bson:"execution_result,omitempty" json:"execution_result,omitempty"`   // JSON string
+	IsEdited               bool               `bson:"is_edited" json:"is_edited"`                                     // if the query has been edited
+	Metadata               *string            `bson:"metadata,omitempty" json:"metadata,omitempty"`                   // JSON string for database-specific metadata (e.g., ClickHouse engine type)
+	ActionAt               *string            `bson:"action_at,omitempty" json:"action_at,omitempty"`                 // The timestamp when the action was taken
+	GeneratedAt            *string            `bson:"generated_at,omitempty" json:"generated_at,omitempty"`           // The timestamp when the LLM generated the query
+	Warnings               []string           `bson:"warnings,omitempty" json:"warnings,omitempty"`                   // Warnings raised by the database during the last execution
+	NearDuplicateOf        *string            `bson:"near_duplicate_of,omitempty" json:"near_duplicate_of,omitempty"` // ID of an earlier query of the message only differing by its quoting or LIMIT
+	Note                   *string            `bson:"note,omitempty" json:"note,omitempty"`                           // Free text the user attached to the query, never sent to the LLM
+	NotedAt                *string            `bson:"noted_at,omitempty" json:"noted_at,omitempty"`                   // The timestamp when the note was last changed
+	ComplexityScore        *string            `bson:"complexity_score,omitempty" json:"complexity_score,omitempty"`   // low, medium or high, see dbmanager.ScoreQueryComplexity
+	ComplexityReasons      []string           `bson:"complexity_reasons,omitempty" json:"complexity_reasons,omitempty"`
+	IsFavorite             bool               `bson:"is_favorite,omitempty" json:"is_favorite,omitempty"`         // Pinned by the user, listed across the chats of the user
+	FavoritedAt            *string            `bson:"favorited_at,omitempty" json:"favorited_at,omitempty"`       // The timestamp when the query was pinned
+	ConnectionName         string             `bson:"connection_name,omitempty" json:"connection_name,omitempty"` // Named connection the query runs on, empty for the main connection of the chat
+	SuggestedIndexes       []SuggestedIndex   `bson:"suggested_indexes,omitempty" json:"suggested_indexes,omitempty"`
+}
+
+// SuggestedIndex is an index the LLM proposed for a read query scanning a large table, see dbmanager.CheckSuggestedIndex
+type SuggestedIndex struct {
+	ID             primitive.ObjectID `bson:"id" json:"id"`
+	Table          string             `bson:"table" json:"table"`
+	Columns        []string           `bson:"columns" json:"columns"`
+	Query          string             `bson:"query" json:"query"`                                           // CREATE INDEX statement
+	RollbackQuery  string             `bson:"rollback_query,omitempty" json:"rollback_query,omitempty"`     // DROP INDEX statement
+	Reason         string             `bson:"reason,omitempty" json:"reason,omitempty"`                     // Why the index speeds up the query
+	CreatedQueryID *string            `bson:"created_query_id,omitempty" json:"created_query_id,omitempty"` // ID of the query of the message running the statement, once the user asked to create the index
 }
 
 type QueryError struct {
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QueryExecution records an execution or a rollback of a query of a chat, it is kept when the query is edited or its
+// message deleted
+type QueryExecution struct {
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ChatID        primitive.ObjectID `bson:"chat_id" json:"chat_id"`
+	MessageID     primitive.ObjectID `bson:"message_id" json:"message_id"`
+	QueryID       primitive.ObjectID `bson:"query_id" json:"query_id"`
+	Query         string             `bson:"query" json:"query"`                   // The query run, the rollback query for a rollback
+	QueryType     *string            `bson:"query_type" json:"query_type"`         // SELECT, INSERT, UPDATE, DELETE...
+	IsRollback    bool               `bson:"is_rollback" json:"is_rollback"`       // Set for RollbackQuery, unset for ExecuteQuery
+	IsPreview     bool               `bson:"is_preview" json:"is_preview"`         // Only a few sample rows were fetched
+	ExecutionTime *int               `bson:"execution_time" json:"execution_time"` // in milliseconds
+	RowCount      *int               `bson:"row_count" json:"row_count"`           // Rows returned or affected, nil if unknown
+	Error         *QueryError        `bson:"error,omitempty" json:"error,omitempty"`
+	Base          `bson:",inline"`
+}
+
+func NewQueryExecution(userID, chatID, messageID, queryID primitive.ObjectID, query string, queryType *string, isRollback bool) *QueryExecution {
+	return &QueryExecution{
+		UserID:     userID,
+		ChatID:     chatID,
+		MessageID:  messageID,
+		QueryID:    queryID,
+		Query:      query,
+		QueryType:  queryType,
+		IsRollback: isRollback,
+		Base:       NewBase(),
+	}
+}
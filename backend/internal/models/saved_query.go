@@ -0,0 +1,31 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedQuery is a query a user keeps to run again on their chats, the values of its {{name}} placeholders are
+// supplied on each execution. Not to be confused with the QueryTemplates of a chat, which are CTEs given to the LLM.
+type SavedQuery struct {
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name           string             `bson:"name" json:"name"`
+	Description    string             `bson:"description,omitempty" json:"description"`
+	Query          string             `bson:"query" json:"query"`
+	Pagination     *Pagination        `bson:"pagination,omitempty" json:"pagination,omitempty"` // Paginated & count queries, with the same placeholders
+	Parameters     []ParameterRequest `bson:"parameters" json:"parameters"`                     // Without values, they are supplied on execution
+	ConnectionType *string            `bson:"connection_type,omitempty" json:"connection_type,omitempty"`
+	Base           `bson:",inline"`
+}
+
+func NewSavedQuery(userID primitive.ObjectID, name, description, query string, pagination *Pagination, parameters []ParameterRequest, connectionType *string) *SavedQuery {
+	return &SavedQuery{
+		UserID:         userID,
+		Name:           name,
+		Description:    description,
+		Query:          query,
+		Pagination:     pagination,
+		Parameters:     parameters,
+		ConnectionType: connectionType,
+		Base:           NewBase(),
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledQuery is a read query of a chat executed on a cron schedule, ex: a daily metric refresh. Each run adds the
+// query to the chat in a message of its own holding the result, which can also be posted to a webhook.
+type ScheduledQuery struct {
+	UserID         primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	ChatID         primitive.ObjectID  `bson:"chat_id" json:"chat_id"`
+	Name           string              `bson:"name" json:"name"`
+	Query          string              `bson:"query" json:"query"`
+	QueryType      *string             `bson:"query_type,omitempty" json:"query_type,omitempty"` // SELECT, FIND, AGGREGATE...
+	Pagination     *Pagination         `bson:"pagination,omitempty" json:"pagination,omitempty"` // Paginated & count queries, the result of a run is paginated like in the chat
+	CronExpression string              `bson:"cron_expression" json:"cron_expression"`           // 5 fields, see utils.ParseCron
+	Timezone       string              `bson:"timezone" json:"timezone"`                         // IANA name the cron expression is read in, ex: Europe/Paris
+	Enabled        bool                `bson:"enabled" json:"enabled"`
+	WebhookURL     string              `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"` // Notified with the result of each run, empty disables it
+	NextRunAt      *time.Time          `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"` // Unset while disabled
+	LastRunAt      *time.Time          `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastRunError   *string             `bson:"last_run_error,omitempty" json:"last_run_error,omitempty"`   // Unset when the last run succeeded
+	LastMessageID  *primitive.ObjectID `bson:"last_message_id,omitempty" json:"last_message_id,omitempty"` // Message holding the result of the last run
+	Base           `bson:",inline"`
+}
+
+func NewScheduledQuery(userID, chatID primitive.ObjectID, name, query string, queryType *string, pagination *Pagination, cronExpression, timezone, webhookURL string, enabled bool) *ScheduledQuery {
+	return &ScheduledQuery{
+		UserID:         userID,
+		ChatID:         chatID,
+		Name:           name,
+		Query:          query,
+		QueryType:      queryType,
+		Pagination:     pagination,
+		CronExpression: cronExpression,
+		Timezone:       timezone,
+		Enabled:        enabled,
+		WebhookURL:     webhookURL,
+		Base:           NewBase(),
+	}
+}
@@ -3,7 +3,10 @@ package models
 type User struct {
 	Username string `bson:"username" json:"username"`
 	Password string `bson:"password" json:"-"`
-	Base     `bson:",inline"`
+	TenantID string `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"` // Tenant of the user in a deployment shared by organizations, see config.Tenant
+	// LLM generations the user can start per minute instead of LLM_RATE_LIMIT_PER_MINUTE, 0 doesn't limit the user
+	LLMRateLimitPerMinute *int `bson:"llm_rate_limit_per_minute,omitempty" json:"llm_rate_limit_per_minute,omitempty"`
+	Base                  `bson:",inline"`
 }
 
 func NewUser(username, password string) *User {
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/mongodb"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository interface {
+	Create(apiKey *models.APIKey) error
+	FindByHash(keyHash string) (*models.APIKey, error)
+	FindByUserID(userID primitive.ObjectID) ([]*models.APIKey, error)
+	CountActiveByUserID(userID primitive.ObjectID) (int64, error)
+	Revoke(userID, keyID primitive.ObjectID) (bool, error)
+	UpdateLastUsed(keyID primitive.ObjectID, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	apiKeyCollection *mongo.Collection
+}
+
+func NewAPIKeyRepository(mongoClient *mongodb.MongoDBClient) APIKeyRepository {
+	return &apiKeyRepository{
+		apiKeyCollection: mongoClient.GetCollectionByName("apiKeys"),
+	}
+}
+
+func (r *apiKeyRepository) Create(apiKey *models.APIKey) error {
+	_, err := r.apiKeyCollection.InsertOne(context.Background(), apiKey)
+	return err
+}
+
+// FindByHash returns the key with the given hash, revoked keys included, nil if there is none
+func (r *apiKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := r.apiKeyCollection.FindOne(context.Background(), bson.M{"key_hash": keyHash}).Decode(&apiKey)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// FindByUserID returns the keys of a user that aren't revoked, newest first
+func (r *apiKeyRepository) FindByUserID(userID primitive.ObjectID) ([]*models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.apiKeyCollection.Find(context.Background(), bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	apiKeys := make([]*models.APIKey, 0)
+	if err := cursor.All(context.Background(), &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) CountActiveByUserID(userID primitive.ObjectID) (int64, error) {
+	return r.apiKeyCollection.CountDocuments(context.Background(), bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}})
+}
+
+// Revoke marks a key of the user as revoked, false is returned if the user has no such active key
+func (r *apiKeyRepository) Revoke(userID, keyID primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	result, err := r.apiKeyCollection.UpdateOne(context.Background(),
+		bson.M{"_id": keyID, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(keyID primitive.ObjectID, usedAt time.Time) error {
+	_, err := r.apiKeyCollection.UpdateOne(context.Background(), bson.M{"_id": keyID}, bson.M{"$set": bson.M{"last_used_at": usedAt}})
+	return err
+}
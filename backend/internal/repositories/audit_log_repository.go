@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/mongodb"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogRepository is append only, the entries of the audit log are never updated or deleted
+type AuditLogRepository interface {
+	Create(entry *models.AuditLog) error
+	FindLast() (*models.AuditLog, error)
+	Find(filter AuditLogFilter, limit, offset int) ([]*models.AuditLog, int64, error)
+}
+
+// AuditLogFilter narrows the audit log to a user & a time range, the zero values don't filter
+type AuditLogFilter struct {
+	UserID *primitive.ObjectID
+	From   *time.Time
+	To     *time.Time
+}
+
+type auditLogRepository struct {
+	auditLogCollection *mongo.Collection
+}
+
+func NewAuditLogRepository(mongoClient *mongodb.MongoDBClient) AuditLogRepository {
+	collection := mongoClient.GetCollectionByName("auditLogs")
+
+	// The log is listed newest first, for all the users or for one of them
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("AuditLogRepository -> Failed to create the timestamp indexes: %v", err)
+	}
+
+	return &auditLogRepository{
+		auditLogCollection: collection,
+	}
+}
+
+func (r *auditLogRepository) Create(entry *models.AuditLog) error {
+	_, err := r.auditLogCollection.InsertOne(context.Background(), entry)
+	return err
+}
+
+// FindLast returns the latest entry of the audit log, nil if the log is empty
+func (r *auditLogRepository) FindLast() (*models.AuditLog, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
+	var entry models.AuditLog
+	err := r.auditLogCollection.FindOne(context.Background(), bson.M{}, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Find returns a page of the entries matching the filter, newest first, with the number of matching entries
+func (r *auditLogRepository) Find(filter AuditLogFilter, limit, offset int) ([]*models.AuditLog, int64, error) {
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.From != nil || filter.To != nil {
+		timestamp := bson.M{}
+		if filter.From != nil {
+			timestamp["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			timestamp["$lte"] = *filter.To
+		}
+		query["timestamp"] = timestamp
+	}
+
+	total, err := r.auditLogCollection.CountDocuments(context.Background(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := r.auditLogCollection.Find(context.Background(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	entries := make([]*models.AuditLog, 0)
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -26,6 +26,8 @@ type ChatRepository interface {
 	FindLatestMessageByChat(chatID primitive.ObjectID, page, pageSize int) ([]*models.Message, int64, error)
 	FindMessageByID(id primitive.ObjectID) (*models.Message, error)
 	FindNextMessageByID(id primitive.ObjectID) (*models.Message, error)
+	IncrementTableAccessCounts(id primitive.ObjectID, tables []string) error
+	FindMessagesWithFavoriteQueries(userID primitive.ObjectID) ([]*models.Message, error)
 }
 
 type chatRepository struct {
@@ -53,6 +55,20 @@ func (r *chatRepository) Update(id primitive.ObjectID, chat *models.Chat) error
 	return err
 }
 
+// IncrementTableAccessCounts adds one to the access count of each table of a chat, without rewriting the chat so that
+// concurrent responses don't lose counts
+func (r *chatRepository) IncrementTableAccessCounts(id primitive.ObjectID, tables []string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	increments := bson.M{}
+	for _, table := range tables {
+		increments["table_access_counts."+models.TableAccessKey(table)] = 1
+	}
+	_, err := r.chatCollection.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$inc": increments})
+	return err
+}
+
 func (r *chatRepository) Delete(id primitive.ObjectID) error {
 	filter := bson.M{"_id": id}
 	_, err := r.chatCollection.DeleteOne(context.Background(), filter)
@@ -171,6 +187,23 @@ func (r *chatRepository) FindLatestMessageByChat(chatID primitive.ObjectID, page
 	return messages, total, err
 }
 
+// FindMessagesWithFavoriteQueries returns the messages of a user having a favorite query, latest first
+func (r *chatRepository) FindMessagesWithFavoriteQueries(userID primitive.ObjectID) ([]*models.Message, error) {
+	filter := bson.M{"user_id": userID, "queries.is_favorite": true}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.messageCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	messages := make([]*models.Message, 0)
+	if err := cursor.All(context.Background(), &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
 func (r *chatRepository) FindMessageByID(id primitive.ObjectID) (*models.Message, error) {
 	var message models.Message
 	err := r.messageCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&message)
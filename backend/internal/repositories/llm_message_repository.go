@@ -23,6 +23,7 @@ type LLMMessageRepository interface {
 	DeleteMessagesByChatID(chatID primitive.ObjectID, dontDeleteSystemMessages bool) error
 	DeleteMessagesByRole(chatID primitive.ObjectID, role string) error
 	GetByChatID(chatID primitive.ObjectID) ([]*models.LLMMessage, error)
+	GetContextByChatID(chatID, upToMessageID primitive.ObjectID, limit int) ([]*models.LLMMessage, error)
 }
 
 type llmMessageRepository struct {
@@ -146,3 +147,41 @@ func (r *llmMessageRepository) DeleteMessagesByRole(chatID primitive.ObjectID, r
 	_, err := r.messageCollection.DeleteMany(context.Background(), filter)
 	return err
 }
+
+// GetContextByChatID returns the system messages of a chat followed by its latest limit other messages, up to the one
+// of the chat message upToMessageID (included), ordered from the oldest. Only that window is loaded for long chats.
+func (r *llmMessageRepository) GetContextByChatID(chatID, upToMessageID primitive.ObjectID, limit int) ([]*models.LLMMessage, error) {
+	systemFilter := bson.M{"chat_id": chatID, "role": "system"}
+	cursor, err := r.messageCollection.Find(context.Background(), systemFilter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var messages []*models.LLMMessage
+	if err := cursor.All(context.Background(), &messages); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"chat_id": chatID, "role": bson.M{"$ne": "system"}}
+	upTo, err := r.FindMessageByChatMessageID(upToMessageID)
+	if err != nil {
+		return nil, err
+	}
+	if upTo != nil {
+		filter["created_at"] = bson.M{"$lte": upTo.CreatedAt}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}). // Latest messages first for the limit, reversed below
+		SetLimit(int64(limit))
+	cursor, err = r.messageCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var latest []*models.LLMMessage
+	if err := cursor.All(context.Background(), &latest); err != nil {
+		return nil, err
+	}
+	for i := len(latest) - 1; i >= 0; i-- {
+		messages = append(messages, latest[i])
+	}
+	return messages, nil
+}
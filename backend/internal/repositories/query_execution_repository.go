@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/mongodb"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QueryExecutionRepository interface {
+	Create(execution *models.QueryExecution) error
+	FindByChatID(chatID primitive.ObjectID, limit, offset int) ([]*models.QueryExecution, int64, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type queryExecutionRepository struct {
+	executionCollection *mongo.Collection
+}
+
+func NewQueryExecutionRepository(mongoClient *mongodb.MongoDBClient) QueryExecutionRepository {
+	collection := mongoClient.GetCollectionByName("queryExecutions")
+
+	// The history of a chat is listed newest first
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "chat_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("QueryExecutionRepository -> Failed to create the chat_id & created_at index: %v", err)
+	}
+
+	return &queryExecutionRepository{
+		executionCollection: collection,
+	}
+}
+
+func (r *queryExecutionRepository) Create(execution *models.QueryExecution) error {
+	_, err := r.executionCollection.InsertOne(context.Background(), execution)
+	return err
+}
+
+// FindByChatID returns a page of the executions of a chat, newest first, with the number of executions of the chat
+func (r *queryExecutionRepository) FindByChatID(chatID primitive.ObjectID, limit, offset int) ([]*models.QueryExecution, int64, error) {
+	filter := bson.M{"chat_id": chatID}
+	total, err := r.executionCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := r.executionCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	executions := make([]*models.QueryExecution, 0)
+	if err := cursor.All(context.Background(), &executions); err != nil {
+		return nil, 0, err
+	}
+	return executions, total, nil
+}
+
+func (r *queryExecutionRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.executionCollection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/pkg/redis"
+	"fmt"
+	"time"
+)
+
+// RateLimitRepository holds the token buckets limiting how often the users can start LLM generations
+type RateLimitRepository interface {
+	TakeLLMToken(userID string, perMinute, burst int) (bool, time.Duration, error)
+}
+
+type rateLimitRepository struct {
+	redis redis.IRedisRepositories
+}
+
+func NewRateLimitRepository(redis redis.IRedisRepositories) RateLimitRepository {
+	return &rateLimitRepository{
+		redis: redis,
+	}
+}
+
+// TakeLLMToken takes a token from the LLM generation bucket of a user, holding burst tokens refilled at perMinute. When
+// the bucket is empty false is returned with the time until the user can generate again. The bucket is shared by every
+// instance of the deployment.
+func (r *rateLimitRepository) TakeLLMToken(userID string, perMinute, burst int) (bool, time.Duration, error) {
+	key := fmt.Sprintf("llm_rate_limit:%s", userID)
+	return r.redis.TakeToken(key, burst, float64(perMinute)/60, context.Background())
+}
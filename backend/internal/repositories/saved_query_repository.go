@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/mongodb"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SavedQueryRepository interface {
+	Create(savedQuery *models.SavedQuery) error
+	FindByID(userID, savedQueryID primitive.ObjectID) (*models.SavedQuery, error)
+	FindByUserID(userID primitive.ObjectID, connectionType string) ([]*models.SavedQuery, error)
+	Delete(userID, savedQueryID primitive.ObjectID) (bool, error)
+}
+
+type savedQueryRepository struct {
+	savedQueryCollection *mongo.Collection
+}
+
+func NewSavedQueryRepository(mongoClient *mongodb.MongoDBClient) SavedQueryRepository {
+	collection := mongoClient.GetCollectionByName("savedQueries")
+
+	// The saved queries of a user are listed by name
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("SavedQueryRepository -> Failed to create the user_id & name index: %v", err)
+	}
+
+	return &savedQueryRepository{
+		savedQueryCollection: collection,
+	}
+}
+
+func (r *savedQueryRepository) Create(savedQuery *models.SavedQuery) error {
+	_, err := r.savedQueryCollection.InsertOne(context.Background(), savedQuery)
+	return err
+}
+
+// FindByID returns a saved query of the user, nil if the user has no such saved query
+func (r *savedQueryRepository) FindByID(userID, savedQueryID primitive.ObjectID) (*models.SavedQuery, error) {
+	var savedQuery models.SavedQuery
+	err := r.savedQueryCollection.FindOne(context.Background(), bson.M{"_id": savedQueryID, "user_id": userID}).Decode(&savedQuery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &savedQuery, nil
+}
+
+// FindByUserID returns the saved queries of a user sorted by name, only the ones runnable on the connection type &
+// the ones for any connection when a connection type is given
+func (r *savedQueryRepository) FindByUserID(userID primitive.ObjectID, connectionType string) ([]*models.SavedQuery, error) {
+	filter := bson.M{"user_id": userID}
+	if connectionType != "" {
+		filter["$or"] = bson.A{
+			bson.M{"connection_type": connectionType},
+			bson.M{"connection_type": bson.M{"$exists": false}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.savedQueryCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	savedQueries := make([]*models.SavedQuery, 0)
+	if err := cursor.All(context.Background(), &savedQueries); err != nil {
+		return nil, err
+	}
+	return savedQueries, nil
+}
+
+// Delete removes a saved query of the user, false is returned if the user has no such saved query
+func (r *savedQueryRepository) Delete(userID, savedQueryID primitive.ObjectID) (bool, error) {
+	result, err := r.savedQueryCollection.DeleteOne(context.Background(), bson.M{"_id": savedQueryID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
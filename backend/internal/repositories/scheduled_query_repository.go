@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/mongodb"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScheduledQueryRepository interface {
+	Create(scheduledQuery *models.ScheduledQuery) error
+	Update(scheduledQuery *models.ScheduledQuery) error
+	FindByID(chatID, scheduledQueryID primitive.ObjectID) (*models.ScheduledQuery, error)
+	FindByChatID(chatID primitive.ObjectID) ([]*models.ScheduledQuery, error)
+	FindDue(now time.Time) ([]*models.ScheduledQuery, error)
+	Delete(chatID, scheduledQueryID primitive.ObjectID) (bool, error)
+	DeleteByChatID(chatID primitive.ObjectID) error
+}
+
+type scheduledQueryRepository struct {
+	scheduledQueryCollection *mongo.Collection
+}
+
+func NewScheduledQueryRepository(mongoClient *mongodb.MongoDBClient) ScheduledQueryRepository {
+	collection := mongoClient.GetCollectionByName("scheduledQueries")
+
+	// The scheduler looks up the enabled schedules due to run
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "enabled", Value: 1}, {Key: "next_run_at", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("ScheduledQueryRepository -> Failed to create the enabled & next_run_at index: %v", err)
+	}
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "chat_id", Value: 1}, {Key: "name", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("ScheduledQueryRepository -> Failed to create the chat_id & name index: %v", err)
+	}
+
+	return &scheduledQueryRepository{
+		scheduledQueryCollection: collection,
+	}
+}
+
+func (r *scheduledQueryRepository) Create(scheduledQuery *models.ScheduledQuery) error {
+	_, err := r.scheduledQueryCollection.InsertOne(context.Background(), scheduledQuery)
+	return err
+}
+
+func (r *scheduledQueryRepository) Update(scheduledQuery *models.ScheduledQuery) error {
+	scheduledQuery.UpdatedAt = time.Now()
+	_, err := r.scheduledQueryCollection.ReplaceOne(context.Background(), bson.M{"_id": scheduledQuery.ID}, scheduledQuery)
+	return err
+}
+
+// FindByID returns a scheduled query of the chat, nil if the chat has no such scheduled query
+func (r *scheduledQueryRepository) FindByID(chatID, scheduledQueryID primitive.ObjectID) (*models.ScheduledQuery, error) {
+	var scheduledQuery models.ScheduledQuery
+	err := r.scheduledQueryCollection.FindOne(context.Background(), bson.M{"_id": scheduledQueryID, "chat_id": chatID}).Decode(&scheduledQuery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledQuery, nil
+}
+
+// FindByChatID returns the scheduled queries of a chat sorted by name
+func (r *scheduledQueryRepository) FindByChatID(chatID primitive.ObjectID) ([]*models.ScheduledQuery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	return r.find(bson.M{"chat_id": chatID}, opts)
+}
+
+// FindDue returns the enabled scheduled queries whose next run is due, the most overdue first
+func (r *scheduledQueryRepository) FindDue(now time.Time) ([]*models.ScheduledQuery, error) {
+	filter := bson.M{"enabled": true, "next_run_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "next_run_at", Value: 1}})
+	return r.find(filter, opts)
+}
+
+func (r *scheduledQueryRepository) find(filter bson.M, opts *options.FindOptions) ([]*models.ScheduledQuery, error) {
+	cursor, err := r.scheduledQueryCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	scheduledQueries := make([]*models.ScheduledQuery, 0)
+	if err := cursor.All(context.Background(), &scheduledQueries); err != nil {
+		return nil, err
+	}
+	return scheduledQueries, nil
+}
+
+// Delete removes a scheduled query of the chat, false is returned if the chat has no such scheduled query
+func (r *scheduledQueryRepository) Delete(chatID, scheduledQueryID primitive.ObjectID) (bool, error) {
+	result, err := r.scheduledQueryCollection.DeleteOne(context.Background(), bson.M{"_id": scheduledQueryID, "chat_id": chatID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *scheduledQueryRepository) DeleteByChatID(chatID primitive.ObjectID) error {
+	_, err := r.scheduledQueryCollection.DeleteMany(context.Background(), bson.M{"chat_id": chatID})
+	return err
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"databot-ai/pkg/redis"
+	"fmt"
+	"time"
+)
+
+// TenantUsageRepository counts the use of the resources limited by the quotas of the tenants
+type TenantUsageRepository interface {
+	Increment(tenantID, resource string, window time.Duration) (int64, error)
+}
+
+type tenantUsageRepository struct {
+	redis redis.IRedisRepositories
+}
+
+func NewTenantUsageRepository(redis redis.IRedisRepositories) TenantUsageRepository {
+	return &tenantUsageRepository{
+		redis: redis,
+	}
+}
+
+// Increment counts a use of a resource by a tenant in the current window, the uses of the window so far are returned.
+// The count is shared by every instance of the deployment.
+func (r *tenantUsageRepository) Increment(tenantID, resource string, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("tenant_usage:%s:%s:%d", tenantID, resource, time.Now().Unix()/int64(window.Seconds()))
+	return r.redis.Incr(key, window, context.Background())
+}
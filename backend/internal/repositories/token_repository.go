@@ -16,6 +16,7 @@ type TokenRepository interface {
 	DeleteRefreshToken(userID string, refreshToken string) error
 	BlacklistToken(token string, expiresAt time.Duration) error
 	IsTokenBlacklisted(token string) bool
+	IncrementAPIKeyRequests(keyID string, window time.Duration) (int64, error)
 }
 
 type tokenRepository struct {
@@ -102,3 +103,10 @@ func (r *tokenRepository) IsTokenBlacklisted(token string) bool {
 	}
 	return value == "blacklisted"
 }
+
+// IncrementAPIKeyRequests counts a request of an API key in the current rate limit window, the requests made in the
+// window so far are returned
+func (r *tokenRepository) IncrementAPIKeyRequests(keyID string, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("api_key_requests:%s:%d", keyID, time.Now().Unix()/int64(window.Seconds()))
+	return r.redis.Incr(key, window, context.Background())
+}
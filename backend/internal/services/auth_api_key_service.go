@@ -0,0 +1,146 @@
+package services
+
+import (
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/auth_service.go
+
+// apiKeyDisplayLength is the number of characters of a key stored in clear, enough to tell the keys of a user apart
+const apiKeyDisplayLength = len(constants.APIKeyPrefix) + 8
+
+// CreateAPIKey issues an API key for the scripts of a user, the key is only returned by this call
+func (s *authService) CreateAPIKey(userID string, req *dtos.CreateAPIKeyRequest) (*dtos.CreateAPIKeyResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+	if !constants.IsValidAPIKeyScope(req.Scope) {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid scope %q, expected %q or %q", req.Scope, constants.APIKeyScopeRead, constants.APIKeyScopeFull)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("name is required")
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByUserID(userObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to count API keys: %v", err)
+	}
+	if count >= constants.MaxAPIKeysPerUser {
+		return nil, http.StatusBadRequest, fmt.Errorf("a user can have at most %d API keys, revoke one before creating another", constants.MaxAPIKeysPerUser)
+	}
+
+	key, err := utils.GenerateAPIKey(constants.APIKeyPrefix)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	apiKey := models.NewAPIKey(userObjID, name, key[:apiKeyDisplayLength], utils.SHA256Hash(key), req.Scope)
+	if err := s.apiKeyRepo.Create(apiKey); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create API key: %v", err)
+	}
+	log.Printf("AuthService -> CreateAPIKey -> Created %s API key %s for userID: %s", apiKey.Scope, apiKey.ID.Hex(), userID)
+
+	return &dtos.CreateAPIKeyResponse{
+		APIKeyResponse: buildAPIKeyResponse(apiKey),
+		Key:            key,
+	}, http.StatusCreated, nil
+}
+
+// ListAPIKeys lists the API keys of a user that aren't revoked, the keys themselves are never returned
+func (s *authService) ListAPIKeys(userID string) ([]dtos.APIKeyResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	apiKeys, err := s.apiKeyRepo.FindByUserID(userObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch API keys: %v", err)
+	}
+	response := make([]dtos.APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		response[i] = buildAPIKeyResponse(apiKey)
+	}
+	return response, http.StatusOK, nil
+}
+
+// RevokeAPIKey revokes an API key of a user, requests made with it are rejected from then on
+func (s *authService) RevokeAPIKey(userID, keyID string) (uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+	keyObjID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid API key ID format")
+	}
+
+	revoked, err := s.apiKeyRepo.Revoke(userObjID, keyObjID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	if !revoked {
+		return http.StatusNotFound, errors.New("API key not found")
+	}
+	log.Printf("AuthService -> RevokeAPIKey -> Revoked API key %s of userID: %s", keyID, userID)
+	return http.StatusOK, nil
+}
+
+// AuthenticateAPIKey returns the API key a request is made with, requests beyond the rate limit of the key are
+// rejected with http.StatusTooManyRequests
+func (s *authService) AuthenticateAPIKey(key string) (*models.APIKey, uint32, error) {
+	apiKey, err := s.apiKeyRepo.FindByHash(utils.SHA256Hash(key))
+	if err != nil {
+		log.Printf("AuthService -> AuthenticateAPIKey -> Error fetching API key: %v", err)
+		return nil, http.StatusInternalServerError, errors.New("failed to validate API key")
+	}
+	if apiKey == nil {
+		return nil, http.StatusUnauthorized, errors.New("invalid API key")
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, http.StatusUnauthorized, errors.New("API key has been revoked")
+	}
+
+	requests, err := s.tokenRepo.IncrementAPIKeyRequests(apiKey.ID.Hex(), time.Minute)
+	if err != nil {
+		// The rate limit is a safeguard, requests aren't rejected when it can't be checked
+		log.Printf("AuthService -> AuthenticateAPIKey -> Error counting requests of API key %s: %v", apiKey.ID.Hex(), err)
+	} else if requests > int64(config.Env.APIKeyRateLimitPerMinute) {
+		return nil, http.StatusTooManyRequests, fmt.Errorf("rate limit of %d requests per minute exceeded for this API key", config.Env.APIKeyRateLimitPerMinute)
+	}
+
+	go func() {
+		if err := s.apiKeyRepo.UpdateLastUsed(apiKey.ID, time.Now()); err != nil {
+			log.Printf("AuthService -> AuthenticateAPIKey -> Error updating last use of API key %s: %v", apiKey.ID.Hex(), err)
+		}
+	}()
+	return apiKey, http.StatusOK, nil
+}
+
+func buildAPIKeyResponse(apiKey *models.APIKey) dtos.APIKeyResponse {
+	response := dtos.APIKeyResponse{
+		ID:        apiKey.ID.Hex(),
+		Name:      apiKey.Name,
+		KeyPrefix: apiKey.KeyPrefix,
+		Scope:     apiKey.Scope,
+		CreatedAt: apiKey.CreatedAt.Format(time.RFC3339),
+	}
+	if apiKey.LastUsedAt != nil {
+		lastUsedAt := apiKey.LastUsedAt.Format(time.RFC3339)
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
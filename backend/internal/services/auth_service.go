@@ -21,6 +21,10 @@ type AuthService interface {
 	Logout(refreshToken string, accessToken string) (uint32, error)
 	GetUser(userID string) (*models.User, uint, error)
 	SetChatService(chatService ChatService)
+	CreateAPIKey(userID string, req *dtos.CreateAPIKeyRequest) (*dtos.CreateAPIKeyResponse, uint32, error)
+	ListAPIKeys(userID string) ([]dtos.APIKeyResponse, uint32, error)
+	RevokeAPIKey(userID, keyID string) (uint32, error)
+	AuthenticateAPIKey(key string) (*models.APIKey, uint32, error)
 }
 
 type authService struct {
@@ -28,13 +32,15 @@ type authService struct {
 	userRepo    repositories.UserRepository
 	jwtService  utils.JWTService
 	tokenRepo   repositories.TokenRepository
+	apiKeyRepo  repositories.APIKeyRepository
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtService utils.JWTService, tokenRepo repositories.TokenRepository) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, jwtService utils.JWTService, tokenRepo repositories.TokenRepository, apiKeyRepo repositories.APIKeyRepository) AuthService {
 	return &authService{
 		userRepo:   userRepo,
 		jwtService: jwtService,
 		tokenRepo:  tokenRepo,
+		apiKeyRepo: apiKeyRepo,
 	}
 }
 
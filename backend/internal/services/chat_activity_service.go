@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ListServerActivity lists the queries & transactions the connection's database user is running, ex: to find a hanging transaction
+func (s *chatService) ListServerActivity(ctx context.Context, userID, chatID string, minDurationMs int64) (*dtos.ServerActivityResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if !dbmanager.ActivitySupported(chat.Connection.Type) {
+		return nil, http.StatusBadRequest, fmt.Errorf("server activity is not supported for %s", chat.Connection.Type)
+	}
+	if !s.dbManager.IsConnected(chatID) {
+		return nil, http.StatusBadRequest, fmt.Errorf("database is not connected")
+	}
+
+	activities, err := s.dbManager.ListServerActivity(ctx, chatID)
+	if err != nil {
+		log.Printf("ChatService -> ListServerActivity -> Error listing server activity: %v", err)
+		return nil, http.StatusInternalServerError, err
+	}
+
+	response := &dtos.ServerActivityResponse{
+		ChatID:     chatID,
+		Activities: make([]dtos.ServerActivity, 0, len(activities)),
+	}
+	for _, activity := range activities {
+		if activity.DurationMs < minDurationMs {
+			continue
+		}
+		response.Activities = append(response.Activities, dtos.ServerActivity(activity))
+	}
+	return response, http.StatusOK, nil
+}
+
+// TerminateServerActivity cancels a query of the connection's database user, or terminates its connection & open transaction if asked to
+func (s *chatService) TerminateServerActivity(ctx context.Context, userID, chatID, activityID string, terminate bool) (uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+	if !dbmanager.ActivitySupported(chat.Connection.Type) {
+		return http.StatusBadRequest, fmt.Errorf("server activity is not supported for %s", chat.Connection.Type)
+	}
+	if !s.dbManager.IsConnected(chatID) {
+		return http.StatusBadRequest, fmt.Errorf("database is not connected")
+	}
+
+	if err := s.dbManager.TerminateServerActivity(ctx, chatID, activityID, terminate); err != nil {
+		log.Printf("ChatService -> TerminateServerActivity -> Error terminating activity %s: %v", activityID, err)
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
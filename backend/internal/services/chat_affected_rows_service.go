@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// estimateAffectedRows counts the rows an UPDATE or DELETE will change before it is executed, from the table & the
+// WHERE clause of the query (see dbmanager.AffectedRowsCountQuery). Nil is returned when the query can't be counted
+// or the count fails, the query is then executed without an estimate.
+func (s *chatService) estimateAffectedRows(ctx context.Context, chat *models.Chat, chatID string, req *dtos.ExecuteQueryRequest, query *models.Query) *int {
+	countQuery := dbmanager.AffectedRowsCountQuery(chat.Connection.Type, query.Query)
+	if countQuery == "" {
+		return nil
+	}
+	log.Printf("ChatService -> estimateAffectedRows -> Counting the rows of queryID %s with: %s", req.QueryID, countQuery)
+
+	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, countQuery, "SELECT", false, true)
+	if queryErr != nil {
+		log.Printf("ChatService -> estimateAffectedRows -> Count failed: %s", queryErr.Message)
+		return nil
+	}
+	count, ok := extractCount(result.ResultJSON)
+	if !ok {
+		log.Printf("ChatService -> estimateAffectedRows -> Could not extract the count from: %s", result.ResultJSON)
+		return nil
+	}
+	return &count
+}
+
+// requiresAffectedRowsConfirmation checks if a query counted to change estimate rows must be confirmed before it is
+// executed, above AFFECTED_ROWS_CONFIRMATION_THRESHOLD
+func requiresAffectedRowsConfirmation(estimate *int, confirmed bool) bool {
+	threshold := config.Env.AffectedRowsConfirmationThreshold
+	return !confirmed && threshold > 0 && estimate != nil && *estimate > threshold
+}
+
+// affectedRowsConfirmationResponse refuses to execute a query changing more rows than the confirmation threshold, the
+// message gets a "Confirm & Execute" button executing it again with confirm_affected_rows
+func (s *chatService) affectedRowsConfirmationResponse(chatID string, msg *models.Message, query *models.Query, estimate int) (*dtos.QueryExecutionResponse, uint32, error) {
+	log.Printf("ChatService -> affectedRowsConfirmationResponse -> queryID %s would change %d rows, waiting for the confirmation", query.ID.Hex(), estimate)
+	s.setConfirmAffectedRowsButton(msg, query.ID.Hex(), true)
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		log.Printf("ChatService -> affectedRowsConfirmationResponse -> Error updating message: %v", err)
+	}
+
+	queryErr := &dtos.QueryError{
+		Code:    "AFFECTED_ROWS_CONFIRMATION_REQUIRED",
+		Message: fmt.Sprintf("this query would change %d rows, confirm it to execute it", estimate),
+		Details: fmt.Sprintf("Queries changing more than %d rows are only executed once confirmed, check that the filter of the query is the intended one.", config.Env.AffectedRowsConfirmationThreshold),
+	}
+	return &dtos.QueryExecutionResponse{
+		ChatID:               chatID,
+		MessageID:            msg.ID.Hex(),
+		QueryID:              query.ID.Hex(),
+		Error:                queryErr,
+		ActionButtons:        dtos.ToActionButtonDto(msg.ActionButtons),
+		AffectedRowsEstimate: &estimate,
+	}, http.StatusConflict, fmt.Errorf("%s", queryErr.Message)
+}
+
+// setConfirmAffectedRowsButton adds a "Confirm & Execute" button for a query waiting for the confirmation of the rows
+// it changes, or removes the button of the query once it is executed
+func (s *chatService) setConfirmAffectedRowsButton(msg *models.Message, queryID string, required bool) {
+	var buttons []models.ActionButton
+	if msg.ActionButtons != nil {
+		for _, button := range *msg.ActionButtons {
+			if button.Action == "confirm_affected_rows" && button.Payload[constants.ActionPayloadQueryID] == queryID {
+				if required {
+					return
+				}
+				continue
+			}
+			buttons = append(buttons, button)
+		}
+	}
+
+	if required {
+		log.Printf("ChatService -> setConfirmAffectedRowsButton -> Adding confirm_affected_rows button for queryID: %s", queryID)
+		buttons = append(buttons, models.ActionButton{
+			ID:        primitive.NewObjectID(),
+			Label:     "Confirm & Execute",
+			Action:    "confirm_affected_rows",
+			IsPrimary: true,
+			Payload:   map[string]interface{}{constants.ActionPayloadQueryID: queryID},
+		})
+	} else if msg.ActionButtons == nil || len(buttons) == len(*msg.ActionButtons) {
+		return
+	}
+	msg.ActionButtons = &buttons
+}
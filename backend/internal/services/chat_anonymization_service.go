@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// anonymizeLLMMessages replaces the schema names of the messages sent to the LLM with opaque tokens when the chat has
+// AnonymizeSchema enabled. The stored schema updates hold the real names, they are replaced by a freshly formatted
+// anonymized schema. The returned anonymizer maps the tokens of the LLM response back, it is nil if the chat isn't
+// anonymized. An error is returned rather than sending the real names if the schema can't be anonymized.
+func (s *chatService) anonymizeLLMMessages(ctx context.Context, chat *models.Chat, messages []*models.LLMMessage) ([]*models.LLMMessage, *dbmanager.SchemaAnonymizer, error) {
+	if chat == nil || !chat.Settings.AnonymizeSchema {
+		return messages, nil, nil
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, nil, fmt.Errorf("the imported schema of a generate only chat can't be anonymized, disable schema anonymization")
+	}
+	if len(chat.Connections) > 0 {
+		return nil, nil, fmt.Errorf("the schemas of named connections can't be anonymized, remove them or disable schema anonymization")
+	}
+	chatID := chat.ID.Hex()
+
+	aliases := make(map[string]string, len(chat.SchemaAliases))
+	for _, alias := range chat.SchemaAliases {
+		aliases[alias.Name] = alias.Token
+	}
+	anonymizer := dbmanager.NewSchemaAnonymizer(chat.Connection.Type, aliases)
+
+	var selectedCollections []string
+	if chat.SelectedCollections != "ALL" && chat.SelectedCollections != "" {
+		selectedCollections = strings.Split(chat.SelectedCollections, ",")
+	}
+	s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+	s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+	s.dbManager.GetSchemaManager().SetSchemaDescriptions(chatID, toDBSchemaDescriptions(chat.SchemaDescriptions))
+	s.dbManager.GetSchemaManager().SetTableAccessCounts(chatID, chat.TableAccessCountsByTable())
+	schema, err := s.dbManager.FormatAnonymizedSchema(ctx, chatID, selectedCollections, anonymizer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to anonymize the schema: %v", err)
+	}
+
+	anonymized := make([]*models.LLMMessage, 0, len(messages)+1)
+	anonymized = append(anonymized, &models.LLMMessage{
+		ChatID:  chat.ID,
+		UserID:  chat.UserID,
+		Role:    string(constants.MessageTypeSystem),
+		Content: map[string]interface{}{"schema_update": schema},
+	})
+	for _, msg := range messages {
+		if _, isSchemaUpdate := msg.Content["schema_update"]; isSchemaUpdate {
+			continue
+		}
+		if _, isServerInfo := msg.Content["server_info"]; isServerInfo {
+			// Holds no schema names, only the version & the supported features
+			anonymized = append(anonymized, msg)
+			continue
+		}
+		if _, isTextCollation := msg.Content["text_collation"]; isTextCollation {
+			// Holds no schema names, only the collation to compare text with
+			anonymized = append(anonymized, msg)
+			continue
+		}
+		copied := *msg
+		copied.Content = anonymizer.AnonymizeValue(msg.Content).(map[string]interface{})
+		anonymized = append(anonymized, &copied)
+	}
+
+	if anonymizer.HasNewTokens() {
+		tokens := anonymizer.Tokens()
+		chat.SchemaAliases = make([]models.SchemaAlias, 0, len(tokens))
+		for name, token := range tokens {
+			chat.SchemaAliases = append(chat.SchemaAliases, models.SchemaAlias{Name: name, Token: token})
+		}
+		sort.Slice(chat.SchemaAliases, func(i, j int) bool {
+			return chat.SchemaAliases[i].Name < chat.SchemaAliases[j].Name
+		})
+		// The tokens must be stored before they reach the LLM, otherwise the next requests couldn't map them back
+		if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+			return nil, nil, fmt.Errorf("failed to store the schema aliases: %v", err)
+		}
+		log.Printf("ChatService -> anonymizeLLMMessages -> Stored %d schema aliases for chatID: %s", len(chat.SchemaAliases), chatID)
+	}
+	return anonymized, anonymizer, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"databot-ai/internal/repositories"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// auditLogMaxRetryDelay is the longest wait between two attempts to write an audit log entry, the entry is retried
+// until it is written
+const auditLogMaxRetryDelay = 30 * time.Second
+
+type sourceIPKey struct{}
+
+// WithSourceIP sets the IP address of the client of a request on its context, it is recorded in the audit log of the
+// calls made with the context
+func WithSourceIP(ctx context.Context, sourceIP string) context.Context {
+	if sourceIP == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceIPKey{}, sourceIP)
+}
+
+// sourceIPOf returns the IP address of the client set on the context, empty for the calls made by the server itself
+// such as the scheduled queries
+func sourceIPOf(ctx context.Context) string {
+	sourceIP, _ := ctx.Value(sourceIPKey{}).(string)
+	return sourceIP
+}
+
+// recordAuditLog queues an entry of the audit log, it is written by StartAuditLogWriter without blocking the request.
+// Entries are never dropped: when the buffer is full the entry waits in its own goroutine.
+func (s *chatService) recordAuditLog(ctx context.Context, userID, chatID, action string, details map[string]interface{}) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return
+	}
+
+	entry := models.NewAuditLog(userObjID, chatObjID, action, sourceIPOf(ctx), details)
+	select {
+	case s.auditLogEntries <- entry:
+	default:
+		log.Printf("ChatService -> recordAuditLog -> Buffer full, queueing the %s entry of chatID %s in the background", action, chatID)
+		go func() { s.auditLogEntries <- entry }()
+	}
+}
+
+// auditLogCallDetails adds the error of a call to the details of its audit log entry
+func auditLogCallDetails(details map[string]interface{}, callErr error) map[string]interface{} {
+	if callErr != nil {
+		details["error"] = callErr.Error()
+	}
+	return details
+}
+
+// StartAuditLogWriter starts the goroutine writing the queued audit log entries one at a time, each entry is chained to
+// the previous one (see models.AuditLog) & retried until it is written
+func (s *chatService) StartAuditLogWriter() {
+	go func() {
+		var prevHash string
+		for attempt := 0; ; attempt++ {
+			last, err := s.auditLogRepo.FindLast()
+			if err == nil {
+				if last != nil {
+					prevHash = last.Hash
+				}
+				break
+			}
+			log.Printf("ChatService -> StartAuditLogWriter -> Error fetching the last entry: %v", err)
+			time.Sleep(auditLogRetryDelay(attempt))
+		}
+
+		log.Printf("ChatService -> StartAuditLogWriter -> Writing the audit log")
+		for entry := range s.auditLogEntries {
+			entry.PrevHash = prevHash
+			entry.Hash = entry.ComputeHash()
+			for attempt := 0; ; attempt++ {
+				err := s.auditLogRepo.Create(entry)
+				if err == nil {
+					break
+				}
+				log.Printf("ChatService -> StartAuditLogWriter -> Error writing the %s entry of chatID %s: %v", entry.Action, entry.ChatID.Hex(), err)
+				time.Sleep(auditLogRetryDelay(attempt))
+			}
+			prevHash = entry.Hash
+		}
+	}()
+}
+
+// auditLogRetryDelay doubles the wait after each failed attempt, up to auditLogMaxRetryDelay
+func auditLogRetryDelay(attempt int) time.Duration {
+	if attempt > 5 {
+		return auditLogMaxRetryDelay
+	}
+	delay := time.Second << attempt
+	if delay > auditLogMaxRetryDelay {
+		return auditLogMaxRetryDelay
+	}
+	return delay
+}
+
+// GetAuditLog returns a page of the audit log, newest first, filtered by user & time range. Only the admin user can
+// read the audit log. Each entry tells if its hash still matches its content.
+func (s *chatService) GetAuditLog(userID string, req *dtos.AuditLogRequest) (*dtos.AuditLogResponse, uint32, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	if user == nil || user.Username != config.Env.AdminUser {
+		return nil, http.StatusForbidden, fmt.Errorf("only the admin user can read the audit log")
+	}
+
+	var filter repositories.AuditLogFilter
+	if req.UserID != "" {
+		filterUserID, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+		}
+		filter.UserID = &filterUserID
+	}
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		return nil, http.StatusBadRequest, fmt.Errorf("from must be before to")
+	}
+	filter.From = req.From
+	filter.To = req.To
+
+	limit := req.Limit
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > config.Env.MaxListPageSize {
+		limit = config.Env.MaxListPageSize
+	}
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := s.auditLogRepo.Find(filter, limit, offset)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch the audit log: %v", err)
+	}
+
+	items := make([]dtos.AuditLogItem, len(entries))
+	for i, entry := range entries {
+		items[i] = dtos.AuditLogItem{
+			ID:        entry.ID.Hex(),
+			UserID:    entry.UserID.Hex(),
+			ChatID:    entry.ChatID.Hex(),
+			Action:    entry.Action,
+			SourceIP:  entry.SourceIP,
+			Details:   entry.Details,
+			Timestamp: entry.Timestamp.Format(time.RFC3339Nano),
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+			IsIntact:  entry.ComputeHash() == entry.Hash,
+		}
+	}
+
+	return &dtos.AuditLogResponse{
+		Entries: items,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: int64(offset+len(items)) < total,
+	}, http.StatusOK, nil
+}
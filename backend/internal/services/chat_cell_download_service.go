@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// binaryDatabaseTypes are the column types (as normalized by dbmanager.NormalizeDatabaseType) holding raw bytes
+var binaryDatabaseTypes = map[string]bool{
+	"BYTEA": true, "BLOB": true, "TINYBLOB": true, "MEDIUMBLOB": true, "LONGBLOB": true, "BINARY": true, "VARBINARY": true,
+}
+
+// errCellFound stops reading the rows of a query once the requested cell is read
+var errCellFound = errors.New("cell found")
+
+// CellContent is the full value of a single cell of a query result, as downloaded by the user
+type CellContent struct {
+	Data        []byte
+	ContentType string
+	FileName    string
+}
+
+// DownloadCellValue re-executes a read query & returns the full value of the cell at row (0 based) & column of its
+// result, so that large text & binary values cut in the result stay retrievable
+func (s *chatService) DownloadCellValue(ctx context.Context, userID, chatID, messageID, queryID, streamID string, row int, column string) (*CellContent, uint32, error) {
+	log.Printf("ChatService -> DownloadCellValue -> chatID: %s, queryID: %s, row: %d, column: %s", chatID, queryID, row, column)
+
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only values of read queries can be downloaded")
+	}
+	if row < 0 || column == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("row can't be negative & column is required")
+	}
+
+	if !s.dbManager.IsConnected(chatID) {
+		if statusCode, err := s.ConnectDB(ctx, userID, chatID, streamID); err != nil {
+			return nil, statusCode, err
+		}
+	}
+
+	var value interface{}
+	databaseType := ""
+	found := false
+	if dbmanager.StreamSupported(chat.Connection.Type) {
+		// Rows are read from the driver cursor until the requested one, values are never cut there
+		columnIndex := -1
+		current := 0
+		err := s.dbManager.StreamQueryRows(ctx, chatID, query.Query, func(columns []dbmanager.ExportColumn) error {
+			for i, resultColumn := range columns {
+				if resultColumn.Name == column {
+					columnIndex = i
+					databaseType = resultColumn.DatabaseType
+					break
+				}
+			}
+			if columnIndex == -1 {
+				return fmt.Errorf("column %s is not in the result of the query", column)
+			}
+			return nil
+		}, func(values []interface{}) error {
+			if current < row {
+				current++
+				return nil
+			}
+			value = values[columnIndex]
+			found = true
+			return errCellFound
+		})
+		if err != nil && !errors.Is(err, errCellFound) {
+			return nil, http.StatusBadRequest, err
+		}
+	} else {
+		queryType := ""
+		if query.QueryType != nil {
+			queryType = *query.QueryType
+		}
+		result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, msg.ID.Hex(), query.ID.Hex(), streamID, query.Query, queryType, false, false)
+		if queryErr != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to execute query: %s", queryErr.Message)
+		}
+		current := 0
+		if err := scanResultRecords(result.ResultJSON, func(record map[string]interface{}) error {
+			if current < row {
+				current++
+				return nil
+			}
+			recordValue, exists := record[column]
+			if !exists {
+				return fmt.Errorf("column %s is not in the result of the query", column)
+			}
+			value = recordValue
+			found = true
+			return errCellFound
+		}); err != nil && !errors.Is(err, errCellFound) {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if !found {
+		return nil, http.StatusNotFound, fmt.Errorf("the result of the query has no row %d", row)
+	}
+
+	content, err := cellContent(value, databaseType)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	content.FileName = fmt.Sprintf("%s-%d%s", column, row, content.FileName)
+	return content, http.StatusOK, nil
+}
+
+// cellContent converts a cell value to the bytes downloaded & their content type, FileName holds the extension only.
+// Binary columns are sniffed (ex: image/png), JSON values are marshalled & the rest is sent as text.
+func cellContent(value interface{}, databaseType string) (*CellContent, error) {
+	normalizedType := dbmanager.NormalizeDatabaseType(databaseType)
+	switch v := value.(type) {
+	case nil:
+		return &CellContent{Data: []byte{}, ContentType: "text/plain; charset=utf-8", FileName: ".txt"}, nil
+	case []byte:
+		return &CellContent{Data: v, ContentType: http.DetectContentType(v), FileName: ".bin"}, nil
+	case string:
+		if binaryDatabaseTypes[normalizedType] {
+			return &CellContent{Data: []byte(v), ContentType: http.DetectContentType([]byte(v)), FileName: ".bin"}, nil
+		}
+		if normalizedType == "JSON" || normalizedType == "JSONB" {
+			return &CellContent{Data: []byte(v), ContentType: "application/json", FileName: ".json"}, nil
+		}
+		return &CellContent{Data: []byte(v), ContentType: "text/plain; charset=utf-8", FileName: ".txt"}, nil
+	case time.Time:
+		return &CellContent{Data: []byte(v.Format(time.RFC3339Nano)), ContentType: "text/plain; charset=utf-8", FileName: ".txt"}, nil
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the value: %v", err)
+		}
+		return &CellContent{Data: data, ContentType: "application/json", FileName: ".json"}, nil
+	default:
+		return &CellContent{Data: []byte(fmt.Sprint(v)), ContentType: "text/plain; charset=utf-8", FileName: ".txt"}, nil
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/pkg/dbmanager"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// GetColumnValues lists the distinct values of a low cardinality column straight from the database, see
+// dbmanager.Manager.GetColumnValues. Masked columns are refused, their values would be revealed.
+func (s *chatService) GetColumnValues(ctx context.Context, userID, chatID, streamID, table, column string, limit int) (*dtos.ColumnValuesResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	if !dbmanager.StreamSupported(chat.Connection.Type) {
+		return nil, http.StatusBadRequest, fmt.Errorf("listing the values of a column is not supported for %s", chat.Connection.Type)
+	}
+	if _, masked := dbmanager.ColumnMasks(chat.Settings.ColumnMasks).FormatFor(table, column); masked {
+		return nil, http.StatusForbidden, fmt.Errorf("column %s is masked, its values can't be listed", column)
+	}
+
+	if limit < 1 {
+		limit = constants.DefaultColumnValuesLimit
+	}
+	if limit > constants.MaxColumnValuesLimit {
+		limit = constants.MaxColumnValuesLimit
+	}
+
+	if !s.dbManager.IsConnected(chatID) {
+		if statusCode, err := s.ConnectDB(ctx, userID, chatID, streamID); err != nil {
+			return nil, statusCode, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	values, err := s.dbManager.GetColumnValues(ctx, chatID, table, column, limit)
+	if errors.Is(err, dbmanager.ErrHighCardinalityColumn) {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("column %s has more than %d distinct values, filter it with a query instead", column, limit)
+	}
+	if err != nil {
+		log.Printf("ChatService -> GetColumnValues -> Failed to read the values of %s.%s: %v", table, column, err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	return &dtos.ColumnValuesResponse{
+		Table:  table,
+		Column: column,
+		Values: values,
+	}, http.StatusOK, nil
+}
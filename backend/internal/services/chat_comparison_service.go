@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// CompareQueryResults executes a read query of a chat on its connection & on the connection of another chat of the
+// user, ex: the old & new databases of a migration, and returns the diff of both results keyed by a column.
+// Both results are bounded by MAX_QUERY_RESULT_ROWS, a warning is returned when a result was truncated.
+func (s *chatService) CompareQueryResults(ctx context.Context, userID, chatID string, req *dtos.CompareQueryResultsRequest) (*dtos.QueryComparisonResponse, uint32, error) {
+	log.Printf("ChatService -> CompareQueryResults -> Comparing queryID: %s of chatID: %s with chatID: %s", req.QueryID, chatID, req.TargetChatID)
+
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only read queries can be compared")
+	}
+
+	if req.TargetChatID == chatID {
+		return nil, http.StatusBadRequest, fmt.Errorf("the target chat must be another chat")
+	}
+	targetChatObjID, err := primitive.ObjectIDFromHex(req.TargetChatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid target chat ID format")
+	}
+	targetChat, err := s.chatRepo.FindByID(targetChatObjID)
+	if err != nil || targetChat == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("target chat not found")
+	}
+	if targetChat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to target chat")
+	}
+	if chat.Settings.GenerateOnly || targetChat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	if (chat.Connection.Type == constants.DatabaseTypeMongoDB) != (targetChat.Connection.Type == constants.DatabaseTypeMongoDB) {
+		return nil, http.StatusBadRequest, fmt.Errorf("a %s query can't be executed on a %s connection", chat.Connection.Type, targetChat.Connection.Type)
+	}
+
+	// The query runs on its connection of the source chat & on the main connection of the target chat
+	sourceCtx, err := s.withNamedConnection(ctx, userID, chat, query.ConnectionName, req.StreamID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	sourceRecords, sourceWarnings, status, err := s.executeComparedQuery(sourceCtx, userID, chatID, msg, query, req.StreamID)
+	if err != nil {
+		return nil, status, err
+	}
+	targetRecords, targetWarnings, status, err := s.executeComparedQuery(ctx, userID, req.TargetChatID, msg, query, req.StreamID)
+	if err != nil {
+		return nil, status, fmt.Errorf("target chat: %v", err)
+	}
+
+	response := diffResultRecords(sourceRecords, targetRecords, req.KeyColumn)
+	response.ChatID = chatID
+	response.TargetChatID = req.TargetChatID
+	response.MessageID = req.MessageID
+	response.QueryID = req.QueryID
+	for _, warning := range sourceWarnings {
+		response.Warnings = append(response.Warnings, "Source: "+warning)
+	}
+	for _, warning := range targetWarnings {
+		response.Warnings = append(response.Warnings, "Target: "+warning)
+	}
+	return response, http.StatusOK, nil
+}
+
+// executeComparedQuery executes the query on the connection of a chat, connecting it if needed, & returns its records
+func (s *chatService) executeComparedQuery(ctx context.Context, userID, chatID string, msg *models.Message, query *models.Query, streamID string) ([]map[string]interface{}, []string, uint32, error) {
+	if !s.dbManager.IsConnected(chatID) {
+		if status, err := s.ConnectDB(ctx, userID, chatID, streamID); err != nil {
+			return nil, nil, status, err
+		}
+	}
+
+	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, msg.ID.Hex(), query.ID.Hex(), streamID, query.Query, *query.QueryType, false, false)
+	if queryErr != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to execute query: %s", queryErr.Message)
+	}
+	records, err := extractResultRecords(result.ResultJSON)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+	return records, result.Warnings, http.StatusOK, nil
+}
+
+// diffResultRecords matches the records of two results on the key column & lists the records missing from either side
+// & the columns that differ. Values are compared on their text so that the same value decoded differently by two
+// drivers, ex: 1 & "1", is equal. Records without the key are skipped & a repeated key only keeps its first record,
+// both are reported as warnings.
+func diffResultRecords(source, target []map[string]interface{}, keyColumn string) *dtos.QueryComparisonResponse {
+	response := &dtos.QueryComparisonResponse{
+		KeyColumn:      keyColumn,
+		SourceRowCount: len(source),
+		TargetRowCount: len(target),
+		OnlyInSource:   []interface{}{},
+		OnlyInTarget:   []interface{}{},
+		ChangedRows:    []dtos.RowDifference{},
+	}
+
+	sourceKeys, sourceByKey, sourceWarnings := indexRecordsByKey(source, keyColumn)
+	targetKeys, targetByKey, targetWarnings := indexRecordsByKey(target, keyColumn)
+	for _, warning := range sourceWarnings {
+		response.Warnings = append(response.Warnings, "Source: "+warning)
+	}
+	for _, warning := range targetWarnings {
+		response.Warnings = append(response.Warnings, "Target: "+warning)
+	}
+
+	for _, key := range sourceKeys {
+		sourceRecord := sourceByKey[key]
+		targetRecord, exists := targetByKey[key]
+		if !exists {
+			response.OnlyInSourceCount++
+			if len(response.OnlyInSource) < constants.MaxComparisonDifferences {
+				response.OnlyInSource = append(response.OnlyInSource, sourceRecord)
+			} else {
+				response.IsTruncated = true
+			}
+			continue
+		}
+
+		columns := diffRecordColumns(sourceRecord, targetRecord)
+		if len(columns) == 0 {
+			response.MatchingRowCount++
+			continue
+		}
+		response.ChangedRowCount++
+		if len(response.ChangedRows) < constants.MaxComparisonDifferences {
+			response.ChangedRows = append(response.ChangedRows, dtos.RowDifference{Key: sourceRecord[keyColumn], Columns: columns})
+		} else {
+			response.IsTruncated = true
+		}
+	}
+	for _, key := range targetKeys {
+		if _, exists := sourceByKey[key]; exists {
+			continue
+		}
+		response.OnlyInTargetCount++
+		if len(response.OnlyInTarget) < constants.MaxComparisonDifferences {
+			response.OnlyInTarget = append(response.OnlyInTarget, targetByKey[key])
+		} else {
+			response.IsTruncated = true
+		}
+	}
+	return response
+}
+
+// indexRecordsByKey maps the records on the text of their key, the keys are returned in the order of the result
+func indexRecordsByKey(records []map[string]interface{}, keyColumn string) ([]string, map[string]map[string]interface{}, []string) {
+	keys := make([]string, 0, len(records))
+	byKey := make(map[string]map[string]interface{}, len(records))
+	missing, duplicates := 0, 0
+	for _, record := range records {
+		value, exists := record[keyColumn]
+		if !exists || value == nil {
+			missing++
+			continue
+		}
+		key := fmt.Sprint(value)
+		if _, seen := byKey[key]; seen {
+			duplicates++
+			continue
+		}
+		keys = append(keys, key)
+		byKey[key] = record
+	}
+
+	var warnings []string
+	if missing > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d rows without a value for %s were skipped", missing, keyColumn))
+	}
+	if duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d rows repeat a value of %s, only the first row of each value was compared", duplicates, keyColumn))
+	}
+	return keys, byKey, warnings
+}
+
+// diffRecordColumns returns the columns of two records whose values differ, a column missing from a record is nil
+func diffRecordColumns(source, target map[string]interface{}) map[string]dtos.ColumnDifference {
+	differences := make(map[string]dtos.ColumnDifference)
+	for column, sourceValue := range source {
+		targetValue, exists := target[column]
+		if !exists || fmt.Sprint(sourceValue) != fmt.Sprint(targetValue) {
+			differences[column] = dtos.ColumnDifference{Source: sourceValue, Target: targetValue}
+		}
+	}
+	for column, targetValue := range target {
+		if _, exists := source[column]; !exists {
+			differences[column] = dtos.ColumnDifference{Source: nil, Target: targetValue}
+		}
+	}
+	return differences
+}
@@ -10,7 +10,9 @@ import (
 	"databot-ai/internal/utils"
 	"databot-ai/pkg/dbmanager"
 	"databot-ai/pkg/llm"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"sort"
@@ -38,40 +40,100 @@ type ChatService interface {
 	Delete(userID, chatID string) (uint32, error)
 	GetByID(userID, chatID string) (*dtos.ChatResponse, uint32, error)
 	List(userID string, page, pageSize int) (*dtos.ChatListResponse, uint32, error)
-	CreateMessage(ctx context.Context, userID, chatID string, streamID string, content string) (*dtos.MessageResponse, uint16, error)
+	CreateMessage(ctx context.Context, userID, chatID string, streamID string, content string, migration bool) (*dtos.MessageResponse, uint16, error)
 	UpdateMessage(ctx context.Context, userID, chatID, messageID string, streamID string, req *dtos.CreateMessageRequest) (*dtos.MessageResponse, uint32, error)
 	DeleteMessages(userID, chatID string) (uint32, error)
 	Duplicate(userID, chatID string, duplicateMessages bool) (*dtos.ChatResponse, uint32, error)
+	ExportChatTemplate(userID, chatID string) (*dtos.ChatTemplate, uint32, error)
+	ImportChatTemplate(userID string, req *dtos.ImportChatTemplateRequest) (*dtos.ChatResponse, uint32, error)
 	ListMessages(userID, chatID string, page, pageSize int) (*dtos.MessageListResponse, uint32, error)
 	EditQuery(ctx context.Context, userID, chatID, messageID, queryID string, query string) (*dtos.EditQueryResponse, uint32, error)
+	UpdateQueryNote(userID, chatID string, req *dtos.UpdateQueryNoteRequest) (*dtos.QueryNoteResponse, uint32, error)
+	ToggleQueryFavorite(userID, chatID, messageID, queryID string) (*dtos.QueryFavoriteResponse, uint32, error)
+	GetFavoriteQueries(userID string) ([]dtos.FavoriteQueryResponse, uint32, error)
 	GetDBConnectionStatus(ctx context.Context, userID, chatID string) (*dtos.ConnectionStatusResponse, uint32, error)
 	HandleSchemaChange(userID, chatID, streamID string, diff *dbmanager.SchemaDiff)
 	HandleDBEvent(userID, chatID, streamID string, response dtos.StreamResponse)
 	GetAllTables(ctx context.Context, userID, chatID string) (*dtos.TablesResponse, uint32, error)
 	GetSelectedCollections(chatID string) (string, error)
+	GetSchemaGraph(ctx context.Context, userID, chatID string) (*dtos.SchemaGraphResponse, uint32, error)
+	ExportSchemaDiagram(ctx context.Context, userID, chatID, format string) (*dtos.SchemaDiagramResponse, uint32, error)
+	GetSchemaStats(ctx context.Context, userID, chatID string) (*dtos.SchemaStatsResponse, uint32, error)
+
+	// Export operations
+	UpdateExportDestination(userID, chatID string, req *dtos.ExportDestinationRequest) (*dtos.ExportDestinationResponse, uint32, error)
+	DeleteExportDestination(userID, chatID string) (uint32, error)
+	UpdateQueryTemplates(ctx context.Context, userID, chatID string, req *dtos.UpdateQueryTemplatesRequest) ([]dtos.QueryTemplate, uint32, error)
+	UpdateSchemaDescriptions(ctx context.Context, userID, chatID string, req *dtos.UpdateSchemaDescriptionsRequest) ([]dtos.SchemaDescription, uint32, error)
+	ImportSchema(userID, chatID string, req *dtos.ImportSchemaRequest) (*dtos.ChatResponse, uint32, error)
+	ExportQueryResultsToCloud(ctx context.Context, userID, chatID string, req *dtos.CloudExportRequest) (*dtos.CloudExportResponse, uint32, error)
+	StreamQueryResults(ctx context.Context, userID, chatID string, req *dtos.StreamExportRequest, w io.Writer) (int, uint32, error)
+	ExportQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, w io.Writer) (int, uint32, error)
+	DownloadCellValue(ctx context.Context, userID, chatID, messageID, queryID, streamID string, row int, column string) (*CellContent, uint32, error)
+	CompareQueryResults(ctx context.Context, userID, chatID string, req *dtos.CompareQueryResultsRequest) (*dtos.QueryComparisonResponse, uint32, error)
 
 	// Execution operations
 	CancelProcessing(userID, chatID, streamID string)
 	ConnectDB(ctx context.Context, userID, chatID string, streamID string) (uint32, error)
 	DisconnectDB(ctx context.Context, userID, chatID string, streamID string) (uint32, error)
+	CloseDBSession(userID, chatID string) (uint32, error)
+	UpdateNamedConnection(userID, chatID, name string, req *dtos.CreateConnectionRequest) (*dtos.NamedConnectionResponse, uint32, error)
+	DeleteNamedConnection(userID, chatID, name string) (uint32, error)
 	ExecuteQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error)
 	RollbackQuery(ctx context.Context, userID, chatID string, req *dtos.RollbackQueryRequest) (*dtos.QueryExecutionResponse, uint32, error)
+	ExecuteQueriesInTransaction(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueriesInTransactionRequest) (*dtos.TransactionExecutionResponse, uint32, error)
+	GetExecutionHistory(userID, chatID string, limit, offset int) (*dtos.ExecutionHistoryResponse, uint32, error)
+	ExplainQuery(ctx context.Context, userID, chatID string, req *dtos.ExplainQueryRequest) (*dtos.QueryPlanResponse, uint32, error)
+	CreateSuggestedIndex(ctx context.Context, userID, chatID string, req *dtos.CreateSuggestedIndexRequest) (*dtos.MessageResponse, uint32, error)
+	ExplainResult(ctx context.Context, userID, chatID string, req *dtos.ExplainResultRequest) (*dtos.MessageResponse, uint32, error)
+	GetColumnValues(ctx context.Context, userID, chatID, streamID, table, column string, limit int) (*dtos.ColumnValuesResponse, uint32, error)
 	CancelQueryExecution(userID, chatID, messageID, queryID, streamID string)
 	processMessage(ctx context.Context, userID, chatID string, messageID, streamID string) error
 	processLLMResponseAndRunQuery(ctx context.Context, userID, chatID string, messageID, streamID string) error
-	RefreshSchema(ctx context.Context, userID, chatID string, sync bool) (uint32, error)
-	GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int) (*dtos.QueryResultsResponse, uint32, error)
+	RefreshSchema(ctx context.Context, userID, chatID string, sync bool, webhookURL string) (uint32, error)
+	GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int, allShards, bypassCache bool) (*dtos.QueryResultsResponse, uint32, error)
+	ListServerActivity(ctx context.Context, userID, chatID string, minDurationMs int64) (*dtos.ServerActivityResponse, uint32, error)
+	TerminateServerActivity(ctx context.Context, userID, chatID, activityID string, terminate bool) (uint32, error)
+	SubmitQueryParameters(ctx context.Context, userID, chatID, messageID string, req *dtos.SubmitQueryParametersRequest) (*dtos.MessageResponse, uint32, error)
+	RegenerateQuery(ctx context.Context, userID, chatID, messageID string, req *dtos.RegenerateQueryRequest) (*dtos.MessageResponse, uint32, error)
+
+	// Saved queries
+	CreateSavedQuery(userID string, req *dtos.CreateSavedQueryRequest) (*dtos.SavedQueryResponse, uint32, error)
+	ListSavedQueries(userID, connectionType string) ([]dtos.SavedQueryResponse, uint32, error)
+	DeleteSavedQuery(userID, savedQueryID string) (uint32, error)
+	ExecuteSavedQuery(ctx context.Context, userID, chatID, savedQueryID string, req *dtos.ExecuteSavedQueryRequest) (*dtos.ExecuteSavedQueryResponse, uint32, error)
+	CreateScheduledQuery(userID, chatID string, req *dtos.CreateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error)
+	ListScheduledQueries(userID, chatID string) ([]dtos.ScheduledQueryResponse, uint32, error)
+	UpdateScheduledQuery(userID, chatID, scheduledQueryID string, req *dtos.UpdateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error)
+	DeleteScheduledQuery(userID, chatID, scheduledQueryID string) (uint32, error)
+	StartQueryScheduler()
+
+	// Audit log
+	GetAuditLog(userID string, req *dtos.AuditLogRequest) (*dtos.AuditLogResponse, uint32, error)
+	StartAuditLogWriter()
 }
 
 type chatService struct {
 	chatRepo        repositories.ChatRepository
 	llmRepo         repositories.LLMMessageRepository
+	executionRepo   repositories.QueryExecutionRepository
 	dbManager       *dbmanager.Manager
 	llmClient       llm.Client
 	streamChans     map[string]chan dtos.StreamResponse
 	streamHandler   StreamHandler
 	activeProcesses map[string]context.CancelFunc // key: streamID
 	processesMu     sync.RWMutex
+	userRepo        repositories.UserRepository        // Resolves the tenant of the users, see tenantOf
+	tenantUsageRepo repositories.TenantUsageRepository // Counts the use of the quotas of the tenants
+	rateLimitRepo   repositories.RateLimitRepository   // Limits the LLM generations of each user, see checkLLMRateLimit
+	savedQueryRepo  repositories.SavedQueryRepository  // Queries the users keep to run again, see ExecuteSavedQuery
+
+	scheduledQueryRepo repositories.ScheduledQueryRepository // Queries run on a cron schedule, see StartQueryScheduler
+	runningSchedules   map[string]bool                       // IDs of the scheduled queries being run, a run doesn't start while the previous one executes
+	schedulesMu        sync.Mutex
+
+	auditLogRepo    repositories.AuditLogRepository // Append only log of the schema refreshes, generations, executions & rollbacks
+	auditLogEntries chan *models.AuditLog           // Entries waiting to be written, see StartAuditLogWriter
 }
 
 func isValidDBType(dbType string) bool {
@@ -79,10 +141,15 @@ func isValidDBType(dbType string) bool {
 		constants.DatabaseTypePostgreSQL,
 		constants.DatabaseTypeYugabyteDB,
 		constants.DatabaseTypeMySQL,
+		constants.DatabaseTypeMariaDB,
 		constants.DatabaseTypeClickhouse,
 		constants.DatabaseTypeMongoDB,
 		constants.DatabaseTypeRedis,
 		constants.DatabaseTypeNeo4j,
+		constants.DatabaseTypeSQLite,
+		constants.DatabaseTypeBigQuery,
+		constants.DatabaseTypeElasticsearch,
+		constants.DatabaseTypeCassandra,
 	}
 
 	for _, validType := range validTypes {
@@ -108,10 +175,25 @@ func (s *chatService) sendStreamEvent(userID, chatID, streamID string, response
 	}
 }
 
+// llmQueuedNotifier returns the function sending a step to the stream when an LLM call waits for a slot, see
+// llm.WithQueuedNotifier
+func (s *chatService) llmQueuedNotifier(userID, chatID, streamID string) func(queued int) {
+	return func(queued int) {
+		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+			Event: "ai-response-step",
+			Data:  fmt.Sprintf("Queued, waiting for the AI to be available (%d requests waiting)..", queued),
+		})
+	}
+}
+
 // Add method to handle DB status events
 func (s *chatService) HandleDBEvent(userID, chatID, streamID string, response dtos.StreamResponse) {
 	// Send to stream handler
 	log.Printf("ChatService -> HandleDBEvent -> response: %+v", response)
+	if _, name := dbmanager.SplitConnectionKey(chatID); name != "" {
+		// The status of the chat is the one of its main connection
+		return
+	}
 	if s.streamHandler != nil {
 		s.streamHandler.HandleStreamEvent(userID, chatID, streamID, response)
 	}
@@ -120,16 +202,34 @@ func (s *chatService) HandleDBEvent(userID, chatID, streamID string, response dt
 func NewChatService(
 	chatRepo repositories.ChatRepository,
 	llmRepo repositories.LLMMessageRepository,
+	executionRepo repositories.QueryExecutionRepository,
 	dbManager *dbmanager.Manager,
 	llmClient llm.Client,
+	userRepo repositories.UserRepository,
+	tenantUsageRepo repositories.TenantUsageRepository,
+	rateLimitRepo repositories.RateLimitRepository,
+	savedQueryRepo repositories.SavedQueryRepository,
+	scheduledQueryRepo repositories.ScheduledQueryRepository,
+	auditLogRepo repositories.AuditLogRepository,
 ) ChatService {
 	return &chatService{
 		chatRepo:        chatRepo,
 		llmRepo:         llmRepo,
+		executionRepo:   executionRepo,
 		dbManager:       dbManager,
 		llmClient:       llmClient,
 		streamChans:     make(map[string]chan dtos.StreamResponse),
 		activeProcesses: make(map[string]context.CancelFunc),
+		userRepo:        userRepo,
+		tenantUsageRepo: tenantUsageRepo,
+		rateLimitRepo:   rateLimitRepo,
+		savedQueryRepo:  savedQueryRepo,
+
+		scheduledQueryRepo: scheduledQueryRepo,
+		runningSchedules:   make(map[string]bool),
+
+		auditLogRepo:    auditLogRepo,
+		auditLogEntries: make(chan *models.AuditLog, config.Env.AuditLogBufferSize),
 	}
 }
 
@@ -158,22 +258,43 @@ func (s *chatService) Create(userID string, req *dtos.CreateChatRequest) (*dtos.
 		return nil, http.StatusBadRequest, fmt.Errorf("unsupported database type: %s", req.Connection.Type)
 	}
 
-	// Test connection without creating a persistent connection
-	err := s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		SSLMode:        req.Connection.SSLMode,
-		UseSSL:         req.Connection.UseSSL,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-	})
-	if err != nil {
-		return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+	// Generate only chats never connect, the connection only tells the database type
+	generateOnly := req.Settings.GenerateOnly != nil && *req.Settings.GenerateOnly
+	if err := validateConnectionDetails(&req.Connection, generateOnly); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var tlsInfo *dbmanager.TLSInfo
+	if !generateOnly {
+		// Test connection without creating a persistent connection
+		var err error
+		tlsInfo, err = s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
+			Type:            req.Connection.Type,
+			Host:            req.Connection.Host,
+			Hosts:           req.Connection.Hosts,
+			Shards:          req.Connection.Shards,
+			Port:            req.Connection.Port,
+			Username:        &req.Connection.Username,
+			Password:        req.Connection.Password,
+			Database:        req.Connection.Database,
+			SSLMode:         req.Connection.SSLMode,
+			UseSSL:          req.Connection.UseSSL,
+			SSLCertURL:      req.Connection.SSLCertURL,
+			SSLKeyURL:       req.Connection.SSLKeyURL,
+			SSLRootCertURL:  req.Connection.SSLRootCertURL,
+			Role:            req.Connection.Role,
+			FilePath:        req.Connection.FilePath,
+			ProjectID:       req.Connection.ProjectID,
+			CredentialsJSON: req.Connection.CredentialsJSON,
+			SSHHost:         req.Connection.SSHHost,
+			SSHPort:         req.Connection.SSHPort,
+			SSHUser:         req.Connection.SSHUser,
+			SSHPrivateKey:   req.Connection.SSHPrivateKey,
+			SSHHostKey:      req.Connection.SSHHostKey,
+		})
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+		}
 	}
 
 	userObjID, err := primitive.ObjectIDFromHex(userID)
@@ -183,18 +304,34 @@ func (s *chatService) Create(userID string, req *dtos.CreateChatRequest) (*dtos.
 
 	// Create connection object with SSL configuration
 	connection := models.Connection{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		SSLMode:        req.Connection.SSLMode,
-		UseSSL:         req.Connection.UseSSL,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-		Base:           models.NewBase(),
+		Type:            req.Connection.Type,
+		Host:            req.Connection.Host,
+		Hosts:           req.Connection.Hosts,
+		Shards:          req.Connection.Shards,
+		Port:            req.Connection.Port,
+		Username:        &req.Connection.Username,
+		Password:        req.Connection.Password,
+		Database:        req.Connection.Database,
+		SSLMode:         req.Connection.SSLMode,
+		UseSSL:          req.Connection.UseSSL,
+		SSLCertURL:      req.Connection.SSLCertURL,
+		SSLKeyURL:       req.Connection.SSLKeyURL,
+		SSLRootCertURL:  req.Connection.SSLRootCertURL,
+		MaxResultRows:   req.Connection.MaxResultRows,
+		Role:            req.Connection.Role,
+		FilePath:        req.Connection.FilePath,
+		ProjectID:       req.Connection.ProjectID,
+		CredentialsJSON: req.Connection.CredentialsJSON,
+		SSHHost:         req.Connection.SSHHost,
+		SSHPort:         req.Connection.SSHPort,
+		SSHUser:         req.Connection.SSHUser,
+		SSHPrivateKey:   req.Connection.SSHPrivateKey,
+		SSHHostKey:      req.Connection.SSHHostKey,
+
+		MaxOpenConns:    req.Connection.MaxOpenConns,
+		MaxIdleConns:    req.Connection.MaxIdleConns,
+		ConnMaxLifetime: req.Connection.ConnMaxLifetime,
+		Base:            models.NewBase(),
 	}
 
 	// Encrypt connection details
@@ -210,12 +347,63 @@ func (s *chatService) Create(userID string, req *dtos.CreateChatRequest) (*dtos.
 	if req.Settings.ShareDataWithAI != nil {
 		settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 	}
+	if req.Settings.ColumnMasks != nil {
+		if err := validateColumnMasks(req.Settings.ColumnMasks); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.ColumnMasks = req.Settings.ColumnMasks
+	}
+	if req.Settings.SessionMode != nil {
+		if *req.Settings.SessionMode && !dbmanager.SessionSupported(req.Connection.Type) {
+			return nil, http.StatusBadRequest, fmt.Errorf("session mode is not supported for %s", req.Connection.Type)
+		}
+		settings.SessionMode = *req.Settings.SessionMode
+	}
+	if req.Settings.AnonymizeSchema != nil {
+		settings.AnonymizeSchema = *req.Settings.AnonymizeSchema
+	}
+	if req.Settings.DisableExampleRecords != nil {
+		settings.DisableExampleRecords = *req.Settings.DisableExampleRecords
+	}
+	if req.Settings.TextCollation != nil {
+		if err := dbmanager.ValidateTextCollation(req.Connection.Type, *req.Settings.TextCollation); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.TextCollation = strings.TrimSpace(*req.Settings.TextCollation)
+	}
+	if req.Settings.ReadOnly != nil {
+		settings.ReadOnly = *req.Settings.ReadOnly
+	}
+	if req.Settings.DisableResponseCache != nil {
+		settings.DisableResponseCache = *req.Settings.DisableResponseCache
+	}
+	if req.Settings.DefaultPageSize != nil {
+		if err := validatePageSize(*req.Settings.DefaultPageSize); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.DefaultPageSize = *req.Settings.DefaultPageSize
+	}
+	if req.Settings.QueryTimeoutSeconds != nil {
+		if err := validateQueryTimeout(*req.Settings.QueryTimeoutSeconds); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.QueryTimeoutSeconds = *req.Settings.QueryTimeoutSeconds
+	}
+	if req.Settings.SchemaRefreshWebhookURL != nil {
+		if err := validateWebhookURL(*req.Settings.SchemaRefreshWebhookURL); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.SchemaRefreshWebhookURL = *req.Settings.SchemaRefreshWebhookURL
+	}
+	settings.GenerateOnly = generateOnly
 	// Create chat with connection
 	chat := models.NewChat(userObjID, connection, settings)
 	if err := s.chatRepo.Create(chat); err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
-	return s.buildChatResponse(chat), http.StatusCreated, nil
+	response := s.buildChatResponse(chat)
+	response.Connection.TLS = buildTLSInfoResponse(tlsInfo)
+	return response, http.StatusCreated, nil
 }
 
 // Create a new chat without connection ping
@@ -243,6 +431,11 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 		return nil, http.StatusBadRequest, fmt.Errorf("unsupported database type: %s", req.Connection.Type)
 	}
 
+	generateOnly := req.Settings.GenerateOnly != nil && *req.Settings.GenerateOnly
+	if err := validateConnectionDetails(&req.Connection, generateOnly); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
@@ -250,19 +443,35 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 
 	// Create connection object with SSL configuration
 	connection := models.Connection{
-		Type:           req.Connection.Type,
-		Host:           req.Connection.Host,
-		Port:           req.Connection.Port,
-		Username:       &req.Connection.Username,
-		Password:       req.Connection.Password,
-		Database:       req.Connection.Database,
-		IsExampleDB:    true, // default is true, if false, then the database is a user's own database
-		UseSSL:         req.Connection.UseSSL,
-		SSLMode:        req.Connection.SSLMode,
-		SSLCertURL:     req.Connection.SSLCertURL,
-		SSLKeyURL:      req.Connection.SSLKeyURL,
-		SSLRootCertURL: req.Connection.SSLRootCertURL,
-		Base:           models.NewBase(),
+		Type:            req.Connection.Type,
+		Host:            req.Connection.Host,
+		Hosts:           req.Connection.Hosts,
+		Shards:          req.Connection.Shards,
+		Port:            req.Connection.Port,
+		Username:        &req.Connection.Username,
+		Password:        req.Connection.Password,
+		Database:        req.Connection.Database,
+		IsExampleDB:     true, // default is true, if false, then the database is a user's own database
+		UseSSL:          req.Connection.UseSSL,
+		SSLMode:         req.Connection.SSLMode,
+		SSLCertURL:      req.Connection.SSLCertURL,
+		SSLKeyURL:       req.Connection.SSLKeyURL,
+		SSLRootCertURL:  req.Connection.SSLRootCertURL,
+		MaxResultRows:   req.Connection.MaxResultRows,
+		Role:            req.Connection.Role,
+		FilePath:        req.Connection.FilePath,
+		ProjectID:       req.Connection.ProjectID,
+		CredentialsJSON: req.Connection.CredentialsJSON,
+		SSHHost:         req.Connection.SSHHost,
+		SSHPort:         req.Connection.SSHPort,
+		SSHUser:         req.Connection.SSHUser,
+		SSHPrivateKey:   req.Connection.SSHPrivateKey,
+		SSHHostKey:      req.Connection.SSHHostKey,
+
+		MaxOpenConns:    req.Connection.MaxOpenConns,
+		MaxIdleConns:    req.Connection.MaxIdleConns,
+		ConnMaxLifetime: req.Connection.ConnMaxLifetime,
+		Base:            models.NewBase(),
 	}
 
 	// Encrypt connection details
@@ -279,6 +488,55 @@ func (s *chatService) CreateWithoutConnectionPing(userID string, req *dtos.Creat
 	if req.Settings.ShareDataWithAI != nil {
 		settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 	}
+	if req.Settings.ColumnMasks != nil {
+		if err := validateColumnMasks(req.Settings.ColumnMasks); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.ColumnMasks = req.Settings.ColumnMasks
+	}
+	if req.Settings.SessionMode != nil {
+		if *req.Settings.SessionMode && !dbmanager.SessionSupported(req.Connection.Type) {
+			return nil, http.StatusBadRequest, fmt.Errorf("session mode is not supported for %s", req.Connection.Type)
+		}
+		settings.SessionMode = *req.Settings.SessionMode
+	}
+	if req.Settings.AnonymizeSchema != nil {
+		settings.AnonymizeSchema = *req.Settings.AnonymizeSchema
+	}
+	if req.Settings.DisableExampleRecords != nil {
+		settings.DisableExampleRecords = *req.Settings.DisableExampleRecords
+	}
+	if req.Settings.TextCollation != nil {
+		if err := dbmanager.ValidateTextCollation(req.Connection.Type, *req.Settings.TextCollation); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.TextCollation = strings.TrimSpace(*req.Settings.TextCollation)
+	}
+	if req.Settings.ReadOnly != nil {
+		settings.ReadOnly = *req.Settings.ReadOnly
+	}
+	if req.Settings.DisableResponseCache != nil {
+		settings.DisableResponseCache = *req.Settings.DisableResponseCache
+	}
+	if req.Settings.DefaultPageSize != nil {
+		if err := validatePageSize(*req.Settings.DefaultPageSize); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.DefaultPageSize = *req.Settings.DefaultPageSize
+	}
+	if req.Settings.QueryTimeoutSeconds != nil {
+		if err := validateQueryTimeout(*req.Settings.QueryTimeoutSeconds); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.QueryTimeoutSeconds = *req.Settings.QueryTimeoutSeconds
+	}
+	if req.Settings.SchemaRefreshWebhookURL != nil {
+		if err := validateWebhookURL(*req.Settings.SchemaRefreshWebhookURL); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		settings.SchemaRefreshWebhookURL = *req.Settings.SchemaRefreshWebhookURL
+	}
+	settings.GenerateOnly = generateOnly
 	// Create chat with connection
 	chat := models.NewChat(userObjID, connection, settings)
 	if err := s.chatRepo.Create(chat); err != nil {
@@ -299,6 +557,9 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
 	}
 
+	// TLS negotiated by the connection test, set when the connection is updated
+	var tlsInfo *dbmanager.TLSInfo
+
 	// Get the chat
 	chat, err := s.chatRepo.FindByID(chatObjID)
 	if err != nil {
@@ -313,6 +574,11 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 		return nil, http.StatusForbidden, fmt.Errorf("chat does not belong to user")
 	}
 
+	generateOnly := chat.Settings.GenerateOnly
+	if req.Settings != nil && req.Settings.GenerateOnly != nil {
+		generateOnly = *req.Settings.GenerateOnly
+	}
+
 	// Check for connection changes
 	var credentialsChanged bool
 	if req.Connection != nil {
@@ -325,45 +591,105 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 		existingConn := chat.Connection
 		utils.DecryptConnection(&existingConn)
 
+		// The service account key of a BigQuery connection is never sent back, an update without one keeps it
+		if req.Connection.Type == constants.DatabaseTypeBigQuery && req.Connection.CredentialsJSON == "" && existingConn.Type == constants.DatabaseTypeBigQuery {
+			req.Connection.CredentialsJSON = existingConn.CredentialsJSON
+		}
+		// Neither is the SSH private key, an update through the same bastion without one keeps it
+		if req.Connection.SSHHost != "" && req.Connection.SSHPrivateKey == "" && existingConn.SSHHost == req.Connection.SSHHost {
+			req.Connection.SSHPrivateKey = existingConn.SSHPrivateKey
+		}
+		if err := validateConnectionDetails(req.Connection, generateOnly); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		// The named connections of the chat are databases of the same type
+		if req.Connection.Type != existingConn.Type && len(chat.Connections) > 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("remove the named connections of the chat before changing its database type")
+		}
+
 		// Check if critical connection details have changed
 		credentialsChanged = existingConn.Database != req.Connection.Database ||
 			existingConn.Host != req.Connection.Host ||
+			strings.Join(existingConn.Hosts, ",") != strings.Join(req.Connection.Hosts, ",") ||
+			strings.Join(existingConn.Shards, ",") != strings.Join(req.Connection.Shards, ",") ||
 			existingConn.Port != req.Connection.Port ||
+			existingConn.MaxResultRows != req.Connection.MaxResultRows ||
+			existingConn.Role != req.Connection.Role ||
+			existingConn.FilePath != req.Connection.FilePath ||
+			existingConn.ProjectID != req.Connection.ProjectID ||
+			(req.Connection.CredentialsJSON != "" && existingConn.CredentialsJSON != req.Connection.CredentialsJSON) ||
+			existingConn.SSHHost != req.Connection.SSHHost ||
+			existingConn.SSHPort != req.Connection.SSHPort ||
+			existingConn.SSHUser != req.Connection.SSHUser ||
+			existingConn.SSHPrivateKey != req.Connection.SSHPrivateKey ||
+			existingConn.SSHHostKey != req.Connection.SSHHostKey ||
+			existingConn.MaxOpenConns != req.Connection.MaxOpenConns ||
+			existingConn.MaxIdleConns != req.Connection.MaxIdleConns ||
+			existingConn.ConnMaxLifetime != req.Connection.ConnMaxLifetime ||
 			*existingConn.Username != req.Connection.Username ||
 			(req.Connection.Password != nil && existingConn.Password != nil && *existingConn.Password != *req.Connection.Password)
 
-		// Test connection without creating a persistent connection
-		err = s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
-			Type:           req.Connection.Type,
-			Host:           req.Connection.Host,
-			Port:           req.Connection.Port,
-			Username:       &req.Connection.Username,
-			Password:       req.Connection.Password,
-			Database:       req.Connection.Database,
-			UseSSL:         req.Connection.UseSSL,
-			SSLMode:        req.Connection.SSLMode,
-			SSLCertURL:     req.Connection.SSLCertURL,
-			SSLKeyURL:      req.Connection.SSLKeyURL,
-			SSLRootCertURL: req.Connection.SSLRootCertURL,
-		})
-		if err != nil {
-			return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+		if !generateOnly {
+			// Test connection without creating a persistent connection
+			tlsInfo, err = s.dbManager.TestConnection(&dbmanager.ConnectionConfig{
+				Type:            req.Connection.Type,
+				Host:            req.Connection.Host,
+				Hosts:           req.Connection.Hosts,
+				Shards:          req.Connection.Shards,
+				Port:            req.Connection.Port,
+				Username:        &req.Connection.Username,
+				Password:        req.Connection.Password,
+				Database:        req.Connection.Database,
+				UseSSL:          req.Connection.UseSSL,
+				SSLMode:         req.Connection.SSLMode,
+				SSLCertURL:      req.Connection.SSLCertURL,
+				SSLKeyURL:       req.Connection.SSLKeyURL,
+				SSLRootCertURL:  req.Connection.SSLRootCertURL,
+				Role:            req.Connection.Role,
+				FilePath:        req.Connection.FilePath,
+				ProjectID:       req.Connection.ProjectID,
+				CredentialsJSON: req.Connection.CredentialsJSON,
+				SSHHost:         req.Connection.SSHHost,
+				SSHPort:         req.Connection.SSHPort,
+				SSHUser:         req.Connection.SSHUser,
+				SSHPrivateKey:   req.Connection.SSHPrivateKey,
+				SSHHostKey:      req.Connection.SSHHostKey,
+			})
+			if err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+			}
 		}
 
 		// Create connection object with SSL configuration
 		connection := models.Connection{
-			Type:           req.Connection.Type,
-			Host:           req.Connection.Host,
-			Port:           req.Connection.Port,
-			Username:       &req.Connection.Username,
-			Password:       req.Connection.Password,
-			Database:       req.Connection.Database,
-			UseSSL:         req.Connection.UseSSL,
-			SSLMode:        req.Connection.SSLMode,
-			SSLCertURL:     req.Connection.SSLCertURL,
-			SSLKeyURL:      req.Connection.SSLKeyURL,
-			SSLRootCertURL: req.Connection.SSLRootCertURL,
-			Base:           models.NewBase(),
+			Type:            req.Connection.Type,
+			Host:            req.Connection.Host,
+			Hosts:           req.Connection.Hosts,
+			Shards:          req.Connection.Shards,
+			Port:            req.Connection.Port,
+			Username:        &req.Connection.Username,
+			Password:        req.Connection.Password,
+			Database:        req.Connection.Database,
+			UseSSL:          req.Connection.UseSSL,
+			SSLMode:         req.Connection.SSLMode,
+			SSLCertURL:      req.Connection.SSLCertURL,
+			SSLKeyURL:       req.Connection.SSLKeyURL,
+			SSLRootCertURL:  req.Connection.SSLRootCertURL,
+			MaxResultRows:   req.Connection.MaxResultRows,
+			Role:            req.Connection.Role,
+			FilePath:        req.Connection.FilePath,
+			ProjectID:       req.Connection.ProjectID,
+			CredentialsJSON: req.Connection.CredentialsJSON,
+			SSHHost:         req.Connection.SSHHost,
+			SSHPort:         req.Connection.SSHPort,
+			SSHUser:         req.Connection.SSHUser,
+			SSHPrivateKey:   req.Connection.SSHPrivateKey,
+			SSHHostKey:      req.Connection.SSHHostKey,
+
+			MaxOpenConns:    req.Connection.MaxOpenConns,
+			MaxIdleConns:    req.Connection.MaxIdleConns,
+			ConnMaxLifetime: req.Connection.ConnMaxLifetime,
+			Base:            models.NewBase(),
 		}
 
 		// Encrypt connection details
@@ -379,6 +705,8 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 				log.Printf("ChatService -> Update -> Warning: Failed to disconnect existing connection: %v", err)
 				// Don't return error as we still want to update the connection details
 			}
+			// The cached results were read from the previous database
+			s.dbManager.InvalidateQueryResultCache(context.Background(), chatID)
 		}
 
 		chat.Connection = connection
@@ -394,6 +722,8 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 	oldSelectedCollections := chat.SelectedCollections
 	// Flag to track if selected collections changed
 	selectedCollectionsChanged := false
+	// Flag to track if example records were enabled or disabled, the schema is rebuilt with or without them
+	exampleRecordsChanged := false
 
 	// Update selected collections if provided
 	if req.SelectedCollections != nil {
@@ -414,6 +744,79 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 			log.Printf("ChatService -> Update -> ShareDataWithAI: %v", *req.Settings.ShareDataWithAI)
 			chat.Settings.ShareDataWithAI = *req.Settings.ShareDataWithAI
 		}
+		if req.Settings.ColumnMasks != nil {
+			if err := validateColumnMasks(req.Settings.ColumnMasks); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			log.Printf("ChatService -> Update -> ColumnMasks: %v", req.Settings.ColumnMasks)
+			chat.Settings.ColumnMasks = req.Settings.ColumnMasks
+			s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+		}
+		if req.Settings.SessionMode != nil {
+			if *req.Settings.SessionMode && !dbmanager.SessionSupported(chat.Connection.Type) {
+				return nil, http.StatusBadRequest, fmt.Errorf("session mode is not supported for %s", chat.Connection.Type)
+			}
+			log.Printf("ChatService -> Update -> SessionMode: %v", *req.Settings.SessionMode)
+			chat.Settings.SessionMode = *req.Settings.SessionMode
+			// Disabling session mode closes the current session, enabling it opens one on the next query
+			s.dbManager.SetSessionMode(chatID, chat.Settings.SessionMode)
+		}
+		if req.Settings.AnonymizeSchema != nil {
+			log.Printf("ChatService -> Update -> AnonymizeSchema: %v", *req.Settings.AnonymizeSchema)
+			chat.Settings.AnonymizeSchema = *req.Settings.AnonymizeSchema
+		}
+		if req.Settings.DisableExampleRecords != nil && *req.Settings.DisableExampleRecords != chat.Settings.DisableExampleRecords {
+			log.Printf("ChatService -> Update -> DisableExampleRecords: %v", *req.Settings.DisableExampleRecords)
+			chat.Settings.DisableExampleRecords = *req.Settings.DisableExampleRecords
+			s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+			exampleRecordsChanged = true
+		}
+		if req.Settings.TextCollation != nil {
+			if err := dbmanager.ValidateTextCollation(chat.Connection.Type, *req.Settings.TextCollation); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			log.Printf("ChatService -> Update -> TextCollation: %s", *req.Settings.TextCollation)
+			chat.Settings.TextCollation = strings.TrimSpace(*req.Settings.TextCollation)
+		}
+		if req.Settings.ReadOnly != nil {
+			log.Printf("ChatService -> Update -> ReadOnly: %v", *req.Settings.ReadOnly)
+			chat.Settings.ReadOnly = *req.Settings.ReadOnly
+		}
+		if req.Settings.DisableResponseCache != nil {
+			log.Printf("ChatService -> Update -> DisableResponseCache: %v", *req.Settings.DisableResponseCache)
+			chat.Settings.DisableResponseCache = *req.Settings.DisableResponseCache
+		}
+		if req.Settings.DefaultPageSize != nil {
+			if err := validatePageSize(*req.Settings.DefaultPageSize); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			log.Printf("ChatService -> Update -> DefaultPageSize: %d", *req.Settings.DefaultPageSize)
+			chat.Settings.DefaultPageSize = *req.Settings.DefaultPageSize
+		}
+		if req.Settings.QueryTimeoutSeconds != nil {
+			if err := validateQueryTimeout(*req.Settings.QueryTimeoutSeconds); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			log.Printf("ChatService -> Update -> QueryTimeoutSeconds: %d", *req.Settings.QueryTimeoutSeconds)
+			chat.Settings.QueryTimeoutSeconds = *req.Settings.QueryTimeoutSeconds
+		}
+		if req.Settings.SchemaRefreshWebhookURL != nil {
+			if err := validateWebhookURL(*req.Settings.SchemaRefreshWebhookURL); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			log.Printf("ChatService -> Update -> SchemaRefreshWebhookURL: %s", *req.Settings.SchemaRefreshWebhookURL)
+			chat.Settings.SchemaRefreshWebhookURL = *req.Settings.SchemaRefreshWebhookURL
+		}
+		if req.Settings.GenerateOnly != nil {
+			log.Printf("ChatService -> Update -> GenerateOnly: %v", *req.Settings.GenerateOnly)
+			if *req.Settings.GenerateOnly && s.dbManager.IsConnected(chatID) {
+				// The chat stops touching the database, the schema comes from the imported one from now on
+				if err := s.dbManager.Disconnect(chatID, userID, false); err != nil {
+					log.Printf("ChatService -> Update -> Warning: Failed to disconnect generate only chat: %v", err)
+				}
+			}
+			chat.Settings.GenerateOnly = *req.Settings.GenerateOnly
+		}
 	}
 
 	// Update the chat
@@ -421,9 +824,9 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
 	}
 
-	// If selected collections changed, trigger a schema refresh
-	if selectedCollectionsChanged {
-		log.Printf("ChatService -> Update -> Triggering schema refresh due to selected collections change")
+	// If selected collections or the example records setting changed, trigger a schema refresh
+	if (selectedCollectionsChanged || exampleRecordsChanged) && !chat.Settings.GenerateOnly {
+		log.Printf("ChatService -> Update -> Triggering schema refresh due to selected collections or example records change")
 		go func() {
 			// Create a completely new context with a much longer timeout
 			// This ensures it's not tied to the API request context
@@ -431,14 +834,16 @@ func (s *chatService) Update(userID, chatID string, req *dtos.UpdateChatRequest)
 			defer cancel()
 
 			log.Printf("ChatService -> Update -> Starting schema refresh with 60-minute timeout")
-			_, err := s.RefreshSchema(ctx, userID, chatID, false)
+			_, err := s.RefreshSchema(ctx, userID, chatID, false, "")
 			if err != nil {
 				log.Printf("ChatService -> Update -> Error refreshing schema: %v", err)
 			}
 		}()
 	}
 
-	return s.buildChatResponse(chat), http.StatusOK, nil
+	response := s.buildChatResponse(chat)
+	response.Connection.TLS = buildTLSInfoResponse(tlsInfo)
+	return response, http.StatusOK, nil
 }
 
 // Delete a chat
@@ -480,11 +885,26 @@ func (s *chatService) Delete(userID, chatID string) (uint32, error) {
 		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat messages: %v", err)
 	}
 
+	// Delete execution history
+	if err := s.executionRepo.DeleteByChatID(chatObjID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat execution history: %v", err)
+	}
+
+	// Delete scheduled queries
+	if err := s.scheduledQueryRepo.DeleteByChatID(chatObjID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete chat scheduled queries: %v", err)
+	}
+
 	go func() {
 		// Delete DB connection
 		if err := s.dbManager.Disconnect(chatID, userID, true); err != nil {
 			log.Printf("failed to delete DB connection: %v", err)
 		}
+		for _, named := range chat.Connections {
+			if err := s.dbManager.Disconnect(dbmanager.NamedConnectionKey(chatID, named.Name), userID, true); err != nil {
+				log.Printf("failed to delete DB connection %s: %v", named.Name, err)
+			}
+		}
 	}()
 
 	return http.StatusOK, nil
@@ -523,14 +943,18 @@ func (s *chatService) List(userID string, page, pageSize int) (*dtos.ChatListRes
 		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
 	}
 
+	page, pageSize = normalizePage(page, pageSize)
 	chats, total, err := s.chatRepo.FindByUserID(userObjID, page, pageSize)
 	if err != nil {
 		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chats: %v", err)
 	}
 
 	response := &dtos.ChatListResponse{
-		Chats: make([]dtos.ChatResponse, len(chats)),
-		Total: total,
+		Chats:    make([]dtos.ChatResponse, len(chats)),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(page*pageSize) < total,
 	}
 
 	for i, chat := range chats {
@@ -540,8 +964,23 @@ func (s *chatService) List(userID string, page, pageSize int) (*dtos.ChatListRes
 	return response, http.StatusOK, nil
 }
 
+// normalizePage bounds the page & page size requested to a list endpoint, pages start at 1 & can't be larger than
+// MAX_LIST_PAGE_SIZE
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	if pageSize > config.Env.MaxListPageSize {
+		pageSize = config.Env.MaxListPageSize
+	}
+	return page, pageSize
+}
+
 // Create a new message
-func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string, streamID string, content string) (*dtos.MessageResponse, uint16, error) {
+func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string, streamID string, content string, migration bool) (*dtos.MessageResponse, uint16, error) {
 	// Validate chat exists and user has access
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
@@ -555,6 +994,9 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 	if chat == nil {
 		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
 	}
+	if migration && !dbmanager.MigrationSupported(chat.Connection.Type) {
+		return nil, http.StatusBadRequest, fmt.Errorf("schema migrations are not supported for %s", chat.Connection.Type)
+	}
 
 	// Create and save the user message first
 	userObjID, err := primitive.ObjectIDFromHex(userID)
@@ -563,11 +1005,12 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 	}
 
 	msg := &models.Message{
-		Base:    models.NewBase(),
-		UserID:  userObjID,
-		ChatID:  chatObjID,
-		Content: content,
-		Type:    string(constants.MessageTypeUser),
+		Base:        models.NewBase(),
+		UserID:      userObjID,
+		ChatID:      chatObjID,
+		Content:     content,
+		Type:        string(constants.MessageTypeUser),
+		IsMigration: migration,
 	}
 
 	if err := s.chatRepo.CreateMessage(msg); err != nil {
@@ -581,9 +1024,7 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 		ChatID:    chatObjID,
 		MessageID: msg.ID,
 		Role:      string(constants.MessageTypeUser),
-		Content: map[string]interface{}{
-			"user_message": content,
-		},
+		Content:   migrationLLMContent(content, migration),
 	}
 	if err := s.llmRepo.CreateMessage(llmMsg); err != nil {
 		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save LLM message: %v", err)
@@ -591,7 +1032,7 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 
 	log.Printf("ChatService -> CreateMessage -> AutoExecuteQuery: %v", chat.Settings.AutoExecuteQuery)
 	// If auto execute query is true, we need to process LLM response & run query automatically
-	if chat.Settings.AutoExecuteQuery {
+	if chat.Settings.AutoExecuteQuery && !chat.Settings.GenerateOnly {
 		if err := s.processLLMResponseAndRunQuery(ctx, userID, chatID, msg.ID.Hex(), streamID); err != nil {
 			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
 		}
@@ -604,11 +1045,12 @@ func (s *chatService) CreateMessage(ctx context.Context, userID, chatID string,
 
 	// Return the actual message ID
 	return &dtos.MessageResponse{
-		ID:        msg.ID.Hex(), // Use actual message ID
-		ChatID:    chatID,
-		Content:   content,
-		Type:      string(constants.MessageTypeUser),
-		CreatedAt: msg.CreatedAt.Format(time.RFC3339),
+		ID:          msg.ID.Hex(), // Use actual message ID
+		ChatID:      chatID,
+		Content:     content,
+		Type:        string(constants.MessageTypeUser),
+		IsMigration: migration,
+		CreatedAt:   msg.CreatedAt.Format(time.RFC3339),
 	}, http.StatusOK, nil
 }
 
@@ -687,16 +1129,15 @@ func (s *chatService) UpdateMessage(ctx context.Context, userID, chatID, message
 	}
 
 	log.Printf("UpdateMessage -> llmMsg: %+v", llmMsg)
-	llmMsg.Content = map[string]interface{}{
-		"user_message": req.Content,
-	}
+	// An edited migration request stays a migration request
+	llmMsg.Content = migrationLLMContent(req.Content, message.IsMigration)
 
 	if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
 		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update LLM message: %v", err)
 	}
 
 	// If auto execute query is true, we need to process LLM response & run query automatically
-	if chat.Settings.AutoExecuteQuery {
+	if chat.Settings.AutoExecuteQuery && !chat.Settings.GenerateOnly {
 		if err := s.processLLMResponseAndRunQuery(ctx, userID, chatID, messageID, streamID); err != nil {
 			return nil, http.StatusInternalServerError, fmt.Errorf("failed to process message: %v", err)
 		}
@@ -773,8 +1214,10 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 	newChat := &models.Chat{
 		UserID:              userObjID,
 		Connection:          chat.Connection,
+		Connections:         chat.Connections,
 		SelectedCollections: chat.SelectedCollections,
 		Settings:            chat.Settings,
+		ImportedSchema:      chat.ImportedSchema,
 		Base:                models.NewBase(), // Create a new Base with new ID and timestamps
 	}
 
@@ -854,6 +1297,14 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 							IsEdited:               q.IsEdited,
 							Metadata:               q.Metadata,
 							ActionAt:               q.ActionAt,
+							GeneratedAt:            q.GeneratedAt,     // Keep the generation time, duplicating doesn't renew critical query confirmations
+							NearDuplicateOf:        q.NearDuplicateOf, // Updated below
+							Note:                   q.Note,
+							NotedAt:                q.NotedAt,
+							ComplexityScore:        q.ComplexityScore,
+							ComplexityReasons:      q.ComplexityReasons,
+							ConnectionName:         q.ConnectionName,
+							// Not favorited, the favorites of the user would list the query of both chats
 						}
 
 						// Copy pagination if it exists
@@ -865,6 +1316,18 @@ func (s *chatService) Duplicate(userID, chatID string, duplicateMessages bool) (
 							}
 						}
 					}
+					// Point the near duplicates to the copies of their queries
+					for i := range queries {
+						if queries[i].NearDuplicateOf == nil {
+							continue
+						}
+						for j, q := range *originalMsg.Queries {
+							if q.ID.Hex() == *queries[i].NearDuplicateOf {
+								queries[i].NearDuplicateOf = utils.ToStringPtr(queries[j].ID.Hex())
+								break
+							}
+						}
+					}
 					newMsg.Queries = &queries
 				}
 
@@ -1008,6 +1471,7 @@ func (s *chatService) ListMessages(userID, chatID string, page, pageSize int) (*
 		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
 	}
 
+	page, pageSize = normalizePage(page, pageSize)
 	messages, total, err := s.chatRepo.FindLatestMessageByChat(chatObjID, page, pageSize)
 	if err != nil {
 		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch messages: %v", err)
@@ -1016,6 +1480,9 @@ func (s *chatService) ListMessages(userID, chatID string, page, pageSize int) (*
 	response := &dtos.MessageListResponse{
 		Messages: make([]dtos.MessageResponse, len(messages)),
 		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(page*pageSize) < total,
 	}
 
 	for i, msg := range messages {
@@ -1029,7 +1496,7 @@ func (s *chatService) ListMessages(userID, chatID string, page, pageSize int) (*
 func (s *chatService) EditQuery(ctx context.Context, userID, chatID, messageID, queryID string, query string) (*dtos.EditQueryResponse, uint32, error) {
 	log.Printf("ChatService -> EditQuery -> userID: %s, chatID: %s, messageID: %s, queryID: %s, query: %s", userID, chatID, messageID, queryID, query)
 
-	_, message, queryData, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	chat, message, queryData, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
@@ -1044,6 +1511,7 @@ func (s *chatService) EditQuery(ctx context.Context, userID, chatID, messageID,
 		if (*message.Queries)[i].ID == queryData.ID {
 			(*message.Queries)[i].Query = query
 			(*message.Queries)[i].IsEdited = true
+			s.scoreQueriesComplexity(ctx, chat, chatID, (*message.Queries)[i:i+1])
 			if (*message.Queries)[i].Pagination != nil && (*message.Queries)[i].Pagination.PaginatedQuery != nil {
 				(*message.Queries)[i].Pagination.PaginatedQuery = utils.ToStringPtr(strings.Replace(*(*message.Queries)[i].Pagination.PaginatedQuery, originalQuery, query, 1))
 			}
@@ -1148,19 +1616,49 @@ func (s *chatService) GetDBConnectionStatus(ctx context.Context, userID, chatID
 		}
 	}
 
-	return &dtos.ConnectionStatusResponse{
-		IsConnected: isConnected,
-		Type:        connInfo.Config.Type,
-		Host:        connInfo.Config.Host,
-		Port:        port,
-		Database:    connInfo.Config.Database,
-		Username:    *connInfo.Config.Username,
-	}, http.StatusOK, nil
+	response := &dtos.ConnectionStatusResponse{
+		IsConnected:   isConnected,
+		Type:          connInfo.Config.Type,
+		Host:          connInfo.Config.Host,
+		Port:          port,
+		Database:      connInfo.Config.Database,
+		Username:      *connInfo.Config.Username,
+		ConnectedHost: connInfo.ConnectedHost,
+	}
+	if session, active := s.dbManager.GetSession(chatID); active {
+		startedAt := session.StartedAt.Format(time.RFC3339)
+		response.SessionActive = true
+		response.SessionStartedAt = &startedAt
+	}
+	if connInfo.ServerInfo != nil {
+		response.Server = &dtos.ServerInfo{
+			Version:      connInfo.ServerInfo.Version,
+			MajorVersion: connInfo.ServerInfo.MajorVersion,
+			MinorVersion: connInfo.ServerInfo.MinorVersion,
+			PatchVersion: connInfo.ServerInfo.PatchVersion,
+			Flavor:       connInfo.ServerInfo.Flavor,
+			Capabilities: connInfo.ServerInfo.Capabilities,
+		}
+		for _, cluster := range connInfo.ServerInfo.Clusters {
+			response.Server.Clusters = append(response.Server.Clusters, dtos.ServerCluster{
+				Name:     cluster.Name,
+				Shards:   cluster.Shards,
+				Replicas: cluster.Replicas,
+			})
+		}
+	}
+	response.TLS = buildTLSInfoResponse(connInfo.TLSInfo)
+	return response, http.StatusOK, nil
 }
 
 // HandleSchemaChange handles schema changes
 func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *dbmanager.SchemaDiff) {
 	log.Printf("ChatService -> HandleSchemaChange -> Starting for chatID: %s", chatID)
+	if _, name := dbmanager.SplitConnectionKey(chatID); name != "" {
+		// The schema of a named connection is formatted from the stored one for every LLM request, see formatNamedConnectionsForLLM
+		log.Printf("ChatService -> HandleSchemaChange -> Schema of connection %s changed", name)
+		return
+	}
 
 	// Get connection info
 	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
@@ -1194,6 +1692,12 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 		return
 	}
 
+	// Masks are only kept in memory, make sure they are in place before example records are formatted
+	s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+	s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+	s.dbManager.GetSchemaManager().SetSchemaDescriptions(chatID, toDBSchemaDescriptions(chat.SchemaDescriptions))
+	s.dbManager.GetSchemaManager().SetTableAccessCounts(chatID, chat.TableAccessCountsByTable())
+
 	// Convert the selectedCollections string to a slice
 	var selectedCollectionsSlice []string
 	if chat.SelectedCollections != "ALL" && chat.SelectedCollections != "" {
@@ -1234,7 +1738,7 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 			if err != nil {
 				log.Printf("ChatService -> HandleSchemaChange -> Error formatting schema with examples: %v", err)
 				// Fall back to the old method if there's an error
-				schemaMsg = s.dbManager.GetSchemaManager().FormatSchemaForLLM(diff.FullSchema)
+				schemaMsg = s.dbManager.GetSchemaManager().FormatSchemaForLLM(s.dbManager.GetSchemaManager().DescribeSchema(chatID, diff.FullSchema))
 			}
 		} else {
 			// For subsequent changes, get current schema with examples and show changes
@@ -1247,7 +1751,7 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 					log.Printf("ChatService -> HandleSchemaChange -> Error getting schema: %v", schemaErr)
 					return
 				}
-				schemaMsg = s.dbManager.GetSchemaManager().FormatSchemaForLLM(schema)
+				schemaMsg = s.dbManager.GetSchemaManager().FormatSchemaForLLM(s.dbManager.GetSchemaManager().DescribeSchema(chatID, schema))
 			}
 		}
 
@@ -1275,37 +1779,199 @@ func (s *chatService) HandleSchemaChange(userID, chatID, streamID string, diff *
 // Helper methods for building responses
 
 func (s *chatService) buildChatResponse(chat *models.Chat) *dtos.ChatResponse {
-	// Create a copy of the connection to avoid modifying the original
-	connectionCopy := chat.Connection
-
-	// Decrypt connection details for the response
-	utils.DecryptConnection(&connectionCopy)
-
 	return &dtos.ChatResponse{
-		ID:     chat.ID.Hex(),
-		UserID: chat.UserID.Hex(),
-		Connection: dtos.ConnectionResponse{
-			ID:             chat.ID.Hex(),
-			Type:           connectionCopy.Type,
-			Host:           connectionCopy.Host,
-			Port:           connectionCopy.Port,
-			Username:       *connectionCopy.Username,
-			Database:       connectionCopy.Database,
-			IsExampleDB:    connectionCopy.IsExampleDB,
-			UseSSL:         connectionCopy.UseSSL,
-			SSLMode:        connectionCopy.SSLMode,
-			SSLCertURL:     connectionCopy.SSLCertURL,
-			SSLKeyURL:      connectionCopy.SSLKeyURL,
-			SSLRootCertURL: connectionCopy.SSLRootCertURL,
-		},
+		ID:                  chat.ID.Hex(),
+		UserID:              chat.UserID.Hex(),
+		Connection:          buildConnectionResponse(chat.ID.Hex(), chat.Connection),
+		Connections:         s.buildNamedConnectionsResponse(chat),
 		SelectedCollections: chat.SelectedCollections,
 		CreatedAt:           chat.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:           chat.UpdatedAt.Format(time.RFC3339),
 		Settings: dtos.ChatSettingsResponse{
 			AutoExecuteQuery: chat.Settings.AutoExecuteQuery,
 			ShareDataWithAI:  chat.Settings.ShareDataWithAI,
+			ColumnMasks:      chat.Settings.ColumnMasks,
+			SessionMode:      chat.Settings.SessionMode,
+			AnonymizeSchema:  chat.Settings.AnonymizeSchema,
+			GenerateOnly:     chat.Settings.GenerateOnly,
+
+			DisableExampleRecords: chat.Settings.DisableExampleRecords,
+			TextCollation:         chat.Settings.TextCollation,
+			ReadOnly:              chat.Settings.ReadOnly,
+			DisableResponseCache:  chat.Settings.DisableResponseCache,
+			DefaultPageSize:       constants.ResolvePageSize(chat.Settings.DefaultPageSize),
+			QueryTimeoutSeconds:   chat.Settings.QueryTimeoutSeconds,
+
+			SchemaRefreshWebhookURL: chat.Settings.SchemaRefreshWebhookURL,
 		},
+		ExportDestination:  buildExportDestinationResponse(chat.ExportDestination),
+		QueryTemplates:     buildQueryTemplatesResponse(chat.QueryTemplates),
+		SchemaDescriptions: buildSchemaDescriptionsResponse(chat.SchemaDescriptions),
+		HasImportedSchema:  chat.ImportedSchema != "",
+	}
+}
+
+// buildConnectionResponse maps a connection of a chat without its secrets, the connection is decrypted on a copy
+func buildConnectionResponse(id string, connection models.Connection) dtos.ConnectionResponse {
+	connectionCopy := connection
+	utils.DecryptConnection(&connectionCopy)
+
+	return dtos.ConnectionResponse{
+		ID:             id,
+		Type:           connectionCopy.Type,
+		Host:           connectionCopy.Host,
+		Hosts:          connectionCopy.Hosts,
+		Shards:         connectionCopy.Shards,
+		Port:           connectionCopy.Port,
+		Username:       *connectionCopy.Username,
+		Database:       connectionCopy.Database,
+		IsExampleDB:    connectionCopy.IsExampleDB,
+		UseSSL:         connectionCopy.UseSSL,
+		SSLMode:        connectionCopy.SSLMode,
+		SSLCertURL:     connectionCopy.SSLCertURL,
+		SSLKeyURL:      connectionCopy.SSLKeyURL,
+		SSLRootCertURL: connectionCopy.SSLRootCertURL,
+		MaxResultRows:  connectionCopy.MaxResultRows,
+		Role:           connectionCopy.Role,
+		FilePath:       connectionCopy.FilePath,
+		ProjectID:      connectionCopy.ProjectID,
+		SSHHost:        connectionCopy.SSHHost,
+		SSHPort:        connectionCopy.SSHPort,
+		SSHUser:        connectionCopy.SSHUser,
+		SSHHostKey:     connectionCopy.SSHHostKey,
+
+		MaxOpenConns:    connectionCopy.MaxOpenConns,
+		MaxIdleConns:    connectionCopy.MaxIdleConns,
+		ConnMaxLifetime: connectionCopy.ConnMaxLifetime,
+	}
+}
+
+// buildTLSInfoResponse maps the TLS details of a connection, nil if they weren't determined
+func buildTLSInfoResponse(info *dbmanager.TLSInfo) *dtos.TLSInfo {
+	if info == nil {
+		return nil
+	}
+	return &dtos.TLSInfo{
+		Requested: info.Requested,
+		SSLMode:   info.SSLMode,
+		Used:      info.Used,
+		Version:   info.Version,
+		Cipher:    info.Cipher,
+	}
+}
+
+// validateConnectionDetails checks that the connection can be reached, generate only chats only need the database type
+func validateConnectionDetails(connection *dtos.CreateConnectionRequest, generateOnly bool) error {
+	if connection.MaxResultRows > 0 && !dbmanager.DatabaseRowLimitSupported(connection.Type) {
+		return fmt.Errorf("a maximum number of result rows is not supported for %s", connection.Type)
+	}
+	if (connection.MaxOpenConns > 0 || connection.MaxIdleConns > 0 || connection.ConnMaxLifetime > 0) && !dbmanager.ConnectionPoolSupported(connection.Type) {
+		return fmt.Errorf("connection pool settings are not supported for %s", connection.Type)
+	}
+	if connection.Role != "" && !dbmanager.SessionRoleSupported(connection.Type) {
+		return fmt.Errorf("a role to run the queries as is not supported for %s", connection.Type)
+	}
+	if connection.FilePath != "" && connection.Type != constants.DatabaseTypeSQLite {
+		return fmt.Errorf("a file path is only supported for %s", constants.DatabaseTypeSQLite)
+	}
+	if connection.Type == constants.DatabaseTypeSQLite && (connection.Host != "" || len(connection.Hosts) > 0 || len(connection.Shards) > 0) {
+		return fmt.Errorf("a SQLite database is a file, set its file_path instead of hosts")
+	}
+	if (connection.ProjectID != "" || connection.CredentialsJSON != "") && connection.Type != constants.DatabaseTypeBigQuery {
+		return fmt.Errorf("a project ID & credentials are only supported for %s", constants.DatabaseTypeBigQuery)
+	}
+	if connection.Type == constants.DatabaseTypeBigQuery && (connection.Host != "" || len(connection.Hosts) > 0 || len(connection.Shards) > 0) {
+		return fmt.Errorf("a BigQuery connection is a project, set its project_id instead of hosts")
+	}
+	if connection.SSHHost != "" {
+		if !dbmanager.SSHTunnelSupported(connection.Type) {
+			return fmt.Errorf("an SSH tunnel is not supported for %s", connection.Type)
+		}
+		// A tunnel forwards to a single host
+		if len(connection.Hosts) > 0 || len(connection.Shards) > 0 {
+			return fmt.Errorf("failover hosts & shards are not supported through an SSH tunnel")
+		}
+		// Without the host key of the bastion, whoever intercepts the connection to it gets the database traffic
+		if connection.SSHUser == "" || connection.SSHPrivateKey == "" || connection.SSHHostKey == "" {
+			return fmt.Errorf("ssh_user, ssh_private_key & ssh_host_key are required to connect through an SSH bastion")
+		}
+	} else if connection.SSHUser != "" || connection.SSHPrivateKey != "" || connection.SSHPort != "" || connection.SSHHostKey != "" {
+		return fmt.Errorf("ssh_host is required to connect through an SSH bastion")
+	}
+	if generateOnly {
+		return nil
+	}
+	if connection.Type == constants.DatabaseTypeSQLite {
+		// The file is checked against SQLITE_DATA_DIR by the connection test
+		if strings.TrimSpace(connection.FilePath) == "" {
+			return fmt.Errorf("file_path is required for a SQLite database")
+		}
+		return nil
 	}
+	if connection.Type == constants.DatabaseTypeBigQuery {
+		// The key & its access to the dataset are checked by the connection test
+		if connection.ProjectID == "" || connection.Database == "" || connection.CredentialsJSON == "" {
+			return fmt.Errorf("project_id, database (the dataset) & credentials_json are required for a BigQuery connection")
+		}
+		if !json.Valid([]byte(connection.CredentialsJSON)) {
+			return fmt.Errorf("credentials_json must be the JSON key of a service account")
+		}
+		return nil
+	}
+	if connection.Type == constants.DatabaseTypeElasticsearch {
+		// A cluster without security has no user, the database is the index pattern the queries read
+		if connection.Host == "" || connection.Database == "" {
+			return fmt.Errorf("host & database (the index pattern, ex: logs-* or * for every index) are required for an Elasticsearch connection")
+		}
+		return nil
+	}
+	if connection.Type == constants.DatabaseTypeCassandra {
+		// A cluster without authentication has no user, the database is the keyspace of the tables
+		if connection.Host == "" || connection.Database == "" {
+			return fmt.Errorf("host & database (the keyspace) are required for a Cassandra connection")
+		}
+		return nil
+	}
+	if connection.Host == "" || connection.Username == "" || connection.Database == "" {
+		return fmt.Errorf("host, username & database are required")
+	}
+	return nil
+}
+
+// validateColumnMasks validates the masking format of every configured column
+func validateColumnMasks(masks map[string]string) error {
+	for column, format := range masks {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("column mask key cannot be empty")
+		}
+		if err := dbmanager.ValidateMaskFormat(format); err != nil {
+			return fmt.Errorf("invalid mask for column %s: %v", column, err)
+		}
+	}
+	return nil
+}
+
+// validatePageSize validates the page size of a chat, 0 resets it to constants.DefaultPageSize
+func validatePageSize(pageSize int) error {
+	if pageSize != 0 && (pageSize < constants.MinPageSize || pageSize > constants.MaxPageSize) {
+		return fmt.Errorf("default_page_size must be between %d and %d", constants.MinPageSize, constants.MaxPageSize)
+	}
+	return nil
+}
+
+// validateQueryTimeout validates the query timeout of a chat or of a retry, 0 sizes it on the estimate of the LLM
+func validateQueryTimeout(seconds int) error {
+	if seconds < 0 || seconds > constants.MaxQueryTimeoutSeconds {
+		return fmt.Errorf("query_timeout_seconds must be between 0 and %d", constants.MaxQueryTimeoutSeconds)
+	}
+	return nil
+}
+
+// sharedResult prepares a result before it is shared with AI: the chat's column masks are applied & the result is cut
+// to SHARED_RESULT_MAX_ROWS rows with a summary of its columns, it is sent with every following message of the chat
+func (s *chatService) sharedResult(chat *models.Chat, resultJSON string) string {
+	maskedJSON := dbmanager.ColumnMasks(chat.Settings.ColumnMasks).ApplyToResultJSON(resultJSON)
+	return dbmanager.ShrinkResultJSON(maskedJSON, config.Env.SharedResultMaxRows, config.Env.SharedResultColumnSummaries)
 }
 
 func (s *chatService) buildMessageResponse(msg *models.Message) *dtos.MessageResponse {
@@ -1319,16 +1985,20 @@ func (s *chatService) buildMessageResponse(msg *models.Message) *dtos.MessageRes
 	actionButtonsDto := dtos.ToActionButtonDto(msg.ActionButtons)
 
 	return &dtos.MessageResponse{
-		ID:            msg.ID.Hex(),
-		ChatID:        msg.ChatID.Hex(),
-		UserMessageID: userMessageID,
-		Type:          msg.Type,
-		Content:       msg.Content,
-		Queries:       queriesDto,
-		ActionButtons: actionButtonsDto,
-		IsEdited:      msg.IsEdited,
-		CreatedAt:     msg.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     msg.UpdatedAt.Format(time.RFC3339),
+		ID:                 msg.ID.Hex(),
+		ChatID:             msg.ChatID.Hex(),
+		UserMessageID:      userMessageID,
+		Type:               msg.Type,
+		Content:            msg.Content,
+		Queries:            queriesDto,
+		ActionButtons:      actionButtonsDto,
+		ParameterRequests:  dtos.ToParameterRequestDto(msg.ParameterRequests),
+		NeedsClarification: msg.NeedsClarification,
+		Confidence:         msg.Confidence,
+		IsEdited:           msg.IsEdited,
+		IsMigration:        msg.IsMigration,
+		CreatedAt:          msg.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          msg.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -1393,6 +2063,10 @@ func (s *chatService) verifyQueryOwnership(_, chatID, messageID, queryID string)
 // NOTE: This is used for UI display
 func (s *chatService) GetSelectedCollections(chatID string) (string, error) {
 	log.Printf("ChatService -> GetSelectedCollections -> Starting for chatID: %s", chatID)
+	if _, name := dbmanager.SplitConnectionKey(chatID); name != "" {
+		// The collections are selected among the ones of the main connection
+		return "ALL", nil
+	}
 
 	// Convert to ObjectID
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
@@ -1464,13 +2138,40 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 			log.Printf("ChatService -> GetAllTables -> Connection not found, attempting to connect: %v", err)
 
 			// Connection not found, try to connect with proper config
+			s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+			s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+			s.dbManager.GetSchemaManager().SetSchemaDescriptions(chatID, toDBSchemaDescriptions(chat.SchemaDescriptions))
+			s.dbManager.GetSchemaManager().SetTableAccessCounts(chatID, chat.TableAccessCountsByTable())
+			s.dbManager.SetSessionMode(chatID, chat.Settings.SessionMode)
+			tenantID := s.tenantOf(userID)
+			if err := s.checkTenantConnections(tenantID, chatID); err != nil {
+				return nil, http.StatusTooManyRequests, err
+			}
 			connectErr := s.dbManager.Connect(chatID, userID, "", dbmanager.ConnectionConfig{
 				Type:     chat.Connection.Type,
 				Host:     chat.Connection.Host,
+				Hosts:    chat.Connection.Hosts,
+				Shards:   chat.Connection.Shards,
 				Port:     chat.Connection.Port,
 				Username: chat.Connection.Username,
 				Password: chat.Connection.Password,
 				Database: chat.Connection.Database,
+
+				MaxResultRows:   chat.Connection.MaxResultRows,
+				Role:            chat.Connection.Role,
+				FilePath:        chat.Connection.FilePath,
+				ProjectID:       chat.Connection.ProjectID,
+				CredentialsJSON: chat.Connection.CredentialsJSON,
+				SSHHost:         chat.Connection.SSHHost,
+				SSHPort:         chat.Connection.SSHPort,
+				SSHUser:         chat.Connection.SSHUser,
+				SSHPrivateKey:   chat.Connection.SSHPrivateKey,
+				SSHHostKey:      chat.Connection.SSHHostKey,
+				TenantID:        tenantID,
+
+				MaxOpenConns:    chat.Connection.MaxOpenConns,
+				MaxIdleConns:    chat.Connection.MaxIdleConns,
+				ConnMaxLifetime: chat.Connection.ConnMaxLifetime,
 			})
 			if connectErr != nil {
 				log.Printf("ChatService -> GetAllTables -> Failed to connect: %v", connectErr)
@@ -1547,3 +2248,120 @@ func (s *chatService) GetAllTables(ctx context.Context, userID, chatID string) (
 		}, http.StatusOK, nil
 	}
 }
+
+// GetSchemaGraph returns the tables, columns & foreign key relationships of the connected database as a graph
+// NOTE: This is used for UI display (ER diagram)
+func (s *chatService) GetSchemaGraph(ctx context.Context, userID, chatID string) (*dtos.SchemaGraphResponse, uint32, error) {
+	log.Printf("ChatService -> GetSchemaGraph -> Starting for chatID: %s", chatID)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+
+	dbConn, err := s.dbManager.GetConnection(chatID)
+	if err != nil {
+		log.Printf("ChatService -> GetSchemaGraph -> Connection not found: %v", err)
+		return nil, http.StatusBadRequest, fmt.Errorf("database is not connected, please connect to the database first")
+	}
+
+	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
+	if !exists {
+		return nil, http.StatusNotFound, fmt.Errorf("connection info not found")
+	}
+
+	schemaManager := s.dbManager.GetSchemaManager()
+	schema, err := schemaManager.GetSchema(ctx, chatID, dbConn, connInfo.Config.Type, []string{})
+	if err != nil {
+		log.Printf("ChatService -> GetSchemaGraph -> Error getting schema: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get schema: %v", err)
+	}
+
+	selectedTablesMap := make(map[string]bool)
+	isAllSelected := chat.SelectedCollections == "ALL" || chat.SelectedCollections == ""
+	if !isAllSelected {
+		for _, tableName := range strings.Split(chat.SelectedCollections, ",") {
+			selectedTablesMap[tableName] = true
+		}
+	}
+
+	relationships := schemaManager.ExtractForeignKeyRelationships(schema)
+	foreignKeyColumns := make(map[string]bool)
+	edges := make([]dtos.SchemaGraphEdge, 0, len(relationships))
+	for _, rel := range relationships {
+		foreignKeyColumns[rel.FromTable+"."+rel.FromColumn] = true
+		edges = append(edges, dtos.SchemaGraphEdge{
+			ID:           rel.Name,
+			Source:       rel.FromTable,
+			SourceColumn: rel.FromColumn,
+			Target:       rel.ToTable,
+			TargetColumn: rel.ToColumn,
+			Cardinality:  rel.Type,
+		})
+	}
+
+	nodes := make([]dtos.SchemaGraphNode, 0, len(schema.Tables))
+	for tableName, tableSchema := range schema.Tables {
+		primaryKeyColumns := make(map[string]bool)
+		var primaryKey []string
+		for _, constraint := range tableSchema.Constraints {
+			if constraint.Type == "PRIMARY KEY" {
+				primaryKey = append(primaryKey, constraint.Columns...)
+				for _, col := range constraint.Columns {
+					primaryKeyColumns[col] = true
+				}
+			}
+		}
+
+		node := dtos.SchemaGraphNode{
+			ID:         tableName,
+			Columns:    make([]dtos.SchemaGraphField, 0, len(tableSchema.Columns)),
+			PrimaryKey: primaryKey,
+			RowCount:   tableSchema.RowCount,
+			IsSelected: isAllSelected || selectedTablesMap[tableName],
+		}
+		for columnName, columnInfo := range tableSchema.Columns {
+			node.Columns = append(node.Columns, dtos.SchemaGraphField{
+				Name:         columnName,
+				Type:         columnInfo.Type,
+				IsNullable:   columnInfo.IsNullable,
+				IsPrimaryKey: primaryKeyColumns[columnName],
+				IsForeignKey: foreignKeyColumns[tableName+"."+columnName],
+			})
+		}
+		sort.Slice(node.Columns, func(i, j int) bool {
+			return node.Columns[i].Name < node.Columns[j].Name
+		})
+		nodes = append(nodes, node)
+	}
+
+	// Sort tables by name for consistent output
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	log.Printf("ChatService -> GetSchemaGraph -> Built graph with %d nodes and %d edges", len(nodes), len(edges))
+	return &dtos.SchemaGraphResponse{
+		Nodes: nodes,
+		Edges: edges,
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"databot-ai/pkg/llm"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// Empty result diagnostics modes, see config.Env.EmptyResultDiagnostics
+const (
+	EmptyResultDiagnosticsOff     = "off"
+	EmptyResultDiagnosticsRelaxed = "relaxed"
+	EmptyResultDiagnosticsLLM     = "llm"
+)
+
+// diagnoseEmptyResult re-counts the rows of a SELECT that returned nothing with each of its filters removed,
+// so that the user can tell a too narrow filter from missing data. Returns nil when diagnostics are disabled.
+func (s *chatService) diagnoseEmptyResult(ctx context.Context, chatID string, msg *models.Message, query *models.Query, streamID string) *dtos.EmptyResultDiagnostics {
+	mode := strings.ToLower(config.Env.EmptyResultDiagnostics)
+	if mode != EmptyResultDiagnosticsRelaxed && mode != EmptyResultDiagnosticsLLM {
+		return nil
+	}
+
+	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
+	if !exists {
+		return nil
+	}
+	switch connInfo.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeClickhouse:
+	default:
+		// Relaxing filters is only supported for SQL
+		return nil
+	}
+
+	relaxedQueries := dbmanager.RelaxWhereConditions(query.Query)
+	if len(relaxedQueries) == 0 {
+		return &dtos.EmptyResultDiagnostics{
+			Message: "The query returned no rows and has no filters to relax, the source data appears to be empty.",
+		}
+	}
+
+	maxProbes := config.Env.EmptyResultDiagnosticsMaxProbes
+	if maxProbes > 0 && len(relaxedQueries) > maxProbes {
+		relaxedQueries = relaxedQueries[:maxProbes]
+	}
+
+	diagnostics := &dtos.EmptyResultDiagnostics{
+		RelaxedFilters: make([]dtos.RelaxedFilterResult, 0, len(relaxedQueries)),
+	}
+	var narrowest *dtos.RelaxedFilterResult
+	for _, relaxed := range relaxedQueries {
+		filterResult := dtos.RelaxedFilterResult{Filter: relaxed.RemovedCondition}
+
+		result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, msg.ID.Hex(), query.ID.Hex(), streamID, relaxed.CountQuery, "SELECT", false, true)
+		if queryErr != nil {
+			log.Printf("ChatService -> diagnoseEmptyResult -> Probe without %q failed: %s", relaxed.RemovedCondition, queryErr.Message)
+		} else if count, ok := extractCount(result.ResultJSON); ok {
+			filterResult.RowCount = &count
+		}
+
+		diagnostics.RelaxedFilters = append(diagnostics.RelaxedFilters, filterResult)
+		if filterResult.RowCount != nil && *filterResult.RowCount > 0 && (narrowest == nil || *filterResult.RowCount > *narrowest.RowCount) {
+			narrowest = &diagnostics.RelaxedFilters[len(diagnostics.RelaxedFilters)-1]
+		}
+	}
+
+	if narrowest != nil {
+		diagnostics.Message = fmt.Sprintf("The query returned no rows, but %d row(s) match without the filter `%s`, it is likely too narrow.", *narrowest.RowCount, narrowest.Filter)
+	} else {
+		diagnostics.Message = "The query returned no rows, even with each filter removed on its own, the data likely doesn't exist for this combination."
+	}
+
+	if mode == EmptyResultDiagnosticsLLM {
+		diagnostics.Suggestion = s.suggestEmptyResultFix(ctx, msg, query, diagnostics, connInfo.Config.Type)
+	}
+	return diagnostics
+}
+
+// suggestEmptyResultFix asks the LLM how to adjust a query that returned no rows, best effort
+func (s *chatService) suggestEmptyResultFix(ctx context.Context, msg *models.Message, query *models.Query, diagnostics *dtos.EmptyResultDiagnostics, dbType string) *string {
+	var counts strings.Builder
+	for _, filter := range diagnostics.RelaxedFilters {
+		if filter.RowCount != nil {
+			counts.WriteString(fmt.Sprintf("- without `%s`: %d row(s)\n", filter.Filter, *filter.RowCount))
+		} else {
+			counts.WriteString(fmt.Sprintf("- without `%s`: unknown (probe failed)\n", filter.Filter))
+		}
+	}
+
+	messages := []*models.LLMMessage{
+		{
+			ChatID: msg.ChatID,
+			UserID: msg.UserID,
+			Role:   string(constants.MessageTypeUser),
+			Content: map[string]interface{}{
+				"user_message": fmt.Sprintf(constants.EmptyResultSuggestionPrompt, query.Query, counts.String()),
+			},
+		},
+	}
+
+	chat, err := s.chatRepo.FindByID(msg.ChatID)
+	if err != nil {
+		log.Printf("ChatService -> suggestEmptyResultFix -> Error fetching chat: %v", err)
+		return nil
+	}
+	messages, anonymizer, err := s.anonymizeLLMMessages(ctx, chat, messages)
+	if err != nil {
+		log.Printf("ChatService -> suggestEmptyResultFix -> Error anonymizing the prompt: %v", err)
+		return nil
+	}
+
+	ctx = llm.WithTenant(llm.WithPageSize(ctx, pageSizeOf(chat)), s.tenantOf(chat.UserID.Hex()))
+	response, err := s.llmClient.GenerateResponse(ctx, messages, dbType)
+	if err != nil {
+		log.Printf("ChatService -> suggestEmptyResultFix -> Error generating suggestion: %v", err)
+		return nil
+	}
+
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		log.Printf("ChatService -> suggestEmptyResultFix -> Error parsing suggestion: %v", err)
+		return nil
+	}
+	suggestion, ok := jsonResponse["assistantMessage"].(string)
+	if !ok || strings.TrimSpace(suggestion) == "" {
+		return nil
+	}
+	if anonymizer != nil {
+		suggestion = anonymizer.DeanonymizeText(suggestion)
+	}
+	return &suggestion
+}
+
+// isEmptyResult checks if a query result JSON holds no rows, either an empty list or an empty "results" list
+func isEmptyResult(resultJSON string) bool {
+	var records []interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &records); err == nil {
+		return len(records) == 0
+	}
+
+	var resultMap map[string]interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &resultMap); err != nil {
+		return false
+	}
+	results, exists := resultMap["results"]
+	if !exists {
+		return false
+	}
+	if results == nil {
+		return true
+	}
+	list, ok := results.([]interface{})
+	return ok && len(list) == 0
+}
+
+// extractCount reads the "count" column of a single row count result
+func extractCount(resultJSON string) (int, bool) {
+	records, err := extractResultRecords(resultJSON)
+	if err != nil || len(records) == 0 {
+		return 0, false
+	}
+
+	for key, value := range records[0] {
+		if !strings.EqualFold(key, "count") {
+			continue
+		}
+		switch v := value.(type) {
+		case float64:
+			return int(v), true
+		case string:
+			count, err := strconv.Atoi(v)
+			return count, err == nil
+		}
+	}
+	return 0, false
+}
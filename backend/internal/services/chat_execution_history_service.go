@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExecuteQuery executes a query, runs realtime query to connected database, stores the result in execution_result etc...
+// Every call is recorded in the execution history of the chat.
+func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
+	startTime := time.Now()
+	response, statusCode, err := s.executeQuery(ctx, userID, chatID, req)
+	s.recordQueryExecution(userID, chatID, req.MessageID, req.QueryID, false, req.Preview, startTime, response, err)
+	s.recordAuditLog(ctx, userID, chatID, constants.AuditActionQueryExecution, auditLogCallDetails(map[string]interface{}{
+		"message_id": req.MessageID,
+		"query_id":   req.QueryID,
+	}, err))
+	return response, statusCode, err
+}
+
+// RollbackQuery runs the rollback query of an executed query, every call is recorded in the execution history of the chat
+func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string, req *dtos.RollbackQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
+	startTime := time.Now()
+	response, statusCode, err := s.rollbackQuery(ctx, userID, chatID, req)
+	s.recordQueryExecution(userID, chatID, req.MessageID, req.QueryID, true, false, startTime, response, err)
+	s.recordAuditLog(ctx, userID, chatID, constants.AuditActionQueryRollback, auditLogCallDetails(map[string]interface{}{
+		"message_id": req.MessageID,
+		"query_id":   req.QueryID,
+	}, err))
+	return response, statusCode, err
+}
+
+// recordQueryExecution saves an execution or a rollback in the history of the chat. Calls made on a query the user
+// doesn't own aren't recorded. The execution time is the one of the database when the query ran, the time of the call
+// otherwise. Failing to record is only logged, the execution itself went through.
+func (s *chatService) recordQueryExecution(userID, chatID, messageID, queryID string, isRollback, isPreview bool, startTime time.Time, response *dtos.QueryExecutionResponse, execErr error) {
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return
+	}
+
+	queryText := query.Query
+	if isRollback && query.RollbackQuery != nil {
+		queryText = *query.RollbackQuery
+	}
+	execution := models.NewQueryExecution(chat.UserID, chat.ID, msg.ID, query.ID, queryText, query.QueryType, isRollback)
+	execution.IsPreview = isPreview
+
+	elapsed := int(time.Since(startTime).Milliseconds())
+	execution.ExecutionTime = &elapsed
+	if response != nil {
+		if response.ExecutionTime != nil {
+			execution.ExecutionTime = response.ExecutionTime
+		}
+		execution.RowCount = executionRowCount(response)
+		if response.Error != nil {
+			execution.Error = &models.QueryError{
+				Code:    response.Error.Code,
+				Message: response.Error.Message,
+				Details: response.Error.Details,
+			}
+		}
+	}
+	if execution.Error == nil && execErr != nil {
+		execution.Error = &models.QueryError{
+			Code:    "EXECUTION_FAILED",
+			Message: execErr.Error(),
+		}
+	}
+
+	if err := s.executionRepo.Create(execution); err != nil {
+		log.Printf("ChatService -> recordQueryExecution -> Failed to record the execution of queryID %s: %v", queryID, err)
+	}
+}
+
+// executionRowCount is the number of rows returned or affected by a query, nil if the response doesn't tell
+func executionRowCount(response *dtos.QueryExecutionResponse) *int {
+	if response.TotalRecordsCount != nil {
+		return response.TotalRecordsCount
+	}
+	switch result := response.ExecutionResult.(type) {
+	case []interface{}:
+		count := len(result)
+		return &count
+	case map[string]interface{}:
+		if rows, ok := result["results"].([]interface{}); ok {
+			count := len(rows)
+			return &count
+		}
+		// Decoded from the JSON of the result
+		if rowsAffected, ok := result["rowsAffected"].(float64); ok {
+			count := int(rowsAffected)
+			return &count
+		}
+	}
+	return nil
+}
+
+// GetExecutionHistory returns a page of the queries executed & rolled back in a chat, newest first
+func (s *chatService) GetExecutionHistory(userID, chatID string, limit, offset int) (*dtos.ExecutionHistoryResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > config.Env.MaxListPageSize {
+		limit = config.Env.MaxListPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	executions, total, err := s.executionRepo.FindByChatID(chat.ID, limit, offset)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch the execution history: %v", err)
+	}
+
+	items := make([]dtos.QueryExecutionHistoryItem, len(executions))
+	for i, execution := range executions {
+		items[i] = dtos.QueryExecutionHistoryItem{
+			ID:            execution.ID.Hex(),
+			MessageID:     execution.MessageID.Hex(),
+			QueryID:       execution.QueryID.Hex(),
+			Query:         execution.Query,
+			QueryType:     execution.QueryType,
+			IsRollback:    execution.IsRollback,
+			IsPreview:     execution.IsPreview,
+			ExecutionTime: execution.ExecutionTime,
+			RowCount:      execution.RowCount,
+			CreatedAt:     execution.CreatedAt.Format(time.RFC3339),
+		}
+		if execution.Error != nil {
+			items[i].Error = &dtos.QueryError{
+				Code:    execution.Error.Code,
+				Message: execution.Error.Message,
+				Details: execution.Error.Details,
+			}
+		}
+	}
+
+	return &dtos.ExecutionHistoryResponse{
+		Executions: items,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		HasMore:    int64(offset+len(items)) < total,
+	}, http.StatusOK, nil
+}
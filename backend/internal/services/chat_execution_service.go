@@ -2,11 +2,13 @@ package services
 
 import (
 	"context"
+	"databot-ai/config"
 	"databot-ai/internal/apis/dtos"
 	"databot-ai/internal/constants"
 	"databot-ai/internal/models"
 	"databot-ai/internal/utils"
 	"databot-ai/pkg/dbmanager"
+	"databot-ai/pkg/llm"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -32,6 +34,11 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// The LLM calls are limited across users, tell the user when the generation waits for its turn
+	if !synchronous || allowSSEUpdates {
+		ctx = llm.WithQueuedNotifier(ctx, s.llmQueuedNotifier(userID, chatID, streamID))
+	}
+
 	chatObjID, err := primitive.ObjectIDFromHex(chatID)
 	if err != nil {
 		s.handleError(ctx, chatID, err)
@@ -49,6 +56,7 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		s.handleError(ctx, chatID, err)
 		return nil, err
 	}
+	s.recordAuditLog(ctx, userID, chatID, constants.AuditActionLLMGeneration, map[string]interface{}{"message_id": userMessageID})
 
 	// Store cancel function
 	s.processesMu.Lock()
@@ -70,25 +78,45 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		})
 	}
 
-	// Get connection info
-	connInfo, exists := s.dbManager.GetConnectionInfo(chatID)
-	if !exists {
-		s.handleError(ctx, chatID, fmt.Errorf("connection info not found"))
-		// Let's create a new connection
-		_, err := s.ConnectDB(ctx, userID, chatID, streamID)
-		if err != nil {
-			// Send a error event to the client
-			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
-				Event: "ai-response-error",
-				Data:  "Error: " + err.Error(),
-			})
-			return nil, err
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		s.handleError(ctx, chatID, err)
+		return nil, fmt.Errorf("failed to fetch chat: %v", err)
+	}
+	dbType := chat.Connection.Type
+	// The paginated queries are generated with the page size of the chat
+	ctx = llm.WithPageSize(ctx, pageSizeOf(chat))
+	// The LLM is called with the API key & within the quota of the tenant of the user
+	ctx = llm.WithTenant(ctx, s.tenantOf(userID))
+
+	// Get connection info, generate only chats never connect & use the imported schema instead
+	var connInfo *dbmanager.ConnectionInfo
+	if !chat.Settings.GenerateOnly {
+		var exists bool
+		connInfo, exists = s.dbManager.GetConnectionInfo(chatID)
+		if !exists {
+			s.handleError(ctx, chatID, fmt.Errorf("connection info not found"))
+			// Let's create a new connection
+			_, err := s.ConnectDB(ctx, userID, chatID, streamID)
+			if err != nil {
+				// Send a error event to the client
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-error",
+					Data:  "Error: " + err.Error(),
+				})
+				return nil, err
+			}
+			connInfo, _ = s.dbManager.GetConnectionInfo(chatID)
 		}
-
 	}
 
-	// Fetch all the messages from the LLM
-	messages, err := s.llmRepo.GetByChatID(chatObjID)
+	// Fetch the messages from the LLM, long chats can be cut to their latest messages
+	var messages []*models.LLMMessage
+	if config.Env.LLMContextMaxMessages > 0 {
+		messages, err = s.llmRepo.GetContextByChatID(chatObjID, userMessageObjID, config.Env.LLMContextMaxMessages)
+	} else {
+		messages, err = s.llmRepo.GetByChatID(chatObjID)
+	}
 	if err != nil {
 		s.handleError(ctx, chatID, err)
 		return nil, err
@@ -103,6 +131,88 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		}
 	}
 
+	// Generate only chats describe their schema with the imported one, it replaces the schema of a former connection
+	if chat.Settings.GenerateOnly && chat.ImportedSchema != "" {
+		withImportedSchema := []*models.LLMMessage{{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"schema_update": chat.ImportedSchema},
+		}}
+		for _, msg := range filteredMessages {
+			if _, isSchemaUpdate := msg.Content["schema_update"]; !isSchemaUpdate {
+				withImportedSchema = append(withImportedSchema, msg)
+			}
+		}
+		filteredMessages = withImportedSchema
+	}
+
+	// Let the LLM know the server version so that it doesn't generate unsupported syntax & the current date of the
+	// server so that relative dates are resolved in its timezone rather than in the one of this host, not persisted
+	if connInfo != nil {
+		serverInfo := dbmanager.FormatServerInfoForLLM(dbType, connInfo.ServerInfo)
+		if serverTime, err := s.dbManager.GetServerTime(ctx, chatID); err != nil {
+			log.Printf("ChatService -> processLLMResponse -> Failed to get the server time: %v", err)
+		} else {
+			serverInfo = strings.TrimSpace(serverInfo + " " + dbmanager.FormatServerTimeForLLM(serverTime))
+		}
+		if serverInfo != "" {
+			filteredMessages = append([]*models.LLMMessage{{
+				ChatID:  chatObjID,
+				UserID:  userObjID,
+				Role:    string(constants.MessageTypeSystem),
+				Content: map[string]interface{}{"server_info": serverInfo},
+			}}, filteredMessages...)
+		}
+	}
+
+	// Share the reusable CTEs of the connection, the AutoPrepend ones are added to the generated queries below
+	var queryTemplates []dbmanager.QueryTemplate
+	if chat != nil {
+		queryTemplates = toDBQueryTemplates(chat.QueryTemplates)
+	}
+	if templatesContext := dbmanager.FormatQueryTemplatesForLLM(queryTemplates); templatesContext != "" {
+		filteredMessages = append([]*models.LLMMessage{{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"query_templates": templatesContext},
+		}}, filteredMessages...)
+	}
+
+	// Text comparisons of the generated queries follow the collation chosen for the chat, see the COLLATE of the columns
+	if collationContext := dbmanager.FormatTextCollationForLLM(dbType, chat.Settings.TextCollation); collationContext != "" {
+		filteredMessages = append([]*models.LLMMessage{{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"text_collation": collationContext},
+		}}, filteredMessages...)
+	}
+
+	// The schemas of the named connections of the chat, queries on them are generated with their connectionName
+	if connectionsContext := s.formatNamedConnectionsForLLM(ctx, chat); connectionsContext != "" {
+		filteredMessages = append([]*models.LLMMessage{{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeSystem),
+			Content: map[string]interface{}{"connections_schema": connectionsContext},
+		}}, filteredMessages...)
+	}
+
+	// Replace the schema names with tokens if the chat asks for it, the response is mapped back below
+	plainMessages := filteredMessages
+	filteredMessages, anonymizer, err := s.anonymizeLLMMessages(ctx, chat, filteredMessages)
+	if err != nil {
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-error",
+				Data:  map[string]string{"error": "Error: " + err.Error()},
+			})
+		}
+		return nil, err
+	}
+
 	// Helper function to check cancellation
 	checkCancellation := func() bool {
 		select {
@@ -140,8 +250,37 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		return nil, fmt.Errorf("operation cancelled")
 	}
 
-	// Generate LLM response
-	response, err := s.llmClient.GenerateResponse(ctx, filteredMessages, connInfo.Config.Type)
+	// Stream the message of the response as it is generated, the ai-response event below replaces it. The synchronous
+	// path waits for the whole response, & an anonymized message can't be mapped back before it is complete.
+	var onMessage func(string)
+	if !synchronous && anonymizer == nil {
+		onMessage = func(chunk string) {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-chunk",
+				Data: dtos.AIResponseChunk{
+					UserMessageID: userMessageID,
+					Chunk:         chunk,
+				},
+			})
+		}
+	}
+
+	// Generate LLM response, an identical request reuses the cached response & a huge schema is scoped to the tables the
+	// request needs, the regenerations below use the scoped schema too. A regenerated query asks for another approach
+	// than the previous response instead.
+	var response string
+	if previousResponse := alternativeQueryOf(ctx); previousResponse != nil {
+		response, err = s.generateAlternativeLLMResponse(ctx, chatObjID, userObjID, filteredMessages, previousResponse, anonymizer, dbType)
+	} else {
+		response, filteredMessages, err = s.generateScopedLLMResponse(ctx, chat, chatID, filteredMessages, dbType, func(step string) {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  step,
+				})
+			}
+		}, onMessage)
+	}
 	if err != nil {
 		if !synchronous || allowSSEUpdates {
 			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
@@ -173,16 +312,163 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 			Data:  map[string]string{"error": "Error: " + err.Error()},
 		})
 	}
+	if anonymizer != nil && jsonResponse != nil {
+		jsonResponse = anonymizer.DeanonymizeValue(jsonResponse).(map[string]interface{})
+	}
+
+	// The LLM asks for a schema refresh when the schema looks stale (ex: it can't find the table of the request), refresh
+	// it & generate again once, a second request for a refresh is left to the user
+	if config.Env.AutoRefreshStaleSchema && connInfo != nil && jsonResponse != nil && requestsSchemaRefresh(jsonResponse) {
+		log.Printf("processLLMResponse -> The LLM asked for a schema refresh, refreshing it once")
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-step",
+				Data:  "The schema looks outdated, refreshing it & generating the query again..",
+			})
+		}
+
+		refreshedJSONResponse, refreshedMessages, refreshedAnonymizer, err := s.regenerateWithRefreshedSchema(ctx, userID, chatID, chat, plainMessages, dbType)
+		if err != nil {
+			// Keep the original response, its refresh_schema button is still there
+			log.Printf("processLLMResponse -> Error regenerating the response with a refreshed schema: %v", err)
+		} else {
+			jsonResponse, filteredMessages, anonymizer = refreshedJSONResponse, refreshedMessages, refreshedAnonymizer
+		}
+
+		if checkCancellation() {
+			return nil, fmt.Errorf("operation cancelled")
+		}
+	}
+
+	// The user asked for data but got no query (ex: a vague clarifying question), nudge the LLM once to be concrete
+	if config.Env.NudgeOnEmptyQueries && jsonResponse != nil && !hasLLMQueries(jsonResponse) && isDataRequest(filteredMessages) {
+		log.Printf("processLLMResponse -> No queries generated for a data request, nudging the LLM once")
+		if !synchronous || allowSSEUpdates {
+			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+				Event: "ai-response-step",
+				Data:  "Looking for a concrete query for the request..",
+			})
+		}
+
+		nudgedJSONResponse, err := s.regenerateLLMResponse(ctx, chatObjID, userObjID, filteredMessages, jsonResponse, constants.EmptyQueriesNudgePrompt, anonymizer, dbType)
+		if err != nil {
+			// Keep the original response, the nudge is best effort
+			log.Printf("processLLMResponse -> Error generating nudged response: %v", err)
+		} else {
+			jsonResponse = nudgedJSONResponse
+		}
+
+		if checkCancellation() {
+			return nil, fmt.Errorf("operation cancelled")
+		}
+	}
+
+	// Queries using tables they don't join return incomplete results, ask the LLM once to add the missing JOINs
+	if jsonResponse != nil && connInfo != nil && hasLLMQueries(jsonResponse) {
+		if missingJoinsPrompt := s.missingJoinsPrompt(ctx, chatID, jsonResponse); missingJoinsPrompt != "" {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  "Joining the tables needed by the query..",
+				})
+			}
+
+			joinedJSONResponse, err := s.regenerateLLMResponse(ctx, chatObjID, userObjID, filteredMessages, jsonResponse, missingJoinsPrompt, anonymizer, dbType)
+			if err != nil {
+				// Keep the original response, the check is best effort
+				log.Printf("processLLMResponse -> Error regenerating the response with the missing joins: %v", err)
+			} else {
+				jsonResponse = joinedJSONResponse
+			}
+
+			if checkCancellation() {
+				return nil, fmt.Errorf("operation cancelled")
+			}
+		}
+	}
+
+	// Comparing a column with a literal of another type converts it implicitly (full scans, wrong matches), warn the
+	// user & ask the LLM once to compare with values of the column types
+	if jsonResponse != nil && connInfo != nil && hasLLMQueries(jsonResponse) {
+		if typeCoercionsPrompt := s.typeCoercionsPrompt(ctx, chatID, jsonResponse); typeCoercionsPrompt != "" {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  "A filter compares a column with a value of another type, fixing the query..",
+				})
+			}
+
+			typedJSONResponse, err := s.regenerateLLMResponse(ctx, chatObjID, userObjID, filteredMessages, jsonResponse, typeCoercionsPrompt, anonymizer, dbType)
+			if err != nil {
+				// Keep the original response, the check is best effort
+				log.Printf("processLLMResponse -> Error regenerating the response without type coercions: %v", err)
+			} else {
+				jsonResponse = typedJSONResponse
+			}
+
+			if checkCancellation() {
+				return nil, fmt.Errorf("operation cancelled")
+			}
+		}
+	}
+
+	// Migrations must only alter the tables & columns that exist, ask the LLM once to fix the statements that don't
+	// match the schema they start from
+	isMigration := isMigrationRequest(filteredMessages)
+	if isMigration && jsonResponse != nil && hasLLMQueries(jsonResponse) {
+		if migrationPrompt := s.migrationObjectsPrompt(ctx, chatID, jsonResponse); migrationPrompt != "" {
+			if !synchronous || allowSSEUpdates {
+				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+					Event: "ai-response-step",
+					Data:  "The migration doesn't match the current schema, fixing it..",
+				})
+			}
+
+			fixedJSONResponse, err := s.regenerateLLMResponse(ctx, chatObjID, userObjID, filteredMessages, jsonResponse, migrationPrompt, anonymizer, dbType)
+			if err != nil {
+				// Keep the original response, the remaining problems are set on the queries
+				log.Printf("processLLMResponse -> Error regenerating the migration: %v", err)
+			} else {
+				jsonResponse = fixedJSONResponse
+			}
+
+			if checkCancellation() {
+				return nil, fmt.Errorf("operation cancelled")
+			}
+		}
+	}
+
+	// Runaway generations (thousands of characters) could hang the database or the UI, they are dropped before being
+	// stored & the user is asked to narrow the request
+	if llmQueries, ok := jsonResponse["queries"].([]interface{}); ok && config.Env.MaxGeneratedQueryLength > 0 {
+		var droppedLengths []int
+		jsonResponse["queries"], droppedLengths = dropOverlongLLMQueries(llmQueries, config.Env.MaxGeneratedQueryLength)
+		if len(droppedLengths) > 0 {
+			assistantMessage, _ := jsonResponse["assistantMessage"].(string)
+			jsonResponse["assistantMessage"] = strings.TrimSpace(assistantMessage + "\n\n" + overlongQueriesMessage(droppedLengths, config.Env.MaxGeneratedQueryLength))
+		}
+	}
+
+	// The same query generated twice is offered (and auto executed) once, queries only differing by their quoting or
+	// LIMIT are flagged as near duplicates
+	var nearDuplicates map[int]int
+	if llmQueries, ok := jsonResponse["queries"].([]interface{}); ok && len(llmQueries) > 1 {
+		jsonResponse["queries"], nearDuplicates = dedupeLLMQueries(dbType, llmQueries)
+	}
 
 	queries := []models.Query{}
 	if jsonResponse["queries"] != nil {
-		for _, query := range jsonResponse["queries"].([]interface{}) {
+		for i, query := range jsonResponse["queries"].([]interface{}) {
 			queryMap := query.(map[string]interface{})
 			var exampleResult *string
 			log.Printf("processLLMResponse -> queryMap: %v", queryMap)
 			if queryMap["exampleResult"] != nil {
 				log.Printf("processLLMResponse -> queryMap[\"exampleResult\"]: %v", queryMap["exampleResult"])
-				result, _ := json.Marshal(queryMap["exampleResult"].([]interface{}))
+				exampleRecords := queryMap["exampleResult"].([]interface{})
+				if queryText, ok := queryMap["query"].(string); ok {
+					exampleRecords = dbmanager.FoldExampleResultKeys(dbType, queryText, exampleRecords)
+				}
+				result, _ := json.Marshal(exampleRecords)
 				exampleResult = utils.ToStringPtr(string(result))
 				log.Printf("processLLMResponse -> saving exampleResult: %v", *exampleResult)
 			} else {
@@ -223,11 +509,17 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 			pagination := &models.Pagination{}
 			if queryMap["pagination"] != nil {
 				if queryMap["pagination"].(map[string]interface{})["paginatedQuery"] != nil {
-					pagination.PaginatedQuery = utils.ToStringPtr(queryMap["pagination"].(map[string]interface{})["paginatedQuery"].(string))
-					log.Printf("processLLMResponse -> pagination.PaginatedQuery: %v", *pagination.PaginatedQuery)
+					// A paginated query whose offset can't be substituted is dropped, the original query is executed instead
+					paginatedQuery, err := dbmanager.FixPaginatedQuery(dbType, applyQueryTemplates(dbType, queryMap["pagination"].(map[string]interface{})["paginatedQuery"].(string), queryTemplates))
+					if err != nil {
+						log.Printf("processLLMResponse -> Dropping the paginated query: %v, query: %s", err, paginatedQuery)
+					} else {
+						pagination.PaginatedQuery = utils.ToStringPtr(paginatedQuery)
+						log.Printf("processLLMResponse -> pagination.PaginatedQuery: %v", *pagination.PaginatedQuery)
+					}
 				}
 				if queryMap["pagination"].(map[string]interface{})["countQuery"] != nil {
-					pagination.CountQuery = utils.ToStringPtr(queryMap["pagination"].(map[string]interface{})["countQuery"].(string))
+					pagination.CountQuery = utils.ToStringPtr(applyQueryTemplates(dbType, queryMap["pagination"].(map[string]interface{})["countQuery"].(string), queryTemplates))
 					log.Printf("processLLMResponse -> pagination.CountQuery: %v", *pagination.CountQuery)
 				}
 			}
@@ -244,15 +536,17 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 				queryType = utils.ToStringPtr(queryMap["queryType"].(string))
 			}
 
+			connectionName, _ := queryMap["connectionName"].(string)
+
 			var rollbackQuery *string
 			if queryMap["rollbackQuery"] != nil {
-				rollbackQuery = utils.ToStringPtr(queryMap["rollbackQuery"].(string))
+				rollbackQuery = utils.ToStringPtr(applyQueryTemplates(dbType, queryMap["rollbackQuery"].(string), queryTemplates))
 			}
 
 			// Create the query object
 			query := models.Query{
 				ID:                     primitive.NewObjectID(),
-				Query:                  queryMap["query"].(string),
+				Query:                  applyQueryTemplates(dbType, queryMap["query"].(string), queryTemplates),
 				Description:            queryMap["explanation"].(string),
 				ExecutionTime:          nil,
 				ExampleExecutionTime:   int(*estimateResponseTime),
@@ -268,10 +562,25 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 				RollbackQuery:          rollbackQuery,
 				RollbackDependentQuery: rollbackDependentQuery,
 				Pagination:             pagination,
+				GeneratedAt:            utils.ToStringPtr(time.Now().Format(time.RFC3339)),
+				ConnectionName:         connectionName,
+				SuggestedIndexes:       parseSuggestedIndexes(queryMap["suggestedIndexes"]),
+			}
+			if original, ok := nearDuplicates[i]; ok {
+				query.NearDuplicateOf = utils.ToStringPtr(queries[original].ID.Hex())
+			}
+			// Flagged upfront so that the query isn't auto executed & the user sees why
+			query.Error = readOnlyQueryError(chat, query.Query)
+			if query.Error == nil {
+				query.Error = namedConnectionQueryError(chat, connectionName)
+			}
+			// The selected collections are the tables of the main connection
+			if query.Error == nil && connectionName == "" {
+				query.Error = tableScopeQueryError(chat, query.Query, tables)
 			}
 
 			// Handle ClickHouse-specific metadata
-			if connInfo.Config.Type == constants.DatabaseTypeClickhouse {
+			if dbType == constants.DatabaseTypeClickhouse {
 				metadata := make(map[string]interface{})
 
 				// Add ClickHouse-specific fields if they exist
@@ -295,11 +604,24 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 				}
 			}
 
+			// BigQuery has no transactions to roll a change back & Elasticsearch queries change nothing, whatever the LLM says
+			if dbType == constants.DatabaseTypeBigQuery || dbType == constants.DatabaseTypeElasticsearch {
+				query.CanRollback = false
+				query.RollbackQuery = nil
+				query.RollbackDependentQuery = nil
+			}
+
 			queries = append(queries, query)
 		}
 	}
 
+	if isMigration {
+		s.markMigrationQueries(ctx, chatID, queries)
+	}
+	s.scoreQueriesComplexity(ctx, chat, chatID, queries)
+	s.checkSuggestedIndexes(ctx, chat, chatID, queries)
 	log.Printf("processLLMResponse -> queries: %v", queries)
+	s.recordTableAccess(chat, queries)
 
 	// Extract action buttons from the LLM response
 	var actionButtons []models.ActionButton
@@ -314,6 +636,7 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 					Label:     btnMap["label"].(string),
 					Action:    btnMap["action"].(string),
 					IsPrimary: btnMap["isPrimary"].(bool),
+					Payload:   actionButtonPayload(btnMap["payload"]),
 				}
 				actionButtons = append(actionButtons, actionButton)
 			}
@@ -321,6 +644,44 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 	} else {
 		actionButtons = []models.ActionButton{}
 	}
+	if button := explainQueryButton(chat, queries); button != nil {
+		actionButtons = append(actionButtons, *button)
+	}
+	if button := regenerateQueryButton(queries); button != nil {
+		actionButtons = append(actionButtons, *button)
+	}
+	actionButtons = append(actionButtons, suggestedIndexButtons(queries)...)
+
+	// Extract the values the LLM needs from the user, the queries hold {{name}} placeholders for them
+	parameterRequests := []models.ParameterRequest{}
+	if parameterRequestsArray, ok := jsonResponse["parameterRequests"].([]interface{}); ok {
+		for _, param := range parameterRequestsArray {
+			paramMap, ok := param.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := paramMap["name"].(string)
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			parameterRequest := models.ParameterRequest{
+				Name: strings.TrimSpace(name),
+				Type: constants.ParameterTypeString,
+			}
+			if label, ok := paramMap["label"].(string); ok && label != "" {
+				parameterRequest.Label = label
+			} else {
+				parameterRequest.Label = parameterRequest.Name
+			}
+			if paramType, ok := paramMap["type"].(string); ok && constants.IsValidParameterType(paramType) {
+				parameterRequest.Type = paramType
+			}
+			if description, ok := paramMap["description"].(string); ok {
+				parameterRequest.Description = description
+			}
+			parameterRequests = append(parameterRequests, parameterRequest)
+		}
+	}
 
 	assistantMessage := ""
 	if jsonResponse["assistantMessage"] != nil {
@@ -329,6 +690,14 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		assistantMessage = ""
 	}
 
+	// A clarification question is rendered as a prompt for more input instead of a final answer, an unknown confidence
+	// level is dropped
+	needsClarification, _ := jsonResponse["needsClarification"].(bool)
+	confidence, _ := jsonResponse["confidence"].(string)
+	if confidence = strings.ToLower(strings.TrimSpace(confidence)); !constants.IsValidConfidence(confidence) {
+		confidence = ""
+	}
+
 	// Find existing AI response message
 	existingMessage, err := s.chatRepo.FindNextMessageByID(userMessageObjID)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -355,10 +724,15 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		} else {
 			log.Printf("processLLMResponse -> saving existingMessage.ActionButtons: nil or empty")
 		}
-		// Update the existing message with new content
+		// Update the existing message with new content, the notes & favorites of the user survive the new response
+		carryQueryNotes(existingMessage.Queries, queries)
+		carryQueryFavorites(existingMessage.Queries, queries, jsonResponse)
 		existingMessage.Content = assistantMessage
 		existingMessage.Queries = queriesPtr // Now correctly typed as *[]models.Query
 		existingMessage.ActionButtons = actionButtonsPtr
+		existingMessage.ParameterRequests = &parameterRequests
+		existingMessage.NeedsClarification = needsClarification
+		existingMessage.Confidence = confidence
 		existingMessage.IsEdited = true
 
 		// Update the message in the database
@@ -389,31 +763,37 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 			s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
 				Event: "ai-response",
 				Data: &dtos.MessageResponse{
-					ID:            existingMessage.ID.Hex(),
-					ChatID:        existingMessage.ChatID.Hex(),
-					Content:       existingMessage.Content,
-					UserMessageID: utils.ToStringPtr(userMessageObjID.Hex()),
-					Queries:       dtos.ToQueryDto(existingMessage.Queries),
-					ActionButtons: dtos.ToActionButtonDto(existingMessage.ActionButtons),
-					Type:          existingMessage.Type,
-					CreatedAt:     existingMessage.CreatedAt.Format(time.RFC3339),
-					UpdatedAt:     existingMessage.UpdatedAt.Format(time.RFC3339),
-					IsEdited:      existingMessage.IsEdited,
+					ID:                 existingMessage.ID.Hex(),
+					ChatID:             existingMessage.ChatID.Hex(),
+					Content:            existingMessage.Content,
+					UserMessageID:      utils.ToStringPtr(userMessageObjID.Hex()),
+					Queries:            dtos.ToQueryDto(existingMessage.Queries),
+					ActionButtons:      dtos.ToActionButtonDto(existingMessage.ActionButtons),
+					ParameterRequests:  dtos.ToParameterRequestDto(existingMessage.ParameterRequests),
+					NeedsClarification: existingMessage.NeedsClarification,
+					Confidence:         existingMessage.Confidence,
+					Type:               existingMessage.Type,
+					CreatedAt:          existingMessage.CreatedAt.Format(time.RFC3339),
+					UpdatedAt:          existingMessage.UpdatedAt.Format(time.RFC3339),
+					IsEdited:           existingMessage.IsEdited,
 				},
 			})
 		}
 
 		return &dtos.MessageResponse{
-			ID:            existingMessage.ID.Hex(),
-			ChatID:        existingMessage.ChatID.Hex(),
-			Content:       existingMessage.Content,
-			UserMessageID: utils.ToStringPtr(userMessageObjID.Hex()),
-			Queries:       dtos.ToQueryDto(existingMessage.Queries),
-			ActionButtons: dtos.ToActionButtonDto(existingMessage.ActionButtons),
-			Type:          existingMessage.Type,
-			CreatedAt:     existingMessage.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:     existingMessage.UpdatedAt.Format(time.RFC3339),
-			IsEdited:      existingMessage.IsEdited,
+			ID:                 existingMessage.ID.Hex(),
+			ChatID:             existingMessage.ChatID.Hex(),
+			Content:            existingMessage.Content,
+			UserMessageID:      utils.ToStringPtr(userMessageObjID.Hex()),
+			Queries:            dtos.ToQueryDto(existingMessage.Queries),
+			ActionButtons:      dtos.ToActionButtonDto(existingMessage.ActionButtons),
+			ParameterRequests:  dtos.ToParameterRequestDto(existingMessage.ParameterRequests),
+			NeedsClarification: existingMessage.NeedsClarification,
+			Confidence:         existingMessage.Confidence,
+			Type:               existingMessage.Type,
+			CreatedAt:          existingMessage.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:          existingMessage.UpdatedAt.Format(time.RFC3339),
+			IsEdited:           existingMessage.IsEdited,
 		}, nil
 	}
 
@@ -421,15 +801,18 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 	// If no existing message found, create a new one
 	// Use the messageObjID that was already defined above
 	chatResponseMsg := &models.Message{
-		Base:          models.NewBase(),
-		UserID:        userObjID,
-		ChatID:        chatObjID,
-		Content:       assistantMessage,
-		Type:          "assistant",
-		Queries:       queriesPtr,
-		ActionButtons: actionButtonsPtr,
-		IsEdited:      false,
-		UserMessageId: &userMessageObjID, // Set the user message ID that this AI message is responding to
+		Base:               models.NewBase(),
+		UserID:             userObjID,
+		ChatID:             chatObjID,
+		Content:            assistantMessage,
+		Type:               "assistant",
+		Queries:            queriesPtr,
+		ActionButtons:      actionButtonsPtr,
+		ParameterRequests:  &parameterRequests,
+		NeedsClarification: needsClarification,
+		Confidence:         confidence,
+		IsEdited:           false,
+		UserMessageId:      &userMessageObjID, // Set the user message ID that this AI message is responding to
 	}
 
 	if err := s.chatRepo.CreateMessage(chatResponseMsg); err != nil {
@@ -457,32 +840,39 @@ func (s *chatService) processLLMResponse(ctx context.Context, userID, chatID, us
 		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
 			Event: "ai-response",
 			Data: &dtos.MessageResponse{
-				ID:            chatResponseMsg.ID.Hex(),
-				ChatID:        chatResponseMsg.ChatID.Hex(),
-				Content:       chatResponseMsg.Content,
-				UserMessageID: utils.ToStringPtr(userMessageObjID.Hex()),
-				Queries:       dtos.ToQueryDto(chatResponseMsg.Queries),
-				ActionButtons: dtos.ToActionButtonDto(chatResponseMsg.ActionButtons),
-				Type:          chatResponseMsg.Type,
-				CreatedAt:     chatResponseMsg.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:     chatResponseMsg.UpdatedAt.Format(time.RFC3339),
+				ID:                 chatResponseMsg.ID.Hex(),
+				ChatID:             chatResponseMsg.ChatID.Hex(),
+				Content:            chatResponseMsg.Content,
+				UserMessageID:      utils.ToStringPtr(userMessageObjID.Hex()),
+				Queries:            dtos.ToQueryDto(chatResponseMsg.Queries),
+				ActionButtons:      dtos.ToActionButtonDto(chatResponseMsg.ActionButtons),
+				ParameterRequests:  dtos.ToParameterRequestDto(chatResponseMsg.ParameterRequests),
+				NeedsClarification: chatResponseMsg.NeedsClarification,
+				Confidence:         chatResponseMsg.Confidence,
+				Type:               chatResponseMsg.Type,
+				CreatedAt:          chatResponseMsg.CreatedAt.Format(time.RFC3339),
+				UpdatedAt:          chatResponseMsg.UpdatedAt.Format(time.RFC3339),
 			},
 		})
 	}
 	return &dtos.MessageResponse{
-		ID:            chatResponseMsg.ID.Hex(),
-		ChatID:        chatResponseMsg.ChatID.Hex(),
-		Content:       chatResponseMsg.Content,
-		UserMessageID: utils.ToStringPtr(userMessageObjID.Hex()),
-		Queries:       dtos.ToQueryDto(chatResponseMsg.Queries),
-		ActionButtons: dtos.ToActionButtonDto(chatResponseMsg.ActionButtons),
-		Type:          chatResponseMsg.Type,
-		CreatedAt:     chatResponseMsg.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     chatResponseMsg.UpdatedAt.Format(time.RFC3339),
+		ID:                 chatResponseMsg.ID.Hex(),
+		ChatID:             chatResponseMsg.ChatID.Hex(),
+		Content:            chatResponseMsg.Content,
+		UserMessageID:      utils.ToStringPtr(userMessageObjID.Hex()),
+		Queries:            dtos.ToQueryDto(chatResponseMsg.Queries),
+		ActionButtons:      dtos.ToActionButtonDto(chatResponseMsg.ActionButtons),
+		ParameterRequests:  dtos.ToParameterRequestDto(chatResponseMsg.ParameterRequests),
+		NeedsClarification: chatResponseMsg.NeedsClarification,
+		Confidence:         chatResponseMsg.Confidence,
+		Type:               chatResponseMsg.Type,
+		CreatedAt:          chatResponseMsg.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          chatResponseMsg.UpdatedAt.Format(time.RFC3339),
 	}, nil
 }
 
-// Cancels the ongoing LLM processing for the given streamID
+// Cancels the ongoing LLM processing for the given streamID, a mutation auto executed for the stream is rolled back
+// or, when a rollback can't undo it, left to finish so that the database isn't left half updated
 func (s *chatService) CancelProcessing(userID, chatID, streamID string) {
 	s.processesMu.Lock()
 	defer s.processesMu.Unlock()
@@ -490,9 +880,20 @@ func (s *chatService) CancelProcessing(userID, chatID, streamID string) {
 	log.Printf("CancelProcessing -> activeProcesses: %+v", s.activeProcesses)
 	if cancel, exists := s.activeProcesses[streamID]; exists {
 		log.Printf("CancelProcessing -> canceling LLM processing for streamID: %s", streamID)
-		cancel() // Only cancels the LLM context
+		cancel() // Only cancels the LLM context, generation can be stopped at any time
 		delete(s.activeProcesses, streamID)
 
+		content := "Operation cancelled by user"
+		if s.dbManager.IsMutationRunning(streamID) {
+			if s.dbManager.CancelQueryExecution(streamID) {
+				log.Printf("CancelProcessing -> rolled back the running mutation for streamID: %s", streamID)
+				content = "Operation cancelled by user, the changes of the running query were rolled back"
+			} else {
+				log.Printf("CancelProcessing -> the running mutation can't be rolled back, letting it finish for streamID: %s", streamID)
+				content = "Operation cancelled by user, the running query changes data that can't be rolled back so it is left to finish"
+			}
+		}
+
 		go func() {
 			chatObjID, err := primitive.ObjectIDFromHex(chatID)
 			if err != nil {
@@ -509,7 +910,7 @@ func (s *chatService) CancelProcessing(userID, chatID, streamID string) {
 				ChatID:  chatObjID,
 				UserID:  userObjID,
 				Type:    string(constants.MessageTypeAssistant),
-				Content: "Operation cancelled by user",
+				Content: content,
 			}
 
 			// Save cancelled event to database
@@ -520,7 +921,7 @@ func (s *chatService) CancelProcessing(userID, chatID, streamID string) {
 		// Send cancelled event using stream
 		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
 			Event: "response-cancelled",
-			Data:  "Operation cancelled by user",
+			Data:  content,
 		})
 	}
 }
@@ -551,46 +952,45 @@ func (s *chatService) ConnectDB(ctx context.Context, userID, chatID string, stre
 		return http.StatusForbidden, fmt.Errorf("chat does not belong to user")
 	}
 
-	// Check if connection details are present
-	if chat.Connection.Host == "" || chat.Connection.Database == "" {
-		return http.StatusBadRequest, fmt.Errorf("connection details are incomplete")
+	if chat.Settings.GenerateOnly {
+		return http.StatusBadRequest, errGenerateOnlyChat
+	}
+
+	// Check if connection details are present, a SQLite database is a file rather than a host & BigQuery authenticates
+	// with the service account key of a project
+	switch chat.Connection.Type {
+	case constants.DatabaseTypeSQLite:
+		if chat.Connection.FilePath == "" {
+			return http.StatusBadRequest, fmt.Errorf("connection details are incomplete")
+		}
+	case constants.DatabaseTypeBigQuery:
+		if chat.Connection.ProjectID == "" || chat.Connection.Database == "" || chat.Connection.CredentialsJSON == "" {
+			return http.StatusBadRequest, fmt.Errorf("connection details are incomplete")
+		}
+	default:
+		if chat.Connection.Host == "" || chat.Connection.Database == "" {
+			return http.StatusBadRequest, fmt.Errorf("connection details are incomplete")
+		}
 	}
 
 	// Decrypt connection details
-	utils.DecryptConnection(&chat.Connection)
+	connection := chat.Connection
+	utils.DecryptConnection(&connection)
 
-	// Ensure port has a default value if empty
-	if chat.Connection.Port == nil || *chat.Connection.Port == "" {
-		var defaultPort string
-		switch chat.Connection.Type {
-		case constants.DatabaseTypePostgreSQL:
-			defaultPort = "5432"
-		case constants.DatabaseTypeYugabyteDB:
-			defaultPort = "5433"
-		case constants.DatabaseTypeMySQL:
-			defaultPort = "3306"
-		case constants.DatabaseTypeClickhouse:
-			defaultPort = "9000"
-		case constants.DatabaseTypeMongoDB:
-			defaultPort = "27017"
-		}
-		chat.Connection.Port = &defaultPort
+	// Column masks must be in place before the schema with example records is built
+	s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+	s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+	s.dbManager.GetSchemaManager().SetSchemaDescriptions(chatID, toDBSchemaDescriptions(chat.SchemaDescriptions))
+	s.dbManager.GetSchemaManager().SetTableAccessCounts(chatID, chat.TableAccessCountsByTable())
+	s.dbManager.SetSessionMode(chatID, chat.Settings.SessionMode)
+
+	tenantID := s.tenantOf(userID)
+	if err := s.checkTenantConnections(tenantID, chatID); err != nil {
+		return http.StatusTooManyRequests, err
 	}
 
 	// Connect to database
-	err = s.dbManager.Connect(chatID, userID, streamID, dbmanager.ConnectionConfig{
-		Type:           chat.Connection.Type,
-		Host:           chat.Connection.Host,
-		Port:           chat.Connection.Port,
-		Username:       chat.Connection.Username,
-		Password:       chat.Connection.Password,
-		Database:       chat.Connection.Database,
-		UseSSL:         chat.Connection.UseSSL,
-		SSLMode:        chat.Connection.SSLMode,
-		SSLCertURL:     chat.Connection.SSLCertURL,
-		SSLKeyURL:      chat.Connection.SSLKeyURL,
-		SSLRootCertURL: chat.Connection.SSLRootCertURL,
-	})
+	err = s.dbManager.Connect(chatID, userID, streamID, dbConnectionConfig(&connection, tenantID))
 
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
@@ -600,9 +1000,86 @@ func (s *chatService) ConnectDB(ctx context.Context, userID, chatID string, stre
 		}
 	}
 
+	// The named connections of the chat are queried next to the main one
+	s.connectNamedDBs(userID, chat, streamID)
+
 	return http.StatusOK, nil
 }
 
+// dbConnectionConfig maps a decrypted connection of a chat to the config the database manager connects with, the
+// default port of the database type is used when the connection has none
+func dbConnectionConfig(connection *models.Connection, tenantID string) dbmanager.ConnectionConfig {
+	// SQLite & BigQuery connections have no port
+	port := connection.Port
+	hasPort := connection.Type != constants.DatabaseTypeSQLite && connection.Type != constants.DatabaseTypeBigQuery
+	if hasPort && (port == nil || *port == "") {
+		var defaultPort string
+		switch connection.Type {
+		case constants.DatabaseTypePostgreSQL:
+			defaultPort = "5432"
+		case constants.DatabaseTypeYugabyteDB:
+			defaultPort = "5433"
+		case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+			defaultPort = "3306"
+		case constants.DatabaseTypeClickhouse:
+			defaultPort = "9000"
+		case constants.DatabaseTypeMongoDB:
+			defaultPort = "27017"
+		case constants.DatabaseTypeElasticsearch:
+			defaultPort = "9200"
+		case constants.DatabaseTypeCassandra:
+			defaultPort = "9042"
+		}
+		port = &defaultPort
+	}
+
+	return dbmanager.ConnectionConfig{
+		Type:            connection.Type,
+		Host:            connection.Host,
+		Hosts:           connection.Hosts,
+		Shards:          connection.Shards,
+		Port:            port,
+		Username:        connection.Username,
+		Password:        connection.Password,
+		Database:        connection.Database,
+		UseSSL:          connection.UseSSL,
+		SSLMode:         connection.SSLMode,
+		SSLCertURL:      connection.SSLCertURL,
+		SSLKeyURL:       connection.SSLKeyURL,
+		SSLRootCertURL:  connection.SSLRootCertURL,
+		MaxResultRows:   connection.MaxResultRows,
+		Role:            connection.Role,
+		FilePath:        connection.FilePath,
+		ProjectID:       connection.ProjectID,
+		CredentialsJSON: connection.CredentialsJSON,
+		SSHHost:         connection.SSHHost,
+		SSHPort:         connection.SSHPort,
+		SSHUser:         connection.SSHUser,
+		SSHPrivateKey:   connection.SSHPrivateKey,
+		SSHHostKey:      connection.SSHHostKey,
+		TenantID:        tenantID,
+
+		MaxOpenConns:    connection.MaxOpenConns,
+		MaxIdleConns:    connection.MaxIdleConns,
+		ConnMaxLifetime: connection.ConnMaxLifetime,
+	}
+}
+
+// withShardFanOut makes the read query run on every shard of the chat's connection, their rows are merged in the
+// order of the query
+func withShardFanOut(ctx context.Context, chat *models.Chat, query *models.Query) (context.Context, error) {
+	if !isReadQuery(chat, query) {
+		return ctx, fmt.Errorf("only read queries can be executed on every shard")
+	}
+	if !dbmanager.ShardFanOutSupported(chat.Connection.Type) {
+		return ctx, fmt.Errorf("executing queries on every shard is not supported for %s", chat.Connection.Type)
+	}
+	if len(chat.Connection.Shards) == 0 {
+		return ctx, fmt.Errorf("the connection has no shards, add them to the connection first")
+	}
+	return dbmanager.WithShardFanOut(ctx), nil
+}
+
 // DisconnectDB disconnects from a database for the chat
 func (s *chatService) DisconnectDB(ctx context.Context, userID, chatID string, streamID string) (uint32, error) {
 	log.Printf("ChatService -> DisconnectDB -> Starting for chatID: %s", chatID)
@@ -615,20 +1092,47 @@ func (s *chatService) DisconnectDB(ctx context.Context, userID, chatID string, s
 		log.Printf("ChatService -> DisconnectDB -> failed to disconnect: %v", err)
 		return http.StatusBadRequest, fmt.Errorf("failed to disconnect: %v", err)
 	}
+	s.disconnectNamedDBs(userID, chatID)
 
 	log.Printf("ChatService -> DisconnectDB -> disconnected from chat: %s", chatID)
 	return http.StatusOK, nil
 }
 
-// ExecuteQuery executes a query, runs realtime query to connected database, stores the result in execution_result etc...
-func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
+// CloseDBSession closes the session of a chat in session mode, dropping its temp tables & session variables.
+// The connection stays up, a fresh session is opened on the next query while session mode is enabled
+func (s *chatService) CloseDBSession(userID, chatID string) (uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+	if !chat.Settings.SessionMode {
+		return http.StatusBadRequest, fmt.Errorf("session mode is not enabled for this chat")
+	}
+
+	if err := s.dbManager.CloseSession(chatID); err != nil {
+		log.Printf("ChatService -> CloseDBSession -> failed to close session: %v", err)
+		return http.StatusInternalServerError, fmt.Errorf("failed to close session: %v", err)
+	}
+
+	log.Printf("ChatService -> CloseDBSession -> closed session for chat: %s", chatID)
+	return http.StatusOK, nil
+}
+
+// executeQuery runs realtime query to connected database, stores the result in execution_result etc..., see ExecuteQuery
+func (s *chatService) executeQuery(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
 	// Verify message and query ownership
 	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
 	if err != nil {
 		return nil, http.StatusForbidden, err
 	}
+	if err := validateQueryTimeout(req.TimeoutSeconds); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	// The count query & the query itself each get the timeout of the query
+	timeout := queryTimeout(chat, query, req.TimeoutSeconds)
+	ctx = dbmanager.WithQueryTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, 2*timeout)
 	defer cancel()
 
 	select {
@@ -638,8 +1142,77 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		log.Printf("ChatService -> ExecuteQuery -> msg: %+v", msg)
 	}
 
+	if query.IsCritical && isConfirmationExpired(msg, query) {
+		return nil, http.StatusConflict, fmt.Errorf("this critical query was generated more than %d minutes ago, please regenerate it before executing", config.Env.CriticalQueryConfirmationTTLMinutes)
+	}
+
+	if hasPendingParameters(msg) {
+		return nil, http.StatusBadRequest, fmt.Errorf("fill the parameters requested in the message before executing its queries")
+	}
+
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+
+	// The user can run the query on another connection of the chat than the one it was generated for
+	connectionName := query.ConnectionName
+	if req.ConnectionName != "" {
+		connectionName = req.ConnectionName
+	}
+
+	refusal := readOnlyQueryError(chat, query.Query)
+	if refusal == nil {
+		refusal = namedConnectionQueryError(chat, connectionName)
+	}
+	if refusal == nil && connectionName == "" {
+		refusal = tableScopeQueryError(chat, query.Query, query.Tables)
+	}
+	if refusal != nil {
+		return &dtos.QueryExecutionResponse{
+			ChatID:    chatID,
+			MessageID: req.MessageID,
+			QueryID:   req.QueryID,
+			Error: &dtos.QueryError{
+				Code:    refusal.Code,
+				Message: refusal.Message,
+				Details: refusal.Details,
+			},
+		}, http.StatusForbidden, fmt.Errorf("%s", refusal.Message)
+	}
+
+	if req.Preview && !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only read queries can be previewed")
+	}
+
+	if req.ReadOnly && !isReadQuery(chat, query) {
+		return nil, http.StatusForbidden, fmt.Errorf("this API key is read only, only read queries can be executed")
+	}
+
+	if req.AsOf != nil {
+		if !isReadQuery(chat, query) {
+			return nil, http.StatusBadRequest, fmt.Errorf("only read queries can be executed at a point in time")
+		}
+		if !dbmanager.ReadAsOfSupported(chat.Connection.Type) {
+			return nil, http.StatusBadRequest, fmt.Errorf("reading the data at a point in time is not supported for %s", chat.Connection.Type)
+		}
+		if req.AsOf.After(time.Now()) {
+			return nil, http.StatusBadRequest, fmt.Errorf("as_of must be a time in the past")
+		}
+		ctx = dbmanager.WithReadAsOf(ctx, *req.AsOf)
+	}
+
+	if req.AllShards {
+		if ctx, err = withShardFanOut(ctx, chat, query); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if err := s.countTenantQueryExecutions(userID, 1); err != nil {
+		return nil, http.StatusTooManyRequests, err
+	}
+
 	// Check connection status and connect if needed
-	if !s.dbManager.IsConnected(chatID) {
+	if !req.ConnectionHeld && !s.dbManager.IsConnected(chatID) {
 		log.Printf("ChatService -> ExecuteQuery -> Database not connected, initiating connection")
 		status, err := s.ConnectDB(ctx, userID, chatID, req.StreamID)
 		if err != nil {
@@ -649,12 +1222,41 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		time.Sleep(1 * time.Second)
 	}
 
+	if ctx, err = s.withNamedConnection(ctx, userID, chat, connectionName, req.StreamID); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if connectionName != query.ConnectionName {
+		// Stored with the execution of the query, its results are fetched again from the same connection
+		query.ConnectionName = connectionName
+		for i := range *msg.Queries {
+			if (*msg.Queries)[i].ID == query.ID {
+				(*msg.Queries)[i].ConnectionName = connectionName
+			}
+		}
+	}
+
+	if req.Preview {
+		return s.previewQuery(ctx, userID, chatID, chat, req, query)
+	}
+
+	// The rows an UPDATE or DELETE will change are counted first, too many of them wait for the user's confirmation
+	var affectedRowsEstimate *int
+	if !isReadQuery(chat, query) {
+		affectedRowsEstimate = s.estimateAffectedRows(ctx, chat, chatID, req, query)
+		if requiresAffectedRowsConfirmation(affectedRowsEstimate, req.ConfirmAffectedRows) {
+			return s.affectedRowsConfirmationResponse(chatID, msg, query, *affectedRowsEstimate)
+		}
+	}
+
+	// Reads of the current data are served from the cache of the chat unless the user forces a refresh
+	useCache := !req.BypassCache && isReadQuery(chat, query) && req.AsOf == nil && !req.AllShards
+
 	var totalRecordsCount *int
 
 	// To find total records count, we need to execute the pagination.countQuery with findCount = true
 	if query.Pagination != nil && query.Pagination.CountQuery != nil && *query.Pagination.CountQuery != "" {
 		log.Printf("ChatService -> ExecuteQuery -> query.Pagination.CountQuery is present, will use it to get the total records count")
-		countResult, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, *query.Pagination.CountQuery, *query.QueryType, false, true)
+		countResult, queryErr := s.executeCachedQuery(ctx, useCache, chatID, req.MessageID, req.QueryID, req.StreamID, *query.Pagination.CountQuery, *query.QueryType, 0, true)
 		if queryErr != nil {
 			log.Printf("ChatService -> ExecuteQuery -> Error executing count query: %v", queryErr)
 		}
@@ -811,24 +1413,48 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	}
 	queryToExecute := query.Query
 
+	// Number of records of the first page, stored in execution_result, the next pages are fetched by GetQueryResults
+	pageSize := pageSizeOf(chat)
+	var paginatedQuery, paginationWarning string
 	if query.Pagination != nil && query.Pagination.PaginatedQuery != nil && *query.Pagination.PaginatedQuery != "" {
-		log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery is present, will use it to cap the result to 50 records. query.Pagination.PaginatedQuery: %+v", *query.Pagination.PaginatedQuery)
-		// Capping the result to 50 records by default and skipping 0 records, we do not need to run the query.Query as we have better paginated query & already have the total records count
+		log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery is present, will use it to cap the result to %d records. query.Pagination.PaginatedQuery: %+v", pageSize, *query.Pagination.PaginatedQuery)
+		// Capping the result to a page of records and skipping 0 records, we do not need to run the query.Query as we have better paginated query & already have the total records count
+
+		paginatedQuery, paginationWarning = s.paginatedQueryAt(ctx, chat, chatID, query, 0)
+		queryToExecute = paginatedQuery
+	}
 
-		queryToExecute = strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(0), 1)
+	// A read of a whole large table is limited unless the user confirmed it
+	var fullTableReadWarning string
+	if !req.ConfirmFullTableRead && isReadQuery(chat, query) {
+		queryToExecute, fullTableReadWarning = s.limitFullTableRead(ctx, chat, chatID, queryToExecute)
 	}
 
 	log.Printf("ChatService -> ExecuteQuery -> queryToExecute: %+v", queryToExecute)
 	// Execute query, we will be executing the pagination.paginatedQuery if it exists, else the query.Query
-	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, false, false)
+	result, queryErr := s.executeCachedQuery(ctx, useCache, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, 0, false)
 	if queryErr != nil {
 		// Checking if executed query was paginatedQuery, if so, let's try to execute it again with the original query
-		if query.Pagination != nil && query.Pagination.PaginatedQuery != nil && *query.Pagination.PaginatedQuery != "" && queryToExecute == strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(0), 1) {
+		if paginatedQuery != "" && queryToExecute == paginatedQuery {
 			log.Printf("ChatService -> ExecuteQuery -> query.Pagination.PaginatedQuery was executed but faced an error, will try to execute the original query")
 			queryToExecute = query.Query
-			result, queryErr = s.dbManager.ExecuteQuery(ctx, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, false, false)
+			paginationWarning = ""
+			if !req.ConfirmFullTableRead && isReadQuery(chat, query) {
+				queryToExecute, fullTableReadWarning = s.limitFullTableRead(ctx, chat, chatID, queryToExecute)
+			}
+			result, queryErr = s.executeCachedQuery(ctx, useCache, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, 0, false)
 		}
 	}
+	if queryErr != nil && s.refreshStaleSchema(ctx, userID, chatID, req.StreamID, chat, queryErr) {
+		log.Printf("ChatService -> ExecuteQuery -> Retrying queryID %s with the refreshed schema", req.QueryID)
+		result, queryErr = s.executeCachedQuery(ctx, useCache, chatID, req.MessageID, req.QueryID, req.StreamID, queryToExecute, *query.QueryType, 0, false)
+	}
+	if queryErr == nil && paginationWarning != "" {
+		result.Warnings = append(result.Warnings, paginationWarning)
+	}
+	if queryErr == nil && fullTableReadWarning != "" {
+		result.Warnings = append(result.Warnings, fullTableReadWarning)
+	}
 	if queryErr != nil {
 		log.Printf("ChatService -> ExecuteQuery -> queryErr: %+v", queryErr)
 		if queryErr.Code == "FAILED_TO_START_TRANSACTION" || strings.Contains(queryErr.Message, "context deadline exceeded") || strings.Contains(queryErr.Message, "context canceled") {
@@ -871,6 +1497,7 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 			} else {
 				s.removeFixErrorButton(msg)
 			}
+			s.setConfirmAffectedRowsButton(msg, query.ID.Hex(), false)
 
 			if msg.ActionButtons != nil {
 				log.Printf("ChatService -> ExecuteQuery -> queryError, msg.ActionButtons: %+v", *msg.ActionButtons)
@@ -978,11 +1605,19 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 			TotalRecordsCount: nil,
 			ActionButtons:     dtos.ToActionButtonDto(msg.ActionButtons),
 			ActionAt:          query.ActionAt,
+
+			AffectedRowsEstimate: affectedRowsEstimate,
 		}, http.StatusOK, nil
 	}
 
-	// Checking if the result record is a list with > 50 records, then cap it to 50 records.
-	// Then we need to save capped 50 results in DB
+	// Large text & binary values are cut before the result is stored, they stay downloadable with DownloadCellValue
+	var truncatedValues int
+	if result.ResultJSON, truncatedValues = dbmanager.TruncateLargeValues(result.ResultJSON, config.Env.MaxResultCellBytes); truncatedValues > 0 {
+		result.Warnings = append(result.Warnings, dbmanager.LargeValuesTruncatedWarning(truncatedValues, config.Env.MaxResultCellBytes))
+	}
+
+	// Checking if the result record is a list with more records than a page, then cap it to the page size.
+	// Then we need to save the capped results in DB
 	log.Printf("ChatService -> ExecuteQuery -> result: %+v", result)
 	log.Printf("ChatService -> ExecuteQuery -> result.ResultJSON: %+v", result.ResultJSON)
 
@@ -1006,12 +1641,12 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	if len(resultListFormatting) > 0 {
 		log.Printf("ChatService -> ExecuteQuery -> resultListFormatting: %+v", resultListFormatting)
 		formattedResultJSON = resultListFormatting
-		if len(resultListFormatting) > 50 {
-			log.Printf("ChatService -> ExecuteQuery -> resultListFormatting length > 50")
-			formattedResultJSON = resultListFormatting[:50] // Cap the result to 50 records
+		if len(resultListFormatting) > pageSize {
+			log.Printf("ChatService -> ExecuteQuery -> resultListFormatting length > %d", pageSize)
+			formattedResultJSON = resultListFormatting[:pageSize] // Cap the result to a page of records
 
-			// Cap the result.ResultJSON to 50 records
-			cappedResults, err := json.Marshal(resultListFormatting[:50])
+			// Cap the result.ResultJSON to a page of records
+			cappedResults, err := json.Marshal(resultListFormatting[:pageSize])
 			if err != nil {
 				log.Printf("ChatService -> ExecuteQuery -> Error marshaling capped results: %v", err)
 			} else {
@@ -1020,12 +1655,12 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		}
 	} else if resultMapFormatting != nil && resultMapFormatting["results"] != nil && len(resultMapFormatting["results"].([]interface{})) > 0 {
 		log.Printf("ChatService -> ExecuteQuery -> resultMapFormatting: %+v", resultMapFormatting)
-		if len(resultMapFormatting["results"].([]interface{})) > 50 {
+		if len(resultMapFormatting["results"].([]interface{})) > pageSize {
 			formattedResultJSON = map[string]interface{}{
-				"results": resultMapFormatting["results"].([]interface{})[:50],
+				"results": resultMapFormatting["results"].([]interface{})[:pageSize],
 			}
 			cappedResults := map[string]interface{}{
-				"results": resultMapFormatting["results"].([]interface{})[:50],
+				"results": resultMapFormatting["results"].([]interface{})[:pageSize],
 			}
 			cappedResultsJSON, err := json.Marshal(cappedResults)
 			if err != nil {
@@ -1050,12 +1685,37 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 	query.ExecutionTime = &result.ExecutionTime
 	query.ExecutionResult = &result.ResultJSON
 	query.ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+	query.Warnings = result.Warnings
+	if result.Error == nil {
+		query.ExampleResult = alignExampleResultKeys(query.ExampleResult, result.ResultJSON)
+	}
+
+	// Let the user know about caveats such as silent data truncation
+	if len(result.Warnings) > 0 {
+		log.Printf("ChatService -> ExecuteQuery -> Driver warnings: %v", result.Warnings)
+		s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
+			Event: "query-warnings",
+			Data: map[string]interface{}{
+				"chat_id":    chatID,
+				"message_id": msg.ID.Hex(),
+				"query_id":   query.ID.Hex(),
+				"warnings":   result.Warnings,
+			},
+		})
+	}
 	if totalRecordsCount != nil {
 		if query.Pagination == nil {
 			query.Pagination = &models.Pagination{}
 		}
 		query.Pagination.TotalRecordsCount = totalRecordsCount
 	}
+
+	// Help the user tell a too narrow filter from missing data when a SELECT returns nothing
+	var emptyResultDiagnostics *dtos.EmptyResultDiagnostics
+	if result.Error == nil && isReadQuery(chat, query) && isEmptyResult(result.ResultJSON) {
+		emptyResultDiagnostics = s.diagnoseEmptyResult(ctx, chatID, msg, query, req.StreamID)
+	}
+
 	if result.Error != nil {
 		query.Error = &models.QueryError{
 			Code:    result.Error.Code,
@@ -1085,6 +1745,8 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 					log.Printf("ChatService -> ExecuteQuery -> result.ResultJSON: %v", result.ResultJSON)
 					log.Printf("ChatService -> ExecuteQuery -> ExecutionResult before update: %v", (*msg.Queries)[i].ExecutionResult)
 					(*msg.Queries)[i].ExecutionResult = &result.ResultJSON
+					(*msg.Queries)[i].ExampleResult = query.ExampleResult
+					(*msg.Queries)[i].Warnings = result.Warnings
 					log.Printf("ChatService -> ExecuteQuery -> ExecutionResult after update: %v", (*msg.Queries)[i].ExecutionResult)
 					if result.Error != nil {
 						(*msg.Queries)[i].Error = &models.QueryError{
@@ -1112,6 +1774,9 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		} else {
 			s.removeFixErrorButton(msg)
 		}
+		s.setAddFilterButton(msg, query.ID.Hex(), fullTableReadWarning != "")
+		s.setConfirmAffectedRowsButton(msg, query.ID.Hex(), false)
+		s.setExplainResultButton(msg, query.ID.Hex(), result.Error == nil && isReadQuery(chat, query) && !isEmptyResult(result.ResultJSON))
 		// Save updated message
 		if msg.ActionButtons != nil {
 			log.Printf("ChatService -> ExecuteQuery -> msg.ActionButtons: %+v", *msg.ActionButtons)
@@ -1158,7 +1823,7 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 								// If share data with AI is true, then we need to share the result with AI
 								if chat.Settings.ShareDataWithAI {
 									queryMap["executionResult"] = map[string]interface{}{
-										"result": result.ResultJSON,
+										"result": s.sharedResult(chat, result.ResultJSON),
 									}
 								} else {
 									queryMap["executionResult"] = map[string]interface{}{
@@ -1194,7 +1859,7 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 								// If share data with AI is true, then we need to share the result with AI
 								if chat.Settings.ShareDataWithAI {
 									queryMap["executionResult"] = map[string]interface{}{
-										"result": result.ResultJSON,
+										"result": s.sharedResult(chat, result.ResultJSON),
 									}
 								} else {
 									queryMap["executionResult"] = map[string]interface{}{
@@ -1241,17 +1906,34 @@ func (s *chatService) ExecuteQuery(ctx context.Context, userID, chatID string, r
 		TotalRecordsCount: totalRecordsCount,
 		ActionButtons:     dtos.ToActionButtonDto(msg.ActionButtons),
 		ActionAt:          query.ActionAt,
+		Warnings:          result.Warnings,
+		Columns:           result.Columns,
+		Cached:            result.Cached,
+
+		EmptyResultDiagnostics: emptyResultDiagnostics,
+		AffectedRowsEstimate:   affectedRowsEstimate,
 	}, http.StatusOK, nil
 }
 
-func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string, req *dtos.RollbackQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
+func (s *chatService) rollbackQuery(ctx context.Context, userID, chatID string, req *dtos.RollbackQueryRequest) (*dtos.QueryExecutionResponse, uint32, error) {
 	// Verify message and query ownership
 	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
 	if err != nil {
 		return nil, http.StatusForbidden, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+
+	// Rolling back changes data too
+	if chat.Settings.ReadOnly {
+		return nil, http.StatusForbidden, fmt.Errorf("this chat is read only, queries changing data can't be executed")
+	}
+
+	timeout := queryTimeout(chat, query, 0)
+	ctx = dbmanager.WithQueryTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, 2*timeout)
 	defer cancel()
 
 	select {
@@ -1273,6 +1955,13 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 	if !query.CanRollback {
 		return nil, http.StatusBadRequest, fmt.Errorf("query cannot be rolled back")
 	}
+	if err := s.countTenantQueryExecutions(userID, 1); err != nil {
+		return nil, http.StatusTooManyRequests, err
+	}
+	// The rollback runs on the connection of the query
+	if ctx, err = s.withNamedConnection(ctx, userID, chat, query.ConnectionName, req.StreamID); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 	// Check if we need to generate rollback query
 	if query.RollbackQuery == nil || *query.RollbackQuery == "" {
 		// First execute the dependent query to get context
@@ -1416,9 +2105,14 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		// Use copy to avoid modifying original messages
 		copy(llmMessages, llmMsgs)
 
+		llmMessages, anonymizer, err := s.anonymizeLLMMessages(ctx, chat, llmMessages)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
 		// Get rollback query from LLM
 		llmResponse, err := s.llmClient.GenerateResponse(
-			ctx,
+			llm.WithTenant(llm.WithPageSize(llm.WithQueuedNotifier(ctx, s.llmQueuedNotifier(userID, chatID, req.StreamID)), pageSizeOf(chat)), s.tenantOf(userID)),
 			llmMessages,      // Pass the LLM messages array
 			conn.Config.Type, // Pass the database type
 		)
@@ -1432,6 +2126,9 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		if err := json.Unmarshal([]byte(llmResponse), &jsonResponse); err != nil {
 			return nil, http.StatusInternalServerError, fmt.Errorf("failed to parse LLM response: %v", err)
 		}
+		if anonymizer != nil {
+			jsonResponse = anonymizer.DeanonymizeValue(jsonResponse).(map[string]interface{})
+		}
 
 		if msg.Queries != nil {
 			for i := range *msg.Queries {
@@ -1659,7 +2356,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 
 		tempMessage := *msg
 		// Add "Fix Rollback Error" action button temporarily to response so that user can fix the error
-		s.addFixRollbackErrorButton(&tempMessage)
+		s.addFixRollbackErrorButton(&tempMessage, query.ID.Hex())
 
 		return &dtos.QueryExecutionResponse{
 			ChatID:            chatID,
@@ -1701,6 +2398,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 				(*msg.Queries)[i].ExecutionTime = &result.ExecutionTime
 				(*msg.Queries)[i].ExecutionResult = &result.ResultJSON
 				(*msg.Queries)[i].ActionAt = utils.ToStringPtr(time.Now().Format(time.RFC3339))
+				(*msg.Queries)[i].Warnings = result.Warnings
 				if result.Error != nil {
 					(*msg.Queries)[i].Error = &models.QueryError{
 						Code:    result.Error.Code,
@@ -1755,7 +2453,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 							// If share data with AI is true, then we need to share the result with AI
 							if chat.Settings.ShareDataWithAI {
 								queryMap["executionResult"] = map[string]interface{}{
-									"result": result.ResultJSON,
+									"result": s.sharedResult(chat, result.ResultJSON),
 								}
 							} else {
 								queryMap["executionResult"] = map[string]interface{}{
@@ -1791,7 +2489,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 							// If share data with AI is true, then we need to share the result with AI
 							if chat.Settings.ShareDataWithAI {
 								queryMap["executionResult"] = map[string]interface{}{
-									"result": result.ResultJSON,
+									"result": s.sharedResult(chat, result.ResultJSON),
 								}
 							} else {
 								queryMap["executionResult"] = map[string]interface{}{
@@ -1838,6 +2536,7 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 			"error":            query.Error,
 			"action_buttons":   dtos.ToActionButtonDto(msg.ActionButtons),
 			"action_at":        query.ActionAt,
+			"warnings":         result.Warnings,
 		},
 	})
 
@@ -1852,15 +2551,33 @@ func (s *chatService) RollbackQuery(ctx context.Context, userID, chatID string,
 		Error:           result.Error,
 		ActionButtons:   dtos.ToActionButtonDto(msg.ActionButtons),
 		ActionAt:        query.ActionAt,
+		Warnings:        result.Warnings,
 	}, http.StatusOK, nil
 }
 
-// Cancels the ongoing query & rollback execution for the given streamID
+// Cancels the ongoing query & rollback execution for the given streamID, a mutation that can't be rolled back is left
+// to finish & the client is told the cancellation was deferred
 func (s *chatService) CancelQueryExecution(userID, chatID, messageID, queryID, streamID string) {
 	log.Printf("ChatService -> CancelQueryExecution -> Cancelling query for streamID: %s", streamID)
 
 	// 1. Cancel the query execution in dbManager
-	s.dbManager.CancelQueryExecution(streamID)
+	if !s.dbManager.CancelQueryExecution(streamID) {
+		s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+			Event: "query-cancel-deferred",
+			Data: map[string]interface{}{
+				"chat_id":    chatID,
+				"message_id": messageID,
+				"query_id":   queryID,
+				"stream_id":  streamID,
+				"error": map[string]string{
+					"code":    "QUERY_CANCELLATION_DEFERRED",
+					"message": "The query changes data that can't be rolled back, it is left to finish",
+				},
+			},
+		})
+		log.Printf("ChatService -> CancelQueryExecution -> Mutation left to finish for streamID: %s", streamID)
+		return
+	}
 
 	// 2. Send cancellation event to client
 	s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
@@ -1880,9 +2597,31 @@ func (s *chatService) CancelQueryExecution(userID, chatID, messageID, queryID, s
 	log.Printf("ChatService -> CancelQueryExecution -> Query cancelled successfully for streamID: %s", streamID)
 }
 
+// holdConnection connects the database of a chat if needed & holds its connection, see
+// dbmanager.Manager.HoldConnection. Returns nil if the chat couldn't be connected, its queries then check the
+// connection on their own.
+func (s *chatService) holdConnection(ctx context.Context, userID, chatID, streamID string) func() {
+	if !s.dbManager.IsConnected(chatID) {
+		if _, err := s.ConnectDB(ctx, userID, chatID, streamID); err != nil {
+			log.Printf("ChatService -> holdConnection -> Failed to connect chatID %s: %v", chatID, err)
+			return nil
+		}
+		// Give a small delay for connection to stabilize
+		time.Sleep(1 * time.Second)
+	}
+	release, ok := s.dbManager.HoldConnection(chatID)
+	if !ok {
+		return nil
+	}
+	return release
+}
+
 // ProcessLLMResponseAndRunQuery processes the LLM response & runs the query automatically, updates SSE stream
 func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID, chatID string, messageID, streamID string) error {
-	msgCtx, cancel := context.WithCancel(context.Background())
+	if s.rejectRateLimitedGeneration(userID, chatID, streamID) {
+		return nil
+	}
+	msgCtx, cancel := context.WithCancel(withAlternativeQuery(WithSourceIP(context.Background(), sourceIPOf(ctx)), alternativeQueryOf(ctx)))
 
 	log.Printf("ProcessLLMResponseAndRunQuery -> userID: %s, chatID: %s, streamID: %s", userID, chatID, streamID)
 
@@ -1913,7 +2652,7 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 			return
 		}
 		log.Printf("ProcessLLMResponseAndRunQuery -> msgResp: %v", msgResp)
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(WithSourceIP(context.Background(), sourceIPOf(msgCtx)), 30*time.Second)
 		defer cancel()
 		select {
 		case <-ctx.Done():
@@ -1921,18 +2660,31 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 			return
 		default:
 			log.Printf("ProcessLLMResponseAndRunQuery -> msgResp.Queries: %v", msgResp.Queries)
-			if msgResp.Queries != nil {
+			// Queries waiting for parameters are executed once the user submits them
+			if msgResp.Queries != nil && msgResp.ParameterRequests == nil {
 				s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
 					Event: "ai-response-step",
 					Data:  "Executing the needful query now.",
 				})
+				// The connection is checked once for all the queries of the response & held until they ran
+				var releaseConnection func()
+				defer func() {
+					if releaseConnection != nil {
+						releaseConnection()
+					}
+				}()
 				tempQueries := make([]dtos.Query, len(*msgResp.Queries))
 				for i, query := range *msgResp.Queries {
-					if query.Query != "" && !query.IsCritical {
+					// A near duplicate would mostly return the result of the query it resembles again
+					if query.Query != "" && !query.IsCritical && query.NearDuplicateOf == nil && (query.Error == nil || (query.Error.Code != readOnlyModeErrorCode && query.Error.Code != tableNotInScopeErrorCode && query.Error.Code != unknownConnectionErrorCode)) {
+						if releaseConnection == nil && config.Env.AutoExecuteConnectionAffinity {
+							releaseConnection = s.holdConnection(ctx, userID, chatID, streamID)
+						}
 						executionResult, _, queryErr := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
-							MessageID: msgResp.ID,
-							QueryID:   query.ID,
-							StreamID:  streamID,
+							MessageID:      msgResp.ID,
+							QueryID:        query.ID,
+							StreamID:       streamID,
+							ConnectionHeld: releaseConnection != nil,
 						})
 						if queryErr != nil {
 							log.Printf("Error executing query: %v", queryErr)
@@ -1971,6 +2723,8 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 							msgResp.ActionButtons = nil
 						}
 						query.Error = executionResult.Error
+						query.Warnings = executionResult.Warnings
+						query.EmptyResultDiagnostics = executionResult.EmptyResultDiagnostics
 						if query.Pagination != nil && executionResult.TotalRecordsCount != nil {
 							query.Pagination.TotalRecordsCount = *executionResult.TotalRecordsCount
 						}
@@ -1999,10 +2753,14 @@ func (s *chatService) processLLMResponseAndRunQuery(ctx context.Context, userID,
 }
 
 // ProcessMessage processes the message, updates SSE stream only if allowSSEUpdates is true, allowSSEUpdates is used to send SSE updates to the client except the final ai-response event
-func (s *chatService) processMessage(_ context.Context, userID, chatID, messageID, streamID string) error {
+func (s *chatService) processMessage(ctx context.Context, userID, chatID, messageID, streamID string) error {
+	if s.rejectRateLimitedGeneration(userID, chatID, streamID) {
+		return nil
+	}
 	// Create a new context specifically for LLM processing
-	// Use context.Background() to avoid cancellation of the parent context
-	msgCtx, cancel := context.WithCancel(context.Background())
+	// Use context.Background() to avoid cancellation of the parent context, only a regeneration request & the source IP
+	// are carried over
+	msgCtx, cancel := context.WithCancel(withAlternativeQuery(WithSourceIP(context.Background(), sourceIPOf(ctx)), alternativeQueryOf(ctx)))
 
 	log.Printf("ProcessMessage -> userID: %s, chatID: %s, streamID: %s", userID, chatID, streamID)
 
@@ -2067,9 +2825,14 @@ func (s *chatService) processMessage(_ context.Context, userID, chatID, messageI
 	return nil
 }
 
-// RefreshSchema refreshes the schema of the chat & stores the latest schema in the database
-func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string, sync bool) (uint32, error) {
+// RefreshSchema refreshes the schema of the chat & stores the latest schema in the database. webhookURL, or else the
+// webhook of the chat settings, is notified once the refresh completes, see notifySchemaRefreshWebhook.
+func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string, sync bool, webhookURL string) (uint32, error) {
 	log.Printf("ChatService -> RefreshSchema -> Starting for chatID: %s", chatID)
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return http.StatusBadRequest, err
+	}
+	s.recordAuditLog(ctx, userID, chatID, constants.AuditActionSchemaRefresh, map[string]interface{}{"sync": sync})
 
 	// Increase the timeout for the initial context to 60 minutes
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Minute)
@@ -2104,6 +2867,12 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 			return http.StatusNotFound, fmt.Errorf("chat not found")
 		}
 
+		// Masks are only kept in memory, make sure they are in place before example records are formatted
+		s.dbManager.GetSchemaManager().SetColumnMasks(chatID, chat.Settings.ColumnMasks)
+		s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(chatID, !chat.Settings.DisableExampleRecords)
+		s.dbManager.GetSchemaManager().SetSchemaDescriptions(chatID, toDBSchemaDescriptions(chat.SchemaDescriptions))
+		s.dbManager.GetSchemaManager().SetTableAccessCounts(chatID, chat.TableAccessCountsByTable())
+
 		// Convert the selectedCollections string to a slice
 		var selectedCollectionsSlice []string
 		if chat.SelectedCollections != "ALL" && chat.SelectedCollections != "" {
@@ -2111,8 +2880,20 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 		}
 		log.Printf("ChatService -> RefreshSchema -> Selected collections: %v", selectedCollectionsSlice)
 
+		if webhookURL == "" {
+			webhookURL = chat.Settings.SchemaRefreshWebhookURL
+		}
+
 		dataChan := make(chan error, 1)
 		go func() {
+			// The webhook is notified after the result is sent, a synchronous refresh doesn't wait for it
+			var refreshErr error
+			if webhookURL != "" {
+				defer func() {
+					s.notifySchemaRefreshWebhook(webhookURL, chatID, refreshErr)
+				}()
+			}
+
 			// Create a new context with a longer timeout specifically for the schema refresh operation
 			// Increase to 90 minutes to handle large schemas or slow database responses
 			schemaCtx, schemaCancel := context.WithTimeout(context.Background(), 90*time.Minute)
@@ -2121,6 +2902,7 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 			userObjID, err := primitive.ObjectIDFromHex(userID)
 			if err != nil {
 				log.Printf("ChatService -> RefreshSchema -> Error getting userID: %v", err)
+				refreshErr = err
 				dataChan <- err
 				return
 			}
@@ -2132,13 +2914,16 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 			schemaMsg, err := s.dbManager.RefreshSchemaWithExamples(schemaCtx, chatID, selectedCollectionsSlice)
 			if err != nil {
 				log.Printf("ChatService -> RefreshSchema -> Error refreshing schema with examples: %v", err)
+				refreshErr = err
 				dataChan <- err
 				return
 			}
 
-			if schemaMsg == "" {
-				log.Printf("ChatService -> RefreshSchema -> Warning: Empty schema message returned")
-				schemaMsg = "Schema refresh completed, but no schema information was returned. Please check your database connection and selected tables."
+			// Without tables the LLM gets the reason instead of a schema, so that it explains what to fix
+			if schema := s.dbManager.GetKnownSchema(schemaCtx, chatID); schemaMsg == "" || schema == nil || len(schema.Tables) == 0 {
+				log.Printf("ChatService -> RefreshSchema -> Warning: Empty schema returned")
+				diagnosis := s.dbManager.DiagnoseEmptySchema(schemaCtx, chatID, selectedCollectionsSlice)
+				schemaMsg = "The schema of the database is empty. " + diagnosis.Message
 			}
 
 			log.Printf("ChatService -> RefreshSchema -> schemaMsg length: %d", len(schemaMsg))
@@ -2166,21 +2951,30 @@ func (s *chatService) RefreshSchema(ctx context.Context, userID, chatID string,
 
 		if sync {
 			log.Println("ChatService -> RefreshSchema -> Waiting for Synchronous refresh to complete")
-			<-dataChan
+			if err := <-dataChan; err != nil {
+				return http.StatusInternalServerError, fmt.Errorf("failed to refresh the schema: %v", err)
+			}
 			log.Println("ChatService -> RefreshSchema -> Synchronous refresh completed")
 		}
 		return http.StatusOK, nil
 	}
 }
 
-// Fetches paginated results for a query, default first 50 records of a large result are stored in execution_result so it fetches records after first 50 recordds
-func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int) (*dtos.QueryResultsResponse, uint32, error) {
+// Fetches paginated results for a query, the first page of a large result is stored in execution_result so it fetches the records after the first page,
+// a page holds the DefaultPageSize records of the chat
+func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, offset int, allShards, bypassCache bool) (*dtos.QueryResultsResponse, uint32, error) {
 	log.Printf("ChatService -> GetQueryResults -> userID: %s, chatID: %s, messageID: %s, queryID: %s, streamID: %s, offset: %d", userID, chatID, messageID, queryID, streamID, offset)
-	_, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	chat, _, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
 
+	if allShards {
+		if ctx, err = withShardFanOut(ctx, chat, query); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
 	if query.Pagination == nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("query does not support pagination")
 	}
@@ -2195,14 +2989,20 @@ func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messa
 			return nil, status, err
 		}
 	}
+	if ctx, err = s.withNamedConnection(ctx, userID, chat, query.ConnectionName, streamID); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 	log.Printf("ChatService -> GetQueryResults -> query.Pagination.PaginatedQuery: %+v", query.Pagination.PaginatedQuery)
-	offSettPaginatedQuery := strings.Replace(*query.Pagination.PaginatedQuery, "offset_size", strconv.Itoa(offset), 1)
+	offSettPaginatedQuery, _ := s.paginatedQueryAt(ctx, chat, chatID, query, offset)
 	log.Printf("ChatService -> GetQueryResults -> offSettPaginatedQuery: %+v", offSettPaginatedQuery)
-	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, messageID, queryID, streamID, offSettPaginatedQuery, *query.QueryType, false, false)
+	useCache := !bypassCache && !allShards && isReadQuery(chat, query)
+	result, queryErr := s.executeCachedQuery(ctx, useCache, chatID, messageID, queryID, streamID, offSettPaginatedQuery, *query.QueryType, offset, false)
 	if queryErr != nil {
 		log.Printf("ChatService -> GetQueryResults -> queryErr: %+v", queryErr)
 		return nil, http.StatusBadRequest, fmt.Errorf(queryErr.Message)
 	}
+	// Large values are cut like those of the first page
+	result.ResultJSON, _ = dbmanager.TruncateLargeValues(result.ResultJSON, config.Env.MaxResultCellBytes)
 
 	var formattedResultJSON interface{}
 	var resultListFormatting []interface{} = []interface{}{}
@@ -2244,16 +3044,22 @@ func (s *chatService) GetQueryResults(ctx context.Context, userID, chatID, messa
 		ExecutionResult:   formattedResultJSON,
 		Error:             queryErr,
 		TotalRecordsCount: query.Pagination.TotalRecordsCount,
+		Cached:            result.Cached,
 	}, http.StatusOK, nil
 }
 
 // Helper function to add a "Fix Rollback Error" button to a message
-func (s *chatService) addFixRollbackErrorButton(msg *models.Message) {
-	log.Printf("ChatService -> addFixRollbackErrorButton -> msg.id: %s", msg.ID)
+func (s *chatService) addFixRollbackErrorButton(msg *models.Message, queryID string) {
+	log.Printf("ChatService -> addFixRollbackErrorButton -> msg.id: %s, queryID: %s", msg.ID, queryID)
+
+	var existingButtons []models.ActionButton
+	if msg.ActionButtons != nil {
+		existingButtons = *msg.ActionButtons
+	}
 
 	// Check if message already has a "Fix Rollback Error" button
 	hasFixRollbackErrorButton := false
-	for _, button := range *msg.ActionButtons {
+	for _, button := range existingButtons {
 		if button.Action == "fix_rollback_error" {
 			hasFixRollbackErrorButton = true
 			break
@@ -2262,11 +3068,12 @@ func (s *chatService) addFixRollbackErrorButton(msg *models.Message) {
 
 	if !hasFixRollbackErrorButton {
 		fixRollbackErrorButton := models.ActionButton{
-			ID:     primitive.NewObjectID(),
-			Label:  "Fix Rollback Error",
-			Action: "fix_rollback_error",
+			ID:      primitive.NewObjectID(),
+			Label:   "Fix Rollback Error",
+			Action:  "fix_rollback_error",
+			Payload: map[string]interface{}{constants.ActionPayloadQueryID: queryID},
 		}
-		actionButtons := append(*msg.ActionButtons, fixRollbackErrorButton)
+		actionButtons := append(existingButtons, fixRollbackErrorButton)
 		msg.ActionButtons = &actionButtons
 		log.Printf("ChatService -> addFixRollbackErrorButton -> Added fix_rollback_error button to existing array")
 	}
@@ -2276,14 +3083,15 @@ func (s *chatService) addFixRollbackErrorButton(msg *models.Message) {
 func (s *chatService) addFixErrorButton(msg *models.Message) {
 	log.Printf("ChatService -> addFixErrorButton -> msg.id: %s", msg.ID)
 
-	// Check if any query has an error
+	// Check if any query has an error, the failed queries are the payload of the button
 	hasError := false
+	failedQueryIDs := make([]string, 0)
 	if msg.Queries != nil {
 		for _, query := range *msg.Queries {
 			if query.Error != nil {
 				hasError = true
+				failedQueryIDs = append(failedQueryIDs, query.ID.Hex())
 				log.Printf("ChatService -> addFixErrorButton -> Found error in query: %s", query.ID.Hex())
-				break
 			}
 		}
 	} else {
@@ -2303,6 +3111,7 @@ func (s *chatService) addFixErrorButton(msg *models.Message) {
 		Label:     "Fix Error",
 		Action:    "fix_error",
 		IsPrimary: true,
+		Payload:   map[string]interface{}{constants.ActionPayloadQueryIDs: failedQueryIDs},
 	}
 
 	// Initialize action buttons array if it doesn't exist
@@ -2311,11 +3120,12 @@ func (s *chatService) addFixErrorButton(msg *models.Message) {
 		msg.ActionButtons = &actionButtons
 		log.Printf("ChatService -> addFixErrorButton -> Created new action buttons array")
 	} else {
-		// Check if a fix_error button already exists
+		// Check if a fix_error button already exists, its payload is refreshed with the queries failing now
 		hasFixErrorButton := false
-		for _, button := range *msg.ActionButtons {
+		for i, button := range *msg.ActionButtons {
 			if button.Action == "fix_error" {
 				hasFixErrorButton = true
+				(*msg.ActionButtons)[i].Payload = fixErrorButton.Payload
 				break
 			}
 		}
@@ -2337,6 +3147,33 @@ func (s *chatService) addFixErrorButton(msg *models.Message) {
 	}
 }
 
+// actionButtonPayload reads the payload of an action button of the LLM response, only the keys with a value are kept
+func actionButtonPayload(value interface{}) map[string]interface{} {
+	payloadMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	payload := make(map[string]interface{})
+	if table, ok := payloadMap[constants.ActionPayloadTable].(string); ok && table != "" {
+		payload[constants.ActionPayloadTable] = table
+	}
+	if columns, ok := payloadMap[constants.ActionPayloadColumns].([]interface{}); ok {
+		names := make([]string, 0, len(columns))
+		for _, column := range columns {
+			if name, ok := column.(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			payload[constants.ActionPayloadColumns] = names
+		}
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return payload
+}
+
 // Helper function to remove the "Fix Error" button from a message
 func (s *chatService) removeFixErrorButton(msg *models.Message) {
 	log.Printf("ChatService -> removeFixErrorButton -> msg.id: %s", msg.ID)
@@ -2386,3 +3223,164 @@ func (s *chatService) removeFixErrorButton(msg *models.Message) {
 		log.Printf("ChatService -> removeFixErrorButton -> msg.ActionButtons: nil")
 	}
 }
+
+// hasLLMQueries checks if the LLM response contains at least one query
+func hasLLMQueries(jsonResponse map[string]interface{}) bool {
+	queries, ok := jsonResponse["queries"].([]interface{})
+	return ok && len(queries) > 0
+}
+
+// isDataRequest checks if the latest user message looks like it is asking for data
+func isDataRequest(messages []*models.LLMMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != string(constants.MessageTypeUser) {
+			continue
+		}
+		userMessage, ok := messages[i].Content["user_message"].(string)
+		if !ok {
+			return false
+		}
+		userMessage = strings.ToLower(strings.TrimSpace(userMessage)) + " "
+		for _, prefix := range constants.DataRequestPolitePrefixes {
+			userMessage = strings.TrimPrefix(userMessage, prefix)
+		}
+		for _, prefix := range constants.DataRequestPrefixes {
+			if strings.HasPrefix(userMessage, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// alignExampleResultKeys renames the example result keys that differ from the executed result columns only by case,
+// e.g. "TotalSales" vs "totalsales" on PostgreSQL, so that both results can be matched by column name
+func alignExampleResultKeys(exampleResult *string, resultJSON string) *string {
+	if exampleResult == nil {
+		return nil
+	}
+
+	resultRecords, err := extractResultRecords(resultJSON)
+	if err != nil || len(resultRecords) == 0 {
+		return exampleResult
+	}
+	columns := make([]string, 0, len(resultRecords[0]))
+	for column := range resultRecords[0] {
+		columns = append(columns, column)
+	}
+
+	var exampleRecords []map[string]interface{}
+	if err := json.Unmarshal([]byte(*exampleResult), &exampleRecords); err != nil {
+		return exampleResult
+	}
+	if !dbmanager.AlignRecordKeys(exampleRecords, columns) {
+		return exampleResult
+	}
+
+	aligned, err := json.Marshal(exampleRecords)
+	if err != nil {
+		log.Printf("ChatService -> alignExampleResultKeys -> Error marshaling example result: %v", err)
+		return exampleResult
+	}
+	return utils.ToStringPtr(string(aligned))
+}
+
+// readOnlyModeErrorCode is the error of the queries a read only chat refuses to execute
+const readOnlyModeErrorCode = "READ_ONLY_MODE"
+
+// readOnlyQueryError returns the error refusing a query changing data in a read only chat, nil if the query can run.
+// The query itself is checked, the isCritical flag & queryType generated with it can be wrong (ex: a CTE wrapping a DELETE).
+func readOnlyQueryError(chat *models.Chat, query string) *models.QueryError {
+	if !chat.Settings.ReadOnly || dbmanager.IsReadOnlyQuery(chat.Connection.Type, query) {
+		return nil
+	}
+	return &models.QueryError{
+		Code:    readOnlyModeErrorCode,
+		Message: "This chat is read only, queries changing data can't be executed",
+		Details: "Only SELECT queries (find & aggregate for MongoDB) run in read only mode, disable it in the chat settings to execute this query",
+	}
+}
+
+// tableNotInScopeErrorCode is the error of the queries referencing tables outside the collections selected for a chat
+const tableNotInScopeErrorCode = "TABLE_NOT_IN_SCOPE"
+
+// tableScopeQueryError returns the error refusing a query referencing tables outside the collections selected for a
+// chat, nil if the query can run or all the collections are selected. Both the tables the LLM generated with the query
+// & the ones found in the query itself are checked, the LLM can leave out a table it joins.
+func tableScopeQueryError(chat *models.Chat, query string, tables *string) *models.QueryError {
+	if chat.SelectedCollections == "ALL" || chat.SelectedCollections == "" {
+		return nil
+	}
+	selected := make(map[string]bool)
+	for _, collection := range strings.Split(chat.SelectedCollections, ",") {
+		selected[unqualifiedTableName(collection)] = true
+	}
+
+	referenced := dbmanager.ReferencedTables(chat.Connection.Type, query)
+	if tables != nil {
+		for _, table := range strings.Split(*tables, ",") {
+			referenced = append(referenced, unqualifiedTableName(table))
+		}
+	}
+	var outOfScope []string
+	seen := make(map[string]bool)
+	for _, table := range referenced {
+		if table == "" || selected[table] || seen[table] {
+			continue
+		}
+		seen[table] = true
+		outOfScope = append(outOfScope, table)
+	}
+	if len(outOfScope) == 0 {
+		return nil
+	}
+	return &models.QueryError{
+		Code:    tableNotInScopeErrorCode,
+		Message: fmt.Sprintf("This query references tables outside the collections selected for this chat: %s", strings.Join(outOfScope, ", ")),
+		Details: "Only the selected collections can be queried, select these tables in the chat settings to execute this query",
+	}
+}
+
+// unqualifiedTableName lower cases a table name & removes its schema & quotes, ex: "Public"."Orders" becomes orders
+func unqualifiedTableName(table string) string {
+	table = strings.TrimSpace(table)
+	if dot := strings.LastIndex(table, "."); dot != -1 {
+		table = table[dot+1:]
+	}
+	return strings.ToLower(strings.Trim(table, "\"`[]"))
+}
+
+// queryTimeout returns the time a query of a chat can run: the timeout of a retry when set, the one of the chat
+// otherwise, or 3 times the response time estimated by the LLM (at least MinDynamicQueryTimeoutSeconds) so that simple
+// lookups fail fast while heavy analytical queries get the time they need
+func queryTimeout(chat *models.Chat, query *models.Query, retrySeconds int) time.Duration {
+	if retrySeconds > 0 {
+		return time.Duration(retrySeconds) * time.Second
+	}
+	if chat.Settings.QueryTimeoutSeconds > 0 {
+		return time.Duration(chat.Settings.QueryTimeoutSeconds) * time.Second
+	}
+	timeout := time.Duration(constants.MinDynamicQueryTimeoutSeconds) * time.Second
+	if estimate := 3 * time.Duration(query.ExampleExecutionTime) * time.Millisecond; estimate > timeout {
+		timeout = estimate
+	}
+	return min(timeout, time.Duration(constants.MaxQueryTimeoutSeconds)*time.Second)
+}
+
+// isConfirmationExpired checks if a query was generated longer ago than the critical query confirmation TTL,
+// queries generated before GeneratedAt was tracked fall back to the creation time of their message
+func isConfirmationExpired(msg *models.Message, query *models.Query) bool {
+	ttl := time.Duration(config.Env.CriticalQueryConfirmationTTLMinutes) * time.Minute
+	if ttl <= 0 {
+		return false
+	}
+
+	generatedAt := msg.CreatedAt
+	if query.GeneratedAt != nil {
+		if parsed, err := time.Parse(time.RFC3339, *query.GeneratedAt); err == nil {
+			generatedAt = parsed
+		}
+	}
+	return time.Since(generatedAt) > ttl
+}
@@ -0,0 +1,939 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"databot-ai/pkg/objectstorage"
+	"databot-ai/pkg/parquet"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// Export formats
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatParquet = "parquet"
+	ExportFormatNDJSON  = "ndjson"
+)
+
+// ContentTypeNDJSON is the media type of newline delimited JSON, one JSON object per line
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// UpdateExportDestination sets the object storage bucket used for cloud exports of the chat
+func (s *chatService) UpdateExportDestination(userID, chatID string, req *dtos.ExportDestinationRequest) (*dtos.ExportDestinationResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// Validate the config before storing it
+	if _, err := objectstorage.NewClient(objectstorage.Config{
+		Provider:        req.Provider,
+		Bucket:          req.Bucket,
+		Region:          req.Region,
+		Endpoint:        req.Endpoint,
+		AccessKeyID:     req.AccessKeyID,
+		SecretAccessKey: req.SecretAccessKey,
+	}); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid export destination: %v", err)
+	}
+
+	destination := &models.ExportDestination{
+		Provider:        req.Provider,
+		Bucket:          req.Bucket,
+		Region:          req.Region,
+		Endpoint:        req.Endpoint,
+		Prefix:          strings.Trim(req.Prefix, "/"),
+		AccessKeyID:     req.AccessKeyID,
+		SecretAccessKey: req.SecretAccessKey,
+	}
+	if err := utils.EncryptExportDestination(destination); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to secure export destination: %v", err)
+	}
+
+	chat.ExportDestination = destination
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+
+	log.Printf("ChatService -> UpdateExportDestination -> Export destination set to %s://%s for chatID: %s", req.Provider, req.Bucket, chatID)
+	return buildExportDestinationResponse(destination), http.StatusOK, nil
+}
+
+// DeleteExportDestination removes the cloud export destination of the chat
+func (s *chatService) DeleteExportDestination(userID, chatID string) (uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	chat.ExportDestination = nil
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+	return http.StatusOK, nil
+}
+
+// ExportQueryResultsToCloud re-executes a read query without the result cap (see writeExportFile), uploads the results to the chat's export destination & returns a signed URL
+func (s *chatService) ExportQueryResultsToCloud(ctx context.Context, userID, chatID string, req *dtos.CloudExportRequest) (*dtos.CloudExportResponse, uint32, error) {
+	log.Printf("ChatService -> ExportQueryResultsToCloud -> Starting for chatID: %s, queryID: %s", chatID, req.QueryID)
+
+	format := req.Format
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	// Checked before the query runs & the file is uploaded, the signing is the last step of the export
+	expiry := time.Duration(config.Env.ExportSignedURLExpiryMinutes) * time.Minute
+	if err := objectstorage.ValidatePresignExpiry(expiry); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("invalid export signed url expiry: %v", err)
+	}
+
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if chat.ExportDestination == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("no export destination configured for this chat")
+	}
+	if !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only read queries can be exported")
+	}
+
+	destination := *chat.ExportDestination
+	if err := utils.DecryptExportDestination(&destination); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	storageClient, err := objectstorage.NewClient(objectstorage.Config{
+		Provider:        destination.Provider,
+		Bucket:          destination.Bucket,
+		Region:          destination.Region,
+		Endpoint:        destination.Endpoint,
+		AccessKeyID:     destination.AccessKeyID,
+		SecretAccessKey: destination.SecretAccessKey,
+	})
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid export destination: %v", err)
+	}
+
+	// Write to a temp file first, so that the upload is streamed from disk with a known size
+	tempFile, err := os.CreateTemp("", "databot-export-*."+format)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create export file: %v", err)
+	}
+	defer func() {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+	}()
+
+	rowsExported, statusCode, err := s.writeExportFile(ctx, userID, chat, msg, query, req.StreamID, format, tempFile)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	fileInfo, err := tempFile.Stat()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read export file: %v", err)
+	}
+	if format == ExportFormatParquet {
+		if err := verifyParquetExport(tempFile, fileInfo.Size(), rowsExported); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("invalid parquet export file: %v", err)
+		}
+	}
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read export file: %v", err)
+	}
+
+	objectKey := path.Join(destination.Prefix, chatID, fmt.Sprintf("%s-%s.%s", query.ID.Hex(), time.Now().UTC().Format("20060102T150405Z"), format))
+	if err := storageClient.Upload(ctx, objectKey, exportContentType(format), tempFile, fileInfo.Size()); err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+
+	signedURL, err := storageClient.PresignGetURL(objectKey, expiry)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to sign export url: %v", err)
+	}
+
+	log.Printf("ChatService -> ExportQueryResultsToCloud -> Exported %d records to %s/%s", rowsExported, destination.Bucket, objectKey)
+	return &dtos.CloudExportResponse{
+		ChatID:       chatID,
+		MessageID:    msg.ID.Hex(),
+		QueryID:      query.ID.Hex(),
+		Format:       format,
+		Bucket:       destination.Bucket,
+		ObjectKey:    objectKey,
+		SignedURL:    signedURL,
+		ExpiresAt:    time.Now().Add(expiry).Format(time.RFC3339),
+		RowsExported: rowsExported,
+		SizeBytes:    fileInfo.Size(),
+	}, http.StatusOK, nil
+}
+
+// StreamQueryResults re-executes a read query without the result cap (see writeExportFile) & writes its rows to w as
+// newline delimited JSON while they are read from the database cursor, followed by a metadata line with the total
+// count. Returns the number of rows written, nothing is written to w when the query can't be started.
+func (s *chatService) StreamQueryResults(ctx context.Context, userID, chatID string, req *dtos.StreamExportRequest, w io.Writer) (int, uint32, error) {
+	log.Printf("ChatService -> StreamQueryResults -> Starting for chatID: %s, queryID: %s", chatID, req.QueryID)
+
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return 0, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if !isReadQuery(chat, query) {
+		return 0, http.StatusBadRequest, fmt.Errorf("only read queries can be exported")
+	}
+
+	rows, statusCode, err := s.writeExportFile(ctx, userID, chat, msg, query, req.StreamID, ExportFormatNDJSON, w)
+	if err != nil {
+		return rows, statusCode, err
+	}
+	log.Printf("ChatService -> StreamQueryResults -> Streamed %d records for queryID: %s", rows, query.ID.Hex())
+	return rows, http.StatusOK, nil
+}
+
+// TotalCountSetter is implemented by the writers of ExportQueryResults that announce the number of rows before the
+// first one is written, ex: as a response header
+type TotalCountSetter interface {
+	SetTotalCount(count int)
+}
+
+// ExportQueryResults re-executes a read query without the result cap (see writeExportFile) & streams its rows to w as
+// CSV, the columns are those of the result (the top level fields of MongoDB documents, nested values are JSON encoded).
+// The row count of the query pagination is announced to w if it implements TotalCountSetter.
+func (s *chatService) ExportQueryResults(ctx context.Context, userID, chatID, messageID, queryID, streamID string, w io.Writer) (int, uint32, error) {
+	log.Printf("ChatService -> ExportQueryResults -> Starting for chatID: %s, queryID: %s", chatID, queryID)
+
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return 0, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if !isReadQuery(chat, query) {
+		return 0, http.StatusBadRequest, fmt.Errorf("only read queries can be exported")
+	}
+
+	if setter, ok := w.(TotalCountSetter); ok && query.Pagination != nil && query.Pagination.TotalRecordsCount != nil {
+		setter.SetTotalCount(*query.Pagination.TotalRecordsCount)
+	}
+
+	rows, statusCode, err := s.writeExportFile(ctx, userID, chat, msg, query, streamID, ExportFormatCSV, w)
+	if err != nil {
+		return rows, statusCode, err
+	}
+	log.Printf("ChatService -> ExportQueryResults -> Exported %d rows as CSV for queryID: %s", rows, query.ID.Hex())
+	return rows, http.StatusOK, nil
+}
+
+// writeExportFile re-executes the original query (not the paginated one) & streams its rows to w, returns the number of rows.
+// SQL results are read from the driver cursor without the result cap, other results are decoded from the result JSON one
+// record at a time & fail the export when they pass the cap.
+func (s *chatService) writeExportFile(ctx context.Context, userID string, chat *models.Chat, msg *models.Message, query *models.Query, streamID, format string, w io.Writer) (int, uint32, error) {
+	chatID := chat.ID.Hex()
+	if !s.dbManager.IsConnected(chatID) {
+		if statusCode, err := s.ConnectDB(ctx, userID, chatID, streamID); err != nil {
+			return 0, statusCode, err
+		}
+	}
+	ctx, err := s.withNamedConnection(ctx, userID, chat, query.ConnectionName, streamID)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+
+	var writer exportWriter
+	var writeErr error
+	rows := 0
+	writeRow := func(values []interface{}) error {
+		if writeErr = writer.WriteRow(values); writeErr == nil {
+			rows++
+		}
+		return writeErr
+	}
+
+	if dbmanager.StreamSupported(chat.Connection.Type) {
+		err := s.dbManager.StreamQueryRows(ctx, chatID, query.Query, func(columns []dbmanager.ExportColumn) error {
+			exportColumns := make([]exportColumn, len(columns))
+			for i, column := range columns {
+				exportColumns[i] = exportColumn{name: column.Name, parquetType: parquetTypeForDatabaseType(column.DatabaseType)}
+			}
+			writer, writeErr = newExportWriter(w, format, exportColumns)
+			return writeErr
+		}, writeRow)
+		if writeErr != nil {
+			return 0, http.StatusInternalServerError, fmt.Errorf("failed to write export file: %v", writeErr)
+		}
+		if err != nil {
+			return 0, http.StatusBadRequest, err
+		}
+	} else {
+		queryType := ""
+		if query.QueryType != nil {
+			queryType = *query.QueryType
+		}
+		// Results of databases that can't be streamed are bounded by MAX_QUERY_RESULT_ROWS & MAX_QUERY_RESULT_BYTES, an
+		// export never leaves rows out: a result over a limit fails the export
+		result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, msg.ID.Hex(), query.ID.Hex(), streamID, query.Query, queryType, false, false)
+		if queryErr != nil {
+			return 0, http.StatusBadRequest, fmt.Errorf("failed to execute query: %s", queryErr.Message)
+		}
+		if dbmanager.IsResultTruncated(result) {
+			return 0, http.StatusBadRequest, fmt.Errorf("the result has more than %d rows, which is the most that can be exported from a %s database: add a filter or aggregate the data", config.Env.MaxQueryResultRows, chat.Connection.Type)
+		}
+		for _, warning := range result.Warnings {
+			log.Printf("ChatService -> writeExportFile -> Warning for queryID %s: %s", query.ID.Hex(), warning)
+		}
+
+		// The first pass collects the columns & their types, the second one writes the records, result JSON has no
+		// column metadata. Only one record is decoded at a time.
+		inferences := make(map[string]*parquetTypeInference)
+		columnNames := make([]string, 0)
+		if err := scanResultRecords(result.ResultJSON, func(record map[string]interface{}) error {
+			for column, value := range record {
+				inference, exists := inferences[column]
+				if !exists {
+					inference = &parquetTypeInference{}
+					inferences[column] = inference
+					columnNames = append(columnNames, column)
+				}
+				inference.add(value)
+			}
+			return nil
+		}); err != nil {
+			return 0, http.StatusInternalServerError, err
+		}
+		sort.Strings(columnNames)
+
+		exportColumns := make([]exportColumn, len(columnNames))
+		for i, name := range columnNames {
+			exportColumns[i] = exportColumn{name: name, parquetType: inferences[name].columnType()}
+		}
+		if writer, writeErr = newExportWriter(w, format, exportColumns); writeErr != nil {
+			return 0, http.StatusInternalServerError, fmt.Errorf("failed to write export file: %v", writeErr)
+		}
+
+		row := make([]interface{}, len(exportColumns))
+		if err := scanResultRecords(result.ResultJSON, func(record map[string]interface{}) error {
+			for i, column := range exportColumns {
+				row[i] = record[column.name]
+			}
+			return writeRow(row)
+		}); err != nil {
+			return 0, http.StatusInternalServerError, fmt.Errorf("failed to write export file: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, http.StatusInternalServerError, fmt.Errorf("failed to write export file: %v", err)
+	}
+	return rows, http.StatusOK, nil
+}
+
+// getOwnedChat fetches a chat & verifies that it belongs to the user
+func (s *chatService) getOwnedChat(userID, chatID string) (*models.Chat, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid chat ID format")
+	}
+
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch chat: %v", err)
+	}
+	if chat == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("chat not found")
+	}
+	if chat.UserID != userObjID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	return chat, http.StatusOK, nil
+}
+
+// isReadQuery checks that a query only reads data, so that it is safe to re-execute for exports
+func isReadQuery(chat *models.Chat, query *models.Query) bool {
+	if query.IsCritical || query.QueryType == nil {
+		return false
+	}
+	switch strings.ToUpper(*query.QueryType) {
+	case "SELECT", "FIND", "AGGREGATE", "COUNT", "COUNTDOCUMENTS", "DISTINCT", "SHOW", "DESCRIBE":
+		// The type is given by the LLM, the text must not change data either (ex: a DELETE in a CTE typed SELECT)
+		return dbmanager.IsReadOnlyQuery(queryConnectionType(chat, query), query.Query)
+	}
+	return false
+}
+
+// queryConnectionType returns the database type of the connection a query runs on, the main connection of the chat
+// or one of its named connections
+func queryConnectionType(chat *models.Chat, query *models.Query) string {
+	if query.ConnectionName != "" {
+		if named := chat.NamedConnection(query.ConnectionName); named != nil {
+			return named.Connection.Type
+		}
+	}
+	return chat.Connection.Type
+}
+
+// extractResultRecords converts a query result JSON (a list of records or an object with a "results" list) to records
+func extractResultRecords(resultJSON string) ([]map[string]interface{}, error) {
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &records); err == nil {
+		return records, nil
+	}
+
+	var resultMap map[string]interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &resultMap); err != nil {
+		return nil, fmt.Errorf("failed to parse query result: %v", err)
+	}
+
+	results, ok := resultMap["results"].([]interface{})
+	if !ok {
+		// Single object results such as a count
+		return []map[string]interface{}{resultMap}, nil
+	}
+
+	records = make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		if record, ok := result.(map[string]interface{}); ok {
+			records = append(records, record)
+		} else {
+			records = append(records, map[string]interface{}{"value": result})
+		}
+	}
+	return records, nil
+}
+
+// scanResultRecords decodes a query result JSON (a list of records or an object with a "results" list) one record at a time,
+// values that aren't objects are passed as a "value" record & other objects, such as a count, as a single record.
+// Numbers are decoded as json.Number so that integers keep their precision.
+func scanResultRecords(resultJSON string, onRecord func(record map[string]interface{}) error) error {
+	decoder := json.NewDecoder(strings.NewReader(resultJSON))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse query result: %v", err)
+	}
+
+	switch token {
+	case json.Delim('['):
+		return scanResultArray(decoder, onRecord)
+	case json.Delim('{'):
+		record := make(map[string]interface{})
+		streamed := false
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("failed to parse query result: %v", err)
+			}
+			key, _ := keyToken.(string)
+			if key != "results" {
+				var value interface{}
+				if err := decoder.Decode(&value); err != nil {
+					return fmt.Errorf("failed to parse query result: %v", err)
+				}
+				record[key] = value
+				continue
+			}
+
+			valueToken, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("failed to parse query result: %v", err)
+			}
+			switch valueToken {
+			case json.Delim('['):
+				streamed = true
+				if err := scanResultArray(decoder, onRecord); err != nil {
+					return err
+				}
+			case json.Delim('{'):
+				value := make(map[string]interface{})
+				for decoder.More() {
+					nestedKey, err := decoder.Token()
+					if err != nil {
+						return fmt.Errorf("failed to parse query result: %v", err)
+					}
+					var nestedValue interface{}
+					if err := decoder.Decode(&nestedValue); err != nil {
+						return fmt.Errorf("failed to parse query result: %v", err)
+					}
+					value[fmt.Sprintf("%v", nestedKey)] = nestedValue
+				}
+				if _, err := decoder.Token(); err != nil {
+					return fmt.Errorf("failed to parse query result: %v", err)
+				}
+				record[key] = value
+			default:
+				record[key] = valueToken
+			}
+		}
+		if streamed {
+			return nil
+		}
+		return onRecord(record)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("failed to parse query result: unexpected %v", token)
+	}
+}
+
+// scanResultArray decodes the elements of a JSON array whose opening bracket was already read, including the closing one
+func scanResultArray(decoder *json.Decoder, onRecord func(record map[string]interface{}) error) error {
+	for decoder.More() {
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("failed to parse query result: %v", err)
+		}
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			record = map[string]interface{}{"value": value}
+		}
+		if err := onRecord(record); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to parse query result: %v", err)
+	}
+	return nil
+}
+
+// exportColumn is a column of an export file, parquetType comes from the driver column metadata for SQL results &
+// is inferred from the values for JSON results
+type exportColumn struct {
+	name        string
+	parquetType parquet.ColumnType
+}
+
+// exportWriter writes the rows of an export file one at a time, values are in the column order
+type exportWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// newExportWriter returns a writer of the given format, the header is written right away
+func newExportWriter(w io.Writer, format string, columns []exportColumn) (exportWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return newCSVExportWriter(w, columns)
+	case ExportFormatParquet:
+		return newParquetExportWriter(w, columns)
+	case ExportFormatNDJSON:
+		return newNDJSONExportWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	case ExportFormatNDJSON:
+		return ContentTypeNDJSON
+	}
+	return "text/csv"
+}
+
+// ndjsonExportWriter writes every row as a JSON object on its own line, keys are in the column order. Close writes
+// a last line with the metadata of the export: {"_metadata":{"total_count":N}}
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []string // JSON encoded column names
+	line    []byte
+	count   int
+}
+
+func newNDJSONExportWriter(w io.Writer, columns []exportColumn) *ndjsonExportWriter {
+	encoded := make([]string, len(columns))
+	seen := make(map[string]int, len(columns))
+	for i, column := range columns {
+		// SQL results can repeat a column name (ex: a.id, b.id), JSON keys must be unique
+		name := column.name
+		if count := seen[name]; count > 0 {
+			name = fmt.Sprintf("%s_%d", name, count+1)
+		}
+		seen[column.name]++
+		key, _ := json.Marshal(name)
+		encoded[i] = string(key)
+	}
+	return &ndjsonExportWriter{w: w, columns: encoded}
+}
+
+func (n *ndjsonExportWriter) WriteRow(values []interface{}) error {
+	n.line = append(n.line[:0], '{')
+	for i, column := range n.columns {
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			// Driver types without a JSON encoding are written as text
+			encoded, _ = json.Marshal(formatExportCell(value))
+		}
+		if i > 0 {
+			n.line = append(n.line, ',')
+		}
+		n.line = append(n.line, column...)
+		n.line = append(n.line, ':')
+		n.line = append(n.line, encoded...)
+	}
+	n.line = append(n.line, '}', '\n')
+	if _, err := n.w.Write(n.line); err != nil {
+		return err
+	}
+	n.count++
+	return nil
+}
+
+func (n *ndjsonExportWriter) Close() error {
+	metadata, err := json.Marshal(map[string]interface{}{"_metadata": map[string]interface{}{"total_count": n.count}})
+	if err != nil {
+		return err
+	}
+	_, err = n.w.Write(append(metadata, '\n'))
+	return err
+}
+
+// csvExportWriter writes rows as CSV, nested values are JSON encoded
+type csvExportWriter struct {
+	writer *csv.Writer
+	row    []string
+}
+
+func newCSVExportWriter(w io.Writer, columns []exportColumn) (*csvExportWriter, error) {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{writer: writer, row: make([]string, len(columns))}, nil
+}
+
+func (c *csvExportWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		c.row[i] = formatExportCell(value)
+	}
+	return c.writer.Write(c.row)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// parquetExportWriter writes rows as parquet, values are converted to the column types & row groups are flushed as
+// they fill up, so only one row group is held in memory
+type parquetExportWriter struct {
+	writer  *parquet.Writer
+	columns []parquet.Column
+	row     []interface{}
+}
+
+func newParquetExportWriter(w io.Writer, columns []exportColumn) (*parquetExportWriter, error) {
+	parquetColumns := make([]parquet.Column, 0, len(columns))
+	seen := make(map[string]int, len(columns))
+	for _, column := range columns {
+		// SQL results can repeat a column name (ex: a.id, b.id), parquet column names must be unique
+		name := column.name
+		if name == "" {
+			name = "column"
+		}
+		if count := seen[name]; count > 0 {
+			seen[name]++
+			name = fmt.Sprintf("%s_%d", name, count+1)
+		}
+		seen[name]++
+		parquetColumns = append(parquetColumns, parquet.Column{Name: name, Type: column.parquetType})
+	}
+	if len(parquetColumns) == 0 {
+		// Parquet requires at least one column, keep empty results readable
+		parquetColumns = append(parquetColumns, parquet.Column{Name: "value", Type: parquet.ColumnTypeString})
+	}
+
+	writer, err := parquet.NewWriter(w, parquetColumns, parquet.DefaultRowGroupSize)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetExportWriter{writer: writer, columns: parquetColumns, row: make([]interface{}, len(parquetColumns))}, nil
+}
+
+func (p *parquetExportWriter) WriteRow(values []interface{}) error {
+	for i, column := range p.columns {
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		converted, err := convertParquetValue(value, column.Type)
+		if err != nil {
+			return fmt.Errorf("column %s: %v", column.Name, err)
+		}
+		p.row[i] = converted
+	}
+	return p.writer.WriteRow(p.row)
+}
+
+func (p *parquetExportWriter) Close() error {
+	return p.writer.Close()
+}
+
+// verifyParquetExport reads the footer of a written parquet export back, so that a corrupt file is never handed out
+func verifyParquetExport(file io.ReaderAt, size int64, rows int) error {
+	reader, err := parquet.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+	if reader.NumRows() != int64(rows) {
+		return fmt.Errorf("parquet file holds %d rows, %d were written", reader.NumRows(), rows)
+	}
+	return nil
+}
+
+// parquetTypeForDatabaseType maps the column type reported by the driver to a parquet column type. Decimals & unsigned
+// 64 bit integers are kept as strings so that no precision is lost, unknown types are written as strings too.
+func parquetTypeForDatabaseType(databaseType string) parquet.ColumnType {
+	switch dbmanager.NormalizeDatabaseType(databaseType) {
+	case "BOOL", "BOOLEAN":
+		return parquet.ColumnTypeBoolean
+	case "INT2", "INT4", "INT8", "INT16", "INT32", "INT64", "SMALLINT", "INT", "INTEGER", "BIGINT", "TINYINT", "MEDIUMINT",
+		"YEAR", "UINT8", "UINT16", "UINT32", "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT":
+		return parquet.ColumnTypeInt64
+	case "FLOAT4", "FLOAT8", "FLOAT32", "FLOAT64", "FLOAT", "DOUBLE", "REAL":
+		return parquet.ColumnTypeDouble
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATETIME64", "DATE", "DATE32":
+		return parquet.ColumnTypeTimestamp
+	case "JSON", "JSONB":
+		return parquet.ColumnTypeJSON
+	default:
+		return parquet.ColumnTypeString
+	}
+}
+
+// parquetTypeInference derives the type of a column from its non null values one at a time: integral numbers become
+// INT64, other numbers DOUBLE, RFC3339 strings TIMESTAMP & nested documents JSON. Columns with mixed types fall back to strings.
+type parquetTypeInference struct {
+	seen     bool
+	inferred parquet.ColumnType
+}
+
+func (i *parquetTypeInference) add(value interface{}) {
+	if value == nil {
+		return
+	}
+
+	var valueType parquet.ColumnType
+	switch v := value.(type) {
+	case bool:
+		valueType = parquet.ColumnTypeBoolean
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			valueType = parquet.ColumnTypeDouble
+		} else if _, err := v.Int64(); err == nil {
+			valueType = parquet.ColumnTypeInt64
+		} else {
+			// Integers out of the INT64 range are kept as text
+			valueType = parquet.ColumnTypeString
+		}
+	case string:
+		valueType = parquet.ColumnTypeString
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			valueType = parquet.ColumnTypeTimestamp
+		}
+	case map[string]interface{}, []interface{}:
+		valueType = parquet.ColumnTypeJSON
+	default:
+		valueType = parquet.ColumnTypeString
+	}
+
+	switch {
+	case !i.seen:
+		i.seen = true
+		i.inferred = valueType
+	case i.inferred == valueType:
+	case isNumericColumnType(i.inferred) && isNumericColumnType(valueType):
+		// Integers mixed with decimals are widened to DOUBLE
+		i.inferred = parquet.ColumnTypeDouble
+	default:
+		i.inferred = parquet.ColumnTypeString
+	}
+}
+
+func (i *parquetTypeInference) columnType() parquet.ColumnType {
+	if !i.seen {
+		return parquet.ColumnTypeString
+	}
+	return i.inferred
+}
+
+func isNumericColumnType(columnType parquet.ColumnType) bool {
+	return columnType == parquet.ColumnTypeInt64 || columnType == parquet.ColumnTypeDouble
+}
+
+// exportTimeLayouts are the text formats of dates & timestamps returned by the drivers without time parsing
+var exportTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// convertParquetValue converts a driver or JSON value to the Go type the parquet writer expects for the column type,
+// an error is returned rather than writing a wrong value
+func convertParquetValue(value interface{}, columnType parquet.ColumnType) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch columnType {
+	case parquet.ColumnTypeString:
+		return formatExportCell(value), nil
+	case parquet.ColumnTypeJSON:
+		// JSON columns of SQL databases are already encoded
+		return value, nil
+	case parquet.ColumnTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			if parsed, err := strconv.ParseBool(v); err == nil {
+				return parsed, nil
+			}
+		}
+	case parquet.ColumnTypeInt64:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case int32:
+			return int64(v), nil
+		case int16:
+			return int64(v), nil
+		case int8:
+			return int64(v), nil
+		case int:
+			return int64(v), nil
+		case uint32:
+			return int64(v), nil
+		case uint16:
+			return int64(v), nil
+		case uint8:
+			return int64(v), nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+				return int64(v), nil
+			}
+		case json.Number:
+			if parsed, err := v.Int64(); err == nil {
+				return parsed, nil
+			}
+		case string:
+			if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return parsed, nil
+			}
+		}
+	case parquet.ColumnTypeDouble:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case json.Number:
+			if parsed, err := v.Float64(); err == nil {
+				return parsed, nil
+			}
+		case string:
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				return parsed, nil
+			}
+		}
+	case parquet.ColumnTypeTimestamp:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			for _, layout := range exportTimeLayouts {
+				if parsed, err := time.Parse(layout, v); err == nil {
+					return parsed, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't convert %T value %v to %s", value, value, columnType)
+}
+
+func formatExportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		// Avoid exponent notation for large numbers
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func buildExportDestinationResponse(destination *models.ExportDestination) *dtos.ExportDestinationResponse {
+	if destination == nil {
+		return nil
+	}
+	return &dtos.ExportDestinationResponse{
+		Provider: destination.Provider,
+		Bucket:   destination.Bucket,
+		Region:   destination.Region,
+		Endpoint: destination.Endpoint,
+		Prefix:   destination.Prefix,
+	}
+}
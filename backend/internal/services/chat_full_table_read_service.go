@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// limitFullTableRead adds a LIMIT to a query reading every row of a table above FULL_TABLE_READ_ROW_THRESHOLD (see
+// dbmanager.FindFullTableReads), only the rows kept from a result are then read. The warning explaining it is
+// returned, the query is returned as is with no warning when it doesn't read a whole large table.
+func (s *chatService) limitFullTableRead(ctx context.Context, chat *models.Chat, chatID, query string) (string, string) {
+	schema := s.dbManager.GetKnownSchema(ctx, chatID)
+	reads := dbmanager.FindFullTableReads(chat.Connection.Type, query, schema, int64(config.Env.FullTableReadRowThreshold))
+	if len(reads) == 0 {
+		return query, ""
+	}
+	log.Printf("ChatService -> limitFullTableRead -> Query reads whole tables %v, limiting it to %d rows", reads, config.Env.MaxQueryResultRows)
+	return dbmanager.LimitFullTableRead(chat.Connection.Type, query, config.Env.MaxQueryResultRows), dbmanager.FormatFullTableReads(reads, config.Env.MaxQueryResultRows)
+}
+
+// setAddFilterButton adds an "Add a Filter" button for a query whose full table read was limited, or removes the
+// button of the query once it runs without being limited
+func (s *chatService) setAddFilterButton(msg *models.Message, queryID string, limited bool) {
+	var buttons []models.ActionButton
+	if msg.ActionButtons != nil {
+		for _, button := range *msg.ActionButtons {
+			if button.Action == "add_filter" && button.Payload[constants.ActionPayloadQueryID] == queryID {
+				if limited {
+					return
+				}
+				continue
+			}
+			buttons = append(buttons, button)
+		}
+	}
+
+	if limited {
+		log.Printf("ChatService -> setAddFilterButton -> Adding add_filter button for queryID: %s", queryID)
+		buttons = append(buttons, models.ActionButton{
+			ID:      primitive.NewObjectID(),
+			Label:   "Add a Filter",
+			Action:  "add_filter",
+			Payload: map[string]interface{}{constants.ActionPayloadQueryID: queryID},
+		})
+	} else if msg.ActionButtons == nil || len(buttons) == len(*msg.ActionButtons) {
+		return
+	}
+	msg.ActionButtons = &buttons
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// missingJoinsPrompt checks the queries of an LLM response against the schema, the tables a query needs without
+// joining them (see dbmanager.FindMissingJoins) are returned in a prompt asking for a fixed response, empty if
+// every query joins the tables it needs
+func (s *chatService) missingJoinsPrompt(ctx context.Context, chatID string, jsonResponse map[string]interface{}) string {
+	schema := s.dbManager.GetKnownSchema(ctx, chatID)
+	if schema == nil {
+		return ""
+	}
+
+	queries, _ := jsonResponse["queries"].([]interface{})
+	var problems []string
+	for i, query := range queries {
+		queryMap, ok := query.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		queryText, _ := queryMap["query"].(string)
+		var tables []string
+		if tablesText, _ := queryMap["tables"].(string); tablesText != "" {
+			tables = strings.Split(tablesText, ",")
+		}
+
+		if missing := dbmanager.FindMissingJoins(queryText, tables, schema); len(missing) > 0 {
+			log.Printf("ChatService -> missingJoinsPrompt -> Missing joins in query %d: %+v", i+1, missing)
+			problems = append(problems, fmt.Sprintf("- Query %d: %s", i+1, dbmanager.FormatMissingJoins(missing)))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(constants.MissingJoinsPrompt, strings.Join(problems, "\n"))
+}
+
+// typeCoercionsPrompt checks the comparisons of the queries of an LLM response against the column types of the
+// schema, the comparisons with a literal of another type (see dbmanager.FindTypeCoercions) are returned in a prompt
+// asking for a fixed response, empty if every comparison matches the column types
+func (s *chatService) typeCoercionsPrompt(ctx context.Context, chatID string, jsonResponse map[string]interface{}) string {
+	schema := s.dbManager.GetKnownSchema(ctx, chatID)
+	if schema == nil {
+		return ""
+	}
+
+	queries, _ := jsonResponse["queries"].([]interface{})
+	var problems []string
+	for i, query := range queries {
+		queryMap, ok := query.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		queryText, _ := queryMap["query"].(string)
+		if coercions := dbmanager.FindTypeCoercions(queryText, schema); len(coercions) > 0 {
+			log.Printf("ChatService -> typeCoercionsPrompt -> Implicit type coercions in query %d: %+v", i+1, coercions)
+			problems = append(problems, fmt.Sprintf("- Query %d: %s", i+1, dbmanager.FormatTypeCoercions(coercions)))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(constants.TypeCoercionsPrompt, strings.Join(problems, "\n"))
+}
+
+// regenerateLLMResponse asks the LLM to respond again to the conversation, after its previous response & a user
+// message with the prompt. The schema names of the response & of the prompt go through the anonymizer of the chat
+// if there is one.
+func (s *chatService) regenerateLLMResponse(ctx context.Context, chatObjID, userObjID primitive.ObjectID, messages []*models.LLMMessage, previousResponse map[string]interface{}, prompt string, anonymizer *dbmanager.SchemaAnonymizer, dbType string) (map[string]interface{}, error) {
+	if anonymizer != nil {
+		previousResponse = anonymizer.AnonymizeValue(previousResponse).(map[string]interface{})
+		prompt = anonymizer.AnonymizeText(prompt)
+	}
+	regenerateMessages := append(messages[:len(messages):len(messages)],
+		&models.LLMMessage{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeAssistant),
+			Content: map[string]interface{}{"assistant_response": previousResponse},
+		},
+		&models.LLMMessage{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeUser),
+			Content: map[string]interface{}{"user_message": prompt},
+		},
+	)
+
+	response, err := s.llmClient.GenerateResponse(ctx, regenerateMessages, dbType)
+	if err != nil {
+		return nil, err
+	}
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse the regenerated response: %v", err)
+	}
+	log.Printf("ChatService -> regenerateLLMResponse -> response: %s", response)
+	if anonymizer != nil {
+		jsonResponse = anonymizer.DeanonymizeValue(jsonResponse).(map[string]interface{})
+	}
+	return jsonResponse, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"encoding/json"
+	"log"
+	"regexp"
+	"strconv"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// serverTimeOfDayRegex matches the date & time of the server_info message, only its date is part of a cache key
+var serverTimeOfDayRegex = regexp.MustCompile(`(\d{4}-\d{2}-\d{2}) \d{2}:\d{2}:\d{2}`)
+
+// llmResponseCacheEntry is what a cache key hashes, see llmResponseCacheKey
+type llmResponseCacheEntry struct {
+	Role    string                 `json:"role"`
+	Content map[string]interface{} `json:"content"`
+}
+
+// llmResponseCacheKey hashes what decides an LLM response: the database type, the page size of the paginated queries,
+// the checksum of the schema & the role & content of the messages sent. IDs & timestamps are left out, an edited message changes the key while a repeated
+// history gets the same one. The time of the server_info message changes on every request, only its date is kept.
+// Empty if the messages can't be hashed.
+func llmResponseCacheKey(dbType string, pageSize int, schemaChecksum string, messages []*models.LLMMessage) string {
+	entries := make([]llmResponseCacheEntry, 0, len(messages))
+	for _, msg := range messages {
+		content := msg.Content
+		if serverInfo, ok := content["server_info"].(string); ok {
+			content = make(map[string]interface{}, len(msg.Content))
+			for key, value := range msg.Content {
+				content[key] = value
+			}
+			content["server_info"] = serverTimeOfDayRegex.ReplaceAllString(serverInfo, "$1")
+		}
+		entries = append(entries, llmResponseCacheEntry{Role: msg.Role, Content: content})
+	}
+	// Map keys are marshalled in order, the same history always gives the same JSON
+	data, err := json.Marshal(entries)
+	if err != nil {
+		log.Printf("ChatService -> llmResponseCacheKey -> Failed to marshal the messages: %v", err)
+		return ""
+	}
+	return utils.SHA256Hash(dbType + ":" + strconv.Itoa(pageSize) + ":" + schemaChecksum + ":" + string(data))
+}
+
+// generateLLMResponse generates the response of the messages, an identical request reuses the response cached in
+// Redis unless the chat disables the cache. Only valid JSON responses are cached, a broken one is generated again.
+// With onMessage the response is streamed, its assistantMessage is passed to onMessage as it is generated.
+func (s *chatService) generateLLMResponse(ctx context.Context, chat *models.Chat, chatID string, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	generate := func() (string, error) {
+		if onMessage != nil {
+			return s.llmClient.GenerateResponseStream(ctx, messages, dbType, onMessage)
+		}
+		return s.llmClient.GenerateResponse(ctx, messages, dbType)
+	}
+	if chat == nil || chat.Settings.DisableResponseCache || !s.dbManager.LLMResponseCacheEnabled() {
+		return generate()
+	}
+
+	schemaChecksum := ""
+	if schema := s.dbManager.GetKnownSchema(ctx, chatID); schema != nil {
+		schemaChecksum = schema.Checksum
+	}
+	key := llmResponseCacheKey(dbType, pageSizeOf(chat), schemaChecksum, messages)
+	if key == "" {
+		return generate()
+	}
+	if response, hit := s.dbManager.GetCachedLLMResponse(ctx, key); hit {
+		log.Printf("ChatService -> generateLLMResponse -> Reusing the cached LLM response for chatID: %s", chatID)
+		return response, nil
+	}
+
+	response, err := generate()
+	if err != nil {
+		return "", err
+	}
+	if json.Valid([]byte(response)) {
+		s.dbManager.CacheLLMResponse(ctx, key, response)
+	}
+	return response, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// migrationUnknownObjectsCode is the error of the migration queries referencing schema objects that don't exist
+const migrationUnknownObjectsCode = "MIGRATION_UNKNOWN_OBJECTS"
+
+// migrationLLMContent is the LLM message of a user message, a migration request wraps the described state with the
+// instructions generating the migration
+func migrationLLMContent(content string, migration bool) map[string]interface{} {
+	if !migration {
+		return map[string]interface{}{"user_message": content}
+	}
+	return map[string]interface{}{
+		"user_message": fmt.Sprintf(constants.MigrationRequestPrompt, content),
+		"migration":    true,
+	}
+}
+
+// isMigrationRequest checks if the latest user message asks for a schema migration
+func isMigrationRequest(messages []*models.LLMMessage) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != string(constants.MessageTypeUser) {
+			continue
+		}
+		migration, _ := messages[i].Content["migration"].(bool)
+		return migration
+	}
+	return false
+}
+
+// migrationObjectsPrompt checks the queries of a migration against the schema they start from (see
+// dbmanager.MigrationValidator), the problems are returned in a prompt asking for a fixed response, empty if every
+// query matches the schema
+func (s *chatService) migrationObjectsPrompt(ctx context.Context, chatID string, jsonResponse map[string]interface{}) string {
+	schema := s.dbManager.GetKnownSchema(ctx, chatID)
+	if schema == nil {
+		return ""
+	}
+
+	validator := dbmanager.NewMigrationValidator(schema)
+	queries, _ := jsonResponse["queries"].([]interface{})
+	var problems []string
+	for i, query := range queries {
+		queryMap, ok := query.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		queryText, _ := queryMap["query"].(string)
+		if queryProblems := validator.Check(queryText); len(queryProblems) > 0 {
+			log.Printf("ChatService -> migrationObjectsPrompt -> Unknown schema objects in query %d: %v", i+1, queryProblems)
+			problems = append(problems, fmt.Sprintf("- Query %d: %s", i+1, strings.Join(queryProblems, ", ")))
+		}
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(constants.MigrationObjectsPrompt, strings.Join(problems, "\n"))
+}
+
+// markMigrationQueries makes the queries of a migration critical whatever the LLM says, a query without a rollback
+// query can't be rolled back, & the queries still referencing schema objects that don't exist get an error
+func (s *chatService) markMigrationQueries(ctx context.Context, chatID string, queries []models.Query) {
+	// Without a known schema (ex: a generate only chat) there is nothing to check the statements against
+	schema := s.dbManager.GetKnownSchema(ctx, chatID)
+	validator := dbmanager.NewMigrationValidator(schema)
+	for i := range queries {
+		query := &queries[i]
+		query.IsCritical = true
+		if query.RollbackQuery == nil || strings.TrimSpace(*query.RollbackQuery) == "" {
+			query.CanRollback = false
+		}
+		if schema == nil {
+			continue
+		}
+		if problems := validator.Check(query.Query); len(problems) > 0 && query.Error == nil {
+			query.Error = &models.QueryError{
+				Code:    migrationUnknownObjectsCode,
+				Message: "This migration doesn't match the current schema",
+				Details: strings.Join(problems, "\n"),
+			}
+		}
+	}
+}
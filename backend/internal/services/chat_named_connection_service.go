@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// namedConnectionNameRegex matches the names of the named connections, they qualify the tables shared with the LLM
+var namedConnectionNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,31}$`)
+
+// unknownConnectionErrorCode is the error of the queries generated for a connection the chat doesn't have
+const unknownConnectionErrorCode = "UNKNOWN_CONNECTION"
+
+// UpdateNamedConnection adds a named connection to the chat or replaces the one with the same name. The connection is
+// tested first & must be a database of the type of the main connection, it is connected right away when the main
+// connection is.
+func (s *chatService) UpdateNamedConnection(userID, chatID, name string, req *dtos.CreateConnectionRequest) (*dtos.NamedConnectionResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	if chat.Settings.AnonymizeSchema {
+		return nil, http.StatusBadRequest, fmt.Errorf("the schemas of named connections can't be anonymized, disable schema anonymization first")
+	}
+	if !namedConnectionNameRegex.MatchString(name) {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid connection name %q, use up to 32 letters, digits & underscores starting with a letter", name)
+	}
+	if req.Type != chat.Connection.Type {
+		return nil, http.StatusBadRequest, fmt.Errorf("a named connection must be a %s database like the main connection of the chat", chat.Connection.Type)
+	}
+
+	existing := chat.NamedConnection(name)
+	if existing != nil {
+		existingConn := existing.Connection
+		utils.DecryptConnection(&existingConn)
+
+		// The secrets are never sent back, a replacement without them keeps them
+		if req.CredentialsJSON == "" {
+			req.CredentialsJSON = existingConn.CredentialsJSON
+		}
+		if req.SSHHost != "" && req.SSHPrivateKey == "" && existingConn.SSHHost == req.SSHHost {
+			req.SSHPrivateKey = existingConn.SSHPrivateKey
+		}
+	}
+	if err := validateConnectionDetails(req, false); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	connection := models.Connection{
+		Type:            req.Type,
+		Host:            req.Host,
+		Hosts:           req.Hosts,
+		Shards:          req.Shards,
+		Port:            req.Port,
+		Username:        &req.Username,
+		Password:        req.Password,
+		Database:        req.Database,
+		UseSSL:          req.UseSSL,
+		SSLMode:         req.SSLMode,
+		SSLCertURL:      req.SSLCertURL,
+		SSLKeyURL:       req.SSLKeyURL,
+		SSLRootCertURL:  req.SSLRootCertURL,
+		MaxResultRows:   req.MaxResultRows,
+		Role:            req.Role,
+		FilePath:        req.FilePath,
+		ProjectID:       req.ProjectID,
+		CredentialsJSON: req.CredentialsJSON,
+		SSHHost:         req.SSHHost,
+		SSHPort:         req.SSHPort,
+		SSHUser:         req.SSHUser,
+		SSHPrivateKey:   req.SSHPrivateKey,
+		SSHHostKey:      req.SSHHostKey,
+
+		MaxOpenConns:    req.MaxOpenConns,
+		MaxIdleConns:    req.MaxIdleConns,
+		ConnMaxLifetime: req.ConnMaxLifetime,
+		Base:            models.NewBase(),
+	}
+
+	testConfig := dbConnectionConfig(&connection, "")
+	tlsInfo, err := s.dbManager.TestConnection(&testConfig)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("%v", err)
+	}
+
+	if err := utils.EncryptConnection(&connection); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to secure connection details: %v", err)
+	}
+
+	key := dbmanager.NamedConnectionKey(chatID, name)
+	if existing != nil {
+		// The replaced connection may be another database, its schema & cached results are dropped with it
+		if s.dbManager.IsConnected(key) {
+			if err := s.dbManager.Disconnect(key, userID, true); err != nil {
+				log.Printf("ChatService -> UpdateNamedConnection -> Warning: Failed to disconnect connection %s: %v", name, err)
+			}
+		}
+		s.dbManager.InvalidateQueryResultCache(context.Background(), key)
+		existing.Connection = connection
+	} else {
+		chat.Connections = append(chat.Connections, models.NamedConnection{Name: name, Connection: connection})
+	}
+
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+	log.Printf("ChatService -> UpdateNamedConnection -> Connection %s saved for chatID: %s", name, chatID)
+
+	// The LLM gets the schema of the connection with the next message
+	if s.dbManager.IsConnected(chatID) {
+		if err := s.connectNamedDB(userID, chat, name, ""); err != nil {
+			log.Printf("ChatService -> UpdateNamedConnection -> Failed to connect connection %s: %v", name, err)
+		}
+	}
+
+	response := s.buildNamedConnectionResponse(chat, chat.NamedConnection(name))
+	response.Connection.TLS = buildTLSInfoResponse(tlsInfo)
+	return &response, http.StatusOK, nil
+}
+
+// DeleteNamedConnection disconnects & removes a named connection of the chat, the queries generated for it can't be
+// executed anymore
+func (s *chatService) DeleteNamedConnection(userID, chatID, name string) (uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return statusCode, err
+	}
+	if chat.NamedConnection(name) == nil {
+		return http.StatusNotFound, fmt.Errorf("the chat has no connection named %s", name)
+	}
+
+	key := dbmanager.NamedConnectionKey(chatID, name)
+	if s.dbManager.IsConnected(key) {
+		if err := s.dbManager.Disconnect(key, userID, true); err != nil {
+			log.Printf("ChatService -> DeleteNamedConnection -> Warning: Failed to disconnect connection %s: %v", name, err)
+		}
+	}
+
+	connections := make([]models.NamedConnection, 0, len(chat.Connections)-1)
+	for _, named := range chat.Connections {
+		if named.Name != name {
+			connections = append(connections, named)
+		}
+	}
+	chat.Connections = connections
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+	log.Printf("ChatService -> DeleteNamedConnection -> Connection %s removed from chatID: %s", name, chatID)
+	return http.StatusOK, nil
+}
+
+// connectNamedDB connects a named connection of the chat under its key (see dbmanager.NamedConnectionKey), the
+// example records of its schema are masked like the ones of the main connection
+func (s *chatService) connectNamedDB(userID string, chat *models.Chat, name, streamID string) error {
+	named := chat.NamedConnection(name)
+	if named == nil {
+		return fmt.Errorf("the chat has no connection named %s", name)
+	}
+	key := dbmanager.NamedConnectionKey(chat.ID.Hex(), name)
+
+	s.dbManager.GetSchemaManager().SetColumnMasks(key, chat.Settings.ColumnMasks)
+	s.dbManager.GetSchemaManager().SetExampleRecordsEnabled(key, !chat.Settings.DisableExampleRecords)
+
+	tenantID := s.tenantOf(userID)
+	if err := s.checkTenantConnections(tenantID, key); err != nil {
+		return err
+	}
+
+	connection := named.Connection
+	utils.DecryptConnection(&connection)
+	if err := s.dbManager.Connect(key, userID, streamID, dbConnectionConfig(&connection, tenantID)); err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+	log.Printf("ChatService -> connectNamedDB -> Connected connection %s of chatID: %s", name, chat.ID.Hex())
+	return nil
+}
+
+// connectNamedDBs connects the named connections of the chat along its main connection, a connection failing is
+// logged & connected again on its next query
+func (s *chatService) connectNamedDBs(userID string, chat *models.Chat, streamID string) {
+	for _, named := range chat.Connections {
+		if s.dbManager.IsConnected(dbmanager.NamedConnectionKey(chat.ID.Hex(), named.Name)) {
+			continue
+		}
+		if err := s.connectNamedDB(userID, chat, named.Name, streamID); err != nil {
+			log.Printf("ChatService -> connectNamedDBs -> Failed to connect connection %s: %v", named.Name, err)
+		}
+	}
+}
+
+// disconnectNamedDBs disconnects the named connections of a chat along its main connection
+func (s *chatService) disconnectNamedDBs(userID, chatID string) {
+	chatObjID, err := primitive.ObjectIDFromHex(chatID)
+	if err != nil {
+		return
+	}
+	chat, err := s.chatRepo.FindByID(chatObjID)
+	if err != nil || chat == nil {
+		log.Printf("ChatService -> disconnectNamedDBs -> Error finding chat: %v", err)
+		return
+	}
+	for _, named := range chat.Connections {
+		key := dbmanager.NamedConnectionKey(chatID, named.Name)
+		if !s.dbManager.IsConnected(key) {
+			continue
+		}
+		if err := s.dbManager.Disconnect(key, userID, false); err != nil {
+			log.Printf("ChatService -> disconnectNamedDBs -> Failed to disconnect connection %s: %v", named.Name, err)
+		}
+	}
+}
+
+// withNamedConnection makes the queries run with the context execute on a named connection of the chat, connected
+// first if needed. An empty name keeps the main connection.
+func (s *chatService) withNamedConnection(ctx context.Context, userID string, chat *models.Chat, name, streamID string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	if chat.NamedConnection(name) == nil {
+		return ctx, fmt.Errorf("the chat has no connection named %s", name)
+	}
+	if !s.dbManager.IsConnected(dbmanager.NamedConnectionKey(chat.ID.Hex(), name)) {
+		if err := s.connectNamedDB(userID, chat, name, streamID); err != nil {
+			return ctx, fmt.Errorf("failed to connect to %s: %v", name, err)
+		}
+	}
+	return dbmanager.WithConnectionName(ctx, name), nil
+}
+
+// namedConnectionQueryError returns the error of a query generated for a connection the chat doesn't have, nil if the
+// query runs on the main connection or on a named connection of the chat
+func namedConnectionQueryError(chat *models.Chat, name string) *models.QueryError {
+	if name == "" || chat.NamedConnection(name) != nil {
+		return nil
+	}
+	return &models.QueryError{
+		Code:    unknownConnectionErrorCode,
+		Message: fmt.Sprintf("The chat has no connection named %s", name),
+		Details: "Add the connection to the chat or execute the query on one of its connections",
+	}
+}
+
+// formatNamedConnectionsForLLM describes the connected named connections of the chat for the LLM, their tables are
+// qualified by the name of their connection. Empty if the chat has none connected.
+func (s *chatService) formatNamedConnectionsForLLM(ctx context.Context, chat *models.Chat) string {
+	if chat == nil || chat.Settings.GenerateOnly || len(chat.Connections) == 0 {
+		return ""
+	}
+	chatID := chat.ID.Hex()
+
+	var sections []string
+	for _, named := range chat.Connections {
+		if !s.dbManager.IsConnected(dbmanager.NamedConnectionKey(chatID, named.Name)) {
+			continue
+		}
+		schema, err := s.dbManager.FormatNamedConnectionSchema(ctx, chatID, named.Name)
+		if err != nil {
+			log.Printf("ChatService -> formatNamedConnectionsForLLM -> Error formatting the schema of connection %s: %v", named.Name, err)
+			continue
+		}
+		sections = append(sections, fmt.Sprintf("Connection %s:\n\n%s", named.Name, schema))
+	}
+	if len(sections) == 0 {
+		return ""
+	}
+
+	return "Besides its main database, the chat is connected to the named connections below, their tables are listed as " +
+		"<connection>.<table>. A query runs on a single connection: set \"connectionName\" of a query on a named " +
+		"connection to the name of the connection & write its tables without the connection prefix, leave " +
+		"\"connectionName\" empty for the main database. To compare data across connections, generate one query per " +
+		"connection.\n\n" + strings.Join(sections, "\n\n")
+}
+
+// buildNamedConnectionsResponse maps the named connections of the chat, without their secrets
+func (s *chatService) buildNamedConnectionsResponse(chat *models.Chat) []dtos.NamedConnectionResponse {
+	if len(chat.Connections) == 0 {
+		return nil
+	}
+	connections := make([]dtos.NamedConnectionResponse, len(chat.Connections))
+	for i := range chat.Connections {
+		connections[i] = s.buildNamedConnectionResponse(chat, &chat.Connections[i])
+	}
+	return connections
+}
+
+func (s *chatService) buildNamedConnectionResponse(chat *models.Chat, named *models.NamedConnection) dtos.NamedConnectionResponse {
+	key := dbmanager.NamedConnectionKey(chat.ID.Hex(), named.Name)
+	return dtos.NamedConnectionResponse{
+		Name:        named.Name,
+		Connection:  buildConnectionResponse(key, named.Connection),
+		IsConnected: s.dbManager.IsConnected(key),
+	}
+}
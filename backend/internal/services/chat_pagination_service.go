@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// paginatedQueryAt returns the paginated query of a query for the page starting at offset, with the page size of the
+// chat as its row count. Paginated SELECTs without an ORDER BY are ordered on the primary key so that rows aren't
+// repeated or missing across pages, the warning is set when the pages may not be stable.
+func (s *chatService) paginatedQueryAt(ctx context.Context, chat *models.Chat, chatID string, query *models.Query, offset int) (string, string) {
+	paginatedQuery, warning := s.dbManager.OrderPaginatedQuery(ctx, chatID, *query.Pagination.PaginatedQuery)
+	if warning != "" {
+		log.Printf("ChatService -> paginatedQueryAt -> queryID: %s, %s", query.ID.Hex(), warning)
+	}
+	paginatedQuery = dbmanager.SetPaginatedPageSize(chat.Connection.Type, paginatedQuery, pageSizeOf(chat))
+	return strings.Replace(paginatedQuery, "offset_size", strconv.Itoa(offset), 1), warning
+}
+
+// pageSizeOf returns the number of rows of a page of query results of a chat
+func pageSizeOf(chat *models.Chat) int {
+	if chat == nil {
+		return constants.DefaultPageSize
+	}
+	return constants.ResolvePageSize(chat.Settings.DefaultPageSize)
+}
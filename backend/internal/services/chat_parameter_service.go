@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+var (
+	numberParameterPattern      = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	parameterPlaceholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// dateParameterLayouts are the formats accepted for date parameters, values are passed to the database unchanged
+var dateParameterLayouts = []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339}
+
+// SubmitQueryParameters fills the values requested by an AI message in the {{name}} placeholders of its queries, the
+// values are validated against their type & rendered as literals of the connection's database so that they can't
+// change the structure of the query. Non critical queries are then executed if the chat auto executes queries.
+func (s *chatService) SubmitQueryParameters(ctx context.Context, userID, chatID, messageID string, req *dtos.SubmitQueryParametersRequest) (*dtos.MessageResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	msgObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid message ID format")
+	}
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("message not found")
+	}
+	if msg.ChatID != chat.ID {
+		return nil, http.StatusForbidden, fmt.Errorf("message does not belong to chat")
+	}
+	if !hasPendingParameters(msg) {
+		return nil, http.StatusBadRequest, fmt.Errorf("message has no pending parameters")
+	}
+
+	literals := make(map[string]string, len(*msg.ParameterRequests))
+	for _, parameter := range *msg.ParameterRequests {
+		value, ok := req.Values[parameter.Name]
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("missing value for %s", parameter.Label)
+		}
+		literal, err := renderParameterLiteral(chat.Connection.Type, parameter.Type, value)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid value for %s: %v", parameter.Label, err)
+		}
+		literals[parameter.Name] = literal
+	}
+
+	if msg.Queries != nil {
+		for i := range *msg.Queries {
+			query := &(*msg.Queries)[i]
+			query.Query = fillParameterPlaceholders(query.Query, literals)
+			if query.RollbackQuery != nil {
+				filled := fillParameterPlaceholders(*query.RollbackQuery, literals)
+				query.RollbackQuery = &filled
+			}
+			if query.Pagination != nil {
+				if query.Pagination.PaginatedQuery != nil {
+					filled := fillParameterPlaceholders(*query.Pagination.PaginatedQuery, literals)
+					query.Pagination.PaginatedQuery = &filled
+				}
+				if query.Pagination.CountQuery != nil {
+					filled := fillParameterPlaceholders(*query.Pagination.CountQuery, literals)
+					query.Pagination.CountQuery = &filled
+				}
+			}
+		}
+	}
+
+	for i := range *msg.ParameterRequests {
+		value := strings.TrimSpace(req.Values[(*msg.ParameterRequests)[i].Name])
+		(*msg.ParameterRequests)[i].Value = &value
+	}
+
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message: %v", err)
+	}
+	log.Printf("ChatService -> SubmitQueryParameters -> Filled %d parameters of messageID: %s", len(literals), messageID)
+
+	if chat.Settings.AutoExecuteQuery && !chat.Settings.GenerateOnly && msg.Queries != nil {
+		var releaseConnection func()
+		for _, query := range *msg.Queries {
+			if query.IsCritical || query.Query == "" || readOnlyQueryError(chat, query.Query) != nil || tableScopeQueryError(chat, query.Query, query.Tables) != nil {
+				continue
+			}
+			if releaseConnection == nil && config.Env.AutoExecuteConnectionAffinity {
+				releaseConnection = s.holdConnection(ctx, userID, chatID, req.StreamID)
+			}
+			if _, _, err := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
+				MessageID:      messageID,
+				QueryID:        query.ID.Hex(),
+				StreamID:       req.StreamID,
+				ConnectionHeld: releaseConnection != nil,
+			}); err != nil {
+				log.Printf("ChatService -> SubmitQueryParameters -> Error executing query %s: %v", query.ID.Hex(), err)
+				break
+			}
+		}
+		if releaseConnection != nil {
+			releaseConnection()
+		}
+
+		// Executions store their results on the message
+		if msg, err = s.chatRepo.FindMessageByID(msgObjID); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch message: %v", err)
+		}
+	}
+
+	return s.buildMessageResponse(msg), http.StatusOK, nil
+}
+
+// hasPendingParameters checks if the message asked the user for values that were not supplied yet
+func hasPendingParameters(msg *models.Message) bool {
+	if msg.ParameterRequests == nil {
+		return false
+	}
+	for _, parameter := range *msg.ParameterRequests {
+		if parameter.Value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// renderParameterLiteral validates a value supplied by the user & renders it as a literal of the database
+func renderParameterLiteral(dbType, paramType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsRune(value, 0) {
+		return "", fmt.Errorf("value contains a null character")
+	}
+
+	switch paramType {
+	case constants.ParameterTypeNumber:
+		if !numberParameterPattern.MatchString(value) {
+			return "", fmt.Errorf("expected a number")
+		}
+		if strings.HasPrefix(value, "-") && dbType != constants.DatabaseTypeMongoDB && dbType != constants.DatabaseTypeElasticsearch {
+			// Parenthesized so that a placeholder following a minus can't turn into a -- comment
+			return "(" + value + ")", nil
+		}
+		return value, nil
+	case constants.ParameterTypeBoolean:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("expected true or false")
+		}
+		return strconv.FormatBool(parsed), nil
+	case constants.ParameterTypeDate:
+		if !isValidDateParameter(value) {
+			return "", fmt.Errorf("expected a date such as 2024-01-31")
+		}
+	}
+
+	if dbType == constants.DatabaseTypeMongoDB || dbType == constants.DatabaseTypeElasticsearch {
+		// A JSON string, the placeholder is written unquoted in the query
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(quoted), nil
+	}
+	if dbType == constants.DatabaseTypeBigQuery {
+		// BigQuery escapes quotes with a backslash rather than by doubling them
+		escaped := strings.ReplaceAll(value, `\`, `\\`)
+		return "'" + strings.ReplaceAll(escaped, "'", `\'`) + "'", nil
+	}
+	escaped := value
+	if dbType == constants.DatabaseTypeMySQL || dbType == constants.DatabaseTypeMariaDB || dbType == constants.DatabaseTypeClickhouse {
+		// Backslashes start escape sequences in MySQL, MariaDB & ClickHouse string literals
+		escaped = strings.ReplaceAll(escaped, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(escaped, "'", "''") + "'", nil
+}
+
+func isValidDateParameter(value string) bool {
+	for _, layout := range dateParameterLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// fillParameterPlaceholders replaces the {{name}} placeholders of a query with the rendered literals in a single pass,
+// so that a value containing a placeholder is never substituted itself
+func fillParameterPlaceholders(query string, literals map[string]string) string {
+	return parameterPlaceholderPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		name := parameterPlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		if literal, ok := literals[name]; ok {
+			return literal
+		}
+		return placeholder
+	})
+}
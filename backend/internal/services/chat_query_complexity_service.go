@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"log"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// scoreQueriesComplexity sets the complexity score of queries from their structure & the indexed columns & row counts
+// of the stored schema (see dbmanager.ScoreQueryComplexity), so that the user is warned before running an expensive
+// query. Without a stored schema (ex: a generate only chat) only the JOINs & subqueries are scored.
+func (s *chatService) scoreQueriesComplexity(ctx context.Context, chat *models.Chat, chatID string, queries []models.Query) {
+	if len(queries) == 0 {
+		return
+	}
+	schema := s.dbManager.GetKnownLLMSchema(ctx, chatID)
+	for i := range queries {
+		query := &queries[i]
+		if strings.TrimSpace(query.Query) == "" {
+			continue
+		}
+		complexity := dbmanager.ScoreQueryComplexity(chat.Connection.Type, query.Query, schema)
+		query.ComplexityScore = &complexity.Score
+		query.ComplexityReasons = complexity.Reasons
+		if complexity.Score != dbmanager.QueryComplexityLow {
+			log.Printf("ChatService -> scoreQueriesComplexity -> Query %s is of %s complexity: %v", query.ID.Hex(), complexity.Score, complexity.Reasons)
+		}
+	}
+}
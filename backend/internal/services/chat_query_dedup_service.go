@@ -0,0 +1,173 @@
+package services
+
+import (
+	"databot-ai/internal/constants"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// dedupeLLMQueries collapses the queries of an LLM response that are the same once formatted alike (whitespace,
+// keyword case, trailing semicolon), the most complete one (pagination, rollback, example result) is kept at the
+// position of the first. Queries only differing by their identifier quoting, AS keywords or LIMIT/OFFSET are kept but
+// returned as near duplicates, mapped to the index of the earlier query they resemble.
+func dedupeLLMQueries(dbType string, queries []interface{}) ([]interface{}, map[int]int) {
+	deduped := make([]interface{}, 0, len(queries))
+	exactIndexes := make(map[string]int)
+	nearIndexes := make(map[string]int)
+	nearDuplicates := make(map[int]int)
+	for _, query := range queries {
+		queryMap, ok := query.(map[string]interface{})
+		queryText, _ := queryMap["query"].(string)
+		if !ok || strings.TrimSpace(queryText) == "" {
+			deduped = append(deduped, query)
+			continue
+		}
+
+		exact, near := queryFingerprints(dbType, queryText)
+		if index, exists := exactIndexes[exact]; exists {
+			log.Printf("ChatService -> dedupeLLMQueries -> Collapsing duplicate query: %s", queryText)
+			if queryCompleteness(queryMap) > queryCompleteness(deduped[index].(map[string]interface{})) {
+				deduped[index] = queryMap
+			}
+			continue
+		}
+
+		index := len(deduped)
+		exactIndexes[exact] = index
+		if original, exists := nearIndexes[near]; exists {
+			log.Printf("ChatService -> dedupeLLMQueries -> Query %d is a near duplicate of query %d", index+1, original+1)
+			nearDuplicates[index] = original
+		} else {
+			nearIndexes[near] = index
+		}
+		deduped = append(deduped, queryMap)
+	}
+	return deduped, nearDuplicates
+}
+
+// queryCompleteness counts the optional parts of an LLM query, the more it has the more the user can do with it
+func queryCompleteness(queryMap map[string]interface{}) int {
+	completeness := 0
+	if pagination, ok := queryMap["pagination"].(map[string]interface{}); ok {
+		for _, key := range []string{"paginatedQuery", "countQuery"} {
+			if value, _ := pagination[key].(string); strings.TrimSpace(value) != "" {
+				completeness++
+			}
+		}
+	}
+	if rollbackQuery, _ := queryMap["rollbackQuery"].(string); strings.TrimSpace(rollbackQuery) != "" {
+		completeness++
+	}
+	if exampleResult, _ := queryMap["exampleResult"].([]interface{}); len(exampleResult) > 0 {
+		completeness++
+	}
+	return completeness
+}
+
+// queryFingerprints returns two normalized forms of a query: the exact one only ignores formatting, the near one also
+// ignores identifier quoting, AS keywords & LIMIT/OFFSET clauses. Strings are compared as written & so are MongoDB
+// names, which are case sensitive.
+func queryFingerprints(dbType, query string) (string, string) {
+	isMongoDB := dbType == constants.DatabaseTypeMongoDB
+	stringQuotes, identifierQuotes := "'", "\"`"
+	if isMongoDB || dbType == constants.DatabaseTypeMySQL || dbType == constants.DatabaseTypeMariaDB {
+		stringQuotes, identifierQuotes = "'\"", "`"
+	}
+
+	var exact, near []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune(stringQuotes, r) || strings.ContainsRune(identifierQuotes, r):
+			end := quotedTokenEnd(runes, i)
+			token := string(runes[i:end])
+			exact = append(exact, token)
+			if strings.ContainsRune(identifierQuotes, r) {
+				// "Users" & users may differ on PostgreSQL, hence a near duplicate only
+				token = strings.ToLower(strings.Trim(token, string(r)))
+			}
+			near = append(near, token)
+			i = end
+		case r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (runes[end] == '_' || runes[end] == '$' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			token := string(runes[i:end])
+			if !isMongoDB {
+				token = strings.ToLower(token)
+			}
+			exact = append(exact, token)
+			near = append(near, token)
+			i = end
+		default:
+			exact = append(exact, string(r))
+			near = append(near, string(r))
+			i++
+		}
+	}
+	for len(exact) > 0 && exact[len(exact)-1] == ";" {
+		exact = exact[:len(exact)-1]
+		near = near[:len(near)-1]
+	}
+	return strings.Join(exact, " "), strings.Join(withoutRowLimits(near, isMongoDB), " ")
+}
+
+// quotedTokenEnd returns the index following the quote closing the string or identifier starting at start, doubled
+// quotes & backslash escapes are part of the token
+func quotedTokenEnd(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// withoutRowLimits removes the AS keywords & the LIMIT/OFFSET clauses of SQL tokens, or the .limit(n) & .skip(n) calls
+// of MongoDB tokens
+func withoutRowLimits(tokens []string, isMongoDB bool) []string {
+	isNumber := func(i int) bool {
+		return i < len(tokens) && tokens[i] != "" && strings.IndexFunc(tokens[i], func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+	}
+
+	kept := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if isMongoDB {
+			// .limit(10) or .skip(20)
+			if token == "." && i+4 < len(tokens) && (tokens[i+1] == "limit" || tokens[i+1] == "skip") && tokens[i+2] == "(" && isNumber(i+3) && tokens[i+4] == ")" {
+				i += 4
+				continue
+			}
+			kept = append(kept, token)
+			continue
+		}
+
+		switch {
+		case token == "as":
+			continue
+		case (token == "limit" || token == "offset" || token == "top") && isNumber(i+1):
+			// LIMIT 10, LIMIT 20, 10 or OFFSET 20
+			i++
+			if token == "limit" && i+2 < len(tokens) && tokens[i+1] == "," && isNumber(i+2) {
+				i += 2
+			}
+			continue
+		}
+		kept = append(kept, token)
+	}
+	return kept
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ToggleQueryFavorite pins a query of a message, or unpins it if it is pinned. The flag is also set on the query of the
+// LLM message so that the favorite survives the message being edited & its response generated again.
+func (s *chatService) ToggleQueryFavorite(userID, chatID, messageID, queryID string) (*dtos.QueryFavoriteResponse, uint32, error) {
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, messageID, queryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+
+	isFavorite := !query.IsFavorite
+	favoritedAt := time.Now().Format(time.RFC3339)
+	for i := range *msg.Queries {
+		if (*msg.Queries)[i].ID != query.ID {
+			continue
+		}
+		(*msg.Queries)[i].IsFavorite = isFavorite
+		if isFavorite {
+			(*msg.Queries)[i].FavoritedAt = &favoritedAt
+		} else {
+			(*msg.Queries)[i].FavoritedAt = nil
+		}
+		query = &(*msg.Queries)[i]
+	}
+
+	// The message isn't flagged as edited, pinning a query doesn't change what the assistant answered
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message: %v", err)
+	}
+
+	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
+	if err != nil {
+		log.Printf("ChatService -> ToggleQueryFavorite -> Error finding LLM message: %v", err)
+	} else if llmMsg != nil {
+		if assistantResponse, ok := llmMsg.Content["assistant_response"].(map[string]interface{}); ok {
+			assistantResponse["queries"] = flagLLMFavoriteQuery(assistantResponse["queries"], query.Query, isFavorite)
+			llmMsg.Content["assistant_response"] = assistantResponse
+			if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
+				log.Printf("ChatService -> ToggleQueryFavorite -> Error updating LLM message: %v", err)
+			}
+		}
+	}
+
+	log.Printf("ChatService -> ToggleQueryFavorite -> queryID: %s, isFavorite: %v", queryID, isFavorite)
+	return &dtos.QueryFavoriteResponse{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		QueryID:     queryID,
+		IsFavorite:  query.IsFavorite,
+		FavoritedAt: query.FavoritedAt,
+	}, http.StatusOK, nil
+}
+
+// GetFavoriteQueries returns the queries pinned by the user across all the chats of the user, latest pinned first
+func (s *chatService) GetFavoriteQueries(userID string) ([]dtos.FavoriteQueryResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	messages, err := s.chatRepo.FindMessagesWithFavoriteQueries(userObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch favorite queries: %v", err)
+	}
+
+	chats := make(map[primitive.ObjectID]*models.Chat)
+	favorites := make([]dtos.FavoriteQueryResponse, 0)
+	for _, msg := range messages {
+		if msg.Queries == nil {
+			continue
+		}
+		chat, ok := chats[msg.ChatID]
+		if !ok {
+			chat, err = s.chatRepo.FindByID(msg.ChatID)
+			if err != nil {
+				log.Printf("ChatService -> GetFavoriteQueries -> Error fetching chat %s: %v", msg.ChatID.Hex(), err)
+				chat = nil
+			}
+			chats[msg.ChatID] = chat
+		}
+		// The favorites of a deleted chat are left out
+		if chat == nil || chat.UserID != userObjID {
+			continue
+		}
+
+		queriesDto := dtos.ToQueryDto(msg.Queries)
+		for _, query := range *queriesDto {
+			if !query.IsFavorite {
+				continue
+			}
+			favorites = append(favorites, dtos.FavoriteQueryResponse{
+				ChatID:         msg.ChatID.Hex(),
+				ConnectionType: chat.Connection.Type,
+				Database:       chat.Connection.Database,
+				MessageID:      msg.ID.Hex(),
+				Query:          query,
+			})
+		}
+	}
+
+	// RFC3339 timestamps sort in time order
+	sort.SliceStable(favorites, func(i, j int) bool {
+		return favoritedAtOf(favorites[i]) > favoritedAtOf(favorites[j])
+	})
+	return favorites, http.StatusOK, nil
+}
+
+func favoritedAtOf(favorite dtos.FavoriteQueryResponse) string {
+	if favorite.Query.FavoritedAt == nil {
+		return ""
+	}
+	return *favorite.Query.FavoritedAt
+}
+
+// flagLLMFavoriteQuery sets the isFavorite flag of the query with the given text in the queries of an LLM message
+func flagLLMFavoriteQuery(queries interface{}, queryText string, isFavorite bool) interface{} {
+	var list []interface{}
+	switch queriesVal := queries.(type) {
+	case primitive.A:
+		list = []interface{}(queriesVal)
+	case []interface{}:
+		list = queriesVal
+	default:
+		return queries
+	}
+	for i, q := range list {
+		queryMap, ok := q.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if text, _ := queryMap["query"].(string); strings.TrimSpace(text) == strings.TrimSpace(queryText) {
+			queryMap["isFavorite"] = isFavorite
+			list[i] = queryMap
+		}
+	}
+	return list
+}
+
+// carryQueryFavorites keeps the favorites of a message when its response is generated again (ex: the user message was
+// edited), a favorite moves to the regenerated query with the same text & the LLM response is flagged the same way
+func carryQueryFavorites(previous *[]models.Query, queries []models.Query, llmResponse map[string]interface{}) {
+	if previous == nil {
+		return
+	}
+	for _, old := range *previous {
+		if !old.IsFavorite {
+			continue
+		}
+		for i := range queries {
+			if !queries[i].IsFavorite && strings.TrimSpace(queries[i].Query) == strings.TrimSpace(old.Query) {
+				queries[i].IsFavorite = true
+				queries[i].FavoritedAt = old.FavoritedAt
+				if llmResponse != nil {
+					llmResponse["queries"] = flagLLMFavoriteQuery(llmResponse["queries"], queries[i].Query, true)
+				}
+				break
+			}
+		}
+	}
+}
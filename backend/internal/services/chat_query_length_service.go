@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// dropOverlongLLMQueries removes the queries of an LLM response whose query or rollback query is longer than
+// maxLength characters, the lengths of the dropped queries are returned to explain their removal
+func dropOverlongLLMQueries(queries []interface{}, maxLength int) ([]interface{}, []int) {
+	kept := make([]interface{}, 0, len(queries))
+	var droppedLengths []int
+	for _, query := range queries {
+		queryMap, ok := query.(map[string]interface{})
+		if !ok {
+			kept = append(kept, query)
+			continue
+		}
+		queryText, _ := queryMap["query"].(string)
+		rollbackQuery, _ := queryMap["rollbackQuery"].(string)
+		length := len([]rune(queryText))
+		if rollbackLength := len([]rune(rollbackQuery)); rollbackLength > length {
+			length = rollbackLength
+		}
+		if length > maxLength {
+			log.Printf("ChatService -> dropOverlongLLMQueries -> Dropping a generated query of %d characters, the limit is %d", length, maxLength)
+			droppedLengths = append(droppedLengths, length)
+			continue
+		}
+		kept = append(kept, query)
+	}
+	return kept, droppedLengths
+}
+
+// overlongQueriesMessage tells the user why generated queries were dropped, added to the assistant message
+func overlongQueriesMessage(droppedLengths []int, maxLength int) string {
+	lengths := make([]string, len(droppedLengths))
+	for i, length := range droppedLengths {
+		lengths[i] = strconv.Itoa(length)
+	}
+	if len(droppedLengths) == 1 {
+		return fmt.Sprintf("A generated query was dropped, it is %s characters long while at most %d are allowed. Please narrow the request (ex: fewer tables, columns or conditions) and try again.", lengths[0], maxLength)
+	}
+	return fmt.Sprintf("%d generated queries were dropped, they are %s characters long while at most %d are allowed. Please narrow the request (ex: fewer tables, columns or conditions) and try again.", len(droppedLengths), strings.Join(lengths, ", "), maxLength)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// UpdateQueryNote attaches a note to an executed query, ex: why a mutation was run. Notes are metadata only, they are
+// kept on the message & never sent to the LLM. An empty note removes the note of the query.
+func (s *chatService) UpdateQueryNote(userID, chatID string, req *dtos.UpdateQueryNoteRequest) (*dtos.QueryNoteResponse, uint32, error) {
+	_, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if !query.IsExecuted && !query.IsRolledBack {
+		return nil, http.StatusBadRequest, fmt.Errorf("only executed queries can have a note")
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if utf8.RuneCountInString(note) > constants.MaxQueryNoteLength {
+		return nil, http.StatusBadRequest, fmt.Errorf("note is longer than %d characters", constants.MaxQueryNoteLength)
+	}
+
+	notedAt := time.Now().Format(time.RFC3339)
+	for i := range *msg.Queries {
+		if (*msg.Queries)[i].ID != query.ID {
+			continue
+		}
+		if note == "" {
+			(*msg.Queries)[i].Note = nil
+			(*msg.Queries)[i].NotedAt = nil
+		} else {
+			(*msg.Queries)[i].Note = &note
+			(*msg.Queries)[i].NotedAt = &notedAt
+		}
+		query = &(*msg.Queries)[i]
+	}
+
+	// The message isn't flagged as edited, a note doesn't change what the assistant answered
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update message: %v", err)
+	}
+
+	log.Printf("ChatService -> UpdateQueryNote -> Updated the note of queryID: %s, removed: %v", req.QueryID, note == "")
+	return &dtos.QueryNoteResponse{
+		ChatID:    chatID,
+		MessageID: req.MessageID,
+		QueryID:   req.QueryID,
+		Note:      query.Note,
+		NotedAt:   query.NotedAt,
+	}, http.StatusOK, nil
+}
+
+// carryQueryNotes keeps the notes of the queries of a message when its response is generated again (ex: the user
+// message was edited), a note moves to the regenerated query with the same text
+func carryQueryNotes(previous *[]models.Query, queries []models.Query) {
+	if previous == nil {
+		return
+	}
+	for _, old := range *previous {
+		if old.Note == nil {
+			continue
+		}
+		for i := range queries {
+			if queries[i].Note == nil && strings.TrimSpace(queries[i].Query) == strings.TrimSpace(old.Query) {
+				queries[i].Note = old.Note
+				queries[i].NotedAt = old.NotedAt
+				break
+			}
+		}
+	}
+}
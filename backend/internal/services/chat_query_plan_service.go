@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExplainQuery returns the execution plan of a read query of a message, see dbmanager.Manager.ExplainQuery. Queries
+// changing data are refused whatever the request, the query isn't marked as executed & nothing is stored on the message.
+func (s *chatService) ExplainQuery(ctx context.Context, userID, chatID string, req *dtos.ExplainQueryRequest) (*dtos.QueryPlanResponse, uint32, error) {
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	if !dbmanager.ExplainSupported(chat.Connection.Type) {
+		return nil, http.StatusBadRequest, fmt.Errorf("explaining queries is not supported for %s", chat.Connection.Type)
+	}
+	if hasPendingParameters(msg) {
+		return nil, http.StatusBadRequest, fmt.Errorf("fill the parameters requested in the message before explaining its queries")
+	}
+	if !isReadQuery(chat, query) || !dbmanager.IsExplainableQuery(chat.Connection.Type, query.Query) {
+		return nil, http.StatusBadRequest, dbmanager.ErrQueryNotExplainable
+	}
+
+	if !s.dbManager.IsConnected(chatID) {
+		if statusCode, err := s.ConnectDB(ctx, userID, chatID, req.StreamID); err != nil {
+			return nil, statusCode, err
+		}
+	}
+	if ctx, err = s.withNamedConnection(ctx, userID, chat, query.ConnectionName, req.StreamID); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	plan, err := s.dbManager.ExplainQuery(ctx, chatID, query.Query, req.Analyze)
+	if errors.Is(err, dbmanager.ErrQueryNotExplainable) {
+		return nil, http.StatusBadRequest, err
+	}
+	if err != nil {
+		log.Printf("ChatService -> ExplainQuery -> Failed to explain queryID %s: %v", req.QueryID, err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	return &dtos.QueryPlanResponse{
+		ChatID:        chatID,
+		MessageID:     req.MessageID,
+		QueryID:       req.QueryID,
+		Statement:     plan.Statement,
+		Format:        plan.Format,
+		Plan:          plan.Plan,
+		Analyzed:      plan.Analyzed,
+		ExecutionTime: plan.ExecutionTime,
+		Warnings:      plan.Warnings,
+	}, http.StatusOK, nil
+}
+
+// explainQueryButton offers to fetch the plan of the read queries of a response, nil when none can be explained
+func explainQueryButton(chat *models.Chat, queries []models.Query) *models.ActionButton {
+	if chat.Settings.GenerateOnly || !dbmanager.ExplainSupported(chat.Connection.Type) {
+		return nil
+	}
+	queryIDs := make([]string, 0)
+	for i := range queries {
+		if isReadQuery(chat, &queries[i]) && dbmanager.IsExplainableQuery(chat.Connection.Type, queries[i].Query) {
+			queryIDs = append(queryIDs, queries[i].ID.Hex())
+		}
+	}
+	if len(queryIDs) == 0 {
+		return nil
+	}
+	return &models.ActionButton{
+		ID:      primitive.NewObjectID(),
+		Label:   "Explain Query Plan",
+		Action:  "explain_query",
+		Payload: map[string]interface{}{constants.ActionPayloadQueryIDs: queryIDs},
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// previewQuery runs the first page of a read query & returns a few sample rows so that the user can check the shape
+// of the result before the full execution. The paginated query is used when there is one so that the database stops
+// at the page size, the query isn't marked as executed & nothing is stored on the message.
+func (s *chatService) previewQuery(ctx context.Context, userID, chatID string, chat *models.Chat, req *dtos.ExecuteQueryRequest, query *models.Query) (*dtos.QueryExecutionResponse, uint32, error) {
+	previewQuery := query.Query
+	if query.Pagination != nil && query.Pagination.PaginatedQuery != nil && *query.Pagination.PaginatedQuery != "" {
+		previewQuery, _ = s.paginatedQueryAt(ctx, chat, chatID, query, 0)
+	}
+	log.Printf("ChatService -> previewQuery -> Previewing queryID: %s with: %s", req.QueryID, previewQuery)
+
+	previewCtx := dbmanager.WithPreviewRowLimit(ctx, constants.QueryPreviewRows)
+	result, queryErr := s.dbManager.ExecuteQuery(previewCtx, chatID, req.MessageID, req.QueryID, req.StreamID, previewQuery, *query.QueryType, false, false)
+	if queryErr != nil {
+		log.Printf("ChatService -> previewQuery -> queryErr: %+v", queryErr)
+		return nil, http.StatusBadRequest, fmt.Errorf("%s", queryErr.Message)
+	}
+
+	var executionResult interface{}
+	if err := json.Unmarshal([]byte(result.ResultJSON), &executionResult); err != nil {
+		log.Printf("ChatService -> previewQuery -> Error unmarshalling result JSON: %v", err)
+	}
+
+	response := &dtos.QueryExecutionResponse{
+		ChatID:          chatID,
+		MessageID:       req.MessageID,
+		QueryID:         req.QueryID,
+		ExecutionTime:   &result.ExecutionTime,
+		ExecutionResult: executionResult,
+		IsPreview:       true,
+	}
+	s.sendStreamEvent(userID, chatID, req.StreamID, dtos.StreamResponse{
+		Event: "query-preview-results",
+		Data:  response,
+	})
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/pkg/dbmanager"
+	"log"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// executeCachedQuery executes a query, serving its result from the cache of the chat when useCache is set. Only read
+// queries of the current data may use the cache, the results of the DML queries are never cached & their success
+// invalidates the cache of the chat (see dbmanager.Manager.ExecuteQuery). The offset tells the pages of a query apart.
+func (s *chatService) executeCachedQuery(ctx context.Context, useCache bool, chatID, messageID, queryID, streamID, query, queryType string, offset int, findCount bool) (*dbmanager.QueryExecutionResult, *dtos.QueryError) {
+	if !useCache {
+		return s.dbManager.ExecuteQuery(ctx, chatID, messageID, queryID, streamID, query, queryType, false, findCount)
+	}
+
+	key := s.dbManager.QueryResultCacheKey(ctx, chatID, query, queryType, offset, findCount)
+	if result, ok := s.dbManager.GetCachedQueryResult(ctx, key); ok {
+		log.Printf("ChatService -> executeCachedQuery -> Serving the cached result of queryID %s at offset %d", queryID, offset)
+		return result, nil
+	}
+
+	result, queryErr := s.dbManager.ExecuteQuery(ctx, chatID, messageID, queryID, streamID, query, queryType, false, findCount)
+	if queryErr == nil {
+		s.dbManager.CacheQueryResult(ctx, key, result)
+	}
+	return result, queryErr
+}
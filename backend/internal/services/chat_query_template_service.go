@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// UpdateQueryTemplates replaces the reusable CTEs of the chat's connection after validating them against the known schema
+func (s *chatService) UpdateQueryTemplates(ctx context.Context, userID, chatID string, req *dtos.UpdateQueryTemplatesRequest) ([]dtos.QueryTemplate, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	templates := make([]models.QueryTemplate, len(req.Templates))
+	for i, template := range req.Templates {
+		templates[i] = models.QueryTemplate{
+			Name:        strings.TrimSpace(template.Name),
+			Query:       strings.TrimSuffix(strings.TrimSpace(template.Query), ";"),
+			Description: strings.TrimSpace(template.Description),
+			AutoPrepend: template.AutoPrepend,
+		}
+	}
+
+	if err := s.dbManager.GetSchemaManager().ValidateQueryTemplates(ctx, chatID, chat.Connection.Type, toDBQueryTemplates(templates)); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	chat.QueryTemplates = templates
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+
+	log.Printf("ChatService -> UpdateQueryTemplates -> Stored %d query templates for chatID: %s", len(templates), chatID)
+	return buildQueryTemplatesResponse(templates), http.StatusOK, nil
+}
+
+// applyQueryTemplates prepends the referenced AutoPrepend templates to a generated query, only SQL queries are rewritten
+func applyQueryTemplates(dbType, query string, templates []dbmanager.QueryTemplate) string {
+	rewritten, prepended := dbmanager.PrependQueryTemplates(dbType, query, templates)
+	if len(prepended) > 0 {
+		log.Printf("ChatService -> applyQueryTemplates -> Prepended templates: %v", prepended)
+	}
+	return rewritten
+}
+
+func toDBQueryTemplates(templates []models.QueryTemplate) []dbmanager.QueryTemplate {
+	dbTemplates := make([]dbmanager.QueryTemplate, len(templates))
+	for i, template := range templates {
+		dbTemplates[i] = dbmanager.QueryTemplate{
+			Name:        template.Name,
+			Query:       template.Query,
+			Description: template.Description,
+			AutoPrepend: template.AutoPrepend,
+		}
+	}
+	return dbTemplates
+}
+
+func buildQueryTemplatesResponse(templates []models.QueryTemplate) []dtos.QueryTemplate {
+	if len(templates) == 0 {
+		return nil
+	}
+	response := make([]dtos.QueryTemplate, len(templates))
+	for i, template := range templates {
+		response[i] = dtos.QueryTemplate{
+			Name:        template.Name,
+			Query:       template.Query,
+			Description: template.Description,
+			AutoPrepend: template.AutoPrepend,
+		}
+	}
+	return response
+}
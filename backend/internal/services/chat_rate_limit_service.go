@@ -0,0 +1,76 @@
+package services
+
+import (
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// llmRateLimitOf returns the LLM generations a user can start per minute, the override of the user or
+// LLM_RATE_LIMIT_PER_MINUTE, 0 doesn't limit the user
+func (s *chatService) llmRateLimitOf(userID string) int {
+	if s.userRepo == nil {
+		return config.Env.LLMRateLimitPerMinute
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		log.Printf("ChatService -> llmRateLimitOf -> Error fetching user %s, using the default limit: %v", userID, err)
+		return config.Env.LLMRateLimitPerMinute
+	}
+	if user.LLMRateLimitPerMinute != nil {
+		return *user.LLMRateLimitPerMinute
+	}
+	return config.Env.LLMRateLimitPerMinute
+}
+
+// checkLLMRateLimit takes a token from the LLM generation bucket of a user before an LLM generation starts. An error
+// telling when to retry is returned once the user started more generations than allowed, the generation must not run.
+func (s *chatService) checkLLMRateLimit(userID string) error {
+	if s.rateLimitRepo == nil {
+		return nil
+	}
+	perMinute := s.llmRateLimitOf(userID)
+	if perMinute <= 0 {
+		return nil
+	}
+	// A user allowed fewer generations per minute than the burst doesn't get the whole burst at once
+	burst := config.Env.LLMRateLimitBurst
+	if perMinute < burst {
+		burst = perMinute
+	}
+
+	allowed, retryAfter, err := s.rateLimitRepo.TakeLLMToken(userID, perMinute, burst)
+	if err != nil {
+		// The rate limit is a safeguard, generations aren't rejected when it can't be checked
+		log.Printf("ChatService -> checkLLMRateLimit -> Error taking a token for user %s: %v", userID, err)
+		return nil
+	}
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		log.Printf("ChatService -> checkLLMRateLimit -> User %s exceeded %d generations per minute, retry in %s", userID, perMinute, retryAfter.Round(time.Millisecond))
+		return fmt.Errorf("rate limit exceeded, retry in %d seconds", seconds)
+	}
+	return nil
+}
+
+// rejectRateLimitedGeneration sends an ai-response-error event instead of starting an LLM generation when the user
+// exceeded the rate limit, true is returned when the generation was rejected
+func (s *chatService) rejectRateLimitedGeneration(userID, chatID, streamID string) bool {
+	err := s.checkLLMRateLimit(userID)
+	if err == nil {
+		return false
+	}
+	s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+		Event: "ai-response-error",
+		Data:  "Error: " + err.Error(),
+	})
+	return true
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+type alternativeQueryKey struct{}
+
+// withAlternativeQuery makes processLLMResponse ask the LLM for another approach than its previous response instead of
+// generating the response of the request again, a nil previousResponse returns ctx as is
+func withAlternativeQuery(ctx context.Context, previousResponse map[string]interface{}) context.Context {
+	if previousResponse == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, alternativeQueryKey{}, previousResponse)
+}
+
+func alternativeQueryOf(ctx context.Context) map[string]interface{} {
+	previousResponse, _ := ctx.Value(alternativeQueryKey{}).(map[string]interface{})
+	return previousResponse
+}
+
+// RegenerateQuery asks the LLM for alternative queries to the request answered by an AI message, avoiding the approach
+// of its current queries. The AI message is updated in place & streamed like an edited request, it still answers the
+// same user message. Non critical queries are executed if the chat auto executes queries.
+func (s *chatService) RegenerateQuery(ctx context.Context, userID, chatID, messageID string, req *dtos.RegenerateQueryRequest) (*dtos.MessageResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	msgObjID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid message ID format")
+	}
+	msg, err := s.chatRepo.FindMessageByID(msgObjID)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("message not found")
+	}
+	if msg.ChatID != chat.ID {
+		return nil, http.StatusForbidden, fmt.Errorf("message does not belong to chat")
+	}
+	if msg.Type != string(constants.MessageTypeAssistant) || msg.UserMessageId == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("only the response to a request can be regenerated")
+	}
+	if msg.Queries == nil || len(*msg.Queries) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("message has no query to regenerate")
+	}
+
+	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch LLM message: %v", err)
+	}
+	previousResponse, ok := llmMsg.Content["assistant_response"].(map[string]interface{})
+	if !ok {
+		return nil, http.StatusInternalServerError, fmt.Errorf("the previous response of the message can't be read")
+	}
+	log.Printf("ChatService -> RegenerateQuery -> Regenerating the queries of messageID: %s, userMessageID: %s", messageID, msg.UserMessageId.Hex())
+
+	ctx = withAlternativeQuery(ctx, previousResponse)
+	userMessageID := msg.UserMessageId.Hex()
+	if chat.Settings.AutoExecuteQuery && !chat.Settings.GenerateOnly {
+		if err := s.processLLMResponseAndRunQuery(ctx, userID, chatID, userMessageID, req.StreamID); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to regenerate the query: %v", err)
+		}
+	} else if err := s.processMessage(ctx, userID, chatID, userMessageID, req.StreamID); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to regenerate the query: %v", err)
+	}
+	return s.buildMessageResponse(msg), http.StatusOK, nil
+}
+
+// generateAlternativeLLMResponse asks the LLM for another approach than its previous response to the latest request of
+// messages, see constants.AlternativeQueryPrompt
+func (s *chatService) generateAlternativeLLMResponse(ctx context.Context, chatObjID, userObjID primitive.ObjectID, messages []*models.LLMMessage, previousResponse map[string]interface{}, anonymizer *dbmanager.SchemaAnonymizer, dbType string) (string, error) {
+	prompt := constants.AlternativeQueryPrompt
+	if anonymizer != nil {
+		previousResponse = anonymizer.AnonymizeValue(previousResponse).(map[string]interface{})
+		prompt = anonymizer.AnonymizeText(prompt)
+	}
+	alternativeMessages := append(messages[:len(messages):len(messages)],
+		&models.LLMMessage{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeAssistant),
+			Content: map[string]interface{}{"assistant_response": previousResponse},
+		},
+		&models.LLMMessage{
+			ChatID:  chatObjID,
+			UserID:  userObjID,
+			Role:    string(constants.MessageTypeUser),
+			Content: map[string]interface{}{"user_message": prompt},
+		},
+	)
+	return s.llmClient.GenerateResponse(ctx, alternativeMessages, dbType)
+}
+
+// regenerateQueryButton offers to regenerate the queries of a response with another approach, nil when it has none
+func regenerateQueryButton(queries []models.Query) *models.ActionButton {
+	for i := range queries {
+		if queries[i].Query != "" {
+			return &models.ActionButton{
+				ID:     primitive.NewObjectID(),
+				Label:  "Try a Different Approach",
+				Action: "regenerate_query",
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/llm"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExplainResult asks the LLM for a plain English summary & the notable insights of the result of an executed read
+// query, added to the chat as a new assistant message. Only the query, its explanation & its result are sent, not the
+// conversation, and the LLM isn't asked for queries. The rows are shared if the chat shares data with AI, only the
+// shape of the result is otherwise.
+func (s *chatService) ExplainResult(ctx context.Context, userID, chatID string, req *dtos.ExplainResultRequest) (*dtos.MessageResponse, uint32, error) {
+	chat, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if chat == nil || chat.UserID.Hex() != userID {
+		return nil, http.StatusForbidden, fmt.Errorf("unauthorized access to chat")
+	}
+	if !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only the result of a read query can be explained")
+	}
+	if !query.IsExecuted || query.Error != nil || query.ExecutionResult == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("run the query successfully before explaining its result")
+	}
+
+	prompt := fmt.Sprintf(constants.ResultExplanationPrompt, query.Query, msg.Content, s.explainedResult(chat, query))
+	messages := []*models.LLMMessage{
+		{
+			ChatID: chat.ID,
+			UserID: chat.UserID,
+			Role:   string(constants.MessageTypeUser),
+			Content: map[string]interface{}{
+				"user_message": prompt,
+			},
+		},
+	}
+	messages, anonymizer, err := s.anonymizeLLMMessages(ctx, chat, messages)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to anonymize the prompt: %v", err)
+	}
+
+	ctx = llm.WithTenant(llm.WithPageSize(ctx, pageSizeOf(chat)), s.tenantOf(userID))
+	response, err := s.llmClient.GenerateResponse(ctx, messages, chat.Connection.Type)
+	if err != nil {
+		log.Printf("ChatService -> ExplainResult -> Error generating the explanation of queryID %s: %v", req.QueryID, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to explain the result: %v", err)
+	}
+
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to parse the explanation: %v", err)
+	}
+	explanation, _ := jsonResponse["assistantMessage"].(string)
+	if strings.TrimSpace(explanation) == "" {
+		return nil, http.StatusInternalServerError, fmt.Errorf("the result could not be explained")
+	}
+	if anonymizer != nil {
+		explanation = anonymizer.DeanonymizeText(explanation)
+	}
+
+	explanationMsg := &models.Message{
+		Base:    models.NewBase(),
+		UserID:  chat.UserID,
+		ChatID:  chat.ID,
+		Content: explanation,
+		Type:    string(constants.MessageTypeAssistant),
+	}
+	if err := s.chatRepo.CreateMessage(explanationMsg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save message: %v", err)
+	}
+
+	// The explanation is part of the conversation, later requests can refer to it
+	llmMsg := &models.LLMMessage{
+		Base:      models.NewBase(),
+		UserID:    chat.UserID,
+		ChatID:    chat.ID,
+		MessageID: explanationMsg.ID,
+		Role:      string(constants.MessageTypeAssistant),
+		Content: map[string]interface{}{
+			"assistant_response": map[string]interface{}{
+				"assistantMessage": explanation,
+				"queries":          []interface{}{},
+			},
+		},
+	}
+	if err := s.llmRepo.CreateMessage(llmMsg); err != nil {
+		log.Printf("ChatService -> ExplainResult -> Error saving LLM message: %v", err)
+	}
+
+	log.Printf("ChatService -> ExplainResult -> Explained the result of queryID %s in messageID %s", req.QueryID, explanationMsg.ID.Hex())
+	return s.buildMessageResponse(explanationMsg), http.StatusOK, nil
+}
+
+// explainedResult is the result of a query as sent to the LLM to explain it, the masked & shrunk rows if the chat
+// shares data with AI, its row count & columns otherwise
+func (s *chatService) explainedResult(chat *models.Chat, query *models.Query) string {
+	if chat.Settings.ShareDataWithAI {
+		return s.sharedResult(chat, *query.ExecutionResult)
+	}
+
+	records, err := extractResultRecords(*query.ExecutionResult)
+	if err != nil || len(records) == 0 {
+		return "The values are not shared, the result has no rows."
+	}
+	rowCount := len(records)
+	if query.Pagination != nil && query.Pagination.TotalRecordsCount != nil {
+		rowCount = *query.Pagination.TotalRecordsCount
+	}
+	columns := make([]string, 0, len(records[0]))
+	for column := range records[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return fmt.Sprintf("The values are not shared, only the shape of the result: %d row(s) with the columns %s.", rowCount, strings.Join(columns, ", "))
+}
+
+// setExplainResultButton offers to explain the result of a read query that returned rows, or removes the button of the
+// query once it no longer has such a result
+func (s *chatService) setExplainResultButton(msg *models.Message, queryID string, explainable bool) {
+	var buttons []models.ActionButton
+	if msg.ActionButtons != nil {
+		for _, button := range *msg.ActionButtons {
+			if button.Action == "explain_result" && button.Payload[constants.ActionPayloadQueryID] == queryID {
+				if explainable {
+					return
+				}
+				continue
+			}
+			buttons = append(buttons, button)
+		}
+	}
+
+	if explainable {
+		buttons = append(buttons, models.ActionButton{
+			ID:      primitive.NewObjectID(),
+			Label:   "Explain Result",
+			Action:  "explain_result",
+			Payload: map[string]interface{}{constants.ActionPayloadQueryID: queryID},
+		})
+	} else if msg.ActionButtons == nil || len(buttons) == len(*msg.ActionButtons) {
+		return
+	}
+	msg.ActionButtons = &buttons
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+var parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CreateSavedQuery saves a query of the user with {{name}} placeholders, every placeholder of the query, its paginated
+// & count queries must be a declared parameter
+func (s *chatService) CreateSavedQuery(userID string, req *dtos.CreateSavedQueryRequest) (*dtos.SavedQueryResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	parameters := make([]models.ParameterRequest, len(req.Parameters))
+	declared := make(map[string]bool, len(req.Parameters))
+	for i, parameter := range req.Parameters {
+		if !parameterNamePattern.MatchString(parameter.Name) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid parameter name %q, use letters, digits & underscores", parameter.Name)
+		}
+		if declared[parameter.Name] {
+			return nil, http.StatusBadRequest, fmt.Errorf("duplicate parameter %s", parameter.Name)
+		}
+		declared[parameter.Name] = true
+		label := parameter.Label
+		if label == "" {
+			label = parameter.Name
+		}
+		parameters[i] = models.ParameterRequest{
+			Name:        parameter.Name,
+			Label:       label,
+			Type:        parameter.Type,
+			Description: parameter.Description,
+		}
+	}
+
+	queries := []string{req.Query}
+	var pagination *models.Pagination
+	if req.PaginatedQuery != nil && strings.TrimSpace(*req.PaginatedQuery) != "" {
+		paginatedQuery := *req.PaginatedQuery
+		if req.ConnectionType != nil {
+			if paginatedQuery, err = dbmanager.FixPaginatedQuery(*req.ConnectionType, paginatedQuery); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+		}
+		pagination = &models.Pagination{PaginatedQuery: &paginatedQuery}
+		queries = append(queries, paginatedQuery)
+	}
+	if req.CountQuery != nil && strings.TrimSpace(*req.CountQuery) != "" {
+		if pagination == nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("a count query requires a paginated query")
+		}
+		pagination.CountQuery = req.CountQuery
+		queries = append(queries, *req.CountQuery)
+	}
+	for _, query := range queries {
+		for _, match := range parameterPlaceholderPattern.FindAllStringSubmatch(query, -1) {
+			if !declared[match[1]] {
+				return nil, http.StatusBadRequest, fmt.Errorf("the placeholder {{%s}} isn't a declared parameter", match[1])
+			}
+		}
+	}
+
+	// The type is derived from the query text on each execution, a saved query may run on chats of any type
+	savedQuery := models.NewSavedQuery(userObjID, strings.TrimSpace(req.Name), req.Description, req.Query, pagination, parameters, req.ConnectionType)
+	if err := s.savedQueryRepo.Create(savedQuery); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save the query: %v", err)
+	}
+	log.Printf("ChatService -> CreateSavedQuery -> Saved query %s for userID: %s", savedQuery.ID.Hex(), userID)
+
+	response := dtos.ToSavedQueryDto(savedQuery)
+	return &response, http.StatusCreated, nil
+}
+
+// ListSavedQueries returns the saved queries of the user, only the ones a chat of the connection type can run if given
+func (s *chatService) ListSavedQueries(userID, connectionType string) ([]dtos.SavedQueryResponse, uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+
+	savedQueries, err := s.savedQueryRepo.FindByUserID(userObjID, connectionType)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch saved queries: %v", err)
+	}
+
+	responses := make([]dtos.SavedQueryResponse, len(savedQueries))
+	for i, savedQuery := range savedQueries {
+		responses[i] = dtos.ToSavedQueryDto(savedQuery)
+	}
+	return responses, http.StatusOK, nil
+}
+
+func (s *chatService) DeleteSavedQuery(userID, savedQueryID string) (uint32, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid user ID format")
+	}
+	savedQueryObjID, err := primitive.ObjectIDFromHex(savedQueryID)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid saved query ID format")
+	}
+
+	deleted, err := s.savedQueryRepo.Delete(userObjID, savedQueryObjID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete the saved query: %v", err)
+	}
+	if !deleted {
+		return http.StatusNotFound, fmt.Errorf("saved query not found")
+	}
+	return http.StatusOK, nil
+}
+
+// ExecuteSavedQuery runs a saved query in a chat with the supplied parameter values. The values are validated against
+// their type & rendered as literals of the chat's database, then the query is added to the chat in a message of its
+// own & executed as a generated query would be: the same checks apply & its results are paginated & counted. A query
+// changing data is critical, it is only added to the chat & the user confirms its execution there.
+func (s *chatService) ExecuteSavedQuery(ctx context.Context, userID, chatID, savedQueryID string, req *dtos.ExecuteSavedQueryRequest) (*dtos.ExecuteSavedQueryResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	savedQueryObjID, err := primitive.ObjectIDFromHex(savedQueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid saved query ID format")
+	}
+	savedQuery, err := s.savedQueryRepo.FindByID(chat.UserID, savedQueryObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch the saved query: %v", err)
+	}
+	if savedQuery == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("saved query not found")
+	}
+	if savedQuery.ConnectionType != nil && *savedQuery.ConnectionType != chat.Connection.Type {
+		return nil, http.StatusBadRequest, fmt.Errorf("this saved query runs on %s chats, not on %s", *savedQuery.ConnectionType, chat.Connection.Type)
+	}
+
+	literals := make(map[string]string, len(savedQuery.Parameters))
+	parameters := make([]models.ParameterRequest, len(savedQuery.Parameters))
+	for i, parameter := range savedQuery.Parameters {
+		value, ok := req.Values[parameter.Name]
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("missing value for %s", parameter.Label)
+		}
+		literal, err := renderParameterLiteral(chat.Connection.Type, parameter.Type, value)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid value for %s: %v", parameter.Label, err)
+		}
+		literals[parameter.Name] = literal
+		value = strings.TrimSpace(value)
+		parameters[i] = parameter
+		parameters[i].Value = &value
+	}
+
+	queryText := fillParameterPlaceholders(savedQuery.Query, literals)
+	queryType, isRead := savedQueryType(chat.Connection.Type, queryText)
+	query := models.Query{
+		ID:          primitive.NewObjectID(),
+		Query:       queryText,
+		QueryType:   &queryType,
+		IsCritical:  !isRead,
+		Description: savedQuery.Name,
+		GeneratedAt: utils.ToStringPtr(time.Now().Format(time.RFC3339)),
+	}
+	if tables := dbmanager.ReferencedTables(chat.Connection.Type, query.Query); len(tables) > 0 {
+		query.Tables = utils.ToStringPtr(strings.Join(tables, ","))
+	}
+	if savedQuery.Pagination != nil && savedQuery.Pagination.PaginatedQuery != nil {
+		// A paginated query whose offset can't be substituted is dropped, the query itself is executed instead
+		paginatedQuery, err := dbmanager.FixPaginatedQuery(chat.Connection.Type, fillParameterPlaceholders(*savedQuery.Pagination.PaginatedQuery, literals))
+		if err != nil {
+			log.Printf("ChatService -> ExecuteSavedQuery -> Dropping the paginated query of saved query %s: %v", savedQueryID, err)
+		} else {
+			query.Pagination = &models.Pagination{PaginatedQuery: &paginatedQuery}
+			if savedQuery.Pagination.CountQuery != nil {
+				countQuery := fillParameterPlaceholders(*savedQuery.Pagination.CountQuery, literals)
+				query.Pagination.CountQuery = &countQuery
+			}
+		}
+	}
+	queries := []models.Query{query}
+	s.scoreQueriesComplexity(ctx, chat, chatID, queries)
+
+	msg := &models.Message{
+		Base:              models.NewBase(),
+		UserID:            chat.UserID,
+		ChatID:            chat.ID,
+		Content:           fmt.Sprintf("Saved query: %s", savedQuery.Name),
+		Type:              string(constants.MessageTypeAssistant),
+		Queries:           &queries,
+		ParameterRequests: &parameters,
+	}
+	if err := s.chatRepo.CreateMessage(msg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save message: %v", err)
+	}
+	log.Printf("ChatService -> ExecuteSavedQuery -> Added saved query %s to chatID: %s in messageID: %s", savedQueryID, chatID, msg.ID.Hex())
+
+	if query.IsCritical {
+		return &dtos.ExecuteSavedQueryResponse{Message: s.buildMessageResponse(msg)}, http.StatusOK, nil
+	}
+
+	execution, statusCode, err := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
+		MessageID: msg.ID.Hex(),
+		QueryID:   query.ID.Hex(),
+		StreamID:  req.StreamID,
+	})
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// The execution stores its result on the message
+	if updated, err := s.chatRepo.FindMessageByID(msg.ID); err == nil {
+		msg = updated
+	}
+	return &dtos.ExecuteSavedQueryResponse{
+		Message:   s.buildMessageResponse(msg),
+		Execution: execution,
+	}, statusCode, nil
+}
+
+// savedQueryType derives the type of a saved query from its text rather than from what the user saved it as, & whether
+// it only reads data: reads are typed SELECT (FIND for MongoDB), writes by their statement or operation
+func savedQueryType(dbType, query string) (string, bool) {
+	if dbmanager.IsReadOnlyQuery(dbType, query) {
+		if dbType == constants.DatabaseTypeMongoDB {
+			return "FIND", true
+		}
+		return "SELECT", true
+	}
+	query = strings.TrimSpace(query)
+	if dbType == constants.DatabaseTypeMongoDB {
+		// db.collection.operation(...)
+		if openParenIndex := strings.Index(query, "("); openParenIndex != -1 {
+			query = query[:openParenIndex]
+		}
+		return strings.ToUpper(query[strings.LastIndex(query, ".")+1:]), false
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(strings.TrimRight(fields[0], ";")), false
+	}
+	return "UNKNOWN", false
+}
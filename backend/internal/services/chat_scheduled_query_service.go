@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// scheduledQueryCheckInterval is how often the scheduler looks for the scheduled queries due to run
+const scheduledQueryCheckInterval = 30 * time.Second
+
+// nextScheduledRun returns the next run of a cron expression read in a timezone, after the given time
+func nextScheduledRun(cronExpression, timezone string, after time.Time) (*time.Time, error) {
+	schedule, err := utils.ParseCron(cronExpression)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", timezone)
+	}
+	next := schedule.Next(after.In(location))
+	if next.IsZero() {
+		return nil, fmt.Errorf("the cron expression %q never runs", cronExpression)
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// CreateScheduledQuery schedules a read query of the chat, it is executed on the cron expression from then on
+func (s *chatService) CreateScheduledQuery(userID, chatID string, req *dtos.CreateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	_, _, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	// A schedule runs unattended, it must not change the data
+	if !isReadQuery(chat, query) {
+		return nil, http.StatusBadRequest, fmt.Errorf("only read queries can be scheduled")
+	}
+	if refusal := readOnlyQueryError(chat, query.Query); refusal != nil {
+		return nil, http.StatusForbidden, fmt.Errorf("%s", refusal.Message)
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	nextRunAt, err := nextScheduledRun(req.CronExpression, timezone, time.Now())
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := validateWebhookURL(req.WebhookURL); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	var pagination *models.Pagination
+	if query.Pagination != nil && query.Pagination.PaginatedQuery != nil {
+		pagination = &models.Pagination{
+			PaginatedQuery: query.Pagination.PaginatedQuery,
+			CountQuery:     query.Pagination.CountQuery,
+		}
+	}
+	scheduledQuery := models.NewScheduledQuery(chat.UserID, chat.ID, strings.TrimSpace(req.Name), query.Query, query.QueryType, pagination, strings.TrimSpace(req.CronExpression), timezone, req.WebhookURL, enabled)
+	if enabled {
+		scheduledQuery.NextRunAt = nextRunAt
+	}
+	if err := s.scheduledQueryRepo.Create(scheduledQuery); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to schedule the query: %v", err)
+	}
+	log.Printf("ChatService -> CreateScheduledQuery -> Scheduled query %s for chatID: %s (%s %s)", scheduledQuery.ID.Hex(), chatID, scheduledQuery.CronExpression, timezone)
+
+	response := dtos.ToScheduledQueryDto(scheduledQuery, false)
+	return &response, http.StatusCreated, nil
+}
+
+// ListScheduledQueries returns the scheduled queries of the chat by name
+func (s *chatService) ListScheduledQueries(userID, chatID string) ([]dtos.ScheduledQueryResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	scheduledQueries, err := s.scheduledQueryRepo.FindByChatID(chat.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch scheduled queries: %v", err)
+	}
+
+	responses := make([]dtos.ScheduledQueryResponse, len(scheduledQueries))
+	for i, scheduledQuery := range scheduledQueries {
+		responses[i] = dtos.ToScheduledQueryDto(scheduledQuery, s.isScheduledQueryRunning(scheduledQuery.ID.Hex()))
+	}
+	return responses, http.StatusOK, nil
+}
+
+// UpdateScheduledQuery changes the name, schedule or webhook of a scheduled query, or enables or disables it. The next
+// run is computed again from now when the schedule changes or the query is enabled.
+func (s *chatService) UpdateScheduledQuery(userID, chatID, scheduledQueryID string, req *dtos.UpdateScheduledQueryRequest) (*dtos.ScheduledQueryResponse, uint32, error) {
+	scheduledQuery, statusCode, err := s.getOwnedScheduledQuery(userID, chatID, scheduledQueryID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("name is required")
+		}
+		scheduledQuery.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.CronExpression != nil {
+		scheduledQuery.CronExpression = strings.TrimSpace(*req.CronExpression)
+	}
+	if req.Timezone != nil {
+		scheduledQuery.Timezone = strings.TrimSpace(*req.Timezone)
+		if scheduledQuery.Timezone == "" {
+			scheduledQuery.Timezone = "UTC"
+		}
+	}
+	if req.WebhookURL != nil {
+		if err := validateWebhookURL(*req.WebhookURL); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		scheduledQuery.WebhookURL = *req.WebhookURL
+	}
+	wasEnabled := scheduledQuery.Enabled
+	if req.Enabled != nil {
+		scheduledQuery.Enabled = *req.Enabled
+	}
+
+	nextRunAt, err := nextScheduledRun(scheduledQuery.CronExpression, scheduledQuery.Timezone, time.Now())
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if !scheduledQuery.Enabled {
+		scheduledQuery.NextRunAt = nil
+	} else if !wasEnabled || req.CronExpression != nil || req.Timezone != nil {
+		scheduledQuery.NextRunAt = nextRunAt
+	}
+
+	if err := s.scheduledQueryRepo.Update(scheduledQuery); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update the scheduled query: %v", err)
+	}
+	log.Printf("ChatService -> UpdateScheduledQuery -> Updated scheduled query %s, enabled: %v", scheduledQueryID, scheduledQuery.Enabled)
+
+	response := dtos.ToScheduledQueryDto(scheduledQuery, s.isScheduledQueryRunning(scheduledQueryID))
+	return &response, http.StatusOK, nil
+}
+
+// DeleteScheduledQuery removes a scheduled query, the messages of its past runs are kept. A run in progress completes.
+func (s *chatService) DeleteScheduledQuery(userID, chatID, scheduledQueryID string) (uint32, error) {
+	scheduledQuery, statusCode, err := s.getOwnedScheduledQuery(userID, chatID, scheduledQueryID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	deleted, err := s.scheduledQueryRepo.Delete(scheduledQuery.ChatID, scheduledQuery.ID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete the scheduled query: %v", err)
+	}
+	if !deleted {
+		return http.StatusNotFound, fmt.Errorf("scheduled query not found")
+	}
+	return http.StatusOK, nil
+}
+
+// getOwnedScheduledQuery fetches a scheduled query of a chat of the user
+func (s *chatService) getOwnedScheduledQuery(userID, chatID, scheduledQueryID string) (*models.ScheduledQuery, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	scheduledQueryObjID, err := primitive.ObjectIDFromHex(scheduledQueryID)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid scheduled query ID format")
+	}
+	scheduledQuery, err := s.scheduledQueryRepo.FindByID(chat.ID, scheduledQueryObjID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch the scheduled query: %v", err)
+	}
+	if scheduledQuery == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("scheduled query not found")
+	}
+	return scheduledQuery, http.StatusOK, nil
+}
+
+// StartQueryScheduler starts the goroutine running the scheduled queries when they are due
+func (s *chatService) StartQueryScheduler() {
+	go func() {
+		ticker := time.NewTicker(scheduledQueryCheckInterval)
+		defer ticker.Stop()
+
+		log.Printf("ChatService -> StartQueryScheduler -> Checking the scheduled queries every %v", scheduledQueryCheckInterval)
+		for range ticker.C {
+			s.runDueScheduledQueries()
+		}
+	}()
+}
+
+// runDueScheduledQueries starts the runs of the scheduled queries that are due. The next run of each is set before it
+// starts, so that a run is only started once. A query still running from its previous run isn't started again, its
+// due run is skipped rather than queued so that slow runs don't pile up.
+func (s *chatService) runDueScheduledQueries() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ChatService -> runDueScheduledQueries -> Panic recovered: %v", r)
+		}
+	}()
+
+	now := time.Now()
+	scheduledQueries, err := s.scheduledQueryRepo.FindDue(now)
+	if err != nil {
+		log.Printf("ChatService -> runDueScheduledQueries -> Error fetching the due scheduled queries: %v", err)
+		return
+	}
+
+	for _, scheduledQuery := range scheduledQueries {
+		nextRunAt, err := nextScheduledRun(scheduledQuery.CronExpression, scheduledQuery.Timezone, now)
+		if err != nil {
+			// The expression was valid when saved, a timezone may have been dropped from the system database
+			log.Printf("ChatService -> runDueScheduledQueries -> Disabling scheduled query %s: %v", scheduledQuery.ID.Hex(), err)
+			scheduledQuery.Enabled = false
+			scheduledQuery.NextRunAt = nil
+			scheduledQuery.LastRunError = utils.ToStringPtr(err.Error())
+		} else {
+			scheduledQuery.NextRunAt = nextRunAt
+		}
+		if err := s.scheduledQueryRepo.Update(scheduledQuery); err != nil {
+			log.Printf("ChatService -> runDueScheduledQueries -> Error updating scheduled query %s: %v", scheduledQuery.ID.Hex(), err)
+			continue
+		}
+		if !scheduledQuery.Enabled {
+			continue
+		}
+
+		if !s.markScheduledQueryRunning(scheduledQuery.ID.Hex()) {
+			log.Printf("ChatService -> runDueScheduledQueries -> Skipping scheduled query %s, its previous run is still executing", scheduledQuery.ID.Hex())
+			continue
+		}
+		go func(scheduledQuery *models.ScheduledQuery) {
+			defer s.unmarkScheduledQueryRunning(scheduledQuery.ID.Hex())
+			s.runScheduledQuery(scheduledQuery)
+		}(scheduledQuery)
+	}
+}
+
+// markScheduledQueryRunning marks a scheduled query as running, false is returned if it already is
+func (s *chatService) markScheduledQueryRunning(scheduledQueryID string) bool {
+	s.schedulesMu.Lock()
+	defer s.schedulesMu.Unlock()
+	if s.runningSchedules[scheduledQueryID] {
+		return false
+	}
+	s.runningSchedules[scheduledQueryID] = true
+	return true
+}
+
+func (s *chatService) unmarkScheduledQueryRunning(scheduledQueryID string) {
+	s.schedulesMu.Lock()
+	defer s.schedulesMu.Unlock()
+	delete(s.runningSchedules, scheduledQueryID)
+}
+
+func (s *chatService) isScheduledQueryRunning(scheduledQueryID string) bool {
+	s.schedulesMu.Lock()
+	defer s.schedulesMu.Unlock()
+	return s.runningSchedules[scheduledQueryID]
+}
+
+// runScheduledQuery adds the scheduled query to its chat in a message of its own & executes it like a generated query,
+// the execution stores the result on the message. The outcome is saved on the scheduled query & posted to its webhook.
+func (s *chatService) runScheduledQuery(scheduledQuery *models.ScheduledQuery) {
+	ctx := context.Background()
+	userID := scheduledQuery.UserID.Hex()
+	chatID := scheduledQuery.ChatID.Hex()
+	ranAt := time.Now()
+	log.Printf("ChatService -> runScheduledQuery -> Running scheduled query %s in chatID: %s", scheduledQuery.ID.Hex(), chatID)
+
+	execution, msgID, runErr := s.executeScheduledQuery(ctx, userID, chatID, scheduledQuery)
+	if runErr == nil && execution != nil && execution.Error != nil {
+		runErr = fmt.Errorf("%s", execution.Error.Message)
+	}
+
+	scheduledQuery.LastRunAt = &ranAt
+	scheduledQuery.LastRunError = nil
+	if runErr != nil {
+		log.Printf("ChatService -> runScheduledQuery -> Scheduled query %s failed: %v", scheduledQuery.ID.Hex(), runErr)
+		scheduledQuery.LastRunError = utils.ToStringPtr(runErr.Error())
+	}
+	if msgID != nil {
+		scheduledQuery.LastMessageID = msgID
+	}
+	// Only the outcome of the run is saved, the schedule may have been changed meanwhile
+	if current, err := s.scheduledQueryRepo.FindByID(scheduledQuery.ChatID, scheduledQuery.ID); err == nil && current != nil {
+		current.LastRunAt = scheduledQuery.LastRunAt
+		current.LastRunError = scheduledQuery.LastRunError
+		current.LastMessageID = scheduledQuery.LastMessageID
+		if err := s.scheduledQueryRepo.Update(current); err != nil {
+			log.Printf("ChatService -> runScheduledQuery -> Error saving the run of scheduled query %s: %v", scheduledQuery.ID.Hex(), err)
+		}
+	}
+
+	if scheduledQuery.WebhookURL == "" {
+		return
+	}
+	payload := dtos.ScheduledQueryWebhookPayload{
+		ScheduledQueryID: scheduledQuery.ID.Hex(),
+		ChatID:           chatID,
+		Name:             scheduledQuery.Name,
+		Status:           "completed",
+		RanAt:            ranAt,
+		Execution:        execution,
+	}
+	if msgID != nil {
+		payload.MessageID = msgID.Hex()
+	}
+	if runErr != nil {
+		payload.Status = "failed"
+		payload.Error = runErr.Error()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("ChatService -> runScheduledQuery -> Error marshaling the webhook payload: %v", err)
+		return
+	}
+	if err := deliverWebhook(scheduledQuery.WebhookURL, body); err != nil {
+		log.Printf("ChatService -> runScheduledQuery -> Giving up on the webhook of scheduled query %s: %v", scheduledQuery.ID.Hex(), err)
+	}
+}
+
+// executeScheduledQuery adds the query to the chat & executes it, the ID of the message is returned once it is added
+func (s *chatService) executeScheduledQuery(ctx context.Context, userID, chatID string, scheduledQuery *models.ScheduledQuery) (*dtos.QueryExecutionResponse, *primitive.ObjectID, error) {
+	chat, _, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := models.Query{
+		ID:          primitive.NewObjectID(),
+		Query:       scheduledQuery.Query,
+		QueryType:   scheduledQuery.QueryType,
+		Description: scheduledQuery.Name,
+		GeneratedAt: utils.ToStringPtr(time.Now().Format(time.RFC3339)),
+	}
+	if tables := dbmanager.ReferencedTables(chat.Connection.Type, query.Query); len(tables) > 0 {
+		query.Tables = utils.ToStringPtr(strings.Join(tables, ","))
+	}
+	if scheduledQuery.Pagination != nil {
+		query.Pagination = &models.Pagination{
+			PaginatedQuery: scheduledQuery.Pagination.PaginatedQuery,
+			CountQuery:     scheduledQuery.Pagination.CountQuery,
+		}
+	}
+	queries := []models.Query{query}
+	s.scoreQueriesComplexity(ctx, chat, chatID, queries)
+
+	msg := &models.Message{
+		Base:    models.NewBase(),
+		UserID:  chat.UserID,
+		ChatID:  chat.ID,
+		Content: fmt.Sprintf("Scheduled query: %s", scheduledQuery.Name),
+		Type:    string(constants.MessageTypeAssistant),
+		Queries: &queries,
+	}
+	if err := s.chatRepo.CreateMessage(msg); err != nil {
+		return nil, nil, fmt.Errorf("failed to save message: %v", err)
+	}
+
+	execution, _, err := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
+		MessageID: msg.ID.Hex(),
+		QueryID:   query.ID.Hex(),
+		StreamID:  "scheduled-" + scheduledQuery.ID.Hex(), // Nobody listens, the stream events are dropped
+	})
+	return execution, &msg.ID, err
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// latestSchemaUpdate returns the index & the text of the latest schema shared with the LLM, -1 if there is none
+func latestSchemaUpdate(messages []*models.LLMMessage) (int, string) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if schema, ok := messages[i].Content["schema_update"].(string); ok {
+			return i, schema
+		}
+	}
+	return -1, ""
+}
+
+// withSchemaUpdate replaces the schema shared with the LLM by schema, the earlier schema updates are dropped
+func withSchemaUpdate(messages []*models.LLMMessage, index int, schema string) []*models.LLMMessage {
+	scoped := make([]*models.LLMMessage, 0, len(messages))
+	for i, msg := range messages {
+		if _, isSchemaUpdate := msg.Content["schema_update"]; isSchemaUpdate && i != index {
+			continue
+		}
+		if i == index {
+			copied := *msg
+			copied.Content = map[string]interface{}{"schema_update": schema}
+			msg = &copied
+		}
+		scoped = append(scoped, msg)
+	}
+	return scoped
+}
+
+// parseScopedTables reads the tables listed by the LLM for a part of the schema, only the tables of the part are kept
+func parseScopedTables(assistantMessage string, chunk dbmanager.SchemaChunk) []string {
+	known := make(map[string]string, len(chunk.Tables))
+	for _, table := range chunk.Tables {
+		known[strings.ToLower(table)] = table
+	}
+	var tables []string
+	for _, name := range strings.FieldsFunc(assistantMessage, func(r rune) bool { return r == ',' || r == '\n' }) {
+		name = strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "- ")), "`\"'.")
+		if table, ok := known[strings.ToLower(name)]; ok {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// generateScopedLLMResponse generates the response of the messages, a schema larger than SCHEMA_CHUNK_MAX_CHARS is
+// split into parts when SCHEMA_CHUNKING is on: an LLM call per part finds the tables the request needs, then the
+// response is generated from these tables only. The messages the response was generated from are returned, so that
+// a regeneration is scoped the same way. A schema needing more than SCHEMA_CHUNK_MAX_CALLS parts is sent whole. Only
+// the final response is streamed to onMessage, see generateLLMResponse.
+func (s *chatService) generateScopedLLMResponse(ctx context.Context, chat *models.Chat, chatID string, messages []*models.LLMMessage, dbType string, step, onMessage func(string)) (string, []*models.LLMMessage, error) {
+	if !config.Env.SchemaChunking {
+		response, err := s.generateLLMResponse(ctx, chat, chatID, messages, dbType, onMessage)
+		return response, messages, err
+	}
+	schemaIndex, schema := latestSchemaUpdate(messages)
+	if schemaIndex == -1 || len(schema) <= config.Env.SchemaChunkMaxChars {
+		response, err := s.generateLLMResponse(ctx, chat, chatID, messages, dbType, onMessage)
+		return response, messages, err
+	}
+
+	chunks := dbmanager.SplitSchemaForLLM(schema, config.Env.SchemaChunkMaxChars)
+	if len(chunks) < 2 || len(chunks) > config.Env.SchemaChunkMaxCalls {
+		log.Printf("ChatService -> generateScopedLLMResponse -> The schema of chatID %s (%d characters) splits into %d parts, max %d, sending it whole", chatID, len(schema), len(chunks), config.Env.SchemaChunkMaxCalls)
+		response, err := s.generateLLMResponse(ctx, chat, chatID, messages, dbType, onMessage)
+		return response, messages, err
+	}
+
+	var tables []string
+	for i, chunk := range chunks {
+		if ctx.Err() != nil {
+			return "", messages, ctx.Err()
+		}
+		step(fmt.Sprintf("The schema is large, looking for the tables needed in part %d of %d..", i+1, len(chunks)))
+		chunkMessages := append(withSchemaUpdate(messages, schemaIndex, chunk.Text), &models.LLMMessage{
+			ChatID:  messages[schemaIndex].ChatID,
+			UserID:  messages[schemaIndex].UserID,
+			Role:    string(constants.MessageTypeUser),
+			Content: map[string]interface{}{"user_message": fmt.Sprintf(constants.SchemaChunkScopingPrompt, i+1, len(chunks))},
+		})
+		response, err := s.generateLLMResponse(ctx, chat, chatID, chunkMessages, dbType, nil)
+		if err != nil {
+			return "", messages, err
+		}
+		var jsonResponse map[string]interface{}
+		if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+			log.Printf("ChatService -> generateScopedLLMResponse -> Error parsing the tables of part %d: %v", i+1, err)
+			continue
+		}
+		assistantMessage, _ := jsonResponse["assistantMessage"].(string)
+		chunkTables := parseScopedTables(assistantMessage, chunk)
+		log.Printf("ChatService -> generateScopedLLMResponse -> Part %d of %d of chatID %s needs the tables: %v", i+1, len(chunks), chatID, chunkTables)
+		tables = append(tables, chunkTables...)
+	}
+
+	// Without any table, the response explains the request can't be answered from the listed tables
+	scopedMessages := withSchemaUpdate(messages, schemaIndex, dbmanager.ScopeSchemaForLLM(schema, tables))
+	step(fmt.Sprintf("Generating the query from the %d tables needed..", len(tables)))
+	response, err := s.generateLLMResponse(ctx, chat, chatID, scopedMessages, dbType, onMessage)
+	return response, scopedMessages, err
+}
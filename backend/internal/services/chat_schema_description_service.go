@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// UpdateSchemaDescriptions replaces the table & column descriptions written by the user, they are layered over the
+// fetched schema so the LLM context keeps them across schema refreshes
+func (s *chatService) UpdateSchemaDescriptions(ctx context.Context, userID, chatID string, req *dtos.UpdateSchemaDescriptionsRequest) ([]dtos.SchemaDescription, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	// A table or column described twice keeps its last description
+	descriptions := make([]models.SchemaDescription, 0, len(req.Descriptions))
+	indexes := make(map[string]int, len(req.Descriptions))
+	for _, description := range req.Descriptions {
+		schemaDescription := models.SchemaDescription{
+			Table:       strings.TrimSpace(description.Table),
+			Column:      strings.TrimSpace(description.Column),
+			Description: strings.TrimSpace(description.Description),
+		}
+		key := schemaDescription.Table + "\x00" + schemaDescription.Column
+		if index, exists := indexes[key]; exists {
+			descriptions[index] = schemaDescription
+			continue
+		}
+		indexes[key] = len(descriptions)
+		descriptions = append(descriptions, schemaDescription)
+	}
+
+	schemaManager := s.dbManager.GetSchemaManager()
+	dbDescriptions := make([]dbmanager.SchemaDescription, len(descriptions))
+	for i, description := range descriptions {
+		dbDescriptions[i] = dbmanager.SchemaDescription(description)
+	}
+	if err := schemaManager.ValidateSchemaDescriptions(ctx, chatID, dbDescriptions); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	chat.SchemaDescriptions = descriptions
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+	schemaManager.SetSchemaDescriptions(chatID, dbmanager.NewSchemaDescriptions(dbDescriptions))
+	log.Printf("ChatService -> UpdateSchemaDescriptions -> Stored %d schema descriptions for chatID: %s", len(descriptions), chatID)
+
+	// The schema shared with the LLM is rebuilt with the new descriptions
+	if !chat.Settings.GenerateOnly && s.dbManager.IsConnected(chatID) {
+		sourceIP := sourceIPOf(ctx)
+		go func() {
+			ctx, cancel := context.WithTimeout(WithSourceIP(context.Background(), sourceIP), 60*time.Minute)
+			defer cancel()
+			if _, err := s.RefreshSchema(ctx, userID, chatID, false, ""); err != nil {
+				log.Printf("ChatService -> UpdateSchemaDescriptions -> Error refreshing schema: %v", err)
+			}
+		}()
+	}
+
+	return buildSchemaDescriptionsResponse(descriptions), http.StatusOK, nil
+}
+
+// toDBSchemaDescriptions indexes the schema descriptions of a chat for the schema manager
+func toDBSchemaDescriptions(descriptions []models.SchemaDescription) dbmanager.SchemaDescriptions {
+	dbDescriptions := make([]dbmanager.SchemaDescription, len(descriptions))
+	for i, description := range descriptions {
+		dbDescriptions[i] = dbmanager.SchemaDescription(description)
+	}
+	return dbmanager.NewSchemaDescriptions(dbDescriptions)
+}
+
+func buildSchemaDescriptionsResponse(descriptions []models.SchemaDescription) []dtos.SchemaDescription {
+	if len(descriptions) == 0 {
+		return nil
+	}
+	response := make([]dtos.SchemaDescription, len(descriptions))
+	for i, description := range descriptions {
+		response[i] = dtos.SchemaDescription{
+			Table:       description.Table,
+			Column:      description.Column,
+			Description: description.Description,
+		}
+	}
+	return response
+}
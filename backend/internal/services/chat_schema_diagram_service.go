@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/pkg/dbmanager"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExportSchemaDiagram renders the stored schema of the chat as a Mermaid erDiagram or a Graphviz DOT graph, see
+// dbmanager.Manager.ExportSchemaDiagram. format defaults to Mermaid.
+func (s *chatService) ExportSchemaDiagram(ctx context.Context, userID, chatID, format string) (*dtos.SchemaDiagramResponse, uint32, error) {
+	if _, statusCode, err := s.getOwnedChat(userID, chatID); err != nil {
+		return nil, statusCode, err
+	}
+	if format == "" {
+		format = dbmanager.SchemaDiagramFormatMermaid
+	}
+	if !dbmanager.IsValidSchemaDiagramFormat(format) {
+		return nil, http.StatusBadRequest, errors.New("format must be mermaid or dot")
+	}
+
+	diagram, err := s.dbManager.ExportSchemaDiagram(ctx, chatID, format)
+	if errors.Is(err, dbmanager.ErrNoStoredSchema) {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		log.Printf("ChatService -> ExportSchemaDiagram -> Failed to render the diagram of chat %s: %v", chatID, err)
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return &dtos.SchemaDiagramResponse{
+		Format:  format,
+		Diagram: diagram,
+	}, http.StatusOK, nil
+}
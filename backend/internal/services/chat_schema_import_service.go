@@ -0,0 +1,42 @@
+package services
+
+import (
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+var errGenerateOnlyChat = fmt.Errorf("this chat only generates queries, disable generate only mode to connect to the database")
+
+// ImportSchema stores the schema a generate only chat generates queries from, it is sent to the LLM as is in place of
+// the schema fetched from a connection
+func (s *chatService) ImportSchema(userID, chatID string, req *dtos.ImportSchemaRequest) (*dtos.ChatResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if !chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, fmt.Errorf("only generate only chats use an imported schema, the schema of the other chats is fetched from the database")
+	}
+
+	schema := strings.TrimSpace(req.Schema)
+	if schema == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("schema cannot be empty")
+	}
+	if len(schema) > constants.MaxImportedSchemaLength {
+		return nil, http.StatusBadRequest, fmt.Errorf("schema is larger than %d KB, keep only the tables worth querying", constants.MaxImportedSchemaLength/1024)
+	}
+
+	chat.ImportedSchema = schema
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+
+	log.Printf("ChatService -> ImportSchema -> Stored a %d bytes schema for chatID: %s", len(schema), chatID)
+	return s.buildChatResponse(chat), http.StatusOK, nil
+}
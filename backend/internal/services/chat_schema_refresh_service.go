@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// requestsSchemaRefresh checks if an LLM response asks for a schema refresh, the prompts make the LLM add a
+// refresh_schema action button when the schema looks stale
+func requestsSchemaRefresh(jsonResponse map[string]interface{}) bool {
+	buttons, _ := jsonResponse["actionButtons"].([]interface{})
+	for _, btn := range buttons {
+		if button, ok := btn.(map[string]interface{}); ok && button["action"] == "refresh_schema" {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerateWithRefreshedSchema refreshes the schema synchronously & generates the response again with it. messages
+// are the LLM messages before anonymization, the returned ones & anonymizer replace those of the first generation.
+func (s *chatService) regenerateWithRefreshedSchema(ctx context.Context, userID, chatID string, chat *models.Chat, messages []*models.LLMMessage, dbType string) (map[string]interface{}, []*models.LLMMessage, *dbmanager.SchemaAnonymizer, error) {
+	if _, err := s.RefreshSchema(ctx, userID, chatID, true, ""); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The refresh stores the fresh schema as the system message of the chat
+	stored, err := s.llmRepo.GetByChatID(chat.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch the refreshed schema: %v", err)
+	}
+	var schemaMsg *models.LLMMessage
+	for _, msg := range stored {
+		if _, isSchemaUpdate := msg.Content["schema_update"]; isSchemaUpdate && msg.Role == string(constants.MessageTypeSystem) {
+			schemaMsg = msg
+		}
+	}
+	if schemaMsg == nil {
+		return nil, nil, nil, fmt.Errorf("the refreshed schema wasn't stored")
+	}
+
+	refreshed := make([]*models.LLMMessage, 0, len(messages)+1)
+	refreshed = append(refreshed, schemaMsg)
+	for _, msg := range messages {
+		if _, isSchemaUpdate := msg.Content["schema_update"]; isSchemaUpdate {
+			continue
+		}
+		refreshed = append(refreshed, msg)
+	}
+
+	// Anonymized chats get their schema from the refreshed cache, with tokens for the new tables
+	refreshed, anonymizer, err := s.anonymizeLLMMessages(ctx, chat, refreshed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	response, err := s.llmClient.GenerateResponse(ctx, refreshed, dbType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var jsonResponse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse the regenerated response: %v", err)
+	}
+	log.Printf("ChatService -> regenerateWithRefreshedSchema -> response: %s", response)
+	if anonymizer != nil {
+		jsonResponse = anonymizer.DeanonymizeValue(jsonResponse).(map[string]interface{})
+	}
+	return jsonResponse, refreshed, anonymizer, nil
+}
+
+// refreshStaleSchema refreshes the schema of a chat when a query failed on a table or a column that doesn't exist, the
+// usual sign of a schema that changed since it was stored. The schema is only refreshed when it is older than
+// StaleSchemaRetryMinutes so that a query referencing a table that really doesn't exist isn't retried in a loop. True
+// is returned once the schema is refreshed, the query is to be retried.
+func (s *chatService) refreshStaleSchema(ctx context.Context, userID, chatID, streamID string, chat *models.Chat, queryErr *dtos.QueryError) bool {
+	if config.Env.StaleSchemaRetryMinutes <= 0 || !dbmanager.IsMissingSchemaObjectError(chat.Connection.Type, queryErr) {
+		return false
+	}
+	if dbmanager.ConnectionName(ctx) != "" {
+		// Only the schema of the main connection is refreshed
+		return false
+	}
+	interval := time.Duration(config.Env.StaleSchemaRetryMinutes) * time.Minute
+	if updatedAt := s.dbManager.GetSchemaUpdatedAt(ctx, chatID); time.Since(updatedAt) < interval {
+		log.Printf("ChatService -> refreshStaleSchema -> The schema of chatID %s was refreshed at %s, not refreshing it again", chatID, updatedAt.Format(time.RFC3339))
+		return false
+	}
+
+	log.Printf("ChatService -> refreshStaleSchema -> Refreshing the schema of chatID %s after: %s", chatID, queryErr.Message)
+	s.sendStreamEvent(userID, chatID, streamID, dtos.StreamResponse{
+		Event: "ai-response-step",
+		Data:  "Schema looked outdated, refreshing and retrying..",
+	})
+	if _, err := s.RefreshSchema(ctx, userID, chatID, true, ""); err != nil {
+		log.Printf("ChatService -> refreshStaleSchema -> Error refreshing the schema: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/pkg/dbmanager"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// GetSchemaStats returns the aggregates of the stored schema of the chat, see dbmanager.Manager.GetSchemaStats. A chat
+// whose schema was never fetched gets empty stats with HasSchema false.
+func (s *chatService) GetSchemaStats(ctx context.Context, userID, chatID string) (*dtos.SchemaStatsResponse, uint32, error) {
+	if _, statusCode, err := s.getOwnedChat(userID, chatID); err != nil {
+		return nil, statusCode, err
+	}
+
+	stats, err := s.dbManager.GetSchemaStats(ctx, chatID)
+	if errors.Is(err, dbmanager.ErrNoStoredSchema) {
+		return &dtos.SchemaStatsResponse{
+			HasSchema: false,
+			Tables:    []dtos.TableRowCount{},
+		}, http.StatusOK, nil
+	}
+	if err != nil {
+		log.Printf("ChatService -> GetSchemaStats -> Failed to read the stored schema of chat %s: %v", chatID, err)
+		return nil, http.StatusInternalServerError, err
+	}
+
+	response := &dtos.SchemaStatsResponse{
+		HasSchema:         true,
+		TableCount:        stats.TableCount,
+		TotalRowCount:     stats.TotalRowCount,
+		RelationshipCount: stats.RelationshipCount,
+		Tables:            make([]dtos.TableRowCount, 0, len(stats.Tables)),
+	}
+	if !stats.UpdatedAt.IsZero() {
+		response.LastRefreshedAt = stats.UpdatedAt.Format(time.RFC3339)
+	}
+	for _, table := range stats.Tables {
+		response.Tables = append(response.Tables, dtos.TableRowCount{
+			Name:     table.Name,
+			RowCount: table.RowCount,
+		})
+	}
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+const (
+	webhookDeliveryAttempts = 4                // First delivery & its retries
+	webhookDeliveryTimeout  = 10 * time.Second // Per attempt, a dead endpoint doesn't hold the goroutine
+	webhookDeliveryBackoff  = 2 * time.Second  // Wait before the first retry, doubled before each next one
+)
+
+// validateWebhookURL validates a webhook notified by a schema refresh or a scheduled query, empty disables it. A host
+// resolving to an internal address is refused when the webhook is delivered, see webhookDialControl.
+func validateWebhookURL(webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook_url must be an http or https URL")
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); (ip != nil && !isPublicWebhookIP(ip)) || strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("webhook_url must not target a loopback, private or link-local address")
+	}
+	return nil
+}
+
+// isPublicWebhookIP reports if a webhook may be delivered to an address, the server's own network & the cloud metadata
+// endpoints (ex: 169.254.169.254) are not reachable through webhooks
+func isPublicWebhookIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// webhookDialControl refuses the connections of a webhook delivery to internal addresses. It runs on the resolved
+// address of each dial, so a host resolving to an internal address (ex: DNS rebinding) is refused as well.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicWebhookIP(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// notifySchemaRefreshWebhook posts the outcome of a schema refresh to a webhook, a failed delivery is retried with a
+// backoff up to webhookDeliveryAttempts times. The delivery is given up after that, the refresh isn't affected.
+func (s *chatService) notifySchemaRefreshWebhook(webhookURL, chatID string, refreshErr error) {
+	payload := dtos.SchemaRefreshWebhookPayload{
+		ChatID:      chatID,
+		Status:      "completed",
+		CompletedAt: time.Now(),
+	}
+	if refreshErr != nil {
+		payload.Status = "failed"
+		payload.Error = refreshErr.Error()
+	} else if schema := s.dbManager.GetKnownSchema(context.Background(), chatID); schema != nil {
+		payload.TableCount = len(schema.Tables)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("ChatService -> notifySchemaRefreshWebhook -> Error marshaling payload: %v", err)
+		return
+	}
+
+	if err := deliverWebhook(webhookURL, body); err != nil {
+		log.Printf("ChatService -> notifySchemaRefreshWebhook -> Giving up on the webhook of chatID %s: %v", chatID, err)
+		return
+	}
+	log.Printf("ChatService -> notifySchemaRefreshWebhook -> Notified the webhook of chatID %s (%s)", chatID, payload.Status)
+}
+
+// deliverWebhook posts a JSON body to a webhook, retrying with a backoff up to webhookDeliveryAttempts times. The error
+// of the last attempt is returned if none succeeded. Redirects aren't followed, a redirect response is a failed attempt.
+func deliverWebhook(webhookURL string, body []byte) error {
+	dialer := &net.Dialer{Timeout: webhookDeliveryTimeout, Control: webhookDialControl}
+	client := &http.Client{
+		Timeout:   webhookDeliveryTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, DisableKeepAlives: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	backoff := webhookDeliveryBackoff
+	var err error
+	for attempt := 1; attempt <= webhookDeliveryAttempts; attempt++ {
+		if err = postWebhook(client, webhookURL, body); err == nil {
+			return nil
+		}
+		log.Printf("ChatService -> deliverWebhook -> Attempt %d/%d failed: %v", attempt, webhookDeliveryAttempts, err)
+		if attempt < webhookDeliveryAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+func postWebhook(client *http.Client, webhookURL string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// parseSuggestedIndexes reads the suggestedIndexes of a query of the LLM response, see constants.SuggestedIndex. They are
+// checked against the schema by checkSuggestedIndexes.
+func parseSuggestedIndexes(value interface{}) []models.SuggestedIndex {
+	suggestions, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var suggestedIndexes []models.SuggestedIndex
+	for _, suggestion := range suggestions {
+		suggestionMap, ok := suggestion.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		table, _ := suggestionMap["table"].(string)
+		query, _ := suggestionMap["query"].(string)
+		reason, _ := suggestionMap["reason"].(string)
+		var columns []string
+		if columnValues, ok := suggestionMap["columns"].([]interface{}); ok {
+			for _, column := range columnValues {
+				if name, ok := column.(string); ok && strings.TrimSpace(name) != "" {
+					columns = append(columns, strings.TrimSpace(name))
+				}
+			}
+		}
+		if strings.TrimSpace(table) == "" || strings.TrimSpace(query) == "" || len(columns) == 0 {
+			continue
+		}
+		suggestedIndexes = append(suggestedIndexes, models.SuggestedIndex{
+			ID:      primitive.NewObjectID(),
+			Table:   strings.TrimSpace(table),
+			Columns: columns,
+			Query:   strings.TrimSpace(query),
+			Reason:  reason,
+		})
+	}
+	return suggestedIndexes
+}
+
+// checkSuggestedIndexes keeps the indexes the LLM suggested for the read queries only when they are worth creating, from
+// the indexed columns & row counts of the stored schema (see dbmanager.CheckSuggestedIndex), so that small tables &
+// indexed columns don't get suggestions. Without a stored schema nothing is suggested.
+func (s *chatService) checkSuggestedIndexes(ctx context.Context, chat *models.Chat, chatID string, queries []models.Query) {
+	var schema *dbmanager.LLMSchemaInfo
+	for i := range queries {
+		query := &queries[i]
+		if len(query.SuggestedIndexes) == 0 {
+			continue
+		}
+		// The indexes are created on the main connection of a chat that executes its queries
+		if chat.Settings.GenerateOnly || query.ConnectionName != "" || query.Error != nil || !isReadQuery(chat, query) {
+			query.SuggestedIndexes = nil
+			continue
+		}
+		if schema == nil {
+			schema = s.dbManager.GetKnownLLMSchema(ctx, chatID)
+		}
+
+		kept := query.SuggestedIndexes[:0]
+		for _, index := range query.SuggestedIndexes {
+			if readOnlyQueryError(chat, index.Query) != nil {
+				continue
+			}
+			rollbackQuery, err := dbmanager.CheckSuggestedIndex(chat.Connection.Type, query.Query, index.Query, index.Table, index.Columns, schema)
+			if err != nil {
+				log.Printf("ChatService -> checkSuggestedIndexes -> Dropping the index suggested for query %s: %v", query.ID.Hex(), err)
+				continue
+			}
+			index.RollbackQuery = rollbackQuery
+			kept = append(kept, index)
+		}
+		if len(kept) == 0 {
+			kept = nil
+		}
+		query.SuggestedIndexes = kept
+	}
+}
+
+// suggestedIndexButtons offers to create each index suggested for the queries, see CreateSuggestedIndex
+func suggestedIndexButtons(queries []models.Query) []models.ActionButton {
+	var buttons []models.ActionButton
+	for i := range queries {
+		for _, index := range queries[i].SuggestedIndexes {
+			if index.CreatedQueryID != nil {
+				continue
+			}
+			buttons = append(buttons, models.ActionButton{
+				ID:     primitive.NewObjectID(),
+				Label:  fmt.Sprintf("Create Index on %s (%s)", index.Table, strings.Join(index.Columns, ", ")),
+				Action: "create_suggested_index",
+				Payload: map[string]interface{}{
+					constants.ActionPayloadQueryID: queries[i].ID.Hex(),
+					constants.ActionPayloadIndexID: index.ID.Hex(),
+				},
+			})
+		}
+	}
+	return buttons
+}
+
+// CreateSuggestedIndex creates an index suggested for a query of a message: its CREATE INDEX statement is added to the
+// message as a critical query, rolled back by dropping the index, & executed with ExecuteQuery. Returns the message with
+// the executed query, the create_suggested_index button of the index is removed.
+func (s *chatService) CreateSuggestedIndex(ctx context.Context, userID, chatID string, req *dtos.CreateSuggestedIndexRequest) (*dtos.MessageResponse, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	if req.ReadOnly {
+		return nil, http.StatusForbidden, fmt.Errorf("read only API keys can't create indexes")
+	}
+
+	_, msg, query, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	var index *models.SuggestedIndex
+	for i := range query.SuggestedIndexes {
+		if query.SuggestedIndexes[i].ID.Hex() == req.IndexID {
+			index = &query.SuggestedIndexes[i]
+			break
+		}
+	}
+	if index == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("suggested index not found")
+	}
+	if index.CreatedQueryID != nil {
+		return nil, http.StatusConflict, fmt.Errorf("the index was already added to the message, execute its query instead")
+	}
+	if refusal := readOnlyQueryError(chat, index.Query); refusal != nil {
+		return nil, http.StatusForbidden, fmt.Errorf("%s", refusal.Message)
+	}
+
+	indexQuery := models.Query{
+		ID:          primitive.NewObjectID(),
+		Query:       index.Query,
+		QueryType:   utils.ToStringPtr("DDL"),
+		Tables:      utils.ToStringPtr(index.Table),
+		Description: fmt.Sprintf("Creates the index on %s (%s) suggested for a query. %s", index.Table, strings.Join(index.Columns, ", "), index.Reason),
+		IsCritical:  true,
+		CanRollback: index.RollbackQuery != "",
+		// Clicking the button confirms the critical query, see isConfirmationExpired
+		GeneratedAt: utils.ToStringPtr(time.Now().Format(time.RFC3339)),
+	}
+	if index.RollbackQuery != "" {
+		indexQuery.RollbackQuery = utils.ToStringPtr(index.RollbackQuery)
+	}
+
+	for i := range *msg.Queries {
+		if (*msg.Queries)[i].ID != query.ID {
+			continue
+		}
+		for j := range (*msg.Queries)[i].SuggestedIndexes {
+			if (*msg.Queries)[i].SuggestedIndexes[j].ID == index.ID {
+				(*msg.Queries)[i].SuggestedIndexes[j].CreatedQueryID = utils.ToStringPtr(indexQuery.ID.Hex())
+			}
+		}
+	}
+	*msg.Queries = append(*msg.Queries, indexQuery)
+	if msg.ActionButtons != nil {
+		buttons := make([]models.ActionButton, 0, len(*msg.ActionButtons))
+		for _, button := range *msg.ActionButtons {
+			if button.Action == "create_suggested_index" && button.Payload[constants.ActionPayloadIndexID] == req.IndexID {
+				continue
+			}
+			buttons = append(buttons, button)
+		}
+		msg.ActionButtons = &buttons
+	}
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to add the index to the message: %v", err)
+	}
+	log.Printf("ChatService -> CreateSuggestedIndex -> Creating index %s of query %s as query %s", req.IndexID, req.QueryID, indexQuery.ID.Hex())
+
+	// The error of a failed statement is stored on the query of the message, like for any execution
+	response, statusCode, err := s.ExecuteQuery(ctx, userID, chatID, &dtos.ExecuteQueryRequest{
+		MessageID: req.MessageID,
+		QueryID:   indexQuery.ID.Hex(),
+		StreamID:  req.StreamID,
+	})
+	if err != nil && response == nil {
+		return nil, statusCode, err
+	}
+
+	updatedMsg, err := s.chatRepo.FindMessageByID(msg.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch message: %v", err)
+	}
+	return s.buildMessageResponse(updatedMsg), http.StatusOK, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"databot-ai/internal/models"
+	"log"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// recordTableAccess counts the tables used by the queries generated for a chat, the most queried tables keep the
+// full detail of a large schema on the next schema refresh
+func (s *chatService) recordTableAccess(chat *models.Chat, queries []models.Query) {
+	seen := make(map[string]bool)
+	tables := []string{}
+	for _, query := range queries {
+		if query.Tables == nil {
+			continue
+		}
+		for _, table := range strings.Split(*query.Tables, ",") {
+			table = strings.TrimSpace(table)
+			if table == "" || seen[table] {
+				continue
+			}
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return
+	}
+	if err := s.chatRepo.IncrementTableAccessCounts(chat.ID, tables); err != nil {
+		log.Printf("ChatService -> recordTableAccess -> Error counting the access of tables %v: %v", tables, err)
+	}
+}
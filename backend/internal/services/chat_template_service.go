@@ -0,0 +1,175 @@
+package services
+
+import (
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExportChatTemplate returns the configuration of a chat as a portable template, the credentials of the connection &
+// of the export destination are left out
+func (s *chatService) ExportChatTemplate(userID, chatID string) (*dtos.ChatTemplate, uint32, error) {
+	chat, statusCode, err := s.getOwnedChat(userID, chatID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	response := s.buildChatResponse(chat)
+	template := &dtos.ChatTemplate{
+		Version: constants.ChatTemplateVersion,
+		Connection: dtos.ChatTemplateConnection{
+			Type:           response.Connection.Type,
+			Host:           response.Connection.Host,
+			Hosts:          response.Connection.Hosts,
+			Shards:         response.Connection.Shards,
+			Port:           response.Connection.Port,
+			Database:       response.Connection.Database,
+			UseSSL:         response.Connection.UseSSL,
+			SSLMode:        response.Connection.SSLMode,
+			SSLRootCertURL: response.Connection.SSLRootCertURL,
+			MaxResultRows:  response.Connection.MaxResultRows,
+			Role:           response.Connection.Role,
+			FilePath:       response.Connection.FilePath,
+			ProjectID:      response.Connection.ProjectID,
+			SSHHost:        response.Connection.SSHHost,
+			SSHPort:        response.Connection.SSHPort,
+			SSHUser:        response.Connection.SSHUser,
+			SSHHostKey:     response.Connection.SSHHostKey,
+
+			MaxOpenConns:    response.Connection.MaxOpenConns,
+			MaxIdleConns:    response.Connection.MaxIdleConns,
+			ConnMaxLifetime: response.Connection.ConnMaxLifetime,
+		},
+		SelectedCollections: chat.SelectedCollections,
+		Settings:            response.Settings,
+		QueryTemplates:      response.QueryTemplates,
+		SchemaDescriptions:  response.SchemaDescriptions,
+		ImportedSchema:      chat.ImportedSchema,
+	}
+
+	log.Printf("ChatService -> ExportChatTemplate -> Exported the template of chatID: %s", chatID)
+	return template, http.StatusOK, nil
+}
+
+// ImportChatTemplate creates a chat from a template & the credentials of the user, the connection is tested & the
+// settings are validated like on create
+func (s *chatService) ImportChatTemplate(userID string, req *dtos.ImportChatTemplateRequest) (*dtos.ChatResponse, uint32, error) {
+	template := req.Template
+	if template.Version != constants.ChatTemplateVersion {
+		return nil, http.StatusBadRequest, fmt.Errorf("unsupported template version %d, this server imports version %d templates", template.Version, constants.ChatTemplateVersion)
+	}
+
+	selectedCollections := strings.TrimSpace(template.SelectedCollections)
+	if selectedCollections == "" {
+		selectedCollections = "ALL"
+	}
+
+	queryTemplates := make([]models.QueryTemplate, len(template.QueryTemplates))
+	for i, queryTemplate := range template.QueryTemplates {
+		queryTemplates[i] = models.QueryTemplate{
+			Name:        strings.TrimSpace(queryTemplate.Name),
+			Query:       strings.TrimSuffix(strings.TrimSpace(queryTemplate.Query), ";"),
+			Description: strings.TrimSpace(queryTemplate.Description),
+			AutoPrepend: queryTemplate.AutoPrepend,
+		}
+	}
+	// The schema isn't fetched yet, the tables read by the templates can't be checked
+	if err := dbmanager.ValidateQueryTemplateDefinitions(template.Connection.Type, toDBQueryTemplates(queryTemplates)); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	descriptions := make([]models.SchemaDescription, len(template.SchemaDescriptions))
+	for i, description := range template.SchemaDescriptions {
+		descriptions[i] = models.SchemaDescription{
+			Table:       strings.TrimSpace(description.Table),
+			Column:      strings.TrimSpace(description.Column),
+			Description: strings.TrimSpace(description.Description),
+		}
+	}
+
+	importedSchema := strings.TrimSpace(template.ImportedSchema)
+	if importedSchema != "" {
+		if !template.Settings.GenerateOnly {
+			return nil, http.StatusBadRequest, fmt.Errorf("only generate only chats use an imported schema, the schema of the other chats is fetched from the database")
+		}
+		if len(importedSchema) > constants.MaxImportedSchemaLength {
+			return nil, http.StatusBadRequest, fmt.Errorf("schema is larger than %d KB, keep only the tables worth querying", constants.MaxImportedSchemaLength/1024)
+		}
+	}
+
+	settings := template.Settings
+	created, statusCode, err := s.Create(userID, &dtos.CreateChatRequest{
+		Connection: dtos.CreateConnectionRequest{
+			Type:           template.Connection.Type,
+			Host:           template.Connection.Host,
+			Hosts:          template.Connection.Hosts,
+			Shards:         template.Connection.Shards,
+			Port:           template.Connection.Port,
+			Username:       req.Username,
+			Password:       req.Password,
+			Database:       template.Connection.Database,
+			UseSSL:         template.Connection.UseSSL,
+			SSLMode:        template.Connection.SSLMode,
+			SSLCertURL:     req.SSLCertURL,
+			SSLKeyURL:      req.SSLKeyURL,
+			SSLRootCertURL: template.Connection.SSLRootCertURL,
+			MaxResultRows:  template.Connection.MaxResultRows,
+			Role:           template.Connection.Role,
+			FilePath:       template.Connection.FilePath,
+			ProjectID:      template.Connection.ProjectID,
+			SSHHost:        template.Connection.SSHHost,
+			SSHPort:        template.Connection.SSHPort,
+			SSHUser:        template.Connection.SSHUser,
+			SSHPrivateKey:  req.SSHPrivateKey,
+			SSHHostKey:     template.Connection.SSHHostKey,
+
+			MaxOpenConns:    template.Connection.MaxOpenConns,
+			MaxIdleConns:    template.Connection.MaxIdleConns,
+			ConnMaxLifetime: template.Connection.ConnMaxLifetime,
+		},
+		Settings: dtos.CreateChatSettings{
+			AutoExecuteQuery: &settings.AutoExecuteQuery,
+			ShareDataWithAI:  &settings.ShareDataWithAI,
+			ColumnMasks:      settings.ColumnMasks,
+			SessionMode:      &settings.SessionMode,
+			AnonymizeSchema:  &settings.AnonymizeSchema,
+			GenerateOnly:     &settings.GenerateOnly,
+
+			DisableExampleRecords: &settings.DisableExampleRecords,
+			TextCollation:         &settings.TextCollation,
+			ReadOnly:              &settings.ReadOnly,
+			DisableResponseCache:  &settings.DisableResponseCache,
+			DefaultPageSize:       &settings.DefaultPageSize,
+			QueryTimeoutSeconds:   &settings.QueryTimeoutSeconds,
+
+			SchemaRefreshWebhookURL: &settings.SchemaRefreshWebhookURL,
+		},
+	})
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	chat, statusCode, err := s.getOwnedChat(userID, created.ID)
+	if err != nil {
+		return nil, statusCode, err
+	}
+	chat.SelectedCollections = selectedCollections
+	chat.QueryTemplates = queryTemplates
+	chat.SchemaDescriptions = descriptions
+	chat.ImportedSchema = importedSchema
+	if err := s.chatRepo.Update(chat.ID, chat); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update chat: %v", err)
+	}
+
+	log.Printf("ChatService -> ImportChatTemplate -> Created chatID: %s from a template with %d query templates & %d schema descriptions", chat.ID.Hex(), len(queryTemplates), len(descriptions))
+	response := s.buildChatResponse(chat)
+	response.Connection.TLS = created.Connection.TLS
+	return response, http.StatusCreated, nil
+}
@@ -0,0 +1,100 @@
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+package services
+
+import (
+	"databot-ai/config"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/repositories"
+	"databot-ai/pkg/llm"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// LLMTenants returns the LLM settings of the tenants of the deployment, see llm.Manager.SetTenants
+func LLMTenants() map[string]llm.TenantConfig {
+	tenants := make(map[string]llm.TenantConfig, len(config.Env.Tenants))
+	for id, tenant := range config.Env.Tenants {
+		tenants[id] = llm.TenantConfig{
+			APIKeys:            tenant.LLMAPIKeys,
+			MaxConcurrentCalls: tenant.MaxConcurrentLLMCalls,
+		}
+	}
+	return tenants
+}
+
+// CountTenantUsage counts a use of a resource (constants.TenantUsageLLMCalls or constants.TenantUsageQueryExecutions)
+// by a tenant today, an error is returned once the daily quota of the tenant is used. The uses of the users without a
+// tenant & of the tenants without a quota for the resource aren't counted.
+func CountTenantUsage(usageRepo repositories.TenantUsageRepository, tenantID, resource string) error {
+	tenant, exists := config.Env.Tenants[tenantID]
+	if !exists {
+		return nil
+	}
+	quota := tenant.DailyLLMCalls
+	if resource == constants.TenantUsageQueryExecutions {
+		quota = tenant.DailyQueryExecutions
+	}
+	if quota == 0 {
+		return nil
+	}
+
+	used, err := usageRepo.Increment(tenantID, resource, 24*time.Hour)
+	if err != nil {
+		// The quota is a safeguard, nothing is rejected when it can't be checked
+		log.Printf("ChatService -> CountTenantUsage -> Error counting %s of tenant %s: %v", resource, tenantID, err)
+		return nil
+	}
+	if used > int64(quota) {
+		return fmt.Errorf("the daily quota of %d %s of your organization is used, try again tomorrow", quota, strings.ReplaceAll(resource, "_", " "))
+	}
+	return nil
+}
+
+// tenantOf returns the tenant of a user, empty when the deployment has no tenants or the user belongs to none
+func (s *chatService) tenantOf(userID string) string {
+	if len(config.Env.Tenants) == 0 || s.userRepo == nil {
+		return ""
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		log.Printf("ChatService -> tenantOf -> Error fetching user %s: %v", userID, err)
+		return ""
+	}
+	if _, exists := config.Env.Tenants[user.TenantID]; user.TenantID != "" && !exists {
+		log.Printf("ChatService -> tenantOf -> Tenant %s of user %s isn't in TENANTS_FILE, the user runs without a tenant", user.TenantID, userID)
+		return ""
+	}
+	return user.TenantID
+}
+
+// countTenantQueryExecutions counts the execution of queries by the tenant of a user against its daily quota
+func (s *chatService) countTenantQueryExecutions(userID string, queries int) error {
+	tenantID := s.tenantOf(userID)
+	if tenantID == "" {
+		return nil
+	}
+	for i := 0; i < queries; i++ {
+		if err := CountTenantUsage(s.tenantUsageRepo, tenantID, constants.TenantUsageQueryExecutions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTenantConnections rejects the connection of a chat once its tenant has as many chats connected as allowed, a
+// chat already connected doesn't count as a new connection
+func (s *chatService) checkTenantConnections(tenantID, chatID string) error {
+	tenant, exists := config.Env.Tenants[tenantID]
+	if !exists || tenant.MaxConnections == 0 {
+		return nil
+	}
+	if _, connected := s.dbManager.GetConnectionInfo(chatID); connected {
+		return nil
+	}
+	if s.dbManager.CountTenantConnections(tenantID) >= tenant.MaxConnections {
+		return fmt.Errorf("your organization can have %d databases connected at the same time, disconnect a chat first", tenant.MaxConnections)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"databot-ai/config"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"databot-ai/internal/utils"
+	"databot-ai/pkg/dbmanager"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NOTE: Service type, signatures are defined in services/chat_crud_service.go
+
+// ExecuteQueriesInTransaction executes dependent queries of a message in a single transaction (see
+// dbmanager.Manager.ExecuteQueriesInTransaction), the queries are only marked as executed once the transaction is
+// committed. Each query is recorded in the execution history of the chat.
+func (s *chatService) ExecuteQueriesInTransaction(ctx context.Context, userID, chatID string, req *dtos.ExecuteQueriesInTransactionRequest) (*dtos.TransactionExecutionResponse, uint32, error) {
+	chat, msg, _, err := s.verifyQueryOwnership(userID, chatID, req.MessageID, req.QueryIDs[0])
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if chat.Settings.GenerateOnly {
+		return nil, http.StatusBadRequest, errGenerateOnlyChat
+	}
+	s.recordAuditLog(ctx, userID, chatID, constants.AuditActionQueryExecution, map[string]interface{}{
+		"message_id": req.MessageID,
+		"query_ids":  req.QueryIDs,
+	})
+	if hasPendingParameters(msg) {
+		return nil, http.StatusBadRequest, fmt.Errorf("fill the parameters requested in the message before executing its queries")
+	}
+	if !dbmanager.TransactionsSupported(chat.Connection.Type) {
+		return transactionUnsupportedResponse(chatID, req.MessageID, &dtos.QueryError{
+			Code:    "TRANSACTIONS_UNSUPPORTED",
+			Message: "these queries can't be executed in a single transaction",
+			Details: fmt.Sprintf("%s doesn't support transactions over several queries, execute the queries one at a time", chat.Connection.Type),
+		})
+	}
+
+	queries := make([]*models.Query, 0, len(req.QueryIDs))
+	transactionQueries := make([]dbmanager.TransactionQuery, 0, len(req.QueryIDs))
+	seen := make(map[string]bool, len(req.QueryIDs))
+	for _, queryID := range req.QueryIDs {
+		if seen[queryID] {
+			return nil, http.StatusBadRequest, fmt.Errorf("query %s is listed more than once", queryID)
+		}
+		seen[queryID] = true
+
+		query := findMessageQuery(msg, queryID)
+		if query == nil {
+			return nil, http.StatusNotFound, fmt.Errorf("query %s not found in the message", queryID)
+		}
+		if query.IsCritical && isConfirmationExpired(msg, query) {
+			return nil, http.StatusConflict, fmt.Errorf("the critical query %s was generated more than %d minutes ago, please regenerate it before executing", queryID, config.Env.CriticalQueryConfirmationTTLMinutes)
+		}
+		if len(queries) > 0 && query.ConnectionName != queries[0].ConnectionName {
+			return nil, http.StatusBadRequest, fmt.Errorf("the queries of a transaction must run on the same connection")
+		}
+		refusal := readOnlyQueryError(chat, query.Query)
+		if refusal == nil {
+			refusal = namedConnectionQueryError(chat, query.ConnectionName)
+		}
+		if refusal == nil && query.ConnectionName == "" {
+			refusal = tableScopeQueryError(chat, query.Query, query.Tables)
+		}
+		if refusal != nil {
+			return &dtos.TransactionExecutionResponse{
+				ChatID:    chatID,
+				MessageID: req.MessageID,
+				Results:   []dtos.QueryExecutionResponse{},
+				Error: &dtos.QueryError{
+					Code:    refusal.Code,
+					Message: refusal.Message,
+					Details: refusal.Details,
+				},
+			}, http.StatusForbidden, fmt.Errorf("%s", refusal.Message)
+		}
+		if req.ReadOnly && !isReadQuery(chat, query) {
+			return nil, http.StatusForbidden, fmt.Errorf("this API key is read only, only read queries can be executed")
+		}
+
+		queryType := ""
+		if query.QueryType != nil {
+			queryType = *query.QueryType
+		}
+		queries = append(queries, query)
+		transactionQueries = append(transactionQueries, dbmanager.TransactionQuery{
+			QueryID:   queryID,
+			Query:     query.Query,
+			QueryType: queryType,
+		})
+	}
+
+	if err := s.countTenantQueryExecutions(userID, len(queries)); err != nil {
+		return nil, http.StatusTooManyRequests, err
+	}
+
+	if !s.dbManager.IsConnected(chatID) {
+		if statusCode, err := s.ConnectDB(ctx, userID, chatID, req.StreamID); err != nil {
+			return nil, statusCode, err
+		}
+	}
+	if ctx, err = s.withNamedConnection(ctx, userID, chat, queries[0].ConnectionName, req.StreamID); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Every query of the transaction gets the timeout of the slowest one
+	var timeout time.Duration
+	for _, query := range queries {
+		timeout = max(timeout, queryTimeout(chat, query, 0))
+	}
+	ctx = dbmanager.WithQueryTimeout(ctx, timeout)
+
+	startTime := time.Now()
+	results, queryErr := s.dbManager.ExecuteQueriesInTransaction(ctx, chatID, req.MessageID, req.StreamID, transactionQueries)
+	if queryErr != nil && queryErr.Code == "TRANSACTIONS_UNSUPPORTED" {
+		return transactionUnsupportedResponse(chatID, req.MessageID, queryErr)
+	}
+
+	committed := queryErr == nil
+	response := &dtos.TransactionExecutionResponse{
+		ChatID:    chatID,
+		MessageID: req.MessageID,
+		Committed: committed,
+		Results:   make([]dtos.QueryExecutionResponse, len(results)),
+		Error:     queryErr,
+	}
+	actionAt := time.Now().Format(time.RFC3339)
+	for i, result := range results {
+		query := queries[i]
+		response.Results[i] = dtos.QueryExecutionResponse{
+			ChatID:        chatID,
+			MessageID:     req.MessageID,
+			QueryID:       query.ID.Hex(),
+			IsExecuted:    committed,
+			ExecutionTime: &result.ExecutionTime,
+			Error:         result.Error,
+			Warnings:      result.Warnings,
+		}
+		if result.Error != nil {
+			query.Error = &models.QueryError{
+				Code:    result.Error.Code,
+				Message: result.Error.Message,
+				Details: result.Error.Details,
+			}
+		}
+		if !committed {
+			continue
+		}
+		executionTime := result.ExecutionTime
+		resultJSON := result.ResultJSON
+		response.Results[i].ActionAt = &actionAt
+		query.IsExecuted = true
+		query.IsRolledBack = false
+		query.ExecutionTime = &executionTime
+		query.ExecutionResult = &resultJSON
+		query.ActionAt = &actionAt
+		query.Warnings = result.Warnings
+		query.Error = nil
+	}
+
+	if err := s.chatRepo.UpdateMessage(msg.ID, msg); err != nil {
+		log.Printf("ChatService -> ExecuteQueriesInTransaction -> Error updating message: %v", err)
+	}
+	if committed {
+		s.markLLMQueriesExecuted(chat, msg, queries, results)
+	}
+
+	for i := range response.Results {
+		s.recordQueryExecution(userID, chatID, req.MessageID, response.Results[i].QueryID, false, false, startTime, &response.Results[i], nil)
+	}
+
+	log.Printf("ChatService -> ExecuteQueriesInTransaction -> Executed %d of %d queries of messageID %s, committed: %v", len(results), len(queries), req.MessageID, committed)
+	return response, http.StatusOK, nil
+}
+
+// transactionUnsupportedResponse refuses queries that can't be executed atomically, nothing was executed
+func transactionUnsupportedResponse(chatID, messageID string, queryErr *dtos.QueryError) (*dtos.TransactionExecutionResponse, uint32, error) {
+	return &dtos.TransactionExecutionResponse{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Results:   []dtos.QueryExecutionResponse{},
+		Error:     queryErr,
+	}, http.StatusBadRequest, fmt.Errorf("%s: %s", queryErr.Message, queryErr.Details)
+}
+
+// findMessageQuery returns the query of a message with the given ID, nil if there is none
+func findMessageQuery(msg *models.Message, queryID string) *models.Query {
+	if msg.Queries == nil {
+		return nil
+	}
+	for i := range *msg.Queries {
+		if (*msg.Queries)[i].ID.Hex() == queryID {
+			return &(*msg.Queries)[i]
+		}
+	}
+	return nil
+}
+
+// markLLMQueriesExecuted marks the queries of a transaction as executed in the LLM message of the chat message, with
+// their results when data is shared with the AI
+func (s *chatService) markLLMQueriesExecuted(chat *models.Chat, msg *models.Message, queries []*models.Query, results []*dbmanager.QueryExecutionResult) {
+	llmMsg, err := s.llmRepo.FindMessageByChatMessageID(msg.ID)
+	if err != nil || llmMsg == nil {
+		log.Printf("ChatService -> markLLMQueriesExecuted -> Error finding LLM message: %v", err)
+		return
+	}
+	assistantResponse, ok := llmMsg.Content["assistant_response"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	var llmQueries []interface{}
+	switch queriesVal := assistantResponse["queries"].(type) {
+	case primitive.A:
+		llmQueries = []interface{}(queriesVal)
+	case []interface{}:
+		llmQueries = queriesVal
+	default:
+		return
+	}
+
+	actionAt := utils.ToStringPtr(time.Now().Format(time.RFC3339))
+	for _, q := range llmQueries {
+		queryMap, ok := q.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for i, query := range queries {
+			if query.QueryType == nil || queryMap["query"] != query.Query || queryMap["queryType"] != *query.QueryType || queryMap["explanation"] != query.Description {
+				continue
+			}
+			queryMap["isExecuted"] = true
+			queryMap["isRolledBack"] = false
+			queryMap["executionTime"] = results[i].ExecutionTime
+			queryMap["actionAt"] = actionAt
+			if chat.Settings.ShareDataWithAI {
+				queryMap["executionResult"] = map[string]interface{}{
+					"result": s.sharedResult(chat, results[i].ResultJSON),
+				}
+			} else {
+				queryMap["executionResult"] = map[string]interface{}{
+					"result": "Query executed successfully",
+				}
+			}
+			queryMap["error"] = nil
+			break
+		}
+	}
+	assistantResponse["queries"] = llmQueries
+
+	if err := s.llmRepo.UpdateMessage(llmMsg.ID, llmMsg); err != nil {
+		log.Printf("ChatService -> markLLMQueriesExecuted -> Error updating LLM message: %v", err)
+	}
+}
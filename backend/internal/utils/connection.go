@@ -36,6 +36,13 @@ func GenerateConfigKey(config map[string]interface{}) string {
 		username,
 		config["database"])
 
+	// Connections without a host (SQLite files, BigQuery projects) are told apart by their other details
+	for _, field := range []string{"file_path", "project_id", "service_account", "ssh_host"} {
+		if value, ok := config[field].(string); ok && value != "" {
+			key += ":" + value
+		}
+	}
+
 	return key
 }
 
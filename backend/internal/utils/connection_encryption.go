@@ -24,6 +24,32 @@ func EncryptConnection(conn *models.Connection) error {
 		return fmt.Errorf("failed to encrypt host: %v", err)
 	}
 
+	// Encrypt failover hosts
+	if len(conn.Hosts) > 0 {
+		hosts := make([]string, len(conn.Hosts))
+		for i, host := range conn.Hosts {
+			encryptedHost, err := encrypt(host, key)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt failover host: %v", err)
+			}
+			hosts[i] = encryptedHost
+		}
+		conn.Hosts = hosts
+	}
+
+	// Encrypt shards
+	if len(conn.Shards) > 0 {
+		shards := make([]string, len(conn.Shards))
+		for i, shard := range conn.Shards {
+			encryptedShard, err := encrypt(shard, key)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt shard: %v", err)
+			}
+			shards[i] = encryptedShard
+		}
+		conn.Shards = shards
+	}
+
 	// Encrypt port if present
 	if conn.Port != nil {
 		if encryptedPort, err := encrypt(*conn.Port, key); err == nil {
@@ -58,6 +84,24 @@ func EncryptConnection(conn *models.Connection) error {
 		return fmt.Errorf("failed to encrypt database: %v", err)
 	}
 
+	// Encrypt the SQLite file path if present
+	if conn.FilePath != "" {
+		if encryptedPath, err := encrypt(conn.FilePath, key); err == nil {
+			conn.FilePath = encryptedPath
+		} else {
+			return fmt.Errorf("failed to encrypt file path: %v", err)
+		}
+	}
+
+	// Encrypt the BigQuery service account key if present
+	if conn.CredentialsJSON != "" {
+		if encryptedCredentials, err := encrypt(conn.CredentialsJSON, key); err == nil {
+			conn.CredentialsJSON = encryptedCredentials
+		} else {
+			return fmt.Errorf("failed to encrypt credentials: %v", err)
+		}
+	}
+
 	// Encrypt SSL certificate URLs if present
 	if conn.SSLCertURL != nil {
 		if encryptedURL, err := encrypt(*conn.SSLCertURL, key); err == nil {
@@ -83,6 +127,31 @@ func EncryptConnection(conn *models.Connection) error {
 		}
 	}
 
+	// Encrypt the SSH bastion & its private key if present
+	if conn.SSHHost != "" {
+		if encryptedHost, err := encrypt(conn.SSHHost, key); err == nil {
+			conn.SSHHost = encryptedHost
+		} else {
+			return fmt.Errorf("failed to encrypt SSH host: %v", err)
+		}
+	}
+
+	if conn.SSHUser != "" {
+		if encryptedUser, err := encrypt(conn.SSHUser, key); err == nil {
+			conn.SSHUser = encryptedUser
+		} else {
+			return fmt.Errorf("failed to encrypt SSH user: %v", err)
+		}
+	}
+
+	if conn.SSHPrivateKey != "" {
+		if encryptedKey, err := encrypt(conn.SSHPrivateKey, key); err == nil {
+			conn.SSHPrivateKey = encryptedKey
+		} else {
+			return fmt.Errorf("failed to encrypt SSH private key: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -98,6 +167,34 @@ func DecryptConnection(conn *models.Connection) {
 		log.Printf("Warning: Failed to decrypt host, using as-is: %v", err)
 	}
 
+	// Decrypt failover hosts
+	if len(conn.Hosts) > 0 {
+		hosts := make([]string, len(conn.Hosts))
+		for i, host := range conn.Hosts {
+			if decryptedHost, err := decrypt(host, key); err == nil {
+				hosts[i] = decryptedHost
+			} else {
+				log.Printf("Warning: Failed to decrypt failover host, using as-is: %v", err)
+				hosts[i] = host
+			}
+		}
+		conn.Hosts = hosts
+	}
+
+	// Decrypt shards
+	if len(conn.Shards) > 0 {
+		shards := make([]string, len(conn.Shards))
+		for i, shard := range conn.Shards {
+			if decryptedShard, err := decrypt(shard, key); err == nil {
+				shards[i] = decryptedShard
+			} else {
+				log.Printf("Warning: Failed to decrypt shard, using as-is: %v", err)
+				shards[i] = shard
+			}
+		}
+		conn.Shards = shards
+	}
+
 	// Decrypt port if present
 	if conn.Port != nil {
 		if decryptedPort, err := decrypt(*conn.Port, key); err == nil {
@@ -132,6 +229,24 @@ func DecryptConnection(conn *models.Connection) {
 		log.Printf("Warning: Failed to decrypt database, using as-is: %v", err)
 	}
 
+	// Decrypt the SQLite file path if present
+	if conn.FilePath != "" {
+		if decryptedPath, err := decrypt(conn.FilePath, key); err == nil {
+			conn.FilePath = decryptedPath
+		} else {
+			log.Printf("Warning: Failed to decrypt file path, using as-is: %v", err)
+		}
+	}
+
+	// Decrypt the BigQuery service account key if present
+	if conn.CredentialsJSON != "" {
+		if decryptedCredentials, err := decrypt(conn.CredentialsJSON, key); err == nil {
+			conn.CredentialsJSON = decryptedCredentials
+		} else {
+			log.Printf("Warning: Failed to decrypt credentials, using as-is: %v", err)
+		}
+	}
+
 	// Decrypt SSL certificate URLs if present
 	if conn.SSLCertURL != nil {
 		if decryptedURL, err := decrypt(*conn.SSLCertURL, key); err == nil {
@@ -156,6 +271,67 @@ func DecryptConnection(conn *models.Connection) {
 			log.Printf("Warning: Failed to decrypt SSL root certificate URL, using as-is: %v", err)
 		}
 	}
+
+	// Decrypt the SSH bastion & its private key if present
+	if conn.SSHHost != "" {
+		if decryptedHost, err := decrypt(conn.SSHHost, key); err == nil {
+			conn.SSHHost = decryptedHost
+		} else {
+			log.Printf("Warning: Failed to decrypt SSH host, using as-is: %v", err)
+		}
+	}
+
+	if conn.SSHUser != "" {
+		if decryptedUser, err := decrypt(conn.SSHUser, key); err == nil {
+			conn.SSHUser = decryptedUser
+		} else {
+			log.Printf("Warning: Failed to decrypt SSH user, using as-is: %v", err)
+		}
+	}
+
+	if conn.SSHPrivateKey != "" {
+		if decryptedKey, err := decrypt(conn.SSHPrivateKey, key); err == nil {
+			conn.SSHPrivateKey = decryptedKey
+		} else {
+			log.Printf("Warning: Failed to decrypt SSH private key, using as-is: %v", err)
+		}
+	}
+}
+
+// EncryptExportDestination encrypts the credentials of an export destination
+func EncryptExportDestination(dest *models.ExportDestination) error {
+	key := []byte(config.Env.SchemaEncryptionKey)
+
+	encryptedAccessKeyID, err := encrypt(dest.AccessKeyID, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access key id: %v", err)
+	}
+	encryptedSecretAccessKey, err := encrypt(dest.SecretAccessKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret access key: %v", err)
+	}
+
+	dest.AccessKeyID = encryptedAccessKeyID
+	dest.SecretAccessKey = encryptedSecretAccessKey
+	return nil
+}
+
+// DecryptExportDestination decrypts the credentials of an export destination
+func DecryptExportDestination(dest *models.ExportDestination) error {
+	key := []byte(config.Env.SchemaEncryptionKey)
+
+	decryptedAccessKeyID, err := decrypt(dest.AccessKeyID, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt access key id: %v", err)
+	}
+	decryptedSecretAccessKey, err := decrypt(dest.SecretAccessKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret access key: %v", err)
+	}
+
+	dest.AccessKeyID = decryptedAccessKeyID
+	dest.SecretAccessKey = decryptedSecretAccessKey
+	return nil
 }
 
 // encrypt encrypts a string using AES-GCM
@@ -1,7 +1,21 @@
 package utils
 
-import "github.com/google/uuid"
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+)
 
 func GenerateSecret() string {
 	return uuid.New().String()
 }
+
+// GenerateAPIKey returns a random API key starting with prefix
+func GenerateAPIKey(prefix string) (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(key), nil
+}
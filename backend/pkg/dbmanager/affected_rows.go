@@ -0,0 +1,216 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mongoWriteManyRegex matches a MongoDB write of several documents & captures its collection, ex: db.orders.deleteMany(
+var mongoWriteManyRegex = regexp.MustCompile(`^\s*db\.(\w+)\.(?:deleteMany|updateMany)\s*\(`)
+
+// affectedRowsTargetJoins are the words of a write target joining other tables, the rows counted from a join could
+// differ from the rows written
+var affectedRowsTargetJoins = map[string]bool{
+	"join": true, "inner": true, "left": true, "right": true, "cross": true, "natural": true, "straight_join": true,
+}
+
+// AffectedRowsCountQuery derives the query counting the rows an UPDATE or DELETE will change from its table & WHERE
+// clause, ex: SELECT COUNT(*) AS count FROM orders WHERE status = 'draft'. ClickHouse mutations (ALTER TABLE ...
+// DELETE/UPDATE) & the MongoDB deleteMany/updateMany (counted with countDocuments) are supported too. An empty string
+// is returned for the other queries & for the writes whose rows can't be told from a single table: several
+// statements, CTEs, UPDATE ... FROM, DELETE ... USING or writes over joined tables.
+func AffectedRowsCountQuery(dbType, query string) string {
+	if dbType == constants.DatabaseTypeMongoDB {
+		return mongoAffectedRowsCountQuery(query)
+	}
+	if !isSQLDialect(dbType) {
+		return ""
+	}
+
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	tokens := tokenizeSQL(query)
+	if len(tokens) < 3 {
+		return ""
+	}
+	for _, token := range tokens {
+		if token.text == ";" {
+			return "" // Multiple statements
+		}
+	}
+
+	// The target starts after the statement keywords & ends at the clause following it
+	var targetStart int
+	var targetEnds map[string]bool
+	switch tokens[0].value {
+	case "update":
+		targetStart = skipWriteModifiers(tokens, 1)
+		targetEnds = map[string]bool{"set": true}
+	case "delete":
+		targetStart = skipWriteModifiers(tokens, 1)
+		if targetStart >= len(tokens) || tokens[targetStart].value != "from" {
+			return "" // MySQL multi-table DELETE t1 FROM t1 JOIN t2...
+		}
+		targetStart = skipWriteModifiers(tokens, targetStart+1)
+		targetEnds = map[string]bool{"where": true, "using": true, "order": true, "limit": true, "returning": true}
+	case "alter":
+		// ClickHouse mutation: ALTER TABLE t [ON CLUSTER c] DELETE WHERE ... / UPDATE ... WHERE ...
+		if dbType != constants.DatabaseTypeClickhouse || tokens[1].value != "table" {
+			return ""
+		}
+		targetStart = 2
+		targetEnds = map[string]bool{"delete": true, "update": true, "on": true}
+	default:
+		return ""
+	}
+
+	targetEnd := -1
+	for i := targetStart; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.kind == sqlTokenWord && targetEnds[token.value] {
+			targetEnd = i
+			break
+		}
+		if token.text == "," || token.text == "(" || (token.kind == sqlTokenWord && affectedRowsTargetJoins[token.value]) {
+			return ""
+		}
+	}
+	if targetEnd == -1 {
+		if tokens[0].value != "delete" {
+			return "" // UPDATE without SET, ALTER TABLE without a mutation
+		}
+		targetEnd = len(tokens)
+	}
+	if targetEnd == targetStart {
+		return ""
+	}
+	target := strings.TrimSpace(query[tokens[targetStart].start:tokenStart(query, tokens, targetEnd)])
+
+	// Locate the WHERE clause at depth 0 & the clauses ending it
+	whereIdx, whereEnd, suffixStart, suffixEnd := -1, len(query), -1, len(query)
+	depth := 0
+scan:
+	for i := targetEnd; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		switch token.value {
+		case "where":
+			if whereIdx == -1 {
+				whereIdx = i
+			}
+		case "from", "using":
+			// UPDATE ... FROM & DELETE ... USING join other tables
+			if whereIdx == -1 {
+				return ""
+			}
+		case "order", "limit":
+			// MySQL & SQLite writes can be limited, the count is then capped the same way
+			if suffixStart == -1 {
+				suffixStart = token.start
+				if whereIdx != -1 {
+					whereEnd = token.start
+				}
+			}
+		case "returning":
+			suffixEnd = token.start
+			if suffixStart == -1 && whereIdx != -1 {
+				whereEnd = token.start
+			}
+			break scan
+		}
+	}
+	if tokens[0].value == "alter" && whereIdx == -1 {
+		return "" // Not a mutation, ex: ALTER TABLE t ADD COLUMN
+	}
+
+	countQuery := "FROM " + target
+	if whereIdx != -1 {
+		condition := strings.TrimSpace(query[tokenStart(query, tokens, whereIdx+1):whereEnd])
+		if condition == "" {
+			return ""
+		}
+		countQuery += " WHERE " + condition
+	}
+	if suffixStart != -1 {
+		suffix := strings.TrimSpace(query[suffixStart:suffixEnd])
+		return "SELECT COUNT(*) AS count FROM (SELECT 1 " + countQuery + " " + suffix + ") AS databot_affected"
+	}
+	return "SELECT COUNT(*) AS count " + countQuery
+}
+
+// skipWriteModifiers skips the modifiers of an UPDATE or DELETE from index i, ex: LOW_PRIORITY, IGNORE, ONLY
+func skipWriteModifiers(tokens []sqlToken, i int) int {
+	for i < len(tokens) && tokens[i].kind == sqlTokenWord {
+		switch tokens[i].value {
+		case "low_priority", "quick", "ignore", "only":
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// tokenStart returns the byte offset where the token at index i starts, the end of the query past the last token
+func tokenStart(query string, tokens []sqlToken, i int) int {
+	if i >= len(tokens) {
+		return len(query)
+	}
+	return tokens[i].start
+}
+
+// mongoAffectedRowsCountQuery derives the countDocuments of the filter of a deleteMany or updateMany
+func mongoAffectedRowsCountQuery(query string) string {
+	match := mongoWriteManyRegex.FindStringSubmatchIndex(query)
+	if match == nil {
+		return ""
+	}
+	params, end, err := extractParenthesisContent(query, match[1]-1)
+	if err != nil || strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query[end+1:]), ";")) != "" {
+		return "" // Several statements or a chained call
+	}
+	filter := strings.TrimSpace(firstMongoArgument(params))
+	if filter == "" {
+		filter = "{}"
+	}
+	return fmt.Sprintf("db.%s.countDocuments(%s)", query[match[2]:match[3]], filter)
+}
+
+// firstMongoArgument returns the first top level argument of a MongoDB method call, the commas of the nested objects,
+// arrays & strings are skipped
+func firstMongoArgument(params string) string {
+	depth := 0
+	var quote rune
+	for i, r := range params {
+		if quote != 0 {
+			if r == quote && (i == 0 || params[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case '"', '\'':
+			quote = r
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				return params[:i]
+			}
+		}
+	}
+	return params
+}
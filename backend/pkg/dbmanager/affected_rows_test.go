@@ -0,0 +1,56 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"testing"
+)
+
+func TestAffectedRowsCountQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		query  string
+		want   string
+	}{
+		{"update", constants.DatabaseTypePostgreSQL, "UPDATE orders SET status = 'paid' WHERE id = 1", "SELECT COUNT(*) AS count FROM orders WHERE id = 1"},
+		{"update without where", constants.DatabaseTypePostgreSQL, "UPDATE orders SET status = 'paid'", "SELECT COUNT(*) AS count FROM orders"},
+		{"update only", constants.DatabaseTypePostgreSQL, "UPDATE ONLY orders SET status = 'paid' WHERE id = 1", "SELECT COUNT(*) AS count FROM orders WHERE id = 1"},
+		{"delete", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE status = 'draft';", "SELECT COUNT(*) AS count FROM orders WHERE status = 'draft'"},
+		{"delete without where", constants.DatabaseTypeMySQL, "DELETE FROM orders", "SELECT COUNT(*) AS count FROM orders"},
+		{"delete returning", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE status = 'draft' RETURNING id", "SELECT COUNT(*) AS count FROM orders WHERE status = 'draft'"},
+		{"delete with a subquery", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE customer_id IN (SELECT id FROM customers WHERE banned)", "SELECT COUNT(*) AS count FROM orders WHERE customer_id IN (SELECT id FROM customers WHERE banned)"},
+		{"mysql limited delete", constants.DatabaseTypeMySQL, "DELETE FROM orders WHERE status = 'draft' ORDER BY id LIMIT 10", "SELECT COUNT(*) AS count FROM (SELECT 1 FROM orders WHERE status = 'draft' ORDER BY id LIMIT 10) AS databot_affected"},
+		{"mysql limited delete without where", constants.DatabaseTypeMySQL, "DELETE FROM orders LIMIT 10", "SELECT COUNT(*) AS count FROM (SELECT 1 FROM orders LIMIT 10) AS databot_affected"},
+		{"mysql low priority", constants.DatabaseTypeMySQL, "DELETE LOW_PRIORITY FROM orders WHERE id = 1", "SELECT COUNT(*) AS count FROM orders WHERE id = 1"},
+		{"update from", constants.DatabaseTypePostgreSQL, "UPDATE orders SET status = c.status FROM customers c WHERE c.id = orders.customer_id", ""},
+		{"delete using", constants.DatabaseTypePostgreSQL, "DELETE FROM orders USING customers WHERE customers.id = orders.customer_id", ""},
+		{"update join", constants.DatabaseTypeMySQL, "UPDATE orders o JOIN customers c ON c.id = o.customer_id SET o.status = 'x'", ""},
+		{"update of several tables", constants.DatabaseTypeMySQL, "UPDATE orders, customers SET orders.status = 'x'", ""},
+		{"mysql multi table delete", constants.DatabaseTypeMySQL, "DELETE o FROM orders o JOIN customers c ON c.id = o.customer_id", ""},
+		{"cte", constants.DatabaseTypePostgreSQL, "WITH old AS (SELECT id FROM orders) DELETE FROM orders WHERE id IN (SELECT id FROM old)", ""},
+		{"several statements", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE id = 1; DELETE FROM customers", ""},
+		{"empty where", constants.DatabaseTypePostgreSQL, "DELETE FROM orders WHERE", ""},
+		{"select", constants.DatabaseTypePostgreSQL, "SELECT * FROM orders", ""},
+		{"insert", constants.DatabaseTypePostgreSQL, "INSERT INTO orders (id) VALUES (1)", ""},
+
+		{"clickhouse delete mutation", constants.DatabaseTypeClickhouse, "ALTER TABLE events DELETE WHERE ts < '2020-01-01'", "SELECT COUNT(*) AS count FROM events WHERE ts < '2020-01-01'"},
+		{"clickhouse update mutation", constants.DatabaseTypeClickhouse, "ALTER TABLE events UPDATE status = 'old' WHERE ts < '2020-01-01'", "SELECT COUNT(*) AS count FROM events WHERE ts < '2020-01-01'"},
+		{"clickhouse alter", constants.DatabaseTypeClickhouse, "ALTER TABLE events ADD COLUMN status String", ""},
+		{"alter outside of clickhouse", constants.DatabaseTypePostgreSQL, "ALTER TABLE events DELETE WHERE id = 1", ""},
+
+		{"mongo delete many", constants.DatabaseTypeMongoDB, `db.orders.deleteMany({"status": "draft"})`, `db.orders.countDocuments({"status": "draft"})`},
+		{"mongo update many", constants.DatabaseTypeMongoDB, `db.orders.updateMany({"status": "draft", "tags": ["a", "b"]}, {"$set": {"status": "old"}})`, `db.orders.countDocuments({"status": "draft", "tags": ["a", "b"]})`},
+		{"mongo delete all", constants.DatabaseTypeMongoDB, `db.orders.deleteMany({})`, `db.orders.countDocuments({})`},
+		{"mongo delete one", constants.DatabaseTypeMongoDB, `db.orders.deleteOne({"_id": 1})`, ""},
+		{"mongo chained call", constants.DatabaseTypeMongoDB, `db.orders.deleteMany({}).limit(1)`, ""},
+
+		{"elasticsearch", constants.DatabaseTypeElasticsearch, `{"query": {"match_all": {}}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AffectedRowsCountQuery(tt.dbType, tt.query); got != tt.want {
+				t.Errorf("AffectedRowsCountQuery(%s, %q) = %q, want %q", tt.dbType, tt.query, got, tt.want)
+			}
+		})
+	}
+}
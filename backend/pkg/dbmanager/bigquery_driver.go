@@ -0,0 +1,504 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const (
+	// bigQueryPageSize is the number of rows read per page of a query result
+	bigQueryPageSize = 1000
+	// bigQueryWaitMs is how long a call waits for a running job before it is polled again
+	bigQueryWaitMs = 10000
+)
+
+// BigQueryClient is the client of a BigQuery connection, stored in Connection.BigQueryObj. The jobs of the queries run
+// in (& are billed to) ProjectID, the tables are read from DatasetProject.Dataset, the project of the dataset is
+// ProjectID unless the database of the connection is "project.dataset" (ex: bigquery-public-data.samples).
+type BigQueryClient struct {
+	Service        *bigquery.Service
+	ProjectID      string
+	DatasetProject string
+	Dataset        string
+	Location       string // Location of the dataset, its queries run there
+}
+
+// datasetPath returns the quoted path of the dataset, ex: `project.dataset`
+func (c *BigQueryClient) datasetPath() string {
+	return "`" + c.DatasetProject + "." + c.Dataset + "`"
+}
+
+// BigQueryDriver implements the DatabaseDriver interface for BigQuery. BigQuery is an analytics service reached through
+// its REST API rather than a database/sql driver: a connection is a project & a dataset authenticated with the key of
+// a service account, queries are jobs billed by the bytes they scan & changes can't be rolled back.
+type BigQueryDriver struct{}
+
+// NewBigQueryDriver creates a new BigQuery driver
+func NewBigQueryDriver() DatabaseDriver {
+	return &BigQueryDriver{}
+}
+
+// bigQueryServiceAccount returns the email of the service account of a key, "" if the key can't be read
+func bigQueryServiceAccount(credentialsJSON string) string {
+	var key struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal([]byte(credentialsJSON), &key); err != nil {
+		return ""
+	}
+	return key.ClientEmail
+}
+
+// Connect creates a BigQuery client & checks that the service account can read the dataset
+func (d *BigQueryDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	if config.ProjectID == "" || config.Database == "" {
+		return nil, fmt.Errorf("the project ID & the dataset of the BigQuery connection are required")
+	}
+	if strings.TrimSpace(config.CredentialsJSON) == "" {
+		return nil, fmt.Errorf("the service account key of the BigQuery connection is required")
+	}
+	if bigQueryServiceAccount(config.CredentialsJSON) == "" {
+		return nil, fmt.Errorf("invalid service account key, expected the JSON key of a service account")
+	}
+
+	// The service refreshes its tokens with the context it is created with, it must outlive the connection
+	service, err := bigquery.NewService(context.Background(), option.WithCredentialsJSON([]byte(config.CredentialsJSON)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BigQuery client: %v", err)
+	}
+
+	client := &BigQueryClient{
+		Service:        service,
+		ProjectID:      config.ProjectID,
+		DatasetProject: config.ProjectID,
+		Dataset:        config.Database,
+	}
+	if project, dataset, ok := strings.Cut(config.Database, "."); ok {
+		client.DatasetProject, client.Dataset = project, dataset
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	dataset, err := service.Datasets.Get(client.DatasetProject, client.Dataset).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset %s.%s: %v", client.DatasetProject, client.Dataset, bigQueryErrorMessage(err))
+	}
+	client.Location = dataset.Location
+	log.Printf("BigQueryDriver -> Connect -> Connected to dataset %s.%s in %s", client.DatasetProject, client.Dataset, client.Location)
+
+	return &Connection{
+		BigQueryObj: client,
+		LastUsed:    time.Now(),
+		Status:      StatusConnected,
+		Config:      config,
+		Subscribers: make(map[string]bool),
+		SubLock:     sync.RWMutex{},
+	}, nil
+}
+
+// bigQueryClientOf returns the BigQuery client of a connection
+func bigQueryClientOf(conn *Connection) (*BigQueryClient, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+	client, ok := conn.BigQueryObj.(*BigQueryClient)
+	if !ok || client == nil {
+		return nil, fmt.Errorf("invalid BigQuery connection, try disconnecting and reconnecting")
+	}
+	return client, nil
+}
+
+// Disconnect releases a BigQuery connection, the client holds no open connection to close
+func (d *BigQueryDriver) Disconnect(conn *Connection) error {
+	return nil
+}
+
+// Ping checks that the dataset of the connection can still be read
+func (d *BigQueryDriver) Ping(conn *Connection) error {
+	client, err := bigQueryClientOf(conn)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.Service.Datasets.Get(client.DatasetProject, client.Dataset).Context(ctx).Do()
+	return err
+}
+
+// IsAlive checks if the BigQuery connection is still valid
+func (d *BigQueryDriver) IsAlive(conn *Connection) bool {
+	return d.Ping(conn) == nil
+}
+
+// ExecuteQuery executes a query on BigQuery
+func (d *BigQueryDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := bigQueryClientOf(conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+	return executeBigQueryQuery(ctx, client, query)
+}
+
+// BeginTx returns a transaction executing the queries as they come: each query is a BigQuery job whose changes are
+// applied once it completes, there is nothing to commit or roll back
+func (d *BigQueryDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
+	if _, err := bigQueryClientOf(conn); err != nil {
+		log.Printf("BigQueryDriver -> BeginTx -> %v", err)
+		return nil
+	}
+	return &BigQueryTransaction{conn: conn}
+}
+
+// bigQueryResult is the result of a BigQuery job
+type bigQueryResult struct {
+	Rows            []map[string]interface{}
+	HasRows         bool // The query returns rows, false for DML & DDL
+	Truncated       bool // Rows past the row limit were left out
+	DMLAffectedRows int64
+	BytesProcessed  int64
+}
+
+// bigQueryPage is a page of the result of a job, from jobs.query or jobs.getQueryResults
+type bigQueryPage struct {
+	Complete        bool
+	Schema          *bigquery.TableSchema
+	Rows            []*bigquery.TableRow
+	PageToken       string
+	TotalRows       uint64
+	BytesProcessed  int64
+	DMLAffectedRows int64
+	Errors          []*bigquery.ErrorProto
+}
+
+// runBigQuery runs a standard SQL query in the dataset of the client & reads at most limit rows of its result (0 reads
+// them all). The ? placeholders of the query are bound to params. The job is cancelled when ctx is.
+func runBigQuery(ctx context.Context, client *BigQueryClient, query string, params []interface{}, limit int) (*bigQueryResult, error) {
+	useLegacySQL := false
+	request := &bigquery.QueryRequest{
+		Query:        query,
+		UseLegacySql: &useLegacySQL,
+		DefaultDataset: &bigquery.DatasetReference{
+			ProjectId: client.DatasetProject,
+			DatasetId: client.Dataset,
+		},
+		Location:      client.Location,
+		MaxResults:    bigQueryPageSize,
+		TimeoutMs:     bigQueryWaitMs,
+		FormatOptions: &bigquery.DataFormatOptions{UseInt64Timestamp: true},
+	}
+	if len(params) > 0 {
+		request.ParameterMode = "POSITIONAL"
+		for _, param := range params {
+			request.QueryParameters = append(request.QueryParameters, bigQueryParameter(param))
+		}
+	}
+
+	response, err := client.Service.Jobs.Query(client.ProjectID, request).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("%s", bigQueryErrorMessage(err))
+	}
+	jobRef := response.JobReference
+	page := &bigQueryPage{
+		Complete:        response.JobComplete,
+		Schema:          response.Schema,
+		Rows:            response.Rows,
+		PageToken:       response.PageToken,
+		TotalRows:       response.TotalRows,
+		BytesProcessed:  response.TotalBytesProcessed,
+		DMLAffectedRows: response.NumDmlAffectedRows,
+		Errors:          response.Errors,
+	}
+
+	// Wait for the job, a query running past the wait of jobs.query is polled
+	for !page.Complete {
+		if page, err = fetchBigQueryPage(ctx, client, jobRef, ""); err != nil {
+			cancelBigQueryJob(ctx, client, jobRef)
+			return nil, err
+		}
+	}
+	if len(page.Errors) > 0 {
+		return nil, fmt.Errorf("%s", page.Errors[0].Message)
+	}
+
+	result := &bigQueryResult{
+		HasRows:         page.Schema != nil && len(page.Schema.Fields) > 0,
+		DMLAffectedRows: page.DMLAffectedRows,
+		BytesProcessed:  page.BytesProcessed,
+	}
+	if !result.HasRows {
+		return result, nil
+	}
+
+	schema, totalRows := page.Schema, page.TotalRows
+	rows := page.Rows
+	for page.PageToken != "" && (limit <= 0 || len(rows) < limit) {
+		if page, err = fetchBigQueryPage(ctx, client, jobRef, page.PageToken); err != nil {
+			return nil, err
+		}
+		rows = append(rows, page.Rows...)
+	}
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	result.Truncated = uint64(len(rows)) < totalRows
+
+	result.Rows = make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		result.Rows = append(result.Rows, bigQueryRecord(schema.Fields, row.F))
+	}
+	return result, nil
+}
+
+// fetchBigQueryPage reads a page of the result of a job, waiting for the job if it is still running
+func fetchBigQueryPage(ctx context.Context, client *BigQueryClient, jobRef *bigquery.JobReference, pageToken string) (*bigQueryPage, error) {
+	if jobRef == nil {
+		return nil, fmt.Errorf("the query has no job to read the results of")
+	}
+	call := client.Service.Jobs.GetQueryResults(jobRef.ProjectId, jobRef.JobId).
+		Location(jobRef.Location).
+		MaxResults(bigQueryPageSize).
+		TimeoutMs(bigQueryWaitMs).
+		FormatOptionsUseInt64Timestamp(true).
+		Context(ctx)
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	response, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("%s", bigQueryErrorMessage(err))
+	}
+	return &bigQueryPage{
+		Complete:        response.JobComplete,
+		Schema:          response.Schema,
+		Rows:            response.Rows,
+		PageToken:       response.PageToken,
+		TotalRows:       response.TotalRows,
+		BytesProcessed:  response.TotalBytesProcessed,
+		DMLAffectedRows: response.NumDmlAffectedRows,
+		Errors:          response.Errors,
+	}, nil
+}
+
+// cancelBigQueryJob cancels a job left running by a cancelled or failed query, so that it stops scanning (& billing)
+func cancelBigQueryJob(ctx context.Context, client *BigQueryClient, jobRef *bigquery.JobReference) {
+	if jobRef == nil || ctx.Err() == nil {
+		return
+	}
+	cancelCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := client.Service.Jobs.Cancel(jobRef.ProjectId, jobRef.JobId).Location(jobRef.Location).Context(cancelCtx).Do(); err != nil {
+		log.Printf("BigQueryDriver -> cancelBigQueryJob -> Failed to cancel job %s: %v", jobRef.JobId, err)
+	}
+}
+
+// bigQueryErrorMessage returns the message of a BigQuery API error without the HTTP details
+func bigQueryErrorMessage(err error) string {
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return err.Error()
+}
+
+// bigQueryParameter converts the value of a ? placeholder to a positional query parameter
+func bigQueryParameter(value interface{}) *bigquery.QueryParameter {
+	paramType, paramValue := "STRING", fmt.Sprint(value)
+	switch v := value.(type) {
+	case int, int32, int64, uint, uint32, uint64:
+		paramType = "INT64"
+	case float32, float64:
+		paramType = "FLOAT64"
+	case bool:
+		paramType, paramValue = "BOOL", strconv.FormatBool(v)
+	case time.Time:
+		paramType, paramValue = "TIMESTAMP", v.UTC().Format(time.RFC3339Nano)
+	}
+	return &bigquery.QueryParameter{
+		ParameterType:  &bigquery.QueryParameterType{Type: paramType},
+		ParameterValue: &bigquery.QueryParameterValue{Value: paramValue},
+	}
+}
+
+// bigQueryRecord converts the cells of a row to a record, the API returns every value as a string
+func bigQueryRecord(fields []*bigquery.TableFieldSchema, cells []*bigquery.TableCell) map[string]interface{} {
+	record := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		if i < len(cells) {
+			record[field.Name] = bigQueryValue(field, cells[i].V, true)
+		}
+	}
+	return record
+}
+
+// bigQueryValue converts the value of a cell to the Go type of its field: the repeated fields to slices, the records
+// to maps, the numbers, booleans & timestamps (microseconds since epoch) to numbers, booleans & RFC 3339 strings. The
+// NUMERIC values stay strings to keep their precision.
+func bigQueryValue(field *bigquery.TableFieldSchema, value interface{}, checkRepeated bool) interface{} {
+	if value == nil {
+		return nil
+	}
+	if checkRepeated && field.Mode == "REPEATED" {
+		items, _ := value.([]interface{})
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if cell, ok := item.(map[string]interface{}); ok {
+				values = append(values, bigQueryValue(field, cell["v"], false))
+			}
+		}
+		return values
+	}
+	if field.Type == "RECORD" || field.Type == "STRUCT" {
+		row, _ := value.(map[string]interface{})
+		items, _ := row["f"].([]interface{})
+		cells := make([]*bigquery.TableCell, 0, len(items))
+		for _, item := range items {
+			cell, _ := item.(map[string]interface{})
+			cells = append(cells, &bigquery.TableCell{V: cell["v"]})
+		}
+		return bigQueryRecord(field.Fields, cells)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+	switch field.Type {
+	case "INTEGER", "INT64":
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "FLOAT64":
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n
+		}
+	case "BOOLEAN", "BOOL":
+		return text == "true"
+	case "TIMESTAMP":
+		if micros, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return time.UnixMicro(micros).UTC().Format(time.RFC3339Nano)
+		}
+	case "JSON":
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(text), &parsed); err == nil {
+			return parsed
+		}
+	}
+	return text
+}
+
+// executeBigQueryQuery executes a query (a single statement or a script) on BigQuery, the rows are read up to the row
+// limit of the context. The bytes the query scanned are reported as a warning, they are what the query is billed for.
+func executeBigQueryQuery(ctx context.Context, client *BigQueryClient, query string) *QueryExecutionResult {
+	startTime := time.Now()
+	result := &QueryExecutionResult{}
+
+	if strings.TrimSpace(query) == "" {
+		result.Error = &dtos.QueryError{
+			Message: "Empty query",
+			Code:    "EXECUTION_ERROR",
+		}
+		return result
+	}
+
+	limit := resultRowLimit(ctx)
+	jobResult, err := runBigQuery(ctx, client, query, nil, limit)
+	if err != nil {
+		if ctx.Err() != nil {
+			result.Error = &dtos.QueryError{
+				Message: "Query execution cancelled",
+				Code:    "EXECUTION_CANCELLED",
+			}
+			return result
+		}
+		result.Error = &dtos.QueryError{
+			Message: err.Error(),
+			Code:    "EXECUTION_ERROR",
+		}
+		return result
+	}
+
+	if jobResult.HasRows {
+		if jobResult.Truncated {
+			result.Warnings = append(result.Warnings, resultTruncatedWarning(limit))
+		}
+		result.Result = map[string]interface{}{
+			"results": jobResult.Rows,
+		}
+	} else if jobResult.DMLAffectedRows > 0 {
+		result.Result = map[string]interface{}{
+			"rowsAffected": jobResult.DMLAffectedRows,
+			"message":      fmt.Sprintf("%d row(s) affected", jobResult.DMLAffectedRows),
+		}
+	} else {
+		result.Result = map[string]interface{}{
+			"message": "Query performed successfully",
+		}
+	}
+	if jobResult.BytesProcessed > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("BigQuery scanned %s for this query", formatByteSize(jobResult.BytesProcessed)))
+	}
+
+	// Calculate execution time
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result.Result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Code:    "JSON_MARSHAL_FAILED",
+				Message: err.Error(),
+				Details: "Failed to marshal query results",
+			},
+		}
+	}
+	result.ResultJSON = string(resultJSON)
+
+	return result
+}
+
+// GetSchema retrieves the database schema
+func (d *BigQueryDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQueryDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewBigQuerySchemaFetcher(db).GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *BigQueryDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQueryDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+	return NewBigQuerySchemaFetcher(db).GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *BigQueryDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQueryDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewBigQuerySchemaFetcher(db).FetchExampleRecords(ctx, db, table, limit)
+}
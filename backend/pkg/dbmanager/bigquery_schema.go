@@ -0,0 +1,428 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+// BigQuery tables have no indexes, the partitioning & clustering columns of a table are listed as these indexes so that
+// the LLM filters on them (a filter on the partitioning column limits the bytes a query scans, see BigQuerySimplifier)
+const (
+	bigQueryPartitionIndex = "PARTITION"
+	bigQueryClusterIndex   = "CLUSTER"
+)
+
+// BigQuerySchemaFetcher implements schema fetching for BigQuery, from the INFORMATION_SCHEMA views of the dataset. Every
+// view is read once for all the tables: BigQuery bills each metadata query, however small.
+type BigQuerySchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewBigQuerySchemaFetcher creates a new BigQuery schema fetcher
+func NewBigQuerySchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &BigQuerySchemaFetcher{db: db}
+}
+
+// bigQueryClientOfExecutor returns the BigQuery client of an executor
+func bigQueryClientOfExecutor(db DBExecutor) (*BigQueryClient, error) {
+	executor, ok := db.(*BigQueryExecutor)
+	if !ok {
+		return nil, fmt.Errorf("not a BigQuery connection")
+	}
+	return executor.client, nil
+}
+
+// informationSchema returns the path of an INFORMATION_SCHEMA view of the dataset, ex: `project.dataset`.INFORMATION_SCHEMA.COLUMNS
+func (f *BigQuerySchemaFetcher) informationSchema(view string) (string, error) {
+	client, err := bigQueryClientOfExecutor(f.db)
+	if err != nil {
+		return "", err
+	}
+	return client.datasetPath() + ".INFORMATION_SCHEMA." + view, nil
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *BigQuerySchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("BigQuerySchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQuerySchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("BigQuerySchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+	log.Printf("BigQuerySchemaFetcher -> GetSchema -> Successfully fetched schema with %d tables", len(schema.Tables))
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	log.Printf("BigQuerySchemaFetcher -> GetSchema -> Filtered schema to %d tables", len(filteredSchema.Tables))
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the full dataset schema, the views are tables too so that their columns are known
+func (f *BigQuerySchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	tables, views, err := f.fetchTables(ctx)
+	if err != nil {
+		log.Printf("BigQuerySchemaFetcher -> FetchSchema -> Error fetching tables: %v", err)
+		return nil, err
+	}
+	schema.Views = views
+	log.Printf("BigQuerySchemaFetcher -> FetchSchema -> Processing %d tables and %d views", len(tables), len(views))
+
+	columns, partitions, clusters, err := f.fetchColumns(ctx)
+	if err != nil {
+		log.Printf("BigQuerySchemaFetcher -> FetchSchema -> Error fetching columns: %v", err)
+		return nil, err
+	}
+	columnComments := f.fetchColumnDescriptions(ctx)
+	options := f.fetchTableOptions(ctx)
+	rowCounts := f.fetchRowCounts(ctx)
+
+	for _, table := range tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		tableSchema := TableSchema{
+			Name:        table,
+			Columns:     columns[table],
+			Indexes:     make(map[string]IndexInfo),
+			ForeignKeys: make(map[string]ForeignKey),
+			Constraints: make(map[string]ConstraintInfo),
+			RowCount:    rowCounts[table],
+		}
+		if tableSchema.Columns == nil {
+			tableSchema.Columns = make(map[string]ColumnInfo)
+		}
+		for column, comment := range columnComments[table] {
+			if col, ok := tableSchema.Columns[column]; ok {
+				col.Comment = comment
+				tableSchema.Columns[column] = col
+			}
+		}
+		if len(partitions[table]) > 0 {
+			tableSchema.Indexes[bigQueryPartitionIndex] = IndexInfo{Name: bigQueryPartitionIndex, Columns: partitions[table]}
+		}
+		if len(clusters[table]) > 0 {
+			tableSchema.Indexes[bigQueryClusterIndex] = IndexInfo{Name: bigQueryClusterIndex, Columns: clusters[table]}
+		}
+		tableSchema.Comment = describeBigQueryTable(options[table], partitions[table], clusters[table])
+		log.Printf("BigQuerySchemaFetcher -> FetchSchema -> Table %s: %d columns, partitioned by %v, clustered by %v, %d rows",
+			table, len(tableSchema.Columns), partitions[table], clusters[table], tableSchema.RowCount)
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[table] = tableSchema
+	}
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("BigQuerySchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d tables and %d views",
+		len(schema.Tables), len(schema.Views))
+	return schema, nil
+}
+
+// describeBigQueryTable writes the description of a table & how its partitions are pruned, so that the LLM writes
+// queries scanning as few bytes as possible
+func describeBigQueryTable(options map[string]string, partitions, clusters []string) string {
+	var parts []string
+	if description := options["description"]; description != "" {
+		parts = append(parts, description)
+	}
+	if len(partitions) > 0 {
+		partitioning := fmt.Sprintf("Partitioned by %s", strings.Join(partitions, ", "))
+		if strings.EqualFold(options["require_partition_filter"], "true") {
+			partitioning += ", queries must filter on it"
+		}
+		parts = append(parts, partitioning)
+	}
+	if len(clusters) > 0 {
+		parts = append(parts, fmt.Sprintf("Clustered by %s", strings.Join(clusters, ", ")))
+	}
+	return strings.Join(parts, ". ")
+}
+
+// bigQueryString reads a string column of an INFORMATION_SCHEMA row
+func bigQueryString(row map[string]interface{}, column string) string {
+	if value, ok := row[column].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// fetchTables retrieves the tables of the dataset & the definition of its views
+func (f *BigQuerySchemaFetcher) fetchTables(_ context.Context) ([]string, map[string]ViewSchema, error) {
+	view, err := f.informationSchema("TABLES")
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(fmt.Sprintf("SELECT table_name, table_type, ddl FROM %s ORDER BY table_name", view), &rows); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+
+	var tables []string
+	views := make(map[string]ViewSchema)
+	for _, row := range rows {
+		name := bigQueryString(row, "table_name")
+		tables = append(tables, name)
+		if tableType := bigQueryString(row, "table_type"); tableType == "VIEW" || tableType == "MATERIALIZED VIEW" {
+			views[name] = ViewSchema{
+				Name:       name,
+				Definition: bigQueryString(row, "ddl"),
+			}
+		}
+	}
+	log.Printf("BigQuerySchemaFetcher -> fetchTables -> Found %d tables: %v", len(tables), tables)
+	return tables, views, nil
+}
+
+// fetchColumns retrieves the columns of every table, with the partitioning columns & the clustering columns in
+// clustering order
+func (f *BigQuerySchemaFetcher) fetchColumns(_ context.Context) (map[string]map[string]ColumnInfo, map[string][]string, map[string][]string, error) {
+	view, err := f.informationSchema("COLUMNS")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	query := fmt.Sprintf(`SELECT table_name, column_name, data_type, is_nullable, is_partitioning_column,
+        clustering_ordinal_position, column_default
+        FROM %s ORDER BY table_name, ordinal_position`, view)
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch columns: %v", err)
+	}
+
+	columns := make(map[string]map[string]ColumnInfo)
+	partitions := make(map[string][]string)
+	clusters := make(map[string][]string)
+	clusterPositions := make(map[string]map[string]int64)
+	for _, row := range rows {
+		table, name := bigQueryString(row, "table_name"), bigQueryString(row, "column_name")
+		if columns[table] == nil {
+			columns[table] = make(map[string]ColumnInfo)
+			clusterPositions[table] = make(map[string]int64)
+		}
+		defaultValue := bigQueryString(row, "column_default")
+		if defaultValue == "NULL" {
+			defaultValue = ""
+		}
+		columns[table][name] = ColumnInfo{
+			Name:         name,
+			Type:         bigQueryString(row, "data_type"),
+			IsNullable:   bigQueryString(row, "is_nullable") == "YES",
+			DefaultValue: defaultValue,
+		}
+		if bigQueryString(row, "is_partitioning_column") == "YES" {
+			partitions[table] = append(partitions[table], name)
+		}
+		if position, ok := row["clustering_ordinal_position"].(int64); ok {
+			clusters[table] = append(clusters[table], name)
+			clusterPositions[table][name] = position
+		}
+	}
+	for table, names := range clusters {
+		positions := clusterPositions[table]
+		sort.SliceStable(names, func(i, j int) bool { return positions[names[i]] < positions[names[j]] })
+	}
+	return columns, partitions, clusters, nil
+}
+
+// fetchColumnDescriptions retrieves the descriptions of the top level columns, by table
+func (f *BigQuerySchemaFetcher) fetchColumnDescriptions(_ context.Context) map[string]map[string]string {
+	descriptions := make(map[string]map[string]string)
+	view, err := f.informationSchema("COLUMN_FIELD_PATHS")
+	if err != nil {
+		return descriptions
+	}
+	query := fmt.Sprintf("SELECT table_name, column_name, description FROM %s WHERE field_path = column_name AND description IS NOT NULL", view)
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		log.Printf("BigQuerySchemaFetcher -> fetchColumnDescriptions -> Error, returning no descriptions: %v", err)
+		return descriptions
+	}
+	for _, row := range rows {
+		table := bigQueryString(row, "table_name")
+		if descriptions[table] == nil {
+			descriptions[table] = make(map[string]string)
+		}
+		descriptions[table][bigQueryString(row, "column_name")] = bigQueryString(row, "description")
+	}
+	return descriptions
+}
+
+// fetchTableOptions retrieves the description & partition filter requirement of the tables, by table & option name.
+// The values are SQL literals, ex: "a description" or true.
+func (f *BigQuerySchemaFetcher) fetchTableOptions(_ context.Context) map[string]map[string]string {
+	options := make(map[string]map[string]string)
+	view, err := f.informationSchema("TABLE_OPTIONS")
+	if err != nil {
+		return options
+	}
+	query := fmt.Sprintf("SELECT table_name, option_name, option_value FROM %s WHERE option_name IN ('description', 'require_partition_filter')", view)
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		log.Printf("BigQuerySchemaFetcher -> fetchTableOptions -> Error, returning no options: %v", err)
+		return options
+	}
+	for _, row := range rows {
+		table := bigQueryString(row, "table_name")
+		if options[table] == nil {
+			options[table] = make(map[string]string)
+		}
+		value := bigQueryString(row, "option_value")
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		options[table][bigQueryString(row, "option_name")] = value
+	}
+	return options
+}
+
+// fetchRowCounts retrieves the row count of the tables from the __TABLES__ metadata, counting the rows would scan them
+func (f *BigQuerySchemaFetcher) fetchRowCounts(_ context.Context) map[string]int64 {
+	counts := make(map[string]int64)
+	client, err := bigQueryClientOfExecutor(f.db)
+	if err != nil {
+		return counts
+	}
+	query := fmt.Sprintf("SELECT table_id, row_count FROM `%s.%s.__TABLES__`", client.DatasetProject, client.Dataset)
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows(query, &rows); err != nil {
+		log.Printf("BigQuerySchemaFetcher -> fetchRowCounts -> Error, returning no row counts: %v", err)
+		return counts
+	}
+	for _, row := range rows {
+		if count, ok := row["row_count"].(int64); ok {
+			counts[bigQueryString(row, "table_id")] = count
+		}
+	}
+	return counts
+}
+
+// GetTableChecksum calculates a checksum for a table's structure from its DDL
+func (f *BigQuerySchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	view, err := f.informationSchema("TABLES")
+	if err != nil {
+		return "", err
+	}
+	var rows []map[string]interface{}
+	if err := db.QueryRows(fmt.Sprintf("SELECT ddl FROM %s WHERE table_name = ?", view), &rows, table); err != nil {
+		return "", fmt.Errorf("failed to get table definition: %v", err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(bigQueryString(rows[0], "ddl")))), nil
+}
+
+// FetchExampleRecords retrieves sample records from a table through the tabledata API: unlike a SELECT ... LIMIT,
+// which is billed for the whole columns it reads, listing rows is free. Views can't be listed, they have no records.
+func (f *BigQuerySchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQuerySchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	client, err := bigQueryClientOfExecutor(db)
+	if err != nil {
+		return nil, err
+	}
+	tableInfo, err := client.Service.Tables.Get(client.DatasetProject, client.Dataset, table).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch table %s: %v", table, bigQueryErrorMessage(err))
+	}
+	if tableInfo.Type == "VIEW" || tableInfo.Type == "MATERIALIZED_VIEW" || tableInfo.Schema == nil {
+		return []map[string]interface{}{}, nil
+	}
+
+	data, err := client.Service.Tabledata.List(client.DatasetProject, client.Dataset, table).
+		MaxResults(int64(limit)).
+		FormatOptionsUseInt64Timestamp(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		log.Printf("BigQuerySchemaFetcher -> FetchExampleRecords -> Error fetching records from table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for table %s: %v", table, bigQueryErrorMessage(err))
+	}
+
+	records := make([]map[string]interface{}, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		records = append(records, bigQueryRecord(tableInfo.Schema.Fields, bigQueryRowCells(row)))
+	}
+	log.Printf("BigQuerySchemaFetcher -> FetchExampleRecords -> Fetched %d records from table %s", len(records), table)
+	return records, nil
+}
+
+// bigQueryRowCells returns the cells of a row listed by the tabledata API
+func bigQueryRowCells(row *bigquery.TableRow) []*bigquery.TableCell {
+	if row == nil {
+		return nil
+	}
+	return row.F
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected tables
+func (f *BigQuerySchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool, len(selectedTables))
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: schema.UpdatedAt,
+	}
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+	for viewName, view := range schema.Views {
+		if selectedTablesMap[viewName] {
+			filteredSchema.Views[viewName] = view
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+	return filteredSchema
+}
@@ -0,0 +1,77 @@
+package dbmanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BigQuerySimplifier implements the SchemaSimplifier interface for BigQuery
+type BigQuerySimplifier struct{}
+
+// SimplifyDataType converts BigQuery data types to simplified versions for LLM
+func (s *BigQuerySimplifier) SimplifyDataType(dbType string) string {
+	upperType := strings.ToUpper(strings.TrimSpace(dbType))
+
+	// Nested types, checked first as their element types match the other cases
+	if strings.HasPrefix(upperType, "ARRAY") {
+		return "array"
+	}
+	if strings.HasPrefix(upperType, "STRUCT") || upperType == "RECORD" {
+		return "struct"
+	}
+
+	// Drop the parameters, ex: NUMERIC(10, 2) or STRING(255)
+	if idx := strings.Index(upperType, "("); idx != -1 {
+		upperType = strings.TrimSpace(upperType[:idx])
+	}
+
+	switch upperType {
+	case "INT64", "INT", "SMALLINT", "INTEGER", "BIGINT", "TINYINT", "BYTEINT":
+		return "integer"
+	case "NUMERIC", "BIGNUMERIC", "DECIMAL", "BIGDECIMAL", "FLOAT64", "FLOAT":
+		return "number"
+	case "DATE", "DATETIME", "TIME", "TIMESTAMP":
+		return "datetime"
+	case "STRING":
+		return "string"
+	case "BYTES":
+		return "binary"
+	case "BOOL", "BOOLEAN":
+		return "boolean"
+	case "JSON":
+		return "json"
+	default:
+		// GEOGRAPHY, INTERVAL & RANGE are kept as is
+		return dbType
+	}
+}
+
+// GetColumnConstraints returns a list of constraints for a column, with the partitioning & clustering of its table
+func (s *BigQuerySimplifier) GetColumnConstraints(col ColumnInfo, table TableSchema) []string {
+	var constraints []string
+
+	if !col.IsNullable {
+		constraints = append(constraints, "NOT NULL")
+	}
+
+	if col.DefaultValue != "" {
+		constraints = append(constraints, fmt.Sprintf("DEFAULT %s", col.DefaultValue))
+	}
+
+	// A filter on the partitioning column prunes the partitions a query scans
+	for _, column := range table.Indexes[bigQueryPartitionIndex].Columns {
+		if column == col.Name {
+			constraints = append(constraints, "PARTITIONING COLUMN")
+			break
+		}
+	}
+
+	for i, column := range table.Indexes[bigQueryClusterIndex].Columns {
+		if column == col.Name {
+			constraints = append(constraints, fmt.Sprintf("CLUSTERING KEY %d", i+1))
+			break
+		}
+	}
+
+	return constraints
+}
@@ -0,0 +1,37 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+)
+
+// BigQueryTransaction implements the Transaction interface for BigQuery. BigQuery has no transaction spanning several
+// jobs: each query is applied once its job completes, Commit & Rollback have nothing to do. A failed script is rolled
+// back by BigQuery itself, a completed one can't be.
+type BigQueryTransaction struct {
+	conn *Connection
+}
+
+// ExecuteQuery executes a query as its own job
+func (t *BigQueryTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := bigQueryClientOf(t.conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+	return executeBigQueryQuery(ctx, client, query)
+}
+
+// Commit does nothing, the query is already applied
+func (t *BigQueryTransaction) Commit() error {
+	return nil
+}
+
+// Rollback does nothing, BigQuery can't undo a completed job
+func (t *BigQueryTransaction) Rollback() error {
+	return nil
+}
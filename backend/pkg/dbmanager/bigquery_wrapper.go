@@ -0,0 +1,161 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// bigQueryExecutorTimeout bounds the queries run through a BigQueryExecutor, its methods have no context
+const bigQueryExecutorTimeout = 2 * time.Minute
+
+// BigQueryExecutor implements DBExecutor for BigQuery, the SQL runs as BigQuery jobs in the dataset of the connection
+type BigQueryExecutor struct {
+	BaseWrapper
+	client *BigQueryClient
+	conn   *Connection
+}
+
+// NewBigQueryExecutor creates a new BigQuery executor
+func NewBigQueryExecutor(conn *Connection, manager *Manager, chatID string) (*BigQueryExecutor, error) {
+	client, err := bigQueryClientOf(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &BigQueryExecutor{
+		BaseWrapper: BaseWrapper{
+			manager: manager,
+			chatID:  chatID,
+		},
+		client: client,
+		conn:   conn,
+	}, nil
+}
+
+// GetDB returns nil for BigQuery as it doesn't use database/sql
+func (e *BigQueryExecutor) GetDB() *sql.DB {
+	return nil
+}
+
+// GetConnection returns the underlying connection
+func (e *BigQueryExecutor) GetConnection() *Connection {
+	return e.conn
+}
+
+// run runs a query with its ? placeholders bound to values & returns all its rows
+func (e *BigQueryExecutor) run(query string, values []interface{}) ([]map[string]interface{}, error) {
+	if err := e.updateUsage(); err != nil {
+		return nil, fmt.Errorf("failed to update usage: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), bigQueryExecutorTimeout)
+	defer cancel()
+	result, err := runBigQuery(ctx, e.client, query, values, 0)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// Raw executes a raw SQL query
+func (e *BigQueryExecutor) Raw(sql string, values ...interface{}) error {
+	_, err := e.run(sql, values)
+	return err
+}
+
+// Exec executes a SQL statement
+func (e *BigQueryExecutor) Exec(sql string, values ...interface{}) error {
+	_, err := e.run(sql, values)
+	return err
+}
+
+// Query executes a SQL query and decodes the result into dest through JSON: a slice of structs with json tags named
+// after the columns, or a slice of scalars for a single column
+func (e *BigQueryExecutor) Query(sql string, dest interface{}, values ...interface{}) error {
+	rows, err := e.run(sql, values)
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 && len(rows[0]) == 1 {
+		// A single column is decoded as a list of its values, ex: []string of table names
+		var column []interface{}
+		for _, row := range rows {
+			for _, value := range row {
+				column = append(column, value)
+			}
+		}
+		if data, err := json.Marshal(column); err == nil && json.Unmarshal(data, dest) == nil {
+			return nil
+		}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to encode the query result: %v", err)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// QueryRows executes a SQL query and scans the result into dest
+func (e *BigQueryExecutor) QueryRows(sql string, dest *[]map[string]interface{}, values ...interface{}) error {
+	rows, err := e.run(sql, values)
+	if err != nil {
+		return err
+	}
+	*dest = rows
+	return nil
+}
+
+// Close does nothing, the client is shared by the connections of the pool
+func (e *BigQueryExecutor) Close() error {
+	return nil
+}
+
+// GetSchema fetches the current database schema
+func (e *BigQueryExecutor) GetSchema(ctx context.Context) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQueryExecutor -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Get the schema fetcher factory for BigQuery
+	fetcherFactory, exists := e.manager.fetchers["bigquery"]
+	if !exists {
+		return nil, fmt.Errorf("BigQuery schema fetcher not found")
+	}
+
+	// Create a schema fetcher for this connection
+	fetcher := fetcherFactory(e)
+
+	// Get selected collections from the chat service if available
+	selectedTables := []string{"ALL"}
+	if e.manager.streamHandler != nil {
+		selectedCollections, err := e.manager.streamHandler.GetSelectedCollections(e.chatID)
+		if err == nil && selectedCollections != "ALL" && selectedCollections != "" {
+			selectedTables = strings.Split(selectedCollections, ",")
+			log.Printf("BigQueryExecutor -> GetSchema -> Using selected collections for chat %s: %v", e.chatID, selectedTables)
+		}
+	}
+
+	return fetcher.GetSchema(ctx, e, selectedTables)
+}
+
+// GetTableChecksum calculates checksum for a single table
+func (e *BigQueryExecutor) GetTableChecksum(ctx context.Context, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("BigQueryExecutor -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	// Get the schema fetcher factory for BigQuery
+	fetcherFactory, exists := e.manager.fetchers["bigquery"]
+	if !exists {
+		return "", fmt.Errorf("BigQuery schema fetcher not found")
+	}
+
+	return fetcherFactory(e).GetTableChecksum(ctx, e, table)
+}
@@ -0,0 +1,85 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"strings"
+)
+
+// Result column names follow each engine's identifier case folding, which the LLM doesn't always anticipate
+// when writing the example result of a query:
+//
+//   - PostgreSQL, YugabyteDB: unquoted identifiers and aliases are folded to lower case, `AS "TotalSales"` keeps its case
+//   - MySQL: column names and aliases are returned as written in the query
+//   - ClickHouse: identifiers are case-sensitive and returned as written
+//   - MongoDB: field names are case-sensitive and returned as stored
+const (
+	CaseFoldingLower    = "lower"
+	CaseFoldingPreserve = "preserve"
+)
+
+// ColumnCaseFolding returns how the given database type folds unquoted column names in results
+func ColumnCaseFolding(dbType string) string {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return CaseFoldingLower
+	default:
+		return CaseFoldingPreserve
+	}
+}
+
+// FoldExampleResultKeys applies the case folding of the database type to the keys of an LLM example result,
+// so that they match the columns the query will actually return. Keys quoted in the query keep their case.
+func FoldExampleResultKeys(dbType, query string, records []interface{}) []interface{} {
+	if ColumnCaseFolding(dbType) != CaseFoldingLower {
+		return records
+	}
+
+	quoted := make(map[string]bool)
+	for _, token := range tokenizeSQL(query) {
+		if token.kind == sqlTokenQuotedIdent {
+			quoted[unquoteIdentifier(token.text)] = true
+		}
+	}
+
+	for i, record := range records {
+		recordMap, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		folded := make(map[string]interface{}, len(recordMap))
+		for key, value := range recordMap {
+			if !quoted[key] {
+				key = strings.ToLower(key)
+			}
+			folded[key] = value
+		}
+		records[i] = folded
+	}
+	return records
+}
+
+// AlignRecordKeys renames the keys of the records that match one of the columns only case-insensitively to the
+// column's casing. Returns whether any key was renamed.
+func AlignRecordKeys(records []map[string]interface{}, columns []string) bool {
+	byFolded := make(map[string]string, len(columns))
+	for _, column := range columns {
+		byFolded[strings.ToLower(column)] = column
+	}
+
+	renamed := false
+	for _, record := range records {
+		for key, value := range record {
+			column, ok := byFolded[strings.ToLower(key)]
+			if !ok || column == key {
+				continue
+			}
+			if _, exists := record[column]; exists {
+				continue
+			}
+			delete(record, key)
+			record[column] = value
+			renamed = true
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,340 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// cassandraDefaultPort is the port of the native protocol of Cassandra & ScyllaDB
+	cassandraDefaultPort = "9042"
+	// cassandraPageSize is the number of rows fetched per page of a result, the next pages are fetched while reading
+	cassandraPageSize = 1000
+)
+
+// cassandraAllowFilteringRegex matches the ALLOW FILTERING clause of a CQL query
+var cassandraAllowFilteringRegex = regexp.MustCompile(`(?i)\ballow\s+filtering\b`)
+
+// CassandraClient is the client of a Cassandra or ScyllaDB connection, stored in Connection.CassandraObj. The queries
+// run in Keyspace (the database of the connection) unless they name their own keyspace.
+type CassandraClient struct {
+	Session  *gocql.Session
+	Keyspace string
+}
+
+// CassandraDriver implements the DatabaseDriver interface for Cassandra & ScyllaDB, which speak the same native
+// protocol & CQL. A connection is a gocql session on the keyspace of the connection, CQL has no transactions.
+type CassandraDriver struct{}
+
+// NewCassandraDriver creates a new Cassandra driver
+func NewCassandraDriver() DatabaseDriver {
+	return &CassandraDriver{}
+}
+
+// Connect opens a gocql session on the keyspace of the connection, the other nodes of the cluster are discovered
+// from the host
+func (d *CassandraDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("the host of the Cassandra connection is required")
+	}
+	keyspace := strings.TrimSpace(config.Database)
+	if keyspace == "" {
+		return nil, fmt.Errorf("the keyspace of the Cassandra connection is required")
+	}
+
+	port := cassandraDefaultPort
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s: %v", port, err)
+	}
+	tlsConfig, tempFiles, err := certificateTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := gocql.NewCluster(config.Host)
+	cluster.Port = portNumber
+	cluster.Keyspace = keyspace
+	cluster.ConnectTimeout = 15 * time.Second
+	// Through an SSH tunnel only its local end can be reached, the addresses of the other nodes can't
+	cluster.DisableInitialHostLookup = config.sshTunneled
+	if config.Username != nil && *config.Username != "" {
+		authenticator := gocql.PasswordAuthenticator{Username: *config.Username}
+		if config.Password != nil {
+			authenticator.Password = *config.Password
+		}
+		cluster.Authenticator = authenticator
+	}
+	if tlsConfig != nil {
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: !tlsConfig.InsecureSkipVerify,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+		return nil, fmt.Errorf("failed to connect to Cassandra at %s:%s: %v", config.Host, port, err)
+	}
+	log.Printf("CassandraDriver -> Connect -> Connected to keyspace %s at %s:%s", keyspace, config.Host, port)
+
+	return &Connection{
+		CassandraObj: &CassandraClient{Session: session, Keyspace: keyspace},
+		LastUsed:     time.Now(),
+		Status:       StatusConnected,
+		Config:       config,
+		Subscribers:  make(map[string]bool),
+		SubLock:      sync.RWMutex{},
+		TempFiles:    tempFiles,
+	}, nil
+}
+
+// cassandraClientOf returns the Cassandra client of a connection
+func cassandraClientOf(conn *Connection) (*CassandraClient, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+	client, ok := conn.CassandraObj.(*CassandraClient)
+	if !ok || client == nil || client.Session == nil {
+		return nil, fmt.Errorf("invalid Cassandra connection, try disconnecting and reconnecting")
+	}
+	return client, nil
+}
+
+// Disconnect closes the session & removes the certificate files of the connection
+func (d *CassandraDriver) Disconnect(conn *Connection) error {
+	if client, err := cassandraClientOf(conn); err == nil {
+		client.Session.Close()
+	}
+	for _, file := range conn.TempFiles {
+		os.Remove(file)
+	}
+	return nil
+}
+
+// Ping checks that the cluster still answers queries
+func (d *CassandraDriver) Ping(conn *Connection) error {
+	client, err := cassandraClientOf(conn)
+	if err != nil {
+		return err
+	}
+	if client.Session.Closed() {
+		return fmt.Errorf("the Cassandra session is closed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.Session.Query("SELECT release_version FROM system.local").WithContext(ctx).Exec()
+}
+
+// IsAlive checks if the Cassandra connection is still valid
+func (d *CassandraDriver) IsAlive(conn *Connection) bool {
+	return d.Ping(conn) == nil
+}
+
+// ExecuteQuery executes a query on Cassandra
+func (d *CassandraDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := cassandraClientOf(conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+	return executeCassandraQuery(ctx, client, query)
+}
+
+// BeginTx returns a transaction executing the queries as they come, CQL has no transactions
+func (d *CassandraDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
+	if _, err := cassandraClientOf(conn); err != nil {
+		log.Printf("CassandraDriver -> BeginTx -> %v", err)
+		return nil
+	}
+	return &CassandraTransaction{conn: conn}
+}
+
+// cassandraResult is the result of a CQL statement
+type cassandraResult struct {
+	Rows      []map[string]interface{}
+	HasRows   bool     // The statement returns rows, false for the writes & the schema changes
+	Truncated bool     // Rows past the row limit were left out
+	Warnings  []string // Warnings of the server, ex: a batch too large or too many tombstones read
+}
+
+// runCassandraQuery runs a single CQL statement & reads at most limit rows of its result (0 reads them all), the pages
+// are fetched as the rows are read. The ? placeholders of the statement are bound to values. Reading stops with
+// errResultTooLarge once the rows pass the byte limit of the context.
+func runCassandraQuery(ctx context.Context, client *CassandraClient, query string, values []interface{}, limit int) (*cassandraResult, error) {
+	statement := strings.TrimSuffix(strings.TrimSpace(query), ";")
+	iter := client.Session.Query(statement, values...).WithContext(ctx).PageSize(cassandraPageSize).Iter()
+
+	result := &cassandraResult{HasRows: len(iter.Columns()) > 0}
+	size := resultSizeOf(ctx)
+	for result.HasRows {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		if limit > 0 && len(result.Rows) >= limit {
+			result.Truncated = true
+			break
+		}
+		for column, value := range row {
+			row[column] = cassandraValue(value)
+		}
+		if err := size.add(row); err != nil {
+			iter.Close()
+			return nil, err
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.Warnings = iter.Warnings()
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cassandraValue converts a value read by gocql to a value encoded as expected in JSON: UUIDs & the decimals & varints
+// (which would lose their precision as JSON numbers) as their text, blobs as hex
+func cassandraValue(value interface{}) interface{} {
+	if reflected := reflect.ValueOf(value); reflected.Kind() == reflect.Ptr && reflected.IsNil() {
+		return nil
+	}
+	switch v := value.(type) {
+	case gocql.UUID:
+		return v.String()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case time.Time:
+		return v
+	case fmt.Stringer:
+		// ex: *inf.Dec for decimal & *big.Int for varint
+		return v.String()
+	}
+	return value
+}
+
+// executeCassandraQuery executes a CQL statement on Cassandra, the rows are kept up to the row limit of the context
+func executeCassandraQuery(ctx context.Context, client *CassandraClient, query string) *QueryExecutionResult {
+	startTime := time.Now()
+	result := &QueryExecutionResult{}
+
+	if strings.TrimSpace(query) == "" {
+		result.Error = &dtos.QueryError{
+			Message: "Empty query",
+			Code:    "EXECUTION_ERROR",
+		}
+		return result
+	}
+
+	limit := resultRowLimit(ctx)
+	cqlResult, err := runCassandraQuery(ctx, client, query, nil, limit)
+	if err != nil {
+		if ctx.Err() != nil {
+			result.Error = &dtos.QueryError{
+				Message: "Query execution cancelled",
+				Code:    "EXECUTION_CANCELLED",
+			}
+			return result
+		}
+		result.Error = &dtos.QueryError{
+			Message: err.Error(),
+			Code:    "EXECUTION_ERROR",
+		}
+		if requestErr, ok := err.(gocql.RequestError); ok {
+			result.Error.Details = fmt.Sprintf("CQL error code 0x%04X", requestErr.Code())
+		}
+		return result
+	}
+
+	if cqlResult.HasRows {
+		if cqlResult.Truncated {
+			result.Warnings = append(result.Warnings, resultTruncatedWarning(limit))
+		}
+		rows := cqlResult.Rows
+		if rows == nil {
+			rows = []map[string]interface{}{}
+		}
+		result.Result = map[string]interface{}{
+			"results": rows,
+		}
+	} else {
+		result.Result = map[string]interface{}{
+			"message": "Query performed successfully",
+		}
+	}
+	if cassandraAllowFilteringRegex.MatchString(query) {
+		result.Warnings = append(result.Warnings, "The query uses ALLOW FILTERING, Cassandra scans the partitions of the table to find its rows")
+	}
+	result.Warnings = append(result.Warnings, cqlResult.Warnings...)
+
+	// Calculate execution time
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result.Result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Code:    "JSON_MARSHAL_FAILED",
+				Message: err.Error(),
+				Details: "Failed to marshal query results",
+			},
+		}
+	}
+	result.ResultJSON = string(resultJSON)
+
+	return result
+}
+
+// GetSchema retrieves the database schema
+func (d *CassandraDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewCassandraSchemaFetcher(db).GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *CassandraDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+	return NewCassandraSchemaFetcher(db).GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *CassandraDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewCassandraSchemaFetcher(db).FetchExampleRecords(ctx, db, table, limit)
+}
@@ -0,0 +1,353 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// cassandraPartitionKeyConstraint is the constraint of a table listing its partition key columns in order
+	cassandraPartitionKeyConstraint = "partition_key"
+	// cassandraClusteringKeyConstraint is the constraint of a table listing its clustering columns in order
+	cassandraClusteringKeyConstraint = "clustering_key"
+)
+
+// CassandraSchemaFetcher implements schema fetching for Cassandra & ScyllaDB from the system_schema keyspace: the
+// tables of the keyspace of the connection with their columns, primary key (partition key & clustering columns) &
+// secondary indexes
+type CassandraSchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewCassandraSchemaFetcher creates a new Cassandra schema fetcher
+func NewCassandraSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &CassandraSchemaFetcher{db: db}
+}
+
+// cassandraClientOfExecutor returns the Cassandra client of an executor
+func cassandraClientOfExecutor(db DBExecutor) (*CassandraClient, error) {
+	executor, ok := db.(*CassandraExecutor)
+	if !ok {
+		return nil, fmt.Errorf("not a Cassandra connection")
+	}
+	return executor.client, nil
+}
+
+// cassandraColumn is a column of system_schema.columns
+type cassandraColumn struct {
+	Table           string `json:"table_name"`
+	Name            string `json:"column_name"`
+	Kind            string `json:"kind"`             // partition_key, clustering, static or regular
+	Position        int    `json:"position"`         // Position in the partition key or clustering columns, -1 for the others
+	ClusteringOrder string `json:"clustering_order"` // asc or desc for the clustering columns, none for the others
+	Type            string `json:"type"`
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *CassandraSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("CassandraSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraSchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("CassandraSchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+	log.Printf("CassandraSchemaFetcher -> GetSchema -> Successfully fetched schema with %d tables", len(schema.Tables))
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	log.Printf("CassandraSchemaFetcher -> GetSchema -> Filtered schema to %d tables", len(filteredSchema.Tables))
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the tables of the keyspace of the connection
+func (f *CassandraSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	client, err := cassandraClientOfExecutor(f.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []struct {
+		Name    string `json:"table_name"`
+		Comment string `json:"comment"`
+	}
+	if err := f.db.Query("SELECT table_name, comment FROM system_schema.tables WHERE keyspace_name = ?", &tables, client.Keyspace); err != nil {
+		log.Printf("CassandraSchemaFetcher -> FetchSchema -> Error fetching tables: %v", err)
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	var columns []cassandraColumn
+	if err := f.db.Query("SELECT table_name, column_name, kind, position, clustering_order, type FROM system_schema.columns WHERE keyspace_name = ?", &columns, client.Keyspace); err != nil {
+		log.Printf("CassandraSchemaFetcher -> FetchSchema -> Error fetching columns: %v", err)
+		return nil, fmt.Errorf("failed to fetch columns: %v", err)
+	}
+	indexes := f.fetchIndexes(client.Keyspace)
+	rowCounts := f.fetchPartitionCounts(client.Keyspace)
+	log.Printf("CassandraSchemaFetcher -> FetchSchema -> Processing %d tables of keyspace %s", len(tables), client.Keyspace)
+
+	columnsByTable := make(map[string][]cassandraColumn, len(tables))
+	for _, column := range columns {
+		columnsByTable[column.Table] = append(columnsByTable[column.Table], column)
+	}
+
+	for _, table := range tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		tableSchema := TableSchema{
+			Name:        table.Name,
+			Columns:     make(map[string]ColumnInfo),
+			Indexes:     indexes[table.Name],
+			ForeignKeys: make(map[string]ForeignKey),
+			Constraints: make(map[string]ConstraintInfo),
+			RowCount:    rowCounts[table.Name],
+		}
+		if tableSchema.Indexes == nil {
+			tableSchema.Indexes = make(map[string]IndexInfo)
+		}
+		addCassandraColumns(&tableSchema, columnsByTable[table.Name])
+		tableSchema.Comment = describeCassandraPrimaryKey(tableSchema, columnsByTable[table.Name])
+		if comment := strings.TrimSpace(table.Comment); comment != "" {
+			tableSchema.Comment += ". " + comment
+		}
+		log.Printf("CassandraSchemaFetcher -> FetchSchema -> Table %s: %d columns, ~%d partitions", table.Name, len(tableSchema.Columns), tableSchema.RowCount)
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[table.Name] = tableSchema
+	}
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("CassandraSchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d tables", len(schema.Tables))
+	return schema, nil
+}
+
+// addCassandraColumns adds the columns of a table with the constraints of its primary key: the partition key columns
+// & the clustering columns in their order, both together are the primary key
+func addCassandraColumns(table *TableSchema, columns []cassandraColumn) {
+	var partitionKey, clusteringKey []cassandraColumn
+	for _, column := range columns {
+		info := ColumnInfo{
+			Name: column.Name,
+			Type: column.Type,
+			// The columns of the primary key always have a value, the others may have none
+			IsNullable: column.Kind != "partition_key" && column.Kind != "clustering",
+		}
+		switch column.Kind {
+		case "partition_key":
+			partitionKey = append(partitionKey, column)
+			info.Comment = fmt.Sprintf("Partition key column %d", column.Position+1)
+		case "clustering":
+			clusteringKey = append(clusteringKey, column)
+			info.Comment = fmt.Sprintf("Clustering column %d, %s", column.Position+1, strings.ToUpper(column.ClusteringOrder))
+		case "static":
+			info.Comment = "Static column, shared by the rows of a partition"
+		}
+		table.Columns[column.Name] = info
+	}
+
+	byPosition := func(key []cassandraColumn) []string {
+		sort.Slice(key, func(i, j int) bool { return key[i].Position < key[j].Position })
+		names := make([]string, len(key))
+		for i, column := range key {
+			names[i] = column.Name
+		}
+		return names
+	}
+	partitionColumns := byPosition(partitionKey)
+	clusteringColumns := byPosition(clusteringKey)
+	if len(partitionColumns) == 0 {
+		return
+	}
+	table.Constraints[cassandraPartitionKeyConstraint] = ConstraintInfo{
+		Name:    cassandraPartitionKeyConstraint,
+		Type:    "PARTITION KEY",
+		Columns: partitionColumns,
+	}
+	if len(clusteringColumns) > 0 {
+		table.Constraints[cassandraClusteringKeyConstraint] = ConstraintInfo{
+			Name:    cassandraClusteringKeyConstraint,
+			Type:    "CLUSTERING KEY",
+			Columns: clusteringColumns,
+		}
+	}
+	table.Constraints["primary_key"] = ConstraintInfo{
+		Name:    "primary_key",
+		Type:    "PRIMARY KEY",
+		Columns: append(append([]string{}, partitionColumns...), clusteringColumns...),
+	}
+}
+
+// describeCassandraPrimaryKey describes the primary key of a table in CQL, the description of the table for the LLM,
+// ex: PRIMARY KEY ((customer_id), order_date, order_id) WITH CLUSTERING ORDER BY (order_date DESC, order_id ASC)
+func describeCassandraPrimaryKey(table TableSchema, columns []cassandraColumn) string {
+	partitionKey := table.Constraints[cassandraPartitionKeyConstraint].Columns
+	if len(partitionKey) == 0 {
+		return ""
+	}
+	clusteringKey := table.Constraints[cassandraClusteringKeyConstraint].Columns
+	key := append([]string{"(" + strings.Join(partitionKey, ", ") + ")"}, clusteringKey...)
+	description := fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(key, ", "))
+	if len(clusteringKey) == 0 {
+		return description
+	}
+
+	orders := make(map[string]string, len(columns))
+	for _, column := range columns {
+		orders[column.Name] = strings.ToUpper(column.ClusteringOrder)
+	}
+	clusteringOrder := make([]string, len(clusteringKey))
+	for i, column := range clusteringKey {
+		clusteringOrder[i] = column + " " + orders[column]
+	}
+	return fmt.Sprintf("%s WITH CLUSTERING ORDER BY (%s)", description, strings.Join(clusteringOrder, ", "))
+}
+
+// fetchIndexes retrieves the secondary indexes of the keyspace by table, best effort. The target of an index is its
+// column, wrapped for the indexes of collections, ex: values(tags) or keys(attributes).
+func (f *CassandraSchemaFetcher) fetchIndexes(keyspace string) map[string]map[string]IndexInfo {
+	var rows []struct {
+		Table   string            `json:"table_name"`
+		Name    string            `json:"index_name"`
+		Options map[string]string `json:"options"`
+	}
+	if err := f.db.Query("SELECT table_name, index_name, options FROM system_schema.indexes WHERE keyspace_name = ?", &rows, keyspace); err != nil {
+		log.Printf("CassandraSchemaFetcher -> fetchIndexes -> Error fetching indexes of keyspace %s: %v", keyspace, err)
+		return nil
+	}
+
+	indexes := make(map[string]map[string]IndexInfo)
+	for _, row := range rows {
+		target := row.Options["target"]
+		if open := strings.Index(target, "("); open != -1 && strings.HasSuffix(target, ")") {
+			target = target[open+1 : len(target)-1]
+		}
+		target = unquoteIdentifier(target)
+		if target == "" {
+			continue
+		}
+		if indexes[row.Table] == nil {
+			indexes[row.Table] = make(map[string]IndexInfo)
+		}
+		indexes[row.Table][row.Name] = IndexInfo{
+			Name:    row.Name,
+			Columns: []string{target},
+		}
+	}
+	return indexes
+}
+
+// fetchPartitionCounts estimates the number of partitions of the tables of the keyspace from system.size_estimates,
+// best effort. The estimates only cover the token ranges of the node answering, they are a lower bound of the rows.
+func (f *CassandraSchemaFetcher) fetchPartitionCounts(keyspace string) map[string]int64 {
+	var rows []map[string]interface{}
+	if err := f.db.QueryRows("SELECT table_name, partitions_count FROM system.size_estimates WHERE keyspace_name = ?", &rows, keyspace); err != nil {
+		log.Printf("CassandraSchemaFetcher -> fetchPartitionCounts -> Error fetching size estimates of keyspace %s: %v", keyspace, err)
+		return nil
+	}
+	counts := make(map[string]int64)
+	for _, row := range rows {
+		table, _ := row["table_name"].(string)
+		count, _ := strconv.ParseInt(fmt.Sprint(row["partitions_count"]), 10, 64)
+		counts[table] += count
+	}
+	return counts
+}
+
+// GetTableChecksum calculates a checksum for a table from its columns
+func (f *CassandraSchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	client, err := cassandraClientOfExecutor(db)
+	if err != nil {
+		return "", err
+	}
+	var columns []cassandraColumn
+	if err := db.Query("SELECT table_name, column_name, kind, position, clustering_order, type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?", &columns, client.Keyspace, table); err != nil {
+		return "", fmt.Errorf("failed to fetch columns of table %s: %v", table, err)
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	data, _ := json.Marshal(columns)
+	return fmt.Sprintf("%x", md5.Sum(data)), nil
+}
+
+// FetchExampleRecords retrieves sample rows from a table, the rows of its first partitions
+func (f *CassandraSchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraSchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	var records []map[string]interface{}
+	query := fmt.Sprintf(`SELECT * FROM "%s" LIMIT %d`, strings.ReplaceAll(table, `"`, `""`), limit)
+	if err := db.QueryRows(query, &records); err != nil {
+		log.Printf("CassandraSchemaFetcher -> FetchExampleRecords -> Error fetching records from table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for table %s: %v", table, err)
+	}
+	if records == nil {
+		records = []map[string]interface{}{}
+	}
+	log.Printf("CassandraSchemaFetcher -> FetchExampleRecords -> Fetched %d records from table %s", len(records), table)
+	return records, nil
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected tables
+func (f *CassandraSchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool, len(selectedTables))
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: schema.UpdatedAt,
+	}
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+	return filteredSchema
+}
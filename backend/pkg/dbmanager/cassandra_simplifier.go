@@ -0,0 +1,69 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// CassandraSimplifier implements the SchemaSimplifier interface for Cassandra
+type CassandraSimplifier struct{}
+
+// SimplifyDataType converts CQL types to simplified versions for LLM, the collections keep their element types
+func (s *CassandraSimplifier) SimplifyDataType(dbType string) string {
+	cqlType := strings.ToLower(strings.TrimSpace(dbType))
+	// ex: frozen<list<text>> is read like list<text>
+	if strings.HasPrefix(cqlType, "frozen<") && strings.HasSuffix(cqlType, ">") {
+		cqlType = strings.TrimSuffix(strings.TrimPrefix(cqlType, "frozen<"), ">")
+	}
+
+	switch cqlType {
+	case "tinyint", "smallint", "int", "bigint", "varint", "counter":
+		return "integer"
+	case "float", "double", "decimal":
+		return "number"
+	case "text", "varchar", "ascii":
+		return "text"
+	case "timestamp":
+		return "timestamp"
+	case "date":
+		return "date"
+	case "time":
+		return "time"
+	case "uuid", "timeuuid":
+		return "uuid"
+	case "boolean":
+		return "boolean"
+	case "blob":
+		return "binary"
+	default:
+		// list<...>, set<...>, map<...>, tuple<...>, inet, duration & the user defined types are kept as is
+		return cqlType
+	}
+}
+
+// GetColumnConstraints returns a list of constraints for a column: its part in the primary key, which decides how the
+// rows can be filtered & ordered
+func (s *CassandraSimplifier) GetColumnConstraints(col ColumnInfo, table TableSchema) []string {
+	var constraints []string
+
+	if isCassandraKeyColumn(table, cassandraPartitionKeyConstraint, col.Name) {
+		constraints = append(constraints, "PARTITION KEY")
+	}
+	if isCassandraKeyColumn(table, cassandraClusteringKeyConstraint, col.Name) {
+		constraints = append(constraints, "CLUSTERING COLUMN")
+	}
+	if strings.EqualFold(col.Type, "counter") {
+		constraints = append(constraints, "COUNTER")
+	}
+
+	return constraints
+}
+
+// isCassandraKeyColumn checks if a column is part of the partition or clustering key constraint of a table
+func isCassandraKeyColumn(table TableSchema, constraintName, column string) bool {
+	for _, keyColumn := range table.Constraints[constraintName].Columns {
+		if keyColumn == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+)
+
+// CassandraTransaction implements the Transaction interface for Cassandra. CQL has no transactions: each statement
+// (a BATCH included) is applied once it succeeds, Commit & Rollback have nothing to do.
+type CassandraTransaction struct {
+	conn *Connection
+}
+
+// ExecuteQuery executes a query as its own statement
+func (t *CassandraTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := cassandraClientOf(t.conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+	return executeCassandraQuery(ctx, client, query)
+}
+
+// Commit does nothing, the query is already applied
+func (t *CassandraTransaction) Commit() error {
+	return nil
+}
+
+// Rollback does nothing, an applied statement can't be undone
+func (t *CassandraTransaction) Rollback() error {
+	return nil
+}
@@ -0,0 +1,146 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// CassandraExecutor implements DBExecutor for Cassandra & ScyllaDB, the queries are CQL statements whose ? placeholders
+// are bound by gocql
+type CassandraExecutor struct {
+	BaseWrapper
+	client *CassandraClient
+	conn   *Connection
+}
+
+// NewCassandraExecutor creates a new Cassandra executor
+func NewCassandraExecutor(conn *Connection, manager *Manager, chatID string) (*CassandraExecutor, error) {
+	client, err := cassandraClientOf(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &CassandraExecutor{
+		BaseWrapper: BaseWrapper{
+			manager: manager,
+			chatID:  chatID,
+		},
+		client: client,
+		conn:   conn,
+	}, nil
+}
+
+// GetDB returns nil for Cassandra as it doesn't use database/sql
+func (e *CassandraExecutor) GetDB() *sql.DB {
+	return nil
+}
+
+// GetConnection returns the underlying connection
+func (e *CassandraExecutor) GetConnection() *Connection {
+	return e.conn
+}
+
+// run runs a statement & returns all its rows
+func (e *CassandraExecutor) run(query string, values []interface{}) ([]map[string]interface{}, error) {
+	if err := e.updateUsage(); err != nil {
+		return nil, fmt.Errorf("failed to update usage: %v", err)
+	}
+	result, err := runCassandraQuery(context.Background(), e.client, query, values, 0)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// Raw executes a raw query
+func (e *CassandraExecutor) Raw(query string, values ...interface{}) error {
+	_, err := e.run(query, values)
+	return err
+}
+
+// Exec executes a query
+func (e *CassandraExecutor) Exec(query string, values ...interface{}) error {
+	_, err := e.run(query, values)
+	return err
+}
+
+// Query executes a query and decodes its rows into dest through JSON
+func (e *CassandraExecutor) Query(query string, dest interface{}, values ...interface{}) error {
+	rows, err := e.run(query, values)
+	if err != nil {
+		return err
+	}
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to encode the query result: %v", err)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// QueryRows executes a query and scans its rows into dest
+func (e *CassandraExecutor) QueryRows(query string, dest *[]map[string]interface{}, values ...interface{}) error {
+	rows, err := e.run(query, values)
+	if err != nil {
+		return err
+	}
+	*dest = rows
+	return nil
+}
+
+// Close does nothing, the session is shared by the connections of the pool
+func (e *CassandraExecutor) Close() error {
+	return nil
+}
+
+// GetSchema fetches the current database schema
+func (e *CassandraExecutor) GetSchema(ctx context.Context) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraExecutor -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Get the schema fetcher factory for Cassandra
+	fetcherFactory, exists := e.manager.fetchers["cassandra"]
+	if !exists {
+		return nil, fmt.Errorf("Cassandra schema fetcher not found")
+	}
+
+	// Create a schema fetcher for this connection
+	fetcher := fetcherFactory(e)
+
+	// Get selected collections from the chat service if available
+	selectedTables := []string{"ALL"}
+	if e.manager.streamHandler != nil {
+		selectedCollections, err := e.manager.streamHandler.GetSelectedCollections(e.chatID)
+		if err == nil && selectedCollections != "ALL" && selectedCollections != "" {
+			selectedTables = strings.Split(selectedCollections, ",")
+			log.Printf("CassandraExecutor -> GetSchema -> Using selected tables for chat %s: %v", e.chatID, selectedTables)
+		}
+	}
+
+	return fetcher.GetSchema(ctx, e, selectedTables)
+}
+
+// GetTableChecksum calculates checksum for a single table
+func (e *CassandraExecutor) GetTableChecksum(ctx context.Context, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("CassandraExecutor -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	// Get the schema fetcher factory for Cassandra
+	fetcherFactory, exists := e.manager.fetchers["cassandra"]
+	if !exists {
+		return "", fmt.Errorf("Cassandra schema fetcher not found")
+	}
+
+	return fetcherFactory(e).GetTableChecksum(ctx, e, table)
+}
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	clickhousedriver "gorm.io/driver/clickhouse"
 	"gorm.io/gorm"
 )
@@ -116,13 +117,8 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 	}
 
 	// Build DSN
-	if config.Password != nil {
-		dsn = fmt.Sprintf("%s://%s:%s@%s:%s/%s",
-			protocol, *config.Username, *config.Password, config.Host, *config.Port, config.Database)
-	} else {
-		dsn = fmt.Sprintf("%s://%s@%s:%s/%s",
-			protocol, *config.Username, config.Host, *config.Port, config.Database)
-	}
+	dsn = fmt.Sprintf("%s://%s@%s:%s/%s",
+		protocol, connectionURLUserInfo(*config.Username, config.Password), config.Host, *config.Port, config.Database)
 
 	// Add parameters
 	dsn += "?dial_timeout=10s&read_timeout=20s"
@@ -170,9 +166,7 @@ func (d *ClickHouseDriver) Connect(config ConnectionConfig) (*Connection, error)
 	}
 
 	// Configure connection pool
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	applySQLPoolSettings(sqlDB, config)
 
 	// Create connection object
 	conn := &Connection{
@@ -306,15 +300,29 @@ func (d *ClickHouseDriver) ExecuteQuery(ctx context.Context, conn *Connection, q
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
-			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := conn.DB.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			// For SELECT, SHOW, DESCRIBE queries, return the results, at most the row limit of the context is read
+			db := conn.DB.WithContext(ctx)
+			sqlRows, err := db.Raw(stmt).Rows()
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
 				}
 				return result
 			}
+			result.Columns = sqlResultColumns(sqlRows)
+			rows, truncated, err := scanLimitedRows(ctx, db, sqlRows)
+			sqlRows.Close()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+			if truncated {
+				result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+			}
 
 			// Process the rows to ensure proper type handling
 			processedRows := make([]map[string]interface{}, len(rows))
@@ -398,8 +406,8 @@ func (d *ClickHouseDriver) ExecuteQuery(ctx context.Context, conn *Connection, q
 	return result
 }
 
-// BeginTx starts a new transaction
-func (d *ClickHouseDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+// BeginTx starts a new transaction, session mode isn't supported for ClickHouse so session is always nil
+func (d *ClickHouseDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
 	if conn == nil || conn.DB == nil {
 		log.Printf("ClickHouseDriver.BeginTx: Connection or DB is nil")
 		return nil
@@ -412,10 +420,17 @@ func (d *ClickHouseDriver) BeginTx(ctx context.Context, conn *Connection) Transa
 		return nil
 	}
 
-	return &ClickHouseTransaction{
-		tx:   tx,
-		conn: conn,
+	// The statements of the transaction run with a query ID of their own, a cancelled query is killed on the server by it
+	queryID := uuid.NewString()
+	transaction := &ClickHouseTransaction{
+		tx:      tx,
+		conn:    conn,
+		queryID: queryID,
+	}
+	transaction.serverQuery.cancel = func(ctx context.Context) error {
+		return conn.DB.WithContext(ctx).Exec("KILL QUERY WHERE query_id = ?", queryID).Error
 	}
+	return transaction
 }
 
 // GetSchema retrieves the database schema
@@ -3,6 +3,7 @@ package dbmanager
 import (
 	"context"
 	"crypto/md5"
+	"databot-ai/internal/constants"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -83,6 +84,12 @@ func (f *ClickHouseSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo,
 
 	log.Printf("ClickHouseSchemaFetcher -> FetchSchema -> Processing %d tables", len(tables))
 
+	// Engine metadata is best effort, the schema is still usable without it
+	engines, err := f.fetchTableEngines(ctx)
+	if err != nil {
+		log.Printf("ClickHouseSchemaFetcher -> FetchSchema -> Error fetching table engines: %v", err)
+	}
+
 	for _, table := range tables {
 		log.Printf("ClickHouseSchemaFetcher -> FetchSchema -> Processing table: %s", table)
 
@@ -93,6 +100,9 @@ func (f *ClickHouseSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo,
 			ForeignKeys: make(map[string]ForeignKey),
 			Constraints: make(map[string]ConstraintInfo),
 		}
+		if engine, ok := engines[table]; ok {
+			tableSchema.Comment = engine.describe()
+		}
 
 		// Fetch columns
 		columns, err := f.fetchColumns(ctx, table)
@@ -135,6 +145,202 @@ type TableInfo struct {
 	PrimaryKey   []string
 }
 
+// clickHouseTableEngine holds the engine of a table as reported by system.tables
+type clickHouseTableEngine struct {
+	Name         string `db:"name"`
+	Database     string `db:"database"`
+	Comment      string `db:"comment"`
+	Engine       string `db:"engine"`
+	EngineFull   string `db:"engine_full"`
+	PartitionKey string `db:"partition_key"`
+	SortingKey   string `db:"sorting_key"`
+}
+
+// describe renders the engine in the table comment, createLLMSchema parses it back into the table description.
+// Distributed tables are rendered as engine=Distributed(cluster,local_table) so that the LLM queries them instead of
+// the local table of a single shard.
+func (e clickHouseTableEngine) describe() string {
+	engine := e.Engine
+	if cluster, database, table, ok := parseDistributedEngine(e.EngineFull); ok {
+		if database != "" && database != e.Database {
+			table = database + "." + table
+		}
+		engine = fmt.Sprintf("Distributed(%s,%s)", cluster, table)
+	}
+
+	description := strings.TrimSpace(e.Comment)
+	if engine == "" {
+		return description
+	}
+	description = strings.TrimSpace(description + " engine=" + engine)
+	if e.PartitionKey != "" {
+		description += " partition by " + e.PartitionKey
+	}
+	if e.SortingKey != "" {
+		description += " order by " + e.SortingKey
+	}
+	return description
+}
+
+// parseDistributedEngine extracts the cluster, database & local table of a Distributed engine such as
+// Distributed('cluster', 'db', 'events_local', rand()), ok is false for other engines
+func parseDistributedEngine(engineFull string) (cluster, database, table string, ok bool) {
+	engineFull = strings.TrimSpace(engineFull)
+	if !strings.HasPrefix(engineFull, "Distributed(") {
+		return "", "", "", false
+	}
+	// Split the arguments on top level commas, the sharding key can be any expression
+	var args []string
+	depth, start := 0, len("Distributed(")
+	for i := start; i < len(engineFull) && depth >= 0; i++ {
+		switch engineFull[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				args = append(args, engineFull[start:i])
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, engineFull[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if len(args) < 3 {
+		return "", "", "", false
+	}
+	for i := range args {
+		args[i] = strings.Trim(strings.TrimSpace(args[i]), "'\"`")
+	}
+	if args[0] == "" || args[2] == "" {
+		return "", "", "", false
+	}
+	// currentDatabase() is the database of the Distributed table itself
+	if strings.HasPrefix(args[1], "currentDatabase") {
+		args[1] = ""
+	}
+	return args[0], args[1], args[2], true
+}
+
+// clickHouseDistributedTables maps the local tables read through a Distributed table to a description of that table
+func clickHouseDistributedTables(schema *SchemaInfo, dbType string) map[string]string {
+	distributedTables := make(map[string]string)
+	if dbType != constants.DatabaseTypeClickhouse {
+		return distributedTables
+	}
+	for tableName, table := range schema.Tables {
+		if cluster, localTable, ok := clickHouseDistributedEngine(table.Comment); ok {
+			distributedTables[localTable] = fmt.Sprintf("%s (cluster %s)", tableName, cluster)
+		}
+	}
+	return distributedTables
+}
+
+// clickHouseDistributedEngine extracts the cluster & local table from a table comment rendered by
+// clickHouseTableEngine.describe, ok is false if the table isn't a Distributed table
+func clickHouseDistributedEngine(comment string) (cluster, localTable string, ok bool) {
+	engineIdx := strings.Index(comment, "engine=Distributed(")
+	if engineIdx == -1 {
+		return "", "", false
+	}
+	args := comment[engineIdx+len("engine=Distributed("):]
+	end := strings.Index(args, ")")
+	if end == -1 {
+		return "", "", false
+	}
+	parts := strings.SplitN(args[:end], ",", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// describeClickHouseTable parses the engine, partition & order by keys of a table comment into the LLM description
+func describeClickHouseTable(tableName, comment string, distributedTables map[string]string) string {
+	description := comment
+	lowerComment := strings.ToLower(comment)
+
+	// Extract engine information
+	if strings.Contains(lowerComment, "engine=") {
+		engineIdx := strings.Index(lowerComment, "engine=")
+		// The engine metadata is rendered below, only the comment of the table is kept as is
+		description = strings.TrimSpace(comment[:engineIdx])
+
+		engineStart := engineIdx + 7
+		engineEnd := len(comment)
+		if spaceIdx := strings.Index(comment[engineStart:], " "); spaceIdx != -1 {
+			engineEnd = engineStart + spaceIdx
+		}
+		engineInfo := comment[engineStart:engineEnd]
+		if cluster, localTable, ok := clickHouseDistributedEngine(comment); ok {
+			description += fmt.Sprintf(" [Distributed table over cluster %s, reads & writes every shard through the local table %s]", cluster, localTable)
+		} else if engineInfo != "" {
+			description += fmt.Sprintf(" [Engine: %s]", engineInfo)
+		}
+	}
+
+	// Extract partition key information
+	if strings.Contains(lowerComment, "partition by") {
+		partitionStart := strings.Index(lowerComment, "partition by") + 12
+		partitionEnd := len(comment)
+		for _, keyword := range []string{"order by", "primary key", "sample by", "settings"} {
+			if keywordIdx := strings.Index(strings.ToLower(comment[partitionStart:]), keyword); keywordIdx != -1 {
+				if partitionStart+keywordIdx < partitionEnd {
+					partitionEnd = partitionStart + keywordIdx
+				}
+			}
+		}
+		partitionKey := strings.TrimSpace(comment[partitionStart:partitionEnd])
+		if partitionKey != "" {
+			description += fmt.Sprintf(" [Partition Key: %s]", partitionKey)
+		}
+	}
+
+	// Extract order by key information
+	if strings.Contains(lowerComment, "order by") {
+		orderStart := strings.Index(lowerComment, "order by") + 8
+		orderEnd := len(comment)
+		for _, keyword := range []string{"partition by", "primary key", "sample by", "settings"} {
+			if keywordIdx := strings.Index(strings.ToLower(comment[orderStart:]), keyword); keywordIdx != -1 {
+				if orderStart+keywordIdx < orderEnd {
+					orderEnd = orderStart + keywordIdx
+				}
+			}
+		}
+		orderByKey := strings.TrimSpace(comment[orderStart:orderEnd])
+		if orderByKey != "" {
+			description += fmt.Sprintf(" [Order By: %s]", orderByKey)
+		}
+	}
+
+	if distributedTable, ok := distributedTables[tableName]; ok {
+		description += fmt.Sprintf(" [Local table of one shard, query the Distributed table %s to see every shard]", distributedTable)
+	}
+	return strings.TrimSpace(description)
+}
+
+// fetchTableEngines retrieves the engine of every table in the database, by table name
+func (f *ClickHouseSchemaFetcher) fetchTableEngines(_ context.Context) (map[string]clickHouseTableEngine, error) {
+	var engineList []clickHouseTableEngine
+	query := `
+        SELECT name, database, comment, engine, engine_full, partition_key, sorting_key
+        FROM system.tables
+        WHERE database = currentDatabase()
+        AND engine NOT LIKE 'View%';
+    `
+	if err := f.db.Query(query, &engineList); err != nil {
+		return nil, fmt.Errorf("failed to fetch table engines: %v", err)
+	}
+
+	engines := make(map[string]clickHouseTableEngine, len(engineList))
+	for _, engine := range engineList {
+		engines[engine.Name] = engine
+	}
+	return engines, nil
+}
+
 // fetchTables retrieves all tables in the database
 func (f *ClickHouseSchemaFetcher) fetchTables(_ context.Context) ([]string, error) {
 	var tables []string
@@ -8,13 +8,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	"gorm.io/gorm"
 )
 
 // ClickHouseTransaction implements the Transaction interface for ClickHouse
 type ClickHouseTransaction struct {
-	tx   *gorm.DB
-	conn *Connection
+	tx          *gorm.DB
+	conn        *Connection
+	queryID     string // query_id of the statements on the server
+	serverQuery        // Kills the running statement with KILL QUERY
 }
 
 // ExecuteQuery executes a query within a transaction
@@ -30,6 +33,7 @@ func (t *ClickHouseTransaction) ExecuteQuery(ctx context.Context, conn *Connecti
 
 	startTime := time.Now()
 	result := &QueryExecutionResult{}
+	ctx = clickhouse.Context(ctx, clickhouse.WithQueryID(t.queryID))
 
 	// Split the query into individual statements
 	statements := splitClickHouseStatements(query)
@@ -53,15 +57,29 @@ func (t *ClickHouseTransaction) ExecuteQuery(ctx context.Context, conn *Connecti
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
-			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := t.tx.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			// For SELECT, SHOW, DESCRIBE queries, return the results, at most the row limit of the context is read
+			db := t.tx.WithContext(ctx)
+			sqlRows, err := db.Raw(stmt).Rows()
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
 				}
 				return result
 			}
+			result.Columns = sqlResultColumns(sqlRows)
+			rows, truncated, err := scanLimitedRows(ctx, db, sqlRows)
+			sqlRows.Close()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+			if truncated {
+				result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+			}
 
 			// Process the rows to ensure proper type handling
 			processedRows := make([]map[string]interface{}, len(rows))
@@ -150,6 +168,7 @@ func (t *ClickHouseTransaction) Commit() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to commit")
 	}
+	t.finish()
 	return t.tx.Commit().Error
 }
 
@@ -158,5 +177,6 @@ func (t *ClickHouseTransaction) Rollback() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to rollback")
 	}
+	t.finish()
 	return t.tx.Rollback().Error
 }
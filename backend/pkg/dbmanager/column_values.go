@@ -0,0 +1,122 @@
+package dbmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ErrHighCardinalityColumn is returned for a column with more distinct values than requested, listing them all isn't
+// useful for a filter & reading them would scan the table
+var ErrHighCardinalityColumn = errors.New("the column has too many distinct values to be listed")
+
+// errColumnValuesRead stops reading the distinct values once one more than the limit is read
+var errColumnValuesRead = errors.New("column values read")
+
+// GetColumnValues returns the distinct values of a column sorted, at most limit, to build filters without going
+// through the LLM. The table & column must be in the known schema of the chat. A column is refused with
+// ErrHighCardinalityColumn before querying the database when it is unique in a table with more rows than limit, or
+// once the query returns more than limit values.
+func (m *Manager) GetColumnValues(ctx context.Context, chatID, table, column string, limit int) ([]interface{}, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !StreamSupported(conn.Config.Type) {
+		return nil, fmt.Errorf("listing the values of a column is not supported for %s", conn.Config.Type)
+	}
+
+	schema := m.GetKnownSchema(ctx, chatID)
+	if schema == nil {
+		return nil, fmt.Errorf("the schema of the database isn't fetched yet")
+	}
+	tableName, tableSchema, ok := findSchemaTable(schema, table)
+	if !ok {
+		return nil, fmt.Errorf("table %s doesn't exist", table)
+	}
+	columnName, ok := findSchemaColumn(tableSchema, column)
+	if !ok {
+		return nil, fmt.Errorf("column %s doesn't exist in table %s", column, tableName)
+	}
+	if isUniqueColumn(tableSchema, columnName) && tableSchema.RowCount > int64(limit) {
+		return nil, ErrHighCardinalityColumn
+	}
+
+	quotedTable := make([]string, 0, 2)
+	for _, part := range strings.Split(tableName, ".") {
+		quotedTable = append(quotedTable, quoteSQLIdentifier(conn.Config.Type, part))
+	}
+	quotedColumn := quoteSQLIdentifier(conn.Config.Type, columnName)
+	// One more value than the limit tells a complete list from a cut one
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s ORDER BY %s LIMIT %d", quotedColumn, strings.Join(quotedTable, "."), quotedColumn, limit+1)
+
+	values := make([]interface{}, 0, limit)
+	err := m.StreamQueryRows(ctx, chatID, query, func([]ExportColumn) error {
+		return nil
+	}, func(row []interface{}) error {
+		if len(values) == limit {
+			return errColumnValuesRead
+		}
+		values = append(values, row[0])
+		return nil
+	})
+	if errors.Is(err, errColumnValuesRead) {
+		return nil, ErrHighCardinalityColumn
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("DBManager -> GetColumnValues -> Read %d values of %s.%s for chatID: %s", len(values), tableName, columnName, chatID)
+	return values, nil
+}
+
+// findSchemaTable finds a table of the schema by name, case insensitive, an exact match is preferred
+func findSchemaTable(schema *SchemaInfo, table string) (string, TableSchema, bool) {
+	if tableSchema, ok := schema.Tables[table]; ok {
+		return table, tableSchema, true
+	}
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.EqualFold(name, table) {
+			return name, schema.Tables[name], true
+		}
+	}
+	return "", TableSchema{}, false
+}
+
+// findSchemaColumn finds a column of a table by name, case insensitive, an exact match is preferred
+func findSchemaColumn(table TableSchema, column string) (string, bool) {
+	if _, ok := table.Columns[column]; ok {
+		return column, true
+	}
+	for name := range table.Columns {
+		if strings.EqualFold(name, column) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isUniqueColumn checks if a column alone is the primary key or a unique index of a table, it then has as many
+// distinct values as the table has rows
+func isUniqueColumn(table TableSchema, column string) bool {
+	if primaryKey := tablePrimaryKey(table); len(primaryKey) == 1 && strings.EqualFold(primaryKey[0], column) {
+		return true
+	}
+	for _, index := range table.Indexes {
+		if index.IsUnique && len(index.Columns) == 1 && strings.EqualFold(index.Columns[0], column) {
+			return true
+		}
+	}
+	return false
+}
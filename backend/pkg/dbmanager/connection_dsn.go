@@ -0,0 +1,33 @@
+package dbmanager
+
+import (
+	"net/url"
+	"strings"
+)
+
+// postgresDSNValue quotes a value of a PostgreSQL key=value connection string when needed, a value containing spaces,
+// quotes or backslashes is otherwise cut or rejected by the driver
+func postgresDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r'\\") {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// connectionURLUserInfo returns the escaped user info of a ClickHouse or MongoDB connection URL, ex: admin:p%40ss%2Fw
+// for the password p@ss/w. Both drivers unescape it, so '@', ':', '/', '?' & '%' can be used in credentials.
+func connectionURLUserInfo(username string, password *string) string {
+	if password == nil {
+		return url.User(username).String()
+	}
+	return url.UserPassword(username, *password).String()
+}
+
+// maskConnectionURL hides the password of a connection URL before it is logged
+func maskConnectionURL(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "(unparsable connection URL)"
+	}
+	return parsed.Redacted()
+}
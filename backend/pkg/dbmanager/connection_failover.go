@@ -0,0 +1,144 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// failoverCandidates returns a config per host of a multi-host connection, Host first then the Hosts in order.
+// Hosts can carry their own port (ex: "db-2:5433"), the port of the config is used otherwise.
+func failoverCandidates(config ConnectionConfig) []ConnectionConfig {
+	hosts := append([]string{config.Host}, config.Hosts...)
+	candidates := make([]ConnectionConfig, 0, len(hosts))
+	seen := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		candidate := config
+		candidate.Host = host
+		candidate.Hosts = nil
+		if name, port, err := net.SplitHostPort(host); err == nil {
+			candidate.Host = name
+			candidate.Port = &port
+		}
+		address := candidateAddress(candidate)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// candidateAddress is the host:port a config connects to
+func candidateAddress(config ConnectionConfig) string {
+	if config.Port == nil || *config.Port == "" {
+		return config.Host
+	}
+	return net.JoinHostPort(config.Host, *config.Port)
+}
+
+// connectWithFailover connects to the primary of a multi-host connection. The hosts are tried in order, a host that
+// is a read-only replica is only used when no primary is reachable. Single host connections are passed to the driver
+// as is. The returned connection keeps the config of the host it is connected to.
+func connectWithFailover(driver DatabaseDriver, config ConnectionConfig) (*Connection, error) {
+	if len(config.Hosts) == 0 {
+		return driver.Connect(config)
+	}
+
+	var replica *Connection
+	var failures []string
+	for _, candidate := range failoverCandidates(config) {
+		address := candidateAddress(candidate)
+		conn, err := driver.Connect(candidate)
+		if err != nil {
+			log.Printf("DBManager -> connectWithFailover -> Failed to connect to %s: %v", address, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", address, err))
+			continue
+		}
+		if isPrimaryServer(conn) {
+			log.Printf("DBManager -> connectWithFailover -> Connected to primary %s", address)
+			if replica != nil {
+				driver.Disconnect(replica)
+			}
+			return conn, nil
+		}
+
+		log.Printf("DBManager -> connectWithFailover -> %s is a read-only replica, trying the next host", address)
+		if replica == nil {
+			replica = conn
+		} else {
+			driver.Disconnect(conn)
+		}
+	}
+
+	if replica != nil {
+		log.Printf("DBManager -> connectWithFailover -> No primary reachable, using replica %s", candidateAddress(replica.Config))
+		return replica, nil
+	}
+	return nil, fmt.Errorf("failed to connect to any host: %s", strings.Join(failures, "; "))
+}
+
+// isPrimaryServer reports if the server of a connection accepts writes. PostgreSQL standbys are in recovery, MySQL
+// replicas are read only. Other databases & servers that can't be checked are considered primary.
+func isPrimaryServer(conn *Connection) bool {
+	if conn == nil || conn.DB == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var readOnly bool
+	var err error
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL:
+		err = conn.DB.WithContext(ctx).Raw("SELECT pg_is_in_recovery()").Row().Scan(&readOnly)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		err = conn.DB.WithContext(ctx).Raw("SELECT @@global.read_only").Row().Scan(&readOnly)
+	default:
+		return true
+	}
+	if err != nil {
+		log.Printf("DBManager -> isPrimaryServer -> Failed to check the role of %s: %v", candidateAddress(conn.Config), err)
+		return true
+	}
+	return !readOnly
+}
+
+// testConnectionFailover tests the hosts of a multi-host connection in order, the connection is valid when any of
+// them accepts the credentials
+func (m *Manager) testConnectionFailover(config *ConnectionConfig) (*TLSInfo, error) {
+	var failures []string
+	for _, candidate := range failoverCandidates(*config) {
+		tlsInfo, err := m.TestConnection(&candidate)
+		if err == nil {
+			return tlsInfo, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", candidateAddress(candidate), err))
+	}
+	return nil, fmt.Errorf("failed to connect to any host: %s", strings.Join(failures, "; "))
+}
+
+// retirePool moves the pool of a former primary out of the way of new connections, the chats still using it keep it
+// until they disconnect. m.mu must be held.
+func (m *Manager) retirePool(configKey string, pool *DatabasePool) {
+	retiredKey := fmt.Sprintf("%s@%s@%d", configKey, pool.Host, time.Now().UnixNano())
+	m.dbPoolsMu.Lock()
+	delete(m.dbPools, configKey)
+	m.dbPools[retiredKey] = pool
+	m.dbPoolsMu.Unlock()
+
+	for _, conn := range m.connections {
+		if conn.ConfigKey == configKey {
+			conn.ConfigKey = retiredKey
+		}
+	}
+}
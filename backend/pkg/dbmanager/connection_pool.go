@@ -0,0 +1,58 @@
+package dbmanager
+
+import (
+	"database/sql"
+	"databot-ai/internal/constants"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Pool settings of the connections that don't set their own
+const (
+	defaultMaxOpenConns    = 10
+	defaultMaxIdleConns    = 5
+	defaultConnMaxLifetime = 30 * time.Minute
+)
+
+// ConnectionPoolSupported checks if the pool of a database type can be configured, the SQL databases & MongoDB
+func ConnectionPoolSupported(dbType string) bool {
+	return dbType == constants.DatabaseTypeMongoDB || isSQLDatabaseType(dbType)
+}
+
+// connectionPoolSettings returns the pool settings of a connection, the defaults replace the unset ones. The idle
+// connections are capped at the open ones.
+func connectionPoolSettings(config ConnectionConfig) (maxOpenConns, maxIdleConns int, connMaxLifetime time.Duration) {
+	maxOpenConns, maxIdleConns, connMaxLifetime = defaultMaxOpenConns, defaultMaxIdleConns, defaultConnMaxLifetime
+	if config.MaxOpenConns > 0 {
+		maxOpenConns = config.MaxOpenConns
+	}
+	if config.MaxIdleConns > 0 {
+		maxIdleConns = config.MaxIdleConns
+	}
+	if config.ConnMaxLifetime > 0 {
+		connMaxLifetime = time.Duration(config.ConnMaxLifetime) * time.Second
+	}
+	if maxIdleConns > maxOpenConns {
+		maxIdleConns = maxOpenConns
+	}
+	return maxOpenConns, maxIdleConns, connMaxLifetime
+}
+
+// applySQLPoolSettings configures the pool of a SQL connection, queries past the max open connections wait for one
+// to be released
+func applySQLPoolSettings(db *sql.DB, config ConnectionConfig) {
+	maxOpenConns, maxIdleConns, connMaxLifetime := connectionPoolSettings(config)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+}
+
+// applyMongoPoolSettings maps the pool settings on the MongoDB client: the max open connections bound the pool, the
+// idle ones are kept open & the lifetime closes the connections idle for that long, MongoDB has no max lifetime
+func applyMongoPoolSettings(clientOptions *options.ClientOptions, config ConnectionConfig) {
+	maxOpenConns, maxIdleConns, connMaxLifetime := connectionPoolSettings(config)
+	clientOptions.SetMaxPoolSize(uint64(maxOpenConns))
+	clientOptions.SetMinPoolSize(uint64(maxIdleConns))
+	clientOptions.SetMaxConnIdleTime(connMaxLifetime)
+}
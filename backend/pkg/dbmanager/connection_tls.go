@@ -0,0 +1,166 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/utils"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLSInfo reports the TLS actually negotiated with the database, which can differ from what the connection asked for
+// (ex: PostgreSQL's sslmode=prefer silently falls back to plain text)
+type TLSInfo struct {
+	Requested bool   `json:"requested"`          // UseSSL of the connection config
+	SSLMode   string `json:"ssl_mode,omitempty"` // SSLMode of the connection config
+	Used      *bool  `json:"used"`               // nil if the driver doesn't tell
+	Version   string `json:"version,omitempty"`  // ex: TLSv1.3
+	Cipher    string `json:"cipher,omitempty"`   // ex: TLS_AES_256_GCM_SHA384
+}
+
+// fetchTLSInfo asks the server how the connection is encrypted, best effort. PostgreSQL & MySQL report the negotiated
+// version & cipher, ClickHouse & MongoDB drivers fail rather than falling back to plain text so TLS is in use if it
+// was requested, without more details.
+func fetchTLSInfo(config *ConnectionConfig, db *sql.DB) *TLSInfo {
+	info := &TLSInfo{Requested: config.UseSSL}
+	if config.SSLMode != nil {
+		info.SSLMode = *config.SSLMode
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	switch config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		if db == nil {
+			return info
+		}
+		var used bool
+		var version, cipher sql.NullString
+		err = db.QueryRowContext(ctx, "SELECT ssl, version, cipher FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&used, &version, &cipher)
+		if err == nil {
+			info.Used = &used
+			info.Version, info.Cipher = version.String, cipher.String
+		}
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		if db == nil {
+			return info
+		}
+		var rows *sql.Rows
+		rows, err = db.QueryContext(ctx, "SHOW SESSION STATUS WHERE Variable_name IN ('Ssl_version', 'Ssl_cipher')")
+		if err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var name, value string
+				if err = rows.Scan(&name, &value); err != nil {
+					break
+				}
+				if name == "Ssl_version" {
+					info.Version = value
+				} else {
+					info.Cipher = value
+				}
+			}
+			if err == nil {
+				err = rows.Err()
+			}
+			if err == nil {
+				// Both are empty on plain text connections
+				used := info.Version != ""
+				info.Used = &used
+			}
+		}
+	case constants.DatabaseTypeClickhouse:
+		used := config.UseSSL
+		info.Used = &used
+	case constants.DatabaseTypeElasticsearch:
+		// The REST API is called over HTTPS unless SSL is off or disabled, see certificateTLSConfig
+		used := config.UseSSL && info.SSLMode != "disable"
+		info.Used = &used
+	case constants.DatabaseTypeCassandra:
+		// gocql fails rather than falling back to plain text, see certificateTLSConfig
+		used := config.UseSSL && info.SSLMode != "disable"
+		info.Used = &used
+	case constants.DatabaseTypeBigQuery:
+		// The BigQuery API is only served over HTTPS
+		used := true
+		info.Used = &used
+	case constants.DatabaseTypeMongoDB:
+		// SRV connections (MongoDB Atlas) use TLS by default
+		used := (config.UseSSL && info.SSLMode != "disable") || strings.Contains(config.Host, ".mongodb.net")
+		info.Used = &used
+	}
+	if err != nil {
+		log.Printf("DBManager -> fetchTLSInfo -> Failed to get the TLS details of the %s connection: %v", config.Type, err)
+	}
+	return info
+}
+
+// certificateTLSConfig builds the TLS configuration of a connection whose driver takes a *tls.Config from its SSL
+// settings, nil for plain text. The certificates are fetched from their URLs to temporary files, returned to be removed
+// on disconnect.
+func certificateTLSConfig(config ConnectionConfig) (*tls.Config, []string, error) {
+	sslMode := "require"
+	if config.SSLMode != nil {
+		sslMode = *config.SSLMode
+	}
+	if !config.UseSSL || sslMode == "disable" {
+		return nil, nil, nil
+	}
+
+	var certURL, keyURL, rootCertURL string
+	if config.SSLCertURL != nil {
+		certURL = *config.SSLCertURL
+	}
+	if config.SSLKeyURL != nil {
+		keyURL = *config.SSLKeyURL
+	}
+	if config.SSLRootCertURL != nil {
+		rootCertURL = *config.SSLRootCertURL
+	}
+	certPath, keyPath, rootCertPath, tempFiles, err := utils.PrepareCertificatesFromURLs(certURL, keyURL, rootCertURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	removeTempFiles := func() {
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: config.Host,
+		MinVersion: tls.VersionTLS12,
+		// require encrypts without verifying the certificate of the server, verify-ca & verify-full verify it
+		InsecureSkipVerify: sslMode == "require",
+	}
+	if certPath != "" && keyPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			removeTempFiles()
+			return nil, nil, fmt.Errorf("failed to load client certificates: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if rootCertPath != "" {
+		pem, err := os.ReadFile(rootCertPath)
+		if err != nil {
+			removeTempFiles()
+			return nil, nil, fmt.Errorf("failed to read CA certificate: %v", err)
+		}
+		rootCertPool := x509.NewCertPool()
+		if !rootCertPool.AppendCertsFromPEM(pem) {
+			removeTempFiles()
+			return nil, nil, fmt.Errorf("failed to append CA certificate")
+		}
+		tlsConfig.RootCAs = rootCertPool
+	}
+	return tlsConfig, tempFiles, nil
+}
@@ -181,18 +181,20 @@ func (w *PostgresWrapper) Close() error {
 	return sqlDB.Close()
 }
 
-// MySQLWrapper implements DBExecutor for MySQL
+// MySQLWrapper implements DBExecutor for MySQL & MariaDB
 type MySQLWrapper struct {
 	BaseWrapper
+	dbType string // Picks the schema fetcher, mysql or mariadb
 }
 
-func NewMySQLWrapper(db *gorm.DB, manager *Manager, chatID string) *MySQLWrapper {
+func NewMySQLWrapper(db *gorm.DB, manager *Manager, chatID, dbType string) *MySQLWrapper {
 	return &MySQLWrapper{
 		BaseWrapper: BaseWrapper{
 			db:      db,
 			manager: manager,
 			chatID:  chatID,
 		},
+		dbType: dbType,
 	}
 }
 
@@ -215,15 +217,15 @@ func (w *MySQLWrapper) GetSchema(ctx context.Context) (*SchemaInfo, error) {
 	}
 
 	// Check if MySQL driver exists
-	_, exists := w.manager.drivers["mysql"]
+	_, exists := w.manager.drivers[w.dbType]
 	if !exists {
-		return nil, fmt.Errorf("MySQL driver not found")
+		return nil, fmt.Errorf("%s driver not found", w.dbType)
 	}
 
-	// Get the schema fetcher factory for MySQL
-	fetcherFactory, exists := w.manager.fetchers["mysql"]
+	// Get the schema fetcher factory for MySQL or MariaDB
+	fetcherFactory, exists := w.manager.fetchers[w.dbType]
 	if !exists {
-		return nil, fmt.Errorf("MySQL schema fetcher not found")
+		return nil, fmt.Errorf("%s schema fetcher not found", w.dbType)
 	}
 
 	// Create a schema fetcher for this connection
@@ -271,10 +273,10 @@ func (w *MySQLWrapper) GetTableChecksum(ctx context.Context, table string) (stri
 		return "", fmt.Errorf("failed to update usage: %v", err)
 	}
 
-	// Get the schema fetcher factory for MySQL
-	fetcherFactory, exists := w.manager.fetchers["mysql"]
+	// Get the schema fetcher factory for MySQL or MariaDB
+	fetcherFactory, exists := w.manager.fetchers[w.dbType]
 	if !exists {
-		return "", fmt.Errorf("MySQL schema fetcher not found")
+		return "", fmt.Errorf("%s schema fetcher not found", w.dbType)
 	}
 
 	// Create a schema fetcher for this connection
@@ -473,3 +475,120 @@ func (w *ClickHouseWrapper) Close() error {
 	}
 	return sqlDB.Close()
 }
+
+// SQLiteWrapper implements DBExecutor for SQLite
+type SQLiteWrapper struct {
+	BaseWrapper
+}
+
+func NewSQLiteWrapper(db *gorm.DB, manager *Manager, chatID string) *SQLiteWrapper {
+	return &SQLiteWrapper{
+		BaseWrapper: BaseWrapper{
+			db:      db,
+			manager: manager,
+			chatID:  chatID,
+		},
+	}
+}
+
+// GetDB returns the underlying *sql.DB
+func (w *SQLiteWrapper) GetDB() *sql.DB {
+	sqlDB, err := w.db.DB()
+	if err != nil {
+		log.Printf("Failed to get SQL DB: %v", err)
+		return nil
+	}
+	return sqlDB
+}
+
+// GetSchema fetches the current database schema
+func (w *SQLiteWrapper) GetSchema(ctx context.Context) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteWrapper -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Get the schema fetcher factory for SQLite
+	fetcherFactory, exists := w.manager.fetchers["sqlite"]
+	if !exists {
+		return nil, fmt.Errorf("SQLite schema fetcher not found")
+	}
+
+	// Create a schema fetcher for this connection
+	fetcher := fetcherFactory(w)
+
+	// Get selected collections from the chat service if available
+	selectedTables := []string{"ALL"}
+	if w.manager.streamHandler != nil {
+		selectedCollections, err := w.manager.streamHandler.GetSelectedCollections(w.chatID)
+		if err == nil && selectedCollections != "ALL" && selectedCollections != "" {
+			selectedTables = strings.Split(selectedCollections, ",")
+			log.Printf("SQLiteWrapper -> GetSchema -> Using selected collections for chat %s: %v", w.chatID, selectedTables)
+		}
+	}
+
+	return fetcher.GetSchema(ctx, w, selectedTables)
+}
+
+// GetTableChecksum calculates checksum for a single table
+func (w *SQLiteWrapper) GetTableChecksum(ctx context.Context, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteWrapper -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	if err := w.updateUsage(); err != nil {
+		return "", fmt.Errorf("failed to update usage: %v", err)
+	}
+
+	// Get the schema fetcher factory for SQLite
+	fetcherFactory, exists := w.manager.fetchers["sqlite"]
+	if !exists {
+		return "", fmt.Errorf("SQLite schema fetcher not found")
+	}
+
+	return fetcherFactory(w).GetTableChecksum(ctx, w, table)
+}
+
+// Raw executes a raw SQL query
+func (w *SQLiteWrapper) Raw(sql string, values ...interface{}) error {
+	if err := w.updateUsage(); err != nil {
+		return fmt.Errorf("failed to update usage: %v", err)
+	}
+	return w.db.Raw(sql, values...).Error
+}
+
+// Exec executes a SQL statement
+func (w *SQLiteWrapper) Exec(sql string, values ...interface{}) error {
+	if err := w.updateUsage(); err != nil {
+		return fmt.Errorf("failed to update usage: %v", err)
+	}
+	return w.db.Exec(sql, values...).Error
+}
+
+// Query executes a SQL query and scans the result into dest
+func (w *SQLiteWrapper) Query(sql string, dest interface{}, values ...interface{}) error {
+	if err := w.updateUsage(); err != nil {
+		return fmt.Errorf("failed to update usage: %v", err)
+	}
+	return w.db.Raw(sql, values...).Scan(dest).Error
+}
+
+// QueryRows executes a SQL query and scans the result into dest
+func (w *SQLiteWrapper) QueryRows(sql string, dest *[]map[string]interface{}, values ...interface{}) error {
+	if err := w.updateUsage(); err != nil {
+		return fmt.Errorf("failed to update usage: %v", err)
+	}
+	return w.db.Raw(sql, values...).Scan(dest).Error
+}
+
+// Close closes the database connection
+func (w *SQLiteWrapper) Close() error {
+	sqlDB, err := w.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,661 @@
+package dbmanager
+
+import (
+	"bytes"
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// elasticsearchDefaultPort is the port of the REST API of Elasticsearch
+	elasticsearchDefaultPort = "9200"
+	// elasticsearchRequestTimeout bounds a request without a deadline of its own, ex: the ping of a connection
+	elasticsearchRequestTimeout = 2 * time.Minute
+)
+
+// elasticsearchEndpoints are the read only endpoints a query can call, the connection never changes the indices
+var elasticsearchEndpoints = map[string]bool{
+	"_search": true,
+	"_count":  true,
+}
+
+// ElasticsearchClient is the client of an Elasticsearch connection, stored in Connection.ElasticsearchObj. The
+// queries read IndexPattern (the database of the connection, ex: logs-* or orders,customers) unless they name their
+// own index.
+type ElasticsearchClient struct {
+	HTTPClient   *http.Client
+	BaseURL      string // ex: https://host:9200
+	Username     string
+	Password     string
+	IndexPattern string
+}
+
+// elasticsearchError is the error of a request, the type & reason Elasticsearch returned or the failed request
+type elasticsearchError struct {
+	Status int
+	Type   string // ex: index_not_found_exception
+	Reason string
+}
+
+func (e *elasticsearchError) Error() string {
+	if e.Type == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Reason)
+}
+
+// do sends a request to the REST API & decodes its JSON response, the numbers are kept as json.Number
+func (c *ElasticsearchClient) do(ctx context.Context, method, path string, body interface{}, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode the request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, elasticsearchRequestTimeout)
+		defer cancel()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.BaseURL+"/"+strings.TrimPrefix(path, "/"), reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.Username != "" {
+		request.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the response: %v", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return elasticsearchResponseError(resp.StatusCode, data)
+	}
+	if response == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(response); err != nil {
+		return fmt.Errorf("failed to decode the response: %v", err)
+	}
+	return nil
+}
+
+// elasticsearchResponseError reads the error of a failed request, the root cause is the most precise one, ex: the
+// field of a failed query rather than "all shards failed"
+func elasticsearchResponseError(status int, data []byte) *elasticsearchError {
+	var response struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil || len(response.Error) == 0 {
+		return &elasticsearchError{Status: status, Reason: fmt.Sprintf("request failed with status %d: %s", status, strings.TrimSpace(string(data)))}
+	}
+
+	var details struct {
+		Type      string `json:"type"`
+		Reason    string `json:"reason"`
+		RootCause []struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"root_cause"`
+	}
+	if err := json.Unmarshal(response.Error, &details); err != nil {
+		// Some errors are a plain message
+		var message string
+		json.Unmarshal(response.Error, &message)
+		return &elasticsearchError{Status: status, Reason: message}
+	}
+	if len(details.RootCause) > 0 && details.RootCause[0].Reason != "" {
+		return &elasticsearchError{Status: status, Type: details.RootCause[0].Type, Reason: details.RootCause[0].Reason}
+	}
+	return &elasticsearchError{Status: status, Type: details.Type, Reason: details.Reason}
+}
+
+// ElasticsearchDriver implements the DatabaseDriver interface for Elasticsearch. Elasticsearch is reached through its
+// REST API: a connection is the URL of the cluster & an index pattern, the queries are Query DSL searches & counts
+// (the indices are never changed) & the hits are returned as rows of their source.
+type ElasticsearchDriver struct{}
+
+// NewElasticsearchDriver creates a new Elasticsearch driver
+func NewElasticsearchDriver() DatabaseDriver {
+	return &ElasticsearchDriver{}
+}
+
+// Connect creates an Elasticsearch client & checks that the cluster can be reached with the credentials
+func (d *ElasticsearchDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("the host of the Elasticsearch connection is required")
+	}
+
+	tlsConfig, tempFiles, err := certificateTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	port := elasticsearchDefaultPort
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &ElasticsearchClient{
+		HTTPClient:   &http.Client{Transport: transport},
+		BaseURL:      (&url.URL{Scheme: scheme, Host: net.JoinHostPort(config.Host, port)}).String(),
+		IndexPattern: strings.TrimSpace(config.Database),
+	}
+	if config.Username != nil {
+		client.Username = *config.Username
+	}
+	if config.Password != nil {
+		client.Password = *config.Password
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var info struct {
+		ClusterName string `json:"cluster_name"`
+		Version     struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	if err := client.do(ctx, http.MethodGet, "/", nil, &info); err != nil {
+		for _, file := range tempFiles {
+			os.Remove(file)
+		}
+		return nil, fmt.Errorf("failed to reach Elasticsearch at %s: %v", client.BaseURL, err)
+	}
+	log.Printf("ElasticsearchDriver -> Connect -> Connected to cluster %s, version %s", info.ClusterName, info.Version.Number)
+
+	return &Connection{
+		ElasticsearchObj: client,
+		LastUsed:         time.Now(),
+		Status:           StatusConnected,
+		Config:           config,
+		Subscribers:      make(map[string]bool),
+		SubLock:          sync.RWMutex{},
+		TempFiles:        tempFiles,
+	}, nil
+}
+
+// elasticsearchClientOf returns the Elasticsearch client of a connection
+func elasticsearchClientOf(conn *Connection) (*ElasticsearchClient, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+	client, ok := conn.ElasticsearchObj.(*ElasticsearchClient)
+	if !ok || client == nil {
+		return nil, fmt.Errorf("invalid Elasticsearch connection, try disconnecting and reconnecting")
+	}
+	return client, nil
+}
+
+// Disconnect closes the idle connections of the client & removes the certificate files of the connection
+func (d *ElasticsearchDriver) Disconnect(conn *Connection) error {
+	if client, err := elasticsearchClientOf(conn); err == nil {
+		client.HTTPClient.CloseIdleConnections()
+	}
+	for _, file := range conn.TempFiles {
+		os.Remove(file)
+	}
+	return nil
+}
+
+// Ping checks that the cluster can still be reached
+func (d *ElasticsearchDriver) Ping(conn *Connection) error {
+	client, err := elasticsearchClientOf(conn)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.do(ctx, http.MethodGet, "/", nil, nil)
+}
+
+// IsAlive checks if the Elasticsearch connection is still valid
+func (d *ElasticsearchDriver) IsAlive(conn *Connection) bool {
+	return d.Ping(conn) == nil
+}
+
+// ExecuteQuery executes a query on Elasticsearch
+func (d *ElasticsearchDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := elasticsearchClientOf(conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+	return executeElasticsearchQuery(ctx, client, query)
+}
+
+// BeginTx returns a transaction executing the queries as they come, Elasticsearch queries only read the indices
+func (d *ElasticsearchDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
+	if _, err := elasticsearchClientOf(conn); err != nil {
+		log.Printf("ElasticsearchDriver -> BeginTx -> %v", err)
+		return nil
+	}
+	return &ElasticsearchTransaction{conn: conn}
+}
+
+// elasticsearchRequest is a query generated by the LLM, written like in the Kibana console: the method & the path on
+// the first line, ex: GET /orders/_search, followed by the Query DSL body
+type elasticsearchRequest struct {
+	Index    string // Index, alias or pattern the query reads, the index pattern of the connection if empty
+	Endpoint string // _search or _count
+	Body     map[string]interface{}
+}
+
+// parseElasticsearchQuery parses a query, a body without a request line searches the index pattern of the connection
+func parseElasticsearchQuery(query string) (*elasticsearchRequest, error) {
+	query = strings.TrimSpace(query)
+	request := &elasticsearchRequest{Endpoint: "_search"}
+
+	body := query
+	if !strings.HasPrefix(query, "{") {
+		line, rest, _ := strings.Cut(query, "\n")
+		body = strings.TrimSpace(rest)
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid request line %q, expected a method & a path, ex: GET /index/_search", strings.TrimSpace(line))
+		}
+		if method := strings.ToUpper(fields[0]); method != http.MethodGet && method != http.MethodPost {
+			return nil, fmt.Errorf("the %s method is not allowed, Elasticsearch queries can only search the indices", method)
+		}
+		path := strings.Trim(fields[1], "/")
+		if strings.Contains(path, "?") {
+			return nil, fmt.Errorf("query string parameters are not supported, write them in the request body")
+		}
+		segments := strings.Split(path, "/")
+		request.Endpoint = segments[len(segments)-1]
+		if !elasticsearchEndpoints[request.Endpoint] || len(segments) > 2 {
+			return nil, fmt.Errorf("only the _search & _count endpoints are allowed, got /%s", path)
+		}
+		if len(segments) == 2 {
+			if request.Index = segments[0]; request.Index == "" || strings.HasPrefix(request.Index, "_") {
+				return nil, fmt.Errorf("invalid index %q", request.Index)
+			}
+		}
+	}
+
+	request.Body = make(map[string]interface{})
+	if body != "" {
+		decoder := json.NewDecoder(strings.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&request.Body); err != nil {
+			return nil, fmt.Errorf("invalid Query DSL body: %v", err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("invalid Query DSL body: a query runs a single request")
+		}
+	}
+	return request, nil
+}
+
+// elasticsearchSearchResponse is the response of a _search request
+type elasticsearchSearchResponse struct {
+	TimedOut bool `json:"timed_out"`
+	Hits     struct {
+		Total *struct {
+			Value    int64  `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		Hits []struct {
+			Index  string                 `json:"_index"`
+			ID     string                 `json:"_id"`
+			Source map[string]interface{} `json:"_source"`
+			Fields map[string]interface{} `json:"fields"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]interface{} `json:"aggregations"`
+}
+
+// runElasticsearchRequest runs a query & normalizes its response to rows, at most limit of them (0 keeps them all).
+// The rows of a search are the hits, or the rows of its aggregations when it returns no hit (ex: "size": 0).
+func runElasticsearchRequest(ctx context.Context, client *ElasticsearchClient, query string, limit int) (map[string]interface{}, bool, error) {
+	request, err := parseElasticsearchQuery(query)
+	if err != nil {
+		return nil, false, err
+	}
+	index := request.Index
+	if index == "" {
+		index = client.IndexPattern
+	}
+	path := request.Endpoint
+	if index != "" {
+		path = url.PathEscape(index) + "/" + request.Endpoint
+	}
+
+	if request.Endpoint == "_count" {
+		var response struct {
+			Count int64 `json:"count"`
+		}
+		if err := client.do(ctx, http.MethodPost, path, request.Body, &response); err != nil {
+			return nil, false, err
+		}
+		return map[string]interface{}{"count": response.Count}, false, nil
+	}
+
+	var response elasticsearchSearchResponse
+	if err := client.do(ctx, http.MethodPost, path, request.Body, &response); err != nil {
+		return nil, false, err
+	}
+
+	multiIndex := index == "" || strings.ContainsAny(index, ",*")
+	rows := make([]map[string]interface{}, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		row := make(map[string]interface{}, len(hit.Source)+2)
+		row["_id"] = hit.ID
+		if multiIndex {
+			row["_index"] = hit.Index
+		}
+		for field, value := range hit.Source {
+			row[field] = value
+		}
+		// Script & docvalue fields are lists of values, a single one is unwrapped
+		for field, value := range hit.Fields {
+			if values, ok := value.([]interface{}); ok && len(values) == 1 {
+				value = values[0]
+			}
+			row[field] = value
+		}
+		rows = append(rows, row)
+	}
+
+	result := map[string]interface{}{}
+	if len(response.Aggregations) > 0 {
+		aggregationRows := flattenElasticsearchAggregations(response.Aggregations)
+		if len(rows) == 0 {
+			rows = aggregationRows
+		} else {
+			result["aggregations"] = aggregationRows
+		}
+	}
+	if response.Hits.Total != nil {
+		result["total"] = response.Hits.Total.Value
+	}
+
+	truncated := false
+	if limit > 0 && len(rows) > limit {
+		rows, truncated = rows[:limit], true
+	}
+	result["results"] = rows
+	return result, truncated, nil
+}
+
+// flattenElasticsearchAggregations converts the aggregations of a search to rows: a row per bucket of the bucket
+// aggregations (a row per leaf bucket when they are nested) with the key & doc_count of the bucket & the values of its
+// metrics, or a single row of the metrics when there is no bucket aggregation
+func flattenElasticsearchAggregations(aggregations map[string]interface{}) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	metrics := map[string]interface{}{}
+
+	names := make([]string, 0, len(aggregations))
+	for name := range aggregations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		aggregation, ok := aggregations[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		buckets := elasticsearchBuckets(aggregation)
+		if buckets == nil {
+			addElasticsearchMetric(metrics, name, aggregation)
+			continue
+		}
+		for _, bucket := range buckets {
+			rows = append(rows, flattenElasticsearchBucket(name, bucket)...)
+		}
+	}
+
+	if len(metrics) > 0 {
+		if len(rows) == 0 {
+			return []map[string]interface{}{metrics}
+		}
+		// The metrics of the whole search are repeated on every bucket row
+		for _, row := range rows {
+			for name, value := range metrics {
+				row[name] = value
+			}
+		}
+	}
+	return rows
+}
+
+// flattenElasticsearchBucket returns the rows of a bucket: a single row, or a row per bucket of its sub aggregations
+// each with the key of the bucket
+func flattenElasticsearchBucket(name string, bucket map[string]interface{}) []map[string]interface{} {
+	row := map[string]interface{}{name: elasticsearchBucketKey(bucket)}
+	if count, ok := bucket["doc_count"]; ok {
+		row["doc_count"] = count
+	}
+
+	subAggregations := map[string]interface{}{}
+	for field, value := range bucket {
+		if field == "key" || field == "key_as_string" || field == "doc_count" || field == "from" || field == "to" {
+			continue
+		}
+		if _, ok := value.(map[string]interface{}); ok {
+			subAggregations[field] = value
+		}
+	}
+	if len(subAggregations) == 0 {
+		return []map[string]interface{}{row}
+	}
+
+	subRows := flattenElasticsearchAggregations(subAggregations)
+	if len(subRows) == 0 {
+		// The sub aggregations have no bucket, the bucket is still a row
+		return []map[string]interface{}{row}
+	}
+	rows := make([]map[string]interface{}, 0, len(subRows))
+	for _, subRow := range subRows {
+		merged := make(map[string]interface{}, len(row)+len(subRow))
+		for field, value := range row {
+			merged[field] = value
+		}
+		// The doc_count of the sub bucket replaces the one of the bucket, a row counts the documents of its leaf
+		for field, value := range subRow {
+			merged[field] = value
+		}
+		rows = append(rows, merged)
+	}
+	return rows
+}
+
+// elasticsearchBuckets returns the buckets of a bucket aggregation (keyed ones are sorted by key), nil for a metric
+func elasticsearchBuckets(aggregation map[string]interface{}) []map[string]interface{} {
+	switch buckets := aggregation["buckets"].(type) {
+	case []interface{}:
+		list := make([]map[string]interface{}, 0, len(buckets))
+		for _, bucket := range buckets {
+			if bucket, ok := bucket.(map[string]interface{}); ok {
+				list = append(list, bucket)
+			}
+		}
+		return list
+	case map[string]interface{}:
+		keys := make([]string, 0, len(buckets))
+		for key := range buckets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		list := make([]map[string]interface{}, 0, len(buckets))
+		for _, key := range keys {
+			if bucket, ok := buckets[key].(map[string]interface{}); ok {
+				if _, hasKey := bucket["key"]; !hasKey {
+					bucket["key"] = key
+				}
+				list = append(list, bucket)
+			}
+		}
+		return list
+	}
+	// A single bucket aggregation (filter, missing, nested...) is a bucket of its own
+	if _, ok := aggregation["doc_count"]; ok {
+		bucket := map[string]interface{}{}
+		for field, value := range aggregation {
+			bucket[field] = value
+		}
+		return []map[string]interface{}{bucket}
+	}
+	return nil
+}
+
+// elasticsearchBucketKey returns the key of a bucket, its formatted key for dates
+func elasticsearchBucketKey(bucket map[string]interface{}) interface{} {
+	if key, ok := bucket["key_as_string"]; ok {
+		return key
+	}
+	return bucket["key"]
+}
+
+// addElasticsearchMetric adds the value of a metric aggregation to a row: the value of a single value metric, or
+// name.stat for the stats of a multi value one (ex: price.avg & price.max for a stats aggregation)
+func addElasticsearchMetric(row map[string]interface{}, name string, aggregation map[string]interface{}) {
+	if value, ok := aggregation["value_as_string"]; ok {
+		row[name] = value
+		return
+	}
+	if value, ok := aggregation["value"]; ok {
+		row[name] = value
+		return
+	}
+	if values, ok := aggregation["values"].(map[string]interface{}); ok {
+		// Percentiles
+		for stat, value := range values {
+			row[name+"."+stat] = value
+		}
+		return
+	}
+	for stat, value := range aggregation {
+		if stat == "meta" {
+			continue
+		}
+		if _, nested := value.(map[string]interface{}); !nested {
+			row[name+"."+stat] = value
+		}
+	}
+}
+
+// executeElasticsearchQuery executes a search or a count on Elasticsearch, the rows are kept up to the row limit of
+// the context
+func executeElasticsearchQuery(ctx context.Context, client *ElasticsearchClient, query string) *QueryExecutionResult {
+	startTime := time.Now()
+	result := &QueryExecutionResult{}
+
+	if strings.TrimSpace(query) == "" {
+		result.Error = &dtos.QueryError{
+			Message: "Empty query",
+			Code:    "EXECUTION_ERROR",
+		}
+		return result
+	}
+
+	limit := resultRowLimit(ctx)
+	response, truncated, err := runElasticsearchRequest(ctx, client, query, limit)
+	if err != nil {
+		if ctx.Err() != nil {
+			result.Error = &dtos.QueryError{
+				Message: "Query execution cancelled",
+				Code:    "EXECUTION_CANCELLED",
+			}
+			return result
+		}
+		result.Error = &dtos.QueryError{
+			Message: err.Error(),
+			Code:    "EXECUTION_ERROR",
+		}
+		if esErr, ok := err.(*elasticsearchError); ok && esErr.Type != "" {
+			result.Error.Details = esErr.Type
+		}
+		return result
+	}
+	if truncated {
+		result.Warnings = append(result.Warnings, resultTruncatedWarning(limit))
+	}
+	result.Result = response
+
+	// Calculate execution time
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result.Result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Code:    "JSON_MARSHAL_FAILED",
+				Message: err.Error(),
+				Details: "Failed to marshal query results",
+			},
+		}
+	}
+	result.ResultJSON = string(resultJSON)
+
+	return result
+}
+
+// GetSchema retrieves the database schema
+func (d *ElasticsearchDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewElasticsearchSchemaFetcher(db).GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *ElasticsearchDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+	return NewElasticsearchSchemaFetcher(db).GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *ElasticsearchDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewElasticsearchSchemaFetcher(db).FetchExampleRecords(ctx, db, table, limit)
+}
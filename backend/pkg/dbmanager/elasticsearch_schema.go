@@ -0,0 +1,268 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ElasticsearchSchemaFetcher implements schema fetching for Elasticsearch: the indices are the tables & the fields of
+// their mappings the columns, the fields of object & nested fields are named by their path (ex: customer.email)
+type ElasticsearchSchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewElasticsearchSchemaFetcher creates a new Elasticsearch schema fetcher
+func NewElasticsearchSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &ElasticsearchSchemaFetcher{db: db}
+}
+
+// elasticsearchClientOfExecutor returns the Elasticsearch client of an executor
+func elasticsearchClientOfExecutor(db DBExecutor) (*ElasticsearchClient, error) {
+	executor, ok := db.(*ElasticsearchExecutor)
+	if !ok {
+		return nil, fmt.Errorf("not an Elasticsearch connection")
+	}
+	return executor.client, nil
+}
+
+// indexPattern returns the escaped index pattern of the connection for a path, every open index if there is none
+func (f *ElasticsearchSchemaFetcher) indexPattern(client *ElasticsearchClient) string {
+	if client.IndexPattern == "" {
+		return "*"
+	}
+	return url.PathEscape(client.IndexPattern)
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *ElasticsearchSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("ElasticsearchSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+	log.Printf("ElasticsearchSchemaFetcher -> GetSchema -> Successfully fetched schema with %d indices", len(schema.Tables))
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	log.Printf("ElasticsearchSchemaFetcher -> GetSchema -> Filtered schema to %d indices", len(filteredSchema.Tables))
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the mappings of the indices matching the index pattern of the connection
+func (f *ElasticsearchSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	client, err := elasticsearchClientOfExecutor(f.db)
+	if err != nil {
+		return nil, err
+	}
+	rowCounts, err := f.fetchDocumentCounts(ctx, client)
+	if err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> FetchSchema -> Error fetching indices: %v", err)
+		return nil, err
+	}
+	mappings, err := f.fetchMappings(ctx, client, f.indexPattern(client))
+	if err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> FetchSchema -> Error fetching mappings: %v", err)
+		return nil, err
+	}
+	log.Printf("ElasticsearchSchemaFetcher -> FetchSchema -> Processing %d indices", len(mappings))
+
+	for index, mapping := range mappings {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// The hidden & system indices (ex: .kibana) are left out
+		if strings.HasPrefix(index, ".") {
+			continue
+		}
+
+		tableSchema := TableSchema{
+			Name:        index,
+			Columns:     make(map[string]ColumnInfo),
+			Indexes:     make(map[string]IndexInfo),
+			ForeignKeys: make(map[string]ForeignKey),
+			Constraints: make(map[string]ConstraintInfo),
+			Comment:     elasticsearchMappingDescription(mapping),
+			RowCount:    rowCounts[index],
+		}
+		properties, _ := mapping["properties"].(map[string]interface{})
+		addElasticsearchFields(tableSchema.Columns, "", properties)
+		log.Printf("ElasticsearchSchemaFetcher -> FetchSchema -> Index %s: %d fields, %d documents", index, len(tableSchema.Columns), tableSchema.RowCount)
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[index] = tableSchema
+	}
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("ElasticsearchSchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d indices", len(schema.Tables))
+	return schema, nil
+}
+
+// fetchMappings retrieves the mapping of the indices matching a pattern, by index
+func (f *ElasticsearchSchemaFetcher) fetchMappings(ctx context.Context, client *ElasticsearchClient, pattern string) (map[string]map[string]interface{}, error) {
+	var response map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := client.do(ctx, http.MethodGet, pattern+"/_mapping", nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch mappings: %v", err)
+	}
+	mappings := make(map[string]map[string]interface{}, len(response))
+	for index, mapping := range response {
+		mappings[index] = mapping.Mappings
+	}
+	return mappings, nil
+}
+
+// fetchDocumentCounts retrieves the number of documents of the open indices matching the index pattern, by index
+func (f *ElasticsearchSchemaFetcher) fetchDocumentCounts(ctx context.Context, client *ElasticsearchClient) (map[string]int64, error) {
+	var rows []map[string]interface{}
+	path := "_cat/indices/" + f.indexPattern(client) + "?format=json&h=index,docs.count&expand_wildcards=open"
+	if err := client.do(ctx, http.MethodGet, path, nil, &rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch indices: %v", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		index, _ := row["index"].(string)
+		count, _ := row["docs.count"].(string)
+		counts[index], _ = strconv.ParseInt(count, 10, 64)
+	}
+	return counts, nil
+}
+
+// elasticsearchMappingDescription returns the description of an index, from the _meta of its mapping
+func elasticsearchMappingDescription(mapping map[string]interface{}) string {
+	meta, _ := mapping["_meta"].(map[string]interface{})
+	description, _ := meta["description"].(string)
+	return description
+}
+
+// addElasticsearchFields adds the fields of mapping properties as columns named by their path. The object fields are
+// only containers, their fields are added instead, while a nested field is a column too as it is queried with a
+// nested query. The multi fields are columns of their own, ex: name.keyword of a text field.
+func addElasticsearchFields(columns map[string]ColumnInfo, prefix string, properties map[string]interface{}) {
+	for name, value := range properties {
+		field, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := prefix + name
+		fieldType, _ := field["type"].(string)
+		subProperties, hasProperties := field["properties"].(map[string]interface{})
+		if fieldType == "" && hasProperties {
+			fieldType = "object"
+		}
+		if fieldType != "object" {
+			columns[path] = ColumnInfo{
+				Name: path,
+				Type: fieldType,
+				// A document may lack any field, there is no required field
+				IsNullable: true,
+			}
+		}
+		if hasProperties {
+			addElasticsearchFields(columns, path+".", subProperties)
+		}
+		if multiFields, ok := field["fields"].(map[string]interface{}); ok {
+			addElasticsearchFields(columns, path+".", multiFields)
+		}
+	}
+}
+
+// GetTableChecksum calculates a checksum for an index from its mapping
+func (f *ElasticsearchSchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	client, err := elasticsearchClientOfExecutor(db)
+	if err != nil {
+		return "", err
+	}
+	mappings, err := f.fetchMappings(ctx, client, url.PathEscape(table))
+	if err != nil {
+		return "", err
+	}
+	mapping, ok := mappings[table]
+	if !ok {
+		return "", fmt.Errorf("index %s not found", table)
+	}
+	data, _ := json.Marshal(mapping)
+	return fmt.Sprintf("%x", md5.Sum(data)), nil
+}
+
+// FetchExampleRecords retrieves sample documents from an index
+func (f *ElasticsearchSchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	var records []map[string]interface{}
+	query := fmt.Sprintf("GET /%s/_search\n{\"size\": %d}", table, limit)
+	if err := db.QueryRows(query, &records); err != nil {
+		log.Printf("ElasticsearchSchemaFetcher -> FetchExampleRecords -> Error fetching records from index %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for index %s: %v", table, err)
+	}
+	log.Printf("ElasticsearchSchemaFetcher -> FetchExampleRecords -> Fetched %d records from index %s", len(records), table)
+	return records, nil
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected indices
+func (f *ElasticsearchSchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool, len(selectedTables))
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: schema.UpdatedAt,
+	}
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+	return filteredSchema
+}
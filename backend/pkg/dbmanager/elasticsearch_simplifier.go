@@ -0,0 +1,57 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// ElasticsearchSimplifier implements the SchemaSimplifier interface for Elasticsearch
+type ElasticsearchSimplifier struct{}
+
+// SimplifyDataType converts Elasticsearch field types to simplified versions for LLM
+func (s *ElasticsearchSimplifier) SimplifyDataType(dbType string) string {
+	switch strings.ToLower(strings.TrimSpace(dbType)) {
+	case "long", "integer", "short", "byte", "unsigned_long":
+		return "integer"
+	case "double", "float", "half_float", "scaled_float":
+		return "number"
+	case "date", "date_nanos":
+		return "datetime"
+	case "text", "match_only_text":
+		return "text"
+	case "keyword", "constant_keyword", "wildcard":
+		return "keyword"
+	case "boolean":
+		return "boolean"
+	case "binary":
+		return "binary"
+	case "object", "flattened":
+		return "object"
+	case "nested":
+		return "nested"
+	default:
+		// ip, geo_point, geo_shape, dense_vector & the range types are kept as is
+		return dbType
+	}
+}
+
+// GetColumnConstraints returns a list of constraints for a field: how it can be searched, a text field matches words
+// while a keyword field matches exact values & can be sorted & aggregated
+func (s *ElasticsearchSimplifier) GetColumnConstraints(col ColumnInfo, table TableSchema) []string {
+	var constraints []string
+
+	switch strings.ToLower(col.Type) {
+	case "text", "match_only_text":
+		constraints = append(constraints, "FULL TEXT")
+	case "keyword", "constant_keyword", "wildcard":
+		constraints = append(constraints, "EXACT MATCH")
+	case "nested":
+		constraints = append(constraints, "NESTED QUERY")
+	}
+
+	// A text field with a keyword sub field is sorted & aggregated on the sub field
+	if _, ok := table.Columns[col.Name+".keyword"]; ok {
+		constraints = append(constraints, "AGGREGATE ON "+col.Name+".keyword")
+	}
+
+	return constraints
+}
@@ -0,0 +1,36 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+)
+
+// ElasticsearchTransaction implements the Transaction interface for Elasticsearch. The queries only search & count the
+// indices, there is nothing to commit or roll back.
+type ElasticsearchTransaction struct {
+	conn *Connection
+}
+
+// ExecuteQuery executes a query as its own request
+func (t *ElasticsearchTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	client, err := elasticsearchClientOf(t.conn)
+	if err != nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: err.Error(),
+				Code:    "TRANSACTION_ERROR",
+			},
+		}
+	}
+	return executeElasticsearchQuery(ctx, client, query)
+}
+
+// Commit does nothing, the query changed nothing
+func (t *ElasticsearchTransaction) Commit() error {
+	return nil
+}
+
+// Rollback does nothing, the query changed nothing
+func (t *ElasticsearchTransaction) Rollback() error {
+	return nil
+}
@@ -0,0 +1,149 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ElasticsearchExecutor implements DBExecutor for Elasticsearch, the queries are Query DSL requests written like in
+// the Kibana console (ex: GET /orders/_search followed by the body), the ? placeholders of SQL aren't supported
+type ElasticsearchExecutor struct {
+	BaseWrapper
+	client *ElasticsearchClient
+	conn   *Connection
+}
+
+// NewElasticsearchExecutor creates a new Elasticsearch executor
+func NewElasticsearchExecutor(conn *Connection, manager *Manager, chatID string) (*ElasticsearchExecutor, error) {
+	client, err := elasticsearchClientOf(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &ElasticsearchExecutor{
+		BaseWrapper: BaseWrapper{
+			manager: manager,
+			chatID:  chatID,
+		},
+		client: client,
+		conn:   conn,
+	}, nil
+}
+
+// GetDB returns nil for Elasticsearch as it doesn't use database/sql
+func (e *ElasticsearchExecutor) GetDB() *sql.DB {
+	return nil
+}
+
+// GetConnection returns the underlying connection
+func (e *ElasticsearchExecutor) GetConnection() *Connection {
+	return e.conn
+}
+
+// run runs a query & returns its rows, the hits or the aggregation rows of a search or the count of a count
+func (e *ElasticsearchExecutor) run(query string, values []interface{}) ([]map[string]interface{}, error) {
+	if len(values) > 0 {
+		return nil, fmt.Errorf("Elasticsearch queries don't support placeholders")
+	}
+	if err := e.updateUsage(); err != nil {
+		return nil, fmt.Errorf("failed to update usage: %v", err)
+	}
+	result, _, err := runElasticsearchRequest(context.Background(), e.client, query, 0)
+	if err != nil {
+		return nil, err
+	}
+	if rows, ok := result["results"].([]map[string]interface{}); ok {
+		return rows, nil
+	}
+	return []map[string]interface{}{result}, nil
+}
+
+// Raw executes a raw query
+func (e *ElasticsearchExecutor) Raw(query string, values ...interface{}) error {
+	_, err := e.run(query, values)
+	return err
+}
+
+// Exec executes a query
+func (e *ElasticsearchExecutor) Exec(query string, values ...interface{}) error {
+	_, err := e.run(query, values)
+	return err
+}
+
+// Query executes a query and decodes its rows into dest through JSON
+func (e *ElasticsearchExecutor) Query(query string, dest interface{}, values ...interface{}) error {
+	rows, err := e.run(query, values)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to encode the query result: %v", err)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// QueryRows executes a query and scans its rows into dest
+func (e *ElasticsearchExecutor) QueryRows(query string, dest *[]map[string]interface{}, values ...interface{}) error {
+	rows, err := e.run(query, values)
+	if err != nil {
+		return err
+	}
+	*dest = rows
+	return nil
+}
+
+// Close does nothing, the client is shared by the connections of the pool
+func (e *ElasticsearchExecutor) Close() error {
+	return nil
+}
+
+// GetSchema fetches the current database schema
+func (e *ElasticsearchExecutor) GetSchema(ctx context.Context) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchExecutor -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Get the schema fetcher factory for Elasticsearch
+	fetcherFactory, exists := e.manager.fetchers["elasticsearch"]
+	if !exists {
+		return nil, fmt.Errorf("Elasticsearch schema fetcher not found")
+	}
+
+	// Create a schema fetcher for this connection
+	fetcher := fetcherFactory(e)
+
+	// Get selected collections from the chat service if available
+	selectedTables := []string{"ALL"}
+	if e.manager.streamHandler != nil {
+		selectedCollections, err := e.manager.streamHandler.GetSelectedCollections(e.chatID)
+		if err == nil && selectedCollections != "ALL" && selectedCollections != "" {
+			selectedTables = strings.Split(selectedCollections, ",")
+			log.Printf("ElasticsearchExecutor -> GetSchema -> Using selected collections for chat %s: %v", e.chatID, selectedTables)
+		}
+	}
+
+	return fetcher.GetSchema(ctx, e, selectedTables)
+}
+
+// GetTableChecksum calculates checksum for a single table
+func (e *ElasticsearchExecutor) GetTableChecksum(ctx context.Context, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("ElasticsearchExecutor -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+
+	// Get the schema fetcher factory for Elasticsearch
+	fetcherFactory, exists := e.manager.fetchers["elasticsearch"]
+	if !exists {
+		return "", fmt.Errorf("Elasticsearch schema fetcher not found")
+	}
+
+	return fetcherFactory(e).GetTableChecksum(ctx, e, table)
+}
@@ -0,0 +1,204 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mongoFindRegex matches a MongoDB find without filter & captures its collection, ex: db.orders.find({}) or db.orders.find()
+var mongoFindRegex = regexp.MustCompile(`(?s)^\s*db\.(\w+)\.find\(\s*(?:\{\s*\})?\s*[,)]`)
+
+// sqlAggregateFunctions are the aggregates returning a single row when the query has no GROUP BY
+var sqlAggregateFunctions = map[string]bool{
+	"count": true, "sum": true, "avg": true, "min": true, "max": true, "array_agg": true, "string_agg": true,
+	"group_concat": true, "json_agg": true, "jsonb_agg": true, "bool_and": true, "bool_or": true, "uniq": true,
+	"groupuniqarray": true, "grouparray": true,
+}
+
+// FullTableRead is a table above the row threshold that a query reads entirely, without a selective filter or LIMIT
+type FullTableRead struct {
+	Table    string `json:"table"`
+	RowCount int64  `json:"row_count"`
+}
+
+func (r FullTableRead) String() string {
+	return fmt.Sprintf("%s (%d rows)", r.Table, r.RowCount)
+}
+
+// FormatFullTableReads describes the tables read entirely by a query, for the warning shown with its result
+func FormatFullTableReads(reads []FullTableRead, limit int) string {
+	tables := make([]string, len(reads))
+	for i, read := range reads {
+		tables[i] = read.String()
+	}
+	return fmt.Sprintf("The query reads every row of %s without a filter, only the first %d rows were read. Add a filter to the query, or execute it again confirming the full read.", strings.Join(tables, ", "), limit)
+}
+
+// FindFullTableReads finds the tables with more than threshold rows (as counted in the schema) that a query reads
+// entirely: a SELECT without LIMIT, aggregation nor selective WHERE, or a MongoDB find with an empty filter & no
+// limit. Tables unknown to the schema are ignored, a threshold of 0 disables the check.
+func FindFullTableReads(dbType, query string, schema *SchemaInfo, threshold int64) []FullTableRead {
+	if threshold <= 0 || schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+	tables := make(map[string]TableSchema, len(schema.Tables))
+	for name, table := range schema.Tables {
+		tables[strings.ToLower(name)] = table
+	}
+	largeTable := func(name string) []FullTableRead {
+		if table, ok := tables[strings.ToLower(name)]; ok && table.RowCount > threshold {
+			return []FullTableRead{{Table: table.Name, RowCount: table.RowCount}}
+		}
+		return nil
+	}
+
+	if dbType == constants.DatabaseTypeMongoDB {
+		// find() & find({}) read every document, find({}, projection) too
+		match := mongoFindRegex.FindStringSubmatch(query)
+		if match == nil || strings.Contains(query, ".limit(") {
+			return nil
+		}
+		return largeTable(match[1])
+	}
+
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].value != "select" {
+		return nil
+	}
+
+	depth := 0
+	where := -1
+	var refs []sqlTableRef
+	consumed := make(map[int]bool)
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth > 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].text
+		}
+
+		switch token.value {
+		case "limit", "fetch", "top", "sample", "group", "union", "intersect", "except":
+			// Bounded, sampled, aggregated or combined, the rows returned aren't the rows of a table
+			return nil
+		case "where":
+			where = i
+		case "from":
+			if i > 0 && tokens[i-1].value == "distinct" {
+				continue
+			}
+			for idx := i + 1; ; {
+				ref, end, ok := parseSQLTableRef(tokens, idx, consumed)
+				if !ok {
+					break
+				}
+				refs = append(refs, ref)
+				if end >= len(tokens) || tokens[end].text != "," {
+					break
+				}
+				idx = end + 1
+			}
+		case "join":
+			if ref, _, ok := parseSQLTableRef(tokens, i+1, consumed); ok {
+				refs = append(refs, ref)
+			}
+		default:
+			// SELECT count(*) FROM ... returns a single row
+			if next == "(" && sqlAggregateFunctions[token.value] && where == -1 && len(refs) == 0 {
+				return nil
+			}
+		}
+	}
+	if where != -1 && selectiveSQLFilter(tokens[where+1:]) {
+		return nil
+	}
+
+	var reads []FullTableRead
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if seen[ref.table] {
+			continue
+		}
+		seen[ref.table] = true
+		reads = append(reads, largeTable(ref.table)...)
+	}
+	return reads
+}
+
+// selectiveSQLFilter checks if the conditions of a WHERE clause can filter rows out. Conditions that are always true
+// (ex: 1 = 1, TRUE) or only exclude NULLs (ex: email IS NOT NULL) don't count, any other condition does.
+func selectiveSQLFilter(tokens []sqlToken) bool {
+	var condition []sqlToken
+	depth := 0
+	trivial := func() bool {
+		switch {
+		case len(condition) == 0:
+			return true
+		case len(condition) == 1:
+			return condition[0].value == "true" || condition[0].text == "1"
+		case len(condition) == 3 && condition[1].text == "=":
+			return condition[0].kind != sqlTokenWord && condition[0].kind != sqlTokenQuotedIdent && condition[0].text == condition[2].text
+		case len(condition) >= 4 && condition[len(condition)-3].value == "is" && condition[len(condition)-2].value == "not" && condition[len(condition)-1].value == "null":
+			for _, token := range condition[:len(condition)-3] {
+				if !isIdentifierToken(token) && token.text != "." {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+
+	for _, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+		if depth == 0 && token.kind == sqlTokenWord {
+			switch token.value {
+			case "order", "window", "for", "settings", "format", "offset":
+				return !trivial()
+			case "or":
+				// Any side of an OR may select every row, it is treated as a filter
+				return true
+			case "and":
+				if !trivial() {
+					return true
+				}
+				condition = nil
+				continue
+			}
+		}
+		if depth == 0 && token.text == ";" {
+			break
+		}
+		condition = append(condition, token)
+	}
+	return !trivial()
+}
+
+// LimitFullTableRead adds a LIMIT (or a MongoDB limit) to a query found by FindFullTableReads, so that only limit
+// rows are read
+func LimitFullTableRead(dbType, query string, limit int) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(query), ";")
+	trimmed = strings.TrimRight(trimmed, " \t\r\n")
+	if dbType == constants.DatabaseTypeMongoDB {
+		return fmt.Sprintf("%s.limit(%d)", trimmed, limit)
+	}
+	return fmt.Sprintf("%s LIMIT %d", trimmed, limit)
+}
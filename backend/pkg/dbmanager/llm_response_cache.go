@@ -0,0 +1,57 @@
+package dbmanager
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// llmResponseKeyPrefix prefixes the Redis keys of the cached LLM responses, the rest of the key is a hash of the request
+const llmResponseKeyPrefix = "llm_response:"
+
+// SetLLMResponseCacheTTL sets how long a generated LLM response is reused for an identical request, 0 disables the cache
+func (m *Manager) SetLLMResponseCacheTTL(ttl time.Duration) {
+	m.llmResponseCacheTTL = ttl
+}
+
+// LLMResponseCacheEnabled checks if the LLM responses are cached
+func (m *Manager) LLMResponseCacheEnabled() bool {
+	return m.llmResponseCacheTTL > 0 && m.redisRepo != nil
+}
+
+// GetCachedLLMResponse returns the response cached for a request key, the second value reports a hit. Responses are
+// encrypted like the schemas, they may hold schema details & data shared with the LLM.
+func (m *Manager) GetCachedLLMResponse(ctx context.Context, key string) (string, bool) {
+	if !m.LLMResponseCacheEnabled() || m.schemaManager == nil {
+		return "", false
+	}
+	encrypted, err := m.redisRepo.Get(llmResponseKeyPrefix+key, ctx)
+	if err != nil {
+		if !strings.Contains(err.Error(), "key does not exist") && !strings.Contains(err.Error(), "redis: nil") {
+			log.Printf("DBManager -> GetCachedLLMResponse -> Error reading the cached response: %v", err)
+		}
+		return "", false
+	}
+	response, err := m.schemaManager.storageService.encryption.Decrypt(string(encrypted))
+	if err != nil {
+		log.Printf("DBManager -> GetCachedLLMResponse -> Error decrypting the cached response: %v", err)
+		return "", false
+	}
+	return string(response), true
+}
+
+// CacheLLMResponse stores the response of a request key for the configured TTL, a failure only loses the cache entry
+func (m *Manager) CacheLLMResponse(ctx context.Context, key, response string) {
+	if !m.LLMResponseCacheEnabled() || m.schemaManager == nil {
+		return
+	}
+	encrypted, err := m.schemaManager.storageService.encryption.Encrypt([]byte(response))
+	if err != nil {
+		log.Printf("DBManager -> CacheLLMResponse -> Error encrypting the response: %v", err)
+		return
+	}
+	if err := m.redisRepo.Set(llmResponseKeyPrefix+key, []byte(encrypted), m.llmResponseCacheTTL, ctx); err != nil {
+		log.Printf("DBManager -> CacheLLMResponse -> Error caching the response: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	// Database drivers
@@ -34,13 +35,20 @@ type cleanupMetrics struct {
 
 // DatabasePool represents a shared database connection with reference counting
 type DatabasePool struct {
-	DB         *sql.DB
-	GORMDB     *gorm.DB
-	RefCount   int
-	Config     ConnectionConfig
-	LastUsed   time.Time
-	Mutex      sync.Mutex // For thread-safe reference counting
-	MongoDBObj interface{}
+	DB               *sql.DB
+	GORMDB           *gorm.DB
+	RefCount         int
+	Config           ConnectionConfig
+	LastUsed         time.Time
+	Mutex            sync.Mutex // For thread-safe reference counting
+	MongoDBObj       interface{}
+	BigQueryObj      interface{}
+	ElasticsearchObj interface{}
+	CassandraObj     interface{}
+	ServerInfo       *ServerInfo // Version & capabilities of the server, fetched once per pool
+	TLSInfo          *TLSInfo    // TLS negotiated by the pool's connections
+	Host             string      // host:port the pool is connected to
+	Tunnel           *sshTunnel  // SSH tunnel the pool's connections go through, closed with the pool
 }
 
 // Manager handles database connections
@@ -60,11 +68,19 @@ type Manager struct {
 	fetchersMu       sync.RWMutex
 	dbPools          map[string]*DatabasePool // key: hash of connection config
 	dbPoolsMu        sync.RWMutex
+	sessionModes     map[string]bool // chatID -> session mode enabled, kept across reconnects
+	sessionModesMu   sync.RWMutex
+	maxResultRows    int   // Rows of a query result read by ExecuteQuery, 0 reads everything
+	maxResultBytes   int64 // Size of a query result read by ExecuteQuery, 0 reads everything
+	paginationOrder  bool  // Paginated queries without an ORDER BY are ordered on the primary key, see OrderPaginatedQuery
 	poolMetrics      struct {
 		totalPools       int
 		totalConnections int
 		reuseCount       int
 	}
+
+	llmResponseCacheTTL time.Duration // Validity of the cached LLM responses, 0 disables the cache
+	queryResultCacheTTL time.Duration // Validity of the cached read query results, 0 disables the cache
 }
 
 // NewManager creates a new connection manager
@@ -85,6 +101,10 @@ func NewManager(redisRepo redis.IRedisRepositories, encryptionKey string) (*Mana
 		executionMu:      sync.RWMutex{},
 		fetchers:         make(map[string]FetcherFactory),
 		dbPools:          make(map[string]*DatabasePool),
+		sessionModes:     make(map[string]bool),
+		maxResultRows:    constants.DefaultMaxQueryResultRows,
+		maxResultBytes:   constants.DefaultMaxQueryResultBytes,
+		paginationOrder:  true,
 	}
 
 	// Set the DBManager in the SchemaManager
@@ -116,6 +136,11 @@ func NewManager(redisRepo redis.IRedisRepositories, encryptionKey string) (*Mana
 		return NewMySQLSchemaFetcher(db)
 	})
 
+	// MariaDB extends the MySQL schema fetcher with its sequences
+	m.RegisterFetcher("mariadb", func(db DBExecutor) SchemaFetcher {
+		return NewMariaDBSchemaFetcher(db)
+	})
+
 	// Add ClickHouse schema fetcher registration
 	m.RegisterFetcher("clickhouse", func(db DBExecutor) SchemaFetcher {
 		return NewClickHouseSchemaFetcher(db)
@@ -125,6 +150,22 @@ func NewManager(redisRepo redis.IRedisRepositories, encryptionKey string) (*Mana
 		return NewMongoDBSchemaFetcher(db)
 	})
 
+	m.RegisterFetcher("sqlite", func(db DBExecutor) SchemaFetcher {
+		return NewSQLiteSchemaFetcher(db)
+	})
+
+	m.RegisterFetcher("bigquery", func(db DBExecutor) SchemaFetcher {
+		return NewBigQuerySchemaFetcher(db)
+	})
+
+	m.RegisterFetcher("elasticsearch", func(db DBExecutor) SchemaFetcher {
+		return NewElasticsearchSchemaFetcher(db)
+	})
+
+	m.RegisterFetcher("cassandra", func(db DBExecutor) SchemaFetcher {
+		return NewCassandraSchemaFetcher(db)
+	})
+
 	m.registerDefaultDrivers()
 
 	return m, nil
@@ -140,12 +181,27 @@ func (m *Manager) registerDefaultDrivers() {
 	// Register MySQL driver
 	m.RegisterDriver("mysql", NewMySQLDriver())
 
+	// Register MariaDB driver (uses MySQL driver)
+	m.RegisterDriver("mariadb", NewMySQLDriver())
+
 	// Register ClickHouse driver
 	m.RegisterDriver("clickhouse", NewClickHouseDriver())
 
 	// Register MongoDB driver
 	m.RegisterDriver("mongodb", NewMongoDBDriver())
 
+	// Register SQLite driver, without a data directory until SQLITE_DATA_DIR is set
+	m.RegisterDriver("sqlite", NewSQLiteDriver(""))
+
+	// Register BigQuery driver
+	m.RegisterDriver("bigquery", NewBigQueryDriver())
+
+	// Register Elasticsearch driver
+	m.RegisterDriver("elasticsearch", NewElasticsearchDriver())
+
+	// Register Cassandra driver, ScyllaDB speaks the same protocol
+	m.RegisterDriver("cassandra", NewCassandraDriver())
+
 	// Register MongoDB schema fetcher
 	m.RegisterFetcher("mongodb", func(db DBExecutor) SchemaFetcher {
 		return NewMongoDBSchemaFetcher(db)
@@ -207,10 +263,22 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 	configKey := utils.GenerateConfigKey(map[string]interface{}{
 		"type":     config.Type,
 		"host":     config.Host,
+		"hosts":    config.Hosts,
 		"port":     config.Port,
 		"username": config.Username,
 		"password": config.Password,
 		"database": config.Database, // Add database to the key to differentiate connections to different databases
+		"role":     config.Role,     // Connections of different roles run queries with different permissions
+
+		// SQLite connections have no host, their file tells them apart
+		"file_path": config.FilePath,
+
+		// BigQuery connections have no host or username, their project & service account tell them apart
+		"project_id":      config.ProjectID,
+		"service_account": bigQueryServiceAccount(config.CredentialsJSON),
+
+		// The same host can be another database behind a bastion
+		"ssh_host": config.SSHHost,
 	})
 	log.Printf("DBManager -> Connect -> Generated config key: %s", configKey)
 
@@ -237,6 +305,13 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 		return fmt.Errorf("connection already exists for chat ID: %s", chatID)
 	}
 
+	// The primary of a multi-host connection can change, a pool connected to a former primary isn't reused
+	if poolExists && len(config.Hosts) > 0 && !isPrimaryServer(&Connection{DB: pool.GORMDB, Config: pool.Config}) {
+		log.Printf("DBManager -> Connect -> Pooled host %s is no longer the primary, resolving the primary again", pool.Host)
+		m.retirePool(configKey, pool)
+		poolExists = false
+	}
+
 	if poolExists {
 		// Use existing connection from pool
 		pool.Mutex.Lock()
@@ -259,16 +334,19 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 		log.Printf("DBManager -> Connect -> Connection config: %+v", config)
 		// Create a new connection using the shared pool
 		conn = &Connection{
-			DB:          pool.GORMDB,
-			LastUsed:    time.Now(),
-			Status:      StatusConnected,
-			Config:      config,
-			UserID:      userID,
-			ChatID:      chatID,
-			StreamID:    streamID,
-			Subscribers: make(map[string]bool),
-			SubLock:     sync.RWMutex{},
-			ConfigKey:   configKey, // Store the config key for reference
+			DB:            pool.GORMDB,
+			LastUsed:      time.Now(),
+			Status:        StatusConnected,
+			Config:        config,
+			UserID:        userID,
+			ChatID:        chatID,
+			StreamID:      streamID,
+			Subscribers:   make(map[string]bool),
+			SubLock:       sync.RWMutex{},
+			ConfigKey:     configKey, // Store the config key for reference
+			ServerInfo:    pool.ServerInfo,
+			TLSInfo:       pool.TLSInfo,
+			ConnectedHost: pool.Host,
 		}
 
 		// Set MongoDBObj for MongoDB connections when reusing from pool
@@ -277,25 +355,68 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 			log.Printf("DBManager -> Connect -> Set MongoDBObj from pool for MongoDB connection")
 		}
 
+		// Set BigQueryObj for BigQuery connections when reusing from pool
+		if config.Type == constants.DatabaseTypeBigQuery && pool.BigQueryObj != nil {
+			conn.BigQueryObj = pool.BigQueryObj
+		}
+
+		// Set ElasticsearchObj for Elasticsearch connections when reusing from pool
+		if config.Type == constants.DatabaseTypeElasticsearch && pool.ElasticsearchObj != nil {
+			conn.ElasticsearchObj = pool.ElasticsearchObj
+		}
+
+		// Set CassandraObj for Cassandra connections when reusing from pool
+		if config.Type == constants.DatabaseTypeCassandra && pool.CassandraObj != nil {
+			conn.CassandraObj = pool.CassandraObj
+		}
+
 		// Update metrics
 		m.poolMetrics.reuseCount++
 	} else {
+		// The driver of a connection behind a bastion connects to the local end of its SSH tunnel
+		var dialConfig ConnectionConfig
+		var tunnel *sshTunnel
+		dialConfig, tunnel, err = openSSHTunnel(config)
+		if err != nil {
+			log.Printf("DBManager -> Connect -> SSH tunnel failed: %v", err)
+			return err
+		}
+
 		// Create a new connection
-		conn, err = driver.Connect(config)
+		conn, err = connectWithFailover(driver, dialConfig)
 		if err != nil {
+			tunnel.Close()
 			log.Printf("DBManager -> Connect -> Driver connection failed: %v", err)
 			return err
 		}
 
-		log.Printf("DBManager -> Connect -> Connection Host, Name, Type: %+v, %+v, %+v", config.Host, config.Database, config.Type)
+		conn.ConnectedHost = candidateAddress(conn.Config)
+		if tunnel != nil {
+			conn.ConnectedHost = tunnel.target
+		}
+		log.Printf("DBManager -> Connect -> Connection Host, Name, Type: %+v, %+v, %+v", conn.ConnectedHost, config.Database, config.Type)
 		log.Printf("DBManager -> Connect -> Driver connection successful, creating new pool")
+		conn.ServerInfo = fetchServerInfo(conn)
+		if conn.ServerInfo != nil {
+			log.Printf("DBManager -> Connect -> Server version: %s, capabilities: %v", conn.ServerInfo.Version, conn.ServerInfo.Capabilities)
+		}
+		var sqlDB *sql.DB
+		if conn.DB != nil {
+			sqlDB, _ = conn.DB.DB()
+		}
+		conn.TLSInfo = fetchTLSInfo(&conn.Config, sqlDB)
+
 		// Create and store the new pool
 		newPool := &DatabasePool{
-			DB:       nil, // The driver doesn't expose sql.DB directly
-			GORMDB:   conn.DB,
-			RefCount: 1,
-			Config:   config,
-			LastUsed: time.Now(),
+			DB:         nil, // The driver doesn't expose sql.DB directly
+			GORMDB:     conn.DB,
+			RefCount:   1,
+			Config:     config,
+			LastUsed:   time.Now(),
+			ServerInfo: conn.ServerInfo,
+			TLSInfo:    conn.TLSInfo,
+			Host:       conn.ConnectedHost,
+			Tunnel:     tunnel,
 		}
 
 		// For MongoDB, store the MongoDB client in the pool
@@ -303,6 +424,21 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 			newPool.MongoDBObj = conn.MongoDBObj
 		}
 
+		// For BigQuery, store the BigQuery client in the pool
+		if config.Type == constants.DatabaseTypeBigQuery {
+			newPool.BigQueryObj = conn.BigQueryObj
+		}
+
+		// For Elasticsearch, store the Elasticsearch client in the pool
+		if config.Type == constants.DatabaseTypeElasticsearch {
+			newPool.ElasticsearchObj = conn.ElasticsearchObj
+		}
+
+		// For Cassandra, store the gocql session in the pool
+		if config.Type == constants.DatabaseTypeCassandra {
+			newPool.CassandraObj = conn.CassandraObj
+		}
+
 		m.dbPoolsMu.Lock()
 		m.dbPools[configKey] = newPool
 		m.dbPoolsMu.Unlock()
@@ -337,7 +473,7 @@ func (m *Manager) Connect(chatID, userID, streamID string, config ConnectionConf
 
 	// Notify subscribers in a separate goroutine
 	go func() {
-		m.notifySubscribers(chatID, userID, StatusConnected, "")
+		m.notifySubscribers(chatID, userID, StatusConnected, map[string]interface{}{"host": conn.ConnectedHost})
 		log.Printf("DBManager -> Connect -> Notified subscribers")
 	}()
 
@@ -383,6 +519,9 @@ func (m *Manager) Disconnect(chatID, userID string, deleteSchema bool) error {
 
 	log.Printf("DBManager -> Disconnect -> Starting disconnect for chatID: %s", chatID)
 
+	// Session state must not outlive the connection
+	m.closeSession(conn)
+
 	// Get the config key for the shared pool
 	configKey := conn.ConfigKey
 
@@ -409,6 +548,8 @@ func (m *Manager) Disconnect(chatID, userID string, deleteSchema bool) error {
 				}
 			}
 
+			pool.Tunnel.Close()
+
 			// Remove from pool
 			delete(m.dbPools, configKey)
 			log.Printf("DBManager -> Disconnect -> Removed pool from dbPools map")
@@ -475,10 +616,33 @@ func (m *Manager) GetConnection(chatID string) (DBExecutor, error) {
 	switch conn.Config.Type {
 	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
 		return NewPostgresWrapper(conn.DB, m, chatID), nil
-	case constants.DatabaseTypeMySQL:
-		return NewMySQLWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return NewMySQLWrapper(conn.DB, m, chatID, conn.Config.Type), nil
 	case constants.DatabaseTypeClickhouse:
 		return NewClickHouseWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeSQLite:
+		return NewSQLiteWrapper(conn.DB, m, chatID), nil
+	case constants.DatabaseTypeBigQuery:
+		// Like MongoDB, BigQuery has no *gorm.DB, its client is in the BigQueryObj field
+		executor, err := NewBigQueryExecutor(conn, m, chatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create BigQuery executor: %v", err)
+		}
+		return executor, nil
+	case constants.DatabaseTypeElasticsearch:
+		// Elasticsearch has no *gorm.DB either, its client is in the ElasticsearchObj field
+		executor, err := NewElasticsearchExecutor(conn, m, chatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Elasticsearch executor: %v", err)
+		}
+		return executor, nil
+	case constants.DatabaseTypeCassandra:
+		// Cassandra has no *gorm.DB either, its session is in the CassandraObj field
+		executor, err := NewCassandraExecutor(conn, m, chatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cassandra executor: %v", err)
+		}
+		return executor, nil
 	case constants.DatabaseTypeMongoDB:
 		// For MongoDB, we use the MongoDBObj field instead of DB
 		_, ok := conn.MongoDBObj.(*MongoDBWrapper)
@@ -495,6 +659,27 @@ func (m *Manager) GetConnection(chatID string) (DBExecutor, error) {
 	}
 }
 
+// HoldConnection keeps the connection of a chat open until the returned function is called, the cleanup routine
+// doesn't remove it however long it is idle. A held connection isn't checked again by its holder, false is returned
+// if the chat isn't connected.
+func (m *Manager) HoldConnection(chatID string) (func(), bool) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+
+	atomic.AddInt32(&conn.holds, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			conn.LastUsed = time.Now()
+			atomic.AddInt32(&conn.holds, -1)
+		})
+	}, true
+}
+
 // startCleanupRoutine periodically checks for and closes inactive connections
 func (m *Manager) startCleanupRoutine() {
 	ticker := time.NewTicker(cleanupInterval)
@@ -519,18 +704,25 @@ func (m *Manager) cleanup() {
 	m.cleanupMetrics.lastRun = now
 
 	// Cleanup connections
+	var removed []*Connection
 	m.mu.Lock()
 	for chatID, conn := range m.connections {
-		if time.Since(conn.LastUsed) > idleTimeout {
+		if time.Since(conn.LastUsed) > idleTimeout && atomic.LoadInt32(&conn.holds) == 0 {
 			log.Printf("DBManager -> cleanup -> Removing idle connection for chatID: %s (idle for %v)", chatID, time.Since(conn.LastUsed))
 
-			// Don't actually disconnect here, just remove from the map
+			// Don't actually disconnect here, just remove from the map, a held session is released though
+			removed = append(removed, conn)
 			delete(m.connections, chatID)
 			m.cleanupMetrics.connectionsRemoved++
 		}
 	}
 	m.mu.Unlock()
 
+	// Outside of m.mu, closing a session waits for its running query
+	for _, conn := range removed {
+		m.closeSession(conn)
+	}
+
 	// Cleanup database pools
 	m.dbPoolsMu.Lock()
 	for key, pool := range m.dbPools {
@@ -545,6 +737,7 @@ func (m *Manager) cleanup() {
 					sqlDB.Close()
 				}
 			}
+			pool.Tunnel.Close()
 			delete(m.dbPools, key)
 		}
 		pool.Mutex.Unlock()
@@ -571,9 +764,28 @@ func (m *Manager) Stop() error {
 	close(m.stopCleanup)
 	log.Println("DBManager -> Stop -> Signaled cleanup routine to stop")
 
-	// Close all connections
+	// Cancel any active executions
+	m.executionMu.Lock()
+	for streamID, execution := range m.activeExecutions {
+		execution.CancelFunc()
+		if execution.Tx != nil {
+			if err := execution.Tx.Rollback(); err != nil {
+				log.Printf("DBManager -> Stop -> Error rolling back transaction for stream %s: %v", streamID, err)
+			}
+		}
+		log.Printf("DBManager -> Stop -> Cancelled execution for stream %s", streamID)
+	}
+	m.activeExecutions = make(map[string]*QueryExecution)
+	m.executionMu.Unlock()
+	log.Println("DBManager -> Stop -> Cancelled all active executions")
+
+	// Close all connections, outside of m.mu since closing a session waits for its running query
 	m.mu.Lock()
-	for chatID, conn := range m.connections {
+	connections := m.connections
+	m.connections = make(map[string]*Connection)
+	m.mu.Unlock()
+	for chatID, conn := range connections {
+		m.closeSession(conn)
 		if driver, exists := m.drivers[conn.Config.Type]; exists {
 			if err := driver.Disconnect(conn); err != nil {
 				log.Printf("DBManager -> Stop -> Error disconnecting chat %s: %v", chatID, err)
@@ -582,8 +794,6 @@ func (m *Manager) Stop() error {
 			}
 		}
 	}
-	m.connections = make(map[string]*Connection)
-	m.mu.Unlock()
 	log.Println("DBManager -> Stop -> Closed all connections")
 
 	// Close all database pools
@@ -596,26 +806,12 @@ func (m *Manager) Stop() error {
 				log.Printf("DBManager -> Stop -> Closed pool: %s", key)
 			}
 		}
+		pool.Tunnel.Close()
 		delete(m.dbPools, key)
 	}
 	m.dbPoolsMu.Unlock()
 	log.Println("DBManager -> Stop -> Closed all connection pools")
 
-	// Cancel any active executions
-	m.executionMu.Lock()
-	for streamID, execution := range m.activeExecutions {
-		execution.CancelFunc()
-		if execution.Tx != nil {
-			if err := execution.Tx.Rollback(); err != nil {
-				log.Printf("DBManager -> Stop -> Error rolling back transaction for stream %s: %v", streamID, err)
-			}
-		}
-		log.Printf("DBManager -> Stop -> Cancelled execution for stream %s", streamID)
-	}
-	m.activeExecutions = make(map[string]*QueryExecution)
-	m.executionMu.Unlock()
-	log.Println("DBManager -> Stop -> Cancelled all active executions")
-
 	log.Println("DBManager -> Stop -> Manager stopped successfully")
 	return nil
 }
@@ -699,8 +895,9 @@ func (m *Manager) GetEventChannel() <-chan SSEEvent {
 	return m.eventChan
 }
 
-// Notify subscribers of connection status change
-func (m *Manager) notifySubscribers(chatID, userID string, status ConnectionStatus, err string) {
+// notifySubscribers sends a status event to the streams of a chat, data is the error of a failure or the details
+// of the status, ex: the host a connection is connected to
+func (m *Manager) notifySubscribers(chatID, userID string, status ConnectionStatus, data interface{}) {
 	log.Printf("DBManager -> notifySubscribers -> Notifying subscribers for chatID: %s", chatID)
 
 	// Get connection and subscribers under read lock
@@ -727,7 +924,7 @@ func (m *Manager) notifySubscribers(chatID, userID string, status ConnectionStat
 	for streamID := range subscribers {
 		response := dtos.StreamResponse{
 			Event: string(status),
-			Data:  err,
+			Data:  data,
 		}
 
 		if m.streamHandler != nil {
@@ -875,7 +1072,10 @@ func (m *Manager) GetConnectionInfo(chatID string) (*ConnectionInfo, bool) {
 
 	// Convert Connection to ConnectionInfo
 	connInfo := &ConnectionInfo{
-		Config: conn.Config,
+		Config:        conn.Config,
+		ServerInfo:    conn.ServerInfo,
+		TLSInfo:       conn.TLSInfo,
+		ConnectedHost: conn.ConnectedHost,
 	}
 
 	// Get the underlying *sql.DB from gorm.DB
@@ -910,6 +1110,21 @@ func (m *Manager) IsConnected(chatID string) bool {
 		return false
 	}
 
+	// For BigQuery connections
+	if conn.Config.Type == constants.DatabaseTypeBigQuery {
+		return (&BigQueryDriver{}).IsAlive(conn)
+	}
+
+	// For Elasticsearch connections
+	if conn.Config.Type == constants.DatabaseTypeElasticsearch {
+		return (&ElasticsearchDriver{}).IsAlive(conn)
+	}
+
+	// For Cassandra connections
+	if conn.Config.Type == constants.DatabaseTypeCassandra {
+		return (&CassandraDriver{}).IsAlive(conn)
+	}
+
 	// For SQL connections
 	if conn.DB != nil {
 		sqlDB, err := conn.DB.DB()
@@ -928,8 +1143,11 @@ func (m *Manager) IsConnected(chatID string) bool {
 }
 
 type ConnectionInfo struct {
-	DB     *sql.DB
-	Config ConnectionConfig
+	DB            *sql.DB
+	Config        ConnectionConfig
+	ServerInfo    *ServerInfo
+	TLSInfo       *TLSInfo
+	ConnectedHost string // host:port the connection is connected to
 }
 
 // SetStreamHandler sets the stream handler for database events
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -28,23 +27,34 @@ import (
 )
 
 type QueryExecution struct {
-	QueryID     string
-	MessageID   string
-	StartTime   time.Time
-	IsExecuting bool
-	IsRollback  bool
-	Tx          Transaction // Changed from *sql.Tx to Transaction
-	CancelFunc  context.CancelFunc
+	QueryID       string
+	MessageID     string
+	StartTime     time.Time
+	IsExecuting   bool
+	IsRollback    bool
+	IsMutation    bool        // The query changes data
+	Interruptible bool        // Rolling back the transaction undoes everything the query did, see rollbackUndoesQuery
+	Tx            Transaction // Changed from *sql.Tx to Transaction
+	CancelFunc    context.CancelFunc
 }
 
-func (m *Manager) CancelQueryExecution(streamID string) {
+// CancelQueryExecution stops the query running for a stream, its transaction is rolled back. A mutation whose changes
+// a rollback can't undo (ex: ClickHouse, MySQL DDL) isn't interrupted halfway, it is left to finish & false is returned.
+func (m *Manager) CancelQueryExecution(streamID string) bool {
 	m.executionMu.Lock()
 	defer m.executionMu.Unlock()
 
 	if execution, exists := m.activeExecutions[streamID]; exists {
+		if execution.IsMutation && !execution.Interruptible {
+			log.Printf("Not cancelling query execution for streamID: %s, the mutation can't be rolled back, it is left to finish", streamID)
+			return false
+		}
 		log.Printf("Cancelling query execution for streamID: %s", streamID)
 
-		// Cancel the context first
+		// Cancel the statement on the server first, cancelling the context alone may leave it running there
+		if execution.Tx != nil {
+			cancelOnServer(execution.Tx)
+		}
 		execution.CancelFunc()
 
 		// Rollback transaction if it exists
@@ -57,23 +67,45 @@ func (m *Manager) CancelQueryExecution(streamID string) {
 		delete(m.activeExecutions, streamID)
 		log.Printf("Query execution cancelled for streamID: %s", streamID)
 	}
+	return true
 }
 
 // ExecuteQuery executes a query and returns the result, synchronous, no SSE events are sent, findCount is used to strictly get the number/count of records that the query returns
 func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID, streamID string, query string, queryType string, isRollback bool, findCount bool) (*QueryExecutionResult, *dtos.QueryError) {
+	// The query runs on the named connection of the chat set on the context, if any
+	chatID = connectionKey(ctx, chatID)
+	m.mu.RLock()
+	dbType := ""
+	if conn, exists := m.connections[chatID]; exists {
+		dbType = conn.Config.Type
+	}
+	m.mu.RUnlock()
+	isMutation := isMutationQueryType(queryType)
+	interruptible := !isMutation || rollbackUndoesQuery(dbType, queryType)
+
 	m.executionMu.Lock()
 
-	// Create cancellable context with timeout
-	execCtx, cancel := context.WithTimeout(ctx, 1*time.Minute) // 1 minute timeout
+	// A mutation that can't be rolled back must not be interrupted halfway when the caller gives up, only the timeout
+	// still applies to it
+	parentCtx := ctx
+	if !interruptible {
+		parentCtx = context.WithoutCancel(ctx)
+	}
+	// Create cancellable context with the timeout of the query, the drivers stop reading large results at the row limit
+	// & fail on results passing the byte limit
+	timeout := queryTimeout(ctx)
+	execCtx, cancel := context.WithTimeout(withResultByteLimit(withResultRowLimit(parentCtx, m.maxResultRows), m.maxResultBytes), timeout)
 
 	// Track execution
 	execution := &QueryExecution{
-		QueryID:     queryID,
-		MessageID:   messageID,
-		StartTime:   time.Now(),
-		IsExecuting: true,
-		IsRollback:  isRollback,
-		CancelFunc:  cancel,
+		QueryID:       queryID,
+		MessageID:     messageID,
+		StartTime:     time.Now(),
+		IsExecuting:   true,
+		IsRollback:    isRollback,
+		IsMutation:    isMutation,
+		Interruptible: interruptible,
+		CancelFunc:    cancel,
 	}
 	m.activeExecutions[streamID] = execution
 	m.executionMu.Unlock()
@@ -87,7 +119,9 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 	}()
 
 	// Get connection and driver
+	m.mu.RLock()
 	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
 	if !exists {
 		return nil, &dtos.QueryError{
 			Code:    "NO_CONNECTION_FOUND",
@@ -105,9 +139,107 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 		}
 	}
 
+	// Only read queries can run in the past, the engine keeps the previous versions of rows but can't write them
+	asOf, hasAsOf := readAsOf(ctx)
+	if hasAsOf {
+		if isMutation {
+			return nil, &dtos.QueryError{
+				Code:    "READ_AS_OF_NOT_ALLOWED",
+				Message: "only read queries can be executed at a point in time",
+				Details: fmt.Sprintf("A %s query changes the data, it can't be executed at a point in time", queryType),
+			}
+		}
+		if !ReadAsOfSupported(conn.Config.Type) {
+			return nil, &dtos.QueryError{
+				Code:    "READ_AS_OF_NOT_SUPPORTED",
+				Message: "reading the data at a point in time is not supported",
+				Details: fmt.Sprintf("%s can't read the data at a point in time", conn.Config.Type),
+			}
+		}
+	}
+
+	// A chat assuming a role runs its queries with the permissions of the role only
+	if conn.Config.Role != "" && ChangesSessionRole(query) {
+		return nil, &dtos.QueryError{
+			Code:    "SESSION_ROLE_CHANGE_NOT_ALLOWED",
+			Message: "the query changes the role of the session",
+			Details: fmt.Sprintf("The chat runs its queries as the role %s, a query can't change or reset the role nor run a DO block or a dynamic EXECUTE", conn.Config.Role),
+		}
+	}
+
+	// The database stops at the row limit of the connection, one more row is fetched to tell if the result was truncated
+	executedQuery := query
+	if limit := conn.Config.MaxResultRows; limit > 0 && !isRollback && !findCount {
+		executedQuery = limitQueryRows(conn.Config.Type, query, limit+1)
+		execCtx = withResultRowLimit(execCtx, limit)
+	}
+
+	// Fanned out read queries run on every shard of the connection, outside of the session
+	if shardFanOut(ctx) {
+		if isMutation {
+			return nil, &dtos.QueryError{
+				Code:    "SHARD_FAN_OUT_NOT_ALLOWED",
+				Message: "only read queries can be executed on every shard",
+				Details: fmt.Sprintf("A %s query changes the data, it can't be executed on every shard", queryType),
+			}
+		}
+		if !ShardFanOutSupported(conn.Config.Type) || len(conn.Config.Shards) == 0 {
+			return nil, &dtos.QueryError{
+				Code:    "SHARD_FAN_OUT_NOT_SUPPORTED",
+				Message: "the connection has no shards to execute the query on",
+				Details: fmt.Sprintf("Add the shards of the %s connection to execute queries on every shard", conn.Config.Type),
+			}
+		}
+		result, queryErr := m.executeOnShards(execCtx, conn, driver, executedQuery, queryType, findCount)
+		if queryErr != nil && execCtx.Err() == context.DeadlineExceeded {
+			return nil, queryTimeoutError(timeout, "Query execution timed out on the shards")
+		}
+		return result, queryErr
+	}
+
+	// Find unqualified columns of JOINs, the query is executed as written (it may have been confirmed by the user), the
+	// ambiguity & the suggested qualified query are added to the error details for the LLM or to the warnings
+	var ambiguityDetails string
+	if !isRollback && isSQLDatabaseType(conn.Config.Type) && m.schemaManager != nil {
+		if schema := m.schemaManager.getKnownSchema(ctx, chatID); schema != nil {
+			if suggestedQuery, ambiguous := FindAmbiguousColumns(query, schema); len(ambiguous) > 0 {
+				log.Printf("Manager -> ExecuteQuery -> Ambiguous columns found: %+v", ambiguous)
+				ambiguityDetails = FormatAmbiguousColumns(ambiguous, suggestedQuery)
+			}
+		}
+	}
+
+	// Session mode, queries of the chat share a dedicated connection & run one at a time on it
+	var session *DBSession
+	releaseSession := func() {}
+	if m.isSessionMode(chatID) && SessionSupported(conn.Config.Type) {
+		var err error
+		session, err = m.OpenSession(execCtx, chatID)
+		if err != nil {
+			return nil, &dtos.QueryError{
+				Code:    "FAILED_TO_OPEN_SESSION",
+				Message: "failed to open session",
+				Details: err.Error(),
+			}
+		}
+		session.mu.Lock()
+		if session.closed {
+			// Closed while waiting for the previous query, its temp tables & variables are gone
+			session.mu.Unlock()
+			return nil, &dtos.QueryError{
+				Code:    "SESSION_CLOSED",
+				Message: "session closed",
+				Details: "The session was closed while the query was waiting for it, run the query again to start a new session",
+			}
+		}
+		releaseSession = session.mu.Unlock
+	}
+	// Replaced when the query is cancelled while still running, the session is then released once the query returns
+	defer func() { releaseSession() }()
+
 	log.Printf("Manager -> ExecuteQuery -> Driver: %v", driver)
 	// Begin transaction
-	tx := driver.BeginTx(execCtx, conn)
+	tx := driver.BeginTx(execCtx, conn, session)
 	if tx == nil {
 		return nil, &dtos.QueryError{
 			Code:    "FAILED_TO_START_TRANSACTION",
@@ -128,6 +260,19 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 
 	execution.Tx = tx
 
+	if hasAsOf {
+		if err := setTransactionReadTime(execCtx, tx, asOf); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Printf("Error rolling back transaction: %v", rollbackErr)
+			}
+			return nil, &dtos.QueryError{
+				Code:    "FAILED_TO_SET_READ_TIME",
+				Message: "failed to read the data at " + asOf.UTC().Format(time.RFC3339),
+				Details: err.Error(),
+			}
+		}
+	}
+
 	// Execute query with proper cancellation handling
 	var result *QueryExecutionResult
 	done := make(chan struct{})
@@ -135,8 +280,23 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 
 	go func() {
 		defer close(done)
-		log.Printf("Manager -> ExecuteQuery -> Executing query: %v", query)
-		result = tx.ExecuteQuery(execCtx, conn, query, queryType, findCount)
+		log.Printf("Manager -> ExecuteQuery -> Executing query: %v", executedQuery)
+		result = tx.ExecuteQuery(execCtx, conn, executedQuery, queryType, findCount)
+		// The rows read are dropped, decoding the result would risk running out of memory
+		if resultSizeOf(execCtx).tooLarge(result) {
+			log.Printf("Manager -> ExecuteQuery -> Result larger than %d bytes", m.maxResultBytes)
+			result = &QueryExecutionResult{
+				ExecutionTime: result.ExecutionTime,
+				Error:         resultTooLargeError(m.maxResultBytes),
+			}
+		}
+		if ambiguityDetails != "" {
+			if result.Error != nil {
+				result.Error.Details = strings.TrimSpace(result.Error.Details + "\n" + ambiguityDetails)
+			} else {
+				result.Warnings = append([]string{ambiguityDetails}, result.Warnings...)
+			}
+		}
 		// log.Printf("Manager -> ExecuteQuery -> Result: %v", result)
 		if result.Error != nil {
 			queryErr = result.Error
@@ -145,15 +305,22 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 
 	select {
 	case <-execCtx.Done():
+		// Timed out statements are cancelled on the server too (a no-op once cancelled by CancelQueryExecution)
+		cancelOnServer(tx)
 		if err := tx.Rollback(); err != nil {
 			log.Printf("Error rolling back transaction: %v", err)
 		}
+		if session != nil {
+			// The query goroutine may still be using the session connection, the next query must not run on it concurrently
+			unlock := releaseSession
+			releaseSession = func() {}
+			go func() {
+				<-done
+				unlock()
+			}()
+		}
 		if execCtx.Err() == context.DeadlineExceeded {
-			return nil, &dtos.QueryError{
-				Code:    "QUERY_EXECUTION_TIMED_OUT",
-				Message: "query execution timed out",
-				Details: "Query execution timed out",
-			}
+			return nil, queryTimeoutError(timeout, "Query execution timed out")
 		}
 		return nil, &dtos.QueryError{
 			Code:    "QUERY_EXECUTION_CANCELLED",
@@ -178,6 +345,11 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 		log.Println("Manager -> ExecuteQuery -> Commit completed:")
 		log.Printf("Manager -> ExecuteQuery -> Query type: %v", queryType)
 
+		// The data of the chat may have changed, its cached read results are stale
+		if isMutation || isRollback {
+			m.InvalidateQueryResultCache(context.WithoutCancel(ctx), chatID)
+		}
+
 		go func() {
 			log.Println("Manager -> ExecuteQuery -> Checking if schema trigger is needed")
 			time.Sleep(2 * time.Second)
@@ -188,13 +360,13 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 						conn.OnSchemaChange(conn.ChatID)
 					}
 				}
-			case constants.DatabaseTypeMySQL:
+			case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
 				if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
 					if conn.OnSchemaChange != nil {
 						conn.OnSchemaChange(conn.ChatID)
 					}
 				}
-			case constants.DatabaseTypeClickhouse:
+			case constants.DatabaseTypeClickhouse, constants.DatabaseTypeSQLite, constants.DatabaseTypeBigQuery:
 				if queryType == "DDL" || queryType == "ALTER" || queryType == "DROP" {
 					if conn.OnSchemaChange != nil {
 						conn.OnSchemaChange(conn.ChatID)
@@ -209,13 +381,23 @@ func (m *Manager) ExecuteQuery(ctx context.Context, chatID, messageID, queryID,
 			}
 		}()
 
+		m.resolveResultColumns(ctx, chatID, conn.Config.Type, query, result)
 		return result, nil
 	}
 }
 
-// TestConnection tests if the provided credentials are valid without creating a persistent connection
-func (m *Manager) TestConnection(config *ConnectionConfig) error {
+// TestConnection tests if the provided credentials are valid without creating a persistent connection, the TLS
+// details negotiated by the test connection are returned
+func (m *Manager) TestConnection(config *ConnectionConfig) (*TLSInfo, error) {
+	if config.SSHHost != "" {
+		return m.testConnectionThroughSSH(config)
+	}
+	if len(config.Hosts) > 0 {
+		return m.testConnectionFailover(config)
+	}
+
 	var tempFiles []string
+	var tlsInfo *TLSInfo
 
 	switch config.Type {
 	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
@@ -231,12 +413,12 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		// Base connection parameters
 		baseParams := fmt.Sprintf(
 			"host=%s port=%s user=%s dbname=%s",
-			config.Host, port, *config.Username, config.Database,
+			config.Host, port, postgresDSNValue(*config.Username), postgresDSNValue(config.Database),
 		)
 
 		// Add password if provided
 		if config.Password != nil {
-			baseParams += fmt.Sprintf(" password=%s", *config.Password)
+			baseParams += " password=" + postgresDSNValue(*config.Password)
 		}
 
 		// Configure SSL/TLS
@@ -251,7 +433,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			// Fetch certificates from URLs
 			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// Track temporary files for cleanup
@@ -275,18 +457,21 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		dsn = baseParams
 
-		// Open connection
-		db, err := sql.Open("postgres", dsn)
+		// Open connection, assuming the role of the chat if any
+		db, err := openPostgres(dsn, config.Role)
 		if err != nil {
 			// Clean up temporary files
 			for _, file := range tempFiles {
 				os.Remove(file)
 			}
-			return fmt.Errorf("failed to create connection: %v", err)
+			return nil, fmt.Errorf("failed to create connection: %v", err)
 		}
 
 		// Test connection
 		err = db.Ping()
+		if err == nil {
+			tlsInfo = fetchTLSInfo(config, db)
+		}
 
 		// Close connection
 		db.Close()
@@ -297,12 +482,12 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		return nil
+		return tlsInfo, nil
 
-	case constants.DatabaseTypeMySQL:
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
 		var dsn string
 		port := "3306" // Default port for MySQL
 
@@ -310,7 +495,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			port = *config.Port
 		}
 
-		// Base connection parameters
+		// Base connection parameters, credentials aren't URL encoded (see MySQLDriver.Connect)
 		if config.Password != nil {
 			dsn = fmt.Sprintf(
 				"%s:%s@tcp(%s:%s)/%s",
@@ -334,7 +519,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			// Fetch certificates from URLs
 			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// Track temporary files for cleanup
@@ -354,7 +539,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to load client certificates: %v", err)
+					return nil, fmt.Errorf("failed to load client certificates: %v", err)
 				}
 				tlsConfig.Certificates = []tls.Certificate{cert}
 			}
@@ -368,14 +553,14 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to read CA certificate: %v", err)
+					return nil, fmt.Errorf("failed to read CA certificate: %v", err)
 				}
 				if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
 					// Clean up temporary files
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to append CA certificate")
+					return nil, fmt.Errorf("failed to append CA certificate")
 				}
 				tlsConfig.RootCAs = rootCertPool
 			}
@@ -394,11 +579,14 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			for _, file := range tempFiles {
 				os.Remove(file)
 			}
-			return fmt.Errorf("failed to create connection: %v", err)
+			return nil, fmt.Errorf("failed to create connection: %v", err)
 		}
 
 		// Test connection
 		err = db.Ping()
+		if err == nil {
+			tlsInfo = fetchTLSInfo(config, db)
+		}
 
 		// Close connection
 		db.Close()
@@ -409,10 +597,10 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		if err != nil {
-			return fmt.Errorf("failed to connect to database: %v", err)
+			return nil, fmt.Errorf("failed to connect to database: %v", err)
 		}
 
-		return nil
+		return tlsInfo, nil
 
 	case constants.DatabaseTypeClickhouse:
 		var dsn string
@@ -430,7 +618,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			// Fetch certificates from URLs
 			_, _, _, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// Track temporary files for cleanup
@@ -441,13 +629,8 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		// Build DSN
-		if config.Password != nil {
-			dsn = fmt.Sprintf("%s://%s:%s@%s:%s/%s",
-				protocol, *config.Username, *config.Password, config.Host, port, config.Database)
-		} else {
-			dsn = fmt.Sprintf("%s://%s@%s:%s/%s",
-				protocol, *config.Username, config.Host, port, config.Database)
-		}
+		dsn = fmt.Sprintf("%s://%s@%s:%s/%s",
+			protocol, connectionURLUserInfo(*config.Username, config.Password), config.Host, port, config.Database)
 
 		// Add parameters
 		dsn += "?dial_timeout=10s&read_timeout=20s"
@@ -464,11 +647,14 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 			for _, file := range tempFiles {
 				os.Remove(file)
 			}
-			return fmt.Errorf("failed to create connection: %v", err)
+			return nil, fmt.Errorf("failed to create connection: %v", err)
 		}
 
 		// Test connection
 		err = db.Ping()
+		if err == nil {
+			tlsInfo = fetchTLSInfo(config, db)
+		}
 
 		// Close connection
 		db.Close()
@@ -479,10 +665,10 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		if err != nil {
-			return fmt.Errorf("failed to connect to database: %v", err)
+			return nil, fmt.Errorf("failed to connect to database: %v", err)
 		}
 
-		return nil
+		return tlsInfo, nil
 
 	case constants.DatabaseTypeMongoDB:
 		var port string
@@ -528,17 +714,16 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		// Base connection parameters with authentication
 		if config.Username != nil && *config.Username != "" {
 			// URL encode username and password to handle special characters
-			encodedUsername := url.QueryEscape(*config.Username)
-			encodedPassword := url.QueryEscape(*config.Password)
+			userInfo := connectionURLUserInfo(*config.Username, config.Password)
 
 			if isSRV {
 				// For SRV records, don't include port
-				uri = fmt.Sprintf("%s://%s:%s@%s/%s",
-					protocol, encodedUsername, encodedPassword, config.Host, config.Database)
+				uri = fmt.Sprintf("%s://%s@%s/%s",
+					protocol, userInfo, config.Host, config.Database)
 			} else {
 				// Include port for standard connections
-				uri = fmt.Sprintf("%s://%s:%s@%s:%s/%s",
-					protocol, encodedUsername, encodedPassword, config.Host, port, config.Database)
+				uri = fmt.Sprintf("%s://%s@%s:%s/%s",
+					protocol, userInfo, config.Host, port, config.Database)
 			}
 		} else {
 			// Without authentication
@@ -552,11 +737,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 		}
 
 		// Log the final URI (with sensitive parts masked)
-		maskedUri := uri
-		if config.Password != nil && *config.Password != "" {
-			maskedUri = strings.Replace(maskedUri, *config.Password, "********", -1)
-		}
-		log.Printf("DBManager -> TestConnection -> Connection URI: %s", maskedUri)
+		log.Printf("DBManager -> TestConnection -> Connection URI: %s", maskConnectionURL(uri))
 
 		// Add connection options
 		if isSRV {
@@ -565,13 +746,16 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		// Configure client options
 		clientOptions := options.Client().ApplyURI(uri)
+		if config.sshTunneled {
+			clientOptions.SetDirect(true)
+		}
 
 		// Configure SSL/TLS
 		if config.UseSSL {
 			// Fetch certificates from URLs
 			certPath, keyPath, rootCertPath, certTempFiles, err := utils.PrepareCertificatesFromURLs(*config.SSLCertURL, *config.SSLKeyURL, *config.SSLRootCertURL)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			// Track temporary files for cleanup
@@ -613,7 +797,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to load client certificates: %v", err)
+					return nil, fmt.Errorf("failed to load client certificates: %v", err)
 				}
 				tlsConfig.Certificates = []tls.Certificate{cert}
 			}
@@ -626,7 +810,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to read root CA: %v", err)
+					return nil, fmt.Errorf("failed to read root CA: %v", err)
 				}
 
 				rootCertPool := x509.NewCertPool()
@@ -635,7 +819,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 					for _, file := range tempFiles {
 						os.Remove(file)
 					}
-					return fmt.Errorf("failed to parse root CA certificate")
+					return nil, fmt.Errorf("failed to parse root CA certificate")
 				}
 
 				tlsConfig.RootCAs = rootCertPool
@@ -656,7 +840,7 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 				os.Remove(file)
 			}
 			log.Printf("DBManager -> TestConnection -> Error connecting to MongoDB: %v", err)
-			return fmt.Errorf("failed to connect to MongoDB: %v", err)
+			return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 		}
 
 		// Ping the database to verify connection
@@ -672,14 +856,68 @@ func (m *Manager) TestConnection(config *ConnectionConfig) error {
 
 		if err != nil {
 			log.Printf("DBManager -> TestConnection -> Error pinging MongoDB: %v", err)
-			return fmt.Errorf("failed to ping MongoDB: %v", err)
+			return nil, fmt.Errorf("failed to ping MongoDB: %v", err)
 		}
 
 		log.Printf("DBManager -> TestConnection -> Successfully connected to MongoDB")
-		return nil
+		return fetchTLSInfo(config, nil), nil
+
+	case constants.DatabaseTypeSQLite:
+		// The driver opens the file, it knows the data directory the file must be in
+		driver, exists := m.drivers[config.Type]
+		if !exists {
+			return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+		}
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %v", err)
+		}
+		driver.Disconnect(conn)
+
+		// A local file has no TLS
+		return nil, nil
+
+	case constants.DatabaseTypeBigQuery:
+		// The driver checks that the service account can read the dataset
+		driver, exists := m.drivers[config.Type]
+		if !exists {
+			return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+		}
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to BigQuery: %v", err)
+		}
+		driver.Disconnect(conn)
+		return fetchTLSInfo(config, nil), nil
+
+	case constants.DatabaseTypeElasticsearch:
+		// The driver checks that the cluster can be reached with the credentials
+		driver, exists := m.drivers[config.Type]
+		if !exists {
+			return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+		}
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Elasticsearch: %v", err)
+		}
+		driver.Disconnect(conn)
+		return fetchTLSInfo(config, nil), nil
+
+	case constants.DatabaseTypeCassandra:
+		// The driver opens a session on the keyspace, checking the credentials
+		driver, exists := m.drivers[config.Type]
+		if !exists {
+			return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+		}
+		conn, err := driver.Connect(*config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Cassandra: %v", err)
+		}
+		driver.Disconnect(conn)
+		return fetchTLSInfo(config, nil), nil
 
 	default:
-		return fmt.Errorf("unsupported database type: %s", config.Type)
+		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
 	}
 }
 
@@ -776,6 +1014,11 @@ func (m *Manager) RefreshSchemaWithExamples(ctx context.Context, chatID string,
 	m.schemaManager.ClearSchemaCache(chatID)
 	log.Printf("DBManager -> RefreshSchemaWithExamples -> Cleared schema cache for chatID: %s", chatID)
 
+	// Stream the progress of the tables to the chat, large schemas take minutes to refresh
+	schemaCtx = withSchemaProgress(schemaCtx, func(progress SchemaProgress) {
+		m.notifySubscribers(chatID, conn.UserID, StatusSchemaProgress, progress)
+	})
+
 	// Check for context cancellation
 	if err := schemaCtx.Err(); err != nil {
 		log.Printf("DBManager -> RefreshSchemaWithExamples -> Context cancelled: %v", err)
@@ -0,0 +1,120 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ExportColumn is a column of a streamed query result, DatabaseType is the type name reported by the driver (ex: INT8, VARCHAR, Nullable(Int64))
+type ExportColumn struct {
+	Name         string
+	DatabaseType string
+}
+
+// StreamSupported checks if StreamQueryRows supports the database type, other types have to go through ExecuteQuery
+func StreamSupported(dbType string) bool {
+	return isSQLDatabaseType(dbType)
+}
+
+// StreamQueryRows runs a read query & passes the rows to onRow one at a time as they are read from the driver cursor,
+// so that exports don't hold the whole result in memory. onColumns is called once with the result columns before the
+// first row. The row slice is reused between calls, byte values are converted to strings.
+// The query runs in a read only transaction on the connection pool, temp tables of a session are not visible to it.
+func (m *Manager) StreamQueryRows(ctx context.Context, chatID, query string, onColumns func([]ExportColumn) error, onRow func([]interface{}) error) error {
+	chatID = connectionKey(ctx, chatID)
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !StreamSupported(conn.Config.Type) {
+		return fmt.Errorf("streaming query results is not supported for %s", conn.Config.Type)
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	var rows *sql.Rows
+	if conn.Config.Type == constants.DatabaseTypeClickhouse {
+		// ClickHouse has no transactions, exported queries are checked to be reads by the caller
+		rows, err = sqlDB.QueryContext(ctx, query)
+	} else {
+		var tx *sql.Tx
+		tx, err = sqlDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return fmt.Errorf("failed to start read only transaction: %v", err)
+		}
+		// Nothing is written, the transaction is only there to enforce it
+		defer tx.Rollback()
+		rows, err = tx.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to read result columns: %v", err)
+	}
+	columns := make([]ExportColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = ExportColumn{Name: columnType.Name(), DatabaseType: columnType.DatabaseTypeName()}
+	}
+	if err := onColumns(columns); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("failed to read row: %v", err)
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		if err := onRow(values); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %v", err)
+	}
+
+	log.Printf("DBManager -> StreamQueryRows -> Streamed %d rows of %d columns for chatID: %s", count, len(columns), chatID)
+	return nil
+}
+
+// NormalizeDatabaseType strips the wrappers of ClickHouse types & the size of SQL types, ex: Nullable(Int64) -> INT64, varchar(255) -> VARCHAR
+func NormalizeDatabaseType(databaseType string) string {
+	normalized := strings.TrimSpace(databaseType)
+	for unwrapped := false; !unwrapped; {
+		unwrapped = true
+		for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+			if strings.HasPrefix(normalized, wrapper) && strings.HasSuffix(normalized, ")") {
+				normalized = normalized[len(wrapper) : len(normalized)-1]
+				unwrapped = false
+			}
+		}
+	}
+	if index := strings.Index(normalized, "("); index > 0 {
+		normalized = normalized[:index]
+	}
+	return strings.ToUpper(strings.TrimSpace(normalized))
+}
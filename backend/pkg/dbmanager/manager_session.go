@@ -0,0 +1,133 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql/driver"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Session mode holds a dedicated connection per chat, so that temp tables & session variables created by a query
+// are visible to the next ones. The session lives until it is closed, the chat disconnects or the connection goes idle,
+// the connection is then physically closed (not returned to the shared pool) so that no session state leaks to other chats.
+
+// SessionSupported checks if the database type supports session mode
+func SessionSupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSQLite:
+		return true
+	}
+	return false
+}
+
+// SetSessionMode enables or disables session mode for a chat, disabling it closes the current session
+func (m *Manager) SetSessionMode(chatID string, enabled bool) {
+	m.sessionModesMu.Lock()
+	if enabled {
+		m.sessionModes[chatID] = true
+	} else {
+		delete(m.sessionModes, chatID)
+	}
+	m.sessionModesMu.Unlock()
+
+	if !enabled {
+		if err := m.CloseSession(chatID); err != nil {
+			log.Printf("DBManager -> SetSessionMode -> %v", err)
+		}
+	}
+}
+
+func (m *Manager) isSessionMode(chatID string) bool {
+	m.sessionModesMu.RLock()
+	defer m.sessionModesMu.RUnlock()
+	return m.sessionModes[chatID]
+}
+
+// OpenSession takes a dedicated connection out of the pool for the chat, it is a no-op if a session is already open
+func (m *Manager) OpenSession(ctx context.Context, chatID string) (*DBSession, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !SessionSupported(conn.Config.Type) {
+		return nil, fmt.Errorf("session mode is not supported for %s", conn.Config.Type)
+	}
+
+	conn.sessionMu.Lock()
+	defer conn.sessionMu.Unlock()
+	if conn.session != nil {
+		return conn.session, nil
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SQL connection: %v", err)
+	}
+	sqlConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %v", err)
+	}
+
+	conn.session = &DBSession{
+		Conn:      sqlConn,
+		StartedAt: time.Now(),
+	}
+	log.Printf("DBManager -> OpenSession -> Opened session for chatID: %s", chatID)
+	return conn.session, nil
+}
+
+// CloseSession closes the session of the chat & drops its temp tables & variables, it is a no-op without a session
+func (m *Manager) CloseSession(chatID string) error {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	m.closeSession(conn)
+	return nil
+}
+
+// GetSession returns the open session of the chat, if any
+func (m *Manager) GetSession(chatID string) (*DBSession, bool) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+
+	conn.sessionMu.Lock()
+	defer conn.sessionMu.Unlock()
+	return conn.session, conn.session != nil
+}
+
+// closeSession discards the session connection, waiting for a running query to finish first.
+// It must not be called with m.mu held, since waiting for the query would block every other chat.
+func (m *Manager) closeSession(conn *Connection) {
+	conn.sessionMu.Lock()
+	session := conn.session
+	conn.session = nil
+	conn.sessionMu.Unlock()
+	if session == nil {
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.closed = true
+
+	// Returning ErrBadConn makes database/sql close the connection instead of putting it back in the pool,
+	// that's what actually drops the temp tables & session variables
+	session.Conn.Raw(func(driverConn interface{}) error {
+		return driver.ErrBadConn
+	})
+	session.Conn.Close()
+	log.Printf("DBManager -> closeSession -> Closed session for chatID: %s (open for %v)", conn.ChatID, time.Since(session.StartedAt))
+}
@@ -0,0 +1,188 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"time"
+)
+
+// TransactionQuery is a query of ExecuteQueriesInTransaction
+type TransactionQuery struct {
+	QueryID   string
+	Query     string
+	QueryType string
+}
+
+// TransactionsSupported checks if several queries can be executed atomically in one transaction. ClickHouse has no
+// transactions & MongoDB only has them on replica sets, their queries can only be executed one at a time.
+func TransactionsSupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeSQLite:
+		return true
+	}
+	return false
+}
+
+// transactionsUnsupportedError is returned instead of executing queries that wouldn't be atomic
+func transactionsUnsupportedError(details string) *dtos.QueryError {
+	return &dtos.QueryError{
+		Code:    "TRANSACTIONS_UNSUPPORTED",
+		Message: "these queries can't be executed in a single transaction",
+		Details: details,
+	}
+}
+
+// ExecuteQueriesInTransaction executes queries in order in a single transaction, committed only if they all succeed.
+// The results of the queries executed are returned, up to the one that failed, with the error that rolled the
+// transaction back. Nothing is executed when the database or one of the query types (ex: MySQL DDL, committed
+// implicitly) can't be rolled back, a TRANSACTIONS_UNSUPPORTED error is returned instead.
+func (m *Manager) ExecuteQueriesInTransaction(ctx context.Context, chatID, messageID, streamID string, queries []TransactionQuery) ([]*QueryExecutionResult, *dtos.QueryError) {
+	chatID = connectionKey(ctx, chatID)
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, &dtos.QueryError{
+			Code:    "NO_CONNECTION_FOUND",
+			Message: "no connection found",
+			Details: "No connection found for chat ID: " + chatID,
+		}
+	}
+	driver, exists := m.drivers[conn.Config.Type]
+	if !exists {
+		return nil, &dtos.QueryError{
+			Code:    "NO_DRIVER_FOUND",
+			Message: "no driver found",
+			Details: "No driver found for type: " + conn.Config.Type,
+		}
+	}
+
+	if !TransactionsSupported(conn.Config.Type) {
+		return nil, transactionsUnsupportedError(fmt.Sprintf("%s doesn't support transactions over several queries, execute the queries one at a time", conn.Config.Type))
+	}
+	for i, query := range queries {
+		if !rollbackUndoesQuery(conn.Config.Type, query.QueryType) {
+			return nil, transactionsUnsupportedError(fmt.Sprintf("query %d is a %s query, %s commits it as soon as it runs so it can't be rolled back with the others", i+1, query.QueryType, conn.Config.Type))
+		}
+	}
+
+	// Every query gets the timeout of a single execution
+	timeout := queryTimeout(ctx)
+	execCtx, cancel := context.WithTimeout(ctx, time.Duration(len(queries))*timeout)
+	defer cancel()
+
+	// Session mode, the transaction runs on the dedicated connection of the chat
+	var session *DBSession
+	if m.isSessionMode(chatID) && SessionSupported(conn.Config.Type) {
+		var err error
+		session, err = m.OpenSession(execCtx, chatID)
+		if err != nil {
+			return nil, &dtos.QueryError{
+				Code:    "FAILED_TO_OPEN_SESSION",
+				Message: "failed to open session",
+				Details: err.Error(),
+			}
+		}
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		if session.closed {
+			return nil, &dtos.QueryError{
+				Code:    "SESSION_CLOSED",
+				Message: "session closed",
+				Details: "The session was closed while the queries were waiting for it, run them again to start a new session",
+			}
+		}
+	}
+
+	tx := driver.BeginTx(execCtx, conn, session)
+	if tx == nil {
+		return nil, &dtos.QueryError{
+			Code:    "FAILED_TO_START_TRANSACTION",
+			Message: "failed to start transaction",
+			Details: "Failed to start transaction",
+		}
+	}
+
+	// Tracked as a single execution, cancelling the stream rolls every query back
+	execution := &QueryExecution{
+		MessageID:     messageID,
+		StartTime:     time.Now(),
+		IsExecuting:   true,
+		IsMutation:    true,
+		Interruptible: true,
+		Tx:            tx,
+		CancelFunc:    cancel,
+	}
+	m.executionMu.Lock()
+	m.activeExecutions[streamID] = execution
+	m.executionMu.Unlock()
+	defer func() {
+		m.executionMu.Lock()
+		delete(m.activeExecutions, streamID)
+		m.executionMu.Unlock()
+	}()
+
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Printf("Manager -> ExecuteQueriesInTransaction -> Error rolling back transaction: %v", err)
+		}
+	}
+
+	results := make([]*QueryExecutionResult, 0, len(queries))
+	schemaChanged := false
+	dataChanged := false
+	for i, query := range queries {
+		execution.QueryID = query.QueryID
+		result := tx.ExecuteQuery(execCtx, conn, query.Query, query.QueryType, false)
+		if execCtx.Err() != nil {
+			rollback()
+			if execCtx.Err() == context.DeadlineExceeded {
+				return results, queryTimeoutError(time.Duration(len(queries))*timeout, fmt.Sprintf("Query %d timed out, the transaction was rolled back", i+1))
+			}
+			return results, &dtos.QueryError{
+				Code:    "QUERY_EXECUTION_CANCELLED",
+				Message: "query execution cancelled",
+				Details: fmt.Sprintf("Query %d was cancelled, the transaction was rolled back", i+1),
+			}
+		}
+		results = append(results, result)
+		if result.Error != nil {
+			rollback()
+			return results, &dtos.QueryError{
+				Code:    result.Error.Code,
+				Message: result.Error.Message,
+				Details: fmt.Sprintf("Query %d failed, the transaction was rolled back: %s", i+1, result.Error.Details),
+			}
+		}
+		switch query.QueryType {
+		case "DDL", "ALTER", "DROP":
+			schemaChanged = true
+		}
+		if isMutationQueryType(query.QueryType) || !IsReadOnlyQuery(conn.Config.Type, query.Query) {
+			dataChanged = true
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return results, &dtos.QueryError{
+			Code:    "QUERY_EXECUTION_FAILED",
+			Message: "failed to commit the transaction",
+			Details: err.Error(),
+		}
+	}
+	log.Printf("Manager -> ExecuteQueriesInTransaction -> Committed %d queries for chatID: %s", len(queries), chatID)
+
+	// The data of the chat may have changed, its cached read results are stale
+	if dataChanged {
+		m.InvalidateQueryResultCache(context.WithoutCancel(ctx), chatID)
+	}
+
+	if schemaChanged && conn.OnSchemaChange != nil {
+		go conn.OnSchemaChange(conn.ChatID)
+	}
+	return results, nil
+}
@@ -0,0 +1,93 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// MariaDBSchemaFetcher implements schema fetching for MariaDB, the schema of MySQL with the sequence objects of MariaDB
+type MariaDBSchemaFetcher struct {
+	*MySQLSchemaFetcher
+}
+
+// NewMariaDBSchemaFetcher creates a new MariaDB schema fetcher
+func NewMariaDBSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &MariaDBSchemaFetcher{MySQLSchemaFetcher: &MySQLSchemaFetcher{db: db}}
+}
+
+// GetSchema retrieves the schema for the selected tables with the sequences of the database, they aren't tied to a table
+func (f *MariaDBSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	schema, err := f.MySQLSchemaFetcher.GetSchema(ctx, db, selectedTables)
+	if err != nil {
+		return nil, err
+	}
+
+	sequences, err := f.fetchSequences(ctx)
+	if err != nil {
+		// Sequences came with MariaDB 10.3, the schema is usable without them
+		log.Printf("MariaDBSchemaFetcher -> GetSchema -> Error fetching sequences: %v", err)
+		return schema, nil
+	}
+	schema.Sequences = sequences
+	log.Printf("MariaDBSchemaFetcher -> GetSchema -> Fetched %d sequences", len(sequences))
+	return schema, nil
+}
+
+// fetchSequences retrieves the sequences of the database, each sequence is a table holding its single row of settings
+func (f *MariaDBSchemaFetcher) fetchSequences(_ context.Context) (map[string]SequenceSchema, error) {
+	var names []string
+	query := `
+        SELECT table_name
+        FROM information_schema.tables
+        WHERE table_schema = DATABASE()
+        AND table_type = 'SEQUENCE'
+        ORDER BY table_name;
+    `
+	if err := f.db.Query(query, &names); err != nil {
+		return nil, fmt.Errorf("failed to fetch sequences: %v", err)
+	}
+
+	sequences := make(map[string]SequenceSchema, len(names))
+	for _, name := range names {
+		var rows []map[string]interface{}
+		settingsQuery := fmt.Sprintf("SELECT start_value, increment, minimum_value, maximum_value, cache_size, cycle_option FROM `%s`", name)
+		if err := f.db.QueryRows(settingsQuery, &rows); err != nil {
+			return nil, fmt.Errorf("failed to fetch the settings of sequence %s: %v", name, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		sequences[name] = SequenceSchema{
+			Name:       name,
+			StartValue: sequenceSetting(rows[0]["start_value"]),
+			Increment:  sequenceSetting(rows[0]["increment"]),
+			MinValue:   sequenceSetting(rows[0]["minimum_value"]),
+			MaxValue:   sequenceSetting(rows[0]["maximum_value"]),
+			CacheSize:  sequenceSetting(rows[0]["cache_size"]),
+			IsCycled:   sequenceSetting(rows[0]["cycle_option"]) != 0,
+		}
+	}
+	return sequences, nil
+}
+
+// sequenceSetting reads a setting of a sequence, the driver returns the integers as numbers or as their text
+func sequenceSetting(value interface{}) int64 {
+	if number, ok := toInt64(value); ok {
+		return number
+	}
+	switch v := value.(type) {
+	case []byte:
+		number, _ := strconv.ParseInt(string(v), 10, 64)
+		return number
+	case string:
+		number, _ := strconv.ParseInt(v, 10, 64)
+		return number
+	case uint64:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
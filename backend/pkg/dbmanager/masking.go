@@ -0,0 +1,299 @@
+package dbmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Built-in masking formats, anything else is treated as a pattern (see applyMaskPattern)
+const (
+	MaskFormatFull        = "full"         // ********
+	MaskFormatLast4       = "last4"        // ************1111
+	MaskFormatFirst4      = "first4"       // 4111************
+	MaskFormatEmailDomain = "email_domain" // ****@example.com
+	MaskFormatEmailLocal  = "email_local"  // j***@e******.com
+	MaskFormatPartial     = "partial"      // partial:2:3 -> keep first 2 and last 3 characters
+
+	maskChar = '*'
+)
+
+// ColumnMasks maps a column to its masking format, keys are either "column" (any table) or "table.column"
+type ColumnMasks map[string]string
+
+// ValidateMaskFormat checks that the format is a built-in or a well-formed pattern
+func ValidateMaskFormat(format string) error {
+	switch format {
+	case MaskFormatFull, MaskFormatLast4, MaskFormatFirst4, MaskFormatEmailDomain, MaskFormatEmailLocal:
+		return nil
+	}
+
+	if strings.HasPrefix(format, MaskFormatPartial+":") {
+		if _, _, err := parsePartialMask(format); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// Patterns must reveal or mask at least one character, otherwise they would just replace the value
+	if !strings.ContainsAny(format, "#*") {
+		return fmt.Errorf("invalid mask format %q: expected a built-in format or a pattern using '#' and '*'", format)
+	}
+	return nil
+}
+
+// FormatFor returns the masking format for a column, table specific keys take precedence over bare column keys
+func (cm ColumnMasks) FormatFor(table, column string) (string, bool) {
+	if len(cm) == 0 {
+		return "", false
+	}
+	if table != "" {
+		if format, ok := cm[table+"."+column]; ok {
+			return format, true
+		}
+	}
+	if format, ok := cm[column]; ok {
+		return format, true
+	}
+	if table == "" {
+		// Results don't carry their source table, so fall back to any "table.column" key for this column
+		for key, format := range cm {
+			if idx := strings.LastIndex(key, "."); idx != -1 && key[idx+1:] == column {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ApplyToRecords masks the configured columns of the given records, table may be empty when unknown
+func (cm ColumnMasks) ApplyToRecords(table string, records []map[string]interface{}) []map[string]interface{} {
+	if len(cm) == 0 || len(records) == 0 {
+		return records
+	}
+
+	masked := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		masked[i] = cm.applyToRecord(table, "", record)
+	}
+	return masked
+}
+
+// applyToRecord masks a record & its nested documents, the fields of nested documents are matched by their
+// dotted path (ex: "address.city") as well as by their own name
+func (cm ColumnMasks) applyToRecord(table, prefix string, record map[string]interface{}) map[string]interface{} {
+	maskedRecord := make(map[string]interface{}, len(record))
+	for column, value := range record {
+		path := prefix + column
+		if format, ok := cm.formatForPath(table, path, column); ok {
+			maskedRecord[column] = MaskValue(value, format)
+			continue
+		}
+		maskedRecord[column] = cm.applyToValue(table, path+".", value)
+	}
+	return maskedRecord
+}
+
+// applyToValue masks the documents nested in a value, other values are returned as is
+func (cm ColumnMasks) applyToValue(table, prefix string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return cm.applyToRecord(table, prefix, v)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = cm.applyToValue(table, prefix, item)
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+func (cm ColumnMasks) formatForPath(table, path, column string) (string, bool) {
+	if format, ok := cm.FormatFor(table, path); ok {
+		return format, true
+	}
+	if path != column {
+		return cm.FormatFor(table, column)
+	}
+	return "", false
+}
+
+// ApplyToResultJSON masks the configured columns of a query result JSON: a list of records, a single record or an
+// object with a "results" record or list of records (ex: MongoDB findOne) & the rows of its result sets. Nested documents
+// are masked as well.
+// Results that can't be parsed are dropped rather than returned unmasked.
+func (cm ColumnMasks) ApplyToResultJSON(resultJSON string) string {
+	if len(cm) == 0 || resultJSON == "" {
+		return resultJSON
+	}
+
+	var result interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		log.Printf("ColumnMasks -> ApplyToResultJSON -> Dropping a result that can't be parsed for masking: %v", err)
+		return "null"
+	}
+
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		if results, isWrapper := resultMap["results"]; isWrapper {
+			masked := make(map[string]interface{}, len(resultMap))
+			for key, value := range resultMap {
+				masked[key] = value
+			}
+			masked["results"] = cm.applyToValue("", "", results)
+			if sets, hasSets := resultMap[ResultSetsKey].([]interface{}); hasSets {
+				maskedSets := make([]interface{}, len(sets))
+				for i, set := range sets {
+					maskedSets[i] = set
+					if setMap, ok := set.(map[string]interface{}); ok {
+						maskedSet := make(map[string]interface{}, len(setMap))
+						for key, value := range setMap {
+							maskedSet[key] = value
+						}
+						maskedSet["results"] = cm.applyToValue("", "", setMap["results"])
+						maskedSets[i] = maskedSet
+					}
+				}
+				masked[ResultSetsKey] = maskedSets
+			}
+			result = masked
+		} else {
+			result = cm.applyToRecord("", "", resultMap)
+		}
+	} else {
+		result = cm.applyToValue("", "", result)
+	}
+
+	maskedJSON, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("ColumnMasks -> ApplyToResultJSON -> Error marshalling masked result: %v", err)
+		return "null"
+	}
+	return string(maskedJSON)
+}
+
+// MaskValue masks a single value with the given format, nil values are left untouched
+func MaskValue(value interface{}, format string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		str = fmt.Sprintf("%v", v)
+	}
+	runes := []rune(str)
+
+	switch format {
+	case MaskFormatFull:
+		return strings.Repeat(string(maskChar), len(runes))
+	case MaskFormatLast4:
+		return maskRunes(runes, 0, 4)
+	case MaskFormatFirst4:
+		return maskRunes(runes, 4, 0)
+	case MaskFormatEmailDomain:
+		at := strings.LastIndex(str, "@")
+		if at == -1 {
+			return maskRunes(runes, 0, 0)
+		}
+		return strings.Repeat(string(maskChar), len([]rune(str[:at]))) + str[at:]
+	case MaskFormatEmailLocal:
+		at := strings.LastIndex(str, "@")
+		if at == -1 {
+			return maskRunes(runes, 1, 0)
+		}
+		local, domain := []rune(str[:at]), str[at+1:]
+		dot := strings.LastIndex(domain, ".")
+		if dot == -1 {
+			return maskRunes(local, 1, 0) + "@" + maskRunes([]rune(domain), 1, 0)
+		}
+		return maskRunes(local, 1, 0) + "@" + maskRunes([]rune(domain[:dot]), 1, 0) + domain[dot:]
+	}
+
+	if strings.HasPrefix(format, MaskFormatPartial+":") {
+		keepFirst, keepLast, err := parsePartialMask(format)
+		if err != nil {
+			return maskRunes(runes, 0, 0)
+		}
+		return maskRunes(runes, keepFirst, keepLast)
+	}
+
+	return applyMaskPattern(runes, format)
+}
+
+// maskRunes keeps the first and last characters visible and masks everything in between,
+// the whole value is masked if it is too short to reveal anything safely
+func maskRunes(runes []rune, keepFirst, keepLast int) string {
+	if keepFirst+keepLast >= len(runes) {
+		return strings.Repeat(string(maskChar), len(runes))
+	}
+	masked := make([]rune, len(runes))
+	for i, r := range runes {
+		if i < keepFirst || i >= len(runes)-keepLast {
+			masked[i] = r
+		} else {
+			masked[i] = maskChar
+		}
+	}
+	return string(masked)
+}
+
+// applyMaskPattern applies a pattern right-aligned to the value, '#' reveals a character, '*' masks it
+// and any other character is copied as a literal, ex: "****-****-****-####" on "4111111111111111"
+// gives "****-****-****-1111". Leading characters not covered by the pattern are masked.
+func applyMaskPattern(runes []rune, pattern string) string {
+	patternRunes := []rune(pattern)
+	result := make([]rune, 0, len(patternRunes))
+
+	valueIdx := len(runes) - 1
+	for i := len(patternRunes) - 1; i >= 0; i-- {
+		switch patternRunes[i] {
+		case '#':
+			if valueIdx >= 0 {
+				result = append(result, runes[valueIdx])
+				valueIdx--
+			}
+		case '*':
+			if valueIdx >= 0 {
+				result = append(result, maskChar)
+				valueIdx--
+			}
+		default:
+			result = append(result, patternRunes[i])
+		}
+	}
+	for ; valueIdx >= 0; valueIdx-- {
+		result = append(result, maskChar)
+	}
+
+	// Reverse since we built it from the end
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result)
+}
+
+// parsePartialMask parses "partial:<keepFirst>:<keepLast>"
+func parsePartialMask(format string) (int, int, error) {
+	parts := strings.Split(format, ":")
+	if len(parts) != 3 {
+		return 0, 0, fmt.Errorf("invalid mask format %q: expected partial:<keepFirst>:<keepLast>", format)
+	}
+	keepFirst, err := strconv.Atoi(parts[1])
+	if err != nil || keepFirst < 0 {
+		return 0, 0, fmt.Errorf("invalid mask format %q: keepFirst must be a non-negative number", format)
+	}
+	keepLast, err := strconv.Atoi(parts[2])
+	if err != nil || keepLast < 0 {
+		return 0, 0, fmt.Errorf("invalid mask format %q: keepLast must be a non-negative number", format)
+	}
+	return keepFirst, keepLast, nil
+}
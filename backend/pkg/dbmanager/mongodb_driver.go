@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -437,20 +436,16 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	// Base connection parameters with authentication
 	if config.Username != nil && *config.Username != "" {
 		// URL encode username and password to handle special characters
-		encodedUsername := url.QueryEscape(*config.Username)
-		var encodedPassword string
-		if config.Password != nil {
-			encodedPassword = url.QueryEscape(*config.Password)
-		}
+		userInfo := connectionURLUserInfo(*config.Username, config.Password)
 
 		if isSRV {
 			// For SRV records, don't include port
-			uri = fmt.Sprintf("%s://%s:%s@%s/%s",
-				protocol, encodedUsername, encodedPassword, config.Host, config.Database)
+			uri = fmt.Sprintf("%s://%s@%s/%s",
+				protocol, userInfo, config.Host, config.Database)
 		} else {
 			// Include port for standard connections
-			uri = fmt.Sprintf("%s://%s:%s@%s:%s/%s",
-				protocol, encodedUsername, encodedPassword, config.Host, port, config.Database)
+			uri = fmt.Sprintf("%s://%s@%s:%s/%s",
+				protocol, userInfo, config.Host, port, config.Database)
 		}
 	} else {
 		// Without authentication
@@ -464,11 +459,7 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	}
 
 	// Log the final URI (with sensitive parts masked)
-	maskedUri := uri
-	if config.Password != nil && *config.Password != "" {
-		maskedUri = strings.Replace(maskedUri, *config.Password, "********", -1)
-	}
-	log.Printf("MongoDBDriver -> Connect -> Connection URI: %s", maskedUri)
+	log.Printf("MongoDBDriver -> Connect -> Connection URI: %s", maskConnectionURL(uri))
 
 	// Add connection options
 	if isSRV {
@@ -555,9 +546,12 @@ func (d *MongoDBDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		clientOptions.SetTLSConfig(&tls.Config{InsecureSkipVerify: true})
 	}
 	// Configure connection pool
-	clientOptions.SetMaxPoolSize(25)
-	clientOptions.SetMinPoolSize(5)
-	clientOptions.SetMaxConnIdleTime(time.Hour)
+	applyMongoPoolSettings(clientOptions, config)
+
+	// Only the tunneled member can be reached through an SSH tunnel, the other members of the replica set aren't used
+	if config.sshTunneled {
+		clientOptions.SetDirect(true)
+	}
 
 	// Connect to MongoDB with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -890,6 +884,7 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 	}
 
 	var result interface{}
+	var warnings []string
 	var err error
 
 	log.Printf("MongoDBDriver -> ExecuteQuery -> operation: %s", operation)
@@ -1022,10 +1017,32 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		// Extract modifiers from the query string
 		modifiers := extractModifiers(query)
 
-		// If count() modifier is present, perform a count operation instead of find
-		if modifiers.Count {
-			// Execute the countDocuments operation
-			count, err := collection.CountDocuments(ctx, filter)
+		// Text is compared with the collation chained to the query if any, ex: .collation({locale: "fr", strength: 2})
+		collation, err := extractMongoCollation(query)
+		if err != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "INVALID_PARAMETERS",
+				},
+			}
+		}
+
+		// If count() modifier is present, perform a count operation instead of find, an explained count gets the plan of
+		// its find
+		if modifiers.Count && queryPlanVerbosity(ctx) == "" {
+			// Execute the countDocuments operation, a chained limit caps the count like for countDocuments below
+			countOptions := options.Count()
+			if collation != nil {
+				countOptions.SetCollation(collation)
+			}
+			if modifiers.Limit > 0 {
+				countOptions.SetLimit(modifiers.Limit)
+			}
+			if modifiers.Skip > 0 {
+				countOptions.SetSkip(modifiers.Skip)
+			}
+			count, err := collection.CountDocuments(ctx, filter, countOptions)
 			if err != nil {
 				return &QueryExecutionResult{
 					Error: &dtos.QueryError{
@@ -1043,6 +1060,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 
 		// Create find options
 		findOptions := options.Find()
+		if collation != nil {
+			findOptions.SetCollation(collation)
+		}
 
 		// Apply limit if specified
 		if modifiers.Limit > 0 {
@@ -1141,6 +1161,20 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			findOptions.SetProjection(projectionDoc)
 		}
 
+		// The plan of the find is returned instead of its documents, see Manager.ExplainQuery
+		if verbosity := queryPlanVerbosity(ctx); verbosity != "" {
+			result, err = explainMongoFind(ctx, collection, filter, findOptions, verbosity)
+			if err != nil {
+				return &QueryExecutionResult{
+					Error: &dtos.QueryError{
+						Message: fmt.Sprintf("Failed to explain find operation: %v", err),
+						Code:    "EXECUTION_ERROR",
+					},
+				}
+			}
+			break
+		}
+
 		// Execute the find operation
 		cursor, err := collection.Find(ctx, filter, findOptions)
 		if err != nil {
@@ -1153,9 +1187,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		}
 		defer cursor.Close(ctx)
 
-		// Decode the results
-		var results []bson.M
-		if err := cursor.All(ctx, &results); err != nil {
+		// Decode the results, at most the row limit of the context is read
+		results, truncated, err := decodeLimitedCursor(ctx, cursor)
+		if err != nil {
 			return &QueryExecutionResult{
 				Error: &dtos.QueryError{
 					Message: fmt.Sprintf("Failed to decode find results: %v", err),
@@ -1163,6 +1197,9 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 				},
 			}
 		}
+		if truncated {
+			warnings = append(warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+		}
 
 		result = results
 
@@ -1730,6 +1767,19 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			}
 		}
 
+		// The options of the aggregation follow its pipeline, only their collation is used, ex: {collation: {locale: "fr"}}
+		var aggregateOptions string
+		paramsStr, aggregateOptions = splitAggregateOptions(paramsStr)
+		collation, err := aggregateCollation(aggregateOptions)
+		if err != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "INVALID_PARAMETERS",
+				},
+			}
+		}
+
 		// Parse the parameters as a pipeline
 		var pipeline []bson.M
 		if err := json.Unmarshal([]byte(paramsStr), &pipeline); err != nil {
@@ -1828,8 +1878,26 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			log.Printf("MongoDBDriver -> ExecuteQuery -> Error processing dot notation in pipeline: %v", err)
 		}
 
+		// The plan of the aggregation is returned instead of its documents, see Manager.ExplainQuery
+		if verbosity := queryPlanVerbosity(ctx); verbosity != "" {
+			result, err = explainMongoAggregate(ctx, collection, pipeline, collation, verbosity)
+			if err != nil {
+				return &QueryExecutionResult{
+					Error: &dtos.QueryError{
+						Message: fmt.Sprintf("Failed to explain aggregation: %v", err),
+						Code:    "EXECUTION_ERROR",
+					},
+				}
+			}
+			break
+		}
+
 		// Execute the aggregation
-		cursor, err := collection.Aggregate(ctx, pipeline)
+		aggregateOpts := options.Aggregate()
+		if collation != nil {
+			aggregateOpts.SetCollation(collation)
+		}
+		cursor, err := collection.Aggregate(ctx, pipeline, aggregateOpts)
 		if err != nil {
 			log.Printf("MongoDBDriver -> ExecuteQuery -> Error executing aggregation: %v", err)
 			return &QueryExecutionResult{
@@ -1901,8 +1969,30 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 			}
 		}
 
+		// countDocuments doesn't chain .limit() or .skip() in MongoDB, generated count queries use them to count at most
+		// the requested number of documents (ex: db.users.countDocuments({}).limit(150)), which are options of the count
+		countOptions := options.Count()
+		if limit, ok := modifiers["limit"].(int); ok && limit > 0 {
+			countOptions.SetLimit(int64(limit))
+		}
+		if skip, ok := modifiers["skip"].(int); ok && skip > 0 {
+			countOptions.SetSkip(int64(skip))
+		}
+		collation, err := extractMongoCollation(query)
+		if err != nil {
+			return &QueryExecutionResult{
+				Error: &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "INVALID_PARAMETERS",
+				},
+			}
+		}
+		if collation != nil {
+			countOptions.SetCollation(collation)
+		}
+
 		// Execute the countDocuments operation
-		count, err := collection.CountDocuments(ctx, filter)
+		count, err := collection.CountDocuments(ctx, filter, countOptions)
 		if err != nil {
 			return &QueryExecutionResult{
 				Error: &dtos.QueryError{
@@ -2036,11 +2126,12 @@ func (d *MongoDBDriver) ExecuteQuery(ctx context.Context, conn *Connection, quer
 		Result:        resultMap,
 		ResultJSON:    string(resultJSON),
 		ExecutionTime: executionTime,
+		Warnings:      warnings,
 	}
 }
 
-// BeginTx begins a MongoDB transaction
-func (d *MongoDBDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+// BeginTx begins a MongoDB transaction, session mode isn't supported for MongoDB so the DB session is always nil
+func (d *MongoDBDriver) BeginTx(ctx context.Context, conn *Connection, _ *DBSession) Transaction {
 	log.Printf("MongoDBDriver -> BeginTx -> Beginning MongoDB transaction")
 
 	// Debug logging: Is MongoDBObj set in the connection?
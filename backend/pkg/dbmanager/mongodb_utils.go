@@ -548,7 +548,7 @@ func extractModifiers(query string) struct {
 func (d *MongoDBDriver) SafeBeginTx(ctx context.Context, conn *Connection) (Transaction, error) {
 	log.Printf("MongoDBDriver -> SafeBeginTx -> Safely beginning MongoDB transaction")
 
-	tx := d.BeginTx(ctx, conn)
+	tx := d.BeginTx(ctx, conn, nil)
 
 	// Check if the transaction has an error
 	if mongoTx, ok := tx.(*MongoDBTransaction); ok && mongoTx.Error != nil {
@@ -648,9 +648,9 @@ func processSortExpression(sortExpr string) (string, error) {
 
 // Process the aggregation results from a cursor
 func processAggregationResultsFromCursor(cursor *mongo.Cursor, ctx context.Context) *QueryExecutionResult {
-	// Decode the results
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
+	// Decode the results, at most the row limit of the context is read
+	results, truncated, err := decodeLimitedCursor(ctx, cursor)
+	if err != nil {
 		return &QueryExecutionResult{
 			Error: &dtos.QueryError{
 				Message: fmt.Sprintf("Failed to decode aggregation results: %v", err),
@@ -658,6 +658,10 @@ func processAggregationResultsFromCursor(cursor *mongo.Cursor, ctx context.Conte
 			},
 		}
 	}
+	var warnings []string
+	if truncated {
+		warnings = []string{resultTruncatedWarning(resultRowLimit(ctx))}
+	}
 
 	// Create a wrapper for the results to maintain compatibility with existing code
 	resultMap := map[string]interface{}{
@@ -683,6 +687,7 @@ func processAggregationResultsFromCursor(cursor *mongo.Cursor, ctx context.Conte
 		Result:        resultMap,
 		ResultJSON:    string(resultJSON),
 		ExecutionTime: executionTime,
+		Warnings:      warnings,
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"database/sql"
 	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
 	"databot-ai/internal/utils"
 	"encoding/json"
 	"fmt"
@@ -34,7 +35,8 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	var dsn string
 	var tempFiles []string
 
-	// Base connection parameters
+	// Base connection parameters, credentials aren't URL encoded: the driver splits them at the first ':' & the last '@'
+	// before the database name, so passwords containing '@', ':', '/' or '?' are read back unchanged
 	if config.Password != nil {
 		dsn = fmt.Sprintf(
 			"%s:%s@tcp(%s:%s)/%s",
@@ -47,8 +49,8 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		)
 	}
 
-	// Add parameters
-	dsn += "?parseTime=true"
+	// Add parameters, the program_name attribute lets ListServerActivity only list DataBot's queries
+	dsn += "?parseTime=true&connectionAttributes=program_name:" + connectionApplicationName
 
 	// Configure SSL/TLS
 	if config.UseSSL {
@@ -156,13 +158,11 @@ func (d *MySQLDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(time.Hour)
+	applySQLPoolSettings(db, config)
 
-	// Create GORM DB
+	// Create GORM DB on the configured pool
 	gormDB, err := gorm.Open(mysql.New(mysql.Config{
-		DSN: dsn,
+		Conn: db,
 	}), &gorm.Config{})
 
 	if err != nil {
@@ -253,6 +253,8 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 
 	// Split the query into individual statements
 	statements := splitMySQLStatements(query)
+	// Result sets of all the statements of a batch
+	var resultSets []ResultSet
 
 	// Execute each statement
 	for _, stmt := range statements {
@@ -272,49 +274,42 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
-			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
-			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := conn.DB.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") ||
+			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "CALL") ||
+			hasReturningClause(conn.Config.Type, stmt) {
+			// For SELECT, SHOW, DESCRIBE & CALL queries, return the results, at most the row limit of the context is read.
+			// Stored procedures can return several result sets, all of them are read. MariaDB writes with a RETURNING
+			// clause return the rows they changed.
+			db := conn.DB.WithContext(ctx)
+			sqlRows, err := db.Raw(stmt).Rows()
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
 				}
 				return result
 			}
-
-			// Process the rows to ensure proper type handling
-			processedRows := make([]map[string]interface{}, len(rows))
-			for i, row := range rows {
-				processedRow := make(map[string]interface{})
-				for key, val := range row {
-					// Handle different types properly
-					switch v := val.(type) {
-					case []byte:
-						// Convert []byte to string
-						processedRow[key] = string(v)
-					case string:
-						// Keep strings as is
-						processedRow[key] = v
-					case float64:
-						// Keep numbers as is
-						processedRow[key] = v
-					case int64:
-						// Keep integers as is
-						processedRow[key] = v
-					case bool:
-						// Keep booleans as is
-						processedRow[key] = v
-					case nil:
-						// Keep nulls as is
-						processedRow[key] = nil
-					default:
-						// For other types, convert to string
-						processedRow[key] = fmt.Sprintf("%v", v)
-					}
+			sets, truncated, err := scanResultSets(ctx, db, sqlRows)
+			sqlRows.Close()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
 				}
-				processedRows[i] = processedRow
+				return result
+			}
+			if truncated {
+				result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+			}
+
+			// Process the rows to ensure proper type handling, "results" holds the last result set
+			processedRows := make([]map[string]interface{}, 0)
+			for i := range sets {
+				sets[i].Rows = processMySQLRows(sets[i].Rows)
+				processedRows = sets[i].Rows
+				result.Columns = sets[i].Types
 			}
+			resultSets = append(resultSets, sets...)
 
 			result.Result = map[string]interface{}{
 				"results": processedRows,
@@ -344,6 +339,8 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 		}
 	}
 
+	addResultSets(result, resultSets)
+
 	// Calculate execution time
 	executionTime := int(time.Since(startTime).Milliseconds())
 	result.ExecutionTime = executionTime
@@ -365,24 +362,61 @@ func (d *MySQLDriver) ExecuteQuery(ctx context.Context, conn *Connection, query
 	return result
 }
 
+// hasReturningClause checks if a MariaDB INSERT, REPLACE or DELETE statement returns the rows it changed, MySQL has no
+// RETURNING clause
+func hasReturningClause(dbType, stmt string) bool {
+	if dbType != constants.DatabaseTypeMariaDB {
+		return false
+	}
+	tokens := tokenizeSQL(stmt)
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[0].value {
+	case "insert", "replace", "delete":
+	default:
+		return false
+	}
+	for _, token := range tokens[1:] {
+		if token.kind == sqlTokenWord && token.value == "returning" {
+			return true
+		}
+	}
+	return false
+}
+
 // BeginTx starts a new transaction
-func (d *MySQLDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+func (d *MySQLDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
 	if conn == nil || conn.DB == nil {
 		log.Printf("MySQLDriver.BeginTx: Connection or DB is nil")
 		return nil
 	}
 
-	// Start a new transaction
-	tx := conn.DB.WithContext(ctx).Begin()
+	// Start a new transaction, on the session connection if one is held so that temp tables & variables persist
+	db := conn.DB.WithContext(ctx)
+	if session != nil {
+		db.Statement.ConnPool = session.Conn
+	}
+	tx := db.Begin()
 	if tx.Error != nil {
 		log.Printf("Failed to begin transaction: %v", tx.Error)
 		return nil
 	}
 
-	return &MySQLTransaction{
+	transaction := &MySQLTransaction{
 		tx:   tx,
 		conn: conn,
 	}
+	// The ID of the connection, a cancelled query is killed on the server from another connection
+	var connectionID uint64
+	if err := tx.Raw("SELECT CONNECTION_ID()").Row().Scan(&connectionID); err != nil {
+		log.Printf("MySQLDriver -> BeginTx -> Failed to get the connection ID: %v", err)
+	} else {
+		transaction.serverQuery.cancel = func(ctx context.Context) error {
+			return conn.DB.WithContext(ctx).Exec(fmt.Sprintf("KILL QUERY %d", connectionID)).Error
+		}
+	}
+	return transaction
 }
 
 // GetSchema retrieves the database schema
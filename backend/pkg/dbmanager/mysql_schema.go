@@ -213,10 +213,10 @@ func (f *MySQLSchemaFetcher) fetchTables(_ context.Context) ([]string, error) {
 func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[string]ColumnInfo, error) {
 	columns := make(map[string]ColumnInfo)
 
-	// Try using DESCRIBE table first, which is more reliable
+	// Try using DESCRIBE table first, which is more reliable. SHOW FULL COLUMNS is DESCRIBE with the collations.
 	log.Printf("MySQLSchemaFetcher -> fetchColumns -> Using DESCRIBE for table %s", table)
 	var describeResults []map[string]interface{}
-	describeQuery := fmt.Sprintf("DESCRIBE `%s`", table)
+	describeQuery := fmt.Sprintf("SHOW FULL COLUMNS FROM `%s`", table)
 	err := f.db.QueryRows(describeQuery, &describeResults)
 	if err != nil {
 		log.Printf("MySQLSchemaFetcher -> fetchColumns -> DESCRIBE error for table %s: %v", table, err)
@@ -230,7 +230,7 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
 			log.Printf("MySQLSchemaFetcher -> fetchColumns -> Processing column data: %+v", col)
 
 			// Extract field values, handling potential type conversions
-			var name, dataType, nullable, defaultVal string
+			var name, dataType, nullable, defaultVal, collation string
 
 			// Handle Field
 			if fieldVal, ok := col["Field"]; ok {
@@ -268,6 +268,15 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
 				}
 			}
 
+			// Handle Collation, NULL for columns that aren't text
+			if collationVal, ok := col["Collation"]; ok && collationVal != nil {
+				if strVal, ok := collationVal.(string); ok {
+					collation = strVal
+				} else if byteVal, ok := collationVal.([]byte); ok {
+					collation = string(byteVal)
+				}
+			}
+
 			if name != "" {
 				isNullable := false
 				if nullable == "YES" {
@@ -280,6 +289,7 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
 					IsNullable:   isNullable,
 					DefaultValue: defaultVal,
 					Comment:      "",
+					Collation:    collation,
 				}
 				log.Printf("MySQLSchemaFetcher -> fetchColumns -> Added column from DESCRIBE: %s, Type: %s, IsNullable: %v",
 					name, dataType, isNullable)
@@ -299,6 +309,7 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
 		IsNullable   string `db:"is_nullable"`
 		DefaultValue string `db:"column_default"`
 		Comment      string `db:"column_comment"`
+		Collation    string `db:"collation_name"`
 	}
 
 	query := `
@@ -307,7 +318,8 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
             data_type,
             is_nullable,
             column_default,
-            column_comment
+            column_comment,
+            collation_name
         FROM information_schema.columns
         WHERE table_schema = DATABASE()
         AND table_name = ?
@@ -336,6 +348,7 @@ func (f *MySQLSchemaFetcher) fetchColumns(_ context.Context, table string) (map[
 				IsNullable:   col.IsNullable == "YES",
 				DefaultValue: col.DefaultValue,
 				Comment:      col.Comment,
+				Collation:    col.Collation,
 			}
 		}
 	}
@@ -5,6 +5,7 @@ import (
 	"databot-ai/internal/apis/dtos"
 	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -13,8 +14,9 @@ import (
 
 // MySQLTransaction implements the Transaction interface for MySQL
 type MySQLTransaction struct {
-	tx   *gorm.DB
-	conn *Connection
+	tx          *gorm.DB
+	conn        *Connection
+	serverQuery // Kills the running statement with KILL QUERY
 }
 
 // ExecuteQuery executes a query within a transaction
@@ -33,6 +35,8 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 
 	// Split the query into individual statements
 	statements := splitMySQLStatements(query)
+	// Result sets of all the statements of a batch
+	var resultSets []ResultSet
 
 	// Execute each statement
 	for _, stmt := range statements {
@@ -52,53 +56,47 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 		// Execute the statement based on query type
 		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT") ||
 			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SHOW") ||
-			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") {
-			// For SELECT, SHOW, DESCRIBE queries, return the results
-			var rows []map[string]interface{}
-			if err := t.tx.WithContext(ctx).Raw(stmt).Scan(&rows).Error; err != nil {
+			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "DESCRIBE") ||
+			strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "CALL") ||
+			hasReturningClause(conn.Config.Type, stmt) {
+			// For SELECT, SHOW, DESCRIBE & CALL queries, return the results, at most the row limit of the context is read.
+			// Stored procedures can return several result sets, all of them are read. MariaDB writes with a RETURNING
+			// clause return the rows they changed.
+			db := t.tx.WithContext(ctx)
+			sqlRows, err := db.Raw(stmt).Rows()
+			if err != nil {
 				result.Error = &dtos.QueryError{
 					Message: err.Error(),
 					Code:    "EXECUTION_ERROR",
 				}
 				return result
 			}
-
-			// Process the rows to ensure proper type handling
-			processedRows := make([]map[string]interface{}, len(rows))
-			for i, row := range rows {
-				processedRow := make(map[string]interface{})
-				for key, val := range row {
-					// Handle different types properly
-					switch v := val.(type) {
-					case []byte:
-						// Convert []byte to string
-						processedRow[key] = string(v)
-					case string:
-						// Keep strings as is
-						processedRow[key] = v
-					case float64:
-						// Keep numbers as is
-						processedRow[key] = v
-					case int64:
-						// Keep integers as is
-						processedRow[key] = v
-					case bool:
-						// Keep booleans as is
-						processedRow[key] = v
-					case nil:
-						// Keep nulls as is
-						processedRow[key] = nil
-					default:
-						// For other types, convert to string
-						processedRow[key] = fmt.Sprintf("%v", v)
-					}
+			sets, truncated, err := scanResultSets(ctx, db, sqlRows)
+			sqlRows.Close()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
 				}
-				processedRows[i] = processedRow
+				return result
 			}
+			if truncated {
+				result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+			}
+
+			// Process the rows to ensure proper type handling, "results" holds the last result set
+			processedRows := make([]map[string]interface{}, 0)
+			for i := range sets {
+				sets[i].Rows = processMySQLRows(sets[i].Rows)
+				processedRows = sets[i].Rows
+				result.Columns = sets[i].Types
+			}
+			resultSets = append(resultSets, sets...)
 
 			result.Result = map[string]interface{}{
 				"results": processedRows,
 			}
+			result.Warnings = append(result.Warnings, t.fetchWarnings(ctx)...)
 		} else {
 			// For other queries (INSERT, UPDATE, DELETE, etc.), execute and return affected rows
 			execResult := t.tx.WithContext(ctx).Exec(stmt)
@@ -110,6 +108,8 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 				return result
 			}
 
+			result.Warnings = append(result.Warnings, t.fetchWarnings(ctx)...)
+
 			rowsAffected := execResult.RowsAffected
 			if rowsAffected > 0 {
 				result.Result = map[string]interface{}{
@@ -124,6 +124,8 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 		}
 	}
 
+	addResultSets(result, resultSets)
+
 	// Calculate execution time
 	executionTime := int(time.Since(startTime).Milliseconds())
 	result.ExecutionTime = executionTime
@@ -145,11 +147,36 @@ func (t *MySQLTransaction) ExecuteQuery(ctx context.Context, conn *Connection, q
 	return result
 }
 
+// fetchWarnings returns the warnings raised by the last statement, MySQL only reports them through SHOW WARNINGS.
+// It runs inside the transaction, on the same connection as the statement, so warnings of other chats are never read.
+func (t *MySQLTransaction) fetchWarnings(ctx context.Context) []string {
+	var rows []map[string]interface{}
+	if err := t.tx.WithContext(ctx).Raw("SHOW WARNINGS").Scan(&rows).Error; err != nil {
+		log.Printf("MySQLTransaction -> fetchWarnings -> Failed to fetch warnings: %v", err)
+		return nil
+	}
+
+	warnings := make([]string, 0, len(rows))
+	for _, row := range rows {
+		warnings = append(warnings, fmt.Sprintf("%s %s: %s",
+			mysqlWarningField(row["Level"]), mysqlWarningField(row["Code"]), mysqlWarningField(row["Message"])))
+	}
+	return warnings
+}
+
+func mysqlWarningField(val interface{}) string {
+	if b, ok := val.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
 // Commit commits the transaction
 func (t *MySQLTransaction) Commit() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to commit")
 	}
+	t.finish()
 	return t.tx.Commit().Error
 }
 
@@ -158,5 +185,6 @@ func (t *MySQLTransaction) Rollback() error {
 	if t.tx == nil {
 		return fmt.Errorf("no active transaction to rollback")
 	}
+	t.finish()
 	return t.tx.Rollback().Error
 }
@@ -1,6 +1,9 @@
 package dbmanager
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // MySQL schema structures
 type MySQLSchema struct {
@@ -93,3 +96,28 @@ func splitMySQLStatements(query string) []string {
 
 	return statements
 }
+
+// processMySQLRows converts the values scanned from MySQL to JSON friendly types, bytes are converted to strings &
+// types other than strings, numbers, booleans & nulls are formatted
+func processMySQLRows(rows []map[string]interface{}) []map[string]interface{} {
+	processedRows := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		processedRow := make(map[string]interface{})
+		for key, val := range row {
+			// Handle different types properly
+			switch v := val.(type) {
+			case []byte:
+				// Convert []byte to string
+				processedRow[key] = string(v)
+			case string, float64, int64, bool, nil:
+				// Keep strings, numbers, booleans & nulls as is
+				processedRow[key] = v
+			default:
+				// For other types, convert to string
+				processedRow[key] = fmt.Sprintf("%v", v)
+			}
+		}
+		processedRows[i] = processedRow
+	}
+	return processedRows
+}
@@ -0,0 +1,118 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// namedConnectionSeparator separates the chat ID from the name of a named connection in its key, chat IDs are hex
+const namedConnectionSeparator = ":"
+
+type connectionNameKey struct{}
+
+// NamedConnectionKey returns the key a named connection of a chat is connected under, next to the main connection of
+// the chat connected under the chat ID. An empty name is the main connection.
+func NamedConnectionKey(chatID, name string) string {
+	if name == "" {
+		return chatID
+	}
+	return chatID + namedConnectionSeparator + name
+}
+
+// SplitConnectionKey returns the chat ID & the connection name of a connection key, the name is empty for the main
+// connection of the chat
+func SplitConnectionKey(key string) (string, string) {
+	chatID, name, _ := strings.Cut(key, namedConnectionSeparator)
+	return chatID, name
+}
+
+// WithConnectionName makes the queries & the schema lookups of a chat run on one of its named connections, see
+// NamedConnectionKey. An empty name keeps the main connection of the chat.
+func WithConnectionName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, connectionNameKey{}, name)
+}
+
+// ConnectionName returns the named connection set on the context, empty for the main connection of the chat
+func ConnectionName(ctx context.Context) string {
+	name, _ := ctx.Value(connectionNameKey{}).(string)
+	return name
+}
+
+// connectionKey resolves the key of the connection a chat uses with the context, a key already naming a connection is
+// returned as is
+func connectionKey(ctx context.Context, chatID string) string {
+	name := ConnectionName(ctx)
+	if name == "" || strings.Contains(chatID, namedConnectionSeparator) {
+		return chatID
+	}
+	return NamedConnectionKey(chatID, name)
+}
+
+// FormatNamedConnectionSchema formats the schema of a named connection of a chat for the LLM, its tables are qualified
+// by the name of the connection (ex: analytics.orders) to tell them apart from the tables of the main connection
+func (m *Manager) FormatNamedConnectionSchema(ctx context.Context, chatID, name string) (string, error) {
+	key := NamedConnectionKey(chatID, name)
+	m.mu.RLock()
+	conn, exists := m.connections[key]
+	m.mu.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("connection %s not found for chat ID: %s", name, chatID)
+	}
+
+	db, err := m.GetConnection(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get database executor: %v", err)
+	}
+
+	storage, err := m.schemaManager.GetSchemaWithExamples(ctx, key, db, conn.Config.Type, []string{"ALL"})
+	if err != nil {
+		return "", fmt.Errorf("failed to get schema with examples: %v", err)
+	}
+	storage = m.schemaManager.maskExampleRecords(key, storage)
+	storage = m.schemaManager.prioritizeLLMSchema(key, storage)
+
+	log.Printf("DBManager -> FormatNamedConnectionSchema -> Formatting the schema of connection %s for chatID: %s", name, chatID)
+	formatted := m.schemaManager.FormatSchemaForLLMWithExamples(qualifySchemaStorage(storage, name))
+	return strings.TrimPrefix(formatted, "Current Database Schema:\n\n"), nil
+}
+
+// qualifySchemaStorage returns a copy of a schema with its table names prefixed by the name of their connection
+func qualifySchemaStorage(storage *SchemaStorage, name string) *SchemaStorage {
+	qualify := func(table string) string {
+		return name + "." + table
+	}
+
+	qualified := *storage
+	if storage.LLMSchema != nil {
+		tables := make(map[string]LLMTableInfo, len(storage.LLMSchema.Tables))
+		for tableName, table := range storage.LLMSchema.Tables {
+			table.Name = qualify(table.Name)
+			tables[qualify(tableName)] = table
+		}
+		relationships := make([]SchemaRelationship, len(storage.LLMSchema.Relationships))
+		for i, relationship := range storage.LLMSchema.Relationships {
+			relationship.FromTable = qualify(relationship.FromTable)
+			relationship.ToTable = qualify(relationship.ToTable)
+			relationships[i] = relationship
+		}
+		qualified.LLMSchema = &LLMSchemaInfo{Tables: tables, Relationships: relationships}
+	}
+	if storage.FullSchema != nil {
+		fullSchema := *storage.FullSchema
+		fullSchema.Tables = make(map[string]TableSchema, len(storage.FullSchema.Tables))
+		for tableName, table := range storage.FullSchema.Tables {
+			foreignKeys := make(map[string]ForeignKey, len(table.ForeignKeys))
+			for fkName, fk := range table.ForeignKeys {
+				fk.RefTable = qualify(fk.RefTable)
+				foreignKeys[fkName] = fk
+			}
+			table.Name = qualify(table.Name)
+			table.ForeignKeys = foreignKeys
+			fullSchema.Tables[qualify(tableName)] = table
+		}
+		qualified.FullSchema = &fullSchema
+	}
+	return &qualified
+}
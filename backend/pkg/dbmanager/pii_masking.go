@@ -0,0 +1,163 @@
+package dbmanager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// piiEmailRegex matches a value that is an email address
+var piiEmailRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// piiPhoneRegex matches a value made of the characters of a phone number (ex: +1 (555) 123-4567), the number of
+// digits is checked apart so that dates & plain numbers aren't taken for phone numbers
+var piiPhoneRegex = regexp.MustCompile(`^\+?[\d\s().-]+$`)
+
+// PIIMasker masks the personal data of the example records shared with the LLM: the values of the columns whose name
+// matches a pattern (ex: email, phone, password) & the values that look like an email, a phone number or a card
+// number whatever their column. Only the values are masked, the columns keep their name.
+type PIIMasker struct {
+	columnPatterns []*regexp.Regexp
+}
+
+// NewPIIMasker compiles the column name patterns, each a case insensitive regular expression matched anywhere in the
+// name (ex: "email" matches customer_email)
+func NewPIIMasker(columnPatterns []string) (*PIIMasker, error) {
+	masker := &PIIMasker{}
+	for _, pattern := range columnPatterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		compiled, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII column pattern %q: %v", pattern, err)
+		}
+		masker.columnPatterns = append(masker.columnPatterns, compiled)
+	}
+	return masker, nil
+}
+
+// SetPIIMasking masks the personal data of the example records fetched from now on, see PIIMasker. The schemas stored
+// before keep their example records until they are fetched again.
+func (m *Manager) SetPIIMasking(enabled bool, columnPatterns []string) error {
+	var masker *PIIMasker
+	if enabled {
+		var err error
+		if masker, err = NewPIIMasker(columnPatterns); err != nil {
+			return err
+		}
+	}
+	m.schemaManager.mu.Lock()
+	defer m.schemaManager.mu.Unlock()
+	m.schemaManager.piiMasker = masker
+	return nil
+}
+
+// ApplyToRecords masks the personal data of records & of their nested documents, the records aren't modified
+func (pm *PIIMasker) ApplyToRecords(records []map[string]interface{}) []map[string]interface{} {
+	if pm == nil || len(records) == 0 {
+		return records
+	}
+	masked := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		masked[i] = pm.applyToRecord(record, false)
+	}
+	return masked
+}
+
+// applyToRecord masks the values of a record, every value of a document nested in a PII column is masked
+func (pm *PIIMasker) applyToRecord(record map[string]interface{}, piiColumn bool) map[string]interface{} {
+	masked := make(map[string]interface{}, len(record))
+	for column, value := range record {
+		masked[column] = pm.applyToValue(value, piiColumn || pm.isPIIColumn(column))
+	}
+	return masked
+}
+
+// applyToValue masks a value of a PII column, or a value that looks like personal data
+func (pm *PIIMasker) applyToValue(value interface{}, piiColumn bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return pm.applyToRecord(v, piiColumn)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = pm.applyToValue(item, piiColumn)
+		}
+		return masked
+	case nil:
+		return nil
+	}
+
+	str, isString := value.(string)
+	switch {
+	case isString && piiEmailRegex.MatchString(str):
+		return MaskValue(str, MaskFormatEmailLocal)
+	case piiColumn:
+		return MaskValue(value, MaskFormatFull)
+	case isString && (isPhoneNumber(str) || isCardNumber(str)):
+		return MaskValue(str, MaskFormatLast4)
+	}
+	return value
+}
+
+func (pm *PIIMasker) isPIIColumn(column string) bool {
+	for _, pattern := range pm.columnPatterns {
+		if pattern.MatchString(column) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPhoneNumber checks if a value looks like a phone number: 9 to 15 digits written with the characters of a phone
+// number & at least one space, dash, parenthesis or a leading +, a bare number is more likely an ID or an amount
+func isPhoneNumber(value string) bool {
+	value = strings.TrimSpace(value)
+	if !piiPhoneRegex.MatchString(value) || !strings.ContainsAny(value, "+ -()") {
+		return false
+	}
+	digits := countDigits(value)
+	return digits >= 9 && digits <= 15
+}
+
+// isCardNumber checks if a value is a payment card number: 13 to 19 digits, optionally grouped with spaces or dashes,
+// passing the Luhn checksum
+func isCardNumber(value string) bool {
+	digits := make([]int, 0, len(value))
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r == ' ' || r == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+func countDigits(value string) int {
+	count := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			count++
+		}
+	}
+	return count
+}
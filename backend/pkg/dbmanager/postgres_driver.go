@@ -3,6 +3,7 @@ package dbmanager
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"databot-ai/internal/apis/dtos"
 	"databot-ai/internal/utils"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -31,13 +33,16 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 		"host=%s port=%s user=%s dbname=%s",
 		config.Host,
 		*config.Port, // Dereference the port pointer
-		*config.Username,
-		config.Database,
+		postgresDSNValue(*config.Username),
+		postgresDSNValue(config.Database),
 	)
 
+	// Tag the connections so that ListServerActivity only lists DataBot's queries
+	baseParams += " application_name=" + connectionApplicationName
+
 	// Add password if provided
 	if config.Password != nil {
-		baseParams += fmt.Sprintf(" password=%s", *config.Password)
+		baseParams += " password=" + postgresDSNValue(*config.Password)
 	}
 
 	// Configure SSL/TLS
@@ -80,8 +85,8 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 
 	dsn = baseParams
 
-	// Open connection
-	db, err := sql.Open("postgres", dsn)
+	// Open connection, assuming the role of the chat if any
+	db, err := openPostgres(dsn, config.Role)
 	if err != nil {
 		// Clean up temporary files
 		for _, file := range tempFiles {
@@ -101,9 +106,7 @@ func (d *PostgresDriver) Connect(config ConnectionConfig) (*Connection, error) {
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(time.Hour)
+	applySQLPoolSettings(db, config)
 
 	// Create GORM DB
 	gormDB, err := gorm.Open(postgres.New(postgres.Config{
@@ -218,7 +221,8 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 	// Process results from the last statement if it returned rows
 	var result *QueryExecutionResult
 	if lastResult != nil {
-		results, err := processRows(lastResult, startTime)
+		columns := sqlResultColumns(lastResult)
+		results, truncated, err := processRows(lastResult, startTime, resultRowLimit(ctx), resultSizeOf(ctx))
 		if err != nil {
 			return &QueryExecutionResult{
 				ExecutionTime: int(time.Since(startTime).Milliseconds()),
@@ -234,6 +238,10 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 			Result: map[string]interface{}{
 				"results": results,
 			},
+			Columns: columns,
+		}
+		if truncated {
+			result.Warnings = []string{resultTruncatedWarning(resultRowLimit(ctx))}
 		}
 	} else {
 		result = &QueryExecutionResult{
@@ -247,7 +255,7 @@ func (d *PostgresDriver) ExecuteQuery(ctx context.Context, conn *Connection, que
 	return result
 }
 
-func (d *PostgresDriver) BeginTx(ctx context.Context, conn *Connection) Transaction {
+func (d *PostgresDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
 	log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Starting transaction")
 
 	if conn == nil || conn.DB == nil {
@@ -261,17 +269,58 @@ func (d *PostgresDriver) BeginTx(ctx context.Context, conn *Connection) Transact
 		return nil
 	}
 
-	tx, err := sqlDB.BeginTx(ctx, nil)
-	if err != nil {
-		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to begin transaction: %v", err)
+	// The transaction runs on a connection of its own so that only its notices (RAISE NOTICE/WARNING) are collected,
+	// in session mode that's the dedicated connection so that temp tables & variables persist
+	var sqlConn *sql.Conn
+	releaseConn := false
+	if session != nil {
+		sqlConn = session.Conn
+	} else {
+		sqlConn, err = sqlDB.Conn(ctx)
+		if err != nil {
+			log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to get a connection from the pool: %v", err)
+			return nil
+		}
+		releaseConn = true
+	}
+
+	transaction := &PostgresTransaction{
+		conn:        conn,
+		sqlConn:     sqlConn,
+		releaseConn: releaseConn,
+		warnings:    &DriverWarnings{},
+	}
+	if err := sqlConn.Raw(func(driverConn interface{}) error {
+		pqConn, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection type %T", driverConn)
+		}
+		pq.SetNoticeHandler(pqConn, func(notice *pq.Error) {
+			transaction.warnings.Add(fmt.Sprintf("%s: %s", notice.Severity, notice.Message))
+		})
 		return nil
+	}); err != nil {
+		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to set the notice handler: %v", err)
 	}
 
-	// Pass connection to transaction
-	return &PostgresTransaction{
-		tx:   tx,
-		conn: conn,
+	// The backend PID of the connection, a cancelled query is cancelled on the server from another connection
+	var backendPID int64
+	if err := sqlConn.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&backendPID); err != nil {
+		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to get the backend PID: %v", err)
+	} else {
+		transaction.serverQuery.cancel = func(ctx context.Context) error {
+			_, err := sqlDB.ExecContext(ctx, "SELECT pg_cancel_backend($1)", backendPID)
+			return err
+		}
+	}
+
+	transaction.tx, err = sqlConn.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("PostgreSQL/YugabyteDB Driver -> BeginTx -> Failed to begin transaction: %v", err)
+		transaction.release()
+		return nil
 	}
+	return transaction
 }
 
 // Improve the GetSchema method to properly detect all tables
@@ -0,0 +1,147 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"databot-ai/internal/constants"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// postgresRoleConnector opens PostgreSQL connections that assume a role with SET ROLE, every connection of the pool
+// runs its queries with the permissions of the role. A reconnection opens new connections, they start without the
+// role of the previous ones & assume the role again.
+type postgresRoleConnector struct {
+	driver.Connector
+	role string
+}
+
+func (c *postgresRoleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set role %s: the connection can't execute statements", c.role)
+	}
+	if _, err := execer.ExecContext(ctx, "SET ROLE "+pq.QuoteIdentifier(c.role), nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set role %s: %v", c.role, err)
+	}
+	return conn, nil
+}
+
+// SessionRoleSupported tells whether the queries of a connection can run as a role, see openPostgres
+func SessionRoleSupported(dbType string) bool {
+	return dbType == constants.DatabaseTypePostgreSQL || dbType == constants.DatabaseTypeYugabyteDB
+}
+
+// openPostgres opens a PostgreSQL/YugabyteDB connection pool, each connection assumes role when it is set. A role the
+// user isn't a member of fails the first connection, so the Ping of the pool.
+func openPostgres(dsn, role string) (*sql.DB, error) {
+	if role == "" {
+		return sql.Open("postgres", dsn)
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&postgresRoleConnector{Connector: connector, role: role}), nil
+}
+
+// ChangesSessionRole tells whether a SQL query changes the role of the session (ex: RESET ROLE, SET SESSION
+// AUTHORIZATION or set_config('role', ...)), a chat assuming a role must not leave it for the permissions of the user.
+// Setting names may be quoted (SET "role" TO ...). DO blocks & dynamic EXECUTE run statements hidden in strings, they
+// are considered role changes as what they run can't be checked.
+func ChangesSessionRole(query string) bool {
+	tokens := tokenizeSQL(query)
+	statementStart := true
+	for i, token := range tokens {
+		if token.kind == sqlTokenPunct && (token.text == ";" || token.text == "$") {
+			// The statements of a function body start after its $$ or $tag$ quote
+			statementStart = true
+			continue
+		}
+		if !isSQLName(token) {
+			statementStart = false
+			continue
+		}
+		if token.value == "set_config" && i+2 < len(tokens) && tokens[i+1].text == "(" {
+			switch strings.TrimSpace(strings.Trim(tokens[i+2].value, "'")) {
+			case "role", "session_authorization":
+				return true
+			}
+		}
+		if token.kind == sqlTokenWord && token.value == "execute" && isDynamicExecute(tokens[i+1:]) {
+			return true
+		}
+		switch token.value {
+		case "begin", "then", "else", "loop":
+			// Statements of a PL/pgSQL block
+			statementStart = true
+			continue
+		}
+		if !statementStart {
+			continue
+		}
+		statementStart = false
+
+		var words []string
+		for _, next := range tokens[i:min(i+4, len(tokens))] {
+			if !isSQLName(next) {
+				break
+			}
+			words = append(words, next.value)
+		}
+		if words[0] == "do" && token.kind == sqlTokenWord {
+			return true
+		}
+		if len(words) < 2 {
+			continue
+		}
+		switch words[0] {
+		case "set", "reset":
+			// SET SESSION ROLE & SET LOCAL ROLE are SET ROLE, SESSION AUTHORIZATION keeps its SESSION
+			setting := words[1:]
+			if (setting[0] == "session" || setting[0] == "local") && len(setting) > 1 && setting[1] != "authorization" {
+				setting = setting[1:]
+			}
+			if setting[0] == "role" || setting[0] == "session_authorization" || (setting[0] == "all" && words[0] == "reset") {
+				return true
+			}
+			if len(setting) > 1 && setting[0] == "session" && setting[1] == "authorization" {
+				return true
+			}
+		case "discard":
+			if words[1] == "all" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSQLName tells whether a token is a keyword or a name, quoted or not
+func isSQLName(token sqlToken) bool {
+	return token.kind == sqlTokenWord || token.kind == sqlTokenQuotedIdent
+}
+
+// isDynamicExecute tells whether the EXECUTE followed by next runs a statement, GRANT EXECUTE ON & EXECUTE FUNCTION
+// (of a trigger) don't
+func isDynamicExecute(next []sqlToken) bool {
+	if len(next) == 0 {
+		return false
+	}
+	if next[0].kind == sqlTokenWord {
+		switch next[0].value {
+		case "on", "function", "procedure":
+			return false
+		}
+	}
+	return true
+}
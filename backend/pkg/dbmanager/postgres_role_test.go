@@ -0,0 +1,48 @@
+package dbmanager
+
+import "testing"
+
+func TestChangesSessionRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"select", "SELECT * FROM orders", false},
+		{"update of a role column", "UPDATE users SET role = 'admin' WHERE id = 1", false},
+		{"role in a string", "SELECT 'SET ROLE postgres'", false},
+		{"parameter", "SELECT * FROM orders WHERE id = $1", false},
+		{"set role", "SET ROLE postgres", true},
+		{"set role lower case", "set role postgres", true},
+		{"set session role", "SET SESSION ROLE postgres", true},
+		{"set local role", "SET LOCAL ROLE postgres", true},
+		{"set role equals", "SET role = postgres", true},
+		{"reset role", "RESET ROLE", true},
+		{"reset all", "RESET ALL", true},
+		{"discard all", "DISCARD ALL", true},
+		{"set session authorization", "SET SESSION AUTHORIZATION postgres", true},
+		{"quoted role", `SET "role" TO postgres`, true},
+		{"quoted session authorization", `SET "session_authorization" TO postgres`, true},
+		{"reset quoted role", `RESET "role"`, true},
+		{"set_config", "SELECT set_config('role', 'postgres', false)", true},
+		{"set_config session authorization", "SELECT set_config('session_authorization', 'postgres', false)", true},
+		{"set_config other setting", "SELECT set_config('search_path', 'public', false)", false},
+		{"second statement", "SELECT 1; RESET ROLE", true},
+		{"after a comment", "/* cleanup */ RESET ROLE", true},
+		{"do block", "DO $$BEGIN EXECUTE 'RESET ROLE'; END$$", true},
+		{"do block without execute", "DO $$BEGIN RAISE NOTICE 'hello'; END$$", true},
+		{"function body", "CREATE FUNCTION f() RETURNS void AS $$ RESET ROLE; $$ LANGUAGE sql", true},
+		{"function body with execute", "CREATE FUNCTION f() RETURNS void AS $$BEGIN EXECUTE format('SET ROLE %I', 'postgres'); END$$ LANGUAGE plpgsql", true},
+		{"prepared statement", "EXECUTE fetch_orders(1)", true},
+		{"grant execute", "GRANT EXECUTE ON FUNCTION f() TO analyst", false},
+		{"trigger function", "CREATE TRIGGER t AFTER INSERT ON orders FOR EACH ROW EXECUTE FUNCTION audit()", false},
+		{"on conflict do nothing", "INSERT INTO orders (id) VALUES (1) ON CONFLICT DO NOTHING", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChangesSessionRole(tt.query); got != tt.want {
+				t.Errorf("ChangesSessionRole(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -125,15 +125,18 @@ func (f *PostgresSchemaFetcher) fetchColumns(_ context.Context, table string) (m
 		IsNullable   string `db:"is_nullable"`
 		DefaultValue string `db:"column_default"`
 		Comment      string `db:"column_comment"`
+		Collation    string `db:"collation_name"`
 	}
 
+	// collation_name is only set for columns with an explicit collation, the others use the database default
 	query := `
         SELECT 
             column_name,
             data_type,
             is_nullable,
             column_default,
-            col_description((table_schema || '.' || table_name)::regclass::oid, ordinal_position) as column_comment
+            col_description((table_schema || '.' || table_name)::regclass::oid, ordinal_position) as column_comment,
+            collation_name
         FROM information_schema.columns c
         WHERE table_schema = 'public'
         AND table_name = $1
@@ -151,6 +154,7 @@ func (f *PostgresSchemaFetcher) fetchColumns(_ context.Context, table string) (m
 			IsNullable:   col.IsNullable == "YES",
 			DefaultValue: col.DefaultValue,
 			Comment:      col.Comment,
+			Collation:    col.Collation,
 		}
 	}
 	return columns, nil
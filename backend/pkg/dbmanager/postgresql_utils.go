@@ -23,11 +23,12 @@ func splitStatements(query string) []string {
 	return result
 }
 
-// Update the processRows function signature to return results and error
-func processRows(rows *sql.Rows, startTime time.Time) ([]map[string]interface{}, error) {
+// processRows reads the rows into maps, reading stops after limit rows (0 reads everything) & the second value
+// reports if rows were left unread. Reading stops with errResultTooLarge once the rows pass the byte limit of size.
+func processRows(rows *sql.Rows, startTime time.Time, limit int, size *resultSize) ([]map[string]interface{}, bool, error) {
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %v", err)
+		return nil, false, fmt.Errorf("failed to get columns: %v", err)
 	}
 
 	results := make([]map[string]interface{}, 0)
@@ -39,9 +40,12 @@ func processRows(rows *sql.Rows, startTime time.Time) ([]map[string]interface{},
 	}
 
 	for rows.Next() {
+		if limit > 0 && len(results) >= limit {
+			return results, true, nil
+		}
 		err := rows.Scan(scanArgs...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %v", err)
+			return nil, false, fmt.Errorf("failed to scan row: %v", err)
 		}
 
 		row := make(map[string]interface{})
@@ -60,14 +64,17 @@ func processRows(rows *sql.Rows, startTime time.Time) ([]map[string]interface{},
 				row[col] = v
 			}
 		}
+		if err := size.add(row); err != nil {
+			return nil, false, err
+		}
 		results = append(results, row)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %v", err)
+		return nil, false, fmt.Errorf("error iterating rows: %v", err)
 	}
 
-	return results, nil
+	return results, false, nil
 }
 
 // Fix the extractTableName function to properly handle table names
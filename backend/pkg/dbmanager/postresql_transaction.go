@@ -3,17 +3,26 @@ package dbmanager
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"databot-ai/internal/apis/dtos"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type PostgresTransaction struct {
-	tx   *sql.Tx
-	conn *Connection // Add connection reference
+	tx          *sql.Tx
+	conn        *Connection // Add connection reference
+	sqlConn     *sql.Conn   // Physical connection the transaction runs on
+	releaseConn bool        // Return sqlConn to the pool once done, false for session connections
+	warnings    *DriverWarnings
+	releaseOnce sync.Once
+	serverQuery // Cancels the running statement with pg_cancel_backend
 }
 
 func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
@@ -88,11 +97,13 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 	// Process results
 	result := &QueryExecutionResult{
 		ExecutionTime: int(time.Since(startTime).Milliseconds()),
+		Warnings:      tx.warnings.Drain(),
 	}
 
 	if rows != nil {
 		defer rows.Close()
-		results, err := processRows(rows, startTime)
+		result.Columns = sqlResultColumns(rows)
+		results, truncated, err := processRows(rows, startTime, resultRowLimit(ctx), resultSizeOf(ctx))
 		if err != nil {
 			return &QueryExecutionResult{
 				ExecutionTime: int(time.Since(startTime).Milliseconds()),
@@ -106,6 +117,9 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 		result.Result = map[string]interface{}{
 			"results": results,
 		}
+		if truncated {
+			result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+		}
 	} else if lastResult != nil {
 		rowsAffected, _ := lastResult.RowsAffected()
 		if rowsAffected > 0 {
@@ -138,10 +152,32 @@ func (tx *PostgresTransaction) ExecuteQuery(ctx context.Context, conn *Connectio
 
 func (t *PostgresTransaction) Commit() error {
 	log.Printf("PostgreSQL Transaction -> Commit -> Committing transaction")
+	t.finish()
+	defer t.release()
 	return t.tx.Commit()
 }
 
 func (t *PostgresTransaction) Rollback() error {
 	log.Printf("PostgreSQL Transaction -> Rollback -> Rolling back transaction")
+	t.finish()
+	defer t.release()
 	return t.tx.Rollback()
 }
+
+// release detaches the notice handler of the transaction & returns its connection to the pool
+func (t *PostgresTransaction) release() {
+	t.releaseOnce.Do(func() {
+		if t.sqlConn == nil {
+			return
+		}
+		t.sqlConn.Raw(func(driverConn interface{}) error {
+			if pqConn, ok := driverConn.(driver.Conn); ok {
+				pq.SetNoticeHandler(pqConn, nil)
+			}
+			return nil
+		})
+		if t.releaseConn {
+			t.sqlConn.Close()
+		}
+	})
+}
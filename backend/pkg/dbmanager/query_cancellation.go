@@ -0,0 +1,111 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverCancelTimeout bounds the statement cancelling a running query on the database server
+const serverCancelTimeout = 5 * time.Second
+
+// readQueryTypes are the query types that don't change data, cancelling them is always safe
+var readQueryTypes = map[string]bool{
+	"SELECT":         true,
+	"FIND":           true,
+	"AGGREGATE":      true,
+	"COUNT":          true,
+	"COUNTDOCUMENTS": true,
+	"DISTINCT":       true,
+	"SHOW":           true,
+	"DESCRIBE":       true,
+	"EXPLAIN":        true,
+}
+
+// mysqlImplicitCommitTypes are the MySQL query types committed as soon as they run, a rollback can't undo them
+var mysqlImplicitCommitTypes = map[string]bool{
+	"DDL":      true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"DROP":     true,
+	"TRUNCATE": true,
+	"RENAME":   true,
+}
+
+// isMutationQueryType reports if a query type changes data, unknown types are considered mutations
+func isMutationQueryType(queryType string) bool {
+	return !readQueryTypes[strings.ToUpper(queryType)]
+}
+
+// rollbackUndoesQuery reports if rolling back the transaction of a running query undoes all of its changes, so that
+// it can be cancelled at any time. ClickHouse has no transactions, MySQL DDL & MongoDB collection or index commands
+// are applied immediately.
+func rollbackUndoesQuery(dbType, queryType string) bool {
+	queryType = strings.ToUpper(queryType)
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return true
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return !mysqlImplicitCommitTypes[queryType]
+	case constants.DatabaseTypeMongoDB:
+		return !strings.Contains(queryType, "COLLECTION") && !strings.Contains(queryType, "INDEX")
+	}
+	return false
+}
+
+// IsMutationRunning reports if a query changing data is running for a stream
+func (m *Manager) IsMutationRunning(streamID string) bool {
+	m.executionMu.RLock()
+	defer m.executionMu.RUnlock()
+	execution, exists := m.activeExecutions[streamID]
+	return exists && execution.IsMutation
+}
+
+// serverCancellable is implemented by the transactions whose running statement can be cancelled on the database server.
+// Cancelling the context only stops the client from waiting: the MySQL driver closes its connection & the statement
+// goes on running on the server until it completes.
+type serverCancellable interface {
+	cancelOnServer(ctx context.Context) error
+}
+
+// serverQuery identifies the connection or the query of a transaction on the database server (ex: the PostgreSQL
+// backend PID, the MySQL connection ID), so that its running statement can be cancelled from another connection.
+type serverQuery struct {
+	mu       sync.Mutex
+	cancel   func(ctx context.Context) error // Cancels the running statement, nil if the server connection is unknown
+	finished bool                            // The transaction is done, its connection may run the queries of others
+}
+
+// cancelOnServer cancels the running statement of the transaction, a no-op once the transaction is done
+func (q *serverQuery) cancelOnServer(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.finished || q.cancel == nil {
+		return nil
+	}
+	return q.cancel(ctx)
+}
+
+// finish marks the transaction done before its connection is returned to the pool, a cancel in progress is waited for
+// so that it can't hit a query of another transaction
+func (q *serverQuery) finish() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.finished = true
+}
+
+// cancelOnServer cancels the running statement of a transaction on the database server, if the database supports it
+func cancelOnServer(tx Transaction) {
+	cancellable, ok := tx.(serverCancellable)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverCancelTimeout)
+	defer cancel()
+	if err := cancellable.cancelOnServer(ctx); err != nil {
+		log.Printf("Error cancelling the query on the database server: %v", err)
+	}
+}
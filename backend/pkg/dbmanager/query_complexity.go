@@ -0,0 +1,213 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Complexity scores of a query, see ScoreQueryComplexity
+const (
+	QueryComplexityLow    = "low"
+	QueryComplexityMedium = "medium"
+	QueryComplexityHigh   = "high"
+)
+
+// Row counts above which scanning a table without an indexed filter makes a query more expensive
+const (
+	complexityMediumTableRows int64 = 100000
+	complexityLargeTableRows  int64 = 1000000
+)
+
+var (
+	// mongoFilterFieldRegex matches the fields of the filters & stages of a MongoDB query, ex: "status" in {status: "active"}
+	mongoFilterFieldRegex = regexp.MustCompile(`["']?([A-Za-z_][\w.]*)["']?\s*:`)
+	// mongoMatchRegex matches a filter of a MongoDB query: a find, count or delete filter, or a $match stage
+	mongoMatchRegex = regexp.MustCompile(`\.(?:find|findOne|countDocuments|count|deleteMany|deleteOne|updateMany|updateOne)\(\s*\{\s*["']?\w|\$match["']?\s*:\s*\{\s*["']?\w`)
+	// mongoForeignFieldRegex matches the field a $lookup stage reads the joined collection by
+	mongoForeignFieldRegex = regexp.MustCompile(`foreignField["']?\s*:\s*["']([^"']+)["']`)
+)
+
+// sqlConditionEnds are the keywords ending the conditions of a WHERE or ON clause
+var sqlConditionEnds = map[string]bool{
+	"where": true, "join": true, "inner": true, "left": true, "right": true, "full": true, "cross": true,
+	"natural": true, "group": true, "order": true, "having": true, "limit": true, "offset": true, "fetch": true,
+	"window": true, "qualify": true, "union": true, "intersect": true, "except": true, "returning": true,
+	"for": true, "settings": true, "format": true,
+}
+
+// QueryComplexity is an objective estimate of how expensive a query is to run, from its structure & the schema
+type QueryComplexity struct {
+	Score      string   `json:"score"` // QueryComplexityLow, QueryComplexityMedium or QueryComplexityHigh
+	Joins      int      `json:"joins"`
+	Subqueries int      `json:"subqueries"`
+	Scans      []string `json:"scans,omitempty"`   // Tables read without a filter on an indexed column, with their row count
+	Reasons    []string `json:"reasons,omitempty"` // Why the score isn't low, shown to the user
+}
+
+// GetKnownLLMSchema returns the simplified schema of a chat last stored, with the indexed columns & row counts of its
+// tables, nil if there is none
+func (m *Manager) GetKnownLLMSchema(ctx context.Context, chatID string) *LLMSchemaInfo {
+	chatID = connectionKey(ctx, chatID)
+	if m.schemaManager == nil {
+		return nil
+	}
+	storage, err := m.schemaManager.getStoredSchema(ctx, chatID)
+	if err != nil || storage == nil {
+		return nil
+	}
+	return storage.LLMSchema
+}
+
+// ScoreQueryComplexity scores a query from its JOINs ($lookup stages for MongoDB), subqueries & the tables it reads
+// without filtering on an indexed column (a filter of a WHERE or a join condition, or a MongoDB filter). The scans
+// only count with the schema: the larger the table, the higher the score, so that the user is warned before running
+// an expensive query on a large table. The query is scanned rather than parsed, the score is an indication.
+func ScoreQueryComplexity(dbType, query string, schema *LLMSchemaInfo) QueryComplexity {
+	var complexity QueryComplexity
+	var filterColumns map[string]bool
+	switch {
+	case dbType == constants.DatabaseTypeMongoDB:
+		complexity.Joins = strings.Count(query, "$lookup") + strings.Count(query, "$graphLookup")
+		complexity.Subqueries = strings.Count(query, "$unionWith")
+		filterColumns = mongoFilterColumns(query)
+	case isSQLDialect(dbType):
+		tokens := tokenizeSQL(query)
+		for i, token := range tokens {
+			if token.kind == sqlTokenWord && token.value == "join" {
+				complexity.Joins++
+			}
+			if token.text == "(" && i+1 < len(tokens) && (tokens[i+1].value == "select" || tokens[i+1].value == "with") {
+				complexity.Subqueries++
+			}
+		}
+		filterColumns = sqlFilterColumns(tokens)
+	default:
+		complexity.Score = QueryComplexityLow
+		return complexity
+	}
+
+	points := complexity.Joins + 2*complexity.Subqueries
+	if complexity.Joins >= 3 {
+		complexity.Reasons = append(complexity.Reasons, fmt.Sprintf("joins %d tables", complexity.Joins+1))
+	}
+	if complexity.Subqueries == 1 {
+		complexity.Reasons = append(complexity.Reasons, "has a subquery")
+	} else if complexity.Subqueries > 1 {
+		complexity.Reasons = append(complexity.Reasons, fmt.Sprintf("has %d subqueries", complexity.Subqueries))
+	}
+
+	for _, table := range unindexedScans(dbType, query, schema, filterColumns) {
+		scan := fmt.Sprintf("%s (%d rows)", table.Name, table.RowCount)
+		complexity.Scans = append(complexity.Scans, scan)
+		switch {
+		case table.RowCount >= complexityLargeTableRows:
+			points += 4
+		case table.RowCount >= complexityMediumTableRows:
+			points += 2
+		default:
+			continue
+		}
+		complexity.Reasons = append(complexity.Reasons, fmt.Sprintf("scans %s without filtering on an indexed column", scan))
+	}
+
+	switch {
+	case points >= 4:
+		complexity.Score = QueryComplexityHigh
+	case points >= 2:
+		complexity.Score = QueryComplexityMedium
+	default:
+		complexity.Score = QueryComplexityLow
+	}
+	return complexity
+}
+
+// unindexedScans returns the tables of the schema a query reads without filtering on one of their indexed columns or
+// primary key
+func unindexedScans(dbType, query string, schema *LLMSchemaInfo, filterColumns map[string]bool) []LLMTableInfo {
+	if schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+	tables := make(map[string]LLMTableInfo, len(schema.Tables))
+	for name, table := range schema.Tables {
+		tables[strings.ToLower(name)] = table
+	}
+
+	var scans []LLMTableInfo
+	for _, name := range ReferencedTables(dbType, query) {
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+		indexed := false
+		for _, column := range table.Columns {
+			if column.IsIndexed && filterColumns[strings.ToLower(column.Name)] {
+				indexed = true
+				break
+			}
+		}
+		for _, column := range strings.Split(table.PrimaryKey, ",") {
+			if column = strings.ToLower(strings.TrimSpace(column)); column != "" && filterColumns[column] {
+				indexed = true
+			}
+		}
+		// MongoDB collections are always indexed on _id
+		if dbType == constants.DatabaseTypeMongoDB && filterColumns["_id"] {
+			indexed = true
+		}
+		if !indexed {
+			scans = append(scans, table)
+		}
+	}
+	return scans
+}
+
+// sqlFilterColumns returns the lower cased identifiers of the conditions of the WHERE & ON clauses of a query, at any
+// depth. Qualifiers & functions are returned too, they don't match column names.
+func sqlFilterColumns(tokens []sqlToken) map[string]bool {
+	columns := make(map[string]bool)
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].kind != sqlTokenWord || (tokens[i].value != "where" && tokens[i].value != "on") {
+			continue
+		}
+		depth := 0
+		for j := i + 1; j < len(tokens); j++ {
+			token := tokens[j]
+			if token.text == "(" {
+				depth++
+			} else if token.text == ")" {
+				depth--
+				if depth < 0 {
+					break
+				}
+			} else if token.text == ";" {
+				break
+			}
+			if depth == 0 && token.kind == sqlTokenWord && sqlConditionEnds[token.value] {
+				break
+			}
+			if isIdentifierToken(token) {
+				columns[token.value] = true
+			}
+		}
+	}
+	return columns
+}
+
+// mongoFilterColumns returns the lower cased fields of a MongoDB query when it filters its documents & the fields its
+// $lookup stages join on, empty for a query reading the whole collection
+func mongoFilterColumns(query string) map[string]bool {
+	columns := make(map[string]bool)
+	for _, match := range mongoForeignFieldRegex.FindAllStringSubmatch(query, -1) {
+		columns[strings.ToLower(match[1])] = true
+	}
+	if !mongoMatchRegex.MatchString(query) {
+		return columns
+	}
+	for _, match := range mongoFilterFieldRegex.FindAllStringSubmatch(query, -1) {
+		columns[strings.ToLower(match[1])] = true
+	}
+	return columns
+}
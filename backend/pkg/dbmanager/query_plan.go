@@ -0,0 +1,234 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrQueryNotExplainable is returned for the queries whose plan can't be fetched without side effects, only SELECTs
+// (and WITH queries reading data) and MongoDB finds & aggregations are explained
+var ErrQueryNotExplainable = errors.New("only read queries can be explained, ex: SELECT or find")
+
+// Formats of a query plan
+const (
+	QueryPlanFormatJSON = "json"
+	QueryPlanFormatText = "text"
+)
+
+type queryPlanVerbosityKey struct{}
+
+// QueryPlan is the execution plan of a query as returned by the database
+type QueryPlan struct {
+	Statement     string      // Statement run to fetch the plan, ex: EXPLAIN (FORMAT JSON) SELECT ...
+	Format        string      // QueryPlanFormatJSON or QueryPlanFormatText
+	Plan          interface{} // Decoded JSON plan, or the lines of a text plan
+	Analyzed      bool        // The query was executed, the plan has the actual rows & timings
+	ExecutionTime int         // Milliseconds
+	Warnings      []string
+}
+
+// ExplainSupported checks if the plan of the queries of a database type can be fetched
+func ExplainSupported(dbType string) bool {
+	return dbType == constants.DatabaseTypeMongoDB || isSQLDatabaseType(dbType)
+}
+
+// IsExplainableQuery checks if the plan of a query can be fetched without side effects: a SQL SELECT or WITH query
+// that only reads data, or a MongoDB find or aggregation without $out or $merge
+func IsExplainableQuery(dbType, query string) bool {
+	if !ExplainSupported(dbType) || !IsReadOnlyQuery(dbType, query) {
+		return false
+	}
+	query = strings.TrimSpace(query)
+	if dbType == constants.DatabaseTypeMongoDB {
+		parts := strings.SplitN(query, ".", 3)
+		if len(parts) < 3 {
+			return false
+		}
+		openParenIndex := strings.Index(parts[2], "(")
+		if openParenIndex == -1 {
+			return false
+		}
+		operation := parts[2][:openParenIndex]
+		return operation == "find" || operation == "aggregate"
+	}
+	tokens := tokenizeSQL(query)
+	return len(tokens) > 0 && (tokens[0].value == "select" || tokens[0].value == "with")
+}
+
+// explainStatement builds the statement returning the plan of a SQL query in the syntax of the database, as JSON when
+// the database can. ClickHouse & SQLite have no EXPLAIN ANALYZE, their plan is always estimated & a warning says so.
+func explainStatement(dbType, query string, analyze bool) (string, []string) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	switch dbType {
+	case constants.DatabaseTypeMySQL:
+		// EXPLAIN ANALYZE only has the TREE format (MySQL 8.0.18+)
+		if analyze {
+			return "EXPLAIN ANALYZE " + query, nil
+		}
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	case constants.DatabaseTypeMariaDB:
+		// MariaDB has no EXPLAIN ANALYZE, its ANALYZE statement returns the JSON plan with the actual rows & timings
+		if analyze {
+			return "ANALYZE FORMAT=JSON " + query, nil
+		}
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	case constants.DatabaseTypeClickhouse:
+		var warnings []string
+		if analyze {
+			warnings = append(warnings, "ClickHouse can't execute a query to explain it, the plan only has estimates")
+		}
+		return "EXPLAIN json = 1, indexes = 1 " + query, warnings
+	case constants.DatabaseTypeSQLite:
+		var warnings []string
+		if analyze {
+			warnings = append(warnings, "SQLite can't execute a query to explain it, the plan has no row counts or timings")
+		}
+		return "EXPLAIN QUERY PLAN " + query, warnings
+	}
+	if analyze {
+		return "EXPLAIN (ANALYZE, FORMAT JSON) " + query, nil
+	}
+	return "EXPLAIN (FORMAT JSON) " + query, nil
+}
+
+// withQueryPlanVerbosity makes the MongoDB driver return the plan of a find or aggregation instead of its documents
+func withQueryPlanVerbosity(ctx context.Context, verbosity string) context.Context {
+	return context.WithValue(ctx, queryPlanVerbosityKey{}, verbosity)
+}
+
+// queryPlanVerbosity returns the explain verbosity set on the context, empty if the query must run as usual
+func queryPlanVerbosity(ctx context.Context) string {
+	verbosity, _ := ctx.Value(queryPlanVerbosityKey{}).(string)
+	return verbosity
+}
+
+// ExplainQuery returns the execution plan of a read query. With analyze the query is executed to measure it (EXPLAIN
+// ANALYZE, or the executionStats verbosity of MongoDB), in a read only transaction for SQL databases, its rows are
+// never returned. Queries changing data are refused with ErrQueryNotExplainable before reaching the database.
+func (m *Manager) ExplainQuery(ctx context.Context, chatID, query string, analyze bool) (*QueryPlan, error) {
+	chatID = connectionKey(ctx, chatID)
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+	if !ExplainSupported(conn.Config.Type) {
+		return nil, fmt.Errorf("explaining queries is not supported for %s", conn.Config.Type)
+	}
+	if !IsExplainableQuery(conn.Config.Type, query) {
+		return nil, ErrQueryNotExplainable
+	}
+
+	startTime := time.Now()
+	var plan *QueryPlan
+	if conn.Config.Type == constants.DatabaseTypeMongoDB {
+		driver, exists := m.drivers[conn.Config.Type]
+		if !exists {
+			return nil, fmt.Errorf("no driver found for type: %s", conn.Config.Type)
+		}
+		verbosity := "queryPlanner"
+		if analyze {
+			verbosity = "executionStats"
+		}
+		result := driver.ExecuteQuery(withQueryPlanVerbosity(ctx, verbosity), conn, query, "FIND", false)
+		if result.Error != nil {
+			return nil, fmt.Errorf("%s", result.Error.Message)
+		}
+		plan = &QueryPlan{
+			Statement: fmt.Sprintf("%s.explain(%q)", strings.TrimSuffix(strings.TrimSpace(query), ";"), verbosity),
+			Format:    QueryPlanFormatJSON,
+			Plan:      result.Result,
+		}
+	} else {
+		statement, warnings := explainStatement(conn.Config.Type, query, analyze)
+		var lines []string
+		err := m.StreamQueryRows(ctx, chatID, statement, func([]ExportColumn) error {
+			return nil
+		}, func(row []interface{}) error {
+			// The rows of a SQLite plan are (id, parent, notused, detail), the other plans are a single column
+			if len(row) > 0 && row[len(row)-1] != nil {
+				lines = append(lines, fmt.Sprint(row[len(row)-1]))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		plan = &QueryPlan{
+			Statement: statement,
+			Warnings:  warnings,
+		}
+		plan.Format, plan.Plan = decodeQueryPlan(lines)
+	}
+	// ClickHouse & SQLite never execute the query
+	plan.Analyzed = analyze && conn.Config.Type != constants.DatabaseTypeClickhouse && conn.Config.Type != constants.DatabaseTypeSQLite
+	plan.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	log.Printf("DBManager -> ExplainQuery -> Explained query of chatID: %s in %d ms, analyze: %v", chatID, plan.ExecutionTime, plan.Analyzed)
+	return plan, nil
+}
+
+// decodeQueryPlan decodes the rows of an EXPLAIN, a JSON plan may be split over several rows (ClickHouse), any other
+// plan is returned as its lines
+func decodeQueryPlan(rows []string) (string, interface{}) {
+	joined := strings.Join(rows, "\n")
+	var plan interface{}
+	if err := json.Unmarshal([]byte(joined), &plan); err == nil {
+		return QueryPlanFormatJSON, plan
+	}
+	lines := strings.Split(strings.TrimRight(joined, "\n"), "\n")
+	return QueryPlanFormatText, lines
+}
+
+// explainMongoFind returns the plan of a find with its options
+func explainMongoFind(ctx context.Context, collection *mongo.Collection, filter interface{}, findOptions *options.FindOptions, verbosity string) (map[string]interface{}, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	command := bson.D{{Key: "find", Value: collection.Name()}, {Key: "filter", Value: filter}}
+	if findOptions.Projection != nil {
+		command = append(command, bson.E{Key: "projection", Value: findOptions.Projection})
+	}
+	if findOptions.Sort != nil {
+		command = append(command, bson.E{Key: "sort", Value: findOptions.Sort})
+	}
+	if findOptions.Skip != nil {
+		command = append(command, bson.E{Key: "skip", Value: *findOptions.Skip})
+	}
+	if findOptions.Limit != nil {
+		command = append(command, bson.E{Key: "limit", Value: *findOptions.Limit})
+	}
+	if findOptions.Collation != nil {
+		command = append(command, bson.E{Key: "collation", Value: findOptions.Collation.ToDocument()})
+	}
+	return runMongoExplain(ctx, collection, command, verbosity)
+}
+
+// explainMongoAggregate returns the plan of an aggregation
+func explainMongoAggregate(ctx context.Context, collection *mongo.Collection, pipeline interface{}, collation *options.Collation, verbosity string) (map[string]interface{}, error) {
+	command := bson.D{{Key: "aggregate", Value: collection.Name()}, {Key: "pipeline", Value: pipeline}, {Key: "cursor", Value: bson.M{}}}
+	if collation != nil {
+		command = append(command, bson.E{Key: "collation", Value: collation.ToDocument()})
+	}
+	return runMongoExplain(ctx, collection, command, verbosity)
+}
+
+func runMongoExplain(ctx context.Context, collection *mongo.Collection, command bson.D, verbosity string) (map[string]interface{}, error) {
+	var plan bson.M
+	err := collection.Database().RunCommand(ctx, bson.D{{Key: "explain", Value: command}, {Key: "verbosity", Value: verbosity}}).Decode(&plan)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}(plan), nil
+}
@@ -0,0 +1,142 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis key prefixes of the cached read query results. A result is stored under a hash of the chat, the generation of
+// its cache & the query, invalidating the cache of a chat changes its generation so that its results aren't found anymore.
+const (
+	queryResultKeyPrefix           = "query_result:"
+	queryResultGenerationKeyPrefix = "query_result_generation:"
+)
+
+// SetQueryResultCacheTTL sets how long the result of a read query is reused for an identical query of the chat, 0
+// disables the cache
+func (m *Manager) SetQueryResultCacheTTL(ttl time.Duration) {
+	m.queryResultCacheTTL = ttl
+}
+
+// QueryResultCacheEnabled checks if the results of the read queries are cached
+func (m *Manager) QueryResultCacheEnabled() bool {
+	return m.queryResultCacheTTL > 0 && m.redisRepo != nil
+}
+
+// QueryResultCacheKey returns the key of the cached result of a read query at an offset, empty if the cache is
+// disabled. The key is read before executing the query so that a result read before a DML of the chat isn't cached
+// after it invalidated the cache.
+func (m *Manager) QueryResultCacheKey(ctx context.Context, chatID, query, queryType string, offset int, findCount bool) string {
+	if !m.QueryResultCacheEnabled() {
+		return ""
+	}
+	chatID = connectionKey(ctx, chatID)
+	var generation string
+	if value, err := m.redisRepo.Get(queryResultGenerationKeyPrefix+chatID, ctx); err == nil {
+		generation = string(value)
+	} else if !strings.Contains(err.Error(), "key does not exist") && !strings.Contains(err.Error(), "redis: nil") {
+		// Without its generation, a result could outlive an invalidation
+		log.Printf("DBManager -> QueryResultCacheKey -> Error reading the cache generation: %v", err)
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%t\x00%s",
+		chatID, generation, strings.ToUpper(queryType), offset, findCount, normalizeCachedQuery(query))))
+	return hex.EncodeToString(hash[:])
+}
+
+// GetCachedQueryResult returns the result cached for a key, the second value reports a hit. Results are encrypted
+// like the schemas since they hold the data of the database.
+func (m *Manager) GetCachedQueryResult(ctx context.Context, key string) (*QueryExecutionResult, bool) {
+	if key == "" || !m.QueryResultCacheEnabled() || m.schemaManager == nil {
+		return nil, false
+	}
+	encrypted, err := m.redisRepo.Get(queryResultKeyPrefix+key, ctx)
+	if err != nil {
+		if !strings.Contains(err.Error(), "key does not exist") && !strings.Contains(err.Error(), "redis: nil") {
+			log.Printf("DBManager -> GetCachedQueryResult -> Error reading the cached result: %v", err)
+		}
+		return nil, false
+	}
+	data, err := m.schemaManager.storageService.encryption.Decrypt(string(encrypted))
+	if err != nil {
+		log.Printf("DBManager -> GetCachedQueryResult -> Error decrypting the cached result: %v", err)
+		return nil, false
+	}
+	var result QueryExecutionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		log.Printf("DBManager -> GetCachedQueryResult -> Error decoding the cached result: %v", err)
+		return nil, false
+	}
+	result.Cached = true
+	return &result, true
+}
+
+// CacheQueryResult stores a successful result under its key for the configured TTL, a failure only loses the cache entry
+func (m *Manager) CacheQueryResult(ctx context.Context, key string, result *QueryExecutionResult) {
+	if key == "" || result == nil || result.Error != nil || !m.QueryResultCacheEnabled() || m.schemaManager == nil {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("DBManager -> CacheQueryResult -> Error encoding the result: %v", err)
+		return
+	}
+	encrypted, err := m.schemaManager.storageService.encryption.Encrypt(data)
+	if err != nil {
+		log.Printf("DBManager -> CacheQueryResult -> Error encrypting the result: %v", err)
+		return
+	}
+	if err := m.redisRepo.Set(queryResultKeyPrefix+key, []byte(encrypted), m.queryResultCacheTTL, ctx); err != nil {
+		log.Printf("DBManager -> CacheQueryResult -> Error caching the result: %v", err)
+	}
+}
+
+// InvalidateQueryResultCache drops the cached results of a chat, called once its data may have changed. The
+// generation expires with the results cached before it, a chat without one has its results under an empty generation.
+func (m *Manager) InvalidateQueryResultCache(ctx context.Context, chatID string) {
+	if !m.QueryResultCacheEnabled() {
+		return
+	}
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := m.redisRepo.Set(queryResultGenerationKeyPrefix+chatID, []byte(generation), m.queryResultCacheTTL, ctx); err != nil {
+		log.Printf("DBManager -> InvalidateQueryResultCache -> Error invalidating the cached results of chat %s: %v", chatID, err)
+	}
+}
+
+// normalizeCachedQuery makes the formatting of a query irrelevant to its cache key, the runs of whitespace outside of
+// the quoted strings & identifiers are collapsed and the trailing semicolons dropped
+func normalizeCachedQuery(query string) string {
+	var normalized strings.Builder
+	var quote rune
+	pendingSpace := false
+	for _, r := range strings.TrimSpace(query) {
+		if quote != 0 {
+			normalized.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			pendingSpace = true
+			continue
+		case '\'', '"', '`':
+			quote = r
+		}
+		if pendingSpace && normalized.Len() > 0 {
+			normalized.WriteByte(' ')
+		}
+		pendingSpace = false
+		normalized.WriteRune(r)
+	}
+	return strings.TrimRight(normalized.String(), "; ")
+}
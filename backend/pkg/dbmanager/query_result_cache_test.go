@@ -0,0 +1,30 @@
+package dbmanager
+
+import "testing"
+
+func TestNormalizeCachedQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"unchanged", "SELECT * FROM orders", "SELECT * FROM orders"},
+		{"surrounding whitespace", "  SELECT * FROM orders\n", "SELECT * FROM orders"},
+		{"whitespace runs", "SELECT  *\n\tFROM   orders", "SELECT * FROM orders"},
+		{"trailing semicolon", "SELECT * FROM orders;", "SELECT * FROM orders"},
+		{"trailing semicolons & spaces", "SELECT * FROM orders ; ;", "SELECT * FROM orders"},
+		{"case is kept", "select * from Orders", "select * from Orders"},
+		{"string", "SELECT * FROM orders WHERE note = 'a   b'", "SELECT * FROM orders WHERE note = 'a   b'"},
+		{"escaped quote", "SELECT 'it''s   here'  FROM orders", "SELECT 'it''s   here' FROM orders"},
+		{"quoted identifier", `SELECT "order  id" FROM orders`, `SELECT "order  id" FROM orders`},
+		{"backquoted identifier", "SELECT `order  id`   FROM orders", "SELECT `order  id` FROM orders"},
+		{"semicolon in a string", "SELECT ';'", "SELECT ';'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeCachedQuery(tt.query); got != tt.want {
+				t.Errorf("normalizeCachedQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// QueryTemplate is a reusable CTE defined on a connection (ex: a standard "active_accounts" definition).
+// Templates are shared with the LLM, the AutoPrepend ones are also added to the WITH clause of generated
+// queries referencing them so that the LLM doesn't have to repeat their definition.
+type QueryTemplate struct {
+	Name        string
+	Query       string
+	Description string
+	AutoPrepend bool
+}
+
+var queryTemplateNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateQueryTemplates checks that every template is a single SELECT, that the names are unique identifiers not
+// shadowing a table & that the referenced tables exist in the known schema of the chat. A template may reference
+// the templates defined before it.
+func (sm *SchemaManager) ValidateQueryTemplates(ctx context.Context, chatID, dbType string, templates []QueryTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	if !isSQLDatabaseType(dbType) {
+		return fmt.Errorf("query templates are not supported for %s", dbType)
+	}
+
+	schema := sm.getKnownSchema(ctx, chatID)
+	if schema == nil {
+		return fmt.Errorf("the schema of the database isn't known yet, connect to the database before adding query templates")
+	}
+	known := make(map[string]bool, len(schema.Tables)+len(schema.Views))
+	for name := range schema.Tables {
+		known[strings.ToLower(name)] = true
+	}
+	for name := range schema.Views {
+		known[strings.ToLower(name)] = true
+	}
+	return validateQueryTemplates(templates, known)
+}
+
+// ValidateQueryTemplateDefinitions checks the templates like ValidateQueryTemplates without a schema, the tables they
+// read aren't checked. Used for the templates of an imported chat, whose schema isn't fetched yet.
+func ValidateQueryTemplateDefinitions(dbType string, templates []QueryTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	if !isSQLDatabaseType(dbType) {
+		return fmt.Errorf("query templates are not supported for %s", dbType)
+	}
+	return validateQueryTemplates(templates, nil)
+}
+
+// validateQueryTemplates checks the names & queries of templates, known holds the tables & views of the schema, nil
+// skips the checks needing the schema
+func validateQueryTemplates(templates []QueryTemplate, known map[string]bool) error {
+	defined := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		name := strings.ToLower(template.Name)
+		switch {
+		case !queryTemplateNameRegex.MatchString(template.Name):
+			return fmt.Errorf("invalid template name %q, use letters, digits & underscores", template.Name)
+		case sqlClauseKeywords[name]:
+			return fmt.Errorf("invalid template name %q, it is a SQL keyword", template.Name)
+		case known[name]:
+			return fmt.Errorf("template name %q shadows an existing table", template.Name)
+		case defined[name]:
+			return fmt.Errorf("duplicate template name %q", template.Name)
+		}
+
+		if err := validateTemplateQuery(template.Query, known, defined); err != nil {
+			return fmt.Errorf("invalid template %q: %v", template.Name, err)
+		}
+		defined[name] = true
+	}
+	return nil
+}
+
+// validateTemplateQuery checks that a template is a single SELECT only reading known tables, views or templates, any
+// table is accepted if known is nil
+func validateTemplateQuery(query string, known, templates map[string]bool) error {
+	tokens := tokenizeSQL(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if len(tokens) == 0 {
+		return fmt.Errorf("query is empty")
+	}
+	if tokens[0].value != "select" && tokens[0].value != "with" {
+		return fmt.Errorf("only SELECT queries can be used as templates")
+	}
+
+	depth := 0
+	functions := make(map[string]bool)
+	ctes := make(map[string]bool)
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses")
+			}
+		case ";":
+			return fmt.Errorf("multiple statements are not allowed")
+		}
+		if !isIdentifierToken(token) || i+1 >= len(tokens) {
+			continue
+		}
+		if tokens[i+1].text == "(" {
+			functions[token.value] = true
+		}
+		if i+2 < len(tokens) && tokens[i+1].value == "as" && tokens[i+2].text == "(" {
+			ctes[token.value] = true
+		}
+		if token.kind == sqlTokenWord && (token.value == "insert" || token.value == "update" || token.value == "delete") && depth == 0 {
+			return fmt.Errorf("only SELECT queries can be used as templates")
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+
+	if known == nil {
+		return nil
+	}
+	refs, _, _ := parseSQLTableRefs(tokens)
+	for _, ref := range refs {
+		if known[ref.table] || templates[ref.table] || ctes[ref.table] || functions[ref.table] {
+			continue
+		}
+		return fmt.Errorf("table %q doesn't exist", ref.table)
+	}
+	return nil
+}
+
+// PrependQueryTemplates adds the AutoPrepend templates referenced by a query, & the templates they depend on, to its
+// WITH clause. Templates the query defines itself are left alone. The rewritten query & the prepended names are returned,
+// queries of non SQL databases are returned as is.
+func PrependQueryTemplates(dbType, query string, templates []QueryTemplate) (string, []string) {
+	if len(templates) == 0 || strings.TrimSpace(query) == "" || !isSQLDatabaseType(dbType) {
+		return query, nil
+	}
+
+	tokens := tokenizeSQL(query)
+	referenced, defined := templateReferences(tokens)
+
+	// Templates may reference earlier templates, walk them backwards to pull in their dependencies
+	needed := make(map[string]bool)
+	for i := len(templates) - 1; i >= 0; i-- {
+		name := strings.ToLower(templates[i].Name)
+		if defined[name] || !(referenced[name] || needed[name]) {
+			continue
+		}
+		if !templates[i].AutoPrepend && !needed[name] {
+			continue
+		}
+		needed[name] = true
+		dependencies, _ := templateReferences(tokenizeSQL(templates[i].Query))
+		for dependency := range dependencies {
+			if !defined[dependency] {
+				needed[dependency] = true
+			}
+		}
+	}
+
+	var definitions, names []string
+	for _, template := range templates {
+		if needed[strings.ToLower(template.Name)] {
+			definitions = append(definitions, fmt.Sprintf("%s AS (%s)", template.Name, strings.TrimSuffix(strings.TrimSpace(template.Query), ";")))
+			names = append(names, template.Name)
+		}
+	}
+	if len(definitions) == 0 {
+		return query, nil
+	}
+
+	prefix := strings.Join(definitions, ", ")
+	if len(tokens) > 0 && tokens[0].value == "with" {
+		// Merge into the existing WITH clause, after RECURSIVE if present
+		insertAt := 1
+		if len(tokens) > 1 && tokens[1].value == "recursive" {
+			insertAt = 2
+		}
+		if insertAt < len(tokens) {
+			offset := tokens[insertAt].start
+			return query[:offset] + prefix + ", " + query[offset:], names
+		}
+	}
+	return "WITH " + prefix + " " + strings.TrimSpace(query), names
+}
+
+// templateReferences returns the unqualified names used in table position (after FROM, JOIN or a comma of a FROM
+// list) & the CTE names defined by a query. Aliases & columns aren't references, so a column named like a template
+// doesn't pull it in.
+func templateReferences(tokens []sqlToken) (map[string]bool, map[string]bool) {
+	referenced := make(map[string]bool)
+	defined := make(map[string]bool)
+	// For every open parenthesis, whether it holds function arguments such as EXTRACT(year FROM created_at)
+	var functionArgs []bool
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			functionArgs = append(functionArgs, i > 0 && isIdentifierToken(tokens[i-1]) && !sqlClauseKeywords[tokens[i-1].value])
+		case ")":
+			if len(functionArgs) > 0 {
+				functionArgs = functionArgs[:len(functionArgs)-1]
+			}
+		}
+		if !isIdentifierToken(token) {
+			continue
+		}
+		if i+2 < len(tokens) && tokens[i+1].value == "as" && tokens[i+2].text == "(" {
+			defined[token.value] = true
+			continue
+		}
+		if token.kind != sqlTokenWord || (token.value != "from" && token.value != "join") {
+			continue
+		}
+		if token.value == "from" && len(functionArgs) > 0 && functionArgs[len(functionArgs)-1] {
+			continue
+		}
+
+		next := i + 1
+		for {
+			ref, end, ok := parseSQLTableRef(tokens, next, make(map[int]bool))
+			if !ok {
+				break
+			}
+			// Schema qualified names are tables, templates are never qualified
+			if !(next+1 < len(tokens) && tokens[next+1].text == ".") {
+				referenced[ref.table] = true
+			}
+			if token.value == "from" && end < len(tokens) && tokens[end].text == "," {
+				next = end + 1
+				continue
+			}
+			break
+		}
+	}
+	return referenced, defined
+}
+
+// FormatQueryTemplatesForLLM describes the templates of a connection as prompt context
+func FormatQueryTemplatesForLLM(templates []QueryTemplate) string {
+	if len(templates) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Reusable CTEs defined for this database, use them instead of redefining the same logic:\n")
+	for _, template := range templates {
+		sb.WriteString(fmt.Sprintf("- %s", template.Name))
+		if template.Description != "" {
+			sb.WriteString(fmt.Sprintf(": %s", template.Description))
+		}
+		if template.AutoPrepend {
+			sb.WriteString(" (reference it by name as a table, its definition is added automatically, don't define it)")
+		} else {
+			sb.WriteString(" (copy its definition into the WITH clause of the query when used)")
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s AS (%s)\n", template.Name, strings.TrimSuffix(strings.TrimSpace(template.Query), ";")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,39 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"fmt"
+	"time"
+)
+
+// DefaultQueryTimeout is the time ExecuteQuery lets a query run when no timeout is set on the context
+const DefaultQueryTimeout = time.Minute
+
+// QueryTimeoutErrorCode is the code of the QueryError of a query running past its timeout, told apart from
+// QUERY_EXECUTION_CANCELLED so that the query can be retried with a longer timeout
+const QueryTimeoutErrorCode = "QUERY_TIMEOUT"
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout sets the time ExecuteQuery lets a query run, a transaction gets it for each of its queries
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// queryTimeout returns the timeout set on the context, DefaultQueryTimeout if there is none
+func queryTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return DefaultQueryTimeout
+}
+
+// queryTimeoutError is returned when a query runs past its timeout, details tell what was stopped
+func queryTimeoutError(timeout time.Duration, details string) *dtos.QueryError {
+	return &dtos.QueryError{
+		Code:    QueryTimeoutErrorCode,
+		Message: fmt.Sprintf("query execution timed out after %s", timeout),
+		Details: details + ", retry it with a longer timeout if it needs more time",
+	}
+}
@@ -0,0 +1,43 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"time"
+)
+
+type readAsOfKey struct{}
+
+// WithReadAsOf makes ExecuteQuery read the data as it was at a point in time, a series of queries run with the same
+// time see a consistent view of the database despite concurrent writes
+func WithReadAsOf(ctx context.Context, asOf time.Time) context.Context {
+	return context.WithValue(ctx, readAsOfKey{}, asOf)
+}
+
+// readAsOf returns the point in time set on the context, false if the query reads the current data
+func readAsOf(ctx context.Context) (time.Time, bool) {
+	asOf, ok := ctx.Value(readAsOfKey{}).(time.Time)
+	return asOf, ok
+}
+
+// ReadAsOfSupported checks if queries of a database type can read the data at a point in time. YugabyteDB keeps the
+// previous versions of rows for timestamp_history_retention_interval_sec (15 minutes by default), PostgreSQL, MySQL &
+// ClickHouse only keep the current version & the MongoDB driver doesn't expose snapshot reads at a cluster time.
+func ReadAsOfSupported(dbType string) bool {
+	return dbType == constants.DatabaseTypeYugabyteDB
+}
+
+// setTransactionReadTime makes the statements of a transaction read the data at a point in time, the read time is
+// local to the transaction so the connection (or the session) is left unchanged
+func setTransactionReadTime(ctx context.Context, tx Transaction, asOf time.Time) error {
+	pgTx, ok := tx.(*PostgresTransaction)
+	if !ok || pgTx.conn == nil || !ReadAsOfSupported(pgTx.conn.Config.Type) {
+		return fmt.Errorf("reading the data at a point in time is not supported by this database")
+	}
+	// yb_read_time is a unix timestamp in microseconds
+	if _, err := pgTx.tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL yb_read_time TO %d", asOf.UnixMicro())); err != nil {
+		return fmt.Errorf("failed to set the read time: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"strings"
+)
+
+// sqlReadStatements are the statements a read only chat may start a query with
+var sqlReadStatements = map[string]bool{
+	"select": true, "with": true, "show": true, "describe": true, "desc": true, "explain": true,
+}
+
+// sqlWriteKeywords change data, the schema or the permissions wherever they appear in a query, ex: a DELETE within a
+// CTE or a SELECT ... INTO creating a table
+var sqlWriteKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true, "upsert": true,
+	"create": true, "drop": true, "alter": true, "truncate": true, "rename": true, "grant": true, "revoke": true,
+	"copy": true, "call": true, "do": true, "lock": true, "into": true, "vacuum": true, "optimize": true,
+	"attach": true, "detach": true, "nextval": true, "setval": true,
+}
+
+// mongoReadOperations are the MongoDB operations a read only chat may run
+var mongoReadOperations = map[string]bool{
+	"find": true, "findOne": true, "aggregate": true, "countDocuments": true, "getCollectionNames": true,
+}
+
+// IsReadOnlyQuery tells whether a query only reads data, from the query itself rather than from its generated type or
+// critical flag. Anything that can't be checked is treated as a write.
+func IsReadOnlyQuery(dbType, query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return false
+	}
+	switch {
+	case dbType == constants.DatabaseTypeMongoDB:
+		return isReadOnlyMongoQuery(query)
+	case dbType == constants.DatabaseTypeElasticsearch:
+		// Only the _search & _count requests are parsed, see parseElasticsearchQuery
+		_, err := parseElasticsearchQuery(query)
+		return err == nil
+	case dbType == constants.DatabaseTypeCassandra:
+		// CQL reads are SELECT statements, its writes use the SQL keywords
+		return isReadOnlySQLQuery(query)
+	case isSQLDialect(dbType):
+		return isReadOnlySQLQuery(query)
+	}
+	return false
+}
+
+func isReadOnlySQLQuery(query string) bool {
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].kind != sqlTokenWord || !sqlReadStatements[tokens[0].value] {
+		return false
+	}
+	for i, token := range tokens {
+		switch token.kind {
+		case sqlTokenWord:
+			if sqlWriteKeywords[token.value] {
+				return false
+			}
+		case sqlTokenPunct:
+			// A second statement could be anything
+			if token.text == ";" && i != len(tokens)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isReadOnlyMongoQuery(query string) bool {
+	if !strings.HasPrefix(query, "db.") {
+		return false
+	}
+	// db.getCollectionNames() or db.collection.operation(...)
+	operationWithParams := strings.TrimPrefix(query, "db.")
+	if openParenIndex := strings.Index(operationWithParams, "("); openParenIndex != -1 && !strings.Contains(operationWithParams[:openParenIndex], ".") {
+		return mongoReadOperations[operationWithParams[:openParenIndex]]
+	}
+	parts := strings.SplitN(query, ".", 3)
+	if len(parts) < 3 {
+		return false
+	}
+	openParenIndex := strings.Index(parts[2], "(")
+	if openParenIndex == -1 || !mongoReadOperations[parts[2][:openParenIndex]] {
+		return false
+	}
+	// $out & $merge write the result of a pipeline to a collection
+	return !strings.Contains(query, "$out") && !strings.Contains(query, "$merge")
+}
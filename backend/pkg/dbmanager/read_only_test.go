@@ -0,0 +1,71 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"testing"
+)
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		query  string
+		want   bool
+	}{
+		{"empty", constants.DatabaseTypePostgreSQL, "  ", false},
+		{"select", constants.DatabaseTypePostgreSQL, "SELECT * FROM orders", true},
+		{"select with a trailing semicolon", constants.DatabaseTypePostgreSQL, "SELECT * FROM orders;", true},
+		{"lower case", constants.DatabaseTypeMySQL, "select id from orders where id = 1", true},
+		{"cte", constants.DatabaseTypePostgreSQL, "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent", true},
+		{"show", constants.DatabaseTypeMySQL, "SHOW TABLES", true},
+		{"explain", constants.DatabaseTypePostgreSQL, "EXPLAIN SELECT * FROM orders", true},
+		{"insert", constants.DatabaseTypePostgreSQL, "INSERT INTO orders (id) VALUES (1)", false},
+		{"update", constants.DatabaseTypeMySQL, "UPDATE orders SET status = 'paid'", false},
+		{"cte wrapped delete", constants.DatabaseTypePostgreSQL, "WITH deleted AS (DELETE FROM orders RETURNING *) SELECT * FROM deleted", false},
+		{"cte wrapped update", constants.DatabaseTypePostgreSQL, "WITH changed AS (UPDATE orders SET status = 'x' RETURNING id) SELECT count(*) FROM changed", false},
+		{"explain analyze delete", constants.DatabaseTypePostgreSQL, "EXPLAIN ANALYZE DELETE FROM orders", false},
+		{"select into", constants.DatabaseTypePostgreSQL, "SELECT * INTO orders_backup FROM orders", false},
+		{"sequence", constants.DatabaseTypePostgreSQL, "SELECT nextval('orders_id_seq')", false},
+		{"two selects", constants.DatabaseTypePostgreSQL, "SELECT 1; SELECT 2", false},
+		{"select then delete", constants.DatabaseTypeMySQL, "SELECT 1; DELETE FROM orders", false},
+		{"keyword in a string", constants.DatabaseTypePostgreSQL, "SELECT * FROM logs WHERE message = 'DELETE FROM orders'", true},
+		{"semicolon in a string", constants.DatabaseTypePostgreSQL, "SELECT * FROM logs WHERE message = 'a; DROP TABLE orders'", true},
+		{"keyword in a quoted identifier", constants.DatabaseTypePostgreSQL, `SELECT "update" FROM orders`, true},
+		{"keyword in a backquoted identifier", constants.DatabaseTypeMySQL, "SELECT `delete` FROM orders", true},
+		{"keyword in a line comment", constants.DatabaseTypePostgreSQL, "SELECT * FROM orders -- DELETE FROM orders", true},
+		{"keyword in a block comment", constants.DatabaseTypePostgreSQL, "/* DROP TABLE orders */ SELECT * FROM orders", true},
+		{"write after a comment", constants.DatabaseTypePostgreSQL, "/* report */ DELETE FROM orders", false},
+		{"sqlite attach", constants.DatabaseTypeSQLite, "SELECT 1; ATTACH '/tmp/other.db' AS other", false},
+		{"unsupported database", constants.DatabaseTypeRedis, "GET key", false},
+
+		{"mongo find", constants.DatabaseTypeMongoDB, `db.orders.find({"status": "paid"})`, true},
+		{"mongo aggregate", constants.DatabaseTypeMongoDB, `db.orders.aggregate([{"$match": {"status": "paid"}}])`, true},
+		{"mongo count", constants.DatabaseTypeMongoDB, `db.orders.countDocuments({})`, true},
+		{"mongo collection names", constants.DatabaseTypeMongoDB, `db.getCollectionNames()`, true},
+		{"mongo out", constants.DatabaseTypeMongoDB, `db.orders.aggregate([{"$match": {}}, {"$out": "orders_copy"}])`, false},
+		{"mongo merge", constants.DatabaseTypeMongoDB, `db.orders.aggregate([{"$merge": {"into": "orders_copy"}}])`, false},
+		{"mongo delete", constants.DatabaseTypeMongoDB, `db.orders.deleteMany({})`, false},
+		{"mongo update", constants.DatabaseTypeMongoDB, `db.orders.updateOne({"_id": 1}, {"$set": {"status": "paid"}})`, false},
+		{"mongo drop database", constants.DatabaseTypeMongoDB, `db.dropDatabase()`, false},
+		{"mongo without db", constants.DatabaseTypeMongoDB, `orders.find({})`, false},
+
+		{"elasticsearch body", constants.DatabaseTypeElasticsearch, `{"query": {"match_all": {}}}`, true},
+		{"elasticsearch search", constants.DatabaseTypeElasticsearch, "GET /orders/_search\n{\"query\": {\"term\": {\"status\": \"paid\"}}}", true},
+		{"elasticsearch count", constants.DatabaseTypeElasticsearch, "POST /orders/_count\n{\"query\": {\"match_all\": {}}}", true},
+		{"elasticsearch delete method", constants.DatabaseTypeElasticsearch, "DELETE /orders", false},
+		{"elasticsearch delete by query", constants.DatabaseTypeElasticsearch, "POST /orders/_delete_by_query\n{\"query\": {\"match_all\": {}}}", false},
+		{"elasticsearch two bodies", constants.DatabaseTypeElasticsearch, `{"query": {}} {"query": {}}`, false},
+
+		{"cassandra select", constants.DatabaseTypeCassandra, "SELECT * FROM shop.orders WHERE id = 1", true},
+		{"cassandra insert", constants.DatabaseTypeCassandra, "INSERT INTO shop.orders (id) VALUES (1)", false},
+		{"cassandra batch", constants.DatabaseTypeCassandra, "BEGIN BATCH INSERT INTO shop.orders (id) VALUES (1); APPLY BATCH", false},
+		{"cassandra truncate", constants.DatabaseTypeCassandra, "TRUNCATE shop.orders", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReadOnlyQuery(tt.dbType, tt.query); got != tt.want {
+				t.Errorf("IsReadOnlyQuery(%s, %q) = %v, want %v", tt.dbType, tt.query, got, tt.want)
+			}
+		})
+	}
+}
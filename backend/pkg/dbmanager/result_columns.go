@@ -0,0 +1,244 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Simplified types of the result columns (dtos.ResultColumn), the clients render the values by them
+const (
+	ResultColumnTypeInteger  = "integer"
+	ResultColumnTypeNumber   = "number"
+	ResultColumnTypeBoolean  = "boolean"
+	ResultColumnTypeText     = "text"
+	ResultColumnTypeDateTime = "datetime"
+	ResultColumnTypeDate     = "date"
+	ResultColumnTypeTime     = "time"
+	ResultColumnTypeJSON     = "json"
+	ResultColumnTypeBinary   = "binary"
+	ResultColumnTypeObjectID = "objectid"
+	ResultColumnTypeUnknown  = "unknown"
+)
+
+// sqlResultColumns describes the columns of rows from the driver's column types, the columns the driver has no type
+// for (ex: SQLite expressions) are unknown. Nil is returned if the driver can't describe them.
+func sqlResultColumns(rows *sql.Rows) []dtos.ResultColumn {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil || len(columnTypes) == 0 {
+		return nil
+	}
+	columns := make([]dtos.ResultColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		databaseType := columnType.DatabaseTypeName()
+		columns[i] = dtos.ResultColumn{
+			Name:         columnType.Name(),
+			Type:         resultColumnType(databaseType),
+			DatabaseType: databaseType,
+		}
+	}
+	return columns
+}
+
+// resultColumnType maps a database type name to its simplified type, ex: BIGINT is an integer & TIMESTAMPTZ a datetime
+func resultColumnType(databaseType string) string {
+	name := strings.ToUpper(strings.TrimSpace(databaseType))
+	// ClickHouse wraps the types, ex: Nullable(Int64), LowCardinality(String)
+	for _, wrapper := range []string{"NULLABLE(", "LOWCARDINALITY("} {
+		for strings.HasPrefix(name, wrapper) && strings.HasSuffix(name, ")") {
+			name = strings.TrimSuffix(strings.TrimPrefix(name, wrapper), ")")
+		}
+	}
+	if name == "" {
+		return ResultColumnTypeUnknown
+	}
+	// Postgres arrays are named _INT4, _TEXT...
+	if strings.HasPrefix(name, "_") || strings.HasSuffix(name, "[]") || strings.HasPrefix(name, "ARRAY") {
+		return ResultColumnTypeJSON
+	}
+	if i := strings.IndexAny(name, "( "); i > 0 {
+		name = name[:i]
+	}
+
+	switch {
+	case name == "BOOL" || name == "BOOLEAN" || name == "BIT":
+		return ResultColumnTypeBoolean
+	case strings.Contains(name, "INT") && !strings.Contains(name, "INTERVAL") && !strings.Contains(name, "POINT"),
+		name == "SERIAL" || name == "BIGSERIAL" || name == "SMALLSERIAL" || name == "YEAR":
+		return ResultColumnTypeInteger
+	case strings.HasPrefix(name, "FLOAT") || strings.HasPrefix(name, "DECIMAL") || name == "NUMERIC" || name == "REAL" ||
+		name == "DOUBLE" || name == "MONEY" || name == "NUMBER" || name == "DEC" || name == "FIXED":
+		return ResultColumnTypeNumber
+	case strings.HasPrefix(name, "TIMESTAMP") || strings.HasPrefix(name, "DATETIME"):
+		return ResultColumnTypeDateTime
+	case name == "DATE" || name == "DATE32":
+		return ResultColumnTypeDate
+	case name == "TIME" || name == "TIMETZ":
+		return ResultColumnTypeTime
+	case name == "JSON" || name == "JSONB" || strings.HasPrefix(name, "MAP") || strings.HasPrefix(name, "TUPLE"):
+		return ResultColumnTypeJSON
+	case name == "BYTEA" || name == "BLOB" || strings.HasSuffix(name, "BLOB") || strings.HasSuffix(name, "BINARY"):
+		return ResultColumnTypeBinary
+	case strings.Contains(name, "CHAR") || strings.Contains(name, "TEXT") || strings.Contains(name, "STRING") ||
+		name == "UUID" || name == "ENUM" || name == "ENUM8" || name == "ENUM16" || name == "SET" || name == "CITEXT" ||
+		name == "NAME" || name == "XML" || name == "INET" || name == "CIDR" || name == "INTERVAL":
+		return ResultColumnTypeText
+	}
+	return ResultColumnTypeUnknown
+}
+
+// valueColumnType infers the simplified type of a column from one of its values, unknown for nil
+func valueColumnType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ResultColumnTypeUnknown
+	case bool:
+		return ResultColumnTypeBoolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ResultColumnTypeInteger
+	case float32, float64, primitive.Decimal128:
+		return ResultColumnTypeNumber
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return ResultColumnTypeNumber
+		}
+		return ResultColumnTypeInteger
+	case time.Time, primitive.DateTime, primitive.Timestamp:
+		return ResultColumnTypeDateTime
+	case primitive.ObjectID:
+		return ResultColumnTypeObjectID
+	case []byte, primitive.Binary:
+		return ResultColumnTypeBinary
+	case map[string]interface{}, bson.M, bson.D, []interface{}, bson.A, []map[string]interface{}:
+		return ResultColumnTypeJSON
+	case string:
+		return ResultColumnTypeText
+	}
+	return ResultColumnTypeUnknown
+}
+
+// resultRows returns the rows of a result as maps whatever the driver stored them as, nil if they aren't rows
+func resultRows(results interface{}) []map[string]interface{} {
+	switch rows := results.(type) {
+	case []map[string]interface{}:
+		return rows
+	case []bson.M:
+		converted := make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			converted[i] = row
+		}
+		return converted
+	case []interface{}:
+		converted := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			switch typed := row.(type) {
+			case map[string]interface{}:
+				converted = append(converted, typed)
+			case bson.M:
+				converted = append(converted, typed)
+			default:
+				return nil
+			}
+		}
+		return converted
+	}
+	return nil
+}
+
+// inferredResultColumns describes the columns of the first row, the _id first & the others sorted by name since the
+// rows are maps
+func inferredResultColumns(rows []map[string]interface{}) []dtos.ResultColumn {
+	if len(rows) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(rows[0]))
+	for name := range rows[0] {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "_id" || names[j] == "_id" {
+			return names[i] == "_id"
+		}
+		return names[i] < names[j]
+	})
+
+	columns := make([]dtos.ResultColumn, len(names))
+	for i, name := range names {
+		columns[i] = dtos.ResultColumn{Name: name, Type: valueColumnType(rows[0][name])}
+	}
+	return columns
+}
+
+// resolveResultColumns describes the columns of a successful result. The SQL drivers describe them from the driver's
+// column types, the ones they couldn't type are looked up in the stored schema of the tables the query references
+// then inferred from the values. MongoDB & the other drivers have them inferred from the first document.
+func (m *Manager) resolveResultColumns(ctx context.Context, chatID, dbType, query string, result *QueryExecutionResult) {
+	if result == nil || result.Error != nil || result.Result == nil {
+		return
+	}
+	// Columns typed by a statement of a batch followed by one returning no rows
+	if _, ok := result.Result["results"]; !ok {
+		result.Columns = nil
+		return
+	}
+	rows := resultRows(result.Result["results"])
+	if len(result.Columns) == 0 {
+		result.Columns = inferredResultColumns(rows)
+		return
+	}
+
+	var schemaColumns map[string]ColumnInfo
+	for i := range result.Columns {
+		column := &result.Columns[i]
+		if column.Type != ResultColumnTypeUnknown {
+			continue
+		}
+		if schemaColumns == nil && isSQLDatabaseType(dbType) && m.schemaManager != nil {
+			schemaColumns = referencedSchemaColumns(m.schemaManager.getKnownSchema(ctx, chatID), ReferencedTables(dbType, query))
+		}
+		if info, ok := schemaColumns[strings.ToLower(column.Name)]; ok {
+			column.Type = resultColumnType(info.Type)
+			if column.DatabaseType == "" {
+				column.DatabaseType = info.Type
+			}
+			if column.Type != ResultColumnTypeUnknown {
+				continue
+			}
+		}
+		for _, row := range rows {
+			if value := row[column.Name]; value != nil {
+				column.Type = valueColumnType(value)
+				break
+			}
+		}
+	}
+}
+
+// referencedSchemaColumns returns the columns of the given tables by lower cased name, the first table wins when
+// several have the same column
+func referencedSchemaColumns(schema *SchemaInfo, tables []string) map[string]ColumnInfo {
+	columns := make(map[string]ColumnInfo)
+	if schema == nil {
+		return columns
+	}
+	for _, table := range tables {
+		for name, tableSchema := range schema.Tables {
+			if !strings.EqualFold(name, table) && !strings.HasSuffix(strings.ToLower(name), "."+table) {
+				continue
+			}
+			for columnName, info := range tableSchema.Columns {
+				if _, exists := columns[strings.ToLower(columnName)]; !exists {
+					columns[strings.ToLower(columnName)] = info
+				}
+			}
+		}
+	}
+	return columns
+}
@@ -0,0 +1,215 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+type resultRowLimitKey struct{}
+
+// SetMaxResultRows sets the number of rows of a query result read from the database by ExecuteQuery, the remaining rows
+// are never loaded in memory & a warning is added to the result, 0 reads everything
+func (m *Manager) SetMaxResultRows(limit int) {
+	m.maxResultRows = limit
+}
+
+// withResultRowLimit makes the drivers stop reading the result of a query after limit rows, 0 reads everything.
+// A smaller limit already set on the context is kept.
+func withResultRowLimit(ctx context.Context, limit int) context.Context {
+	if current := resultRowLimit(ctx); current > 0 && (limit == 0 || current < limit) {
+		return ctx
+	}
+	return context.WithValue(ctx, resultRowLimitKey{}, limit)
+}
+
+// WithPreviewRowLimit makes ExecuteQuery read at most rows rows of the result, used to preview a query
+func WithPreviewRowLimit(ctx context.Context, rows int) context.Context {
+	return withResultRowLimit(ctx, rows)
+}
+
+// resultRowLimit returns the row limit set on the context, 0 if there is none
+func resultRowLimit(ctx context.Context) int {
+	limit, _ := ctx.Value(resultRowLimitKey{}).(int)
+	return limit
+}
+
+const resultTruncatedWarningPrefix = "The result was truncated to its first "
+
+// resultTruncatedWarning is added to the warnings of a result whose rows were cut at the limit
+func resultTruncatedWarning(limit int) string {
+	return fmt.Sprintf(resultTruncatedWarningPrefix+"%d rows, add a LIMIT or aggregate the data to see everything", limit)
+}
+
+// IsResultTruncated reports if rows of a result were left out at the row limit of ExecuteQuery
+func IsResultTruncated(result *QueryExecutionResult) bool {
+	for _, warning := range result.Warnings {
+		if strings.HasPrefix(warning, resultTruncatedWarningPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanLimitedRows reads the rows of a gorm query into maps, at most the row limit of the context is read from the
+// driver, the remaining rows are never materialized. The second value reports if rows were left unread. Reading stops
+// with errResultTooLarge once the rows pass the byte limit of the context.
+func scanLimitedRows(ctx context.Context, db *gorm.DB, rows *sql.Rows) ([]map[string]interface{}, bool, error) {
+	limit := resultRowLimit(ctx)
+	size := resultSizeOf(ctx)
+	results := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		if limit > 0 && len(results) >= limit {
+			return results, true, nil
+		}
+		row := make(map[string]interface{})
+		if err := db.ScanRows(rows, &row); err != nil {
+			return nil, false, fmt.Errorf("failed to scan row: %v", err)
+		}
+		if err := size.add(row); err != nil {
+			return nil, false, err
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error iterating rows: %v", err)
+	}
+	return results, false, nil
+}
+
+// decodeLimitedCursor decodes the documents of a MongoDB cursor, at most the row limit of the context is decoded.
+// The second value reports if documents were left unread. Decoding stops with errResultTooLarge once the documents pass
+// the byte limit of the context.
+func decodeLimitedCursor(ctx context.Context, cursor *mongo.Cursor) ([]bson.M, bool, error) {
+	limit := resultRowLimit(ctx)
+	size := resultSizeOf(ctx)
+	results := make([]bson.M, 0)
+	for cursor.Next(ctx) {
+		if limit > 0 && len(results) >= limit {
+			return results, true, nil
+		}
+		var document bson.M
+		if err := cursor.Decode(&document); err != nil {
+			return nil, false, err
+		}
+		if err := size.add(document); err != nil {
+			return nil, false, err
+		}
+		results = append(results, document)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, false, err
+	}
+	return results, false, nil
+}
+
+// mongoLimitRegex matches the limit chained to a MongoDB find, ex: .limit(50)
+var mongoLimitRegex = regexp.MustCompile(`\.limit\(\s*(\d+)\s*\)`)
+
+// DatabaseRowLimitSupported checks if the database of a connection can be asked to return at most a number of rows
+func DatabaseRowLimitSupported(dbType string) bool {
+	return dbType == constants.DatabaseTypeMongoDB || isSQLDialect(dbType)
+}
+
+// limitQueryRows rewrites a read query so that the database itself returns at most limit rows, the rows past the
+// limit never leave the database. Queries changing data & queries that can't be rewritten safely are returned as is,
+// the rows read by the drivers are still capped by the row limit of the context.
+func limitQueryRows(dbType, query string, limit int) string {
+	if limit <= 0 || !IsReadOnlyQuery(dbType, query) {
+		return query
+	}
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	switch dbType {
+	case constants.DatabaseTypeMongoDB:
+		return limitMongoQueryRows(query, limit)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return limitMySQLQueryRows(query, limit)
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeClickhouse, constants.DatabaseTypeSQLite,
+		constants.DatabaseTypeBigQuery:
+		tokens := tokenizeSQL(query)
+		if tokens[0].value != "select" && tokens[0].value != "with" {
+			return query
+		}
+		for _, token := range tokens {
+			// A FORMAT or SETTINGS clause of ClickHouse must stay at the end of the query
+			if token.kind == sqlTokenWord && (token.value == "format" || token.value == "settings") {
+				return query
+			}
+		}
+		// The query ends on its own line, a trailing comment must not hide the closing parenthesis
+		return fmt.Sprintf("SELECT * FROM (\n%s\n) AS databot_row_limit LIMIT %d", query, limit)
+	}
+	return query
+}
+
+// limitMySQLQueryRows sets sql_select_limit for the statement only, through a SET_VAR hint of its main SELECT (MySQL
+// 8.0.3+, older servers ignore the hint). A derived table would fail on the duplicate column names of joins.
+func limitMySQLQueryRows(query string, limit int) string {
+	tokens := tokenizeSQL(query)
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.kind == sqlTokenPunct && token.text == "(":
+			depth++
+		case token.kind == sqlTokenPunct && token.text == ")":
+			depth--
+		case depth == 0 && token.kind == sqlTokenWord && token.value == "select":
+			end := token.start + len(token.text)
+			return fmt.Sprintf("%s /*+ SET_VAR(sql_select_limit = %d) */%s", query[:end], limit, query[end:])
+		}
+	}
+	return query
+}
+
+// limitMongoQueryRows caps the limit of a find or adds a $limit stage at the end of an aggregation pipeline
+func limitMongoQueryRows(query string, limit int) string {
+	parts := strings.SplitN(query, ".", 3)
+	if len(parts) < 3 {
+		return query
+	}
+	operationIndex := len(parts[0]) + len(parts[1]) + 2
+	openParenIndex := strings.Index(query[operationIndex:], "(")
+	if openParenIndex == -1 {
+		return query
+	}
+	openParenIndex += operationIndex
+	params, closeParenIndex, err := extractParenthesisContent(query, openParenIndex)
+	if err != nil {
+		return query
+	}
+
+	switch query[operationIndex:openParenIndex] {
+	case "find":
+		if match := mongoLimitRegex.FindStringSubmatchIndex(query); match != nil {
+			if current, err := strconv.Atoi(query[match[2]:match[3]]); err == nil && current > 0 && current <= limit {
+				return query
+			}
+			return query[:match[2]] + strconv.Itoa(limit) + query[match[3]:]
+		}
+		return fmt.Sprintf("%s.limit(%d)%s", query[:closeParenIndex+1], limit, query[closeParenIndex+1:])
+	case "aggregate":
+		pipeline, aggregateOptions := splitAggregateOptions(params)
+		pipeline = strings.TrimSpace(pipeline)
+		if !strings.HasPrefix(pipeline, "[") || !strings.HasSuffix(pipeline, "]") {
+			return query
+		}
+		stages := strings.TrimSuffix(strings.TrimSpace(pipeline[1:len(pipeline)-1]), ",")
+		if stages != "" {
+			stages += ", "
+		}
+		pipeline = fmt.Sprintf(`[%s{"$limit": %d}]`, stages, limit)
+		if aggregateOptions != "" {
+			pipeline += ", " + aggregateOptions
+		}
+		return query[:openParenIndex+1] + pipeline + query[closeParenIndex:]
+	}
+	return query
+}
@@ -0,0 +1,65 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/apis/dtos"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ResultSet is one of the results of a query, stored procedures & batches of statements can return several of them
+type ResultSet struct {
+	Columns []string                 `json:"columns"` // In the order of the result, the rows are maps
+	Rows    []map[string]interface{} `json:"results"`
+	Types   []dtos.ResultColumn      `json:"-"` // Columns with their types, see QueryExecutionResult.Columns
+}
+
+// ResultSetsKey is the key of QueryExecutionResult.Result holding the result sets of a query that returned more than
+// one, "results" holds the rows of the last one
+const ResultSetsKey = "result_sets"
+
+// scanResultSets reads every result set of rows, at most the row limit of the context is read from each of them.
+// The second value reports if rows were left unread in any result set. Result sets without columns, such as the
+// status returned at the end of a MySQL CALL, are skipped.
+func scanResultSets(ctx context.Context, db *gorm.DB, rows *sql.Rows) ([]ResultSet, bool, error) {
+	sets := make([]ResultSet, 0, 1)
+	anyTruncated := false
+	for {
+		columns, err := rows.Columns()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read result columns: %v", err)
+		}
+		types := sqlResultColumns(rows)
+		setRows, truncated, err := scanLimitedRows(ctx, db, rows)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(columns) > 0 {
+			sets = append(sets, ResultSet{Columns: columns, Rows: setRows, Types: types})
+		}
+		anyTruncated = anyTruncated || truncated
+
+		// Rows left unread in a truncated result set are discarded by the driver
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error reading result sets: %v", err)
+	}
+	return sets, anyTruncated, nil
+}
+
+// addResultSets stores the result sets of a query in its result when there is more than one, a single result set is
+// only returned in "results"
+func addResultSets(result *QueryExecutionResult, sets []ResultSet) {
+	if len(sets) < 2 {
+		return
+	}
+	if result.Result == nil {
+		result.Result = map[string]interface{}{}
+	}
+	result.Result[ResultSetsKey] = sets
+}
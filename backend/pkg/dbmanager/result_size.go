@@ -0,0 +1,202 @@
+package dbmanager
+
+import (
+	"bytes"
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type resultSizeKey struct{}
+
+// errResultTooLarge stops reading the rows of a query once its result passes the byte limit of the context
+var errResultTooLarge = errors.New("the query result is larger than the maximum result size")
+
+// resultSize counts the bytes of the rows read for a query, the shards of a fanned out query add their rows to the same
+// counter concurrently
+type resultSize struct {
+	limit    int64
+	bytes    atomic.Int64
+	exceeded atomic.Bool
+}
+
+// SetMaxResultBytes sets the size of a query result read from the database by ExecuteQuery, reading stops with a
+// RESULT_TOO_LARGE error once the rows read pass it, 0 reads everything
+func (m *Manager) SetMaxResultBytes(limit int64) {
+	m.maxResultBytes = limit
+}
+
+// withResultByteLimit makes the drivers count the bytes of the rows they read & stop once limit is passed, 0 doesn't
+// count them
+func withResultByteLimit(ctx context.Context, limit int64) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, resultSizeKey{}, &resultSize{limit: limit})
+}
+
+// resultSizeOf returns the byte counter of the context, nil if there is no byte limit
+func resultSizeOf(ctx context.Context) *resultSize {
+	size, _ := ctx.Value(resultSizeKey{}).(*resultSize)
+	return size
+}
+
+// add counts the bytes of a row, errResultTooLarge is returned once the rows read pass the limit
+func (s *resultSize) add(row interface{}) error {
+	if s == nil {
+		return nil
+	}
+	if s.bytes.Add(estimateValueBytes(row)) > s.limit {
+		s.exceeded.Store(true)
+		return errResultTooLarge
+	}
+	return nil
+}
+
+// tooLarge reports if reading the rows passed the limit, or if the encoded result of a driver reading its rows at once
+// (ex: Elasticsearch, BigQuery) is larger than it
+func (s *resultSize) tooLarge(result *QueryExecutionResult) bool {
+	if s == nil || result == nil {
+		return false
+	}
+	return s.exceeded.Load() || int64(len(result.ResultJSON)) > s.limit
+}
+
+// estimateValueBytes estimates the size of a value encoded in JSON, the values of the documents & arrays included
+func estimateValueBytes(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 4
+	case string:
+		return int64(len(v)) + 2
+	case []byte:
+		// Encoded as base64 or hex text
+		return int64(len(v))*2 + 2
+	case primitive.Binary:
+		return int64(len(v.Data))*2 + 2
+	case map[string]interface{}:
+		return estimateDocumentBytes(v)
+	case bson.M:
+		return estimateDocumentBytes(v)
+	case bson.D:
+		size := int64(2)
+		for _, element := range v {
+			size += int64(len(element.Key)) + 4 + estimateValueBytes(element.Value)
+		}
+		return size
+	case []interface{}:
+		return estimateArrayBytes(v)
+	case bson.A:
+		return estimateArrayBytes(v)
+	}
+	// Numbers, booleans, dates & the other scalars
+	return 16
+}
+
+func estimateDocumentBytes(document map[string]interface{}) int64 {
+	size := int64(2)
+	for key, value := range document {
+		size += int64(len(key)) + 4 + estimateValueBytes(value)
+	}
+	return size
+}
+
+func estimateArrayBytes(values []interface{}) int64 {
+	size := int64(2)
+	for _, value := range values {
+		size += estimateValueBytes(value) + 1
+	}
+	return size
+}
+
+// resultTooLargeError returns the error of a result passing the byte limit of the context
+func (s *resultSize) tooLargeError() *dtos.QueryError {
+	return resultTooLargeError(s.limit)
+}
+
+// resultTooLargeError is returned for a query whose result is larger than limit bytes
+func resultTooLargeError(limit int64) *dtos.QueryError {
+	return &dtos.QueryError{
+		Code:    "RESULT_TOO_LARGE",
+		Message: "the query result is too large",
+		Details: fmt.Sprintf("The result of the query is larger than %s, select fewer columns (ex: leave out the large text, JSON or binary columns) or fewer rows", formatByteSize(limit)),
+	}
+}
+
+// formatByteSize formats a number of bytes, ex: 1.5 GB
+func formatByteSize(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// TruncateLargeValues cuts the text values of an encoded query result (blobs are encoded as text) larger than maxBytes,
+// a cut value ends with a marker giving the size of the full value. The number of values cut is returned with the
+// result, which is returned as is when nothing is cut or when it can't be decoded.
+func TruncateLargeValues(resultJSON string, maxBytes int) (string, int) {
+	if maxBytes <= 0 || len(resultJSON) <= maxBytes {
+		return resultJSON, 0
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(resultJSON)))
+	// Numbers are kept as written, large integers would lose their precision as float64
+	decoder.UseNumber()
+	var result interface{}
+	if err := decoder.Decode(&result); err != nil {
+		return resultJSON, 0
+	}
+
+	truncated := 0
+	result = truncateLargeValues(result, maxBytes, &truncated)
+	if truncated == 0 {
+		return resultJSON, 0
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return resultJSON, 0
+	}
+	return string(encoded), truncated
+}
+
+func truncateLargeValues(value interface{}, maxBytes int, truncated *int) interface{} {
+	switch v := value.(type) {
+	case string:
+		if len(v) <= maxBytes {
+			return v
+		}
+		*truncated++
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(v[cut]) {
+			cut--
+		}
+		return v[:cut] + fmt.Sprintf("... [truncated, %s in total]", formatByteSize(int64(len(v))))
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = truncateLargeValues(item, maxBytes, truncated)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = truncateLargeValues(item, maxBytes, truncated)
+		}
+	}
+	return value
+}
+
+// LargeValuesTruncatedWarning is added to the warnings of a result whose large values were cut
+func LargeValuesTruncatedWarning(count, maxBytes int) string {
+	return fmt.Sprintf("%d values larger than %s were truncated, download a cell to see its full value", count, formatByteSize(int64(maxBytes)))
+}
@@ -0,0 +1,145 @@
+package dbmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// ColumnSummary describes the values of a column across the rows of a result
+type ColumnSummary struct {
+	NonNull  int         `json:"non_null"`
+	Distinct int         `json:"distinct"`
+	Min      interface{} `json:"min,omitempty"` // Only for numeric columns
+	Max      interface{} `json:"max,omitempty"`
+	Avg      *float64    `json:"avg,omitempty"`
+}
+
+// ShrinkResultJSON keeps the first maxRows records of a query result JSON: a list of records or an object with a
+// "results" list of records & the rows of its result sets. When records are dropped the result becomes an object with
+// the kept "results", the "total_rows" of the result &, if summarize is set, a summary of each column computed on
+// every record. Results with at most maxRows records, results that can't be parsed & a maxRows of 0 are returned as is.
+func ShrinkResultJSON(resultJSON string, maxRows int, summarize bool) string {
+	if maxRows <= 0 || resultJSON == "" {
+		return resultJSON
+	}
+
+	var result interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		return resultJSON
+	}
+
+	var shrunk map[string]interface{}
+	switch value := result.(type) {
+	case []interface{}:
+		if len(value) <= maxRows {
+			return resultJSON
+		}
+		shrunk = map[string]interface{}{}
+		shrinkRecords(shrunk, value, maxRows, summarize)
+	case map[string]interface{}:
+		records, isWrapper := value["results"].([]interface{})
+		sets, hasSets := value[ResultSetsKey].([]interface{})
+		if !isWrapper || (len(records) <= maxRows && !hasSets) {
+			return resultJSON
+		}
+		shrunk = make(map[string]interface{}, len(value)+2)
+		for key, item := range value {
+			shrunk[key] = item
+		}
+		if len(records) > maxRows {
+			shrinkRecords(shrunk, records, maxRows, summarize)
+		}
+		if hasSets {
+			shrunkSets := make([]interface{}, len(sets))
+			for i, set := range sets {
+				shrunkSets[i] = set
+				setMap, ok := set.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if setRecords, ok := setMap["results"].([]interface{}); ok && len(setRecords) > maxRows {
+					shrunkSet := make(map[string]interface{}, len(setMap)+2)
+					for key, item := range setMap {
+						shrunkSet[key] = item
+					}
+					shrinkRecords(shrunkSet, setRecords, maxRows, summarize)
+					shrunkSets[i] = shrunkSet
+				}
+			}
+			shrunk[ResultSetsKey] = shrunkSets
+		}
+	default:
+		return resultJSON
+	}
+
+	shrunkJSON, err := json.Marshal(shrunk)
+	if err != nil {
+		log.Printf("DBManager -> ShrinkResultJSON -> Error marshalling shrunk result: %v", err)
+		return resultJSON
+	}
+	return string(shrunkJSON)
+}
+
+// shrinkRecords sets the first maxRows records in the "results" of target with the total & the column summaries
+func shrinkRecords(target map[string]interface{}, records []interface{}, maxRows int, summarize bool) {
+	target["results"] = records[:maxRows]
+	target["total_rows"] = len(records)
+	if summarize {
+		target["column_summaries"] = summarizeColumns(records)
+	}
+}
+
+// summarizeColumns computes the summary of the top level columns of the records, values that aren't records are
+// ignored
+func summarizeColumns(records []interface{}) map[string]*ColumnSummary {
+	summaries := make(map[string]*ColumnSummary)
+	distinct := make(map[string]map[string]bool)
+	sums := make(map[string]float64)
+	numbers := make(map[string]int)
+	for _, record := range records {
+		recordMap, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for column, value := range recordMap {
+			summary, exists := summaries[column]
+			if !exists {
+				summary = &ColumnSummary{}
+				summaries[column] = summary
+				distinct[column] = make(map[string]bool)
+			}
+			if value == nil {
+				continue
+			}
+			summary.NonNull++
+			if encoded, err := json.Marshal(value); err == nil {
+				distinct[column][string(encoded)] = true
+			} else {
+				distinct[column][fmt.Sprint(value)] = true
+			}
+			if number, isNumber := value.(float64); isNumber {
+				if numbers[column] == 0 || number < summary.Min.(float64) {
+					summary.Min = number
+				}
+				if numbers[column] == 0 || number > summary.Max.(float64) {
+					summary.Max = number
+				}
+				sums[column] += number
+				numbers[column]++
+			}
+		}
+	}
+
+	for column, summary := range summaries {
+		summary.Distinct = len(distinct[column])
+		if count := numbers[column]; count > 0 && count == summary.NonNull {
+			avg := sums[column] / float64(count)
+			summary.Avg = &avg
+		} else {
+			// Mixed columns aren't numeric
+			summary.Min, summary.Max = nil, nil
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,427 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SchemaAnonymizer maps the names of a schema (tables, columns, indexes...) to opaque tokens such as "table_3" or
+// "column_12" before anything is sent to the LLM, and the tokens of the generated queries back to the real names.
+// The mapping is per name, not per table, so that a column used by several tables keeps one token & a query can be
+// mapped back without knowing its tables. Dotted names (ex: "public.orders", MongoDB "address.city") are mapped per part.
+type SchemaAnonymizer struct {
+	dbType string
+	tokens map[string]string // Real name -> token
+	names  map[string]string // Token -> real name
+	folded map[string]string // Lower cased real name -> token, for free text where the casing of names is loose
+	added  bool
+}
+
+// Token prefixes per kind of name
+const (
+	anonymizedTablePrefix  = "table_"
+	anonymizedColumnPrefix = "column_"
+	anonymizedObjectPrefix = "object_" // Indexes, constraints & foreign keys
+)
+
+var (
+	anonymizerWordRegex  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_$]*`)
+	anonymizerTokenRegex = regexp.MustCompile("([\"`]?)\\b((?:" + anonymizedTablePrefix + "|" + anonymizedColumnPrefix + "|" + anonymizedObjectPrefix + `)[0-9]+)\b(["` + "`" + `]?)`)
+	plainLowerIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+	plainIdentifier      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+)
+
+// NewSchemaAnonymizer creates an anonymizer from a previously stored real name -> token mapping, nil starts a new one
+func NewSchemaAnonymizer(dbType string, tokens map[string]string) *SchemaAnonymizer {
+	a := &SchemaAnonymizer{
+		dbType: dbType,
+		tokens: make(map[string]string, len(tokens)),
+		names:  make(map[string]string, len(tokens)),
+		folded: make(map[string]string, len(tokens)),
+	}
+	for name, token := range tokens {
+		a.tokens[name] = token
+		a.names[token] = name
+		if _, exists := a.folded[strings.ToLower(name)]; !exists {
+			a.folded[strings.ToLower(name)] = token
+		}
+	}
+	return a
+}
+
+// Tokens returns the real name -> token mapping, to be stored so that tokens stay stable across requests
+func (a *SchemaAnonymizer) Tokens() map[string]string {
+	tokens := make(map[string]string, len(a.tokens))
+	for name, token := range a.tokens {
+		tokens[name] = token
+	}
+	return tokens
+}
+
+// HasNewTokens checks if names were added since the anonymizer was created
+func (a *SchemaAnonymizer) HasNewTokens() bool {
+	return a.added
+}
+
+// AddSchema assigns tokens to the names of the schema that don't have one yet
+func (a *SchemaAnonymizer) AddSchema(schema *SchemaInfo) {
+	if schema == nil {
+		return
+	}
+
+	tableNames := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		table := schema.Tables[tableName]
+		a.add(tableName, anonymizedTablePrefix)
+		for _, columnName := range sortedKeys(table.Columns) {
+			a.add(columnName, anonymizedColumnPrefix)
+		}
+		for _, indexName := range sortedKeys(table.Indexes) {
+			a.add(indexName, anonymizedObjectPrefix)
+		}
+		for _, constraintName := range sortedKeys(table.Constraints) {
+			a.add(constraintName, anonymizedObjectPrefix)
+		}
+		for _, fkName := range sortedKeys(table.ForeignKeys) {
+			a.add(fkName, anonymizedObjectPrefix)
+		}
+	}
+	for _, viewName := range sortedKeys(schema.Views) {
+		a.add(viewName, anonymizedTablePrefix)
+	}
+}
+
+// add assigns a token to every part of a dotted name
+func (a *SchemaAnonymizer) add(name, prefix string) {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			continue
+		}
+		if _, exists := a.tokens[part]; exists {
+			continue
+		}
+
+		token := ""
+		for n := len(a.names) + 1; ; n++ {
+			token = fmt.Sprintf("%s%d", prefix, n)
+			// Never hand out a token that is also a real name, the mapping must stay bijective
+			if _, taken := a.names[token]; !taken {
+				if _, isName := a.tokens[token]; !isName {
+					break
+				}
+			}
+		}
+		a.tokens[part] = token
+		a.names[token] = part
+		if _, exists := a.folded[strings.ToLower(part)]; !exists {
+			a.folded[strings.ToLower(part)] = token
+		}
+		a.added = true
+	}
+}
+
+// anonymizeName maps a possibly dotted name, parts without a token are kept
+func (a *SchemaAnonymizer) anonymizeName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if token, ok := a.tokens[part]; ok {
+			parts[i] = token
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// AnonymizeText replaces the schema names of a free text such as a user message or a query with their tokens.
+// Names are matched case-insensitively, except all upper case words & function calls (ex: "COUNT(") which are
+// left alone since they are most likely SQL keywords & functions.
+func (a *SchemaAnonymizer) AnonymizeText(text string) string {
+	if len(a.tokens) == 0 || text == "" {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, match := range anonymizerWordRegex.FindAllStringIndex(text, -1) {
+		word := text[match[0]:match[1]]
+		token, ok := a.tokens[word]
+		if !ok {
+			isFunctionCall := match[1] < len(text) && text[match[1]] == '('
+			if word == strings.ToUpper(word) || isFunctionCall {
+				continue
+			}
+			if token, ok = a.folded[strings.ToLower(word)]; !ok {
+				continue
+			}
+		}
+		sb.WriteString(text[last:match[0]])
+		sb.WriteString(token)
+		last = match[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// AnonymizeValue anonymizes a decoded JSON value such as a stored assistant response: every string is passed to
+// AnonymizeText, the keys are kept except the column names keying the records of an "exampleResult"
+func (a *SchemaAnonymizer) AnonymizeValue(value interface{}) interface{} {
+	return a.mapValue(value, a.AnonymizeText, a.anonymizeName, false)
+}
+
+// DeanonymizeText replaces the tokens of a text with the real names, quoting the names that need it for the
+// database type unless the token is already quoted
+func (a *SchemaAnonymizer) DeanonymizeText(text string) string {
+	if len(a.names) == 0 || text == "" {
+		return text
+	}
+	return anonymizerTokenRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := anonymizerTokenRegex.FindStringSubmatch(match)
+		openQuote, token, closeQuote := groups[1], groups[2], groups[3]
+		name, ok := a.names[token]
+		if !ok {
+			return match
+		}
+		if openQuote != "" && openQuote == closeQuote {
+			return openQuote + name + closeQuote
+		}
+		return openQuote + a.quoteIfNeeded(name) + closeQuote
+	})
+}
+
+// DeanonymizeValue maps the tokens of a decoded JSON value such as the LLM response back to the real names, the keys
+// are handled as in AnonymizeValue
+func (a *SchemaAnonymizer) DeanonymizeValue(value interface{}) interface{} {
+	return a.mapValue(value, a.DeanonymizeText, a.deanonymizeName, false)
+}
+
+// deanonymizeName maps the tokens of a possibly dotted name back to the real names, without quoting
+func (a *SchemaAnonymizer) deanonymizeName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if realName, ok := a.names[part]; ok {
+			parts[i] = realName
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIfNeeded quotes a real name that wouldn't be read as is by the database, ex: a mixed case PostgreSQL table
+func (a *SchemaAnonymizer) quoteIfNeeded(name string) string {
+	switch a.dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		if !plainLowerIdentifier.MatchString(name) {
+			return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+		}
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeClickhouse:
+		if !plainIdentifier.MatchString(name) {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	return name
+}
+
+// mapValue walks a decoded JSON value, mapKeys is set inside example records whose keys are column names
+func (a *SchemaAnonymizer) mapValue(value interface{}, mapText, mapKey func(string) string, mapKeys bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		return mapText(v)
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			mappedKey := key
+			if mapKeys {
+				mappedKey = mapKey(key)
+			}
+			mapped[mappedKey] = a.mapValue(item, mapText, mapKey, mapKeys || key == "exampleResult")
+		}
+		return mapped
+	case []interface{}:
+		mapped := make([]interface{}, len(v))
+		for i, item := range v {
+			mapped[i] = a.mapValue(item, mapText, mapKey, mapKeys)
+		}
+		return mapped
+	case []map[string]interface{}:
+		mapped := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			mapped[i] = a.mapValue(item, mapText, mapKey, mapKeys).(map[string]interface{})
+		}
+		return mapped
+	case bson.M: // Stored LLM messages are decoded from MongoDB
+		return a.mapValue(map[string]interface{}(v), mapText, mapKey, mapKeys)
+	case bson.A:
+		return a.mapValue([]interface{}(v), mapText, mapKey, mapKeys)
+	case bson.D:
+		return a.mapValue(v.Map(), mapText, mapKey, mapKeys)
+	default:
+		return value
+	}
+}
+
+// anonymizeRecordKeys renames the keys of example records, values are data & are left to the column masks
+func (a *SchemaAnonymizer) anonymizeRecordKeys(record map[string]interface{}) map[string]interface{} {
+	anonymized := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = a.anonymizeRecordKeys(nested)
+		}
+		anonymized[a.anonymizeName(key)] = value
+	}
+	return anonymized
+}
+
+// AnonymizeStorage returns a copy of the storage with every name replaced by its token. Free text that can't be
+// mapped reliably, such as table & column comments, is dropped.
+func (a *SchemaAnonymizer) AnonymizeStorage(storage *SchemaStorage) *SchemaStorage {
+	if storage == nil || storage.LLMSchema == nil {
+		return storage
+	}
+
+	llmTables := make(map[string]LLMTableInfo, len(storage.LLMSchema.Tables))
+	for tableName, table := range storage.LLMSchema.Tables {
+		anonymizedTable := LLMTableInfo{
+			Name:       a.anonymizeName(table.Name),
+			PrimaryKey: a.anonymizeName(table.PrimaryKey),
+			RowCount:   table.RowCount,
+			Columns:    make([]LLMColumnInfo, len(table.Columns)),
+		}
+		for i, column := range table.Columns {
+			anonymizedTable.Columns[i] = LLMColumnInfo{
+				Name:       a.anonymizeName(column.Name),
+				Type:       column.Type,
+				IsNullable: column.IsNullable,
+				IsIndexed:  column.IsIndexed,
+				Collation:  column.Collation,
+			}
+		}
+		for _, record := range table.ExampleRecords {
+			anonymizedTable.ExampleRecords = append(anonymizedTable.ExampleRecords, a.anonymizeRecordKeys(record))
+		}
+		llmTables[a.anonymizeName(tableName)] = anonymizedTable
+	}
+
+	relationships := make([]SchemaRelationship, len(storage.LLMSchema.Relationships))
+	for i, relationship := range storage.LLMSchema.Relationships {
+		relationships[i] = SchemaRelationship{
+			FromTable: a.anonymizeName(relationship.FromTable),
+			ToTable:   a.anonymizeName(relationship.ToTable),
+			Type:      relationship.Type,
+			Through:   a.anonymizeName(relationship.Through),
+		}
+	}
+
+	anonymized := *storage
+	anonymized.LLMSchema = &LLMSchemaInfo{
+		Tables:        llmTables,
+		Relationships: relationships,
+	}
+	if storage.FullSchema != nil {
+		anonymized.FullSchema = a.anonymizeSchemaInfo(storage.FullSchema)
+	}
+	return &anonymized
+}
+
+// anonymizeSchemaInfo maps the parts of the full schema used when formatting for the LLM: indexes, constraints & foreign keys
+func (a *SchemaAnonymizer) anonymizeSchemaInfo(schema *SchemaInfo) *SchemaInfo {
+	tables := make(map[string]TableSchema, len(schema.Tables))
+	for tableName, table := range schema.Tables {
+		anonymizedTable := TableSchema{
+			Name:        a.anonymizeName(table.Name),
+			Indexes:     make(map[string]IndexInfo, len(table.Indexes)),
+			Constraints: make(map[string]ConstraintInfo, len(table.Constraints)),
+			ForeignKeys: make(map[string]ForeignKey, len(table.ForeignKeys)),
+			RowCount:    table.RowCount,
+		}
+		for name, index := range table.Indexes {
+			columns := make([]string, len(index.Columns))
+			for i, column := range index.Columns {
+				columns[i] = a.anonymizeName(column)
+			}
+			anonymizedTable.Indexes[a.anonymizeName(name)] = IndexInfo{
+				Name:     a.anonymizeName(index.Name),
+				Columns:  columns,
+				IsUnique: index.IsUnique,
+			}
+		}
+		for name, constraint := range table.Constraints {
+			columns := make([]string, len(constraint.Columns))
+			for i, column := range constraint.Columns {
+				columns[i] = a.anonymizeName(column)
+			}
+			anonymizedTable.Constraints[a.anonymizeName(name)] = ConstraintInfo{
+				Name:       a.anonymizeName(constraint.Name),
+				Type:       constraint.Type,
+				Definition: a.AnonymizeText(constraint.Definition),
+				Columns:    columns,
+			}
+		}
+		for name, fk := range table.ForeignKeys {
+			anonymizedTable.ForeignKeys[a.anonymizeName(name)] = ForeignKey{
+				Name:       a.anonymizeName(fk.Name),
+				ColumnName: a.anonymizeName(fk.ColumnName),
+				RefTable:   a.anonymizeName(fk.RefTable),
+				RefColumn:  a.anonymizeName(fk.RefColumn),
+				OnDelete:   fk.OnDelete,
+				OnUpdate:   fk.OnUpdate,
+			}
+		}
+		tables[a.anonymizeName(tableName)] = anonymizedTable
+	}
+	return &SchemaInfo{Tables: tables}
+}
+
+// FormatAnonymizedSchema formats the schema of the chat for the LLM with every name replaced by its token,
+// names missing from the anonymizer are added to it
+func (m *Manager) FormatAnonymizedSchema(ctx context.Context, chatID string, selectedCollections []string, anonymizer *SchemaAnonymizer) (string, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("connection not found for chat ID: %s", chatID)
+	}
+
+	db, err := m.GetConnection(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get database executor: %v", err)
+	}
+
+	storage, err := m.schemaManager.GetSchemaWithExamples(ctx, chatID, db, conn.Config.Type, selectedCollections)
+	if err != nil {
+		return "", fmt.Errorf("failed to get schema with examples: %v", err)
+	}
+	storage = m.schemaManager.maskExampleRecords(chatID, storage)
+	storage = m.schemaManager.prioritizeLLMSchema(chatID, storage)
+
+	anonymizer.AddSchema(storage.FullSchema)
+	if storage.LLMSchema != nil {
+		// Collections without a full schema entry, ex: MongoDB fields only known from the simplified schema
+		for _, tableName := range sortedKeys(storage.LLMSchema.Tables) {
+			anonymizer.add(tableName, anonymizedTablePrefix)
+			for _, column := range storage.LLMSchema.Tables[tableName].Columns {
+				anonymizer.add(column.Name, anonymizedColumnPrefix)
+			}
+		}
+	}
+
+	log.Printf("DBManager -> FormatAnonymizedSchema -> Anonymized %d names for chatID: %s", len(anonymizer.tokens), chatID)
+	return m.schemaManager.FormatSchemaForLLMWithExamples(anonymizer.AnonymizeStorage(storage)), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,123 @@
+package dbmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schemaTablePrefix starts the block of each table in a schema formatted for the LLM, see FormatSchemaForLLM
+const schemaTablePrefix = "Table: "
+
+// SchemaChunk is a part of a schema formatted for the LLM, see SplitSchemaForLLM
+type SchemaChunk struct {
+	Tables []string // Names of the tables of the part, as written in the schema
+	Text   string
+}
+
+type schemaTableBlock struct {
+	name string
+	text string
+}
+
+// splitSchemaTables splits a schema formatted for the LLM into the text before its first table & the block of each
+// table, a schema without tables is returned as its header
+func splitSchemaTables(schema string) (string, []schemaTableBlock) {
+	var starts []int
+	if strings.HasPrefix(schema, schemaTablePrefix) {
+		starts = append(starts, 0)
+	}
+	for offset := 0; ; {
+		i := strings.Index(schema[offset:], "\n"+schemaTablePrefix)
+		if i == -1 {
+			break
+		}
+		offset += i + 1
+		starts = append(starts, offset)
+	}
+	if len(starts) == 0 {
+		return schema, nil
+	}
+
+	blocks := make([]schemaTableBlock, 0, len(starts))
+	for k, start := range starts {
+		end := len(schema)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		text := schema[start:end]
+		name := strings.TrimPrefix(text, schemaTablePrefix)
+		if newline := strings.IndexByte(name, '\n'); newline != -1 {
+			name = name[:newline]
+		}
+		blocks = append(blocks, schemaTableBlock{name: strings.TrimSpace(name), text: text})
+	}
+	return schema[:starts[0]], blocks
+}
+
+// SplitSchemaForLLM splits a schema formatted for the LLM into parts of about maxChars characters, a table is never
+// split & a table larger than maxChars gets a part of its own. Each part is introduced as part i of n. A schema
+// fitting in maxChars, or without at least two tables, is returned as a single part.
+func SplitSchemaForLLM(schema string, maxChars int) []SchemaChunk {
+	header, blocks := splitSchemaTables(schema)
+	if maxChars <= 0 || len(schema) <= maxChars || len(blocks) < 2 {
+		tables := make([]string, len(blocks))
+		for i, block := range blocks {
+			tables[i] = block.name
+		}
+		return []SchemaChunk{{Tables: tables, Text: schema}}
+	}
+
+	var chunks []SchemaChunk
+	var current SchemaChunk
+	var text strings.Builder
+	flush := func() {
+		current.Text = text.String()
+		chunks = append(chunks, current)
+		current = SchemaChunk{}
+		text.Reset()
+	}
+	for _, block := range blocks {
+		if text.Len() > 0 && text.Len()+len(block.text) > maxChars {
+			flush()
+		}
+		current.Tables = append(current.Tables, block.name)
+		text.WriteString(block.text)
+	}
+	flush()
+
+	title := strings.TrimSuffix(strings.TrimSpace(header), ":")
+	for i := range chunks {
+		chunks[i].Text = fmt.Sprintf("%s (part %d of %d, the other parts have the remaining tables):\n\n%s", title, i+1, len(chunks), chunks[i].Text)
+	}
+	return chunks
+}
+
+// ScopeSchemaForLLM keeps the tables of a schema formatted for the LLM that are in tables (case insensitive), the
+// names of the other tables are listed at the end so that the LLM knows they exist
+func ScopeSchemaForLLM(schema string, tables []string) string {
+	header, blocks := splitSchemaTables(schema)
+	if len(blocks) == 0 {
+		return schema
+	}
+	kept := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		kept[strings.ToLower(table)] = true
+	}
+
+	var result strings.Builder
+	result.WriteString(header)
+	var others []string
+	for _, block := range blocks {
+		if kept[strings.ToLower(block.name)] {
+			result.WriteString(block.text)
+		} else {
+			others = append(others, block.name)
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		result.WriteString(fmt.Sprintf("Other tables, left out as they aren't needed for the request: %s\n", strings.Join(others, ", ")))
+	}
+	return result.String()
+}
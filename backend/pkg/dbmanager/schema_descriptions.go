@@ -0,0 +1,146 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SchemaDescription is the business meaning of a table, or of one of its columns if Column is set, written by the user
+type SchemaDescription struct {
+	Table       string
+	Column      string
+	Description string
+}
+
+// SchemaDescriptions maps "table" or "table.column" to a description written by the user. They are kept apart from
+// the schema fetched from the database & layered over its comments each time the schema is formatted for the LLM, so
+// that they survive schema refreshes.
+type SchemaDescriptions map[string]string
+
+// NewSchemaDescriptions indexes descriptions by table & column, empty descriptions are skipped
+func NewSchemaDescriptions(descriptions []SchemaDescription) SchemaDescriptions {
+	indexed := make(SchemaDescriptions, len(descriptions))
+	for _, description := range descriptions {
+		if strings.TrimSpace(description.Description) != "" {
+			indexed[schemaDescriptionKey(description.Table, description.Column)] = description.Description
+		}
+	}
+	return indexed
+}
+
+// schemaDescriptionKey returns the key of the description of a table, or of one of its columns if column is set
+func schemaDescriptionKey(table, column string) string {
+	if column == "" {
+		return table
+	}
+	return table + "." + column
+}
+
+// mergeDescription puts the user description of a table or column before the comment fetched from the database
+func (d SchemaDescriptions) mergeDescription(table, column, fetched string) string {
+	custom := strings.TrimSpace(d[schemaDescriptionKey(table, column)])
+	switch {
+	case custom == "":
+		return fetched
+	case fetched == "" || fetched == custom:
+		return custom
+	}
+	return fmt.Sprintf("%s (database comment: %s)", custom, fetched)
+}
+
+// ValidateSchemaDescriptions checks that the described tables & columns exist in the known schema of a chat, nothing
+// can be checked before the schema is known (ex: generate only chats)
+func (sm *SchemaManager) ValidateSchemaDescriptions(ctx context.Context, chatID string, descriptions []SchemaDescription) error {
+	if len(descriptions) == 0 {
+		return nil
+	}
+	schema := sm.getKnownSchema(ctx, chatID)
+	if schema == nil {
+		return nil
+	}
+	for _, description := range descriptions {
+		table, ok := schema.Tables[description.Table]
+		if !ok {
+			return fmt.Errorf("table %s doesn't exist in the schema", description.Table)
+		}
+		if description.Column == "" {
+			continue
+		}
+		if _, ok := table.Columns[description.Column]; !ok {
+			return fmt.Errorf("column %s doesn't exist in table %s", description.Column, description.Table)
+		}
+	}
+	return nil
+}
+
+// SetSchemaDescriptions sets the table & column descriptions written by the user of a chat, an empty map clears them
+func (sm *SchemaManager) SetSchemaDescriptions(chatID string, descriptions SchemaDescriptions) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(descriptions) == 0 {
+		delete(sm.schemaDescriptions, chatID)
+		return
+	}
+	sm.schemaDescriptions[chatID] = descriptions
+}
+
+// GetSchemaDescriptions returns the table & column descriptions written by the user of a chat
+func (sm *SchemaManager) GetSchemaDescriptions(chatID string) SchemaDescriptions {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.schemaDescriptions[chatID]
+}
+
+// describeLLMSchema returns a copy of the storage with the user descriptions of the chat merged into the table &
+// column descriptions, the stored schema is left as fetched
+func (sm *SchemaManager) describeLLMSchema(chatID string, storage *SchemaStorage) *SchemaStorage {
+	descriptions := sm.GetSchemaDescriptions(chatID)
+	if len(descriptions) == 0 || storage == nil || storage.LLMSchema == nil {
+		return storage
+	}
+
+	describedTables := make(map[string]LLMTableInfo, len(storage.LLMSchema.Tables))
+	for tableName, table := range storage.LLMSchema.Tables {
+		table.Description = descriptions.mergeDescription(tableName, "", table.Description)
+		columns := make([]LLMColumnInfo, len(table.Columns))
+		for i, column := range table.Columns {
+			column.Description = descriptions.mergeDescription(tableName, column.Name, column.Description)
+			columns[i] = column
+		}
+		table.Columns = columns
+		describedTables[tableName] = table
+	}
+
+	describedStorage := *storage
+	describedStorage.LLMSchema = &LLMSchemaInfo{
+		Tables:        describedTables,
+		Relationships: storage.LLMSchema.Relationships,
+	}
+	log.Printf("SchemaManager -> describeLLMSchema -> Applied %d descriptions for chatID: %s", len(descriptions), chatID)
+	return &describedStorage
+}
+
+// DescribeSchema returns a copy of a schema with the user descriptions of the chat merged into the table & column
+// comments, for the schema formatted without example records
+func (sm *SchemaManager) DescribeSchema(chatID string, schema *SchemaInfo) *SchemaInfo {
+	descriptions := sm.GetSchemaDescriptions(chatID)
+	if len(descriptions) == 0 || schema == nil {
+		return schema
+	}
+
+	describedSchema := *schema
+	describedSchema.Tables = make(map[string]TableSchema, len(schema.Tables))
+	for tableName, table := range schema.Tables {
+		table.Comment = descriptions.mergeDescription(tableName, "", table.Comment)
+		columns := make(map[string]ColumnInfo, len(table.Columns))
+		for columnName, column := range table.Columns {
+			column.Comment = descriptions.mergeDescription(tableName, columnName, column.Comment)
+			columns[columnName] = column
+		}
+		table.Columns = columns
+		describedSchema.Tables[tableName] = table
+	}
+	return &describedSchema
+}
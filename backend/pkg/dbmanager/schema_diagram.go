@@ -0,0 +1,201 @@
+package dbmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Formats of the schema diagrams, see ExportSchemaDiagram
+const (
+	SchemaDiagramFormatMermaid = "mermaid" // Mermaid erDiagram
+	SchemaDiagramFormatDOT     = "dot"     // Graphviz DOT
+)
+
+// ErrNoStoredSchema is returned when the schema of a chat was never fetched & stored
+var ErrNoStoredSchema = errors.New("no schema has been stored for this chat yet, refresh the schema first")
+
+// mermaidInvalidCharRegex matches the characters Mermaid doesn't accept in the names & types of an erDiagram
+var mermaidInvalidCharRegex = regexp.MustCompile(`[^A-Za-z0-9_\-()\[\]]`)
+
+// IsValidSchemaDiagramFormat checks if format is one of the SchemaDiagramFormat constants
+func IsValidSchemaDiagramFormat(format string) bool {
+	return format == SchemaDiagramFormatMermaid || format == SchemaDiagramFormatDOT
+}
+
+// ExportSchemaDiagram renders the stored schema of a chat as an ER diagram: its tables with their columns, primary &
+// foreign keys, and an edge for each foreign key with its cardinality (see determineRelationType). The diagram is
+// rendered from the stored schema, the database doesn't need to be connected. Foreign keys referencing a table outside
+// the schema (ex: not selected) have no edge.
+func (m *Manager) ExportSchemaDiagram(ctx context.Context, chatID, format string) (string, error) {
+	if !IsValidSchemaDiagramFormat(format) {
+		return "", fmt.Errorf("unsupported diagram format: %s, use %s or %s", format, SchemaDiagramFormatMermaid, SchemaDiagramFormatDOT)
+	}
+	schema := m.GetKnownSchema(ctx, chatID)
+	if schema == nil || len(schema.Tables) == 0 {
+		return "", ErrNoStoredSchema
+	}
+
+	tables := newDiagramTables(schema)
+	var relationships []ForeignKeyRelationship
+	for _, rel := range m.schemaManager.ExtractForeignKeyRelationships(schema) {
+		if _, exists := schema.Tables[rel.ToTable]; exists {
+			relationships = append(relationships, rel)
+		}
+	}
+	if format == SchemaDiagramFormatDOT {
+		return renderDOTDiagram(tables, relationships), nil
+	}
+	return renderMermaidDiagram(tables, relationships), nil
+}
+
+// diagramTable is a table of a diagram, its primary key columns first then the others by name
+type diagramTable struct {
+	name    string
+	columns []diagramColumn
+}
+
+type diagramColumn struct {
+	name         string
+	dataType     string
+	isNullable   bool
+	isPrimaryKey bool
+	isForeignKey bool
+}
+
+func newDiagramTables(schema *SchemaInfo) []diagramTable {
+	tables := make([]diagramTable, 0, len(schema.Tables))
+	for tableName, table := range schema.Tables {
+		primaryKey := make(map[string]bool)
+		for _, constraint := range table.Constraints {
+			if constraint.Type == "PRIMARY KEY" {
+				for _, column := range constraint.Columns {
+					primaryKey[column] = true
+				}
+			}
+		}
+		foreignKey := make(map[string]bool)
+		for _, fk := range table.ForeignKeys {
+			foreignKey[fk.ColumnName] = true
+		}
+
+		diagram := diagramTable{name: tableName, columns: make([]diagramColumn, 0, len(table.Columns))}
+		for columnName, column := range table.Columns {
+			diagram.columns = append(diagram.columns, diagramColumn{
+				name:         columnName,
+				dataType:     column.Type,
+				isNullable:   column.IsNullable,
+				isPrimaryKey: primaryKey[columnName],
+				isForeignKey: foreignKey[columnName],
+			})
+		}
+		sort.Slice(diagram.columns, func(i, j int) bool {
+			if diagram.columns[i].isPrimaryKey != diagram.columns[j].isPrimaryKey {
+				return diagram.columns[i].isPrimaryKey
+			}
+			return diagram.columns[i].name < diagram.columns[j].name
+		})
+		tables = append(tables, diagram)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].name < tables[j].name })
+	return tables
+}
+
+// renderMermaidDiagram renders an erDiagram, the referenced table is on the left of each relationship: exactly one
+// (||) or zero or one (|o) when the foreign key is nullable, to many (o{) or to one (o|) referencing rows
+func renderMermaidDiagram(tables []diagramTable, relationships []ForeignKeyRelationship) string {
+	nullableColumns := make(map[string]bool)
+	var builder strings.Builder
+	builder.WriteString("erDiagram\n")
+	for _, table := range tables {
+		builder.WriteString(fmt.Sprintf("    %s {\n", mermaidName(table.name)))
+		for _, column := range table.columns {
+			nullableColumns[table.name+"."+column.name] = column.isNullable
+			var keys []string
+			if column.isPrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if column.isForeignKey {
+				keys = append(keys, "FK")
+			}
+			line := fmt.Sprintf("        %s %s", mermaidName(column.dataType), mermaidName(column.name))
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ", ")
+			}
+			builder.WriteString(line + "\n")
+		}
+		builder.WriteString("    }\n")
+	}
+
+	for _, rel := range relationships {
+		referenced := "||"
+		if nullableColumns[rel.FromTable+"."+rel.FromColumn] {
+			referenced = "|o"
+		}
+		referencing := "o{"
+		if rel.Type == "one_to_one" {
+			referencing = "o|"
+		}
+		builder.WriteString(fmt.Sprintf("    %s %s--%s %s : %q\n", mermaidName(rel.ToTable), referenced, referencing,
+			mermaidName(rel.FromTable), rel.FromColumn))
+	}
+	return builder.String()
+}
+
+// mermaidName replaces the characters Mermaid doesn't accept in a name or a type with _, ex: character varying
+// becomes character_varying & public.orders becomes public_orders
+func mermaidName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return mermaidInvalidCharRegex.ReplaceAllString(name, "_")
+}
+
+// renderDOTDiagram renders a digraph where each table is an HTML table node with a port per column, each foreign key
+// is an edge from its column to the referenced column labelled with the cardinality (ex: * to 1)
+func renderDOTDiagram(tables []diagramTable, relationships []ForeignKeyRelationship) string {
+	var builder strings.Builder
+	builder.WriteString("digraph schema {\n")
+	builder.WriteString("    rankdir=LR;\n")
+	builder.WriteString("    node [shape=plaintext];\n")
+	for _, table := range tables {
+		builder.WriteString(fmt.Sprintf("    %s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", dotQuote(table.name)))
+		builder.WriteString(fmt.Sprintf("<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(table.name)))
+		for _, column := range table.columns {
+			var keys []string
+			if column.isPrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if column.isForeignKey {
+				keys = append(keys, "FK")
+			}
+			text := fmt.Sprintf("%s: %s", column.name, column.dataType)
+			if len(keys) > 0 {
+				text += " (" + strings.Join(keys, ", ") + ")"
+			}
+			builder.WriteString(fmt.Sprintf("<tr><td port=\"%s\" align=\"left\">%s</td></tr>", html.EscapeString(column.name), html.EscapeString(text)))
+		}
+		builder.WriteString("</table>>];\n")
+	}
+
+	for _, rel := range relationships {
+		tailLabel := "*"
+		if rel.Type == "one_to_one" {
+			tailLabel = "1"
+		}
+		builder.WriteString(fmt.Sprintf("    %s:%s -> %s:%s [taillabel=%s, headlabel=\"1\", label=%s];\n",
+			dotQuote(rel.FromTable), dotQuote(rel.FromColumn), dotQuote(rel.ToTable), dotQuote(rel.ToColumn),
+			dotQuote(tailLabel), dotQuote(rel.Name)))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// dotQuote quotes a DOT identifier
+func dotQuote(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(id) + `"`
+}
@@ -0,0 +1,243 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Causes of an empty schema, see Manager.DiagnoseEmptySchema
+const (
+	EmptySchemaNoTables          = "NO_TABLES"               // The database has no tables
+	EmptySchemaPermissionDenied  = "PERMISSION_DENIED"       // The user can't see the tables of the database
+	EmptySchemaOtherSchemas      = "TABLES_IN_OTHER_SCHEMAS" // PostgreSQL, the tables aren't in the public schema that is read
+	EmptySchemaSelectionNotFound = "SELECTED_TABLES_MISSING" // None of the selected tables exist
+	EmptySchemaUnknown           = "UNKNOWN"
+)
+
+// EmptySchemaDiagnosis tells why a schema refresh returned no tables
+type EmptySchemaDiagnosis struct {
+	Cause   string `json:"cause"`
+	Message string `json:"message"` // Actionable message for the user, it replaces the schema given to the LLM
+}
+
+// mysqlGrantRegex splits a MySQL or ClickHouse grant, ex: GRANT SELECT, INSERT ON `shop`.* TO `bot`@`%`
+var mysqlGrantRegex = regexp.MustCompile(`(?i)^GRANT\s+(.+?)\s+ON\s+(\S+)\s+TO\s`)
+
+// DiagnoseEmptySchema finds out why the schema of a chat has no tables: the database genuinely has none, the user
+// isn't allowed to see them, they are outside of the schema read or the selected tables don't exist. The subscribers of
+// the chat are alerted with StatusSchemaEmpty. The diagnosis is best effort, its queries failing give EmptySchemaUnknown.
+func (m *Manager) DiagnoseEmptySchema(ctx context.Context, chatID string, selectedCollections []string) *EmptySchemaDiagnosis {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaUnknown,
+			Message: "The schema of the database is empty & the connection was lost before finding out why, reconnect & refresh the schema.",
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var diagnosis *EmptySchemaDiagnosis
+	var visibleTables int64
+	var err error
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		diagnosis, visibleTables, err = diagnosePostgresEmptySchema(ctx, conn)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		diagnosis, visibleTables, err = diagnoseGrantedEmptySchema(ctx, conn, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE()", "SHOW GRANTS")
+	case constants.DatabaseTypeClickhouse:
+		diagnosis, visibleTables, err = diagnoseGrantedEmptySchema(ctx, conn, "SELECT count() FROM system.tables WHERE database = currentDatabase()", "SHOW GRANTS")
+	case constants.DatabaseTypeMongoDB:
+		diagnosis, visibleTables, err = diagnoseMongoEmptySchema(ctx, conn)
+	default:
+		err = fmt.Errorf("unsupported database type: %s", conn.Config.Type)
+	}
+	if err != nil {
+		log.Printf("DBManager -> DiagnoseEmptySchema -> Failed to diagnose the empty schema of chatID %s: %v", chatID, err)
+	}
+
+	if diagnosis == nil {
+		diagnosis = &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaUnknown,
+			Message: fmt.Sprintf("No tables were found in the database %s. Check that it is the right database & that %s is allowed to read its tables, then refresh the schema.", conn.Config.Database, connectionUsername(conn)),
+		}
+		if visibleTables > 0 {
+			diagnosis.Message = fmt.Sprintf("The database %s has %d tables but none of their schema could be read. Check that %s is allowed to read them, then refresh the schema.", conn.Config.Database, visibleTables, connectionUsername(conn))
+		}
+		// The tables are there, the selection of the chat doesn't match any of them
+		if visibleTables > 0 && len(selectedCollections) > 0 && !(len(selectedCollections) == 1 && selectedCollections[0] == "ALL") {
+			diagnosis = &EmptySchemaDiagnosis{
+				Cause:   EmptySchemaSelectionNotFound,
+				Message: fmt.Sprintf("None of the tables selected for this chat (%s) exist in the database %s, which has %d other tables. Update the selected tables in the chat settings.", strings.Join(selectedCollections, ", "), conn.Config.Database, visibleTables),
+			}
+		}
+	}
+
+	log.Printf("DBManager -> DiagnoseEmptySchema -> chatID: %s, cause: %s", chatID, diagnosis.Cause)
+	m.notifySubscribers(chatID, conn.UserID, StatusSchemaEmpty, diagnosis)
+	return diagnosis
+}
+
+func connectionUsername(conn *Connection) string {
+	if conn.Config.Username != nil && *conn.Config.Username != "" {
+		return "the user " + *conn.Config.Username
+	}
+	return "the connection user"
+}
+
+// diagnosePostgresEmptySchema compares the tables of pg_class, listed whatever the privileges, with the ones the user
+// may read. Only the public schema is read by the schema fetch. The visible tables are returned with the diagnosis, nil
+// when no cause stands out.
+func diagnosePostgresEmptySchema(ctx context.Context, conn *Connection) (*EmptySchemaDiagnosis, int64, error) {
+	if conn.DB == nil {
+		return nil, 0, fmt.Errorf("no database connection")
+	}
+	var publicTables, readableTables, otherTables int64
+	err := conn.DB.WithContext(ctx).Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE n.nspname = 'public'),
+			COUNT(*) FILTER (WHERE n.nspname = 'public' AND has_schema_privilege(n.oid, 'USAGE') AND has_table_privilege(c.oid, 'SELECT')),
+			COUNT(*) FILTER (WHERE n.nspname <> 'public')
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg\_%'`).Row().Scan(&publicTables, &readableTables, &otherTables)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case publicTables > 0 && readableTables == 0:
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaPermissionDenied,
+			Message: fmt.Sprintf("The database %s has %d tables in the public schema but %s isn't allowed to read any of them. Grant the access with: GRANT USAGE ON SCHEMA public TO %s; GRANT SELECT ON ALL TABLES IN SCHEMA public TO %s; then refresh the schema.", conn.Config.Database, publicTables, connectionUsername(conn), grantee(conn), grantee(conn)),
+		}, readableTables, nil
+	case publicTables == 0 && otherTables > 0:
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaOtherSchemas,
+			Message: fmt.Sprintf("The database %s has no tables in the public schema, its %d tables are in other schemas which aren't read. Move the tables to be queried to the public schema, then refresh the schema.", conn.Config.Database, otherTables),
+		}, readableTables, nil
+	case publicTables == 0:
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaNoTables,
+			Message: fmt.Sprintf("The database %s has no tables yet. Check that the connection uses the right database, or create tables & refresh the schema.", conn.Config.Database),
+		}, 0, nil
+	}
+	return nil, readableTables, nil
+}
+
+// diagnoseGrantedEmptySchema diagnoses the databases listing only the tables the user has privileges on (MySQL,
+// ClickHouse): with no visible table, the grants of the user tell an empty database from a missing privilege
+func diagnoseGrantedEmptySchema(ctx context.Context, conn *Connection, countQuery, grantsQuery string) (*EmptySchemaDiagnosis, int64, error) {
+	if conn.DB == nil {
+		return nil, 0, fmt.Errorf("no database connection")
+	}
+	var visibleTables int64
+	if err := conn.DB.WithContext(ctx).Raw(countQuery).Row().Scan(&visibleTables); err != nil {
+		return nil, 0, err
+	}
+	if visibleTables > 0 {
+		return nil, visibleTables, nil
+	}
+
+	rows, err := conn.DB.WithContext(ctx).Raw(grantsQuery).Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var grants []string
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, 0, err
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if grantsCoverDatabase(grants, conn.Config.Database) {
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaNoTables,
+			Message: fmt.Sprintf("The database %s has no tables yet. Check that the connection uses the right database, or create tables & refresh the schema.", conn.Config.Database),
+		}, 0, nil
+	}
+	return &EmptySchemaDiagnosis{
+		Cause:   EmptySchemaPermissionDenied,
+		Message: fmt.Sprintf("%s has no privileges on the database %s, its tables can't be seen. Grant the access with: GRANT SELECT ON %s.* TO %s; then refresh the schema.", capitalize(connectionUsername(conn)), conn.Config.Database, conn.Config.Database, grantee(conn)),
+	}, 0, nil
+}
+
+// grantsCoverDatabase checks if one of the grants of a user gives a privilege other than USAGE on a database, directly
+// or through *.*. Privileges granted through roles aren't resolved.
+func grantsCoverDatabase(grants []string, database string) bool {
+	for _, grant := range grants {
+		match := mysqlGrantRegex.FindStringSubmatch(strings.TrimSpace(grant))
+		if match == nil || strings.EqualFold(strings.TrimSpace(match[1]), "USAGE") {
+			continue
+		}
+		target := strings.ReplaceAll(match[2], "`", "")
+		if target == "*.*" || target == "*" {
+			return true
+		}
+		if dot := strings.Index(target, "."); dot != -1 && strings.EqualFold(target[:dot], database) {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnoseMongoEmptySchema lists the collections without restricting them to the authorized ones, an authorization
+// error means the user can't list them
+func diagnoseMongoEmptySchema(ctx context.Context, conn *Connection) (*EmptySchemaDiagnosis, int64, error) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok || wrapper == nil {
+		return nil, 0, fmt.Errorf("invalid MongoDB connection")
+	}
+	collections, err := wrapper.Client.Database(wrapper.Database).ListCollectionNames(ctx, bson.M{}, options.ListCollections().SetNameOnly(true))
+	if err != nil {
+		message := strings.ToLower(err.Error())
+		if strings.Contains(message, "not authorized") || strings.Contains(message, "unauthorized") {
+			return &EmptySchemaDiagnosis{
+				Cause:   EmptySchemaPermissionDenied,
+				Message: fmt.Sprintf("%s isn't allowed to list the collections of the database %s. Give it the read role on the database, then refresh the schema.", capitalize(connectionUsername(conn)), wrapper.Database),
+			}, 0, nil
+		}
+		return nil, 0, err
+	}
+	if len(collections) == 0 {
+		return &EmptySchemaDiagnosis{
+			Cause:   EmptySchemaNoTables,
+			Message: fmt.Sprintf("The database %s doesn't exist or has no collections yet. Check that the connection uses the right database, or create collections & refresh the schema.", wrapper.Database),
+		}, 0, nil
+	}
+	return nil, int64(len(collections)), nil
+}
+
+// grantee is the user of a connection as written in a GRANT
+func grantee(conn *Connection) string {
+	if conn.Config.Username != nil && *conn.Config.Username != "" {
+		return *conn.Config.Username
+	}
+	return "<user>"
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
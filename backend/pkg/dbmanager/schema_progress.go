@@ -0,0 +1,40 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type schemaProgressKey struct{}
+
+// SchemaProgress is sent to the chat streams after every table processed while refreshing the schema
+type SchemaProgress struct {
+	Table      string `json:"table"`
+	Processed  int    `json:"processed"`
+	Total      int    `json:"total"`
+	DurationMs int64  `json:"duration_ms"` // Time spent on the table, example records included
+	ElapsedMs  int64  `json:"elapsed_ms"`  // Time spent since the first table
+	Message    string `json:"message"`     // ex: "Fetched 42/300 tables"
+}
+
+// withSchemaProgress makes the schema manager call report after every table it processes with ctx
+func withSchemaProgress(ctx context.Context, report func(SchemaProgress)) context.Context {
+	return context.WithValue(ctx, schemaProgressKey{}, report)
+}
+
+// reportSchemaProgress reports a processed table, nothing is done unless the context was made by withSchemaProgress
+func reportSchemaProgress(ctx context.Context, table string, processed, total int, tableStart, start time.Time) {
+	report, ok := ctx.Value(schemaProgressKey{}).(func(SchemaProgress))
+	if !ok || report == nil {
+		return
+	}
+	report(SchemaProgress{
+		Table:      table,
+		Processed:  processed,
+		Total:      total,
+		DurationMs: time.Since(tableStart).Milliseconds(),
+		ElapsedMs:  time.Since(start).Milliseconds(),
+		Message:    fmt.Sprintf("Fetched %d/%d tables", processed, total),
+	})
+}
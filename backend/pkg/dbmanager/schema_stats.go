@@ -0,0 +1,71 @@
+package dbmanager
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaStats are the aggregates of the stored schema of a chat, shown on the dashboards
+type SchemaStats struct {
+	TableCount        int
+	TotalRowCount     int64 // Sum of the estimated row counts of the tables
+	RelationshipCount int
+	UpdatedAt         time.Time
+	Tables            []TableRowCount // By row count, the largest first
+}
+
+// TableRowCount is the estimated row count of a table, as stored with the schema
+type TableRowCount struct {
+	Name     string
+	RowCount int64
+}
+
+// GetSchemaStats computes the aggregates of the stored schema of a chat, the database isn't queried. The row counts &
+// relationships are those of the LLM schema, or of the full schema for a schema stored without it. ErrNoStoredSchema is
+// returned when the schema of the chat was never fetched.
+func (m *Manager) GetSchemaStats(ctx context.Context, chatID string) (*SchemaStats, error) {
+	chatID = connectionKey(ctx, chatID)
+	if m.schemaManager == nil {
+		return nil, ErrNoStoredSchema
+	}
+	storage, err := m.schemaManager.storageService.Retrieve(ctx, chatID)
+	if err != nil {
+		if strings.Contains(err.Error(), "key does not exist") {
+			return nil, ErrNoStoredSchema
+		}
+		return nil, err
+	}
+	if storage == nil || storage.FullSchema == nil || len(storage.FullSchema.Tables) == 0 {
+		return nil, ErrNoStoredSchema
+	}
+
+	stats := &SchemaStats{UpdatedAt: storage.UpdatedAt}
+	if stats.UpdatedAt.IsZero() {
+		stats.UpdatedAt = storage.FullSchema.UpdatedAt
+	}
+	if storage.LLMSchema != nil && len(storage.LLMSchema.Tables) > 0 {
+		for name, table := range storage.LLMSchema.Tables {
+			stats.Tables = append(stats.Tables, TableRowCount{Name: name, RowCount: table.RowCount})
+		}
+		stats.RelationshipCount = len(storage.LLMSchema.Relationships)
+	} else {
+		for name, table := range storage.FullSchema.Tables {
+			stats.Tables = append(stats.Tables, TableRowCount{Name: name, RowCount: table.RowCount})
+		}
+		stats.RelationshipCount = len(m.schemaManager.ExtractForeignKeyRelationships(storage.FullSchema))
+	}
+
+	stats.TableCount = len(stats.Tables)
+	for _, table := range stats.Tables {
+		stats.TotalRowCount += table.RowCount
+	}
+	sort.Slice(stats.Tables, func(i, j int) bool {
+		if stats.Tables[i].RowCount != stats.Tables[j].RowCount {
+			return stats.Tables[i].RowCount > stats.Tables[j].RowCount
+		}
+		return stats.Tables[i].Name < stats.Tables[j].Name
+	})
+	return stats, nil
+}
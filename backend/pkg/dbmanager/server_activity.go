@@ -0,0 +1,305 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm"
+)
+
+// connectionApplicationName tags the connections DataBot opens, as application_name on PostgreSQL & as the program_name
+// connection attribute on MySQL, so that the server activity of other clients sharing the database user is left alone
+const connectionApplicationName = "databot"
+
+// ServerActivity is a query or transaction running on the database server. Only the activity of the database user
+// of the connection is listed, so that operators can stop runaway queries DataBot started without seeing other users' work.
+// On PostgreSQL & MySQL it is further restricted to the connections DataBot opened, see connectionApplicationName.
+type ServerActivity struct {
+	ID            string `json:"id"` // pid, thread ID, query ID or operation ID depending on the database type
+	User          string `json:"user"`
+	Database      string `json:"database"`
+	State         string `json:"state"`
+	Query         string `json:"query"`
+	DurationMs    int64  `json:"duration_ms"` // Since the start of the transaction if one is open, of the query otherwise
+	InTransaction bool   `json:"in_transaction"`
+}
+
+// ActivitySupported checks if listing & terminating server activity is supported for the database type
+func ActivitySupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL,
+		constants.DatabaseTypeMariaDB, constants.DatabaseTypeClickhouse, constants.DatabaseTypeMongoDB:
+		return true
+	}
+	return false
+}
+
+// ListServerActivity lists the active queries & open transactions of the connection's database user, longest running first
+func (m *Manager) ListServerActivity(ctx context.Context, chatID string) ([]ServerActivity, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found for chat %s", chatID)
+	}
+
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return listPostgresActivity(ctx, conn.DB)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return listMySQLActivity(ctx, conn.DB)
+	case constants.DatabaseTypeClickhouse:
+		return listClickHouseActivity(ctx, conn.DB)
+	case constants.DatabaseTypeMongoDB:
+		return listMongoDBActivity(ctx, conn)
+	default:
+		return nil, fmt.Errorf("server activity is not supported for %s", conn.Config.Type)
+	}
+}
+
+// TerminateServerActivity stops a query or transaction listed by ListServerActivity, activity of other users can't be terminated.
+// The running query is cancelled unless terminate is set, in which case the whole backend (PostgreSQL) or connection (MySQL)
+// is closed, rolling back its open transaction. ClickHouse queries & MongoDB operations can only be killed.
+func (m *Manager) TerminateServerActivity(ctx context.Context, chatID, activityID string, terminate bool) error {
+	activities, err := m.ListServerActivity(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, activity := range activities {
+		if activity.ID == activityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("activity %s not found, it may have already finished", activityID)
+	}
+
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("connection not found for chat %s", chatID)
+	}
+
+	log.Printf("DBManager -> TerminateServerActivity -> Stopping %s activity %s for chatID: %s (terminate: %t)", conn.Config.Type, activityID, chatID, terminate)
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		pid, err := strconv.Atoi(activityID)
+		if err != nil {
+			return fmt.Errorf("invalid activity ID: %s", activityID)
+		}
+		function, action := "pg_cancel_backend", "cancel the query of"
+		if terminate {
+			function, action = "pg_terminate_backend", "terminate"
+		}
+		var stopped bool
+		if err := conn.DB.WithContext(ctx).Raw(fmt.Sprintf("SELECT %s(?)", function), pid).Row().Scan(&stopped); err != nil {
+			return fmt.Errorf("failed to %s backend %d: %v", action, pid, err)
+		}
+		if !stopped {
+			return fmt.Errorf("could not %s backend %d", action, pid)
+		}
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		threadID, err := strconv.ParseUint(activityID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid activity ID: %s", activityID)
+		}
+		// KILL doesn't accept placeholders, the ID is validated as a number above
+		statement := fmt.Sprintf("KILL QUERY %d", threadID)
+		if terminate {
+			statement = fmt.Sprintf("KILL CONNECTION %d", threadID)
+		}
+		if err := conn.DB.WithContext(ctx).Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to kill thread %d: %v", threadID, err)
+		}
+	case constants.DatabaseTypeClickhouse:
+		if err := conn.DB.WithContext(ctx).Exec("KILL QUERY WHERE query_id = ? ASYNC", activityID).Error; err != nil {
+			return fmt.Errorf("failed to kill query %s: %v", activityID, err)
+		}
+	case constants.DatabaseTypeMongoDB:
+		wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+		if !ok || wrapper == nil {
+			return fmt.Errorf("invalid MongoDB connection")
+		}
+		var op interface{} = activityID // "shard:opid" on sharded clusters
+		if opID, err := strconv.ParseInt(activityID, 10, 64); err == nil {
+			op = opID
+		}
+		if err := wrapper.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "killOp", Value: 1}, {Key: "op", Value: op}}).Err(); err != nil {
+			return fmt.Errorf("failed to kill operation %s: %v", activityID, err)
+		}
+	}
+	return nil
+}
+
+func listPostgresActivity(ctx context.Context, db *gorm.DB) ([]ServerActivity, error) {
+	rows, err := db.WithContext(ctx).Raw(`
+		SELECT pid::text, usename, datname, state, query, xact_start IS NOT NULL,
+			(EXTRACT(EPOCH FROM (now() - COALESCE(xact_start, query_start))) * 1000)::bigint
+		FROM pg_stat_activity
+		WHERE usename = current_user AND datname = current_database() AND application_name = ?
+			AND pid <> pg_backend_pid() AND state <> 'idle'
+		ORDER BY COALESCE(xact_start, query_start)`, connectionApplicationName).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pg_stat_activity: %v", err)
+	}
+	defer rows.Close()
+
+	activities := []ServerActivity{}
+	for rows.Next() {
+		var activity ServerActivity
+		var user, database, state, query sql.NullString
+		var duration sql.NullInt64
+		if err := rows.Scan(&activity.ID, &user, &database, &state, &query, &activity.InTransaction, &duration); err != nil {
+			return nil, fmt.Errorf("failed to read pg_stat_activity: %v", err)
+		}
+		activity.User, activity.Database, activity.State, activity.Query = user.String, database.String, state.String, query.String
+		activity.DurationMs = duration.Int64
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+func listMySQLActivity(ctx context.Context, db *gorm.DB) ([]ServerActivity, error) {
+	// The program_name attribute is only readable through performance_schema, it must be enabled to list the activity
+	const ownThreads = `p.USER = SUBSTRING_INDEX(CURRENT_USER(), '@', 1) AND p.ID <> CONNECTION_ID()
+		AND p.ID IN (SELECT PROCESSLIST_ID FROM performance_schema.session_connect_attrs WHERE ATTR_NAME = 'program_name' AND ATTR_VALUE = ?)`
+	query := `
+		SELECT p.ID, p.USER, p.DB, CONCAT_WS(': ', p.COMMAND, NULLIF(p.STATE, '')), p.INFO, t.trx_id IS NOT NULL,
+			COALESCE(TIMESTAMPDIFF(MICROSECOND, t.trx_started, NOW()) DIV 1000, p.TIME * 1000)
+		FROM information_schema.PROCESSLIST p
+		LEFT JOIN information_schema.INNODB_TRX t ON t.trx_mysql_thread_id = p.ID
+		WHERE ` + ownThreads + ` AND (p.COMMAND <> 'Sleep' OR t.trx_id IS NOT NULL)
+		ORDER BY 7 DESC`
+	rows, err := db.WithContext(ctx).Raw(query, connectionApplicationName).Rows()
+	if err != nil {
+		// Reading INNODB_TRX requires the PROCESS privilege, fall back to the running statements only
+		log.Printf("DBManager -> listMySQLActivity -> Can't read open transactions, listing running statements only: %v", err)
+		query = `
+			SELECT p.ID, p.USER, p.DB, CONCAT_WS(': ', p.COMMAND, NULLIF(p.STATE, '')), p.INFO, FALSE, p.TIME * 1000
+			FROM information_schema.PROCESSLIST p
+			WHERE ` + ownThreads + ` AND p.COMMAND <> 'Sleep'
+			ORDER BY 7 DESC`
+		rows, err = db.WithContext(ctx).Raw(query, connectionApplicationName).Rows()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the process list: %v", err)
+		}
+	}
+	defer rows.Close()
+
+	activities := []ServerActivity{}
+	for rows.Next() {
+		var activity ServerActivity
+		var user, database, state, query sql.NullString
+		var duration sql.NullInt64
+		if err := rows.Scan(&activity.ID, &user, &database, &state, &query, &activity.InTransaction, &duration); err != nil {
+			return nil, fmt.Errorf("failed to read the process list: %v", err)
+		}
+		activity.User, activity.Database, activity.State, activity.Query = user.String, database.String, state.String, query.String
+		activity.DurationMs = duration.Int64
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+func listClickHouseActivity(ctx context.Context, db *gorm.DB) ([]ServerActivity, error) {
+	rows, err := db.WithContext(ctx).Raw(`
+		SELECT query_id, user, current_database, query, toInt64(elapsed * 1000) AS duration_ms
+		FROM system.processes
+		WHERE user = currentUser() AND query_id != queryID()
+		ORDER BY elapsed DESC`).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query system.processes: %v", err)
+	}
+	defer rows.Close()
+
+	activities := []ServerActivity{}
+	for rows.Next() {
+		activity := ServerActivity{State: "running"}
+		if err := rows.Scan(&activity.ID, &activity.User, &activity.Database, &activity.Query, &activity.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to read system.processes: %v", err)
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+func listMongoDBActivity(ctx context.Context, conn *Connection) ([]ServerActivity, error) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok || wrapper == nil {
+		return nil, fmt.Errorf("invalid MongoDB connection")
+	}
+
+	var result struct {
+		InProgress []bson.M `bson:"inprog"`
+	}
+	command := bson.D{{Key: "currentOp", Value: 1}, {Key: "$ownOps", Value: true}, {Key: "active", Value: true}}
+	if err := wrapper.Client.Database("admin").RunCommand(ctx, command).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to run currentOp: %v", err)
+	}
+
+	activities := []ServerActivity{}
+	for _, op := range result.InProgress {
+		// Skip the currentOp command itself & internal operations without a client
+		if command, ok := op["command"].(bson.M); ok {
+			if _, isCurrentOp := command["currentOp"]; isCurrentOp {
+				continue
+			}
+		}
+		if _, hasOpID := op["opid"]; !hasOpID {
+			continue
+		}
+
+		activity := ServerActivity{
+			ID:    fmt.Sprintf("%v", op["opid"]),
+			State: fmt.Sprintf("%v", op["op"]),
+		}
+		if ns, ok := op["ns"].(string); ok {
+			activity.Database = ns
+		}
+		if command, ok := op["command"].(bson.M); ok {
+			if commandJSON, err := bson.MarshalExtJSON(command, false, false); err == nil {
+				activity.Query = string(commandJSON)
+			}
+		}
+		if users, ok := op["effectiveUsers"].(bson.A); ok && len(users) > 0 {
+			if user, ok := users[0].(bson.M); ok {
+				activity.User = fmt.Sprintf("%v", user["user"])
+			}
+		}
+		if micros, ok := toInt64(op["microsecs_running"]); ok {
+			activity.DurationMs = micros / 1000
+		}
+		if transaction, ok := op["transaction"].(bson.M); ok {
+			activity.InTransaction = true
+			if micros, ok := toInt64(transaction["timeOpenMicros"]); ok {
+				activity.DurationMs = micros / 1000
+			}
+		}
+		activities = append(activities, activity)
+	}
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].DurationMs > activities[j].DurationMs
+	})
+	return activities, nil
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
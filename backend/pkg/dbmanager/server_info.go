@@ -0,0 +1,264 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ServerInfo describes the database server a connection is talking to, so that generated queries only use
+// syntax it supports
+type ServerInfo struct {
+	Version      string          `json:"version"`       // Version reported by the server, ex: "16.2", "8.0.36", "10.11.2-MariaDB"
+	MajorVersion int             `json:"major_version"` // 0 if the version couldn't be parsed
+	MinorVersion int             `json:"minor_version"`
+	PatchVersion int             `json:"patch_version"`
+	Flavor       string          `json:"flavor,omitempty"`   // Set for compatible servers, ex: "mariadb" for a MySQL connection
+	Capabilities []string        `json:"capabilities"`       // Version dependent features, see serverCapabilities
+	Clusters     []ServerCluster `json:"clusters,omitempty"` // ClickHouse clusters the server is a member of
+}
+
+// ServerCluster is the topology of a ClickHouse cluster, DDL of its tables has to run ON CLUSTER
+type ServerCluster struct {
+	Name     string `json:"name"`
+	Shards   int    `json:"shards"`
+	Replicas int    `json:"replicas"` // Replicas of each shard
+}
+
+// serverCapability is a feature available from a given server version on
+type serverCapability struct {
+	Name  string
+	Major int
+	Minor int
+	Patch int
+}
+
+// serverCapabilities lists the version dependent features worth telling the LLM about, per database type & flavor
+var serverCapabilities = map[string][]serverCapability{
+	constants.DatabaseTypePostgreSQL: {
+		{"generated_columns", 12, 0, 0},
+		{"jsonpath", 12, 0, 0},
+		{"merge", 15, 0, 0},
+		{"unique_nulls_not_distinct", 15, 0, 0},
+		{"any_value", 16, 0, 0},
+		{"json_table", 17, 0, 0},
+		{"merge_returning", 17, 0, 0},
+	},
+	constants.DatabaseTypeMySQL: {
+		{"cte", 8, 0, 1},
+		{"window_functions", 8, 0, 2},
+		{"json_table", 8, 0, 4},
+		{"lateral_derived_tables", 8, 0, 14},
+		{"check_constraints", 8, 0, 16},
+	},
+	constants.DatabaseTypeMariaDB: {
+		{"window_functions", 10, 2, 0},
+		{"cte", 10, 2, 1},
+		{"check_constraints", 10, 2, 1},
+		{"returning", 10, 5, 0},
+		{"json_table", 10, 6, 0},
+	},
+	constants.DatabaseTypeClickhouse: {
+		{"window_functions", 21, 9, 0},
+		{"lightweight_delete", 23, 3, 0},
+	},
+	constants.DatabaseTypeSQLite: {
+		{"upsert", 3, 24, 0},
+		{"window_functions", 3, 25, 0},
+		{"rename_column", 3, 25, 0},
+		{"returning", 3, 35, 0},
+		{"drop_column", 3, 35, 0},
+	},
+	constants.DatabaseTypeMongoDB: {
+		{"merge_stage", 4, 2, 0},
+		{"union_with_stage", 4, 4, 0},
+		{"set_window_fields_stage", 5, 0, 0},
+		{"time_series_collections", 5, 0, 0},
+		{"densify_stage", 5, 1, 0},
+		{"fill_stage", 5, 3, 0},
+	},
+}
+
+// fetchServerInfo asks the server for its version, best effort, nil is returned if it can't be determined
+func fetchServerInfo(conn *Connection) *ServerInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var version string
+	var err error
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		version, err = querySQLVersion(ctx, conn, "SHOW server_version")
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		version, err = querySQLVersion(ctx, conn, "SELECT VERSION()")
+	case constants.DatabaseTypeClickhouse:
+		version, err = querySQLVersion(ctx, conn, "SELECT version()")
+	case constants.DatabaseTypeSQLite:
+		version, err = querySQLVersion(ctx, conn, "SELECT sqlite_version()")
+	case constants.DatabaseTypeMongoDB:
+		version, err = queryMongoDBVersion(ctx, conn)
+	default:
+		return nil
+	}
+	if err != nil {
+		log.Printf("DBManager -> fetchServerInfo -> Failed to get %s server version: %v", conn.Config.Type, err)
+		return nil
+	}
+
+	info := newServerInfo(conn.Config.Type, version)
+	if conn.Config.Type == constants.DatabaseTypeClickhouse {
+		if info.Clusters, err = queryClickHouseClusters(ctx, conn); err != nil {
+			log.Printf("DBManager -> fetchServerInfo -> Failed to get ClickHouse clusters: %v", err)
+		}
+	}
+	return info
+}
+
+// newServerInfo parses a server version & derives the capabilities of the server from it
+func newServerInfo(dbType, version string) *ServerInfo {
+	info := &ServerInfo{
+		Version:      strings.TrimSpace(version),
+		Capabilities: []string{},
+	}
+	info.MajorVersion, info.MinorVersion, info.PatchVersion = parseServerVersion(info.Version)
+
+	capabilityKey := dbType
+	switch {
+	case dbType == constants.DatabaseTypeMySQL && strings.Contains(strings.ToLower(info.Version), "mariadb"):
+		info.Flavor = constants.DatabaseTypeMariaDB
+		capabilityKey = constants.DatabaseTypeMariaDB
+	case dbType == constants.DatabaseTypeYugabyteDB:
+		// YugabyteDB reports the version of the PostgreSQL it is based on, ex: "11.2-YB-2.20.1.0-b0"
+		capabilityKey = constants.DatabaseTypePostgreSQL
+	}
+
+	if info.MajorVersion == 0 {
+		return info
+	}
+	for _, capability := range serverCapabilities[capabilityKey] {
+		if compareVersions(info.MajorVersion, info.MinorVersion, info.PatchVersion, capability.Major, capability.Minor, capability.Patch) >= 0 {
+			info.Capabilities = append(info.Capabilities, capability.Name)
+		}
+	}
+	return info
+}
+
+// compareVersions compares major.minor.patch versions, it returns -1, 0 or 1 like strings.Compare
+func compareVersions(major, minor, patch, otherMajor, otherMinor, otherPatch int) int {
+	for _, pair := range [][2]int{{major, otherMajor}, {minor, otherMinor}, {patch, otherPatch}} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseServerVersion extracts the major, minor & patch numbers of a version such as "16.2 (Debian 16.2-1)" or "8.0.36-log"
+func parseServerVersion(version string) (int, int, int) {
+	end := strings.IndexFunc(version, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end != -1 {
+		version = version[:end]
+	}
+
+	parts := strings.Split(version, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, 0
+	}
+	minor, patch := 0, 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	if len(parts) > 2 {
+		patch, _ = strconv.Atoi(parts[2])
+	}
+	return major, minor, patch
+}
+
+func querySQLVersion(ctx context.Context, conn *Connection, query string) (string, error) {
+	if conn.DB == nil {
+		return "", fmt.Errorf("no database connection")
+	}
+	var version string
+	if err := conn.DB.WithContext(ctx).Raw(query).Row().Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// queryClickHouseClusters lists the clusters the server is a member of, the test_ clusters of the default
+// ClickHouse configuration are skipped
+func queryClickHouseClusters(ctx context.Context, conn *Connection) ([]ServerCluster, error) {
+	if conn.DB == nil {
+		return nil, fmt.Errorf("no database connection")
+	}
+	rows, err := conn.DB.WithContext(ctx).Raw(`
+		SELECT cluster, toUInt32(uniqExact(shard_num)), toUInt32(max(replica_num))
+		FROM system.clusters
+		WHERE cluster NOT LIKE 'test\\_%'
+		GROUP BY cluster
+		HAVING countIf(is_local = 1) > 0
+		ORDER BY cluster`).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := make([]ServerCluster, 0)
+	for rows.Next() {
+		var cluster ServerCluster
+		if err := rows.Scan(&cluster.Name, &cluster.Shards, &cluster.Replicas); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, rows.Err()
+}
+
+func queryMongoDBVersion(ctx context.Context, conn *Connection) (string, error) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok || wrapper == nil {
+		return "", fmt.Errorf("invalid MongoDB connection")
+	}
+	var buildInfo struct {
+		Version string `bson:"version"`
+	}
+	if err := wrapper.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err != nil {
+		return "", err
+	}
+	return buildInfo.Version, nil
+}
+
+// FormatServerInfoForLLM describes the server in a line of prompt context
+func FormatServerInfoForLLM(dbType string, info *ServerInfo) string {
+	if info == nil || info.Version == "" {
+		return ""
+	}
+	description := fmt.Sprintf("The connected %s server runs version %s", dbType, info.Version)
+	if info.Flavor != "" {
+		description += fmt.Sprintf(" (%s)", info.Flavor)
+	}
+	if len(info.Capabilities) > 0 {
+		description += fmt.Sprintf(" and supports: %s", strings.Join(info.Capabilities, ", "))
+	}
+	description += ". Only generate syntax supported by this version."
+	if len(info.Clusters) > 0 {
+		clusters := make([]string, len(info.Clusters))
+		for i, cluster := range info.Clusters {
+			clusters[i] = fmt.Sprintf("%s (%d shards, %d replicas per shard)", cluster.Name, cluster.Shards, cluster.Replicas)
+		}
+		description += fmt.Sprintf(" The server is a member of the clusters: %s. Use ON CLUSTER in DDL of tables sharded or replicated across a cluster.", strings.Join(clusters, ", "))
+	}
+	return description
+}
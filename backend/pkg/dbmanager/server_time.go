@@ -0,0 +1,133 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ServerTime is the current date & time of a database server in the timezone its sessions use, relative dates such
+// as "yesterday" have to be resolved with it rather than with the clock of the host running DataBot
+type ServerTime struct {
+	LocalTime time.Time // Wall clock of the server, the location is a fixed zone with the server offset
+	TimeZone  string    // Name of the timezone reported by the server, ex: "Europe/Paris", "UTC"
+}
+
+// GetServerTime asks the database of a chat for its current date, time & timezone
+func (m *Manager) GetServerTime(ctx context.Context, chatID string) (*ServerTime, error) {
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("connection not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var now time.Time
+	var offset int64
+	var timeZone string
+	var err error
+	switch conn.Config.Type {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		now, offset, timeZone, err = querySQLServerTime(ctx, conn,
+			"SELECT to_char(now(), 'YYYY-MM-DD HH24:MI:SS'), EXTRACT(TIMEZONE FROM now())::bigint, current_setting('TimeZone')")
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		// The session timezone is SYSTEM unless it was set, the name of the system one is then reported instead
+		now, offset, timeZone, err = querySQLServerTime(ctx, conn,
+			"SELECT DATE_FORMAT(NOW(), '%Y-%m-%d %H:%i:%s'), TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW()), IF(@@session.time_zone = 'SYSTEM', @@system_time_zone, @@session.time_zone)")
+	case constants.DatabaseTypeClickhouse:
+		now, offset, timeZone, err = querySQLServerTime(ctx, conn,
+			"SELECT formatDateTime(now(), '%Y-%m-%d %H:%i:%S'), toInt64(timeZoneOffset(now())), timezone()")
+	case constants.DatabaseTypeSQLite:
+		// SQLite has no timezone, 'now' is UTC unless a query converts it with the localtime modifier
+		now, offset, timeZone, err = querySQLServerTime(ctx, conn, "SELECT datetime('now'), 0, 'UTC'")
+	case constants.DatabaseTypeMongoDB:
+		// MongoDB stores & compares dates in UTC, there is no session timezone
+		now, err = queryMongoDBServerTime(ctx, conn)
+		timeZone = "UTC"
+	case constants.DatabaseTypeBigQuery:
+		// BigQuery evaluates CURRENT_TIMESTAMP() & CURRENT_DATE() in UTC unless a query passes a timezone, every query
+		// is billed so the time isn't queried
+		now, timeZone = time.Now().UTC(), "UTC"
+	case constants.DatabaseTypeElasticsearch:
+		// Elasticsearch stores dates in UTC & evaluates now in date math in UTC unless a query sets a time_zone
+		now, timeZone = time.Now().UTC(), "UTC"
+	case constants.DatabaseTypeCassandra:
+		// Cassandra stores timestamps in UTC, currentTimestamp() is the clock of the coordinator in UTC
+		now, err = queryCassandraServerTime(ctx, conn)
+		timeZone = "UTC"
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", conn.Config.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	location := time.FixedZone(timeZone, int(offset))
+	return &ServerTime{
+		LocalTime: time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, location),
+		TimeZone:  timeZone,
+	}, nil
+}
+
+// querySQLServerTime runs a query returning the local time of the server formatted as "2006-01-02 15:04:05", its
+// offset to UTC in seconds & its timezone name
+func querySQLServerTime(ctx context.Context, conn *Connection, query string) (time.Time, int64, string, error) {
+	if conn.DB == nil {
+		return time.Time{}, 0, "", fmt.Errorf("no database connection")
+	}
+	var localTime, timeZone string
+	var offset int64
+	if err := conn.DB.WithContext(ctx).Raw(query).Row().Scan(&localTime, &offset, &timeZone); err != nil {
+		return time.Time{}, 0, "", err
+	}
+	now, err := time.Parse(time.DateTime, localTime)
+	if err != nil {
+		return time.Time{}, 0, "", fmt.Errorf("failed to parse server time %q: %v", localTime, err)
+	}
+	return now, offset, timeZone, nil
+}
+
+func queryMongoDBServerTime(ctx context.Context, conn *Connection) (time.Time, error) {
+	wrapper, ok := conn.MongoDBObj.(*MongoDBWrapper)
+	if !ok || wrapper == nil {
+		return time.Time{}, fmt.Errorf("invalid MongoDB connection")
+	}
+	var hello struct {
+		LocalTime time.Time `bson:"localTime"`
+	}
+	if err := wrapper.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return time.Time{}, err
+	}
+	return hello.LocalTime.UTC(), nil
+}
+
+// queryCassandraServerTime reads the clock of the node coordinating the query
+func queryCassandraServerTime(ctx context.Context, conn *Connection) (time.Time, error) {
+	client, err := cassandraClientOf(conn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var now time.Time
+	if err := client.Session.Query("SELECT toTimestamp(now()) FROM system.local").WithContext(ctx).Scan(&now); err != nil {
+		return time.Time{}, err
+	}
+	return now.UTC(), nil
+}
+
+// FormatServerTimeForLLM describes the current date of the server in a line of prompt context, so that relative
+// dates in questions are resolved in the timezone of the data
+func FormatServerTimeForLLM(serverTime *ServerTime) string {
+	if serverTime == nil {
+		return ""
+	}
+	return fmt.Sprintf("The current date & time on the database server is %s (%s), timezone %s (UTC%s). "+
+		"Resolve relative dates such as \"today\", \"yesterday\" or \"last week\" from this date & timezone, preferably "+
+		"with the date functions of the database (ex: the current date of the server) rather than literal dates.",
+		serverTime.LocalTime.Format(time.DateTime), serverTime.LocalTime.Weekday(), serverTime.TimeZone, serverTime.LocalTime.Format("-07:00"))
+}
@@ -0,0 +1,586 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type shardFanOutKey struct{}
+
+// shardAggregationWarning is returned when the rows merged from the shards were aggregated by each shard separately
+const shardAggregationWarning = "The query aggregates its rows, each shard returned its own groups: the rows of a group are not combined across shards."
+
+// WithShardFanOut makes ExecuteQuery run a read query on every shard of the connection & merge their rows, see
+// ConnectionConfig.Shards
+func WithShardFanOut(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shardFanOutKey{}, true)
+}
+
+// shardFanOut checks if the query must run on every shard of the connection
+func shardFanOut(ctx context.Context) bool {
+	fanOut, _ := ctx.Value(shardFanOutKey{}).(bool)
+	return fanOut
+}
+
+// ShardFanOutSupported checks if the read queries of a database type can be run on its shards & merged, the SQL
+// results are merged on the ORDER BY & LIMIT of the query. Partitions of a single database don't need it, the database
+// already merges them when the parent table is queried.
+func ShardFanOutSupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeClickhouse:
+		return true
+	}
+	return false
+}
+
+// shardConfigs returns a config per shard of a connection, the connection itself first. Shards can carry their own
+// port & database (ex: "shard-2:5433/orders_2"), the port & database of the config are used otherwise.
+func shardConfigs(config ConnectionConfig) []ConnectionConfig {
+	primary := config
+	primary.Shards = nil
+	configs := []ConnectionConfig{primary}
+	seen := map[string]bool{candidateAddress(primary) + "/" + primary.Database: true}
+	for _, shard := range config.Shards {
+		address, database, _ := strings.Cut(strings.TrimSpace(shard), "/")
+		if address == "" {
+			continue
+		}
+		shardConfig := primary
+		shardConfig.Host = address
+		shardConfig.Hosts = nil
+		if name, port, err := net.SplitHostPort(address); err == nil {
+			shardConfig.Host = name
+			shardConfig.Port = &port
+		}
+		if database != "" {
+			shardConfig.Database = database
+		}
+		key := candidateAddress(shardConfig) + "/" + shardConfig.Database
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		configs = append(configs, shardConfig)
+	}
+	return configs
+}
+
+// shardOrderKey is an expression of the ORDER BY of a query fanned out to shards
+type shardOrderKey struct {
+	column     string // Lower cased column the merged rows are ordered on
+	desc       bool
+	nullsFirst bool
+}
+
+// shardQueryPlan is how a query is run on every shard & how their rows are merged
+type shardQueryPlan struct {
+	query      string // Query run on each shard, it returns the rows of the first offset + limit merged rows at most
+	orderBy    []shardOrderKey
+	limit      int // -1 without LIMIT
+	offset     int
+	aggregated bool // GROUP BY, DISTINCT or set operation, each shard returns its own groups
+}
+
+// planShardQuery parses the ORDER BY, LIMIT & OFFSET of a SELECT. Each shard runs the query without OFFSET & with a
+// LIMIT of offset + limit rows, the page is cut from the merged rows.
+func planShardQuery(dbType, query string) (*shardQueryPlan, error) {
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || (tokens[0].value != "select" && tokens[0].value != "with") {
+		return nil, fmt.Errorf("only SELECT queries can be run on every shard")
+	}
+
+	plan := &shardQueryPlan{limit: -1}
+	orderBy, clauseStart, clauseEnd := -1, -1, -1
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth > 0 {
+			continue
+		}
+		if token.text == ";" && i != len(tokens)-1 {
+			return nil, fmt.Errorf("only a single statement can be run on every shard")
+		}
+		if token.kind != sqlTokenWord {
+			continue
+		}
+
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].value
+		}
+		switch token.value {
+		case "group", "union", "intersect", "except":
+			if token.value != "group" || next == "by" {
+				plan.aggregated = true
+			}
+		case "distinct":
+			if i > 0 && tokens[i-1].value == "select" {
+				plan.aggregated = true
+			}
+		case "order":
+			if next == "by" {
+				orderBy = i + 2
+			}
+		case "fetch":
+			return nil, fmt.Errorf("FETCH FIRST can't be merged across shards, use LIMIT & OFFSET")
+		case "limit", "offset":
+			if clauseStart == -1 {
+				clauseStart = i
+			}
+			value, end, err := shardClauseNumber(tokens, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if token.value == "offset" {
+				plan.offset = value
+				// OFFSET 20 ROWS
+				if end < len(tokens) && (tokens[end].value == "rows" || tokens[end].value == "row") {
+					end++
+				}
+			} else if end+1 < len(tokens) && tokens[end].text == "," {
+				// LIMIT 20, 10
+				plan.offset = value
+				if plan.limit, end, err = shardClauseNumber(tokens, end+1); err != nil {
+					return nil, err
+				}
+			} else {
+				plan.limit = value
+			}
+			clauseEnd = end
+			i = end - 1
+		}
+	}
+
+	end := len(query)
+	if last := tokens[len(tokens)-1]; last.text == ";" {
+		end = last.start
+	}
+	if orderBy != -1 {
+		orderEnd := end
+		if clauseStart > orderBy {
+			orderEnd = tokens[clauseStart].start
+		}
+		keys, err := parseShardOrderBy(dbType, tokens[orderBy:], orderEnd)
+		if err != nil {
+			return nil, err
+		}
+		plan.orderBy = keys
+	}
+
+	plan.query = query[:end]
+	if clauseStart != -1 {
+		rest := ""
+		if clauseEnd < len(tokens) && tokens[clauseEnd].start < end {
+			rest = " " + query[tokens[clauseEnd].start:end]
+		}
+		plan.query = strings.TrimRight(query[:tokens[clauseStart].start], " \t\r\n")
+		if plan.limit != -1 {
+			plan.query += fmt.Sprintf(" LIMIT %d", plan.offset+plan.limit)
+		}
+		plan.query += rest
+	}
+	return plan, nil
+}
+
+// shardClauseNumber reads the row count of a LIMIT or OFFSET, the index following it is returned
+func shardClauseNumber(tokens []sqlToken, i int) (int, int, error) {
+	if i >= len(tokens) || tokens[i].kind != sqlTokenNumber {
+		return 0, i, fmt.Errorf("LIMIT & OFFSET must be numbers to be merged across shards")
+	}
+	value, err := strconv.Atoi(tokens[i].text)
+	if err != nil {
+		return 0, i, fmt.Errorf("invalid row count %s: %v", tokens[i].text, err)
+	}
+	return value, i + 1, nil
+}
+
+// parseShardOrderBy reads the keys of an ORDER BY ending at the byte offset end. The merged rows are ordered on the
+// returned columns, so each key must be a column (or alias) of the result.
+func parseShardOrderBy(dbType string, tokens []sqlToken, end int) ([]shardOrderKey, error) {
+	var keys []shardOrderKey
+	var expression []sqlToken
+	depth := 0
+	flush := func() error {
+		if len(expression) == 0 {
+			return nil
+		}
+		key := shardOrderKey{}
+		// Column, table.column or schema.table.column, then ASC/DESC & NULLS FIRST/LAST
+		i := 0
+		for ; i < len(expression); i++ {
+			if !isIdentifierToken(expression[i]) || (expression[i].kind == sqlTokenWord && sqlClauseKeywords[expression[i].value]) {
+				break
+			}
+			key.column = expression[i].value
+			if i+1 >= len(expression) || expression[i+1].text != "." {
+				i++
+				break
+			}
+			i++
+		}
+		if key.column == "" {
+			return fmt.Errorf("ORDER BY %s can't be merged across shards, order on a selected column or its alias", expression[0].text)
+		}
+		if i < len(expression) && (expression[i].value == "asc" || expression[i].value == "desc") {
+			key.desc = expression[i].value == "desc"
+			i++
+		}
+		// Default NULL ordering of the database: PostgreSQL NULLs are larger than any value, MySQL ones are smaller &
+		// ClickHouse puts them last in both directions
+		switch dbType {
+		case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+			key.nullsFirst = !key.desc
+		case constants.DatabaseTypeClickhouse:
+			key.nullsFirst = false
+		default:
+			key.nullsFirst = key.desc
+		}
+		if i+1 < len(expression) && expression[i].value == "nulls" {
+			key.nullsFirst = expression[i+1].value == "first"
+			i += 2
+		}
+		if i != len(expression) {
+			return fmt.Errorf("ORDER BY %s can't be merged across shards, order on a selected column or its alias", key.column)
+		}
+		keys = append(keys, key)
+		expression = nil
+		return nil
+	}
+
+	for _, token := range tokens {
+		if token.start >= end || (depth == 0 && token.text == ";") {
+			break
+		}
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 && token.text == "," {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		expression = append(expression, token)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// executeOnShards runs a read query on every shard of a connection in parallel & merges their rows. The connection
+// is the first shard, the other ones are connected for the query only.
+func (m *Manager) executeOnShards(ctx context.Context, conn *Connection, driver DatabaseDriver, query, queryType string, findCount bool) (*QueryExecutionResult, *dtos.QueryError) {
+	startTime := time.Now()
+	plan, err := planShardQuery(conn.Config.Type, query)
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "SHARD_FAN_OUT_NOT_SUPPORTED",
+			Message: "the query can't be run on every shard",
+			Details: err.Error(),
+		}
+	}
+	asOf, hasAsOf := readAsOf(ctx)
+
+	configs := shardConfigs(conn.Config)
+	log.Printf("Manager -> executeOnShards -> Running the query on %d shards: %s", len(configs), plan.query)
+	results := make([]*QueryExecutionResult, len(configs))
+	errs := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config ConnectionConfig) {
+			defer wg.Done()
+			shardConn := conn
+			if i > 0 {
+				var err error
+				if shardConn, err = driver.Connect(config); err != nil {
+					errs[i] = fmt.Errorf("failed to connect: %v", err)
+					return
+				}
+				defer func() {
+					if err := driver.Disconnect(shardConn); err != nil {
+						log.Printf("Manager -> executeOnShards -> Failed to disconnect from %s: %v", candidateAddress(config), err)
+					}
+				}()
+			}
+
+			tx := driver.BeginTx(ctx, shardConn, nil)
+			if tx == nil {
+				errs[i] = fmt.Errorf("failed to start transaction")
+				return
+			}
+			// Nothing is written, the transaction is rolled back once the rows are read
+			defer func() {
+				if err := tx.Rollback(); err != nil {
+					log.Printf("Manager -> executeOnShards -> Error rolling back transaction: %v", err)
+				}
+			}()
+			if hasAsOf {
+				if err := setTransactionReadTime(ctx, tx, asOf); err != nil {
+					errs[i] = err
+					return
+				}
+			}
+
+			results[i] = tx.ExecuteQuery(ctx, shardConn, plan.query, queryType, findCount)
+			if results[i] != nil && results[i].Error != nil {
+				errs[i] = fmt.Errorf("%s: %s", results[i].Error.Message, results[i].Error.Details)
+			}
+		}(i, config)
+	}
+	wg.Wait()
+
+	// The rows of all the shards count towards the byte limit, a shard stopped at it fails like the others
+	size := resultSizeOf(ctx)
+	if size != nil && size.exceeded.Load() {
+		return nil, size.tooLargeError()
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, &dtos.QueryError{
+				Code:    "SHARD_QUERY_FAILED",
+				Message: fmt.Sprintf("the query failed on shard %s", candidateAddress(configs[i])),
+				Details: err.Error(),
+			}
+		}
+	}
+
+	result, err := mergeShardResults(plan, results, findCount, resultRowLimit(ctx))
+	if err != nil {
+		return nil, &dtos.QueryError{
+			Code:    "SHARD_MERGE_FAILED",
+			Message: "failed to merge the rows of the shards",
+			Details: err.Error(),
+		}
+	}
+	if size.tooLarge(result) {
+		return nil, size.tooLargeError()
+	}
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+	return result, nil
+}
+
+// mergeShardResults combines the rows of the shards in the order of the query & cuts its page. The counts of count
+// queries are summed, so that the total of a paginated query covers every shard.
+func mergeShardResults(plan *shardQueryPlan, results []*QueryExecutionResult, findCount bool, rowLimit int) (*QueryExecutionResult, error) {
+	merged := &QueryExecutionResult{}
+	var rows []map[string]interface{}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		shardRows, _ := result.Result["results"].([]map[string]interface{})
+		rows = append(rows, shardRows...)
+	}
+
+	if findCount && !plan.aggregated {
+		rows = sumShardCounts(rows)
+	} else {
+		if plan.aggregated {
+			merged.Warnings = append(merged.Warnings, shardAggregationWarning)
+		}
+		if len(plan.orderBy) > 0 {
+			if err := sortShardRows(rows, plan.orderBy); err != nil {
+				return nil, err
+			}
+		}
+		if plan.offset >= len(rows) {
+			rows = rows[:0]
+		} else {
+			rows = rows[plan.offset:]
+		}
+		if plan.limit != -1 && plan.limit < len(rows) {
+			rows = rows[:plan.limit]
+		}
+		if rowLimit > 0 && len(rows) > rowLimit {
+			rows = rows[:rowLimit]
+			merged.Warnings = append(merged.Warnings, resultTruncatedWarning(rowLimit))
+		}
+	}
+	if rows == nil {
+		rows = make([]map[string]interface{}, 0)
+	}
+
+	// Every shard raises the same warnings for the same query
+	warnings := merged.Warnings[:0]
+	seen := make(map[string]bool, len(merged.Warnings))
+	for _, warning := range merged.Warnings {
+		if !seen[warning] {
+			seen[warning] = true
+			warnings = append(warnings, warning)
+		}
+	}
+	merged.Warnings = warnings
+	merged.Result = map[string]interface{}{
+		"results": rows,
+	}
+	resultJSON, err := json.Marshal(merged.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query results: %v", err)
+	}
+	merged.ResultJSON = string(resultJSON)
+	return merged, nil
+}
+
+// sumShardCounts adds the numeric columns of the single row returned by each shard for a count query
+func sumShardCounts(rows []map[string]interface{}) []map[string]interface{} {
+	if len(rows) <= 1 {
+		return rows
+	}
+	total := make(map[string]interface{}, len(rows[0]))
+	for _, row := range rows {
+		for column, value := range row {
+			number, ok := shardNumber(value)
+			if !ok {
+				if _, exists := total[column]; !exists {
+					total[column] = value
+				}
+				continue
+			}
+			sum, _ := shardNumber(total[column])
+			if float64(int64(sum+number)) == sum+number {
+				total[column] = int64(sum + number)
+			} else {
+				total[column] = sum + number
+			}
+		}
+	}
+	return []map[string]interface{}{total}
+}
+
+// sortShardRows orders the merged rows on the ORDER BY keys of the query, the keys are matched to the result columns
+// ignoring case
+func sortShardRows(rows []map[string]interface{}, keys []shardOrderKey) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		for column := range rows[0] {
+			if strings.EqualFold(column, key.column) {
+				columns[i] = column
+				break
+			}
+		}
+		if columns[i] == "" {
+			return fmt.Errorf("the ORDER BY column %s must be selected to order the rows of every shard", key.column)
+		}
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		for i, key := range keys {
+			left, right := rows[a][columns[i]], rows[b][columns[i]]
+			var cmp int
+			switch {
+			case left == nil && right == nil:
+				continue
+			case left == nil || right == nil:
+				// NULLs keep their position whatever the direction
+				if (left == nil) == key.nullsFirst {
+					return true
+				}
+				return false
+			default:
+				cmp = compareShardValues(left, right)
+			}
+			if cmp == 0 {
+				continue
+			}
+			if key.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return nil
+}
+
+// compareShardValues compares two non NULL values of a column, numbers & times by value, anything else as text
+func compareShardValues(left, right interface{}) int {
+	if leftNumber, ok := shardNumber(left); ok {
+		if rightNumber, ok := shardNumber(right); ok {
+			switch {
+			case leftNumber < rightNumber:
+				return -1
+			case leftNumber > rightNumber:
+				return 1
+			}
+			return 0
+		}
+	}
+	if leftTime, ok := left.(time.Time); ok {
+		if rightTime, ok := right.(time.Time); ok {
+			return leftTime.Compare(rightTime)
+		}
+	}
+	if leftBool, ok := left.(bool); ok {
+		if rightBool, ok := right.(bool); ok {
+			switch {
+			case leftBool == rightBool:
+				return 0
+			case rightBool:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+}
+
+// shardNumber converts the numeric values returned by the drivers to float64, numeric text included
+func shardNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		// NUMERIC & DECIMAL columns are read as text
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,478 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AmbiguousColumn is an unqualified column reference that exists in more than one of the tables referenced by a query
+type AmbiguousColumn struct {
+	Column     string   `json:"column"`
+	Tables     []string `json:"tables"`               // Qualifiers (alias or table name) of the tables having the column
+	Suggestion string   `json:"suggestion,omitempty"` // Safe qualification when the column is an equi-join key of the tables
+}
+
+func (a AmbiguousColumn) String() string {
+	if a.Suggestion != "" {
+		return fmt.Sprintf("column %q exists in multiple joined tables (%s) which are joined on it, qualify it as %s", a.Column, strings.Join(a.Tables, ", "), a.Suggestion)
+	}
+	qualified := make([]string, len(a.Tables))
+	for i, table := range a.Tables {
+		qualified[i] = table + "." + a.Column
+	}
+	return fmt.Sprintf("column %q exists in multiple joined tables (%s), qualify it as %s", a.Column, strings.Join(a.Tables, ", "), strings.Join(qualified, " or "))
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenWord sqlTokenKind = iota
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenPunct
+)
+
+type sqlToken struct {
+	kind  sqlTokenKind
+	text  string // Raw text as written in the query
+	value string // Lower cased identifier without quotes
+	start int
+}
+
+// sqlTableRef is a table referenced in a FROM or JOIN clause
+type sqlTableRef struct {
+	table     string // Lower cased table name without schema
+	qualifier string // Alias or table name, as written in the query
+	joinType  string // "", "INNER", "LEFT", "RIGHT", "FULL" or "CROSS"
+}
+
+// sqlClauseKeywords are never treated as column references even if a table has a column with the same name
+var sqlClauseKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "join": true, "inner": true, "left": true, "right": true,
+	"full": true, "outer": true, "cross": true, "natural": true, "on": true, "using": true, "and": true,
+	"or": true, "not": true, "as": true, "group": true, "order": true, "by": true, "having": true,
+	"limit": true, "offset": true, "distinct": true, "case": true, "when": true, "then": true, "else": true,
+	"end": true, "is": true, "null": true, "in": true, "like": true, "ilike": true, "between": true,
+	"asc": true, "desc": true, "true": true, "false": true, "exists": true, "lateral": true, "fetch": true,
+	"window": true, "union": true, "all": true, "any": true, "with": true, "for": true, "interval": true,
+}
+
+// FindAmbiguousColumns finds unqualified columns of a SELECT that exist in more than one referenced table, the query
+// itself is never modified. When every ambiguous column is an equi-join key between all the tables having it
+// (ex: "orders o JOIN customers c ON o.customer_id = c.customer_id"), a qualified version of the query is suggested
+// since every candidate holds the same value, the suggestion is empty otherwise.
+// Queries with subqueries, CTEs or set operations are skipped since their scopes aren't tracked.
+func FindAmbiguousColumns(query string, schema *SchemaInfo) (string, []AmbiguousColumn) {
+	if schema == nil || len(schema.Tables) == 0 {
+		return "", nil
+	}
+
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].value != "select" {
+		return "", nil
+	}
+	for i, token := range tokens {
+		if i > 0 && token.kind == sqlTokenWord && (token.value == "select" || token.value == "with" || token.value == "natural") {
+			return "", nil
+		}
+	}
+
+	refs, consumed, usingColumns := parseSQLTableRefs(tokens)
+	if len(refs) < 2 {
+		return "", nil
+	}
+
+	tables := make(map[string]TableSchema, len(schema.Tables))
+	for name, table := range schema.Tables {
+		tables[strings.ToLower(name)] = table
+	}
+
+	// Names that can't be columns: table names, aliases & output aliases, with or without AS (ex: "c.name customer_name")
+	reserved := make(map[string]bool)
+	for _, ref := range refs {
+		reserved[ref.table] = true
+		reserved[strings.ToLower(unquoteIdentifier(ref.qualifier))] = true
+	}
+	for i := 1; i < len(tokens); i++ {
+		if !isIdentifierToken(tokens[i]) || (tokens[i].kind == sqlTokenWord && sqlClauseKeywords[tokens[i].value]) {
+			continue
+		}
+		if (tokens[i-1].kind == sqlTokenWord && tokens[i-1].value == "as") || endsSQLExpression(tokens[i-1]) {
+			reserved[tokens[i].value] = true
+		}
+	}
+
+	occurrences := make(map[string][]int) // column -> token indexes
+	candidates := make(map[string][]sqlTableRef)
+	for i, token := range tokens {
+		if !isIdentifierToken(token) || consumed[i] || reserved[token.value] || usingColumns[token.value] {
+			continue
+		}
+		if token.kind == sqlTokenWord && sqlClauseKeywords[token.value] {
+			continue
+		}
+		if i > 0 && (tokens[i-1].text == "." || tokens[i-1].text == ":") {
+			// Qualified columns & cast targets (ex: amount::numeric)
+			continue
+		}
+		if i+1 < len(tokens) && (tokens[i+1].text == "." || tokens[i+1].text == "(") {
+			continue
+		}
+
+		if _, seen := candidates[token.value]; !seen {
+			var matches []sqlTableRef
+			for _, ref := range refs {
+				if table, ok := tables[ref.table]; ok && tableHasColumn(table, token.value) {
+					matches = append(matches, ref)
+				}
+			}
+			candidates[token.value] = matches
+		}
+		if len(candidates[token.value]) > 1 {
+			occurrences[token.value] = append(occurrences[token.value], i)
+		}
+	}
+	if len(occurrences) == 0 {
+		return "", nil
+	}
+
+	joinKeys := parseEquiJoinKeys(tokens)
+
+	columns := make([]string, 0, len(occurrences))
+	for column := range occurrences {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	insertions := make(map[int]string) // byte offset -> qualifier
+	var ambiguous []AmbiguousColumn
+	for _, column := range columns {
+		matches := candidates[column]
+		preferred := matches[0]
+
+		resolvable := true
+		for _, other := range matches[1:] {
+			if other.joinType == "RIGHT" || other.joinType == "FULL" || !joinKeys[joinKeyID(preferred.qualifier, other.qualifier, column)] {
+				resolvable = false
+				break
+			}
+		}
+
+		qualifiers := make([]string, len(matches))
+		for i, match := range matches {
+			qualifiers[i] = match.qualifier
+		}
+		found := AmbiguousColumn{Column: tokens[occurrences[column][0]].text, Tables: qualifiers}
+		if resolvable {
+			found.Suggestion = preferred.qualifier + "." + found.Column
+			for _, idx := range occurrences[column] {
+				insertions[tokens[idx].start] = preferred.qualifier + "."
+			}
+		}
+		ambiguous = append(ambiguous, found)
+	}
+
+	for _, column := range ambiguous {
+		if column.Suggestion == "" {
+			return "", ambiguous
+		}
+	}
+
+	offsets := make([]int, 0, len(insertions))
+	for offset := range insertions {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+
+	var suggested strings.Builder
+	last := 0
+	for _, offset := range offsets {
+		suggested.WriteString(query[last:offset])
+		suggested.WriteString(insertions[offset])
+		last = offset
+	}
+	suggested.WriteString(query[last:])
+
+	return suggested.String(), ambiguous
+}
+
+// FormatAmbiguousColumns builds a readable detail for the LLM & the user, including the suggested query if any
+func FormatAmbiguousColumns(columns []AmbiguousColumn, suggestedQuery string) string {
+	details := make([]string, len(columns))
+	for i, column := range columns {
+		details[i] = column.String()
+	}
+	formatted := "Ambiguous column reference: " + strings.Join(details, "; ")
+	if suggestedQuery != "" {
+		formatted += ". Suggested query: " + suggestedQuery
+	}
+	return formatted
+}
+
+// getKnownSchema returns the last known schema of a chat without querying the database, nil if none is available
+func (sm *SchemaManager) getKnownSchema(ctx context.Context, chatID string) *SchemaInfo {
+	sm.mu.RLock()
+	schema, exists := sm.schemaCache[chatID]
+	sm.mu.RUnlock()
+	if exists && schema != nil {
+		return schema
+	}
+
+	storage, err := sm.getStoredSchema(ctx, chatID)
+	if err != nil || storage == nil {
+		return nil
+	}
+	return storage.FullSchema
+}
+
+func isSQLDatabaseType(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB,
+		constants.DatabaseTypeClickhouse, constants.DatabaseTypeSQLite:
+		return true
+	}
+	return false
+}
+
+// isSQLDialect tells whether the queries of a database type are SQL, the SQL databases & BigQuery whose client isn't a
+// database/sql connection (no pool, session or streaming)
+func isSQLDialect(dbType string) bool {
+	return isSQLDatabaseType(dbType) || dbType == constants.DatabaseTypeBigQuery
+}
+
+// parseSQLTableRefs extracts the tables of the FROM & JOIN clauses, the token indexes they use & the columns of USING clauses
+func parseSQLTableRefs(tokens []sqlToken) ([]sqlTableRef, map[int]bool, map[string]bool) {
+	var refs []sqlTableRef
+	consumed := make(map[int]bool)
+	usingColumns := make(map[string]bool)
+
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if token.kind != sqlTokenWord {
+			continue
+		}
+
+		switch token.value {
+		case "from":
+			if depth > 0 {
+				// Function arguments such as EXTRACT(year FROM created_at)
+				continue
+			}
+			next := i + 1
+			for {
+				ref, end, ok := parseSQLTableRef(tokens, next, consumed)
+				if !ok {
+					break
+				}
+				refs = append(refs, ref)
+				if end < len(tokens) && tokens[end].text == "," {
+					next = end + 1
+					continue
+				}
+				break
+			}
+		case "join":
+			ref, _, ok := parseSQLTableRef(tokens, i+1, consumed)
+			if !ok {
+				continue
+			}
+			ref.joinType = "INNER"
+			for j := i - 1; j >= 0 && j >= i-2; j-- {
+				switch tokens[j].value {
+				case "left", "right", "full", "cross":
+					ref.joinType = strings.ToUpper(tokens[j].value)
+				}
+			}
+			refs = append(refs, ref)
+		case "using":
+			if i+1 < len(tokens) && tokens[i+1].text == "(" {
+				for j := i + 2; j < len(tokens) && tokens[j].text != ")"; j++ {
+					if isIdentifierToken(tokens[j]) {
+						usingColumns[tokens[j].value] = true
+					}
+				}
+			}
+		}
+	}
+	return refs, consumed, usingColumns
+}
+
+// parseSQLTableRef parses "[schema.]table [[AS] alias]" starting at idx, subqueries aren't table references
+func parseSQLTableRef(tokens []sqlToken, idx int, consumed map[int]bool) (sqlTableRef, int, bool) {
+	if idx >= len(tokens) || !isIdentifierToken(tokens[idx]) || sqlClauseKeywords[tokens[idx].value] {
+		return sqlTableRef{}, idx, false
+	}
+
+	ref := sqlTableRef{}
+	i := idx
+	for {
+		consumed[i] = true
+		ref.table = tokens[i].value
+		ref.qualifier = tokens[i].text
+		if i+2 < len(tokens) && tokens[i+1].text == "." && isIdentifierToken(tokens[i+2]) {
+			i += 2
+			continue
+		}
+		break
+	}
+	i++
+
+	if i < len(tokens) && tokens[i].kind == sqlTokenWord && tokens[i].value == "as" {
+		i++
+	}
+	if i < len(tokens) && isIdentifierToken(tokens[i]) && !(tokens[i].kind == sqlTokenWord && sqlClauseKeywords[tokens[i].value]) {
+		consumed[i] = true
+		ref.qualifier = tokens[i].text
+		i++
+	}
+	return ref, i, true
+}
+
+// parseEquiJoinKeys finds "a.col = b.col" conditions, keyed by joinKeyID
+func parseEquiJoinKeys(tokens []sqlToken) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i+6 < len(tokens); i++ {
+		left, right := tokens[i:i+3], tokens[i+4:i+7]
+		if tokens[i+3].text != "=" || left[1].text != "." || right[1].text != "." {
+			continue
+		}
+		if !isIdentifierToken(left[0]) || !isIdentifierToken(left[2]) || !isIdentifierToken(right[0]) || !isIdentifierToken(right[2]) {
+			continue
+		}
+		if left[2].value != right[2].value {
+			continue
+		}
+		keys[joinKeyID(left[0].text, right[0].text, left[2].value)] = true
+		keys[joinKeyID(right[0].text, left[0].text, left[2].value)] = true
+	}
+	return keys
+}
+
+func joinKeyID(leftQualifier, rightQualifier, column string) string {
+	return strings.ToLower(unquoteIdentifier(leftQualifier)) + "|" + strings.ToLower(unquoteIdentifier(rightQualifier)) + "|" + column
+}
+
+func tableHasColumn(table TableSchema, column string) bool {
+	if _, ok := table.Columns[column]; ok {
+		return true
+	}
+	for name := range table.Columns {
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsSQLExpression checks if a token can end an expression, an identifier right after it is an output alias without AS
+func endsSQLExpression(token sqlToken) bool {
+	switch token.kind {
+	case sqlTokenQuotedIdent, sqlTokenString, sqlTokenNumber:
+		return true
+	case sqlTokenWord:
+		return !sqlClauseKeywords[token.value] || token.value == "end"
+	}
+	return token.text == ")"
+}
+
+func isIdentifierToken(token sqlToken) bool {
+	return token.kind == sqlTokenWord || token.kind == sqlTokenQuotedIdent
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) >= 2 {
+		first, last := identifier[0], identifier[len(identifier)-1]
+		if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+			return identifier[1 : len(identifier)-1]
+		}
+	}
+	return identifier
+}
+
+// tokenizeSQL splits a query into words, quoted identifiers, literals & punctuation, comments are skipped
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	runes := []rune(query)
+	// Byte offsets of every rune, so that tokens can be located in the original string
+	offsets := make([]int, len(runes)+1)
+	offset := 0
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			i++
+			for i < len(runes) {
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						i += 2 // Escaped quote
+						continue
+					}
+					break
+				}
+				i++
+			}
+			if i < len(runes) {
+				i++
+			}
+			kind := sqlTokenQuotedIdent
+			if r == '\'' {
+				kind = sqlTokenString
+			}
+			text := string(runes[start:min(i, len(runes))])
+			tokens = append(tokens, sqlToken{kind: kind, text: text, value: strings.ToLower(unquoteIdentifier(text)), start: offsets[start]})
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			text := string(runes[start:i])
+			tokens = append(tokens, sqlToken{kind: sqlTokenWord, text: text, value: strings.ToLower(text), start: offsets[start]})
+			continue
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: string(runes[start:i]), start: offsets[start]})
+			continue
+		default:
+			i++
+			tokens = append(tokens, sqlToken{kind: sqlTokenPunct, text: string(r), start: offsets[start]})
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,333 @@
+package dbmanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlTableElementKeywords start the constraints of a CREATE TABLE or of an ALTER TABLE ... ADD, they aren't columns
+var sqlTableElementKeywords = map[string]bool{
+	"constraint": true, "primary": true, "foreign": true, "unique": true, "check": true, "key": true, "index": true,
+	"exclude": true, "like": true, "fulltext": true, "spatial": true, "partition": true,
+}
+
+// sqlIndexColumnKeywords follow the columns of a CREATE INDEX, they aren't columns
+var sqlIndexColumnKeywords = map[string]bool{
+	"asc": true, "desc": true, "nulls": true, "first": true, "last": true, "collate": true,
+}
+
+// MigrationSupported checks if schema migrations can be generated for a database type, only SQL DDL is validated
+func MigrationSupported(dbType string) bool {
+	return isSQLDatabaseType(dbType)
+}
+
+// MigrationValidator checks the DDL statements of a migration against the schema they start from, the tables &
+// columns created, renamed or dropped by a statement are seen by the following ones
+type MigrationValidator struct {
+	tables map[string]map[string]bool // Lower cased table name without schema -> lower cased column names
+}
+
+// NewMigrationValidator starts a migration from the current schema
+func NewMigrationValidator(schema *SchemaInfo) *MigrationValidator {
+	v := &MigrationValidator{tables: make(map[string]map[string]bool)}
+	if schema == nil {
+		return v
+	}
+	for name, table := range schema.Tables {
+		columns := make(map[string]bool, len(table.Columns))
+		for column := range table.Columns {
+			columns[strings.ToLower(column)] = true
+		}
+		v.tables[migrationTableName(name)] = columns
+	}
+	return v
+}
+
+// Check returns the problems of the statements of a query: altered, dropped & indexed tables and their columns must
+// exist, created tables & added columns must not. The changes of the query are then applied to the schema of the
+// following queries. Statements other than ALTER TABLE, CREATE/DROP TABLE & CREATE INDEX aren't checked.
+func (v *MigrationValidator) Check(query string) []string {
+	var problems []string
+	for _, statement := range splitSQLTopLevel(tokenizeSQL(query), ";") {
+		problems = append(problems, v.checkStatement(statement)...)
+	}
+	return problems
+}
+
+func (v *MigrationValidator) checkStatement(tokens []sqlToken) []string {
+	if len(tokens) < 3 {
+		return nil
+	}
+	switch {
+	case tokens[0].value == "alter" && tokens[1].value == "table":
+		return v.checkAlterTable(tokens[2:])
+	case tokens[0].value == "drop" && tokens[1].value == "table":
+		return v.checkDropTable(tokens[2:])
+	case tokens[0].value == "create" && tokens[1].value == "table":
+		return v.checkCreateTable(tokens[2:])
+	case tokens[0].value == "create" && (tokens[1].value == "index" || tokens[1].value == "unique"):
+		return v.checkCreateIndex(tokens[1:])
+	}
+	return nil
+}
+
+func (v *MigrationValidator) checkAlterTable(tokens []sqlToken) []string {
+	i, ifExists := skipSQLWords(tokens, 0, "if", "exists")
+	i, _ = skipSQLWords(tokens, i, "only")
+	table, i, ok := parseMigrationTableName(tokens, i)
+	if !ok {
+		return nil
+	}
+	columns, exists := v.tables[table]
+	if !exists {
+		if ifExists {
+			return nil
+		}
+		return []string{fmt.Sprintf("table %q doesn't exist", table)}
+	}
+
+	var problems []string
+	for _, action := range splitSQLTopLevel(tokens[i:], ",") {
+		if len(action) == 0 || action[0].kind != sqlTokenWord {
+			continue
+		}
+		switch action[0].value {
+		case "add":
+			j, _ := skipSQLWords(action, 1, "column")
+			j, ifNotExists := skipSQLWords(action, j, "if", "not", "exists")
+			if j >= len(action) || !isIdentifierToken(action[j]) || (action[j].kind == sqlTokenWord && sqlTableElementKeywords[action[j].value]) {
+				continue
+			}
+			column := action[j].value
+			if columns[column] && !ifNotExists {
+				problems = append(problems, fmt.Sprintf("column %q already exists in table %q", column, table))
+			}
+			columns[column] = true
+		case "drop":
+			j, isColumn := skipSQLWords(action, 1, "column")
+			j, ifExists := skipSQLWords(action, j, "if", "exists")
+			if j >= len(action) || !isIdentifierToken(action[j]) || (!isColumn && action[j].kind == sqlTokenWord && (sqlTableElementKeywords[action[j].value] || action[j].value == "default")) {
+				continue
+			}
+			column := action[j].value
+			if !columns[column] && !ifExists {
+				problems = append(problems, fmt.Sprintf("column %q doesn't exist in table %q", column, table))
+			}
+			delete(columns, column)
+		case "alter", "modify", "change":
+			j, _ := skipSQLWords(action, 1, "column")
+			if j >= len(action) || !isIdentifierToken(action[j]) {
+				continue
+			}
+			column := action[j].value
+			if !columns[column] {
+				problems = append(problems, fmt.Sprintf("column %q doesn't exist in table %q", column, table))
+			}
+			// CHANGE old new type renames the column (MySQL)
+			if action[0].value == "change" && j+1 < len(action) && isIdentifierToken(action[j+1]) {
+				delete(columns, column)
+				columns[action[j+1].value] = true
+			}
+		case "rename":
+			j, _ := skipSQLWords(action, 1, "column")
+			if j < len(action) && action[j].value == "to" {
+				// RENAME TO new_name renames the table
+				if newTable, _, ok := parseMigrationTableName(action, j+1); ok {
+					delete(v.tables, table)
+					v.tables[newTable] = columns
+					table = newTable
+				}
+				continue
+			}
+			if j+2 >= len(action) || !isIdentifierToken(action[j]) || action[j+1].value != "to" || (action[j].kind == sqlTokenWord && sqlTableElementKeywords[action[j].value]) {
+				continue
+			}
+			column := action[j].value
+			if !columns[column] {
+				problems = append(problems, fmt.Sprintf("column %q doesn't exist in table %q", column, table))
+			}
+			delete(columns, column)
+			columns[action[j+2].value] = true
+		}
+	}
+	return problems
+}
+
+func (v *MigrationValidator) checkDropTable(tokens []sqlToken) []string {
+	i, ifExists := skipSQLWords(tokens, 0, "if", "exists")
+	var problems []string
+	for {
+		table, end, ok := parseMigrationTableName(tokens, i)
+		if !ok {
+			break
+		}
+		if _, exists := v.tables[table]; !exists && !ifExists {
+			problems = append(problems, fmt.Sprintf("table %q doesn't exist", table))
+		}
+		delete(v.tables, table)
+		if end >= len(tokens) || tokens[end].text != "," {
+			break
+		}
+		i = end + 1
+	}
+	return problems
+}
+
+func (v *MigrationValidator) checkCreateTable(tokens []sqlToken) []string {
+	i, ifNotExists := skipSQLWords(tokens, 0, "if", "not", "exists")
+	table, i, ok := parseMigrationTableName(tokens, i)
+	if !ok {
+		return nil
+	}
+	var problems []string
+	if _, exists := v.tables[table]; exists && !ifNotExists {
+		problems = append(problems, fmt.Sprintf("table %q already exists", table))
+	}
+
+	columns := make(map[string]bool)
+	if i < len(tokens) && tokens[i].text == "(" {
+		if end := matchingSQLParenthesis(tokens, i); end != -1 {
+			for _, element := range splitSQLTopLevel(tokens[i+1:end], ",") {
+				if len(element) > 0 && isIdentifierToken(element[0]) && !(element[0].kind == sqlTokenWord && sqlTableElementKeywords[element[0].value]) {
+					columns[element[0].value] = true
+				}
+			}
+		}
+	}
+	if _, exists := v.tables[table]; !exists {
+		v.tables[table] = columns
+	}
+	return problems
+}
+
+func (v *MigrationValidator) checkCreateIndex(tokens []sqlToken) []string {
+	// [UNIQUE] INDEX [CONCURRENTLY] [IF NOT EXISTS] [name] ON [ONLY] table [USING method] (columns)
+	on := -1
+	for i, token := range tokens {
+		if token.kind == sqlTokenWord && token.value == "on" {
+			on = i
+			break
+		}
+	}
+	if on == -1 {
+		return nil
+	}
+	i, _ := skipSQLWords(tokens, on+1, "only")
+	table, i, ok := parseMigrationTableName(tokens, i)
+	if !ok {
+		return nil
+	}
+	columns, exists := v.tables[table]
+	if !exists {
+		return []string{fmt.Sprintf("table %q doesn't exist", table)}
+	}
+
+	if i+1 < len(tokens) && tokens[i].value == "using" {
+		i += 2
+	}
+	if i >= len(tokens) || tokens[i].text != "(" {
+		return nil
+	}
+	end := matchingSQLParenthesis(tokens, i)
+	if end == -1 {
+		return nil
+	}
+	var problems []string
+	for _, part := range splitSQLTopLevel(tokens[i+1:end], ",") {
+		// Expressions (ex: lower(email)) aren't checked
+		if len(part) == 0 || !isIdentifierToken(part[0]) || (len(part) > 1 && part[1].text == "(") {
+			continue
+		}
+		if part[0].kind == sqlTokenWord && sqlIndexColumnKeywords[part[0].value] {
+			continue
+		}
+		if column := part[0].value; !columns[column] {
+			problems = append(problems, fmt.Sprintf("column %q doesn't exist in table %q", column, table))
+		}
+	}
+	return problems
+}
+
+// migrationTableName is the lower cased name of a table without its schema
+func migrationTableName(name string) string {
+	name = strings.ToLower(name)
+	if dot := strings.LastIndex(name, "."); dot != -1 {
+		name = name[dot+1:]
+	}
+	return name
+}
+
+// parseMigrationTableName parses "[schema.]table" starting at i, the table is returned without its schema
+func parseMigrationTableName(tokens []sqlToken, i int) (string, int, bool) {
+	if i >= len(tokens) || !isIdentifierToken(tokens[i]) {
+		return "", i, false
+	}
+	table := tokens[i].value
+	i++
+	for i+1 < len(tokens) && tokens[i].text == "." && isIdentifierToken(tokens[i+1]) {
+		table = tokens[i+1].value
+		i += 2
+	}
+	return table, i, true
+}
+
+// skipSQLWords skips the words at i if they all follow in order, ex: IF NOT EXISTS, the second value reports if they did
+func skipSQLWords(tokens []sqlToken, i int, words ...string) (int, bool) {
+	if i+len(words) > len(tokens) {
+		return i, false
+	}
+	for j, word := range words {
+		if tokens[i+j].kind != sqlTokenWord || tokens[i+j].value != word {
+			return i, false
+		}
+	}
+	return i + len(words), true
+}
+
+// splitSQLTopLevel splits tokens on a punctuation outside of parentheses, empty parts are dropped
+func splitSQLTopLevel(tokens []sqlToken, separator string) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, start := 0, 0
+	for i, token := range tokens {
+		if token.kind != sqlTokenPunct {
+			continue
+		}
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case separator:
+			if depth == 0 {
+				if i > start {
+					parts = append(parts, tokens[start:i])
+				}
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// matchingSQLParenthesis returns the index of the parenthesis closing the one at open, -1 if there is none
+func matchingSQLParenthesis(tokens []sqlToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind != sqlTokenPunct {
+			continue
+		}
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
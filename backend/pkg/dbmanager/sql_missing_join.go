@@ -0,0 +1,172 @@
+package dbmanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MissingJoin is a table a query needs but doesn't read in any FROM or JOIN clause, its results are then incomplete
+type MissingJoin struct {
+	Table      string `json:"table"`
+	Reason     string `json:"reason"`               // Why the table is needed, ex: its columns are referenced
+	Suggestion string `json:"suggestion,omitempty"` // JOIN following a foreign key with a table of the query, empty if there is none
+}
+
+func (j MissingJoin) String() string {
+	if j.Suggestion != "" {
+		return fmt.Sprintf("table %q is %s but isn't joined, join it with: %s", j.Table, j.Reason, j.Suggestion)
+	}
+	return fmt.Sprintf("table %q is %s but isn't joined", j.Table, j.Reason)
+}
+
+// GetKnownSchema returns the last schema fetched for a chat, nil if there is none
+func (m *Manager) GetKnownSchema(ctx context.Context, chatID string) *SchemaInfo {
+	chatID = connectionKey(ctx, chatID)
+	if m.schemaManager == nil {
+		return nil
+	}
+	return m.schemaManager.getKnownSchema(ctx, chatID)
+}
+
+// FindMissingJoins finds the tables a SELECT needs without reading them: tables listed in tables (the tables the LLM
+// says the query uses) & tables whose columns are referenced with a qualifier (ex: customers.name) that aren't in a
+// FROM or JOIN clause of the query. A JOIN is suggested from the foreign keys between the missing table & the tables
+// of the query. Tables unknown to the schema are ignored.
+func FindMissingJoins(query string, tables []string, schema *SchemaInfo) []MissingJoin {
+	if schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].value != "select" {
+		return nil
+	}
+
+	schemaTables := make(map[string]TableSchema, len(schema.Tables))
+	for name, table := range schema.Tables {
+		schemaTables[strings.ToLower(name)] = table
+	}
+
+	// Tables read anywhere in the query, subqueries included, with the qualifier to use in a JOIN condition
+	consumed := make(map[int]bool)
+	read := make(map[string]string) // table -> qualifier
+	qualifiers := make(map[string]bool)
+	for i, token := range tokens {
+		if token.kind != sqlTokenWord || (token.value != "from" && token.value != "join") {
+			continue
+		}
+		for idx := i + 1; ; {
+			ref, end, ok := parseSQLTableRef(tokens, idx, consumed)
+			if !ok {
+				break
+			}
+			if _, seen := read[ref.table]; !seen {
+				read[ref.table] = ref.qualifier
+			}
+			qualifiers[strings.ToLower(unquoteIdentifier(ref.qualifier))] = true
+			if token.value == "join" || end >= len(tokens) || tokens[end].text != "," {
+				break
+			}
+			idx = end + 1
+		}
+	}
+	if len(read) == 0 {
+		return nil
+	}
+
+	reasons := make(map[string]string)
+	for _, table := range tables {
+		name := strings.ToLower(strings.TrimSpace(table))
+		if dot := strings.LastIndex(name, "."); dot != -1 {
+			name = name[dot+1:]
+		}
+		name = unquoteIdentifier(name)
+		if _, known := schemaTables[name]; known && !qualifiers[name] {
+			if _, isRead := read[name]; !isRead {
+				reasons[name] = "listed in the tables of the query"
+			}
+		}
+	}
+	for i := 0; i+2 < len(tokens); i++ {
+		if consumed[i] || !isIdentifierToken(tokens[i]) || tokens[i+1].text != "." || !isIdentifierToken(tokens[i+2]) {
+			continue
+		}
+		name := tokens[i].value
+		if i > 0 && tokens[i-1].text == "." {
+			// schema.table.column, the table is the middle part
+			continue
+		}
+		if _, known := schemaTables[name]; !known || qualifiers[name] {
+			continue
+		}
+		if _, isRead := read[name]; !isRead {
+			reasons[name] = fmt.Sprintf("referenced by %s.%s", tokens[i].text, tokens[i+2].text)
+		}
+	}
+
+	names := make([]string, 0, len(reasons))
+	for name := range reasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	missing := make([]MissingJoin, 0, len(names))
+	for _, name := range names {
+		missing = append(missing, MissingJoin{
+			Table:      name,
+			Reason:     reasons[name],
+			Suggestion: suggestJoin(name, read, schemaTables),
+		})
+	}
+	return missing
+}
+
+// suggestJoin returns a JOIN of table following a foreign key between it & one of the read tables
+func suggestJoin(table string, read map[string]string, schemaTables map[string]TableSchema) string {
+	readTables := make([]string, 0, len(read))
+	for name := range read {
+		readTables = append(readTables, name)
+	}
+	sort.Strings(readTables)
+
+	for _, readTable := range readTables {
+		qualifier := read[readTable]
+		// Foreign key of the missing table referencing a read table
+		for _, fk := range sortedForeignKeys(schemaTables[table]) {
+			if strings.EqualFold(fk.RefTable, readTable) {
+				return fmt.Sprintf("JOIN %s ON %s.%s = %s.%s", table, table, fk.ColumnName, qualifier, fk.RefColumn)
+			}
+		}
+		// Foreign key of a read table referencing the missing table
+		for _, fk := range sortedForeignKeys(schemaTables[readTable]) {
+			if strings.EqualFold(fk.RefTable, table) {
+				return fmt.Sprintf("JOIN %s ON %s.%s = %s.%s", table, table, fk.RefColumn, qualifier, fk.ColumnName)
+			}
+		}
+	}
+	return ""
+}
+
+func sortedForeignKeys(table TableSchema) []ForeignKey {
+	names := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	foreignKeys := make([]ForeignKey, len(names))
+	for i, name := range names {
+		foreignKeys[i] = table.ForeignKeys[name]
+	}
+	return foreignKeys
+}
+
+// FormatMissingJoins describes the missing joins of a query for the LLM
+func FormatMissingJoins(missing []MissingJoin) string {
+	descriptions := make([]string, len(missing))
+	for i, join := range missing {
+		descriptions[i] = join.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
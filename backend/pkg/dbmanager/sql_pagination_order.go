@@ -0,0 +1,190 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unorderedPaginationWarning is returned for paginated queries without ORDER BY that can't be ordered automatically
+const unorderedPaginationWarning = "The paginated query has no ORDER BY, rows may be repeated or missing across pages."
+
+// plainIdentifierRegex matches identifiers that don't need to be quoted
+var plainIdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// SetPaginationOrderInjection enables ordering paginated queries without an ORDER BY, see OrderPaginatedQuery
+func (m *Manager) SetPaginationOrderInjection(enabled bool) {
+	m.paginationOrder = enabled
+}
+
+// OrderPaginatedQuery makes the pages of an offset paginated SELECT deterministic. Without an ORDER BY the database is
+// free to return the rows in a different order for every page, so rows can be repeated or missing across pages.
+// The primary keys of the tables read by the query are added as ORDER BY, the warning is set when the query has no
+// ORDER BY & can't be ordered (ex: a table without primary key).
+func (m *Manager) OrderPaginatedQuery(ctx context.Context, chatID, query string) (string, string) {
+	chatID = connectionKey(ctx, chatID)
+	if !m.paginationOrder || m.schemaManager == nil {
+		return query, ""
+	}
+	m.mu.RLock()
+	conn, exists := m.connections[chatID]
+	m.mu.RUnlock()
+	if !exists || !isSQLDatabaseType(conn.Config.Type) {
+		return query, ""
+	}
+	if conn.Config.Type == constants.DatabaseTypeClickhouse {
+		// ClickHouse primary keys are sorting keys, they aren't unique so only aggregations can be ordered
+		return addPaginationOrder(conn.Config.Type, query, nil)
+	}
+	return addPaginationOrder(conn.Config.Type, query, m.schemaManager.getKnownSchema(ctx, chatID))
+}
+
+// addPaginationOrder adds an ORDER BY before the LIMIT of a SELECT that has none: the GROUP BY expressions of
+// aggregations, the primary keys of the FROM & JOIN tables otherwise. The query is returned as is when it is already
+// ordered or isn't a SELECT.
+func addPaginationOrder(dbType, query string, schema *SchemaInfo) (string, string) {
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].value != "select" {
+		return query, ""
+	}
+
+	depth := 0
+	var refs []sqlTableRef
+	consumed := make(map[int]bool)
+	groupBy, insertAt := -1, -1
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth > 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].value
+		}
+
+		switch token.value {
+		case "order":
+			if next == "by" {
+				return query, ""
+			}
+		case "distinct":
+			if i != 1 {
+				// IS DISTINCT FROM
+				continue
+			}
+			return query, unorderedPaginationWarning + " Add an ORDER BY on unique columns to make the pages stable."
+		case "union", "intersect", "except":
+			return query, unorderedPaginationWarning + " Add an ORDER BY on unique columns to make the pages stable."
+		case "group":
+			if next == "by" {
+				groupBy = i
+			}
+		case "from":
+			if i > 0 && tokens[i-1].value == "distinct" {
+				continue
+			}
+			for idx := i + 1; ; {
+				ref, end, ok := parseSQLTableRef(tokens, idx, consumed)
+				if !ok {
+					break
+				}
+				refs = append(refs, ref)
+				if end >= len(tokens) || tokens[end].text != "," {
+					break
+				}
+				idx = end + 1
+			}
+		case "join":
+			if ref, _, ok := parseSQLTableRef(tokens, i+1, consumed); ok {
+				refs = append(refs, ref)
+			}
+		case "limit", "offset", "fetch", "for", "settings", "format":
+			if insertAt == -1 {
+				insertAt = i
+			}
+		}
+	}
+
+	end := len(query)
+	if insertAt != -1 {
+		end = tokens[insertAt].start
+	} else if last := tokens[len(tokens)-1]; last.text == ";" {
+		end = last.start
+	}
+
+	var orderBy string
+	if groupBy != -1 {
+		// Each group is a single row, ordering on the grouping expressions is deterministic
+		groupEnd := end
+		for _, token := range tokens[groupBy+2:] {
+			if token.start >= end {
+				break
+			}
+			if token.kind == sqlTokenWord && (token.value == "having" || token.value == "window" || token.value == "rollup" || token.value == "cube" || token.value == "grouping" || token.value == "with") {
+				if token.value != "having" && token.value != "window" {
+					return query, unorderedPaginationWarning + " Add an ORDER BY on the grouping columns to make the pages stable."
+				}
+				groupEnd = token.start
+				break
+			}
+		}
+		orderBy = strings.TrimSpace(query[tokens[groupBy+1].start+len(tokens[groupBy+1].text) : groupEnd])
+	} else {
+		if schema == nil || len(refs) == 0 {
+			return query, unorderedPaginationWarning
+		}
+		tables := make(map[string]TableSchema, len(schema.Tables))
+		for name, table := range schema.Tables {
+			tables[strings.ToLower(name)] = table
+		}
+
+		var keys []string
+		for _, ref := range refs {
+			primaryKey := tablePrimaryKey(tables[ref.table])
+			if len(primaryKey) == 0 {
+				return query, fmt.Sprintf("%s The %s table has no primary key to order the pages on.", unorderedPaginationWarning, ref.table)
+			}
+			for _, column := range primaryKey {
+				keys = append(keys, ref.qualifier+"."+quoteSQLIdentifier(dbType, column))
+			}
+		}
+		orderBy = strings.Join(keys, ", ")
+	}
+	if orderBy == "" {
+		return query, ""
+	}
+
+	ordered := strings.TrimRight(query[:end], " \t\r\n") + " ORDER BY " + orderBy
+	if end < len(query) {
+		ordered += " " + query[end:]
+	}
+	return ordered, ""
+}
+
+// tablePrimaryKey returns the primary key columns of a table, nil if it has none
+func tablePrimaryKey(table TableSchema) []string {
+	for _, constraint := range table.Constraints {
+		if constraint.Type == "PRIMARY KEY" && len(constraint.Columns) > 0 {
+			return constraint.Columns
+		}
+	}
+	return nil
+}
+
+// quoteSQLIdentifier quotes a column name that isn't a plain lower case identifier
+func quoteSQLIdentifier(dbType, identifier string) string {
+	if plainIdentifierRegex.MatchString(identifier) && !sqlClauseKeywords[identifier] {
+		return identifier
+	}
+	if dbType == constants.DatabaseTypeMySQL || dbType == constants.DatabaseTypeMariaDB {
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,312 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// paginationPlaceholder is replaced by the offset of the page in a paginated query
+const paginationPlaceholder = "offset_size"
+
+// paginationClauseRanks orders the trailing clauses of a paginated query, MySQL, ClickHouse, SQLite & BigQuery only
+// accept the LIMIT before the OFFSET while PostgreSQL keeps them as written (ex: OFFSET ... FETCH NEXT 50 ROWS ONLY)
+func paginationClauseRanks(dbType string) map[string]int {
+	switch dbType {
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeClickhouse, constants.DatabaseTypeSQLite,
+		constants.DatabaseTypeBigQuery:
+		return map[string]int{"order": 0, "limit": 1, "offset": 2, "fetch": 3}
+	}
+	return map[string]int{"order": 0, "limit": 1, "offset": 1, "fetch": 1}
+}
+
+// FixPaginatedQuery checks the structure of a paginated query generated by the LLM before it is stored: the
+// offset_size placeholder must appear exactly once, as the offset of the query. The mistakes that can be fixed are
+// corrected: a placeholder written as {offset_size}, :offset_size or 'offset_size', an ORDER BY after the LIMIT & for
+// MySQL/ClickHouse/SQLite/BigQuery an OFFSET before the LIMIT. For Elasticsearch the placeholder must be the from of
+// the search. An error is returned for a query whose pages can't be substituted.
+func FixPaginatedQuery(dbType, query string) (string, error) {
+	if strings.TrimSpace(query) != "" && dbType == constants.DatabaseTypeElasticsearch {
+		return fixElasticsearchPaginatedQuery(query)
+	}
+	if strings.TrimSpace(query) == "" || (dbType != constants.DatabaseTypeMongoDB && !isSQLDialect(dbType)) {
+		return query, nil
+	}
+	query = normalizePaginationPlaceholder(query)
+
+	tokens := tokenizeSQL(query)
+	placeholder := -1
+	for i, token := range tokens {
+		if token.kind != sqlTokenWord || token.value != paginationPlaceholder {
+			continue
+		}
+		if placeholder != -1 {
+			return query, fmt.Errorf("the paginated query has the %s placeholder more than once", paginationPlaceholder)
+		}
+		placeholder = i
+	}
+	if placeholder == -1 {
+		return query, fmt.Errorf("the paginated query has no %s placeholder", paginationPlaceholder)
+	}
+	if dbType == constants.DatabaseTypeMongoDB {
+		return query, nil
+	}
+
+	// OFFSET offset_size, or LIMIT offset_size, 50 for MySQL
+	previous := ""
+	if placeholder > 0 {
+		previous = tokens[placeholder-1].value
+	}
+	mysqlOffset := previous == "limit" && placeholder+1 < len(tokens) && tokens[placeholder+1].text == ","
+	if previous != "offset" && !mysqlOffset {
+		return query, fmt.Errorf("the %s placeholder of the paginated query isn't its OFFSET", paginationPlaceholder)
+	}
+	return orderPaginationClauses(dbType, query, tokens), nil
+}
+
+// normalizePaginationPlaceholder rewrites the placeholders quoted or wrapped like a bind parameter (ex: {offset_size},
+// {{offset_size}}, :offset_size, $offset_size or 'offset_size') as a bare offset_size that the offset replaces
+func normalizePaginationPlaceholder(query string) string {
+	tokens := tokenizeSQL(query)
+	var result strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if strings.Trim(token.value, "'") != paginationPlaceholder || token.kind == sqlTokenPunct || token.kind == sqlTokenNumber {
+			continue
+		}
+		start, end := token.start, token.start+len(token.text)
+		if token.kind == sqlTokenWord {
+			// Braces around the placeholder, as many opening as closing ones (ex: not the { of {$skip: offset_size})
+			opening, closing := i, i
+			for opening > 0 && tokens[opening-1].text == "{" && strings.TrimSpace(query[tokens[opening-1].start+1:tokens[opening].start]) == "" {
+				opening--
+			}
+			for closing < len(tokens)-1 && closing-i < i-opening && tokens[closing+1].text == "}" && strings.TrimSpace(query[tokens[closing].start+len(tokens[closing].text):tokens[closing+1].start]) == "" {
+				closing++
+			}
+			if braces := closing - i; braces > 0 {
+				start, end = tokens[i-braces].start, tokens[closing].start+1
+			} else if i > 1 && tokens[i-1].start+1 == token.start && strings.Contains(":$@", tokens[i-1].text) &&
+				(tokens[i-2].value == "offset" || tokens[i-2].value == "limit" || tokens[i-2].text == "(") {
+				// Bind parameter prefix right after OFFSET, LIMIT or skip(
+				start = tokens[i-1].start
+			}
+		}
+		if start == token.start && end == start+len(paginationPlaceholder) && token.text == paginationPlaceholder {
+			continue
+		}
+		result.WriteString(query[last:start])
+		result.WriteString(paginationPlaceholder)
+		last = end
+	}
+	if last == 0 {
+		return query
+	}
+	result.WriteString(query[last:])
+	return result.String()
+}
+
+// orderPaginationClauses moves the top level ORDER BY of a query before its LIMIT & OFFSET, the LLM sometimes writes
+// it last. The query is returned as is when its clauses are in order or followed by anything else (ex: FOR UPDATE).
+func orderPaginationClauses(dbType, query string, tokens []sqlToken) string {
+	end := len(query)
+	if last := tokens[len(tokens)-1]; last.text == ";" {
+		end = last.start
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	type clause struct {
+		keyword string
+		start   int
+	}
+	var clauses []clause
+	depth := 0
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth > 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		switch token.value {
+		case "order":
+			if i+1 < len(tokens) && tokens[i+1].value == "by" {
+				clauses = append(clauses, clause{keyword: "order", start: token.start})
+			}
+		case "limit", "offset", "fetch":
+			clauses = append(clauses, clause{keyword: token.value, start: token.start})
+		case "union", "intersect", "except", "for", "settings", "format", "select", "from", "where", "group", "having":
+			if len(clauses) > 0 {
+				return query
+			}
+		}
+	}
+	if len(clauses) < 2 {
+		return query
+	}
+
+	ranks := paginationClauseRanks(dbType)
+	ordered := make([]clause, len(clauses))
+	copy(ordered, clauses)
+	sort.SliceStable(ordered, func(i, j int) bool { return ranks[ordered[i].keyword] < ranks[ordered[j].keyword] })
+	changed := false
+	for i := range clauses {
+		if ordered[i] != clauses[i] {
+			changed = true
+		}
+	}
+	if !changed {
+		return query
+	}
+
+	texts := make(map[int]string, len(clauses))
+	for i, c := range clauses {
+		clauseEnd := end
+		if i+1 < len(clauses) {
+			clauseEnd = clauses[i+1].start
+		}
+		texts[c.start] = strings.TrimSpace(query[c.start:clauseEnd])
+	}
+	parts := make([]string, 0, len(ordered))
+	for _, c := range ordered {
+		parts = append(parts, texts[c.start])
+	}
+	return strings.TrimRight(query[:clauses[0].start], " \t\r\n") + " " + strings.Join(parts, " ") + query[end:]
+}
+
+// mongoPageLimitRegex matches the row count of a paginated MongoDB query, ex: .limit(50) or {$limit: 50}
+var mongoPageLimitRegex = regexp.MustCompile(`(?:\.limit\(\s*|["']?\$limit["']?\s*:\s*)(\d+)`)
+
+// SetPaginatedPageSize writes the page size as the row count of a paginated query (ex: LIMIT 50 becomes LIMIT 100),
+// the LLM may have written another one or the page size of the chat changed since the query was generated. The query
+// is returned as is when its row count isn't a number.
+func SetPaginatedPageSize(dbType, query string, pageSize int) string {
+	if pageSize <= 0 {
+		return query
+	}
+	if dbType == constants.DatabaseTypeMongoDB {
+		return setMongoPageSize(query, pageSize)
+	}
+	if dbType == constants.DatabaseTypeElasticsearch {
+		return setElasticsearchPageSize(query, pageSize)
+	}
+	if !isSQLDialect(dbType) {
+		return query
+	}
+
+	tokens := tokenizeSQL(query)
+	depth := 0
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth > 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		count := -1
+		switch token.value {
+		case "limit":
+			// LIMIT offset_size, 50 for MySQL
+			count = i + 1
+			if i+3 < len(tokens) && tokens[i+1].value == paginationPlaceholder && tokens[i+2].text == "," {
+				count = i + 3
+			}
+		case "fetch":
+			// FETCH NEXT 50 ROWS ONLY
+			count = i + 2
+		}
+		if count == -1 || count >= len(tokens) || tokens[count].kind != sqlTokenNumber {
+			continue
+		}
+		number := tokens[count]
+		return query[:number.start] + strconv.Itoa(pageSize) + query[number.start+len(number.text):]
+	}
+	return query
+}
+
+// setMongoPageSize writes the page size in the limit following the skip(offset_size) of a MongoDB query, or in the
+// limit of a find chaining it first (ex: .limit(50).skip(offset_size), the cursor skips first anyway)
+func setMongoPageSize(query string, pageSize int) string {
+	placeholder := strings.Index(query, paginationPlaceholder)
+	var count []int
+	for _, match := range mongoPageLimitRegex.FindAllStringSubmatchIndex(query, -1) {
+		if match[0] > placeholder {
+			count = match
+			break
+		}
+		if count == nil && strings.HasPrefix(query[match[0]:], ".limit") {
+			count = match
+		}
+	}
+	if count == nil {
+		return query
+	}
+	return query[:count[2]] + strconv.Itoa(pageSize) + query[count[3]:]
+}
+
+var (
+	// elasticsearchFromPlaceholderRegex matches the from of a paginated Elasticsearch query with its placeholder quoted
+	// or wrapped like a bind parameter, ex: "from": "offset_size" or "from": {{offset_size}}
+	elasticsearchFromPlaceholderRegex = regexp.MustCompile(`("from"\s*:\s*)["']?\{*\s*[:$]?offset_size\s*\}*["']?`)
+	// elasticsearchPageSizeRegex matches the size of an Elasticsearch query, ex: "size": 50
+	elasticsearchPageSizeRegex = regexp.MustCompile(`"size"\s*:\s*(\d+)`)
+)
+
+// fixElasticsearchPaginatedQuery checks that the offset_size placeholder of a paginated Elasticsearch query is its
+// from, written bare so that the offset replaces it, ex: "from": offset_size, "size": 50
+func fixElasticsearchPaginatedQuery(query string) (string, error) {
+	query = elasticsearchFromPlaceholderRegex.ReplaceAllString(query, "${1}"+paginationPlaceholder)
+	if count := strings.Count(query, paginationPlaceholder); count == 0 {
+		return query, fmt.Errorf("the paginated query has no %s placeholder", paginationPlaceholder)
+	} else if count > 1 {
+		return query, fmt.Errorf("the paginated query has the %s placeholder more than once", paginationPlaceholder)
+	}
+	if !elasticsearchFromPlaceholderRegex.MatchString(query) {
+		return query, fmt.Errorf("the %s placeholder of the paginated query isn't its from", paginationPlaceholder)
+	}
+	return query, nil
+}
+
+// setElasticsearchPageSize writes the page size in the size of the search body, the sizes of the aggregations nested
+// deeper (ex: a terms aggregation) are left as is
+func setElasticsearchPageSize(query string, pageSize int) string {
+	depth := -1
+	if placeholder := strings.Index(query, paginationPlaceholder); placeholder != -1 {
+		depth = jsonDepthAt(query, placeholder)
+	}
+	for _, match := range elasticsearchPageSizeRegex.FindAllStringSubmatchIndex(query, -1) {
+		if matchDepth := jsonDepthAt(query, match[0]); depth == -1 && matchDepth == 1 || matchDepth == depth {
+			return query[:match[2]] + strconv.Itoa(pageSize) + query[match[3]:]
+		}
+	}
+	return query
+}
+
+// jsonDepthAt returns the number of JSON objects & arrays open at a position of a text, the braces of strings aside
+func jsonDepthAt(text string, position int) int {
+	depth := 0
+	inString := false
+	for i := 0; i < position && i < len(text); i++ {
+		switch c := text[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && (c == '{' || c == '['):
+			depth++
+		case !inString && (c == '}' || c == ']'):
+			depth--
+		}
+	}
+	return depth
+}
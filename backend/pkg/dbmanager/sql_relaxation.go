@@ -0,0 +1,160 @@
+package dbmanager
+
+import (
+	"strings"
+)
+
+// RelaxedQuery is a SELECT with one of its WHERE conditions removed, used to find which filter empties a result
+type RelaxedQuery struct {
+	RemovedCondition string `json:"removed_condition"`
+	CountQuery       string `json:"count_query"` // Counts the rows of the relaxed query
+}
+
+// sqlClauseEnds are the clauses that end a WHERE clause
+var sqlClauseEnds = map[string]bool{
+	"group": true, "order": true, "limit": true, "having": true, "offset": true, "fetch": true,
+	"union": true, "window": true, "for": true, "intersect": true, "except": true,
+}
+
+// relaxedSuffixEnds are the trailing clauses dropped from the relaxed queries, ORDER BY is matched separately
+var relaxedSuffixEnds = map[string]bool{"limit": true, "offset": true, "fetch": true, "for": true}
+
+// RelaxWhereConditions returns a count query per top level AND condition of the WHERE clause, each one without that
+// condition. A WHERE clause with a top level OR is treated as a single condition. Only plain SELECT statements are supported,
+// nil is returned for anything else.
+func RelaxWhereConditions(query string) []RelaxedQuery {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 || tokens[0].value != "select" {
+		return nil
+	}
+
+	// Locate the WHERE clause at depth 0
+	whereIdx, endIdx := -1, len(tokens)
+	depth := 0
+findWhere:
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		case ";":
+			return nil // Multiple statements
+		}
+		if depth != 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		if token.value == "where" && whereIdx == -1 {
+			whereIdx = i
+		} else if sqlClauseEnds[token.value] {
+			if token.value == "union" || token.value == "intersect" || token.value == "except" {
+				return nil
+			}
+			if whereIdx != -1 {
+				endIdx = i
+				break findWhere
+			}
+		}
+	}
+	if whereIdx == -1 || whereIdx+1 >= endIdx {
+		return nil
+	}
+
+	whereEnd := len(query)
+	if endIdx < len(tokens) {
+		whereEnd = tokens[endIdx].start
+	}
+
+	// Split the conditions on top level ANDs, the AND of "BETWEEN x AND y" is part of the condition
+	var conditions [][2]int // Byte ranges of the conditions
+	condStart := tokens[whereIdx+1].start
+	depth = 0
+	pendingBetween := false
+	for i := whereIdx + 1; i < endIdx; i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		switch token.value {
+		case "or":
+			// Relaxing a part of an OR would widen other branches, keep the clause whole
+			conditions = [][2]int{{tokens[whereIdx+1].start, whereEnd}}
+			i = endIdx
+		case "between":
+			pendingBetween = true
+		case "and":
+			if pendingBetween {
+				pendingBetween = false
+				continue
+			}
+			conditions = append(conditions, [2]int{condStart, token.start})
+			if i+1 < endIdx {
+				condStart = tokens[i+1].start
+			}
+		}
+	}
+	if len(conditions) == 0 || conditions[len(conditions)-1][1] != whereEnd {
+		conditions = append(conditions, [2]int{condStart, whereEnd})
+	}
+
+	// ORDER BY, LIMIT, OFFSET, FETCH & FOR UPDATE are dropped, the count must not be capped by the original limit
+	// & the probes must neither sort nor lock rows
+	suffixEnd := len(query)
+	depth = 0
+	for i := endIdx; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth != 0 || token.kind != sqlTokenWord {
+			continue
+		}
+		if relaxedSuffixEnds[token.value] || (token.value == "order" && i+1 < len(tokens) && tokens[i+1].value == "by") {
+			suffixEnd = token.start
+			break
+		}
+	}
+
+	prefix := strings.TrimSpace(query[:tokens[whereIdx].start])
+	suffix := strings.TrimSpace(query[whereEnd:suffixEnd])
+
+	relaxed := make([]RelaxedQuery, 0, len(conditions))
+	for i, removed := range conditions {
+		var kept []string
+		for j, condition := range conditions {
+			if j != i {
+				kept = append(kept, strings.TrimSpace(query[condition[0]:condition[1]]))
+			}
+		}
+
+		relaxedQuery := prefix
+		if len(kept) > 0 {
+			relaxedQuery += " WHERE " + strings.Join(kept, " AND ")
+		}
+		if suffix != "" {
+			relaxedQuery += " " + suffix
+		}
+
+		relaxed = append(relaxed, RelaxedQuery{
+			RemovedCondition: strings.TrimSpace(query[removed[0]:removed[1]]),
+			CountQuery:       "SELECT COUNT(*) AS count FROM (" + relaxedQuery + ") AS databot_relaxed",
+		})
+	}
+	return relaxed
+}
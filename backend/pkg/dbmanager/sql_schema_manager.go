@@ -52,6 +52,7 @@ type ColumnInfo struct {
 	IsNullable   bool   `json:"is_nullable"`
 	DefaultValue string `json:"default_value,omitempty"`
 	Comment      string `json:"comment,omitempty"`
+	Collation    string `json:"collation,omitempty"` // Collation of a text column, where the database reports it
 }
 
 type IndexInfo struct {
@@ -124,6 +125,7 @@ type LLMColumnInfo struct {
 	Description string `json:"description,omitempty"`
 	IsNullable  bool   `json:"is_nullable"`
 	IsIndexed   bool   `json:"is_indexed,omitempty"`
+	Collation   string `json:"collation,omitempty"`
 }
 
 type SchemaRelationship struct {
@@ -133,6 +135,16 @@ type SchemaRelationship struct {
 	Through   string `json:"through,omitempty"` // For many-to-many relationships
 }
 
+// ForeignKeyRelationship is a column level relationship between two tables, used to render ER diagrams
+type ForeignKeyRelationship struct {
+	Name       string `json:"name"`
+	FromTable  string `json:"from_table"`
+	FromColumn string `json:"from_column"`
+	ToTable    string `json:"to_table"`
+	ToColumn   string `json:"to_column"`
+	Type       string `json:"type"` // "one_to_one" or "one_to_many"
+}
+
 // Update the interfaces
 type SchemaFetcher interface {
 	GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error)
@@ -148,6 +160,13 @@ type SchemaManager struct {
 	dbManager      *Manager
 	fetcherMap     map[string]func(DBExecutor) SchemaFetcher
 	simplifiers    map[string]SchemaSimplifier
+	columnMasks    map[string]ColumnMasks // chatID -> column masking formats applied to example records
+
+	exampleRecordsDisabled map[string]bool               // chatIDs whose schema is built without example records
+	schemaDescriptions     map[string]SchemaDescriptions // chatID -> table & column descriptions written by the user
+	tableAccessCounts      map[string]TableAccessCounts  // chatID -> number of generated queries that used each table
+	fullDetailTables       int                           // Most queried tables keeping their example records, 0 keeps them all
+	piiMasker              *PIIMasker                    // Masks the personal data of the fetched example records, nil keeps them as is
 }
 
 func NewSchemaManager(redisRepo redis.IRedisRepositories, encryptionKey string, dbManager *Manager) (*SchemaManager, error) {
@@ -162,6 +181,11 @@ func NewSchemaManager(redisRepo redis.IRedisRepositories, encryptionKey string,
 		dbManager:      dbManager,
 		fetcherMap:     make(map[string]func(DBExecutor) SchemaFetcher),
 		simplifiers:    make(map[string]SchemaSimplifier),
+		columnMasks:    make(map[string]ColumnMasks),
+
+		exampleRecordsDisabled: make(map[string]bool),
+		schemaDescriptions:     make(map[string]SchemaDescriptions),
+		tableAccessCounts:      make(map[string]TableAccessCounts),
 	}
 
 	// Register default fetchers
@@ -474,8 +498,8 @@ func (sm *SchemaManager) storeSchema(ctx context.Context, chatID string, schema
 		return err
 	}
 
-	// Create LLM-friendly schema with example records
-	llmSchema := sm.createLLMSchemaWithExamples(ctx, schema, dbType, db)
+	// Create LLM-friendly schema with example records, unless the chat disabled them
+	llmSchema := sm.createLLMSchemaWithExamples(ctx, schema, dbType, db, sm.ExampleRecordsEnabled(chatID))
 
 	// Check for context cancellation
 	if err := ctx.Err(); err != nil {
@@ -558,7 +582,8 @@ func (sm *SchemaManager) getTableChecksums(ctx context.Context, db DBExecutor, d
 			checksums[tableName] = checksum
 		}
 		return checksums, nil
-	case constants.DatabaseTypeMySQL:
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB, constants.DatabaseTypeSQLite, constants.DatabaseTypeBigQuery,
+		constants.DatabaseTypeElasticsearch, constants.DatabaseTypeCassandra:
 		// Implement MySQL checksum calculation
 		checksums := make(map[string]string)
 
@@ -954,6 +979,11 @@ func (m *SchemaManager) FormatSchemaForLLMWithExamples(storage *SchemaStorage) s
 				result.WriteString(" INDEXED")
 			}
 
+			// Text comparisons on the column follow its collation (ex: case insensitive)
+			if column.Collation != "" {
+				result.WriteString(fmt.Sprintf(" COLLATE %s", column.Collation))
+			}
+
 			if column.Description != "" {
 				result.WriteString(fmt.Sprintf(" -- %s", column.Description))
 			}
@@ -1363,6 +1393,7 @@ func (sm *SchemaManager) createLLMSchema(schema *SchemaInfo, dbType string) *LLM
 
 	// Get the appropriate simplifier for this database type
 	simplifier := sm.getSimplifier(dbType)
+	distributedTables := clickHouseDistributedTables(schema, dbType)
 
 	// Process tables
 	for tableName, table := range schema.Tables {
@@ -1384,6 +1415,7 @@ func (sm *SchemaManager) createLLMSchema(schema *SchemaInfo, dbType string) *LLM
 				Description: col.Comment,
 				IsNullable:  col.IsNullable,
 				IsIndexed:   sm.isColumnIndexed(col.Name, table.Indexes),
+				Collation:   col.Collation,
 			}
 			llmTable.Columns = append(llmTable.Columns, llmCol)
 		}
@@ -1398,55 +1430,7 @@ func (sm *SchemaManager) createLLMSchema(schema *SchemaInfo, dbType string) *LLM
 
 		// Add ClickHouse-specific information if applicable
 		if dbType == constants.DatabaseTypeClickhouse && table.Comment != "" {
-			// Parse the comment for ClickHouse-specific information
-			comment := table.Comment
-
-			// Extract engine information
-			if strings.Contains(strings.ToLower(comment), "engine=") {
-				engineStart := strings.Index(strings.ToLower(comment), "engine=") + 7
-				engineEnd := len(comment)
-				if spaceIdx := strings.Index(comment[engineStart:], " "); spaceIdx != -1 {
-					engineEnd = engineStart + spaceIdx
-				}
-				engineInfo := comment[engineStart:engineEnd]
-				if engineInfo != "" {
-					llmTable.Description += fmt.Sprintf(" [Engine: %s]", engineInfo)
-				}
-			}
-
-			// Extract partition key information
-			if strings.Contains(strings.ToLower(comment), "partition by") {
-				partitionStart := strings.Index(strings.ToLower(comment), "partition by") + 12
-				partitionEnd := len(comment)
-				for _, keyword := range []string{"order by", "primary key", "sample by", "settings"} {
-					if keywordIdx := strings.Index(strings.ToLower(comment[partitionStart:]), keyword); keywordIdx != -1 {
-						if partitionStart+keywordIdx < partitionEnd {
-							partitionEnd = partitionStart + keywordIdx
-						}
-					}
-				}
-				partitionKey := strings.TrimSpace(comment[partitionStart:partitionEnd])
-				if partitionKey != "" {
-					llmTable.Description += fmt.Sprintf(" [Partition Key: %s]", partitionKey)
-				}
-			}
-
-			// Extract order by key information
-			if strings.Contains(strings.ToLower(comment), "order by") {
-				orderStart := strings.Index(strings.ToLower(comment), "order by") + 8
-				orderEnd := len(comment)
-				for _, keyword := range []string{"partition by", "primary key", "sample by", "settings"} {
-					if keywordIdx := strings.Index(strings.ToLower(comment[orderStart:]), keyword); keywordIdx != -1 {
-						if orderStart+keywordIdx < orderEnd {
-							orderEnd = orderStart + keywordIdx
-						}
-					}
-				}
-				orderByKey := strings.TrimSpace(comment[orderStart:orderEnd])
-				if orderByKey != "" {
-					llmTable.Description += fmt.Sprintf(" [Order By: %s]", orderByKey)
-				}
-			}
+			llmTable.Description = describeClickHouseTable(tableName, table.Comment, distributedTables)
 		}
 
 		llmSchema.Tables[tableName] = llmTable
@@ -1458,7 +1442,7 @@ func (sm *SchemaManager) createLLMSchema(schema *SchemaInfo, dbType string) *LLM
 	return llmSchema
 }
 
-func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema *SchemaInfo, dbType string, db DBExecutor) *LLMSchemaInfo {
+func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema *SchemaInfo, dbType string, db DBExecutor, withExamples bool) *LLMSchemaInfo {
 	// Check for context cancellation
 	if err := ctx.Err(); err != nil {
 		log.Printf("createLLMSchemaWithExamples -> context cancelled at start: %v", err)
@@ -1477,14 +1461,24 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 
 	// Get the appropriate simplifier for this database type
 	simplifier := sm.getSimplifier(dbType)
+	distributedTables := clickHouseDistributedTables(schema, dbType)
+	sm.mu.RLock()
+	piiMasker := sm.piiMasker
+	sm.mu.RUnlock()
 
-	// Get fetcher for the database type
-	fetcher, err := sm.getFetcher(dbType, db)
-	if err != nil {
-		log.Printf("createLLMSchemaWithExamples -> Failed to get schema fetcher: %v", err)
-		// Continue without example records
+	// Get fetcher for the database type, not needed when example records are disabled
+	var fetcher SchemaFetcher
+	if withExamples {
+		var err error
+		fetcher, err = sm.getFetcher(dbType, db)
+		if err != nil {
+			log.Printf("createLLMSchemaWithExamples -> Failed to get schema fetcher: %v", err)
+			// Continue without example records
+		} else {
+			log.Printf("createLLMSchemaWithExamples -> Successfully got schema fetcher for dbType: %s", dbType)
+		}
 	} else {
-		log.Printf("createLLMSchemaWithExamples -> Successfully got schema fetcher for dbType: %s", dbType)
+		log.Printf("createLLMSchemaWithExamples -> Example records are disabled, skipping them")
 	}
 
 	// Check for context cancellation
@@ -1494,12 +1488,15 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 	}
 
 	// Process tables
+	start := time.Now()
+	processed := 0
 	for tableName, table := range schema.Tables {
 		// Check for context cancellation periodically
 		if err := ctx.Err(); err != nil {
 			log.Printf("createLLMSchemaWithExamples -> context cancelled during table processing: %v", err)
 			return llmSchema
 		}
+		tableStart := time.Now()
 
 		log.Printf("createLLMSchemaWithExamples -> Processing table: %s with %d columns", tableName, len(table.Columns))
 
@@ -1522,6 +1519,7 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 				Description: col.Comment,
 				IsNullable:  col.IsNullable,
 				IsIndexed:   sm.isColumnIndexed(col.Name, table.Indexes),
+				Collation:   col.Collation,
 			}
 			llmTable.Columns = append(llmTable.Columns, llmCol)
 			log.Printf("createLLMSchemaWithExamples -> Added column: %s of simplified type %s", col.Name, simplifiedType)
@@ -1537,6 +1535,11 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 			}
 		}
 
+		// Add ClickHouse-specific information if applicable
+		if dbType == constants.DatabaseTypeClickhouse && table.Comment != "" {
+			llmTable.Description = describeClickHouseTable(tableName, table.Comment, distributedTables)
+		}
+
 		// Fetch example records if fetcher is available
 		if fetcher != nil {
 			log.Printf("createLLMSchemaWithExamples -> Fetching example records for table: %s", tableName)
@@ -1545,6 +1548,8 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 				log.Printf("createLLMSchemaWithExamples -> Failed to fetch example records for table %s: %v", tableName, err)
 			} else {
 				log.Printf("createLLMSchemaWithExamples -> Successfully fetched %d example records for table %s", len(examples), tableName)
+				// Emails, phone numbers & the like are masked before the records are stored or shared with the LLM
+				examples = piiMasker.ApplyToRecords(examples)
 				llmTable.ExampleRecords = examples
 
 				// Debug the example records
@@ -1559,6 +1564,9 @@ func (sm *SchemaManager) createLLMSchemaWithExamples(ctx context.Context, schema
 		llmSchema.Tables[tableName] = llmTable
 		log.Printf("createLLMSchemaWithExamples -> Added table %s to LLM schema with %d columns and %d example records",
 			tableName, len(llmTable.Columns), len(llmTable.ExampleRecords))
+
+		processed++
+		reportSchemaProgress(ctx, tableName, processed, len(schema.Tables), tableStart, start)
 	}
 
 	// Extract relationships
@@ -1594,6 +1602,38 @@ func (sm *SchemaManager) extractRelationships(schema *SchemaInfo) []SchemaRelati
 	return relationships
 }
 
+// ExtractForeignKeyRelationships returns every foreign key as a column level relationship, unlike extractRelationships
+// it doesn't collapse multiple foreign keys between the same pair of tables
+func (sm *SchemaManager) ExtractForeignKeyRelationships(schema *SchemaInfo) []ForeignKeyRelationship {
+	relationships := make([]ForeignKeyRelationship, 0)
+	if schema == nil {
+		return relationships
+	}
+
+	for tableName, table := range schema.Tables {
+		for fkName, fk := range table.ForeignKeys {
+			relationships = append(relationships, ForeignKeyRelationship{
+				Name:       fkName,
+				FromTable:  tableName,
+				FromColumn: fk.ColumnName,
+				ToTable:    fk.RefTable,
+				ToColumn:   fk.RefColumn,
+				Type:       sm.determineRelationType(schema, tableName, fk),
+			})
+		}
+	}
+
+	// Sort for consistent output
+	sort.Slice(relationships, func(i, j int) bool {
+		if relationships[i].FromTable != relationships[j].FromTable {
+			return relationships[i].FromTable < relationships[j].FromTable
+		}
+		return relationships[i].Name < relationships[j].Name
+	})
+
+	return relationships
+}
+
 // Determine relationship type (one-to-one, one-to-many, etc.)
 func (sm *SchemaManager) determineRelationType(schema *SchemaInfo, fromTable string, fk ForeignKey) string {
 	// Check if the foreign key column is unique
@@ -1641,6 +1681,11 @@ func (sm *SchemaManager) registerDefaultFetchers() {
 		return NewMySQLSchemaFetcher(db)
 	})
 
+	// Register MariaDB schema fetcher (extends the MySQL fetcher with sequences)
+	sm.RegisterFetcher("mariadb", func(db DBExecutor) SchemaFetcher {
+		return NewMariaDBSchemaFetcher(db)
+	})
+
 	// Register ClickHouse schema fetcher
 	sm.RegisterFetcher("clickhouse", func(db DBExecutor) SchemaFetcher {
 		return NewClickHouseSchemaFetcher(db)
@@ -1650,6 +1695,26 @@ func (sm *SchemaManager) registerDefaultFetchers() {
 	sm.RegisterFetcher("mongodb", func(db DBExecutor) SchemaFetcher {
 		return NewMongoDBSchemaFetcher(db)
 	})
+
+	// Register SQLite schema fetcher
+	sm.RegisterFetcher("sqlite", func(db DBExecutor) SchemaFetcher {
+		return NewSQLiteSchemaFetcher(db)
+	})
+
+	// Register BigQuery schema fetcher
+	sm.RegisterFetcher("bigquery", func(db DBExecutor) SchemaFetcher {
+		return NewBigQuerySchemaFetcher(db)
+	})
+
+	// Register Elasticsearch schema fetcher
+	sm.RegisterFetcher("elasticsearch", func(db DBExecutor) SchemaFetcher {
+		return NewElasticsearchSchemaFetcher(db)
+	})
+
+	// Register Cassandra schema fetcher
+	sm.RegisterFetcher("cassandra", func(db DBExecutor) SchemaFetcher {
+		return NewCassandraSchemaFetcher(db)
+	})
 }
 
 // Update the CompareSchemasDetailed function to be more precise
@@ -2008,7 +2073,8 @@ func (sm *SchemaManager) GetSchemaWithExamples(ctx context.Context, chatID strin
 			}
 		}
 
-		if hasExamples {
+		// A schema without example records is complete when the chat disabled them
+		if hasExamples || !sm.ExampleRecordsEnabled(chatID) {
 			return storage, nil
 		}
 	}
@@ -2064,10 +2130,82 @@ func (sm *SchemaManager) FormatSchemaWithExamplesAndCollections(ctx context.Cont
 		return "", fmt.Errorf("failed to get schema with examples: %v", err)
 	}
 
+	// Mask example records before they reach the LLM, or drop them if the chat disabled them
+	storage = sm.maskExampleRecords(chatID, storage)
+	// Keep the example records of the tables the chat queries the most when the schema is large
+	storage = sm.prioritizeLLMSchema(chatID, storage)
+	// Layer the descriptions written by the user over the comments of the database
+	storage = sm.describeLLMSchema(chatID, storage)
+
 	// Format the schema for LLM
 	return sm.FormatSchemaForLLMWithExamples(storage), nil
 }
 
+// SetColumnMasks sets the column masking formats for a chat, an empty map clears them
+func (sm *SchemaManager) SetColumnMasks(chatID string, masks ColumnMasks) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(masks) == 0 {
+		delete(sm.columnMasks, chatID)
+		return
+	}
+	sm.columnMasks[chatID] = masks
+}
+
+// GetColumnMasks returns the column masking formats for a chat
+func (sm *SchemaManager) GetColumnMasks(chatID string) ColumnMasks {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.columnMasks[chatID]
+}
+
+// SetExampleRecordsEnabled sets if the schema of a chat is built with example records, they are enabled by default
+func (sm *SchemaManager) SetExampleRecordsEnabled(chatID string, enabled bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if enabled {
+		delete(sm.exampleRecordsDisabled, chatID)
+		return
+	}
+	sm.exampleRecordsDisabled[chatID] = true
+}
+
+// ExampleRecordsEnabled checks if the schema of a chat is built with example records
+func (sm *SchemaManager) ExampleRecordsEnabled(chatID string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return !sm.exampleRecordsDisabled[chatID]
+}
+
+// maskExampleRecords returns a copy of the storage with the chat's column masks applied to the example records,
+// masking happens at format time so that changing the masks doesn't require refetching the schema. The example
+// records are dropped when the chat disabled them, a schema stored before they were disabled still holds some.
+func (sm *SchemaManager) maskExampleRecords(chatID string, storage *SchemaStorage) *SchemaStorage {
+	masks := sm.GetColumnMasks(chatID)
+	enabled := sm.ExampleRecordsEnabled(chatID)
+	if (enabled && len(masks) == 0) || storage == nil || storage.LLMSchema == nil {
+		return storage
+	}
+
+	maskedTables := make(map[string]LLMTableInfo, len(storage.LLMSchema.Tables))
+	for tableName, table := range storage.LLMSchema.Tables {
+		if enabled {
+			table.ExampleRecords = masks.ApplyToRecords(tableName, table.ExampleRecords)
+		} else {
+			table.ExampleRecords = nil
+		}
+		maskedTables[tableName] = table
+	}
+
+	maskedStorage := *storage
+	maskedStorage.LLMSchema = &LLMSchemaInfo{
+		Tables:        maskedTables,
+		Relationships: storage.LLMSchema.Relationships,
+	}
+	log.Printf("SchemaManager -> maskExampleRecords -> Applied %d column masks for chatID: %s", len(masks), chatID)
+	return &maskedStorage
+}
+
 // Add a method to register simplifiers
 func (sm *SchemaManager) RegisterSimplifier(dbType string, simplifier SchemaSimplifier) {
 	sm.mu.Lock()
@@ -2099,9 +2237,24 @@ func (sm *SchemaManager) registerDefaultSimplifiers() {
 	// Register MySQL simplifier
 	sm.RegisterSimplifier("mysql", &MySQLSimplifier{})
 
+	// Register MariaDB simplifier (uses MySQL simplifier)
+	sm.RegisterSimplifier("mariadb", &MySQLSimplifier{})
+
 	// Register ClickHouse simplifier
 	sm.RegisterSimplifier("clickhouse", &ClickHouseSimplifier{})
 
 	// Register MongoDB simplifier
 	sm.RegisterSimplifier("mongodb", &MongoDBSimplifier{})
+
+	// Register SQLite simplifier (uses MySQL simplifier, the declared types have the same names: INTEGER, TEXT, BLOB..)
+	sm.RegisterSimplifier("sqlite", &MySQLSimplifier{})
+
+	// Register BigQuery simplifier
+	sm.RegisterSimplifier("bigquery", &BigQuerySimplifier{})
+
+	// Register Elasticsearch simplifier
+	sm.RegisterSimplifier("elasticsearch", &ElasticsearchSimplifier{})
+
+	// Register Cassandra simplifier
+	sm.RegisterSimplifier("cassandra", &CassandraSimplifier{})
 }
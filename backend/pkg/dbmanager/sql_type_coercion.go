@@ -0,0 +1,287 @@
+package dbmanager
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TypeCoercion is a predicate comparing a column with a literal of another type, the database converts one of them
+// implicitly: a string column compared with a number is converted for every row (its index can't be used & values
+// such as '0123' or '123abc' match in MySQL), a string that isn't a number compared with a numeric column is converted
+// to 0 by MySQL or fails on PostgreSQL
+type TypeCoercion struct {
+	Column     string `json:"column"` // As written in the query, ex: o.customer_ref
+	ColumnType string `json:"column_type"`
+	Literal    string `json:"literal"`
+	Suggestion string `json:"suggestion"`
+}
+
+func (c TypeCoercion) String() string {
+	return fmt.Sprintf("%s is a column of type %s compared with %s, %s", c.Column, c.ColumnType, c.Literal, c.Suggestion)
+}
+
+var (
+	// numericColumnTypeRegex matches the base name of numeric column types of every supported SQL database
+	numericColumnTypeRegex = regexp.MustCompile(`^(u?int\d*|tinyint|smallint|mediumint|bigint|integer|(small|big)?serial\d*|numeric|decimal\d*|dec|float\d*|double|real)$`)
+	// stringColumnTypeRegex matches the base name of text column types of every supported SQL database
+	stringColumnTypeRegex = regexp.MustCompile(`^(n?(var)?char|character|(tiny|medium|long)?text|citext|string|fixedstring|enum\d*|uuid)$`)
+	// columnTypeWrapperRegex matches the ClickHouse types wrapping the actual type of a column
+	columnTypeWrapperRegex = regexp.MustCompile(`^(nullable|lowcardinality)\((.*)\)$`)
+)
+
+// FindTypeCoercions finds the comparisons (=, <>, <, >, IN, ...) of a SELECT, UPDATE or DELETE between a column & a
+// literal that doesn't match the column type in the schema, each column & literal pair is reported once. Columns
+// that can't be resolved to a single table of the query are ignored.
+func FindTypeCoercions(query string, schema *SchemaInfo) []TypeCoercion {
+	if schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+	tokens := tokenizeSQL(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	refs, consumed, _ := parseSQLTableRefs(tokens)
+	start := 0
+	switch tokens[0].value {
+	case "select", "delete":
+	case "update":
+		ref, end, ok := parseSQLTableRef(tokens, 1, consumed)
+		if !ok {
+			return nil
+		}
+		if strings.EqualFold(ref.qualifier, "set") {
+			// UPDATE table SET, the table has no alias
+			delete(consumed, end-1)
+			ref.qualifier = tokens[1].text
+		}
+		refs = append(refs, ref)
+		// The assignments of the SET clause aren't comparisons
+		start = end
+		for start < len(tokens) && tokens[start].value != "where" {
+			start++
+		}
+	default:
+		return nil
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	tables := make(map[string]TableSchema, len(schema.Tables))
+	for name, table := range schema.Tables {
+		tables[strings.ToLower(name)] = table
+	}
+
+	var coercions []TypeCoercion
+	seen := make(map[string]bool)
+	report := func(column, literal int) {
+		columnType, written := resolveColumnType(tokens, column, refs, tables, consumed)
+		if columnType == "" {
+			return
+		}
+		coercion, ok := literalCoercion(columnType, tokens[literal], literal > 0 && tokens[literal-1].text == "-")
+		if !ok {
+			return
+		}
+		coercion.Column = written
+		key := strings.ToLower(written) + "\x00" + coercion.Literal
+		if !seen[key] {
+			seen[key] = true
+			coercions = append(coercions, coercion)
+		}
+	}
+
+	for i := start; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// column [NOT] IN (literal, ...)
+		if token.kind == sqlTokenWord && token.value == "in" && i+1 < len(tokens) && tokens[i+1].text == "(" {
+			column := i - 1
+			if column >= 0 && tokens[column].kind == sqlTokenWord && tokens[column].value == "not" {
+				column--
+			}
+			if column < 0 || !isIdentifierToken(tokens[column]) {
+				continue
+			}
+			for j := i + 2; j < len(tokens) && tokens[j].text != ")"; j++ {
+				if tokens[j].kind == sqlTokenWord && tokens[j].value == "select" {
+					break
+				}
+				if isSQLLiteralToken(tokens, j) {
+					report(column, j)
+				}
+			}
+			continue
+		}
+
+		// column <op> literal or literal <op> column
+		end, ok := parseComparisonOperator(tokens, i)
+		if !ok || i == 0 || end+1 >= len(tokens) {
+			continue
+		}
+		left, right := i-1, end+1
+		if tokens[right].text == "-" && right+1 < len(tokens) && tokens[right+1].kind == sqlTokenNumber {
+			right++
+		}
+		switch {
+		case isIdentifierToken(tokens[left]) && isSQLLiteralToken(tokens, right):
+			report(left, right)
+		case isSQLLiteralToken(tokens, left) && isIdentifierToken(tokens[right]):
+			column := right
+			if column+2 < len(tokens) && tokens[column+1].text == "." && isIdentifierToken(tokens[column+2]) {
+				column += 2
+			}
+			report(column, left)
+		}
+		i = end
+	}
+	return coercions
+}
+
+// parseComparisonOperator checks if a comparison operator starts at a token, the index of its last token is returned
+func parseComparisonOperator(tokens []sqlToken, i int) (int, bool) {
+	switch tokens[i].text {
+	case "=":
+		return i, true
+	case "<", ">", "!":
+		if i+1 < len(tokens) && (tokens[i+1].text == "=" || (tokens[i].text == "<" && tokens[i+1].text == ">")) {
+			return i + 1, true
+		}
+		return i, tokens[i].text != "!"
+	}
+	return i, false
+}
+
+// isSQLLiteralToken checks if a token is a string or number literal used as is, casts such as '5'::int are excluded
+func isSQLLiteralToken(tokens []sqlToken, i int) bool {
+	if tokens[i].kind != sqlTokenString && tokens[i].kind != sqlTokenNumber {
+		return false
+	}
+	return i+1 >= len(tokens) || tokens[i+1].text != ":"
+}
+
+// resolveColumnType returns the type of the column at a token, qualified or belonging to a single table of the query,
+// with the column as written. The type is empty if the token isn't a known column.
+func resolveColumnType(tokens []sqlToken, i int, refs []sqlTableRef, tables map[string]TableSchema, consumed map[int]bool) (string, string) {
+	token := tokens[i]
+	if consumed[i] || (token.kind == sqlTokenWord && sqlClauseKeywords[token.value]) {
+		return "", ""
+	}
+	if i+1 < len(tokens) && (tokens[i+1].text == "(" || tokens[i+1].text == ".") {
+		return "", ""
+	}
+
+	var candidates []sqlTableRef
+	written := token.text
+	if i >= 2 && tokens[i-1].text == "." {
+		qualifier := tokens[i-2].value
+		for _, ref := range refs {
+			if strings.ToLower(unquoteIdentifier(ref.qualifier)) == qualifier || ref.table == qualifier {
+				candidates = append(candidates, ref)
+				break
+			}
+		}
+		written = tokens[i-2].text + "." + token.text
+	} else if i > 0 && tokens[i-1].text == ":" {
+		// Cast target, ex: amount::text
+		return "", ""
+	} else {
+		candidates = refs
+	}
+
+	columnType := ""
+	for _, ref := range candidates {
+		table, ok := tables[ref.table]
+		if !ok {
+			continue
+		}
+		for name, column := range table.Columns {
+			if strings.EqualFold(name, token.value) {
+				if columnType != "" {
+					// The column exists in several tables of the query, it can't be resolved
+					return "", ""
+				}
+				columnType = column.Type
+			}
+		}
+	}
+	return columnType, written
+}
+
+// literalCoercion checks if comparing a column type with a literal converts one of them implicitly
+func literalCoercion(columnType string, literal sqlToken, negative bool) (TypeCoercion, bool) {
+	text := literal.text
+	if negative {
+		text = "-" + text
+	}
+
+	switch columnTypeCategory(columnType) {
+	case "string":
+		if literal.kind != sqlTokenNumber {
+			return TypeCoercion{}, false
+		}
+		return TypeCoercion{
+			ColumnType: columnType,
+			Literal:    "the number " + text,
+			Suggestion: fmt.Sprintf("compare it with the string '%s' so that the column isn't converted for every row & its index can be used", text),
+		}, true
+	case "numeric":
+		if literal.kind != sqlTokenString {
+			return TypeCoercion{}, false
+		}
+		value := strings.TrimSpace(unquoteSQLString(literal.text))
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			// A numeric string is converted once, the comparison stays correct
+			return TypeCoercion{}, false
+		}
+		return TypeCoercion{
+			ColumnType: columnType,
+			Literal:    "the string " + literal.text + " which isn't a number",
+			Suggestion: "it is converted to 0 by MySQL or fails on other databases, compare the column with a number or use the right column",
+		}, true
+	}
+	return TypeCoercion{}, false
+}
+
+// columnTypeCategory returns "string" or "numeric" for the column types involved in implicit coercions, empty otherwise
+func columnTypeCategory(columnType string) string {
+	base := strings.ToLower(strings.TrimSpace(columnType))
+	for {
+		match := columnTypeWrapperRegex.FindStringSubmatch(base)
+		if match == nil {
+			break
+		}
+		base = strings.TrimSpace(match[2])
+	}
+	if end := strings.IndexAny(base, "( "); end != -1 {
+		base = base[:end]
+	}
+
+	switch {
+	case numericColumnTypeRegex.MatchString(base):
+		return "numeric"
+	case stringColumnTypeRegex.MatchString(base):
+		return "string"
+	}
+	return ""
+}
+
+// unquoteSQLString returns the value of a single quoted string literal
+func unquoteSQLString(literal string) string {
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		literal = literal[1 : len(literal)-1]
+	}
+	return strings.ReplaceAll(literal, "''", "'")
+}
+
+// FormatTypeCoercions describes the implicit type coercions of a query for the LLM
+func FormatTypeCoercions(coercions []TypeCoercion) string {
+	descriptions := make([]string, len(coercions))
+	for i, coercion := range coercions {
+		descriptions[i] = coercion.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
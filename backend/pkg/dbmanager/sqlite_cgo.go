@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package dbmanager
+
+// The SQLite driver needs cgo, it is only part of the servers built with -tags sqlite once github.com/mattn/go-sqlite3
+// is added to go.mod, see sqliteDriverName
+import _ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,443 @@
+package dbmanager
+
+import (
+	"context"
+	"database/sql"
+	"databot-ai/internal/apis/dtos"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// sqliteDriverName is the database/sql driver of SQLite, registered by github.com/mattn/go-sqlite3 when the server is
+// built with the sqlite tag, see sqlite_cgo.go. The driver needs cgo, the default build has no SQLite support.
+const sqliteDriverName = "sqlite3"
+
+// SQLiteDriver implements the DatabaseDriver interface for SQLite, meant for local development: the database is a file
+// of the data directory rather than a server, a connection has a file path instead of a host & port
+type SQLiteDriver struct {
+	dataDir string
+}
+
+// NewSQLiteDriver creates a new SQLite driver opening the database files of dataDir, SQLite connections are refused
+// when dataDir is empty
+func NewSQLiteDriver(dataDir string) DatabaseDriver {
+	return &SQLiteDriver{dataDir: dataDir}
+}
+
+// resolveSQLitePath returns the absolute path of the database file of a connection, the file must exist inside the
+// data directory: a relative path is read from the data directory & a path leaving it (ex: ../../etc/passwd or a
+// symbolic link pointing elsewhere) is refused, chats must not open any file of the server
+func resolveSQLitePath(dataDir, filePath string) (string, error) {
+	if dataDir == "" {
+		return "", fmt.Errorf("SQLite connections are disabled, set SQLITE_DATA_DIR to the directory of the database files")
+	}
+	if strings.TrimSpace(filePath) == "" {
+		return "", fmt.Errorf("the file path of the SQLite database is required")
+	}
+
+	root, err := filepath.Abs(dataDir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid SQLite data directory %s: %v", dataDir, err)
+	}
+	path := filePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("SQLite database file %s not found", filePath)
+		}
+		return "", fmt.Errorf("invalid SQLite database file %s: %v", filePath, err)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("SQLite database file %s is outside of the data directory", filePath)
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return "", fmt.Errorf("SQLite database file %s is not a file", filePath)
+	}
+	return path, nil
+}
+
+// sqliteDriverRegistered tells whether the server was built with a SQLite database/sql driver
+func sqliteDriverRegistered() bool {
+	for _, name := range sql.Drivers() {
+		if name == sqliteDriverName {
+			return true
+		}
+	}
+	return false
+}
+
+// openSQLite opens the database file of a connection, the file is never created: mode=rw fails on a missing file.
+// Foreign keys are enforced & a locked database is retried for 5 seconds, ex: while another chat writes to it.
+func openSQLite(dataDir string, config ConnectionConfig) (*sql.DB, error) {
+	if !sqliteDriverRegistered() {
+		return nil, fmt.Errorf("this server is built without SQLite support, build it with -tags sqlite (requires cgo)")
+	}
+	path, err := resolveSQLitePath(dataDir, config.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return sql.Open(sqliteDriverName, fmt.Sprintf("file:%s?mode=rw&_foreign_keys=on&_busy_timeout=5000", path))
+}
+
+// Connect opens a SQLite database file
+func (d *SQLiteDriver) Connect(config ConnectionConfig) (*Connection, error) {
+	db, err := openSQLite(d.dataDir, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Configure connection pool
+	applySQLPoolSettings(db, config)
+
+	// Create GORM DB on the configured pool
+	gormDB, err := gorm.Open(sqliteDialector{conn: db}, &gorm.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create GORM connection: %v", err)
+	}
+
+	// Create connection object
+	conn := &Connection{
+		DB:          gormDB,
+		LastUsed:    time.Now(),
+		Status:      StatusConnected,
+		Config:      config,
+		Subscribers: make(map[string]bool),
+		SubLock:     sync.RWMutex{},
+	}
+
+	return conn, nil
+}
+
+// Disconnect closes a SQLite database connection
+func (d *SQLiteDriver) Disconnect(conn *Connection) error {
+	// Get the underlying SQL DB
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %v", err)
+	}
+
+	// Close the connection
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("failed to close connection: %v", err)
+	}
+	return nil
+}
+
+// Ping checks if the SQLite connection is alive
+func (d *SQLiteDriver) Ping(conn *Connection) error {
+	if conn == nil || conn.DB == nil {
+		return fmt.Errorf("no active connection to ping")
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	return sqlDB.Ping()
+}
+
+// IsAlive checks if the SQLite connection is still valid
+func (d *SQLiteDriver) IsAlive(conn *Connection) bool {
+	if conn == nil || conn.DB == nil {
+		return false
+	}
+
+	sqlDB, err := conn.DB.DB()
+	if err != nil {
+		return false
+	}
+
+	return sqlDB.Ping() == nil
+}
+
+// ExecuteQuery executes a SQL query on the SQLite database
+func (d *SQLiteDriver) ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult {
+	if conn == nil || conn.DB == nil {
+		return &QueryExecutionResult{
+			Error: &dtos.QueryError{
+				Message: "No active connection",
+				Code:    "CONNECTION_ERROR",
+			},
+		}
+	}
+	return executeSQLiteQuery(ctx, conn.DB, query)
+}
+
+// isSQLiteReadStatement tells whether a statement returns rows, PRAGMA returns the value of the setting it reads
+func isSQLiteReadStatement(stmt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(stmt))
+	for _, prefix := range []string{"SELECT", "WITH", "PRAGMA", "EXPLAIN", "VALUES"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqliteFileStatement returns the ATTACH or VACUUM INTO statement of a query if it has one, empty otherwise. Both open
+// a file of their own, which resolveSQLitePath doesn't keep inside the data directory.
+func sqliteFileStatement(query string) string {
+	statementStart := true
+	vacuum := false
+	for _, token := range tokenizeSQL(query) {
+		if token.kind == sqlTokenPunct && token.text == ";" {
+			statementStart, vacuum = true, false
+			continue
+		}
+		if token.kind == sqlTokenWord {
+			switch {
+			case statementStart && token.value == "attach":
+				return "ATTACH"
+			case statementStart && token.value == "vacuum":
+				vacuum = true
+			case vacuum && token.value == "into":
+				return "VACUUM INTO"
+			}
+		}
+		statementStart = false
+	}
+	return ""
+}
+
+// executeSQLiteQuery executes the statements of a query on a SQLite connection or transaction
+func executeSQLiteQuery(ctx context.Context, gormDB *gorm.DB, query string) *QueryExecutionResult {
+	startTime := time.Now()
+	result := &QueryExecutionResult{}
+
+	if statement := sqliteFileStatement(query); statement != "" {
+		result.Error = &dtos.QueryError{
+			Message: fmt.Sprintf("%s is not allowed on SQLite connections", statement),
+			Code:    "STATEMENT_NOT_ALLOWED",
+			Details: "The query can only use the database file of the connection, it can't open other files",
+		}
+		return result
+	}
+
+	// Split the query into individual statements, SQLite quotes identifiers like MySQL (", ` or [])
+	statements := splitMySQLStatements(query)
+	// Result sets of all the statements of a batch
+	var resultSets []ResultSet
+
+	// Execute each statement
+	for _, stmt := range statements {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+
+		// Check for context cancellation
+		if ctx.Err() != nil {
+			result.Error = &dtos.QueryError{
+				Message: "Query execution cancelled",
+				Code:    "EXECUTION_CANCELLED",
+			}
+			return result
+		}
+
+		if isSQLiteReadStatement(stmt) {
+			// Return the results, at most the row limit of the context is read
+			db := gormDB.WithContext(ctx)
+			sqlRows, err := db.Raw(stmt).Rows()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+			sets, truncated, err := scanResultSets(ctx, db, sqlRows)
+			sqlRows.Close()
+			if err != nil {
+				result.Error = &dtos.QueryError{
+					Message: err.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+			if truncated {
+				result.Warnings = append(result.Warnings, resultTruncatedWarning(resultRowLimit(ctx)))
+			}
+
+			// TEXT values can be read as []byte, they are converted like the MySQL ones
+			processedRows := make([]map[string]interface{}, 0)
+			for i := range sets {
+				sets[i].Rows = processMySQLRows(sets[i].Rows)
+				processedRows = sets[i].Rows
+				result.Columns = sets[i].Types
+			}
+			resultSets = append(resultSets, sets...)
+
+			result.Result = map[string]interface{}{
+				"results": processedRows,
+			}
+		} else {
+			// For other queries (INSERT, UPDATE, DELETE, etc.), execute and return affected rows
+			execResult := gormDB.WithContext(ctx).Exec(stmt)
+			if execResult.Error != nil {
+				result.Error = &dtos.QueryError{
+					Message: execResult.Error.Error(),
+					Code:    "EXECUTION_ERROR",
+				}
+				return result
+			}
+
+			rowsAffected := execResult.RowsAffected
+			if rowsAffected > 0 {
+				result.Result = map[string]interface{}{
+					"rowsAffected": rowsAffected,
+					"message":      fmt.Sprintf("%d row(s) affected", rowsAffected),
+				}
+			} else {
+				result.Result = map[string]interface{}{
+					"message": "Query performed successfully",
+				}
+			}
+		}
+	}
+
+	addResultSets(result, resultSets)
+
+	// Calculate execution time
+	result.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	// Marshal the result to JSON
+	resultJSON, err := json.Marshal(result.Result)
+	if err != nil {
+		return &QueryExecutionResult{
+			ExecutionTime: int(time.Since(startTime).Milliseconds()),
+			Error: &dtos.QueryError{
+				Code:    "JSON_MARSHAL_FAILED",
+				Message: err.Error(),
+				Details: "Failed to marshal query results",
+			},
+		}
+	}
+	result.ResultJSON = string(resultJSON)
+
+	return result
+}
+
+// BeginTx starts a new transaction
+func (d *SQLiteDriver) BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction {
+	if conn == nil || conn.DB == nil {
+		log.Printf("SQLiteDriver.BeginTx: Connection or DB is nil")
+		return nil
+	}
+
+	// Start a new transaction, on the session connection if one is held so that temp tables persist
+	db := conn.DB.WithContext(ctx)
+	if session != nil {
+		db.Statement.ConnPool = session.Conn
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		log.Printf("Failed to begin transaction: %v", tx.Error)
+		return nil
+	}
+
+	return &SQLiteTransaction{
+		tx:   tx,
+		conn: conn,
+	}
+}
+
+// sqliteDialector is the GORM dialector of the SQLite connections. Queries only go through Raw & Exec, so it has no
+// migrations or type mapping: gorm.io/driver/sqlite would link the cgo driver into every build of the server.
+type sqliteDialector struct {
+	conn gorm.ConnPool
+}
+
+func (sqliteDialector) Name() string {
+	return "sqlite"
+}
+
+func (d sqliteDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	db.ConnPool = d.conn
+	return nil
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}
+}
+
+func (sqliteDialector) DataTypeOf(field *schema.Field) string {
+	return string(field.DataType)
+}
+
+func (sqliteDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "NULL"}
+}
+
+func (sqliteDialector) BindVarTo(writer clause.Writer, _ *gorm.Statement, _ interface{}) {
+	writer.WriteByte('?')
+}
+
+func (sqliteDialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteString(quoteSQLiteIdentifier(str))
+}
+
+func (sqliteDialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, nil, `"`, vars...)
+}
+
+// quoteSQLiteIdentifier quotes a table or column name for SQLite
+func quoteSQLiteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// GetSchema retrieves the database schema
+func (d *SQLiteDriver) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteDriver -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewSQLiteSchemaFetcher(db).GetSchema(ctx, db, selectedTables)
+}
+
+// GetTableChecksum calculates a checksum for a table
+func (d *SQLiteDriver) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteDriver -> GetTableChecksum -> Context cancelled: %v", err)
+		return "", err
+	}
+	return NewSQLiteSchemaFetcher(db).GetTableChecksum(ctx, db, table)
+}
+
+// FetchExampleRecords fetches example records from a table
+func (d *SQLiteDriver) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteDriver -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+	return NewSQLiteSchemaFetcher(db).FetchExampleRecords(ctx, db, table, limit)
+}
@@ -0,0 +1,30 @@
+package dbmanager
+
+import "testing"
+
+func TestSQLiteFileStatement(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"select", "SELECT * FROM orders", ""},
+		{"vacuum", "VACUUM", ""},
+		{"attach in a string", "SELECT 'ATTACH DATABASE x AS y'", ""},
+		{"into column", "INSERT INTO orders (id) VALUES (1)", ""},
+		{"attach", "ATTACH DATABASE '/etc/passwd' AS secrets", "ATTACH"},
+		{"attach lower case", "attach '/tmp/other.db' as other", "ATTACH"},
+		{"attach after a comment", "-- read another file\nATTACH '/tmp/other.db' AS other", "ATTACH"},
+		{"second statement", "SELECT 1; ATTACH '/tmp/other.db' AS other", "ATTACH"},
+		{"vacuum into", "VACUUM INTO '/tmp/copy.db'", "VACUUM INTO"},
+		{"vacuum schema into", "VACUUM main INTO '/tmp/copy.db'", "VACUUM INTO"},
+		{"vacuum then insert", "VACUUM; INSERT INTO orders (id) VALUES (1)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqliteFileStatement(tt.query); got != tt.want {
+				t.Errorf("sqliteFileStatement(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
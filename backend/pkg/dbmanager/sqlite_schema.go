@@ -0,0 +1,369 @@
+package dbmanager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SQLiteSchemaFetcher implements schema fetching for SQLite, from sqlite_master & the table_info, index_list &
+// foreign_key_list pragmas (read through their table-valued functions so that the table name is a bound parameter)
+type SQLiteSchemaFetcher struct {
+	db DBExecutor
+}
+
+// NewSQLiteSchemaFetcher creates a new SQLite schema fetcher
+func NewSQLiteSchemaFetcher(db DBExecutor) SchemaFetcher {
+	return &SQLiteSchemaFetcher{db: db}
+}
+
+// GetSchema retrieves the schema for the selected tables
+func (f *SQLiteSchemaFetcher) GetSchema(ctx context.Context, db DBExecutor, selectedTables []string) (*SchemaInfo, error) {
+	log.Printf("SQLiteSchemaFetcher -> GetSchema -> Starting schema fetch with selected tables: %v", selectedTables)
+
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> GetSchema -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	schema, err := f.FetchSchema(ctx)
+	if err != nil {
+		log.Printf("SQLiteSchemaFetcher -> GetSchema -> Error fetching schema: %v", err)
+		return nil, err
+	}
+	log.Printf("SQLiteSchemaFetcher -> GetSchema -> Successfully fetched schema with %d tables", len(schema.Tables))
+
+	filteredSchema := f.filterSchemaForSelectedTables(schema, selectedTables)
+	log.Printf("SQLiteSchemaFetcher -> GetSchema -> Filtered schema to %d tables", len(filteredSchema.Tables))
+	return filteredSchema, nil
+}
+
+// FetchSchema retrieves the full database schema
+func (f *SQLiteSchemaFetcher) FetchSchema(ctx context.Context) (*SchemaInfo, error) {
+	schema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: time.Now(),
+	}
+
+	tables, err := f.fetchTables(ctx)
+	if err != nil {
+		log.Printf("SQLiteSchemaFetcher -> FetchSchema -> Error fetching tables: %v", err)
+		return nil, err
+	}
+	log.Printf("SQLiteSchemaFetcher -> FetchSchema -> Processing %d tables", len(tables))
+
+	for _, table := range tables {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		tableSchema := TableSchema{
+			Name:        table,
+			Columns:     make(map[string]ColumnInfo),
+			Indexes:     make(map[string]IndexInfo),
+			ForeignKeys: make(map[string]ForeignKey),
+			Constraints: make(map[string]ConstraintInfo),
+		}
+
+		// Columns & the primary key
+		columns, primaryKey, err := f.fetchColumns(ctx, table)
+		if err != nil {
+			log.Printf("SQLiteSchemaFetcher -> FetchSchema -> Error fetching columns for table %s: %v", table, err)
+			return nil, fmt.Errorf("failed to fetch columns for table %s: %v", table, err)
+		}
+		tableSchema.Columns = columns
+		if len(primaryKey) > 0 {
+			tableSchema.Constraints["PRIMARY"] = ConstraintInfo{
+				Name:    "PRIMARY",
+				Type:    "PRIMARY KEY",
+				Columns: primaryKey,
+			}
+		}
+
+		// Indexes & the unique constraints, SQLite backs each unique constraint with an index
+		indexes, uniques := f.fetchIndexes(ctx, table)
+		tableSchema.Indexes = indexes
+		for name, constraint := range uniques {
+			tableSchema.Constraints[name] = constraint
+		}
+
+		tableSchema.ForeignKeys = f.fetchForeignKeys(ctx, table)
+		tableSchema.RowCount = f.getTableRowCount(ctx, table)
+		log.Printf("SQLiteSchemaFetcher -> FetchSchema -> Table %s: %d columns, %d indexes, %d foreign keys, %d rows",
+			table, len(columns), len(indexes), len(tableSchema.ForeignKeys), tableSchema.RowCount)
+
+		// Calculate table schema checksum
+		tableData, _ := json.Marshal(tableSchema)
+		tableSchema.Checksum = fmt.Sprintf("%x", md5.Sum(tableData))
+
+		schema.Tables[table] = tableSchema
+	}
+
+	schema.Views = f.fetchViews(ctx)
+
+	// Calculate overall schema checksum
+	schemaData, _ := json.Marshal(schema.Tables)
+	schema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+
+	log.Printf("SQLiteSchemaFetcher -> FetchSchema -> Successfully completed schema fetch with %d tables and %d views",
+		len(schema.Tables), len(schema.Views))
+	return schema, nil
+}
+
+// fetchTables retrieves the tables of the database, the internal sqlite_ tables (ex: sqlite_sequence) are left out
+func (f *SQLiteSchemaFetcher) fetchTables(_ context.Context) ([]string, error) {
+	var tables []string
+	query := `
+        SELECT name
+        FROM sqlite_master
+        WHERE type = 'table'
+        AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+        ORDER BY name;
+    `
+	if err := f.db.Query(query, &tables); err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	log.Printf("SQLiteSchemaFetcher -> fetchTables -> Found %d tables: %v", len(tables), tables)
+	return tables, nil
+}
+
+// fetchColumns retrieves the columns of a table & the columns of its primary key, in key order
+func (f *SQLiteSchemaFetcher) fetchColumns(_ context.Context, table string) (map[string]ColumnInfo, []string, error) {
+	var columnList []struct {
+		Name         string  `gorm:"column:name"`
+		Type         string  `gorm:"column:type"`
+		NotNull      int     `gorm:"column:notnull"`
+		DefaultValue *string `gorm:"column:dflt_value"`
+		PrimaryKey   int     `gorm:"column:pk"` // Position of the column in the primary key, 0 if it isn't part of it
+	}
+	query := `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid;`
+	if err := f.db.Query(query, &columnList, table); err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]ColumnInfo, len(columnList))
+	keyPositions := make(map[string]int)
+	var primaryKey []string
+	for _, col := range columnList {
+		defaultValue := ""
+		if col.DefaultValue != nil {
+			defaultValue = *col.DefaultValue
+		}
+		columns[col.Name] = ColumnInfo{
+			Name:         col.Name,
+			Type:         col.Type, // Declared type, empty for a column declared without one
+			IsNullable:   col.NotNull == 0,
+			DefaultValue: defaultValue,
+		}
+		if col.PrimaryKey > 0 {
+			keyPositions[col.Name] = col.PrimaryKey
+			primaryKey = append(primaryKey, col.Name)
+		}
+	}
+	sort.SliceStable(primaryKey, func(i, j int) bool { return keyPositions[primaryKey[i]] < keyPositions[primaryKey[j]] })
+	return columns, primaryKey, nil
+}
+
+// fetchIndexes retrieves the indexes of a table & its unique constraints, the automatic index of the primary key is
+// left out. Indexes on expressions list the columns they read only.
+func (f *SQLiteSchemaFetcher) fetchIndexes(_ context.Context, table string) (map[string]IndexInfo, map[string]ConstraintInfo) {
+	indexes := make(map[string]IndexInfo)
+	uniques := make(map[string]ConstraintInfo)
+	var indexList []struct {
+		Name   string `gorm:"column:name"`
+		Unique int    `gorm:"column:unique"`
+		Origin string `gorm:"column:origin"` // c: CREATE INDEX, u: UNIQUE constraint, pk: PRIMARY KEY
+	}
+	query := `SELECT name, "unique", origin FROM pragma_index_list(?);`
+	if err := f.db.Query(query, &indexList, table); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> fetchIndexes -> Error for table %s, returning no indexes: %v", table, err)
+		return indexes, uniques
+	}
+
+	for _, index := range indexList {
+		if index.Origin == "pk" {
+			continue
+		}
+		var columns []string
+		columnsQuery := `SELECT name FROM pragma_index_info(?) WHERE name IS NOT NULL ORDER BY seqno;`
+		if err := f.db.Query(columnsQuery, &columns, index.Name); err != nil {
+			log.Printf("SQLiteSchemaFetcher -> fetchIndexes -> Error fetching the columns of index %s: %v", index.Name, err)
+			continue
+		}
+		indexes[index.Name] = IndexInfo{
+			Name:     index.Name,
+			Columns:  columns,
+			IsUnique: index.Unique == 1,
+		}
+		if index.Origin == "u" {
+			uniques[index.Name] = ConstraintInfo{
+				Name:    index.Name,
+				Type:    "UNIQUE",
+				Columns: columns,
+			}
+		}
+	}
+	return indexes, uniques
+}
+
+// fetchForeignKeys retrieves the foreign keys of a table. SQLite foreign keys have no name, each column of a key is
+// named <table>_<column>_fkey like the PostgreSQL ones. A key without referenced columns references the primary key.
+func (f *SQLiteSchemaFetcher) fetchForeignKeys(_ context.Context, table string) map[string]ForeignKey {
+	fkeys := make(map[string]ForeignKey)
+	var fkList []struct {
+		Seq       int     `gorm:"column:seq"`
+		RefTable  string  `gorm:"column:table"`
+		Column    string  `gorm:"column:from"`
+		RefColumn *string `gorm:"column:to"`
+		OnUpdate  string  `gorm:"column:on_update"`
+		OnDelete  string  `gorm:"column:on_delete"`
+	}
+	query := `SELECT seq, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq;`
+	if err := f.db.Query(query, &fkList, table); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> fetchForeignKeys -> Error for table %s, returning no foreign keys: %v", table, err)
+		return fkeys
+	}
+
+	for _, fk := range fkList {
+		refColumn := ""
+		if fk.RefColumn != nil {
+			refColumn = *fk.RefColumn
+		} else {
+			var refColumns []string
+			refQuery := `SELECT name FROM pragma_table_info(?) WHERE pk = ?;`
+			if err := f.db.Query(refQuery, &refColumns, fk.RefTable, fk.Seq+1); err == nil && len(refColumns) > 0 {
+				refColumn = refColumns[0]
+			}
+		}
+		name := fmt.Sprintf("%s_%s_fkey", table, fk.Column)
+		fkeys[name] = ForeignKey{
+			Name:       name,
+			ColumnName: fk.Column,
+			RefTable:   fk.RefTable,
+			RefColumn:  refColumn,
+			OnDelete:   fk.OnDelete,
+			OnUpdate:   fk.OnUpdate,
+		}
+	}
+	return fkeys
+}
+
+// fetchViews retrieves the views of the database with their CREATE VIEW statement
+func (f *SQLiteSchemaFetcher) fetchViews(_ context.Context) map[string]ViewSchema {
+	views := make(map[string]ViewSchema)
+	var viewList []struct {
+		Name       string `gorm:"column:name"`
+		Definition string `gorm:"column:sql"`
+	}
+	query := `SELECT name, sql FROM sqlite_master WHERE type = 'view' ORDER BY name;`
+	if err := f.db.Query(query, &viewList); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> fetchViews -> Error, returning no views: %v", err)
+		return views
+	}
+	for _, view := range viewList {
+		views[view.Name] = ViewSchema{
+			Name:       view.Name,
+			Definition: view.Definition,
+		}
+	}
+	return views
+}
+
+// getTableRowCount counts the rows of a table, SQLite keeps no row estimate so 0 is returned when the count fails
+func (f *SQLiteSchemaFetcher) getTableRowCount(_ context.Context, table string) int64 {
+	var count int64
+	if err := f.db.Query(fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteSQLiteIdentifier(table)), &count); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> getTableRowCount -> Error for table %s, returning 0 rows: %v", table, err)
+		return 0
+	}
+	return count
+}
+
+// GetTableChecksum calculates a checksum for a table's structure, SQLite keeps the statements creating the table & its
+// indexes & triggers in sqlite_master
+func (f *SQLiteSchemaFetcher) GetTableChecksum(ctx context.Context, db DBExecutor, table string) (string, error) {
+	var definitions []string
+	query := `SELECT sql FROM sqlite_master WHERE tbl_name = ? AND sql IS NOT NULL ORDER BY type, name;`
+	if err := db.Query(query, &definitions, table); err != nil {
+		return "", fmt.Errorf("failed to get table definition: %v", err)
+	}
+	if len(definitions) == 0 {
+		return "", fmt.Errorf("table %s not found", table)
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(definitions, "\n")))), nil
+}
+
+// FetchExampleRecords retrieves sample records from a table
+func (f *SQLiteSchemaFetcher) FetchExampleRecords(ctx context.Context, db DBExecutor, table string, limit int) ([]map[string]interface{}, error) {
+	// Check for context cancellation
+	if err := ctx.Err(); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> FetchExampleRecords -> Context cancelled: %v", err)
+		return nil, err
+	}
+
+	// Ensure limit is reasonable
+	if limit <= 0 {
+		limit = 3 // Default to 3 records
+	} else if limit > 10 {
+		limit = 10 // Cap at 10 records to avoid large data transfers
+	}
+
+	var records []map[string]interface{}
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", quoteSQLiteIdentifier(table), limit)
+	if err := db.QueryRows(query, &records); err != nil {
+		log.Printf("SQLiteSchemaFetcher -> FetchExampleRecords -> Error fetching records from table %s: %v", table, err)
+		return nil, fmt.Errorf("failed to fetch example records for table %s: %v", table, err)
+	}
+	log.Printf("SQLiteSchemaFetcher -> FetchExampleRecords -> Fetched %d records from table %s", len(records), table)
+
+	// BLOB values are read as []byte, they are shared as strings
+	processedRecords := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		processedRecords[i] = make(map[string]interface{}, len(record))
+		for key, value := range record {
+			if byteVal, ok := value.([]byte); ok {
+				processedRecords[i][key] = string(byteVal)
+			} else {
+				processedRecords[i][key] = value
+			}
+		}
+	}
+	return processedRecords, nil
+}
+
+// filterSchemaForSelectedTables filters the schema to only include the selected tables
+func (f *SQLiteSchemaFetcher) filterSchemaForSelectedTables(schema *SchemaInfo, selectedTables []string) *SchemaInfo {
+	// If no tables are selected or "ALL" is selected, return the full schema
+	if len(selectedTables) == 0 || (len(selectedTables) == 1 && selectedTables[0] == "ALL") {
+		return schema
+	}
+
+	selectedTablesMap := make(map[string]bool, len(selectedTables))
+	for _, table := range selectedTables {
+		selectedTablesMap[table] = true
+	}
+
+	filteredSchema := &SchemaInfo{
+		Tables:    make(map[string]TableSchema),
+		Views:     make(map[string]ViewSchema),
+		UpdatedAt: schema.UpdatedAt,
+	}
+	for tableName, tableSchema := range schema.Tables {
+		if selectedTablesMap[tableName] {
+			filteredSchema.Tables[tableName] = tableSchema
+		}
+	}
+
+	// Calculate new checksum for filtered schema
+	schemaData, _ := json.Marshal(filteredSchema.Tables)
+	filteredSchema.Checksum = fmt.Sprintf("%x", md5.Sum(schemaData))
+	return filteredSchema
+}
@@ -0,0 +1,170 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshTunnelDefaultPorts are the ports the databases reached through an SSH bastion listen on when the connection has
+// none, the database types missing can't be tunneled
+var sshTunnelDefaultPorts = map[string]string{
+	constants.DatabaseTypePostgreSQL: "5432",
+	constants.DatabaseTypeYugabyteDB: "5433",
+	constants.DatabaseTypeMySQL:      "3306",
+	constants.DatabaseTypeMariaDB:    "3306",
+	constants.DatabaseTypeClickhouse: "9000",
+	constants.DatabaseTypeMongoDB:    "27017",
+}
+
+const sshTunnelDialTimeout = 15 * time.Second
+
+// SSHTunnelSupported reports if the connections of a database type can go through an SSH bastion
+func SSHTunnelSupported(dbType string) bool {
+	_, ok := sshTunnelDefaultPorts[dbType]
+	return ok
+}
+
+// sshTunnel forwards the connections accepted on a local port to the database, dialed from an SSH bastion. It lives as
+// long as the pool of the connection, see DatabasePool.Tunnel.
+type sshTunnel struct {
+	client    *ssh.Client
+	listener  net.Listener
+	target    string // host:port of the database, as the bastion resolves it
+	closeOnce sync.Once
+}
+
+// openSSHTunnel opens the SSH tunnel of a connection, the returned config connects the driver to the local end of the
+// tunnel. A config without an SSH host is returned as is with a nil tunnel. The bastion is verified against the host key
+// of the config, a config without one is refused.
+func openSSHTunnel(config ConnectionConfig) (ConnectionConfig, *sshTunnel, error) {
+	if config.SSHHost == "" {
+		return config, nil, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(config.SSHPrivateKey))
+	if err != nil {
+		return config, nil, fmt.Errorf("invalid SSH private key: %v", err)
+	}
+	if config.SSHHostKey == "" {
+		return config, nil, fmt.Errorf("the SSH host key of the bastion is required to connect through it")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.SSHHostKey))
+	if err != nil {
+		return config, nil, fmt.Errorf("invalid SSH host key: %v", err)
+	}
+
+	sshPort := config.SSHPort
+	if sshPort == "" {
+		sshPort = "22"
+	}
+	bastion := net.JoinHostPort(config.SSHHost, sshPort)
+	client, err := ssh.Dial("tcp", bastion, &ssh.ClientConfig{
+		User:            config.SSHUser,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+		Timeout:         sshTunnelDialTimeout,
+	})
+	if err != nil {
+		return config, nil, fmt.Errorf("failed to connect to the SSH bastion %s: %v", bastion, err)
+	}
+
+	port := sshTunnelDefaultPorts[config.Type]
+	if config.Port != nil && *config.Port != "" {
+		port = *config.Port
+	}
+	target := net.JoinHostPort(config.Host, port)
+
+	// The error of the bastion is clearer than the one the driver reports for a closed tunnel
+	probe, err := client.Dial("tcp", target)
+	if err != nil {
+		client.Close()
+		return config, nil, fmt.Errorf("the SSH bastion %s can't reach %s: %v", bastion, target, err)
+	}
+	probe.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		client.Close()
+		return config, nil, fmt.Errorf("failed to open the local end of the SSH tunnel: %v", err)
+	}
+
+	tunnel := &sshTunnel{
+		client:   client,
+		listener: listener,
+		target:   target,
+	}
+	go tunnel.serve()
+	log.Printf("DBManager -> openSSHTunnel -> Tunneling %s through %s on %s", target, bastion, listener.Addr())
+
+	localHost, localPort, _ := net.SplitHostPort(listener.Addr().String())
+	tunneled := config
+	tunneled.Host = localHost
+	tunneled.Port = &localPort
+	tunneled.SSHHost = ""
+	tunneled.sshTunneled = true
+	return tunneled, tunnel, nil
+}
+
+func (t *sshTunnel) serve() {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			// The tunnel is closed
+			return
+		}
+		go t.forward(local)
+	}
+}
+
+func (t *sshTunnel) forward(local net.Conn) {
+	remote, err := t.client.Dial("tcp", t.target)
+	if err != nil {
+		log.Printf("DBManager -> sshTunnel -> Failed to dial %s through the bastion: %v", t.target, err)
+		local.Close()
+		return
+	}
+
+	// Either side closing ends the forwarded connection
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+	local.Close()
+	remote.Close()
+}
+
+// Close stops the local end of the tunnel & the SSH connection, the connections forwarded are closed with it. A nil
+// tunnel is ignored.
+func (t *sshTunnel) Close() {
+	if t == nil {
+		return
+	}
+	t.closeOnce.Do(func() {
+		t.listener.Close()
+		t.client.Close()
+		log.Printf("DBManager -> sshTunnel -> Closed the tunnel to %s", t.target)
+	})
+}
+
+// testConnectionThroughSSH tests a connection through its SSH tunnel, closed once the test is done
+func (m *Manager) testConnectionThroughSSH(config *ConnectionConfig) (*TLSInfo, error) {
+	tunneled, tunnel, err := openSSHTunnel(*config)
+	if err != nil {
+		return nil, err
+	}
+	defer tunnel.Close()
+	return m.TestConnection(&tunneled)
+}
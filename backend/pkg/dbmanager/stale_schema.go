@@ -0,0 +1,78 @@
+package dbmanager
+
+import (
+	"context"
+	"databot-ai/internal/apis/dtos"
+	"databot-ai/internal/constants"
+	"regexp"
+	"time"
+)
+
+// missingSchemaObjectPatterns match the errors of each database for a table or a column that doesn't exist, the
+// errors a query gets when it was generated from an outdated schema
+var missingSchemaObjectPatterns = map[string][]*regexp.Regexp{
+	constants.DatabaseTypePostgreSQL: {
+		regexp.MustCompile(`(?i)\b(?:relation|column)\s+\S+\s+does not exist`),
+		regexp.MustCompile(`SQLSTATE 42(?:P01|703)`),
+	},
+	constants.DatabaseTypeMySQL: {
+		regexp.MustCompile(`Error 1(?:146|054)\b`),
+		regexp.MustCompile(`(?i)\btable '[^']+' doesn't exist|\bunknown column '[^']+'`),
+	},
+	constants.DatabaseTypeClickhouse: {
+		regexp.MustCompile(`\b(?:UNKNOWN_TABLE|UNKNOWN_IDENTIFIER|UNKNOWN_DATABASE)\b`),
+		regexp.MustCompile(`(?i)\bmissing columns\b`),
+	},
+	constants.DatabaseTypeSQLite: {
+		regexp.MustCompile(`(?i)\bno such (?:table|column)\b`),
+	},
+	constants.DatabaseTypeBigQuery: {
+		regexp.MustCompile(`(?i)\bnot found: table\b|\bunrecognized name\b|\bname \S+ not found inside\b`),
+	},
+	// A field that isn't mapped matches no document rather than failing, only a missing index is an error
+	constants.DatabaseTypeElasticsearch: {
+		regexp.MustCompile(`\bindex_not_found_exception\b`),
+	},
+	// ScyllaDB reports an unknown column of a WHERE clause as an undefined name
+	constants.DatabaseTypeCassandra: {
+		regexp.MustCompile(`(?i)\bunconfigured table\b|\bundefined (?:column )?name\b`),
+	},
+}
+
+// mongoMissingCollectionCode is the code of the errors of the MongoDB driver for a collection that doesn't exist
+const mongoMissingCollectionCode = "COLLECTION_NOT_FOUND"
+
+// IsMissingSchemaObjectError checks if a query failed because a table or a column it references doesn't exist. Only
+// these errors are matched, a syntax error or a failed constraint is not a sign of an outdated schema.
+func IsMissingSchemaObjectError(dbType string, queryErr *dtos.QueryError) bool {
+	if queryErr == nil {
+		return false
+	}
+	if dbType == constants.DatabaseTypeMongoDB {
+		return queryErr.Code == mongoMissingCollectionCode
+	}
+	switch dbType {
+	case constants.DatabaseTypeYugabyteDB:
+		dbType = constants.DatabaseTypePostgreSQL
+	case constants.DatabaseTypeMariaDB:
+		dbType = constants.DatabaseTypeMySQL
+	}
+	for _, pattern := range missingSchemaObjectPatterns[dbType] {
+		if pattern.MatchString(queryErr.Message) || pattern.MatchString(queryErr.Details) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSchemaUpdatedAt returns when the schema of a chat was last stored, the zero time if there is none
+func (m *Manager) GetSchemaUpdatedAt(ctx context.Context, chatID string) time.Time {
+	if m.schemaManager == nil {
+		return time.Time{}
+	}
+	storage, err := m.schemaManager.getStoredSchema(ctx, chatID)
+	if err != nil || storage == nil {
+		return time.Time{}
+	}
+	return storage.UpdatedAt
+}
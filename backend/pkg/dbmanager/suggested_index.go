@@ -0,0 +1,171 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"fmt"
+	"strings"
+)
+
+// IndexSuggestionsSupported checks if the LLM can suggest indexes for a database type, the other databases have no
+// secondary indexes (BigQuery) or index their data otherwise (ClickHouse sorting keys, MongoDB, Elasticsearch)
+func IndexSuggestionsSupported(dbType string) bool {
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB, constants.DatabaseTypeMySQL,
+		constants.DatabaseTypeMariaDB, constants.DatabaseTypeSQLite:
+		return true
+	}
+	return false
+}
+
+// CheckSuggestedIndex checks an index the LLM suggested for a read query, so that only the indexes worth creating are
+// offered to the user: indexQuery must be a single CREATE INDEX statement on table, the table must be large (see
+// complexityMediumTableRows) & read by the query, and the query must filter it on a column of the index that isn't
+// indexed yet. Returns the DROP INDEX statement rolling the index back, the error tells why the suggestion is dropped.
+func CheckSuggestedIndex(dbType, query, indexQuery, table string, columns []string, schema *LLMSchemaInfo) (string, error) {
+	if !IndexSuggestionsSupported(dbType) {
+		return "", fmt.Errorf("indexes can't be suggested for %s", dbType)
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("the index has no column")
+	}
+	indexName, indexTable, err := parseCreateIndex(indexQuery)
+	if err != nil {
+		return "", err
+	}
+	tableName := strings.ToLower(unqualifiedName(table))
+	if strings.ToLower(unqualifiedName(indexTable)) != tableName {
+		return "", fmt.Errorf("the index is created on %s instead of %s", indexTable, table)
+	}
+
+	if schema == nil {
+		return "", fmt.Errorf("the schema of the chat isn't known")
+	}
+	var tableInfo *LLMTableInfo
+	for name, info := range schema.Tables {
+		if strings.ToLower(unqualifiedName(name)) == tableName {
+			tableInfo = &info
+			break
+		}
+	}
+	if tableInfo == nil {
+		return "", fmt.Errorf("table %s isn't in the schema", table)
+	}
+	if tableInfo.RowCount < complexityMediumTableRows {
+		return "", fmt.Errorf("table %s only has %d rows", table, tableInfo.RowCount)
+	}
+
+	isRead := false
+	for _, name := range ReferencedTables(dbType, query) {
+		if name == tableName {
+			isRead = true
+			break
+		}
+	}
+	if !isRead {
+		return "", fmt.Errorf("the query doesn't read table %s", table)
+	}
+
+	indexed := make(map[string]bool, len(tableInfo.Columns))
+	for _, column := range tableInfo.Columns {
+		indexed[strings.ToLower(column.Name)] = column.IsIndexed
+	}
+	for _, column := range strings.Split(tableInfo.PrimaryKey, ",") {
+		if column = strings.ToLower(strings.TrimSpace(column)); column != "" {
+			indexed[column] = true
+		}
+	}
+	filterColumns := sqlFilterColumns(tokenizeSQL(query))
+	useful := false
+	for _, column := range columns {
+		isIndexed, ok := indexed[strings.ToLower(column)]
+		if !ok {
+			return "", fmt.Errorf("column %s isn't in table %s", column, table)
+		}
+		if !isIndexed && filterColumns[strings.ToLower(column)] {
+			useful = true
+		}
+	}
+	if !useful {
+		return "", fmt.Errorf("the query doesn't filter table %s on a column of the index without an index", table)
+	}
+
+	return dropIndexQuery(dbType, indexName, indexTable), nil
+}
+
+// parseCreateIndex returns the name & table of a CREATE [UNIQUE] INDEX [CONCURRENTLY] [IF NOT EXISTS] name ON table
+// statement, as written
+func parseCreateIndex(indexQuery string) (string, string, error) {
+	tokens := tokenizeSQL(indexQuery)
+	if len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	for _, token := range tokens {
+		if token.text == ";" {
+			return "", "", fmt.Errorf("the index must be created by a single statement")
+		}
+	}
+
+	i := 0
+	accept := func(words ...string) bool {
+		if i+len(words) > len(tokens) {
+			return false
+		}
+		for j, word := range words {
+			if tokens[i+j].kind != sqlTokenWord || tokens[i+j].value != word {
+				return false
+			}
+		}
+		i += len(words)
+		return true
+	}
+	qualifiedName := func() string {
+		var parts []string
+		for i < len(tokens) && isIdentifierToken(tokens[i]) {
+			parts = append(parts, tokens[i].text)
+			i++
+			if i+1 < len(tokens) && tokens[i].text == "." {
+				i++
+				continue
+			}
+			break
+		}
+		return strings.Join(parts, ".")
+	}
+
+	if !accept("create") {
+		return "", "", fmt.Errorf("the index must be created by a CREATE INDEX statement")
+	}
+	accept("unique")
+	if !accept("index") {
+		return "", "", fmt.Errorf("the index must be created by a CREATE INDEX statement")
+	}
+	accept("concurrently")
+	accept("if", "not", "exists")
+	name := qualifiedName()
+	if name == "" || !accept("on") {
+		return "", "", fmt.Errorf("the index must be named")
+	}
+	accept("only")
+	table := qualifiedName()
+	if table == "" || i >= len(tokens) || (tokens[i].text != "(" && tokens[i].value != "using") {
+		return "", "", fmt.Errorf("the table of the index is missing")
+	}
+	return name, table, nil
+}
+
+// dropIndexQuery returns the statement dropping an index, MySQL & MariaDB name the table of the index
+func dropIndexQuery(dbType, indexName, table string) string {
+	switch dbType {
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return fmt.Sprintf("DROP INDEX %s ON %s", indexName, table)
+	}
+	return fmt.Sprintf("DROP INDEX %s", indexName)
+}
+
+// unqualifiedName returns the name of a table without its schema & quotes, ex: orders for "public"."orders"
+func unqualifiedName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return unquoteIdentifier(name)
+}
@@ -0,0 +1,76 @@
+package dbmanager
+
+import (
+	"log"
+	"sort"
+)
+
+// TableAccessCounts maps the tables of a chat to the number of generated queries that used them
+type TableAccessCounts map[string]int64
+
+// SetFullDetailTables sets the number of most queried tables of a chat keeping their example records when its schema
+// has more tables, the other tables are summarized to their columns. 0 keeps the full detail of every table.
+func (m *Manager) SetFullDetailTables(limit int) {
+	m.schemaManager.mu.Lock()
+	defer m.schemaManager.mu.Unlock()
+	m.schemaManager.fullDetailTables = limit
+}
+
+// SetTableAccessCounts sets the number of queries that used each table of a chat, an empty map clears them
+func (sm *SchemaManager) SetTableAccessCounts(chatID string, counts TableAccessCounts) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(counts) == 0 {
+		delete(sm.tableAccessCounts, chatID)
+		return
+	}
+	sm.tableAccessCounts[chatID] = counts
+}
+
+// GetTableAccessCounts returns the number of queries that used each table of a chat
+func (sm *SchemaManager) GetTableAccessCounts(chatID string) TableAccessCounts {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.tableAccessCounts[chatID]
+}
+
+// prioritizeLLMSchema returns a copy of the storage where only the most queried tables of a large schema keep their
+// example records, so that the tables the chat works with get the full detail within the same prompt size. Schemas
+// without access counts yet are left as is, nothing tells which tables matter.
+func (sm *SchemaManager) prioritizeLLMSchema(chatID string, storage *SchemaStorage) *SchemaStorage {
+	sm.mu.RLock()
+	limit := sm.fullDetailTables
+	sm.mu.RUnlock()
+	counts := sm.GetTableAccessCounts(chatID)
+	if limit <= 0 || len(counts) == 0 || storage == nil || storage.LLMSchema == nil || len(storage.LLMSchema.Tables) <= limit {
+		return storage
+	}
+
+	// Most queried first, by name on ties so that the prompt stays stable
+	tableNames := sortedKeys(storage.LLMSchema.Tables)
+	sort.SliceStable(tableNames, func(i, j int) bool {
+		return counts[tableNames[i]] > counts[tableNames[j]]
+	})
+	fullDetail := make(map[string]bool, limit)
+	for _, tableName := range tableNames[:limit] {
+		if counts[tableName] > 0 {
+			fullDetail[tableName] = true
+		}
+	}
+
+	prioritizedTables := make(map[string]LLMTableInfo, len(storage.LLMSchema.Tables))
+	for tableName, table := range storage.LLMSchema.Tables {
+		if !fullDetail[tableName] {
+			table.ExampleRecords = nil
+		}
+		prioritizedTables[tableName] = table
+	}
+
+	prioritizedStorage := *storage
+	prioritizedStorage.LLMSchema = &LLMSchemaInfo{
+		Tables:        prioritizedTables,
+		Relationships: storage.LLMSchema.Relationships,
+	}
+	log.Printf("SchemaManager -> prioritizeLLMSchema -> Kept the full detail of %d/%d tables for chatID: %s", len(fullDetail), len(prioritizedTables), chatID)
+	return &prioritizedStorage
+}
@@ -0,0 +1,284 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"regexp"
+	"strings"
+)
+
+// sqlSystemSchemas hold the catalog of a database, their tables are never out of the scope of a chat
+var sqlSystemSchemas = map[string]bool{
+	"information_schema": true, "pg_catalog": true, "mysql": true, "performance_schema": true, "sys": true,
+	"system": true, "system_schema": true,
+}
+
+// sqlSystemTables are the catalog tables & pseudo tables queried without a schema
+var sqlSystemTables = map[string]bool{
+	"dual": true, "sqlite_master": true, "sqlite_schema": true, "sqlite_sequence": true,
+}
+
+var (
+	// mongoCollectionRegex matches the collection of db.collection.operation(...)
+	mongoCollectionRegex = regexp.MustCompile(`\bdb\.([A-Za-z0-9_$-]+)\s*\.`)
+	// mongoGetCollectionRegex matches the collection of db.getCollection("collection")
+	mongoGetCollectionRegex = regexp.MustCompile(`\bdb\.getCollection\(\s*["']([^"']+)["']\s*\)`)
+	// mongoStageCollectionRegexes match the collections the aggregation stages read or write, one per capture group
+	mongoStageCollectionRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`\$(?:lookup|graphLookup)["']?\s*:\s*\{[^{}]*?["']?from["']?\s*:\s*["']([^"']+)["']`),
+		regexp.MustCompile(`\$(?:unionWith|out)["']?\s*:\s*(?:["']([^"']+)["']|\{[^{}]*?["']?coll["']?\s*:\s*["']([^"']+)["'])`),
+		regexp.MustCompile(`\$merge["']?\s*:\s*(?:["']([^"']+)["']|\{[^{}]*?["']?into["']?\s*:\s*(?:["']([^"']+)["']|\{[^{}]*?["']?coll["']?\s*:\s*["']([^"']+)["']))`),
+	}
+	// cqlUsingRegex matches the USING TTL & USING TIMESTAMP clauses of CQL writes, they name no table unlike a SQL USING
+	cqlUsingRegex = regexp.MustCompile(`(?i)\busing\s+(ttl|timestamp)\b`)
+)
+
+// ReferencedTables lists the tables (collections for MongoDB) a query reads or writes, lower cased without their
+// schema & in the order they appear. The query is scanned rather than parsed: CTEs, table functions & the catalog
+// tables aren't listed, and a table the scan can't find isn't either, so callers should also check the tables the LLM
+// generated with the query.
+func ReferencedTables(dbType, query string) []string {
+	var tables []string
+	switch {
+	case dbType == constants.DatabaseTypeMongoDB:
+		tables = referencedMongoCollections(query)
+	case dbType == constants.DatabaseTypeElasticsearch:
+		tables = referencedElasticsearchIndices(query)
+	case dbType == constants.DatabaseTypeCassandra:
+		// CQL statements name their tables like SQL ones, without joins
+		tables = referencedSQLTables(cqlUsingRegex.ReplaceAllString(query, "$1"))
+	case isSQLDialect(dbType):
+		tables = referencedSQLTables(query)
+	}
+
+	seen := make(map[string]bool, len(tables))
+	unique := tables[:0]
+	for _, table := range tables {
+		if !seen[table] {
+			seen[table] = true
+			unique = append(unique, table)
+		}
+	}
+	return unique
+}
+
+func referencedSQLTables(query string) []string {
+	tokens := tokenizeSQL(query)
+	ctes := sqlCTENames(tokens)
+	var tables []string
+	add := func(name string) {
+		if name != "" && !ctes[name] {
+			tables = append(tables, name)
+		}
+	}
+
+	// Whether a SELECT or DELETE opened each level of parentheses, a FROM anywhere else belongs to a function such as
+	// EXTRACT(year FROM created_at)
+	statementAtDepth := []bool{true}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "(":
+			statementAtDepth = append(statementAtDepth, false)
+			continue
+		case ")":
+			if len(statementAtDepth) > 1 {
+				statementAtDepth = statementAtDepth[:len(statementAtDepth)-1]
+			}
+			continue
+		}
+		if token.kind != sqlTokenWord {
+			continue
+		}
+
+		switch token.value {
+		case "select", "delete":
+			statementAtDepth[len(statementAtDepth)-1] = true
+		case "from":
+			if !statementAtDepth[len(statementAtDepth)-1] {
+				continue
+			}
+			for _, name := range sqlTableList(tokens, i+1) {
+				add(name)
+			}
+		case "join", "into":
+			if i+1 < len(tokens) && (tokens[i+1].value == "outfile" || tokens[i+1].value == "dumpfile") {
+				continue
+			}
+			// The parenthesis after the table of INSERT INTO is its column list, not a table function
+			name, _, _ := sqlTableName(tokens, i+1, token.value == "into")
+			add(name)
+		case "using":
+			// DELETE ... USING other_table, JOIN ... USING (column) is skipped as it starts with a parenthesis
+			for _, name := range sqlTableList(tokens, i+1) {
+				add(name)
+			}
+		case "update":
+			// Only a statement, not FOR UPDATE or ON DUPLICATE KEY UPDATE
+			if i == 0 || tokens[i-1].text == ";" || tokens[i-1].text == "(" {
+				name, _, _ := sqlTableName(tokens, i+1, false)
+				add(name)
+			}
+		case "truncate", "alter", "drop":
+			next := i + 1
+			if next < len(tokens) && tokens[next].value == "table" {
+				next++
+			} else if token.value != "truncate" {
+				// DROP INDEX, ALTER USER...
+				continue
+			}
+			for next < len(tokens) && (tokens[next].value == "if" || tokens[next].value == "exists" || tokens[next].value == "only") {
+				next++
+			}
+			for _, name := range sqlTableList(tokens, next) {
+				add(name)
+			}
+		}
+	}
+	return tables
+}
+
+// sqlTableList returns the comma separated tables starting at idx, with their aliases. Subqueries are skipped, their
+// tables are found when their own FROM is scanned.
+func sqlTableList(tokens []sqlToken, idx int) []string {
+	var tables []string
+	i := idx
+	for i < len(tokens) {
+		if tokens[i].text == "(" {
+			i = skipSQLParentheses(tokens, i)
+		} else if tokens[i].value == "lateral" {
+			i++
+			continue
+		} else {
+			name, next, ok := sqlTableName(tokens, i, false)
+			if !ok {
+				break
+			}
+			if name != "" {
+				tables = append(tables, name)
+			}
+			i = next
+		}
+
+		if i < len(tokens) && tokens[i].kind == sqlTokenWord && tokens[i].value == "as" {
+			i++
+		}
+		if i < len(tokens) && isIdentifierToken(tokens[i]) && !(tokens[i].kind == sqlTokenWord && sqlClauseKeywords[tokens[i].value]) {
+			i++
+		}
+		if i >= len(tokens) || tokens[i].text != "," {
+			break
+		}
+		i++
+	}
+	return tables
+}
+
+// sqlTableName parses "[project.][schema.]table" starting at idx and returns the lower cased table name & the index after it.
+// The name is empty for a table function (ex: generate_series(1, 10)) unless columnList, or for a catalog table.
+func sqlTableName(tokens []sqlToken, idx int, columnList bool) (string, int, bool) {
+	if idx >= len(tokens) || !isIdentifierToken(tokens[idx]) || (tokens[idx].kind == sqlTokenWord && sqlClauseKeywords[tokens[idx].value]) {
+		return "", idx, false
+	}
+	var parts []string
+	i := idx
+	for {
+		// A BigQuery path can be quoted whole, ex: `project.dataset.table`
+		parts = append(parts, strings.Split(tokens[i].value, ".")...)
+		if i+2 < len(tokens) && tokens[i+1].text == "." && isIdentifierToken(tokens[i+2]) {
+			i += 2
+			continue
+		}
+		break
+	}
+	i++
+
+	if i < len(tokens) && tokens[i].text == "(" && !columnList {
+		return "", skipSQLParentheses(tokens, i), true
+	}
+	name := parts[len(parts)-1]
+	if sqlSystemTables[name] || (len(parts) > 1 && sqlSystemSchemas[parts[len(parts)-2]]) {
+		return "", i, true
+	}
+	return name, i, true
+}
+
+// sqlCTENames returns the names of the common table expressions, "name [(columns)] AS [[NOT] MATERIALIZED] ("
+func sqlCTENames(tokens []sqlToken) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < len(tokens); i++ {
+		if !isIdentifierToken(tokens[i]) || (tokens[i].kind == sqlTokenWord && sqlClauseKeywords[tokens[i].value]) {
+			continue
+		}
+		next := i + 1
+		if next < len(tokens) && tokens[next].text == "(" {
+			next = skipSQLParentheses(tokens, next)
+		}
+		if next >= len(tokens) || tokens[next].value != "as" {
+			continue
+		}
+		next++
+		for next < len(tokens) && (tokens[next].value == "not" || tokens[next].value == "materialized") {
+			next++
+		}
+		if next < len(tokens) && tokens[next].text == "(" {
+			names[tokens[i].value] = true
+		}
+	}
+	return names
+}
+
+// skipSQLParentheses returns the index after the parenthesis closing the one at idx
+func skipSQLParentheses(tokens []sqlToken, idx int) int {
+	depth := 0
+	for i := idx; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
+func referencedMongoCollections(query string) []string {
+	var collections []string
+	for _, match := range mongoCollectionRegex.FindAllStringSubmatch(query, -1) {
+		if match[1] != "getCollection" {
+			collections = append(collections, strings.ToLower(match[1]))
+		}
+	}
+	for _, match := range mongoGetCollectionRegex.FindAllStringSubmatch(query, -1) {
+		collections = append(collections, strings.ToLower(match[1]))
+	}
+	for _, regex := range mongoStageCollectionRegexes {
+		for _, match := range regex.FindAllStringSubmatch(query, -1) {
+			for _, group := range match[1:] {
+				if group != "" {
+					collections = append(collections, strings.ToLower(group))
+					break
+				}
+			}
+		}
+	}
+	return collections
+}
+
+// referencedElasticsearchIndices returns the indices of the path of an Elasticsearch query, ex: orders & customers for
+// GET /orders,customers/_search. A query without an index reads the index pattern of the connection, none is listed.
+func referencedElasticsearchIndices(query string) []string {
+	request, err := parseElasticsearchQuery(query)
+	if err != nil || request.Index == "" {
+		return nil
+	}
+	var indices []string
+	for _, index := range strings.Split(request.Index, ",") {
+		if index = strings.ToLower(strings.TrimSpace(index)); index != "" {
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,15 @@
+package dbmanager
+
+// CountTenantConnections returns the number of chats of a tenant connected to their database, so that a tenant of a
+// shared deployment can be limited to a number of connections
+func (m *Manager) CountTenantConnections(tenantID string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for _, conn := range m.connections {
+		if conn.Config.TenantID == tenantID && conn.Status == StatusConnected {
+			count++
+		}
+	}
+	return count
+}
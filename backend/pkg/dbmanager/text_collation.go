@@ -0,0 +1,192 @@
+package dbmanager
+
+import (
+	"databot-ai/internal/constants"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoKeyRegex matches the unquoted keys of a MongoDB document, ex: {locale: "fr"}
+var mongoKeyRegex = regexp.MustCompile(`([{,]\s*)([A-Za-z_$][\w$]*)\s*:`)
+
+// sqlCollationRegex matches the collation names accepted for SQL databases, ex: utf8mb4_0900_ai_ci, en-US-x-icu, C
+var sqlCollationRegex = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// mongoCollation is a MongoDB collation document as written in queries, ex: {locale: "fr", strength: 2}
+type mongoCollation struct {
+	Locale          string `json:"locale"`
+	CaseLevel       bool   `json:"caseLevel,omitempty"`
+	CaseFirst       string `json:"caseFirst,omitempty"`
+	Strength        int    `json:"strength,omitempty"`
+	NumericOrdering bool   `json:"numericOrdering,omitempty"`
+	Alternate       string `json:"alternate,omitempty"`
+	MaxVariable     string `json:"maxVariable,omitempty"`
+	Normalization   bool   `json:"normalization,omitempty"`
+	Backwards       bool   `json:"backwards,omitempty"`
+}
+
+// ValidateTextCollation checks the collation chosen for the text comparisons of a chat: a collation name for SQL
+// databases, a locale (ex: "fr") or a collation document (ex: {"locale": "fr", "strength": 2}) for MongoDB
+func ValidateTextCollation(dbType, collation string) error {
+	collation = strings.TrimSpace(collation)
+	if collation == "" {
+		return nil
+	}
+	switch {
+	case dbType == constants.DatabaseTypeMongoDB:
+		if _, err := parseMongoCollation(collation); err != nil {
+			return err
+		}
+	case isSQLDatabaseType(dbType):
+		if !sqlCollationRegex.MatchString(collation) {
+			return fmt.Errorf("invalid collation %q, only letters, digits, '_', '-', '.' & '@' are allowed", collation)
+		}
+	default:
+		return fmt.Errorf("text collation is not supported for %s", dbType)
+	}
+	return nil
+}
+
+// FormatTextCollationForLLM tells the LLM to compare text with the collation chosen for the chat, rather than with the
+// collation of each column (see the COLLATE of the schema columns), "" if no collation is chosen
+func FormatTextCollationForLLM(dbType, collation string) string {
+	collation = strings.TrimSpace(collation)
+	if collation == "" {
+		return ""
+	}
+	switch dbType {
+	case constants.DatabaseTypePostgreSQL, constants.DatabaseTypeYugabyteDB:
+		return fmt.Sprintf(`Compare text with the collation "%s": add COLLATE "%s" to text comparisons, LIKE & ORDER BY of text columns (ex: WHERE name COLLATE "%s" = 'Ana'), the collation of a column only applies otherwise.`, collation, collation, collation)
+	case constants.DatabaseTypeMySQL, constants.DatabaseTypeMariaDB:
+		return fmt.Sprintf("Compare text with the collation %s: add COLLATE %s to text comparisons, LIKE & ORDER BY of text columns (ex: WHERE name = 'Ana' COLLATE %s), the collation of a column only applies otherwise.", collation, collation, collation)
+	case constants.DatabaseTypeClickhouse:
+		return fmt.Sprintf("Sort text with the collation '%s' (ex: ORDER BY name COLLATE '%s'), ClickHouse comparisons are binary so compare lower(column) or use ILIKE for case insensitive matches.", collation, collation)
+	case constants.DatabaseTypeMongoDB:
+		document := collation
+		if parsed, err := parseMongoCollation(collation); err == nil {
+			if encoded, err := json.Marshal(parsed); err == nil {
+				document = string(encoded)
+			}
+		}
+		return fmt.Sprintf("Compare text with the collation %s: chain .collation(%s) to find queries & pass {collation: %s} as the options of aggregate queries, ex: db.users.aggregate([...], {collation: %s}).", document, document, document, document)
+	}
+	return ""
+}
+
+// mongoDocumentToJSON converts a small MongoDB document (unquoted keys, single quotes) to JSON
+func mongoDocumentToJSON(document string) string {
+	document = strings.ReplaceAll(document, "'", `"`)
+	return mongoKeyRegex.ReplaceAllString(document, `$1"$2":`)
+}
+
+// parseMongoCollation parses a collation document (MongoDB syntax accepted) or a bare locale
+func parseMongoCollation(collation string) (*mongoCollation, error) {
+	collation = strings.TrimSpace(collation)
+	var parsed mongoCollation
+	if strings.HasPrefix(collation, "{") {
+		if err := json.Unmarshal([]byte(mongoDocumentToJSON(collation)), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid collation %s: %v", collation, err)
+		}
+	} else {
+		parsed.Locale = strings.Trim(collation, `"'`)
+	}
+	if parsed.Locale == "" {
+		return nil, fmt.Errorf("invalid collation %s: a locale is required", collation)
+	}
+	if parsed.Strength < 0 || parsed.Strength > 5 {
+		return nil, fmt.Errorf("invalid collation %s: strength must be between 1 & 5", collation)
+	}
+	return &parsed, nil
+}
+
+// options converts the collation to the options of the MongoDB driver
+func (c *mongoCollation) options() *options.Collation {
+	return &options.Collation{
+		Locale:          c.Locale,
+		CaseLevel:       c.CaseLevel,
+		CaseFirst:       c.CaseFirst,
+		Strength:        c.Strength,
+		NumericOrdering: c.NumericOrdering,
+		Alternate:       c.Alternate,
+		MaxVariable:     c.MaxVariable,
+		Normalization:   c.Normalization,
+		Backwards:       c.Backwards,
+	}
+}
+
+// extractMongoCollation finds the .collation({...}) chained to a MongoDB query, nil if there is none
+func extractMongoCollation(query string) (*options.Collation, error) {
+	index := strings.Index(query, ".collation(")
+	if index == -1 {
+		return nil, nil
+	}
+	document, _, err := extractParenthesisContent(query, index+len(".collation"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid collation: %v", err)
+	}
+	collation, err := parseMongoCollation(document)
+	if err != nil {
+		return nil, err
+	}
+	return collation.options(), nil
+}
+
+// splitAggregateOptions splits the arguments of an aggregate into its pipeline & its options document, ex:
+// [{$match: {...}}], {collation: {locale: "fr"}}. The options are "" if there are none.
+func splitAggregateOptions(params string) (string, string) {
+	trimmed := strings.TrimSpace(params)
+	if !strings.HasPrefix(trimmed, "[") {
+		return params, ""
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{' || c == '(':
+			depth++
+		case c == ']' || c == '}' || c == ')':
+			depth--
+			if depth == 0 {
+				rest := strings.TrimSpace(trimmed[i+1:])
+				if !strings.HasPrefix(rest, ",") {
+					return params, ""
+				}
+				return trimmed[:i+1], strings.TrimSpace(rest[1:])
+			}
+		}
+	}
+	return params, ""
+}
+
+// aggregateCollation reads the collation of the options document of an aggregate, nil if it has none
+func aggregateCollation(aggregateOptions string) (*options.Collation, error) {
+	if aggregateOptions == "" {
+		return nil, nil
+	}
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(mongoDocumentToJSON(aggregateOptions)), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid aggregate options: %v", err)
+	}
+	document, ok := parsed["collation"]
+	if !ok {
+		return nil, nil
+	}
+	collation, err := parseMongoCollation(string(document))
+	if err != nil {
+		return nil, err
+	}
+	return collation.options(), nil
+}
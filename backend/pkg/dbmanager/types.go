@@ -2,6 +2,7 @@ package dbmanager
 
 import (
 	"context"
+	"database/sql"
 	"databot-ai/internal/apis/dtos"
 	"sync"
 	"time"
@@ -17,34 +18,86 @@ const (
 	StatusConnected    ConnectionStatus = "db-connected"
 	StatusDisconnected ConnectionStatus = "db-disconnected"
 	StatusError        ConnectionStatus = "db-error"
+
+	// StatusSchemaProgress isn't a connection state, it streams the progress of a schema refresh, see SchemaProgress
+	StatusSchemaProgress ConnectionStatus = "schema-refresh-progress"
+	// StatusSchemaEmpty alerts that a schema refresh found no tables, with the EmptySchemaDiagnosis telling why
+	StatusSchemaEmpty ConnectionStatus = "schema-empty"
 )
 
 // Connection represents an active database connection
 type Connection struct {
-	DB             *gorm.DB
-	MongoDBObj     interface{} // MongoDB client object
-	LastUsed       time.Time
-	Status         ConnectionStatus
-	Error          string
-	Config         ConnectionConfig
-	UserID         string
-	ChatID         string
-	StreamID       string
-	Subscribers    map[string]bool     // Map of subscriber IDs (e.g., streamIDs) that need notifications
-	SubLock        sync.RWMutex        // Lock for thread-safe subscriber operations
-	OnSchemaChange func(chatID string) // Callback for schema changes
-	ConfigKey      string              // Reference to the shared connection pool
-	TempFiles      []string            // Temporary certificate files to clean up on disconnect
+	DB               *gorm.DB
+	MongoDBObj       interface{} // MongoDB client object
+	BigQueryObj      interface{} // BigQuery client object, see BigQueryClient
+	ElasticsearchObj interface{} // Elasticsearch client object, see ElasticsearchClient
+	CassandraObj     interface{} // Cassandra session, see CassandraClient
+	LastUsed         time.Time
+	Status           ConnectionStatus
+	Error            string
+	Config           ConnectionConfig
+	UserID           string
+	ChatID           string
+	StreamID         string
+	Subscribers      map[string]bool     // Map of subscriber IDs (e.g., streamIDs) that need notifications
+	SubLock          sync.RWMutex        // Lock for thread-safe subscriber operations
+	OnSchemaChange   func(chatID string) // Callback for schema changes
+	ConfigKey        string              // Reference to the shared connection pool
+	TempFiles        []string            // Temporary certificate files to clean up on disconnect
+	session          *DBSession          // Dedicated connection held in session mode, nil for stateless per-query execution
+	sessionMu        sync.Mutex          // Guards session, see OpenSession & closeSession
+	ServerInfo       *ServerInfo         // Version & capabilities of the server, captured at connect time
+	TLSInfo          *TLSInfo            // TLS negotiated by the connection, captured at connect time
+	ConnectedHost    string              // host:port the connection is connected to, one of Config.Hosts after a failover
+	holds            int32               // Callers holding the connection open, see HoldConnection
+}
+
+// DBSession is a dedicated database connection held across queries, so that temp tables & session variables persist
+type DBSession struct {
+	Conn      *sql.Conn
+	StartedAt time.Time
+	mu        sync.Mutex // A session connection can only run one transaction at a time
+	closed    bool       // Set under mu once the connection is discarded, a query waiting for mu must not use it
+}
+
+// DriverWarnings collects warnings emitted by the database driver while a transaction runs, ex: PostgreSQL notices
+type DriverWarnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// Add records a warning message
+func (w *DriverWarnings) Add(message string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, message)
+}
+
+// Drain returns the collected warnings and resets the collector
+func (w *DriverWarnings) Drain() []string {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	messages := w.messages
+	w.messages = nil
+	return messages
 }
 
 // ConnectionConfig holds the configuration for a database connection
 type ConnectionConfig struct {
-	Type     string  `json:"type"`
-	Host     string  `json:"host"`
-	Port     *string `json:"port"`
-	Username *string `json:"username"`
-	Password *string `json:"password"`
-	Database string  `json:"database"`
+	Type     string   `json:"type"`
+	Host     string   `json:"host"`
+	Hosts    []string `json:"hosts,omitempty"`  // Failover hosts of a cluster, tried after Host, ex: "db-2" or "db-2:5433"
+	Shards   []string `json:"shards,omitempty"` // Other shards holding the same tables, ex: "shard-2" or "shard-2:5433/orders_2", see WithShardFanOut
+	Port     *string  `json:"port"`
+	Username *string  `json:"username"`
+	Password *string  `json:"password"`
+	Database string   `json:"database"`
 
 	// SSL/TLS Configuration
 	UseSSL         bool    `json:"use_ssl"`
@@ -52,6 +105,31 @@ type ConnectionConfig struct {
 	SSLCertURL     *string `json:"ssl_cert_url,omitempty"`      // URL to client certificate
 	SSLKeyURL      *string `json:"ssl_key_url,omitempty"`       // URL to client key
 	SSLRootCertURL *string `json:"ssl_root_cert_url,omitempty"` // URL to CA certificate
+
+	MaxResultRows int `json:"max_result_rows,omitempty"` // Rows the database returns for a read query at most, see limitQueryRows, 0 is unlimited
+
+	Role string `json:"role,omitempty"` // PostgreSQL/YugabyteDB role the queries run as, assumed with SET ROLE, see openPostgres
+
+	FilePath string `json:"file_path,omitempty"` // SQLite database file, used instead of Host & Port, see resolveSQLitePath
+
+	ProjectID       string `json:"project_id,omitempty"` // BigQuery project, the Database is its dataset
+	CredentialsJSON string `json:"-"`                    // BigQuery service account key, used instead of Username & Password
+
+	TenantID string `json:"tenant_id,omitempty"` // Tenant of the user of the chat, see CountTenantConnections
+
+	// Connection pool, see connectionPoolSettings for the defaults of the unset (0) ones
+	MaxOpenConns    int `json:"max_open_conns,omitempty"`
+	MaxIdleConns    int `json:"max_idle_conns,omitempty"`
+	ConnMaxLifetime int `json:"conn_max_lifetime,omitempty"` // in seconds
+
+	// SSH bastion the database is reached through, see openSSHTunnel
+	SSHHost       string `json:"ssh_host,omitempty"`
+	SSHPort       string `json:"ssh_port,omitempty"` // 22 if empty
+	SSHUser       string `json:"ssh_user,omitempty"`
+	SSHPrivateKey string `json:"-"`                      // PEM private key of SSHUser
+	SSHHostKey    string `json:"ssh_host_key,omitempty"` // Public key of the bastion (authorized_keys format), required with an SSH host
+
+	sshTunneled bool // Set on the config connecting the driver to the local end of the tunnel
 }
 
 // SSEEvent represents an event to be sent via SSE
@@ -77,6 +155,9 @@ type QueryExecutionResult struct {
 	ResultJSON    string                 `json:"result_json"`
 	ExecutionTime int                    `json:"execution_time"`
 	Error         *dtos.QueryError       `json:"error,omitempty"`
+	Warnings      []string               `json:"warnings,omitempty"` // Driver warnings such as data truncation or deprecated syntax
+	Columns       []dtos.ResultColumn    `json:"columns,omitempty"`  // Columns of "results" with their types, set on success
+	Cached        bool                   `json:"-"`                  // Served from the cache of the read query results, see GetCachedQueryResult
 
 	// Additional fields for testing and query parsing
 	Database   string    `json:"-"` // Database name
@@ -96,7 +177,7 @@ type DatabaseDriver interface {
 	Ping(conn *Connection) error
 	IsAlive(conn *Connection) bool
 	ExecuteQuery(ctx context.Context, conn *Connection, query string, queryType string, findCount bool) *QueryExecutionResult
-	BeginTx(ctx context.Context, conn *Connection) Transaction
+	BeginTx(ctx context.Context, conn *Connection, session *DBSession) Transaction // session is nil unless the chat is in session mode
 }
 
 // Add new Transaction interface
@@ -0,0 +1,242 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"databot-ai/internal/constants"
+	"databot-ai/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicMessagesURL = "https://api.anthropic.com/v1/messages"
+	// anthropicResponseTool is the tool Claude must call, its input is the JSON response of the other providers
+	anthropicResponseTool = "databot_response"
+)
+
+type AnthropicClient struct {
+	httpClient          *http.Client
+	apiKey              string
+	model               string
+	maxCompletionTokens int
+	temperature         float64
+	DBConfigs           []LLMDBConfig
+}
+
+// anthropicMessage is a message of the Messages API, content holds text blocks
+type anthropicMessage struct {
+	Role    string                 `json:"role"`
+	Content []anthropicTextContent `json:"content"`
+}
+
+type anthropicTextContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicRequest struct {
+	Model       string                 `json:"model"`
+	MaxTokens   int                    `json:"max_tokens"`
+	Temperature float64                `json:"temperature"`
+	System      []anthropicTextContent `json:"system,omitempty"`
+	Messages    []anthropicMessage     `json:"messages"`
+	Tools       []anthropicTool        `json:"tools"`
+	ToolChoice  anthropicToolChoice    `json:"tool_choice"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewAnthropicClient(config Config) (*AnthropicClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("anthropic API key is required")
+	}
+	model := config.Model
+	if model == "" {
+		model = constants.AnthropicModel
+	}
+
+	return &AnthropicClient{
+		httpClient:          &http.Client{},
+		apiKey:              config.APIKey,
+		model:               model,
+		maxCompletionTokens: config.MaxCompletionTokens,
+		temperature:         config.Temperature,
+		DBConfigs:           config.DBConfigs,
+	}, nil
+}
+
+func (c *AnthropicClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	// Check if the context is cancelled
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	systemPrompt := ""
+	responseSchema := ""
+	for _, dbConfig := range c.DBConfigs {
+		if dbConfig.DBType == dbType {
+			systemPrompt = constants.RenderPageSize(dbConfig.SystemPrompt, pageSize(ctx))
+			responseSchema = constants.RenderPageSize(dbConfig.Schema.(string), pageSize(ctx))
+			break
+		}
+	}
+
+	// Claude only takes user & assistant messages, the system messages of the chat (schema, server info...) join the
+	// database prompt in the system blocks
+	system := []anthropicTextContent{{Type: "text", Text: systemPrompt}}
+	anthropicMessages := make([]anthropicMessage, 0, len(messages))
+	for _, msg := range messages {
+		content := ""
+		switch msg.Role {
+		case "user":
+			if userMsg, ok := msg.Content["user_message"].(string); ok {
+				content = userMsg
+			}
+		case "assistant":
+			content = formatAssistantResponse(msg.Content["assistant_response"].(map[string]interface{}))
+		case "system":
+			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
+				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
+			} else if serverInfo, ok := msg.Content["server_info"].(string); ok {
+				content = serverInfo
+			} else if queryTemplates, ok := msg.Content["query_templates"].(string); ok {
+				content = queryTemplates
+			} else if textCollation, ok := msg.Content["text_collation"].(string); ok {
+				content = textCollation
+			} else if connectionsSchema, ok := msg.Content["connections_schema"].(string); ok {
+				content = connectionsSchema
+			}
+			if content != "" {
+				system = append(system, anthropicTextContent{Type: "text", Text: content})
+			}
+			continue
+		}
+		if content == "" {
+			continue
+		}
+
+		// Consecutive messages of the same role are merged, the roles must alternate
+		role := mapRole(msg.Role)
+		if last := len(anthropicMessages) - 1; last >= 0 && anthropicMessages[last].Role == role {
+			anthropicMessages[last].Content = append(anthropicMessages[last].Content, anthropicTextContent{Type: "text", Text: content})
+			continue
+		}
+		anthropicMessages = append(anthropicMessages, anthropicMessage{
+			Role:    role,
+			Content: []anthropicTextContent{{Type: "text", Text: content}},
+		})
+	}
+	if len(anthropicMessages) == 0 || anthropicMessages[0].Role != "user" {
+		// The conversation must start with a user message
+		anthropicMessages = append([]anthropicMessage{{
+			Role:    "user",
+			Content: []anthropicTextContent{{Type: "text", Text: "Please provide a response based on our conversation history."}},
+		}}, anthropicMessages...)
+	}
+
+	// The response schema is forced through a tool call, its input is the structured response
+	req := anthropicRequest{
+		Model:       c.model,
+		MaxTokens:   c.maxCompletionTokens,
+		Temperature: c.temperature,
+		System:      system,
+		Messages:    anthropicMessages,
+		Tools: []anthropicTool{{
+			Name:        anthropicResponseTool,
+			Description: "A friendly AI Response/Explanation or clarification question (Must Send this)",
+			InputSchema: json.RawMessage(responseSchema),
+		}},
+		ToolChoice: anthropicToolChoice{Type: "tool", Name: anthropicResponseTool},
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the Anthropic request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, anthropicMessagesURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the Anthropic request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", constants.AnthropicAPIVersion)
+
+	// Call Anthropic API
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.Printf("GenerateResponse -> err: %v", err)
+		return "", fmt.Errorf("anthropic API error: %v", err)
+	}
+	defer httpResp.Body.Close()
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the Anthropic response: %v", err)
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("anthropic API error (status %d): %s", httpResp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("anthropic API error: %s: %s", resp.Error.Type, resp.Error.Message)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("anthropic API error: status %d", httpResp.StatusCode)
+	}
+
+	log.Printf("ANTHROPIC -> GenerateResponse -> stop_reason: %s", resp.StopReason)
+	for _, block := range resp.Content {
+		if block.Type != "tool_use" || block.Name != anthropicResponseTool {
+			continue
+		}
+		// Validate response against schema
+		var llmResponse constants.LLMResponse
+		if err := json.Unmarshal(block.Input, &llmResponse); err != nil {
+			return "", fmt.Errorf("invalid response format: %v", err)
+		}
+		return string(block.Input), nil
+	}
+	return "", fmt.Errorf("no response from Anthropic")
+}
+
+// GenerateResponseStream emits the assistantMessage once the whole response arrives, the tool input holding the
+// response isn't streamed
+func (c *AnthropicClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	return generateWithoutStreaming(ctx, c, messages, dbType, onMessage)
+}
+
+func (c *AnthropicClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Name:                c.model,
+		Provider:            "anthropic",
+		MaxCompletionTokens: c.maxCompletionTokens,
+	}
+}
@@ -59,8 +59,8 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 
 	for _, dbConfig := range c.DBConfigs {
 		if dbConfig.DBType == dbType {
-			systemPrompt = dbConfig.SystemPrompt
-			responseSchema = dbConfig.Schema.(*genai.Schema)
+			systemPrompt = constants.RenderPageSize(dbConfig.SystemPrompt, pageSize(ctx))
+			responseSchema = renderGeminiSchemaPageSize(dbConfig.Schema.(*genai.Schema), pageSize(ctx))
 			break
 		}
 	}
@@ -91,6 +91,14 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 		case "system":
 			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
 				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
+			} else if serverInfo, ok := msg.Content["server_info"].(string); ok {
+				content = serverInfo
+			} else if queryTemplates, ok := msg.Content["query_templates"].(string); ok {
+				content = queryTemplates
+			} else if textCollation, ok := msg.Content["text_collation"].(string); ok {
+				content = textCollation
+			} else if connectionsSchema, ok := msg.Content["connections_schema"].(string); ok {
+				content = connectionsSchema
 			}
 		}
 
@@ -191,6 +199,11 @@ func (c *GeminiClient) GenerateResponse(ctx context.Context, messages []*models.
 }
 
 // GetModelInfo returns information about the Gemini model.
+// GenerateResponseStream emits the assistantMessage once the whole response arrives, the response isn't streamed
+func (c *GeminiClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	return generateWithoutStreaming(ctx, c, messages, dbType, onMessage)
+}
+
 func (c *GeminiClient) GetModelInfo() ModelInfo {
 	return ModelInfo{
 		Name:                c.model,
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"sync"
+)
+
+// CallMetrics reports the load of the LLM calls
+type CallMetrics struct {
+	MaxConcurrentCalls int `json:"max_concurrent_calls"` // 0 when the calls aren't limited
+	ActiveCalls        int `json:"active_calls"`
+	QueuedCalls        int `json:"queued_calls"` // Calls waiting for an active call to finish
+}
+
+type queuedNotifierKey struct{}
+
+// WithQueuedNotifier sets a function called when a call made with the context has to wait for a slot, with the
+// number of calls queued at that time
+func WithQueuedNotifier(ctx context.Context, notify func(queued int)) context.Context {
+	return context.WithValue(ctx, queuedNotifierKey{}, notify)
+}
+
+// callLimiter bounds the number of concurrent calls shared by every client, calls over the bound are queued in
+// arrival order
+type callLimiter struct {
+	slots  chan struct{}
+	mu     sync.Mutex
+	queued int
+}
+
+func newCallLimiter(maxCalls int) *callLimiter {
+	return &callLimiter{slots: make(chan struct{}, maxCalls)}
+}
+
+// acquire waits for a free slot, the error of the context is returned if it is done first
+func (l *callLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l.mu.Lock()
+	l.queued++
+	queued := l.queued
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+
+	if notify, ok := ctx.Value(queuedNotifierKey{}).(func(int)); ok && notify != nil {
+		notify(queued)
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *callLimiter) release() {
+	<-l.slots
+}
+
+func (l *callLimiter) metrics() CallMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return CallMetrics{
+		MaxConcurrentCalls: cap(l.slots),
+		ActiveCalls:        len(l.slots),
+		QueuedCalls:        l.queued,
+	}
+}
+
+// limitedClient makes the calls of a client wait for a slot of the limiter of the manager
+type limitedClient struct {
+	Client
+	limiter *callLimiter
+}
+
+func (c *limitedClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.limiter.release()
+	return c.Client.GenerateResponse(ctx, messages, dbType)
+}
+
+func (c *limitedClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.limiter.release()
+	return c.Client.GenerateResponseStream(ctx, messages, dbType, onMessage)
+}
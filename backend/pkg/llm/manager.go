@@ -10,12 +10,18 @@ import (
 
 type Manager struct {
 	clients map[string]Client
+	configs map[string]Config // Config of each client, the clients of the tenants are created from it
 	mu      sync.RWMutex
+	limiter *callLimiter // Shared by the clients, nil when the calls aren't limited
+
+	tenants   map[string]*tenant          // Tenants calling with their own API keys or limits, see SetTenants
+	callQuota func(tenantID string) error // Counts a call of a tenant, see SetCallQuota
 }
 
 func NewManager() *Manager {
 	return &Manager{
 		clients: make(map[string]Client),
+		configs: make(map[string]Config),
 	}
 }
 
@@ -23,6 +29,20 @@ func (m *Manager) RegisterClient(name string, config Config) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	client, err := newClient(config)
+	if err != nil {
+		return err
+	}
+
+	m.clients[name] = client
+	m.configs[name] = config
+	for _, t := range m.tenants {
+		t.removeClient(name)
+	}
+	return nil
+}
+
+func newClient(config Config) (Client, error) {
 	var client Client
 	var err error
 
@@ -31,17 +51,17 @@ func (m *Manager) RegisterClient(name string, config Config) error {
 		client, err = NewOpenAIClient(config)
 	case "gemini":
 		client, err = NewGeminiClient(config)
-	// Add other providers here (Gemini, etc.)
+	case "anthropic":
+		client, err = NewAnthropicClient(config)
+	// Add other providers here
 	default:
-		return fmt.Errorf("unsupported LLM provider: %s", config.Provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s", config.Provider)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to create LLM client: %v", err)
+		return nil, fmt.Errorf("failed to create LLM client: %v", err)
 	}
-
-	m.clients[name] = client
-	return nil
+	return client, nil
 }
 
 func (m *Manager) GetClient(name string) (Client, error) {
@@ -53,13 +73,46 @@ func (m *Manager) GetClient(name string) (Client, error) {
 		return nil, fmt.Errorf("LLM client not found: %s", name)
 	}
 
+	if m.limiter != nil {
+		client = &limitedClient{Client: client, limiter: m.limiter}
+	}
+	if len(m.tenants) > 0 || m.callQuota != nil {
+		client = &tenantClient{Client: client, name: name, manager: m}
+	}
 	return client, nil
 }
 
+// SetMaxConcurrentCalls limits the number of LLM calls running at the same time across every client, the calls over
+// the limit wait for one to finish, 0 doesn't limit the calls. Only the clients returned by GetClient afterwards are
+// limited.
+func (m *Manager) SetMaxConcurrentCalls(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if limit <= 0 {
+		m.limiter = nil
+		return
+	}
+	m.limiter = newCallLimiter(limit)
+}
+
+// GetCallMetrics returns the number of active & queued LLM calls
+func (m *Manager) GetCallMetrics() CallMetrics {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.limiter == nil {
+		return CallMetrics{}
+	}
+	return m.limiter.metrics()
+}
+
 func (m *Manager) RemoveClient(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.clients, name)
+	delete(m.configs, name)
+	for _, t := range m.tenants {
+		t.removeClient(name)
+	}
 }
 
 // Add helper function to properly format assistant response
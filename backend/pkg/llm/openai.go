@@ -5,8 +5,11 @@ import (
 	"databot-ai/internal/constants"
 	"databot-ai/internal/models"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -39,12 +42,9 @@ func NewOpenAIClient(config Config) (*OpenAIClient, error) {
 	}, nil
 }
 
-func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
-	// Check if the context is cancelled
-	if ctx.Err() != nil {
-		return "", ctx.Err()
-	}
-
+// chatCompletionRequest converts the messages to a chat completion request of the response schema of the database,
+// pageSize is the page size of the paginated queries
+func (c *OpenAIClient) chatCompletionRequest(messages []*models.LLMMessage, dbType string, pageSize int) openai.ChatCompletionRequest {
 	// Convert messages to OpenAI format
 	openAIMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
 
@@ -53,8 +53,8 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 
 	for _, dbConfig := range c.DBConfigs {
 		if dbConfig.DBType == dbType {
-			systemPrompt = dbConfig.SystemPrompt
-			responseSchema = dbConfig.Schema.(string)
+			systemPrompt = constants.RenderPageSize(dbConfig.SystemPrompt, pageSize)
+			responseSchema = constants.RenderPageSize(dbConfig.Schema.(string), pageSize)
 			break
 		}
 	}
@@ -81,6 +81,14 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 		case "system":
 			if schemaUpdate, ok := msg.Content["schema_update"].(string); ok {
 				content = fmt.Sprintf("Database schema update:\n%s", schemaUpdate)
+			} else if serverInfo, ok := msg.Content["server_info"].(string); ok {
+				content = serverInfo
+			} else if queryTemplates, ok := msg.Content["query_templates"].(string); ok {
+				content = queryTemplates
+			} else if textCollation, ok := msg.Content["text_collation"].(string); ok {
+				content = textCollation
+			} else if connectionsSchema, ok := msg.Content["connections_schema"].(string); ok {
+				content = connectionsSchema
 			}
 		}
 
@@ -93,7 +101,7 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 	}
 
 	// Create completion request with JSON schema
-	req := openai.ChatCompletionRequest{
+	return openai.ChatCompletionRequest{
 		Model:               c.model,
 		Messages:            openAIMessages,
 		MaxCompletionTokens: c.maxCompletionTokens,
@@ -108,12 +116,16 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 			},
 		},
 	}
+}
 
+func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
 	// Check if the context is cancelled
 	if ctx.Err() != nil {
 		return "", ctx.Err()
 	}
 
+	req := c.chatCompletionRequest(messages, dbType, pageSize(ctx))
+
 	// Call OpenAI API
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
@@ -135,6 +147,51 @@ func (c *OpenAIClient) GenerateResponse(ctx context.Context, messages []*models.
 	return resp.Choices[0].Message.Content, nil
 }
 
+// GenerateResponseStream streams the response, the assistantMessage is passed to onMessage as its tokens arrive &
+// the complete response is returned once validated like GenerateResponse
+func (c *OpenAIClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	req := c.chatCompletionRequest(messages, dbType, pageSize(ctx))
+	req.Stream = true
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		log.Printf("GenerateResponseStream -> err: %v", err)
+		return "", fmt.Errorf("OpenAI API error: %v", err)
+	}
+	defer stream.Close()
+
+	message := newAssistantMessageStream(onMessage)
+	var content strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("GenerateResponseStream -> err: %v", err)
+			return "", fmt.Errorf("OpenAI API error: %v", err)
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		content.WriteString(resp.Choices[0].Delta.Content)
+		message.Write(resp.Choices[0].Delta.Content)
+	}
+
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	// Validate response against schema
+	var llmResponse constants.LLMResponse
+	if err := json.Unmarshal([]byte(content.String()), &llmResponse); err != nil {
+		return "", fmt.Errorf("invalid response format: %v", err)
+	}
+	return content.String(), nil
+}
+
 func (c *OpenAIClient) GetModelInfo() ModelInfo {
 	return ModelInfo{
 		Name:                c.model,
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"databot-ai/internal/constants"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+type pageSizeKey struct{}
+
+// WithPageSize sets the page size of the paginated queries generated by the calls made with the context, it is
+// written in the system prompt & response schema in place of constants.PageSizePlaceholder
+func WithPageSize(ctx context.Context, pageSize int) context.Context {
+	return context.WithValue(ctx, pageSizeKey{}, pageSize)
+}
+
+// pageSize returns the page size set on the context, constants.DefaultPageSize if there is none
+func pageSize(ctx context.Context) int {
+	size, _ := ctx.Value(pageSizeKey{}).(int)
+	return constants.ResolvePageSize(size)
+}
+
+// renderGeminiSchemaPageSize copies a Gemini response schema with the page size written in its descriptions, the
+// schema of the config is shared by every call
+func renderGeminiSchemaPageSize(schema *genai.Schema, pageSize int) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	rendered := *schema
+	rendered.Description = constants.RenderPageSize(schema.Description, pageSize)
+	rendered.Items = renderGeminiSchemaPageSize(schema.Items, pageSize)
+	if schema.Properties != nil {
+		rendered.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			rendered.Properties[name] = renderGeminiSchemaPageSize(property, pageSize)
+		}
+	}
+	return &rendered
+}
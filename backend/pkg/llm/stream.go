@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// assistantMessageKeyRegex matches the start of the assistantMessage of a JSON response up to the opening quote of its
+// value, a key escaped inside another string (ex: a query) is preceded by a backslash & doesn't match
+var assistantMessageKeyRegex = regexp.MustCompile(`"assistantMessage"\s*:\s*"`)
+
+// assistantMessageStream extracts the assistantMessage of a JSON response while it is streamed, the decoded text is
+// passed to onMessage as it arrives & the rest of the JSON (ex: the queries) is never emitted
+type assistantMessageStream struct {
+	raw       []byte
+	offset    int // Offset in raw of the next character of the message to decode, 0 until the key is found
+	done      bool
+	onMessage func(string)
+}
+
+func newAssistantMessageStream(onMessage func(string)) *assistantMessageStream {
+	return &assistantMessageStream{onMessage: onMessage}
+}
+
+// Write adds a part of the JSON response, the complete characters of the message it holds are emitted. An escape
+// sequence or a UTF-8 character split over parts is emitted with the next part.
+func (s *assistantMessageStream) Write(delta string) {
+	if s.done || delta == "" {
+		return
+	}
+	s.raw = append(s.raw, delta...)
+	if s.offset == 0 {
+		loc := assistantMessageKeyRegex.FindIndex(s.raw)
+		if loc == nil {
+			return
+		}
+		s.offset = loc[1]
+	}
+
+	end := s.offset
+	for end < len(s.raw) {
+		c := s.raw[end]
+		if c == '"' {
+			s.done = true
+			break
+		}
+		if c != '\\' {
+			end++
+			continue
+		}
+		if end+1 >= len(s.raw) {
+			break
+		}
+		if s.raw[end+1] != 'u' {
+			end += 2
+			continue
+		}
+		if end+6 > len(s.raw) {
+			break
+		}
+		// A high surrogate is decoded with the low surrogate following it
+		if r, err := strconv.ParseUint(string(s.raw[end+2:end+6]), 16, 16); err == nil && r >= 0xD800 && r < 0xDC00 {
+			if end+12 > len(s.raw) {
+				break
+			}
+			if s.raw[end+6] == '\\' && s.raw[end+7] == 'u' {
+				end += 12
+				continue
+			}
+		}
+		end += 6
+	}
+	for !s.done && end > s.offset && !utf8.Valid(s.raw[s.offset:end]) {
+		end--
+	}
+	if end == s.offset {
+		return
+	}
+
+	quoted := make([]byte, 0, end-s.offset+2)
+	quoted = append(quoted, '"')
+	quoted = append(quoted, s.raw[s.offset:end]...)
+	quoted = append(quoted, '"')
+	var text string
+	if err := json.Unmarshal(quoted, &text); err != nil {
+		return
+	}
+	s.offset = end
+	s.onMessage(text)
+}
+
+// generateWithoutStreaming serves GenerateResponseStream for the providers whose response isn't streamed, the
+// assistantMessage is emitted at once when the response arrives
+func generateWithoutStreaming(ctx context.Context, client Client, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	response, err := client.GenerateResponse(ctx, messages, dbType)
+	if err != nil {
+		return "", err
+	}
+	newAssistantMessageStream(onMessage).Write(response)
+	return response, nil
+}
@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"databot-ai/internal/models"
+	"sync"
+)
+
+// TenantConfig holds the LLM settings of a tenant of a deployment shared by organizations
+type TenantConfig struct {
+	APIKeys            map[string]string // API key of each provider (ex: "openai"), the providers without one use the key of the client
+	MaxConcurrentCalls int               // Calls of the tenant running at the same time, 0 only applies SetMaxConcurrentCalls
+}
+
+type tenantKey struct{}
+
+// WithTenant makes the calls made with the context use the API keys, limits & quota of a tenant, see SetTenants &
+// SetCallQuota. An empty tenantID calls the client as is.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func tenantOf(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// tenant holds the clients created with the API keys of a tenant & the limiter of its calls
+type tenant struct {
+	config  TenantConfig
+	limiter *callLimiter // nil when the calls of the tenant aren't limited
+	mu      sync.Mutex
+	clients map[string]Client // Keyed by the name of the client they are created from
+}
+
+func (t *tenant) removeClient(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.clients, name)
+}
+
+// SetTenants sets the API keys & limits of the tenants, replacing the former ones. Only the clients returned by
+// GetClient afterwards resolve the tenant of their calls.
+func (m *Manager) SetTenants(tenants map[string]TenantConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tenants = make(map[string]*tenant, len(tenants))
+	for id, config := range tenants {
+		t := &tenant{config: config, clients: make(map[string]Client)}
+		if config.MaxConcurrentCalls > 0 {
+			t.limiter = newCallLimiter(config.MaxConcurrentCalls)
+		}
+		m.tenants[id] = t
+	}
+}
+
+// SetCallQuota sets a function called before each call made for a tenant, an error rejects the call (ex: the daily
+// quota of the tenant is used). Only the clients returned by GetClient afterwards check the quota.
+func (m *Manager) SetCallQuota(check func(tenantID string) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callQuota = check
+}
+
+// tenantClient resolves the tenant of the context of each call: the call is counted against the quota of the tenant,
+// waits for a slot of its limiter & is made with its API key
+type tenantClient struct {
+	Client
+	name    string
+	manager *Manager
+}
+
+func (c *tenantClient) GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error) {
+	client, release, err := c.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return client.GenerateResponse(ctx, messages, dbType)
+}
+
+func (c *tenantClient) GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error) {
+	client, release, err := c.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return client.GenerateResponseStream(ctx, messages, dbType, onMessage)
+}
+
+// resolve returns the client to call for the tenant of the context & the function releasing the slot of the tenant
+func (c *tenantClient) resolve(ctx context.Context) (Client, func(), error) {
+	noRelease := func() {}
+	tenantID := tenantOf(ctx)
+	if tenantID == "" {
+		return c.Client, noRelease, nil
+	}
+
+	c.manager.mu.RLock()
+	t := c.manager.tenants[tenantID]
+	callQuota := c.manager.callQuota
+	c.manager.mu.RUnlock()
+
+	if callQuota != nil {
+		if err := callQuota(tenantID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if t == nil {
+		return c.Client, noRelease, nil
+	}
+
+	client, err := c.manager.tenantClient(t, c.name, c.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.limiter == nil {
+		return client, noRelease, nil
+	}
+	if err := t.limiter.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+	return client, t.limiter.release, nil
+}
+
+// tenantClient returns the client named name created with the API key of the tenant for its provider, created on
+// first use. fallback is returned when the tenant has no API key for the provider.
+func (m *Manager) tenantClient(t *tenant, name string, fallback Client) (Client, error) {
+	m.mu.RLock()
+	config, exists := m.configs[name]
+	limiter := m.limiter
+	m.mu.RUnlock()
+	apiKey := t.config.APIKeys[config.Provider]
+	if !exists || apiKey == "" {
+		return fallback, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if client, exists := t.clients[name]; exists {
+		return client, nil
+	}
+	config.APIKey = apiKey
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		client = &limitedClient{Client: client, limiter: limiter}
+	}
+	t.clients[name] = client
+	return client, nil
+}
@@ -16,6 +16,9 @@ type Message struct {
 // Client defines the interface for LLM interactions
 type Client interface {
 	GenerateResponse(ctx context.Context, messages []*models.LLMMessage, dbType string) (string, error)
+	// GenerateResponseStream generates the same response, its assistantMessage is passed to onMessage piece by piece
+	// as it is generated
+	GenerateResponseStream(ctx context.Context, messages []*models.LLMMessage, dbType string, onMessage func(string)) (string, error)
 	GetModelInfo() ModelInfo
 }
 
@@ -0,0 +1,144 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported object storage providers
+const (
+	ProviderS3  = "s3"
+	ProviderGCS = "gcs"
+
+	gcsEndpoint = "storage.googleapis.com"
+	gcsRegion   = "auto"
+
+	maxPresignExpiry = 7 * 24 * time.Hour // SigV4 presigned URLs can't outlive 7 days
+)
+
+// Config holds the bucket & credentials of an export destination
+// For GCS, AccessKeyID & SecretAccessKey are HMAC keys of a service account (interoperability API)
+type Config struct {
+	Provider        string
+	Bucket          string
+	Region          string
+	Endpoint        string // Optional, for S3 compatible storages such as MinIO or R2
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Client uploads objects & generates signed download URLs using AWS Signature Version 4
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+func NewClient(config Config) (*Client, error) {
+	switch config.Provider {
+	case ProviderS3:
+		if config.Region == "" && config.Endpoint == "" {
+			return nil, fmt.Errorf("region is required for s3")
+		}
+	case ProviderGCS:
+		config.Region = gcsRegion
+		if config.Endpoint == "" {
+			config.Endpoint = gcsEndpoint
+		}
+	default:
+		return nil, fmt.Errorf("unsupported object storage provider: %s", config.Provider)
+	}
+
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("access key id and secret access key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Minute},
+	}, nil
+}
+
+// Upload streams the body to the bucket under the given key, size must be the exact body length
+func (c *Client) Upload(ctx context.Context, key string, contentType string, body io.Reader, size int64) error {
+	objectURL := c.objectURL(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %v", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	c.signRequest(req, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		log.Printf("ObjectStorage -> Upload -> Failed with status %d: %s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("failed to upload object, status: %d, response: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	log.Printf("ObjectStorage -> Upload -> Uploaded %d bytes to %s/%s", size, c.config.Bucket, key)
+	return nil
+}
+
+// PresignGetURL returns a signed URL to download the object without credentials
+func (c *Client) PresignGetURL(key string, expiry time.Duration) (string, error) {
+	if err := ValidatePresignExpiry(expiry); err != nil {
+		return "", err
+	}
+	return c.presign(http.MethodGet, c.objectURL(key), time.Now().UTC(), expiry), nil
+}
+
+// ValidatePresignExpiry checks that a presigned URL expiry is supported, from 1s to 7 days
+func ValidatePresignExpiry(expiry time.Duration) error {
+	if expiry < time.Second || expiry > maxPresignExpiry {
+		return fmt.Errorf("presigned url expiry must be between 1s and %v, got %v", maxPresignExpiry, expiry)
+	}
+	return nil
+}
+
+// objectURL builds the URL of an object, virtual hosted style for AWS and path style for custom endpoints & GCS
+func (c *Client) objectURL(key string) *url.URL {
+	key = strings.TrimPrefix(key, "/")
+
+	var objectURL *url.URL
+	if c.config.Endpoint == "" {
+		objectURL = &url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", c.config.Bucket, c.config.Region),
+			Path:   "/" + key,
+		}
+	} else {
+		scheme, host := "https", c.config.Endpoint
+		if parsed, err := url.Parse(c.config.Endpoint); err == nil && parsed.Host != "" {
+			scheme, host = parsed.Scheme, parsed.Host
+		}
+		objectURL = &url.URL{
+			Scheme: scheme,
+			Host:   host,
+			Path:   "/" + c.config.Bucket + "/" + key,
+		}
+	}
+
+	// Send the path exactly as it is signed
+	objectURL.RawPath = encodePath(objectURL.Path)
+	return objectURL
+}
@@ -0,0 +1,489 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Reader reads parquet files with a flat schema, uncompressed PLAIN encoded data pages v1 & at most one definition
+// level, which covers the files written by Writer. It is used to verify exports before they are handed out.
+type Reader struct {
+	r         io.ReaderAt
+	columns   []Column
+	required  []bool // REQUIRED columns have no definition levels
+	numRows   int64
+	rowGroups []readerRowGroup
+}
+
+type readerRowGroup struct {
+	numRows int64
+	chunks  []readerChunk
+}
+
+type readerChunk struct {
+	physicalType int32
+	codec        int32
+	numValues    int64
+	offset       int64
+	size         int64
+}
+
+type schemaElement struct {
+	physicalType  int32
+	convertedType int32
+	repetition    int32
+	name          string
+	numChildren   int32
+}
+
+// NewReader validates the magic bytes & parses the footer of a parquet file of the given size
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, fmt.Errorf("file is too small to be a parquet file")
+	}
+
+	header := make([]byte, len(magic))
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read parquet header: %v", err)
+	}
+	trailer := make([]byte, 4+len(magic))
+	if _, err := r.ReadAt(trailer, size-int64(len(trailer))); err != nil {
+		return nil, fmt.Errorf("failed to read parquet footer: %v", err)
+	}
+	if string(header) != magic || string(trailer[4:]) != magic {
+		return nil, fmt.Errorf("missing parquet magic bytes")
+	}
+
+	footerLength := int64(binary.LittleEndian.Uint32(trailer[:4]))
+	footerOffset := size - int64(len(trailer)) - footerLength
+	if footerOffset < int64(len(magic)) {
+		return nil, fmt.Errorf("invalid parquet footer length: %d", footerLength)
+	}
+	footer := make([]byte, footerLength)
+	if _, err := r.ReadAt(footer, footerOffset); err != nil {
+		return nil, fmt.Errorf("failed to read parquet footer: %v", err)
+	}
+
+	reader := &Reader{r: r}
+	if err := reader.parseFileMetadata(footer); err != nil {
+		return nil, fmt.Errorf("invalid parquet footer: %v", err)
+	}
+	return reader, nil
+}
+
+// Columns returns the columns of the file in schema order
+func (r *Reader) Columns() []Column {
+	return r.columns
+}
+
+// NumRows returns the number of rows recorded in the footer
+func (r *Reader) NumRows() int64 {
+	return r.numRows
+}
+
+// NumRowGroups returns the number of row groups of the file
+func (r *Reader) NumRowGroups() int {
+	return len(r.rowGroups)
+}
+
+// ReadRowGroup decodes a row group, values use the same Go types the Writer accepts: string, bool, int64, float64
+// & time.Time (UTC), nulls are nil & JSON columns are returned as their encoded string
+func (r *Reader) ReadRowGroup(index int) ([][]interface{}, error) {
+	if index < 0 || index >= len(r.rowGroups) {
+		return nil, fmt.Errorf("row group %d out of range", index)
+	}
+	group := r.rowGroups[index]
+
+	rows := make([][]interface{}, group.numRows)
+	for i := range rows {
+		rows[i] = make([]interface{}, len(r.columns))
+	}
+	for i, chunk := range group.chunks {
+		values, err := r.readChunk(i, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", r.columns[i].Name, err)
+		}
+		if int64(len(values)) != group.numRows {
+			return nil, fmt.Errorf("column %s: expected %d values, got %d", r.columns[i].Name, group.numRows, len(values))
+		}
+		for row, value := range values {
+			rows[row][i] = value
+		}
+	}
+	return rows, nil
+}
+
+func (r *Reader) parseFileMetadata(footer []byte) error {
+	cr := &compactReader{data: footer}
+	var schema []schemaElement
+
+	cr.structBegin()
+	for {
+		id, fieldType := cr.fieldBegin()
+		if fieldType == thriftStop {
+			break
+		}
+		switch {
+		case id == 2 && fieldType == thriftList:
+			_, size := cr.listBegin()
+			for i := 0; i < size && cr.err == nil; i++ {
+				schema = append(schema, readSchemaElement(cr))
+			}
+		case id == 3 && fieldType == thriftI64:
+			r.numRows = cr.readZigzag()
+		case id == 4 && fieldType == thriftList:
+			_, size := cr.listBegin()
+			for i := 0; i < size && cr.err == nil; i++ {
+				r.rowGroups = append(r.rowGroups, readRowGroup(cr))
+			}
+		default:
+			cr.skip(fieldType, false)
+		}
+	}
+	cr.structEnd()
+	if cr.err != nil {
+		return cr.err
+	}
+
+	if len(schema) < 2 || int(schema[0].numChildren) != len(schema)-1 {
+		return fmt.Errorf("only flat schemas with at least one column are supported")
+	}
+	for _, element := range schema[1:] {
+		if element.numChildren > 0 {
+			return fmt.Errorf("nested column %s is not supported", element.name)
+		}
+		columnType, err := columnTypeOf(element.physicalType, element.convertedType)
+		if err != nil {
+			return fmt.Errorf("column %s: %v", element.name, err)
+		}
+		r.columns = append(r.columns, Column{Name: element.name, Type: columnType})
+		r.required = append(r.required, element.repetition == 0)
+	}
+
+	var totalRows int64
+	for i, group := range r.rowGroups {
+		if len(group.chunks) != len(r.columns) {
+			return fmt.Errorf("row group %d has %d columns, expected %d", i, len(group.chunks), len(r.columns))
+		}
+		for j, chunk := range group.chunks {
+			if physicalType, _ := r.columns[j].Type.parquetTypes(); chunk.physicalType != physicalType {
+				return fmt.Errorf("row group %d: column %s has physical type %d, expected %d", i, r.columns[j].Name, chunk.physicalType, physicalType)
+			}
+		}
+		totalRows += group.numRows
+	}
+	if totalRows != r.numRows {
+		return fmt.Errorf("row groups hold %d rows, footer records %d", totalRows, r.numRows)
+	}
+	return nil
+}
+
+func readSchemaElement(cr *compactReader) schemaElement {
+	element := schemaElement{physicalType: -1, convertedType: -1}
+	cr.structBegin()
+	for {
+		id, fieldType := cr.fieldBegin()
+		if fieldType == thriftStop {
+			break
+		}
+		switch {
+		case id == 1 && fieldType == thriftI32:
+			element.physicalType = int32(cr.readZigzag())
+		case id == 3 && fieldType == thriftI32:
+			element.repetition = int32(cr.readZigzag())
+		case id == 4 && fieldType == thriftBinary:
+			element.name = cr.readString()
+		case id == 5 && fieldType == thriftI32:
+			element.numChildren = int32(cr.readZigzag())
+		case id == 6 && fieldType == thriftI32:
+			element.convertedType = int32(cr.readZigzag())
+		default:
+			cr.skip(fieldType, false)
+		}
+	}
+	cr.structEnd()
+	return element
+}
+
+func readRowGroup(cr *compactReader) readerRowGroup {
+	var group readerRowGroup
+	cr.structBegin()
+	for {
+		id, fieldType := cr.fieldBegin()
+		if fieldType == thriftStop {
+			break
+		}
+		switch {
+		case id == 1 && fieldType == thriftList:
+			_, size := cr.listBegin()
+			for i := 0; i < size && cr.err == nil; i++ {
+				group.chunks = append(group.chunks, readColumnChunk(cr))
+			}
+		case id == 3 && fieldType == thriftI64:
+			group.numRows = cr.readZigzag()
+		default:
+			cr.skip(fieldType, false)
+		}
+	}
+	cr.structEnd()
+	return group
+}
+
+func readColumnChunk(cr *compactReader) readerChunk {
+	var chunk readerChunk
+	cr.structBegin()
+	for {
+		id, fieldType := cr.fieldBegin()
+		if fieldType == thriftStop {
+			break
+		}
+		if id != 3 || fieldType != thriftStruct {
+			cr.skip(fieldType, false)
+			continue
+		}
+
+		// ColumnMetaData
+		cr.structBegin()
+		for {
+			id, fieldType := cr.fieldBegin()
+			if fieldType == thriftStop {
+				break
+			}
+			switch {
+			case id == 1 && fieldType == thriftI32:
+				chunk.physicalType = int32(cr.readZigzag())
+			case id == 4 && fieldType == thriftI32:
+				chunk.codec = int32(cr.readZigzag())
+			case id == 5 && fieldType == thriftI64:
+				chunk.numValues = cr.readZigzag()
+			case id == 7 && fieldType == thriftI64:
+				chunk.size = cr.readZigzag()
+			case id == 9 && fieldType == thriftI64:
+				chunk.offset = cr.readZigzag()
+			case id == 11 && fieldType == thriftI64:
+				// dictionary_page_offset, dictionary encoding isn't supported
+				cr.readZigzag()
+				cr.fail("dictionary encoded columns are not supported")
+			default:
+				cr.skip(fieldType, false)
+			}
+		}
+		cr.structEnd()
+	}
+	cr.structEnd()
+	return chunk
+}
+
+// readChunk reads the data pages of a column chunk until all its values are decoded
+func (r *Reader) readChunk(column int, chunk readerChunk) ([]interface{}, error) {
+	if chunk.codec != codecUncompressed {
+		return nil, fmt.Errorf("compression codec %d is not supported", chunk.codec)
+	}
+	if chunk.size < 0 || chunk.size > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid column chunk size: %d", chunk.size)
+	}
+	data := make([]byte, chunk.size)
+	if _, err := r.r.ReadAt(data, chunk.offset); err != nil {
+		return nil, fmt.Errorf("failed to read column chunk: %v", err)
+	}
+
+	values := make([]interface{}, 0, chunk.numValues)
+	pos := 0
+	for int64(len(values)) < chunk.numValues {
+		cr := &compactReader{data: data, pos: pos}
+		pageType, pageSize, numValues, encoding := readPageHeader(cr)
+		if cr.err != nil {
+			return nil, fmt.Errorf("invalid page header: %v", cr.err)
+		}
+		if pageType != pageTypeData {
+			return nil, fmt.Errorf("page type %d is not supported", pageType)
+		}
+		if encoding != encodingPlain {
+			return nil, fmt.Errorf("encoding %d is not supported", encoding)
+		}
+		if pageSize < 0 || pageSize > len(data)-cr.pos {
+			return nil, fmt.Errorf("page of %d bytes exceeds the column chunk", pageSize)
+		}
+
+		page := data[cr.pos : cr.pos+pageSize]
+		pageValues, err := r.decodePage(column, page, numValues)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, pageValues...)
+		pos = cr.pos + pageSize
+	}
+	return values, nil
+}
+
+// readPageHeader returns the type, compressed size, number of values & value encoding of a data page
+func readPageHeader(cr *compactReader) (int32, int, int, int32) {
+	pageType, pageSize, numValues, encoding := int32(-1), 0, 0, int32(-1)
+	cr.structBegin()
+	for {
+		id, fieldType := cr.fieldBegin()
+		if fieldType == thriftStop {
+			break
+		}
+		switch {
+		case id == 1 && fieldType == thriftI32:
+			pageType = int32(cr.readZigzag())
+		case id == 3 && fieldType == thriftI32:
+			pageSize = int(cr.readZigzag())
+		case id == 5 && fieldType == thriftStruct:
+			cr.structBegin()
+			for {
+				id, fieldType := cr.fieldBegin()
+				if fieldType == thriftStop {
+					break
+				}
+				switch {
+				case id == 1 && fieldType == thriftI32:
+					numValues = int(cr.readZigzag())
+				case id == 2 && fieldType == thriftI32:
+					encoding = int32(cr.readZigzag())
+				default:
+					cr.skip(fieldType, false)
+				}
+			}
+			cr.structEnd()
+		default:
+			cr.skip(fieldType, false)
+		}
+	}
+	cr.structEnd()
+	return pageType, pageSize, numValues, encoding
+}
+
+func (r *Reader) decodePage(column int, page []byte, numValues int) ([]interface{}, error) {
+	if numValues < 0 {
+		return nil, fmt.Errorf("invalid number of values: %d", numValues)
+	}
+
+	defined := make([]bool, numValues)
+	if r.required[column] {
+		for i := range defined {
+			defined[i] = true
+		}
+	} else {
+		if len(page) < 4 {
+			return nil, fmt.Errorf("missing definition levels")
+		}
+		length := int(binary.LittleEndian.Uint32(page[:4]))
+		if length > len(page)-4 {
+			return nil, fmt.Errorf("definition levels exceed the page")
+		}
+		if err := decodeDefinitionLevels(page[4:4+length], defined); err != nil {
+			return nil, err
+		}
+		page = page[4+length:]
+	}
+
+	columnType := r.columns[column].Type
+	values := make([]interface{}, numValues)
+	buf := bytes.NewReader(page)
+	var boolIndex int
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+
+		switch columnType {
+		case ColumnTypeBoolean:
+			if boolIndex/8 >= len(page) {
+				return nil, fmt.Errorf("unexpected end of page")
+			}
+			values[i] = page[boolIndex/8]&(1<<(boolIndex%8)) != 0
+			boolIndex++
+		case ColumnTypeInt64, ColumnTypeTimestamp:
+			var v int64
+			if err := binary.Read(buf, binary.LittleEndian, &v); err != nil {
+				return nil, fmt.Errorf("unexpected end of page")
+			}
+			if columnType == ColumnTypeTimestamp {
+				values[i] = time.UnixMilli(v).UTC()
+			} else {
+				values[i] = v
+			}
+		case ColumnTypeDouble:
+			var bits uint64
+			if err := binary.Read(buf, binary.LittleEndian, &bits); err != nil {
+				return nil, fmt.Errorf("unexpected end of page")
+			}
+			values[i] = math.Float64frombits(bits)
+		default:
+			var length uint32
+			if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
+				return nil, fmt.Errorf("unexpected end of page")
+			}
+			if int64(length) > int64(buf.Len()) {
+				return nil, fmt.Errorf("byte array exceeds the page")
+			}
+			value := make([]byte, length)
+			buf.Read(value)
+			values[i] = string(value)
+		}
+	}
+	return values, nil
+}
+
+// decodeDefinitionLevels decodes RLE/bit packed hybrid runs of bit width 1 into defined
+func decodeDefinitionLevels(data []byte, defined []bool) error {
+	pos, index := 0, 0
+	for index < len(defined) {
+		header, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return fmt.Errorf("invalid definition levels")
+		}
+		pos += n
+
+		if header&1 == 1 {
+			// Bit packed run of groups of 8 values
+			count := int(header>>1) * 8
+			if (count+7)/8 > len(data)-pos {
+				return fmt.Errorf("definition levels run exceeds the data")
+			}
+			for i := 0; i < count && index < len(defined); i++ {
+				defined[index] = data[pos+i/8]&(1<<(i%8)) != 0
+				index++
+			}
+			pos += (count + 7) / 8
+		} else {
+			// RLE run, the repeated value takes a single byte for bit width 1
+			count := int(header >> 1)
+			if pos >= len(data) {
+				return fmt.Errorf("definition levels run exceeds the data")
+			}
+			value := data[pos] != 0
+			pos++
+			for i := 0; i < count && index < len(defined); i++ {
+				defined[index] = value
+				index++
+			}
+		}
+	}
+	return nil
+}
+
+// columnTypeOf maps the physical & converted types of a column back to the column types of Writer
+func columnTypeOf(physicalType, convertedType int32) (ColumnType, error) {
+	switch {
+	case physicalType == physicalBoolean:
+		return ColumnTypeBoolean, nil
+	case physicalType == physicalInt64 && convertedType == convertedTimestampMillis:
+		return ColumnTypeTimestamp, nil
+	case physicalType == physicalInt64 && convertedType < 0:
+		return ColumnTypeInt64, nil
+	case physicalType == physicalDouble:
+		return ColumnTypeDouble, nil
+	case physicalType == physicalByteArray && convertedType == convertedJSON:
+		return ColumnTypeJSON, nil
+	case physicalType == physicalByteArray:
+		return ColumnTypeString, nil
+	}
+	return 0, fmt.Errorf("unsupported physical type %d with converted type %d", physicalType, convertedType)
+}
@@ -0,0 +1,255 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Thrift compact protocol types, used by the parquet file & page metadata
+const (
+	thriftStop      = 0
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+)
+
+// compactWriter is a minimal thrift compact protocol encoder, it only supports what parquet metadata needs
+type compactWriter struct {
+	buf        bytes.Buffer
+	lastFields []int16 // Last written field id of every open struct, field ids are delta encoded
+}
+
+func (w *compactWriter) structBegin() {
+	w.lastFields = append(w.lastFields, 0)
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf.WriteByte(0) // STOP
+	w.lastFields = w.lastFields[:len(w.lastFields)-1]
+}
+
+func (w *compactWriter) fieldBegin(id int16, fieldType byte) {
+	last := w.lastFields[len(w.lastFields)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buf.WriteByte(fieldType)
+		w.writeZigzag(int64(id))
+	}
+	w.lastFields[len(w.lastFields)-1] = id
+}
+
+func (w *compactWriter) i32Field(id int16, value int32) {
+	w.fieldBegin(id, thriftI32)
+	w.writeZigzag(int64(value))
+}
+
+func (w *compactWriter) i64Field(id int16, value int64) {
+	w.fieldBegin(id, thriftI64)
+	w.writeZigzag(value)
+}
+
+func (w *compactWriter) stringField(id int16, value string) {
+	w.fieldBegin(id, thriftBinary)
+	w.writeString(value)
+}
+
+// structField opens a nested struct field, it must be closed with structEnd
+func (w *compactWriter) structField(id int16) {
+	w.fieldBegin(id, thriftStruct)
+	w.structBegin()
+}
+
+// listField writes a list header, the elements must be written right after
+func (w *compactWriter) listField(id int16, elemType byte, size int) {
+	w.fieldBegin(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xF0 | elemType)
+		w.writeVarint(uint64(size))
+	}
+}
+
+func (w *compactWriter) writeString(value string) {
+	w.writeVarint(uint64(len(value)))
+	w.buf.WriteString(value)
+}
+
+func (w *compactWriter) writeZigzag(value int64) {
+	w.writeVarint(uint64((value << 1) ^ (value >> 63)))
+}
+
+func (w *compactWriter) writeVarint(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], value)
+	w.buf.Write(scratch[:n])
+}
+
+// compactReader is a minimal thrift compact protocol decoder, fields the reader doesn't need are skipped.
+// The first decoding error is kept in err & every later read returns zero values.
+type compactReader struct {
+	data       []byte
+	pos        int
+	lastFields []int16
+	err        error
+}
+
+func (r *compactReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *compactReader) readByte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of thrift data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) readVarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("invalid thrift varint at offset %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return value
+}
+
+func (r *compactReader) readZigzag() int64 {
+	value := r.readVarint()
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (r *compactReader) readBytes() []byte {
+	length := r.readVarint()
+	if r.err != nil {
+		return nil
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		r.fail("thrift binary of %d bytes exceeds the data", length)
+		return nil
+	}
+	value := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return value
+}
+
+func (r *compactReader) readString() string {
+	return string(r.readBytes())
+}
+
+func (r *compactReader) structBegin() {
+	r.lastFields = append(r.lastFields, 0)
+}
+
+func (r *compactReader) structEnd() {
+	r.lastFields = r.lastFields[:len(r.lastFields)-1]
+}
+
+// fieldBegin returns the id & type of the next field of the current struct, thriftStop marks the end of the struct.
+// Boolean fields carry their value in the type (thriftBoolTrue or thriftBoolFalse).
+func (r *compactReader) fieldBegin() (int16, byte) {
+	header := r.readByte()
+	fieldType := header & 0x0F
+	if fieldType == thriftStop || r.err != nil {
+		return 0, thriftStop
+	}
+
+	id := r.lastFields[len(r.lastFields)-1]
+	if delta := int16(header >> 4); delta != 0 {
+		id += delta
+	} else {
+		id = int16(r.readZigzag())
+	}
+	r.lastFields[len(r.lastFields)-1] = id
+	return id, fieldType
+}
+
+// listBegin returns the element type & size of a list or set
+func (r *compactReader) listBegin() (byte, int) {
+	header := r.readByte()
+	size := int(header >> 4)
+	if size == 15 {
+		size = int(r.readVarint())
+	}
+	if size < 0 || size > len(r.data)-r.pos {
+		// Every element takes at least one byte, bigger sizes can only come from corrupt data
+		r.fail("invalid thrift list size %d", size)
+		return thriftStop, 0
+	}
+	return header & 0x0F, size
+}
+
+// skip discards a value of the given type, list elements are skipped with inList set as their booleans take a byte
+func (r *compactReader) skip(valueType byte, inList bool) {
+	if r.err != nil {
+		return
+	}
+	switch valueType {
+	case thriftBoolTrue, thriftBoolFalse:
+		if inList {
+			r.readByte()
+		}
+	case thriftByte:
+		r.readByte()
+	case thriftI16, thriftI32, thriftI64:
+		r.readVarint()
+	case thriftDouble:
+		if len(r.data)-r.pos < 8 {
+			r.fail("unexpected end of thrift data")
+			return
+		}
+		r.pos += 8
+	case thriftBinary:
+		r.readBytes()
+	case thriftList, thriftSet:
+		elemType, size := r.listBegin()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(elemType, true)
+		}
+	case thriftMap:
+		size := int(r.readVarint())
+		if size == 0 {
+			return
+		}
+		types := r.readByte()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(types>>4, true)
+			r.skip(types&0x0F, true)
+		}
+	case thriftStruct:
+		r.structBegin()
+		for {
+			_, fieldType := r.fieldBegin()
+			if fieldType == thriftStop {
+				break
+			}
+			r.skip(fieldType, false)
+		}
+		r.structEnd()
+	default:
+		r.fail("unknown thrift type %d", valueType)
+	}
+}
@@ -0,0 +1,483 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ColumnType is the logical type of a column, every column is written as OPTIONAL so that nulls are supported
+type ColumnType int
+
+const (
+	ColumnTypeString    ColumnType = iota // BYTE_ARRAY (UTF8)
+	ColumnTypeBoolean                     // BOOLEAN
+	ColumnTypeInt64                       // INT64
+	ColumnTypeDouble                      // DOUBLE
+	ColumnTypeTimestamp                   // INT64 (TIMESTAMP_MILLIS)
+	ColumnTypeJSON                        // BYTE_ARRAY (JSON), used for nested documents & arrays
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnTypeBoolean:
+		return "boolean"
+	case ColumnTypeInt64:
+		return "int64"
+	case ColumnTypeDouble:
+		return "double"
+	case ColumnTypeTimestamp:
+		return "timestamp"
+	case ColumnTypeJSON:
+		return "json"
+	default:
+		return "string"
+	}
+}
+
+// Parquet physical types, converted types & encodings (see parquet.thrift)
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+	convertedJSON            = 19
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+
+	magic     = "PAR1"
+	createdBy = "databot"
+
+	// DefaultRowGroupSize is the number of rows buffered in memory before a row group is flushed
+	DefaultRowGroupSize = 10000
+)
+
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Writer writes rows to a parquet file, rows are buffered per row group & flushed to the underlying writer
+// once the row group is full, so memory usage is bounded by the row group size and not the file size.
+// Pages are PLAIN encoded & uncompressed to keep the writer dependency free.
+type Writer struct {
+	w            *countingWriter
+	columns      []Column
+	rowGroupSize int
+	buffers      []*columnBuffer
+	bufferedRows int
+	numRows      int64
+	rowGroups    []rowGroupMeta
+	closed       bool
+}
+
+type columnBuffer struct {
+	defined []bool // Definition level of every row, false means null
+	values  bytes.Buffer
+	bools   []bool // Boolean values are bit packed when the page is flushed
+}
+
+type rowGroupMeta struct {
+	columns       []columnChunkMeta
+	totalByteSize int64
+	numRows       int64
+}
+
+type columnChunkMeta struct {
+	dataPageOffset int64
+	totalSize      int64
+	numValues      int64
+}
+
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	return n, err
+}
+
+// NewWriter writes the parquet header & returns a writer for the given columns
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one column is required")
+	}
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if column.Name == "" {
+			return nil, fmt.Errorf("column name can't be empty")
+		}
+		if seen[column.Name] {
+			return nil, fmt.Errorf("duplicate column name: %s", column.Name)
+		}
+		seen[column.Name] = true
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+
+	writer := &Writer{
+		w:            &countingWriter{w: w},
+		columns:      columns,
+		rowGroupSize: rowGroupSize,
+		buffers:      make([]*columnBuffer, len(columns)),
+	}
+	for i := range writer.buffers {
+		writer.buffers[i] = &columnBuffer{}
+	}
+
+	if _, err := io.WriteString(writer.w, magic); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// WriteRow appends a row, values must be in the column order & nil values are written as nulls
+func (w *Writer) WriteRow(values []interface{}) error {
+	if w.closed {
+		return fmt.Errorf("parquet writer is closed")
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(values))
+	}
+
+	for i, value := range values {
+		if err := w.buffers[i].append(w.columns[i], value); err != nil {
+			return err
+		}
+	}
+
+	w.bufferedRows++
+	if w.bufferedRows >= w.rowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+// Close flushes the last row group & writes the file footer, it doesn't close the underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.bufferedRows > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	footer := w.fileMetadata()
+	if _, err := w.w.Write(footer); err != nil {
+		return err
+	}
+	var footerLength [4]byte
+	binary.LittleEndian.PutUint32(footerLength[:], uint32(len(footer)))
+	if _, err := w.w.Write(footerLength[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, magic)
+	return err
+}
+
+// NumRows returns the number of rows written so far
+func (w *Writer) NumRows() int64 {
+	return w.numRows + int64(w.bufferedRows)
+}
+
+// flushRowGroup writes every buffered column as a single data page & resets the buffers
+func (w *Writer) flushRowGroup() error {
+	group := rowGroupMeta{
+		columns: make([]columnChunkMeta, len(w.columns)),
+		numRows: int64(w.bufferedRows),
+	}
+
+	for i, column := range w.columns {
+		buffer := w.buffers[i]
+
+		pageData := encodeDefinitionLevels(buffer.defined)
+		if column.Type == ColumnTypeBoolean {
+			pageData = append(pageData, packBits(buffer.bools)...)
+		} else {
+			pageData = append(pageData, buffer.values.Bytes()...)
+		}
+		if len(pageData) > math.MaxInt32 {
+			return fmt.Errorf("column %s exceeds the maximum page size, use a smaller row group size", column.Name)
+		}
+
+		header := pageHeader(len(buffer.defined), len(pageData))
+		offset := w.w.written
+		if _, err := w.w.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(pageData); err != nil {
+			return err
+		}
+
+		chunkSize := int64(len(header) + len(pageData))
+		group.columns[i] = columnChunkMeta{
+			dataPageOffset: offset,
+			totalSize:      chunkSize,
+			numValues:      int64(len(buffer.defined)),
+		}
+		group.totalByteSize += chunkSize
+
+		w.buffers[i] = &columnBuffer{}
+	}
+
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += group.numRows
+	w.bufferedRows = 0
+	return nil
+}
+
+func (b *columnBuffer) append(column Column, value interface{}) error {
+	if value == nil {
+		b.defined = append(b.defined, false)
+		return nil
+	}
+
+	switch column.Type {
+	case ColumnTypeBoolean:
+		v, ok := value.(bool)
+		if !ok {
+			return typeMismatch(column, value)
+		}
+		b.bools = append(b.bools, v)
+	case ColumnTypeInt64:
+		v, ok := toInt64(value)
+		if !ok {
+			return typeMismatch(column, value)
+		}
+		binary.Write(&b.values, binary.LittleEndian, v)
+	case ColumnTypeDouble:
+		v, ok := toFloat64(value)
+		if !ok {
+			return typeMismatch(column, value)
+		}
+		binary.Write(&b.values, binary.LittleEndian, math.Float64bits(v))
+	case ColumnTypeTimestamp:
+		var v time.Time
+		switch t := value.(type) {
+		case time.Time:
+			v = t
+		case string:
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return typeMismatch(column, value)
+			}
+			v = parsed
+		default:
+			return typeMismatch(column, value)
+		}
+		binary.Write(&b.values, binary.LittleEndian, v.UnixMilli())
+	case ColumnTypeJSON:
+		var encoded []byte
+		switch v := value.(type) {
+		case string:
+			encoded = []byte(v)
+		case []byte:
+			encoded = v
+		default:
+			var err error
+			if encoded, err = json.Marshal(v); err != nil {
+				return fmt.Errorf("column %s: failed to encode value as json: %v", column.Name, err)
+			}
+		}
+		writeByteArray(&b.values, encoded)
+	default:
+		switch v := value.(type) {
+		case string:
+			writeByteArray(&b.values, []byte(v))
+		case []byte:
+			writeByteArray(&b.values, v)
+		default:
+			writeByteArray(&b.values, []byte(fmt.Sprintf("%v", v)))
+		}
+	}
+
+	b.defined = append(b.defined, true)
+	return nil
+}
+
+func typeMismatch(column Column, value interface{}) error {
+	return fmt.Errorf("column %s: can't write %T as %s", column.Name, value, column.Type)
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// writeByteArray writes a PLAIN encoded BYTE_ARRAY value (4 bytes little endian length followed by the bytes)
+func writeByteArray(buf *bytes.Buffer, value []byte) {
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(value)))
+	buf.Write(length[:])
+	buf.Write(value)
+}
+
+// encodeDefinitionLevels encodes the definition levels with the RLE/bit packed hybrid encoding (bit width 1),
+// prefixed by its length as required by data pages v1. A single bit packed run is used for simplicity.
+func encodeDefinitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+
+	var run bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], uint64(groups)<<1|1)
+	run.Write(scratch[:n])
+	run.Write(packBits(defined))
+
+	encoded := make([]byte, 4, 4+run.Len())
+	binary.LittleEndian.PutUint32(encoded, uint32(run.Len()))
+	return append(encoded, run.Bytes()...)
+}
+
+// packBits packs booleans LSB first, padded to a whole number of bytes
+func packBits(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+func pageHeader(numValues, pageSize int) []byte {
+	var w compactWriter
+	w.structBegin()
+	w.i32Field(1, pageTypeData)
+	w.i32Field(2, int32(pageSize)) // uncompressed_page_size
+	w.i32Field(3, int32(pageSize)) // compressed_page_size
+	w.structField(5)               // data_page_header
+	w.i32Field(1, int32(numValues))
+	w.i32Field(2, encodingPlain)
+	w.i32Field(3, encodingRLE) // definition_level_encoding
+	w.i32Field(4, encodingRLE) // repetition_level_encoding
+	w.structEnd()
+	w.structEnd()
+	return w.buf.Bytes()
+}
+
+func (w *Writer) fileMetadata() []byte {
+	var cw compactWriter
+	cw.structBegin()
+	cw.i32Field(1, 1) // version
+
+	// Schema is flattened depth first, the root element holds the number of columns
+	cw.listField(2, thriftStruct, len(w.columns)+1)
+	cw.structBegin()
+	cw.stringField(4, "schema")
+	cw.i32Field(5, int32(len(w.columns)))
+	cw.structEnd()
+	for _, column := range w.columns {
+		physicalType, convertedType := column.Type.parquetTypes()
+		cw.structBegin()
+		cw.i32Field(1, physicalType)
+		cw.i32Field(3, repetitionOptional)
+		cw.stringField(4, column.Name)
+		if convertedType >= 0 {
+			cw.i32Field(6, convertedType)
+		}
+		cw.structEnd()
+	}
+
+	cw.i64Field(3, w.numRows)
+
+	cw.listField(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		cw.structBegin()
+		cw.listField(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			physicalType, _ := w.columns[i].Type.parquetTypes()
+			cw.structBegin()
+			cw.i64Field(2, chunk.dataPageOffset) // file_offset
+			cw.structField(3)                    // meta_data
+			cw.i32Field(1, physicalType)
+			cw.listField(2, thriftI32, 2)
+			cw.writeZigzag(encodingPlain)
+			cw.writeZigzag(encodingRLE)
+			cw.listField(3, thriftBinary, 1)
+			cw.writeString(w.columns[i].Name)
+			cw.i32Field(4, codecUncompressed)
+			cw.i64Field(5, chunk.numValues)
+			cw.i64Field(6, chunk.totalSize) // total_uncompressed_size
+			cw.i64Field(7, chunk.totalSize) // total_compressed_size
+			cw.i64Field(9, chunk.dataPageOffset)
+			cw.structEnd()
+			cw.structEnd()
+		}
+		cw.i64Field(2, group.totalByteSize)
+		cw.i64Field(3, group.numRows)
+		cw.structEnd()
+	}
+
+	cw.stringField(6, createdBy)
+	cw.structEnd()
+	return cw.buf.Bytes()
+}
+
+// parquetTypes returns the physical & converted types of the column, -1 means no converted type
+func (t ColumnType) parquetTypes() (int32, int32) {
+	switch t {
+	case ColumnTypeBoolean:
+		return physicalBoolean, -1
+	case ColumnTypeInt64:
+		return physicalInt64, -1
+	case ColumnTypeDouble:
+		return physicalDouble, -1
+	case ColumnTypeTimestamp:
+		return physicalInt64, convertedTimestampMillis
+	case ColumnTypeJSON:
+		return physicalByteArray, convertedJSON
+	default:
+		return physicalByteArray, convertedUTF8
+	}
+}
@@ -0,0 +1,98 @@
+package parquet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "name", Type: ColumnTypeString},
+		{Name: "active", Type: ColumnTypeBoolean},
+		{Name: "orders", Type: ColumnTypeInt64},
+		{Name: "balance", Type: ColumnTypeDouble},
+		{Name: "created_at", Type: ColumnTypeTimestamp},
+		{Name: "address", Type: ColumnTypeJSON},
+	}
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 123000000, time.UTC)
+
+	var rows [][]interface{}
+	for i := 0; i < 11; i++ {
+		row := []interface{}{"customer", i%2 == 0, int64(i), float64(i) + 0.5, createdAt.Add(time.Duration(i) * time.Hour), `{"city":"Paris"}`}
+		// Every column gets nulls at different rows, so that definition levels of a column can't leak into another
+		row[i%len(columns)] = nil
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	// A row group size that doesn't divide the rows, so that the last row group is partial
+	writer, err := NewWriter(&buf, columns, 4)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if !reflect.DeepEqual(reader.Columns(), columns) {
+		t.Fatalf("columns = %+v, want %+v", reader.Columns(), columns)
+	}
+	if reader.NumRows() != int64(len(rows)) {
+		t.Fatalf("NumRows = %d, want %d", reader.NumRows(), len(rows))
+	}
+	if reader.NumRowGroups() != 3 {
+		t.Fatalf("NumRowGroups = %d, want 3", reader.NumRowGroups())
+	}
+
+	var read [][]interface{}
+	for i := 0; i < reader.NumRowGroups(); i++ {
+		group, err := reader.ReadRowGroup(i)
+		if err != nil {
+			t.Fatalf("ReadRowGroup(%d): %v", i, err)
+		}
+		read = append(read, group...)
+	}
+	if !reflect.DeepEqual(read, rows) {
+		t.Fatalf("rows = %v, want %v", read, rows)
+	}
+}
+
+func TestWriterRejectsTypeMismatch(t *testing.T) {
+	writer, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "orders", Type: ColumnTypeInt64}}, DefaultRowGroupSize)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{1.5}); err == nil {
+		t.Fatal("expected an error when writing a decimal to an INT64 column")
+	}
+}
+
+func TestReaderRejectsTruncatedFile(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, []Column{{Name: "name", Type: ColumnTypeString}}, DefaultRowGroupSize)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{"customer"}); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if _, err := NewReader(bytes.NewReader(truncated), int64(len(truncated))); err == nil {
+		t.Fatal("expected an error for a truncated file")
+	}
+}
@@ -22,6 +22,8 @@ type IRedisRepositories interface {
 	Del(key string, ctx context.Context) error
 	GetAllByField(ctx context.Context, modelType interface{}, filterFunc func(interface{}) bool) ([]interface{}, error)
 	TTL(key string, ctx context.Context) (time.Duration, error)
+	Incr(key string, expiredTime time.Duration, ctx context.Context) (int64, error)
+	TakeToken(key string, capacity int, refillPerSecond float64, ctx context.Context) (bool, time.Duration, error)
 	StartPipeline(ctx context.Context) *Pipeline
 }
 
@@ -130,6 +132,61 @@ func (r *RedisRepositories) TTL(key string, ctx context.Context) (time.Duration,
 	return duration, nil
 }
 
+// Incr increments a counter & sets its expiration when the counter is created, the new value is returned
+func (r *RedisRepositories) Incr(key string, expiredTime time.Duration, ctx context.Context) (int64, error) {
+	count, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := r.Client.Expire(ctx, key, expiredTime).Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// takeTokenScript takes a token from the bucket of KEYS[1] holding ARGV[1] tokens refilled by ARGV[2] tokens per
+// second, ARGV[3] is the current time in milliseconds. It returns 1 & 0 when a token was taken, or 0 & the milliseconds
+// until the next token. The bucket is read & written atomically so that every instance shares it.
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+  tokens = capacity
+  updated = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate / 1000)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tokens, "updated_at", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity * 1000 / rate))
+return {allowed, wait}
+`)
+
+// TakeToken takes a token from a token bucket of capacity tokens refilled at refillPerSecond, a full bucket is created
+// on first use. When the bucket is empty false is returned with the time until a token is available.
+func (r *RedisRepositories) TakeToken(key string, capacity int, refillPerSecond float64, ctx context.Context) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(ctx, r.Client, []string{key}, capacity, refillPerSecond, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, errors.New("unexpected token bucket result")
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
 // Pipeline represents a Redis pipeline
 type Pipeline struct {
 	pipe redis.Pipeliner
@@ -593,6 +593,7 @@ function AppContent() {
       console.log('handleRefreshSchema called');
       const response = await chatService.refreshSchema(selectedConnection?.id || '', controller);
       console.log('handleRefreshSchema response', response);
+      toast.dismiss('schema-refresh-progress');
       if (response) {
         toast.success('Knowledge base refreshed successfully');
       } else {
@@ -600,6 +601,7 @@ function AppContent() {
       }
     } catch (error) {
       console.error('Failed to refresh knowledge base:', error);
+      toast.dismiss('schema-refresh-progress');
       toast.error('Failed to refresh knowledge base ' + error);
     }
   };
@@ -862,6 +864,18 @@ function AppContent() {
               handleConnectionStatusChange(selectedConnection.id, false, 'app-sse-connection');
             }
             break;
+          case 'schema-refresh-progress':
+            // Same toast id, every table updates the progress instead of stacking toasts
+            toast.loading(response.data?.message || 'Refreshing knowledge base...', {
+              id: 'schema-refresh-progress',
+              style: {
+                background: '#000',
+                color: '#fff',
+                borderRadius: '12px',
+                border: '4px solid #000',
+              },
+            });
+            break;
           case 'ai-response-step':
             // Set default of 500 ms delay for first step
             await new Promise(resolve => setTimeout(resolve, 500));
@@ -1183,6 +1197,53 @@ function AppContent() {
     }
   };
 
+  // Asks for alternative queries to the request answered by an AI message, the message is regenerated in place
+  const handleRegenerateQuery = async (id: string) => {
+    if (!selectedConnection?.id || !streamId || isMessageSending) return;
+
+    try {
+      if (eventSource?.readyState !== EventSource.OPEN) {
+        await setupSSEConnection(selectedConnection.id);
+      }
+
+      const response = await axios.post(
+        `${import.meta.env.VITE_API_URL}/chats/${selectedConnection.id}/messages/${id}/regenerate`,
+        {
+          stream_id: streamId
+        },
+        {
+          withCredentials: true,
+          headers: {
+            'Content-Type': 'application/json',
+            'Authorization': `Bearer ${localStorage.getItem('token')}`
+          }
+        }
+      );
+
+      if (response.data.success) {
+        setMessages(prev => prev.map(msg => {
+          if (msg.id === id) {
+            return {
+              ...msg,
+              is_edited: true,
+              content: "",
+              queries: [],
+              action_buttons: [],
+              updated_at: new Date().toISOString(),
+              loading_steps: [{ text: 'DataBot is analyzing your request..', done: false }],
+              is_streaming: true
+            };
+          }
+          return msg;
+        }));
+        setTemporaryMessage(messages.find(msg => msg.id === id) || null);
+      }
+    } catch (error: any) {
+      console.error('Failed to regenerate query:', error);
+      toast.error(error.response?.data?.error || 'Failed to regenerate query', errorToast);
+    }
+  };
+
   // Add function to handle updating selected collections
   const handleUpdateSelectedCollections = async (chatId: string, selectedCollections: string): Promise<void> => {
     let loadingToast: string | null = null;
@@ -1380,6 +1441,7 @@ function AppContent() {
                 onSendMessage={handleSendMessage}
                 onClearChat={handleClearChat}
                 onEditMessage={handleEditMessage}
+                onRegenerateQuery={handleRegenerateQuery}
                 onCloseConnection={handleCloseConnection}
                 onEditConnection={handleEditConnection}
                 onConnectionStatusChange={handleConnectionStatusChange}
@@ -22,6 +22,7 @@ interface ChatWindowProps {
   setMessages: React.Dispatch<React.SetStateAction<Message[]>>;
   onSendMessage: (message: string) => Promise<void>;
   onEditMessage: (id: string, content: string) => void;
+  onRegenerateQuery?: (id: string) => void;
   onClearChat: () => void;
   onCloseConnection: () => void;
   onEditConnection?: (id: string, connection: Connection, settings: ChatSettings) => Promise<{ success: boolean, error?: string }>;
@@ -87,6 +88,7 @@ const groupMessagesByDate = (messages: Message[]) => {
 export default function ChatWindow({
   chat,
   onEditMessage,
+  onRegenerateQuery,
   isExpanded,
   messages,
   setMessages,
@@ -724,6 +726,17 @@ export default function ChatWindow({
       onSendMessage(fixRollbackErrorContent);
     }
 
+  // Asks for a filter on a query whose read of a whole large table was limited
+  const handleAddFilterAction = (message: Message, payload?: Record<string, unknown>) => {
+    const query = message.queries?.find(q => q.id === payload?.query_id);
+    if (!query) {
+      toast.error("Could not find the query to filter");
+      return;
+    }
+
+    onSendMessage(`Add a filter to the query '${query.query}', it reads every row of a large table.`);
+  };
+
   const handleConfirmClearChat = useCallback(async () => {
     // Track chat cleared event
     if (chat?.id) {
@@ -877,15 +890,21 @@ export default function ChatWindow({
                   isFirstMessage={index === 0}
                   onQueryUpdate={handleQueryUpdate}
                   onEditQuery={handleEditQuery}
-                  buttonCallback={(action) => {
+                  buttonCallback={(action, payload) => {
                     if (action === "refresh_schema") {
                       setShowRefreshSchema(true);
+                    } else if (action === "add_filter") {
+                      // Handle add_filter action
+                      handleAddFilterAction(message, payload);
                     } else if (action === "fix_error") {
                       // Handle fix_error action
                       handleFixErrorAction(message);
                     } else if (action === "fix_rollback_error") {
                       // Handle fix_rollback_error action
                       handleFixRollbackErrorAction(message);
+                    } else if (action === "regenerate_query" && onRegenerateQuery) {
+                      // Handle regenerate_query action
+                      onRegenerateQuery(message.id);
                     } else {
                       console.log(`Action not implemented: ${action}`);
                       toast.error(`There is no available action for this button: ${action}`);
@@ -10,6 +10,7 @@ import LoadingSteps from './LoadingSteps';
 import { Message, QueryResult } from './types';
 import MarkdownRenderer from './MarkdownRenderer';
 import { formatActionAt } from '../../utils/message';
+import { transformBackendMessage } from '../../types/messages';
 
 interface QueryState {
     isExecuting: boolean;
@@ -47,7 +48,7 @@ interface MessageTileProps {
     isFirstMessage?: boolean;
     onQueryUpdate: (callback: () => void) => void;
     onEditQuery: (id: string, queryId: string, query: string) => void;
-    buttonCallback?: (action: string) => void;
+    buttonCallback?: (action: string, payload?: Record<string, unknown>) => void;
 }
 
 const toastStyle = {
@@ -277,7 +278,7 @@ export default function MessageTile({
         executeQuery(queryId);
     };
 
-    const executeQuery = async (queryId: string) => {
+    const executeQuery = async (queryId: string, confirmAffectedRows = false) => {
         const query = message.queries?.find(q => q.id === queryId);
         if (!query) return;
 
@@ -304,10 +305,21 @@ export default function MessageTile({
                 message.id,
                 queryId,
                 streamId || '',
-                abortControllerRef.current[queryId]
+                abortControllerRef.current[queryId],
+                confirmAffectedRows
             );
 
             console.log('executeQuery response', response?.success);
+            if (response && !response.success && response.data?.error?.code === 'AFFECTED_ROWS_CONFIRMATION_REQUIRED') {
+                onQueryUpdate(() => {
+                    setMessage({
+                        ...message,
+                        action_buttons: response.data.action_buttons || []
+                    });
+                });
+                toast.error(response.data.error.message);
+                return;
+            }
             if (response?.success) {
 
                 const fullData = parseResults(response.data.execution_result);
@@ -394,6 +406,37 @@ export default function MessageTile({
         }
     };
 
+    const createSuggestedIndex = async (queryId: string, indexId: string) => {
+        try {
+            await checkSSEConnection();
+            const response = await chatService.createSuggestedIndex(chatId, message.id, queryId, indexId, streamId || '');
+            if (response?.success) {
+                const updatedMessage = transformBackendMessage(response.data);
+                onQueryUpdate(() => {
+                    setMessage({
+                        ...message,
+                        queries: updatedMessage.queries,
+                        action_buttons: updatedMessage.action_buttons
+                    });
+                });
+                const createdQueryId = updatedMessage.queries
+                    ?.find(q => q.id === queryId)
+                    ?.suggested_indexes?.find(index => index.id === indexId)?.created_query_id;
+                const indexQuery = updatedMessage.queries?.find(q => q.id === createdQueryId);
+                if (indexQuery?.error) {
+                    toast.error("Index creation failed: " + indexQuery.error.message);
+                } else {
+                    toast('Index created!', {
+                        ...toastStyle,
+                        icon: '✅',
+                    });
+                }
+            }
+        } catch (error: any) {
+            toast.error("Index creation failed: " + error.message);
+        }
+    };
+
     const handleRollback = async (queryId: string) => {
         const queryIndex = message.queries?.findIndex(q => q.id === queryId) ?? -1;
         if (queryIndex === -1) return;
@@ -1287,6 +1330,11 @@ export default function MessageTile({
                                     Edited
                                 </span>
                             )}
+                            {query.connection_name && (
+                                <span className="text-xs bg-blue-500/20 text-blue-300 px-2 py-0.5 rounded">
+                                    {query.connection_name}
+                                </span>
+                            )}
                             {query.is_rolled_back ? (
                                 <span className="text-xs bg-yellow-500/20 text-yellow-300 px-2 py-0.5 rounded">
                                     Rolled Back on {query.action_at != null ? `${formatActionAt(query.action_at)}` : ''}
@@ -2067,8 +2115,15 @@ export default function MessageTile({
                                                 <button
                                                     key={button.id}
                                                     onClick={() => {
-                                                        if (buttonCallback) {
-                                                            buttonCallback(button.action);
+                                                        const payloadQueryId = button.payload?.query_id;
+                                                        if (button.action === "confirm_affected_rows" && typeof payloadQueryId === "string") {
+                                                            // Executes the query again, confirming the rows it changes
+                                                            executeQuery(payloadQueryId, true);
+                                                        } else if (button.action === "create_suggested_index" && typeof payloadQueryId === "string" && typeof button.payload?.index_id === "string") {
+                                                            // Adds the CREATE INDEX statement to the message as a critical query & executes it
+                                                            createSuggestedIndex(payloadQueryId, button.payload.index_id);
+                                                        } else if (buttonCallback) {
+                                                            buttonCallback(button.action, button.payload);
                                                         } else {
                                                             console.log(`Action button clicked: ${button.action}`);
                                                         }
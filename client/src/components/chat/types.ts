@@ -24,6 +24,18 @@ export interface QueryResult {
     is_streaming?: boolean;
     is_edited?: boolean;
     action_at?: string;
+    connection_name?: string;
+    suggested_indexes?: SuggestedIndex[];
+}
+
+// Index suggested for a read query scanning a large table, created by the create_suggested_index action
+export interface SuggestedIndex {
+    id: string;
+    table: string;
+    columns: string[];
+    query: string;
+    reason?: string;
+    created_query_id?: string;
 }
 
 export interface ActionButton {
@@ -31,6 +43,7 @@ export interface ActionButton {
     label: string;
     action: string;
     isPrimary: boolean;
+    payload?: Record<string, unknown>;
 }
 
 export interface Message {
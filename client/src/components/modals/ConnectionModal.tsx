@@ -620,7 +620,13 @@ export default function ConnectionModal({
     const lines = text.split('\n');
 
     lines.forEach(line => {
-      const [key, value] = line.split('=').map(s => s.trim());
+      // Split at the first = only, passwords and URLs can contain = too
+      const separatorIndex = line.indexOf('=');
+      if (separatorIndex === -1) {
+        return;
+      }
+      const key = line.substring(0, separatorIndex).trim();
+      const value = line.substring(separatorIndex + 1).trim();
       switch (key) {
         case 'DATABASE_TYPE':
           if (['postgresql', 'yugabytedb', 'mysql', 'clickhouse', 'mongodb', 'redis', 'neo4j'].includes(value)) {
@@ -69,7 +69,7 @@ const BasicConnectionTab: React.FC<BasicConnectionTabProps> = ({
               { value: 'mysql', label: 'MySQL' },
               { value: 'clickhouse', label: 'ClickHouse' },
               { value: 'mongodb', label: 'MongoDB' },
-              { value: 'cassandra', label: 'Cassandra (Coming Soon)' },
+              { value: 'cassandra', label: 'Cassandra / ScyllaDB' },
               { value: 'redis', label: 'Redis (Coming Soon)' },
               { value: 'neo4j', label: 'Neo4J (Coming Soon)' }
             ].map(option => (
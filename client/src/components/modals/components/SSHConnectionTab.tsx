@@ -192,7 +192,7 @@ const SSHConnectionTab: React.FC<SSHConnectionTabProps> = ({
                     { value: 'mysql', label: 'MySQL' },
                     { value: 'clickhouse', label: 'ClickHouse' },
                     { value: 'mongodb', label: 'MongoDB' },
-                    { value: 'cassandra', label: 'Cassandra (Coming Soon)' },
+                    { value: 'cassandra', label: 'Cassandra / ScyllaDB' },
                     { value: 'redis', label: 'Redis (Coming Soon)' },
                     { value: 'neo4j', label: 'Neo4J (Coming Soon)' }
                   ].map(option => (
@@ -1,5 +1,5 @@
 import { Chat, Connection, TablesResponse, ChatSettings } from '../types/chat';
-import { ExecuteQueryResponse, MessagesResponse, SendMessageResponse } from '../types/messages';
+import { CreateSuggestedIndexResponse, ExecuteQueryResponse, MessagesResponse, SendMessageResponse } from '../types/messages';
 import axios from './axiosConfig';
 
 const API_URL = import.meta.env.VITE_API_URL;
@@ -191,14 +191,15 @@ const chatService = {
         }
     },
 
-    async executeQuery(chatId: string, messageId: string, queryId: string, streamId: string, controller: AbortController): Promise<ExecuteQueryResponse | undefined> {
+    async executeQuery(chatId: string, messageId: string, queryId: string, streamId: string, controller: AbortController, confirmAffectedRows = false): Promise<ExecuteQueryResponse | undefined> {
         try {
             const response = await axios.post<ExecuteQueryResponse>(
                 `${API_URL}/chats/${chatId}/queries/execute`,
                 {
                     message_id: messageId,
                     query_id: queryId,
-                    stream_id: streamId
+                    stream_id: streamId,
+                    confirm_affected_rows: confirmAffectedRows
                 },
                 {
                     signal: controller.signal,
@@ -215,11 +216,40 @@ const chatService = {
             if (error.name === 'CanceledError' || error.name === 'AbortError') {
                 return undefined;
             }
+            // An UPDATE or DELETE changing too many rows waits for the confirmation, the response carries the button
+            if (error.response?.data?.data?.error?.code === 'AFFECTED_ROWS_CONFIRMATION_REQUIRED') {
+                return error.response.data;
+            }
             console.error('Execute query error:', error);
             throw new Error(error.response?.data?.error || 'Failed to execute query');
         }
     },
 
+    async createSuggestedIndex(chatId: string, messageId: string, queryId: string, indexId: string, streamId: string): Promise<CreateSuggestedIndexResponse> {
+        try {
+            const response = await axios.post<CreateSuggestedIndexResponse>(
+                `${API_URL}/chats/${chatId}/queries/suggested-index`,
+                {
+                    message_id: messageId,
+                    query_id: queryId,
+                    index_id: indexId,
+                    stream_id: streamId
+                },
+                {
+                    withCredentials: true,
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
+                    }
+                }
+            );
+            return response.data;
+        } catch (error: any) {
+            console.error('Create suggested index error:', error);
+            throw new Error(error.response?.data?.error || 'Failed to create the index');
+        }
+    },
+
     async rollbackQuery(chatId: string, messageId: string, queryId: string, streamId: string, controller: AbortController): Promise<ExecuteQueryResponse | undefined> {
         try {
             const response = await axios.post<ExecuteQueryResponse>(`${API_URL}/chats/${chatId}/queries/rollback`, {
@@ -23,10 +23,17 @@ export interface Connection {
     ssh_passphrase?: string;
 }
 
+export interface NamedConnection {
+    name: string;
+    connection: Connection;
+    is_connected: boolean;
+}
+
 export interface Chat {
     id: string;
     user_id: string;
     connection: Connection;
+    connections?: NamedConnection[]; // Queried along the main connection, their queries carry the connection_name
     selected_collections?: string; // "ALL" or comma-separated table names
     settings: ChatSettings;
     created_at: string;
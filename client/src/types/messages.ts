@@ -1,4 +1,4 @@
-import { ActionButton, Message } from "../components/chat/types";
+import { ActionButton, Message, SuggestedIndex } from "../components/chat/types";
 
 
 // Update MessagesResponse to use BackendMessage instead of Message
@@ -38,6 +38,8 @@ export interface BackendMessage {
         example_result: any[];
         execution_result: any[];
         action_at?: string;
+        connection_name?: string;
+        suggested_indexes?: SuggestedIndex[];
         query_type: string;
         pagination?: {
             total_records_count?: number;
@@ -98,5 +100,12 @@ export interface ExecuteQueryResponse {
         };
         action_buttons?: ActionButton[];
         action_at?: string;
+        affected_rows_estimate?: number;
     };
+}
+
+// The message with the query creating the suggested index, executed
+export interface CreateSuggestedIndexResponse {
+    success: boolean;
+    data: BackendMessage;
 }
\ No newline at end of file